@@ -61,6 +61,27 @@ app: traffic-manager
 telepresence: manager
 {{- end }}
 
+{{- /*
+Leader election is enabled when explicitly requested, or when the Traffic Manager has more than one replica.
+*/}}
+{{- define "traffic-manager.leaderElection" -}}
+{{- if or (and .Values.leaderElection .Values.leaderElection.enabled) (gt (int .Values.replicaCount) 1) }}true{{- end }}
+{{- end -}}
+
+{{- /*
+A UDP service that exposes an endpoint whose connections are held in the memory of the traffic-manager
+that accepted them must only route to the leader. A standby isn't ready, so the service only routes to
+the leader when the readinessProbe uses the /readyz path. Expects a dict with the root context and the
+name of the values that declare the service.
+*/}}
+{{- define "traffic-manager.requireLeaderReadiness" -}}
+{{- if and (include "traffic-manager.leaderElection" .root) .root.Values.readinessProbe }}
+{{- if ne (dig "httpGet" "path" "" .root.Values.readinessProbe) "/readyz" }}
+{{- fail (printf "%s.service requires a readinessProbe that uses the /readyz path when leader election is enabled" .name) }}
+{{- end }}
+{{- end }}
+{{- end -}}
+
 {{- /*
 Client RBAC name suffix
 */}}
@@ -86,7 +107,10 @@ RBAC rules required to create an intercept in a namespace; excludes any rules th
   resources: ["pods/portforward"]
   verbs: ["create"]
 - apiGroups: ["apps"]
-  resources: ["deployments", "replicasets", "statefulsets"]
+  resources: ["deployments", "replicasets", "statefulsets", "daemonsets"]
+  verbs: ["get", "watch", "list"]
+- apiGroups: ["apps.openshift.io"]
+  resources: ["deploymentconfigs"]
   verbs: ["get", "watch", "list"]
 - apiGroups: [""]
   resources: ["configmaps"]
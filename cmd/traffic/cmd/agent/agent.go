@@ -8,12 +8,15 @@ import (
 	"io"
 	"net"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/pkg/sftp"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dhttp"
@@ -74,9 +77,41 @@ func AppEnvironment(ctx context.Context, ag *agentconfig.Container) (map[string]
 	if len(ag.Mounts) > 0 {
 		fullEnv[agentconfig.EnvInterceptMounts] = strings.Join(ag.Mounts, ":")
 	}
+	if se := secretEnv(osEnv, ag); len(se) > 0 {
+		fullEnv[agentconfig.EnvInterceptSecrets] = strings.Join(se, ",")
+	}
 	return fullEnv, nil
 }
 
+// secretEnv returns the names of the environment variables of the given container that are sourced from
+// Secrets. A name that ends with "*" is replaced by the names of the variables declared for the app container
+// that start with its prefix. Those variables may also come from a ConfigMap declared using envFrom with the
+// same prefix, so they are all treated as sourced from Secrets.
+func secretEnv(osEnv []string, ag *agentconfig.Container) []string {
+	prefix := agentconfig.EnvPrefixApp + ag.EnvPrefix
+	var names []string
+	for _, se := range ag.SecretEnv {
+		pfx, ok := strings.CutSuffix(se, "*")
+		if !ok {
+			if !slices.Contains(names, se) {
+				names = append(names, se)
+			}
+			continue
+		}
+		var matched []string
+		for _, env := range osEnv {
+			if k, ok := strings.CutPrefix(env, prefix); ok {
+				if k, _, ok = strings.Cut(k, "="); ok && strings.HasPrefix(k, pfx) && !slices.Contains(names, k) {
+					matched = append(matched, k)
+				}
+			}
+		}
+		slices.Sort(matched)
+		names = append(names, matched...)
+	}
+	return names
+}
+
 // sftpServer creates a listener on the next available port, writes that port on the
 // given channel, and then starts accepting connections on that port. Each connection
 // starts a sftp-server that communicates with that connection using its stdin and stdout.
@@ -135,6 +170,23 @@ func Main(ctx context.Context, _ ...string) error {
 		return err
 	}
 
+	// The tunnels that the agent creates to the traffic-manager offer the configured compression.
+	if tc := config.AgentConfig().TunnelCompression; tc != "" {
+		c, err := tunnel.ParseCompression(tc)
+		if err != nil {
+			return err
+		}
+		ctx = tunnel.WithCompression(ctx, c)
+	}
+
+	// The agent isn't ready until it has established a session with the traffic-manager.
+	hs := health.NewServer()
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	ctx = withHealthServer(ctx, hs)
+	if config.AgentConfig().MetricsPort != 0 {
+		ctx = withMetrics(ctx, newMetrics())
+	}
+
 	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{
 		EnableSignalHandling: true,
 	})
@@ -157,6 +209,7 @@ func Main(ctx context.Context, _ ...string) error {
 func sidecar(ctx context.Context, s SimpleState, info *rpc.AgentInfo) error {
 	// Manage the forwarders
 	ac := s.AgentConfig()
+	tlsConfig := terminatingTLSConfig(ctx, agentconfig.TerminatingTLSMountPoint)
 	for _, cn := range ac.Containers {
 		env, err := AppEnvironment(ctx, cn)
 		if err != nil {
@@ -176,6 +229,9 @@ func sidecar(ctx context.Context, s SimpleState, info *rpc.AgentInfo) error {
 				return err
 			}
 			fwd := forwarder.NewInterceptor(lisAddr, "127.0.0.1", cp)
+			if tt, ok := fwd.(forwarder.TLSTerminator); ok && tlsConfig != nil {
+				tt.SetTerminatingTLS(tlsConfig)
+			}
 			dgroup.ParentGroup(ctx).Go(fmt.Sprintf("forward-%s", iputil.JoinHostPort(cn.Name, cp)), func(ctx context.Context) error {
 				return fwd.Serve(tunnel.WithPool(ctx, tunnel.NewPool()), nil)
 			})
@@ -288,6 +344,18 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 	}
 	srv.SetFileSharingPorts(ftpPort, sftpPort)
 
+	if hs := healthServer(ctx); hs != nil && ac.HealthPort != 0 {
+		g.Go("health-server", func(ctx context.Context) error {
+			return serveHealth(ctx, ac.HealthPort, hs)
+		})
+	}
+
+	if m := getMetrics(ctx); m != nil && ac.MetricsPort != 0 {
+		g.Go("metrics-server", func(ctx context.Context) error {
+			return serveMetrics(ctx, ac.MetricsPort, m)
+		})
+	}
+
 	if ac.APIPort != 0 {
 		g.Go("API-server", func(ctx context.Context) error {
 			return restapi.NewServer(srv.AgentState()).ListenAndServe(ctx, int(ac.APIPort))
@@ -299,6 +367,8 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 		Namespace: config.AgentConfig().Namespace,
 		PodName:   config.PodName(),
 		PodIp:     config.PodIP(),
+		PodUid:    config.PodUID(),
+		NodeName:  config.NodeName(),
 		ApiPort:   int32(grpcPort),
 		Product:   "telepresence",
 		Version:   version.Version,
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	core "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
@@ -22,6 +23,8 @@ const (
 	serviceName = "test-echo"
 	namespace   = "teltest"
 	podIP       = "192.168.50.34"
+	podUID      = "7e0c3f12-5a4b-4d3c-9a41-6f0d5b1c2e7a"
+	nodeName    = "worker-1"
 )
 
 var testConfig = agentconfig.Sidecar{
@@ -40,6 +43,7 @@ var testConfig = agentconfig.Sidecar{
 		EnvPrefix:  "A_",
 		MountPoint: "/tel_app_mounts/test-echo",
 		Mounts:     []string{"/home/bob"},
+		SecretEnv:  []string{"ALPHA"},
 		Intercepts: []*agentconfig.Intercept{
 			{
 				ContainerPortName: "http",
@@ -68,6 +72,8 @@ func testContext(t *testing.T, env dos.MapEnv) context.Context {
 	require.NoError(t, afero.WriteFile(fs, filepath.Join(agentconfig.ConfigMountPoint, agentconfig.ConfigFile), y, 0o600))
 
 	env[agentconfig.EnvPrefixAgent+"POD_IP"] = podIP
+	env[agentconfig.EnvPrefixAgent+"POD_UID"] = podUID
+	env[agentconfig.EnvPrefixAgent+"NODE_NAME"] = nodeName
 
 	ctx := dlog.NewTestContext(t, false)
 	ctx = dos.WithFS(ctx, aferofs.Wrap(fs))
@@ -80,6 +86,8 @@ func Test_LoadConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, &testConfig, config.AgentConfig())
 	require.Equal(t, podIP, config.PodIP())
+	require.Equal(t, podUID, config.PodUID())
+	require.Equal(t, nodeName, config.NodeName())
 }
 
 func Test_AppEnvironment(t *testing.T) {
@@ -105,5 +113,38 @@ func Test_AppEnvironment(t *testing.T) {
 		"ZULU":                            "zulu",
 		agentconfig.EnvInterceptContainer: "test-echo",
 		agentconfig.EnvInterceptMounts:    "/home/bob",
+		agentconfig.EnvInterceptSecrets:   "ALPHA",
 	}, env)
 }
+
+func Test_AppEnvironment_secretEnvFrom(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipped on windows")
+	}
+	// The variables of a Secret declared using envFrom with the prefix "DB_", and a ConfigMap declared without
+	// a prefix.
+	ctx := testContext(t, dos.MapEnv{
+		agentconfig.EnvPrefixApp + "A_" + "ALPHA":       "alpha",
+		agentconfig.EnvPrefixApp + "A_" + "DB_PASSWORD": "secret",
+		agentconfig.EnvPrefixApp + "A_" + "DB_USER":     "user",
+		agentconfig.EnvPrefixApp + "A_" + "LOG_LEVEL":   "debug",
+		"DB_HOST": "db.example.com",
+	})
+
+	config, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+
+	cn := config.AgentConfig().Containers[0]
+	cn.SecretEnv = []string{"ALPHA", "DB_*"}
+	env, err := agent.AppEnvironment(ctx, cn)
+	require.NoError(t, err)
+	assert.Equal(t, "ALPHA,DB_PASSWORD,DB_USER", env[agentconfig.EnvInterceptSecrets],
+		"only the variables declared for the app container are matched")
+	assert.Equal(t, "debug", env["LOG_LEVEL"])
+
+	// A name that ends with "*" matches nothing when the app container lacks such variables.
+	ctx = testContext(t, dos.MapEnv{agentconfig.EnvPrefixApp + "A_" + "LOG_LEVEL": "debug"})
+	env, err = agent.AppEnvironment(ctx, cn)
+	require.NoError(t, err)
+	assert.Equal(t, "ALPHA", env[agentconfig.EnvInterceptSecrets])
+}
@@ -50,9 +50,14 @@ func TalkToManager(ctx context.Context, address string, info *rpc.AgentInfo, sta
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	conn, err := grpc.NewClient(address,
+	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+	if state.AgentConfig().ServiceAccountToken != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(newTokenCredentials()))
+	}
+	conn, err := grpc.NewClient(address, opts...)
 	if err != nil {
 		return err
 	}
@@ -128,7 +133,7 @@ func TalkToManager(ctx context.Context, address string, info *rpc.AgentInfo, sta
 		return err
 	}
 	wg.Go("dialWait", func(ctx context.Context) error {
-		return tunnel.DialWaitLoop(ctx, tunnel.ManagerProvider(manager), dialerStream, session.SessionId)
+		return tunnel.DialWaitLoop(ctx, tunnel.ManagerMuxProvider(ctx, manager), dialerStream, session.SessionId)
 	})
 
 	// Deal with log-level changes
@@ -162,6 +167,7 @@ func TalkToManager(ctx context.Context, address string, info *rpc.AgentInfo, sta
 		return err
 	}
 	_ = file.Close()
+	setReady(ctx, true)
 	return wg.Wait()
 }
 
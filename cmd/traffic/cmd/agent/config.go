@@ -24,12 +24,16 @@ type Config interface {
 	HasMounts(ctx context.Context) bool
 	PodName() string
 	PodIP() string
+	PodUID() string
+	NodeName() string
 }
 
 type config struct {
 	sidecarExt agentconfig.SidecarExt
 	podName    string
 	podIP      string
+	podUID     string
+	nodeName   string
 }
 
 func LoadConfig(ctx context.Context) (Config, error) {
@@ -48,11 +52,21 @@ func LoadConfig(ctx context.Context) (Config, error) {
 		// Override default from environment
 		log.SetLevel(ctx, sc.LogLevel)
 	}
+	if sc.LogFormat != "" {
+		// Override default from environment
+		log.SetFormat(sc.LogFormat)
+	}
 	if sc.ManagerPort == 0 {
 		sc.ManagerPort = 8081
 	}
 	c.podName = dos.Getenv(ctx, "_TEL_AGENT_NAME")
 	c.podIP = dos.Getenv(ctx, "_TEL_AGENT_POD_IP")
+	c.podUID = dos.Getenv(ctx, "_TEL_AGENT_POD_UID")
+	c.nodeName = dos.Getenv(ctx, "_TEL_AGENT_NODE_NAME")
+	if sc.Namespace == "" {
+		// Older, manually created, configs might lack the namespace.
+		sc.Namespace = dos.Getenv(ctx, "_TEL_AGENT_NAMESPACE")
+	}
 	for _, cn := range sc.Containers {
 		if err := addAppMounts(ctx, cn); err != nil {
 			return nil, err
@@ -86,6 +100,14 @@ func (c *config) PodIP() string {
 	return c.podIP
 }
 
+func (c *config) PodUID() string {
+	return c.podUID
+}
+
+func (c *config) NodeName() string {
+	return c.nodeName
+}
+
 func OtelResources(ctx context.Context, c Config) []attribute.KeyValue {
 	return []attribute.KeyValue{
 		attribute.Bool("tel2.has-mounts", c.HasMounts(ctx)),
@@ -95,6 +117,8 @@ func OtelResources(ctx context.Context, c Config) []attribute.KeyValue {
 		attribute.Bool("tel2.manual", c.AgentConfig().Manual),
 		attribute.String("k8s.namespace", c.AgentConfig().Namespace),
 		attribute.String("k8s.pod-ip", c.PodIP()),
+		attribute.String("k8s.pod-uid", c.PodUID()),
+		attribute.String("k8s.node-name", c.NodeName()),
 	}
 }
 
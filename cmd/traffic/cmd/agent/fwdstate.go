@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/datawire/dlib/dlog"
@@ -38,14 +39,15 @@ func (fs *fwdState) Target() InterceptTarget {
 }
 
 func (fs *fwdState) InterceptInfo(ctx context.Context, callerID, path string, containerPort uint16, headers http.Header) (*restapi.InterceptInfo, error) {
-	// The OSS agent is either intercepting or it isn't. There's no way to tell what it is that's being intercepted.
+	// The OSS agent is either intercepting or it isn't. There's no way to tell what it is that's being intercepted,
+	// other than by the header and gRPC conditions of the intercept.
 	fw := fs.forwarder
 	if containerPort == 0 {
-		return fw.InterceptInfo(), nil
+		return fw.InterceptInfo(path, headers), nil
 	}
 	_, port := fw.Target()
 	if containerPort == port {
-		return fw.InterceptInfo(), nil
+		return fw.InterceptInfo(path, headers), nil
 	}
 	portInfo := ""
 	if containerPort != 0 {
@@ -58,9 +60,21 @@ func (fs *fwdState) InterceptInfo(ctx context.Context, callerID, path string, co
 type ProviderMux struct {
 	AgentProvider   tunnel.ClientStreamProvider
 	ManagerProvider tunnel.StreamProvider
+
+	// interceptor, when set, provides the id of the intercept that the streams are created for. It is
+	// used when recording metrics.
+	interceptor interface{ InterceptId() string }
+}
+
+func (pm *ProviderMux) interceptID() string {
+	if pm.interceptor == nil {
+		return ""
+	}
+	return pm.interceptor.InterceptId()
 }
 
 func (pm *ProviderMux) ReportMetrics(ctx context.Context, metrics *manager.TunnelMetrics) {
+	getMetrics(ctx).interceptEnded(pm.interceptID(), metrics.IngressBytes, metrics.EgressBytes)
 	pm.AgentProvider.ReportMetrics(ctx, metrics)
 }
 
@@ -69,10 +83,61 @@ func (pm *ProviderMux) CreateClientStream(ctx context.Context, sessionID string,
 	if err == nil && s == nil {
 		s, err = pm.ManagerProvider.CreateClientStream(ctx, sessionID, id, roundTripLatency, dialTimeout)
 	}
+	if err == nil {
+		getMetrics(ctx).interceptStarted(pm.interceptID(), id)
+	}
 	return s, err
 }
 
+// ingestDesc is the MechanismArgsDesc of an ingest.
+const ingestDesc = "no traffic (ingest)"
+
 func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
+	var reviews []*manager.ReviewInterceptRequest
+	cepts = slices.DeleteFunc(slices.Clone(cepts), func(cept *manager.InterceptInfo) bool {
+		switch {
+		case cept.Spec.Ingest:
+			// An ingest doesn't route any traffic, so it never conflicts with other intercepts, and it
+			// never becomes the intercept that the forwarder serves.
+			if cept.Disposition == manager.InterceptDispositionType_WAITING {
+				dlog.Infof(ctx, "Setting ingest %q as ACTIVE", cept.Id)
+				reviews = append(reviews, &manager.ReviewInterceptRequest{
+					Id:                cept.Id,
+					Disposition:       manager.InterceptDispositionType_ACTIVE,
+					PodIp:             fs.PodIP(),
+					FtpPort:           int32(fs.FtpPort()),
+					SftpPort:          int32(fs.SftpPort()),
+					MountPoint:        fs.mountPoint,
+					MechanismArgsDesc: ingestDesc,
+					Environment:       fs.env,
+				})
+			}
+		case cept.Spec.TerminateTls && !fs.terminatesTLS():
+			if cept.Disposition == manager.InterceptDispositionType_WAITING {
+				dlog.Infof(ctx, "Setting intercept %q as AGENT_ERROR; the pod has no terminating TLS secret", cept.Id)
+				reviews = append(reviews, &manager.ReviewInterceptRequest{
+					Id:                cept.Id,
+					Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
+					Message:           "Cannot terminate TLS. The pod has no terminating TLS secret",
+					MechanismArgsDesc: "all TCP connections",
+				})
+			}
+		default:
+			return false
+		}
+		return true
+	})
+	return append(fs.handleIntercepts(ctx, cepts), reviews...)
+}
+
+// terminatesTLS returns true if the forwarder can terminate the TLS of the connections that it
+// routes to the intercepting client.
+func (fs *fwdState) terminatesTLS() bool {
+	tt, ok := fs.forwarder.(forwarder.TLSTerminator)
+	return ok && tt.TerminatesTLS()
+}
+
+func (fs *fwdState) handleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
 	var myChoice, activeIntercept *manager.InterceptInfo
 
 	// Find the chosen intercept if it still exists
@@ -106,6 +171,7 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 			&ProviderMux{
 				AgentProvider:   fs,
 				ManagerProvider: &tunnel.TrafficManagerStreamProvider{Manager: fs.ManagerClient(), AgentSessionID: fs.sessionInfo.SessionId},
+				interceptor:     fs.forwarder,
 			})
 	}
 	fs.forwarder.SetIntercepting(activeIntercept)
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+type healthKey struct{}
+
+// withHealthServer returns a context that carries the given health server, so that the
+// functions that establish the agent's readiness can update its serving status.
+func withHealthServer(ctx context.Context, hs *health.Server) context.Context {
+	return context.WithValue(ctx, healthKey{}, hs)
+}
+
+func healthServer(ctx context.Context) *health.Server {
+	hs, _ := ctx.Value(healthKey{}).(*health.Server)
+	return hs
+}
+
+// setReady updates the serving status of the health server found in the given context. It
+// is a no-op if no such server exists.
+func setReady(ctx context.Context, ready bool) {
+	if hs := healthServer(ctx); hs != nil {
+		st := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		if ready {
+			st = grpc_health_v1.HealthCheckResponse_SERVING
+		}
+		hs.SetServingStatus("", st)
+	}
+}
+
+// serveHealth serves the standard gRPC health service on the given port. Plain HTTP GET requests
+// to agentconfig.HealthPath on the same port are answered with status 200 when the agent is
+// ready and 503 otherwise, which makes it possible to use an httpGet probe in clusters that
+// lack support for gRPC probes.
+func serveHealth(ctx context.Context, port uint16, hs *health.Server) error {
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	grpcHandler := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcHandler, hs)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcHandler.ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path != agentconfig.HealthPath {
+			http.NotFound(w, r)
+			return
+		}
+		rsp, err := hs.Check(r.Context(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil || rsp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	dlog.Infof(ctx, "Health server started on port %d", port)
+	sc := &dhttp.ServerConfig{Handler: handler}
+	if err = sc.Serve(ctx, l); err != nil && ctx.Err() != nil {
+		err = nil // Normal shutdown
+	}
+	return err
+}
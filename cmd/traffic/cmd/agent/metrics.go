@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// metrics are the Prometheus metrics of the traffic-agent. They are registered with a registry of
+// their own, so that the metrics server only serves the metrics of the agent.
+type metrics struct {
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	ingressBytes  *prometheus.CounterVec
+	egressBytes   *prometheus.CounterVec
+	activeTunnels prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	labels := []string{"intercept_id"}
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "intercept_request_count",
+			Help: "Number of connections that have been routed to an intercepting client",
+		}, labels),
+		ingressBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "intercept_ingress_bytes",
+			Help: "Number of bytes received from an intercepting client",
+		}, labels),
+		egressBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "intercept_egress_bytes",
+			Help: "Number of bytes forwarded to an intercepting client",
+		}, labels),
+		activeTunnels: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "active_tunnel_count",
+			Help: "Number of currently active tunnel connections",
+		}),
+	}
+	m.registry.MustRegister(m.requests, m.ingressBytes, m.egressBytes, m.activeTunnels)
+	return m
+}
+
+type metricsKey struct{}
+
+// withMetrics returns a context that carries the given metrics.
+func withMetrics(ctx context.Context, m *metrics) context.Context {
+	return context.WithValue(ctx, metricsKey{}, m)
+}
+
+// getMetrics returns the metrics found in the given context, or nil if metrics aren't enabled. All
+// methods of metrics are no-ops when called on nil.
+func getMetrics(ctx context.Context) *metrics {
+	m, _ := ctx.Value(metricsKey{}).(*metrics)
+	return m
+}
+
+// interceptStarted records the start of a connection that is routed to an intercepting client.
+// Only TCP connections count as active tunnels, because the end of a UDP tunnel is never reported.
+func (m *metrics) interceptStarted(interceptID string, id tunnel.ConnID) {
+	if m != nil {
+		m.requests.WithLabelValues(interceptID).Inc()
+		if id.Protocol() == ipproto.TCP {
+			m.activeTunnels.Inc()
+		}
+	}
+}
+
+// interceptEnded records the end of a TCP connection that was routed to an intercepting client.
+func (m *metrics) interceptEnded(interceptID string, ingressBytes, egressBytes uint64) {
+	if m != nil {
+		m.activeTunnels.Dec()
+		m.ingressBytes.WithLabelValues(interceptID).Add(float64(ingressBytes))
+		m.egressBytes.WithLabelValues(interceptID).Add(float64(egressBytes))
+	}
+}
+
+// tunnelStarted records the start of a tunnel that is dialed by a client through the agent.
+func (m *metrics) tunnelStarted() {
+	if m != nil {
+		m.activeTunnels.Inc()
+	}
+}
+
+// tunnelEnded records the end of a tunnel that was dialed by a client through the agent.
+func (m *metrics) tunnelEnded() {
+	if m != nil {
+		m.activeTunnels.Dec()
+	}
+}
+
+// serveMetrics serves the given metrics in the Prometheus text format on the given port.
+func serveMetrics(ctx context.Context, port uint16, m *metrics) error {
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	dlog.Infof(ctx, "Metrics server started on port %d", port)
+	sc := &dhttp.ServerConfig{Handler: promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})}
+	if err = sc.Serve(ctx, l); err != nil && ctx.Err() != nil {
+		err = nil // Normal shutdown
+	}
+	return err
+}
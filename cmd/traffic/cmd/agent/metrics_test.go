@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+type fakeInterceptor string
+
+func (f fakeInterceptor) InterceptId() string {
+	return string(f)
+}
+
+type fakeStreamProvider struct{}
+
+func (fakeStreamProvider) CreateClientStream(context.Context, string, tunnel.ConnID, time.Duration, time.Duration) (tunnel.Stream, error) {
+	return nil, nil
+}
+
+func (fakeStreamProvider) ReportMetrics(context.Context, *manager.TunnelMetrics) {}
+
+func TestMetrics(t *testing.T) {
+	m := newMetrics()
+	ctx := withMetrics(context.Background(), m)
+	pm := &ProviderMux{
+		AgentProvider:   fakeStreamProvider{},
+		ManagerProvider: fakeStreamProvider{},
+		interceptor:     fakeInterceptor("abc:echo"),
+	}
+	src, dst := net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2}
+	_, err := pm.CreateClientStream(ctx, "abc", tunnel.NewConnID(ipproto.TCP, src, dst, 4711, 8080), 0, 0)
+	require.NoError(t, err)
+	_, err = pm.CreateClientStream(ctx, "abc", tunnel.NewConnID(ipproto.UDP, src, dst, 4711, 53), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("abc:echo")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.activeTunnels), "UDP streams are not counted as active tunnels")
+
+	pm.ReportMetrics(ctx, &manager.TunnelMetrics{ClientSessionId: "abc", IngressBytes: 100, EgressBytes: 2000})
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.activeTunnels))
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.ingressBytes.WithLabelValues("abc:echo")))
+	assert.Equal(t, 2000.0, testutil.ToFloat64(m.egressBytes.WithLabelValues("abc:echo")))
+
+	srv := httptest.NewServer(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	defer srv.Close()
+	rsp, err := srv.Client().Get(srv.URL)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `intercept_request_count{intercept_id="abc:echo"} 2`)
+}
+
+func TestMetrics_disabled(t *testing.T) {
+	// All methods are no-ops when metrics aren't enabled.
+	m := getMetrics(context.Background())
+	require.Nil(t, m)
+	m.interceptStarted("abc:echo", tunnel.ConnID(""))
+	m.interceptEnded("abc:echo", 1, 1)
+	m.tunnelStarted()
+	m.tunnelEnded()
+}
@@ -39,6 +39,10 @@ func (s *state) Tunnel(server agent.Agent_TunnelServer) error {
 		}
 	}
 
+	m := getMetrics(ctx)
+	m.tunnelStarted()
+	defer m.tunnelEnded()
+
 	ingressBytes := tunnel.NewCounterProbe("FromClientBytes")
 	egressBytes := tunnel.NewCounterProbe("ToClientBytes")
 	endPoint := tunnel.NewDialer(stream, func() {}, ingressBytes, egressBytes)
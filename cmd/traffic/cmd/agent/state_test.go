@@ -2,6 +2,7 @@ package agent_test
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"path/filepath"
 	"testing"
@@ -149,3 +150,88 @@ func TestState_HandleIntercepts(t *testing.T) {
 	a.Len(reviews, 0)
 	a.Equal("", f.InterceptId())
 }
+
+func TestState_HandleIngests(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+
+	newCept := func(id, client string, ingest bool) *rpc.InterceptInfo {
+		return &rpc.InterceptInfo{
+			Spec: &rpc.InterceptSpec{
+				Name:                  id + "Name",
+				Client:                client,
+				Agent:                 "agentName",
+				Mechanism:             "tcp",
+				Namespace:             namespace,
+				ServiceName:           serviceName,
+				ServicePortIdentifier: "http",
+				TargetPort:            8080,
+				Ingest:                ingest,
+			},
+			Id:          id,
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		}
+	}
+
+	// Ingests are accepted even when they are reviewed together with an intercept
+
+	cepts := []*rpc.InterceptInfo{
+		newCept("ingest-01", "user@host1", true),
+		newCept("intercept-01", "user@host2", false),
+		newCept("ingest-02", "user@host3", true),
+	}
+	reviews := s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 3)
+	for _, r := range reviews {
+		a.Equal(rpc.InterceptDispositionType_ACTIVE, r.Disposition, r.Id)
+	}
+	a.Equal("intercept-01", reviews[0].Id)
+	a.Equal("no traffic (ingest)", reviews[1].MechanismArgsDesc)
+
+	// An active ingest is never served by the forwarder
+
+	cepts = []*rpc.InterceptInfo{newCept("ingest-01", "user@host1", true)}
+	cepts[0].Disposition = rpc.InterceptDispositionType_ACTIVE
+	reviews = s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 0)
+	a.Equal("", f.InterceptId())
+}
+
+func TestState_HandleTerminateTLS(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+
+	cepts := []*rpc.InterceptInfo{{
+		Spec: &rpc.InterceptSpec{
+			Name:                  "cept1Name",
+			Client:                "user@host1",
+			Agent:                 "agentName",
+			Mechanism:             "tcp",
+			Namespace:             namespace,
+			ServiceName:           serviceName,
+			ServicePortIdentifier: "http",
+			TargetPort:            8080,
+			TerminateTls:          true,
+		},
+		Id:          "intercept-01",
+		Disposition: rpc.InterceptDispositionType_WAITING,
+	}}
+
+	// Rejected when there's no terminating TLS secret
+
+	reviews := s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+	a.Equal("Cannot terminate TLS. The pod has no terminating TLS secret", reviews[0].Message)
+
+	// Accepted when there is one
+
+	tt, ok := f.(forwarder.TLSTerminator)
+	a.True(ok)
+	tt.SetTerminatingTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	reviews = s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// terminatingTLSConfig returns the config that the forwarders use when terminating the TLS of
+// intercepted connections. The certificate is read from the terminating TLS secret mounted in the
+// given directory. The config is nil when no such secret is mounted, or when it can't be loaded.
+func terminatingTLSConfig(ctx context.Context, dir string) *tls.Config {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, core.TLSCertKey), filepath.Join(dir, core.TLSPrivateKeyKey))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			dlog.Errorf(ctx, "unable to load the terminating TLS secret: %v", err)
+		}
+		return nil
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
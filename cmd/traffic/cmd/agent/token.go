@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// tokenCredentials is a credentials.PerRPCCredentials that sends the projected service-account token
+// of the traffic-agent as a bearer token. The token is read on each call, because the kubelet rotates
+// it before it expires.
+type tokenCredentials string
+
+func newTokenCredentials() tokenCredentials {
+	return tokenCredentials(filepath.Join(agentconfig.TokenMountPoint, agentconfig.TokenFile))
+}
+
+func (tc tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	data, err := os.ReadFile(string(tc))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + strings.TrimSpace(string(data))}, nil
+}
+
+func (tc tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
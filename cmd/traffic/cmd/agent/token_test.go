@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCredentials(t *testing.T) {
+	tf := filepath.Join(t.TempDir(), "token")
+	tc := tokenCredentials(tf)
+	_, err := tc.GetRequestMetadata(context.Background())
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(tf, []byte("abc\n"), 0o600))
+	md, err := tc.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"authorization": "Bearer abc"}, md)
+
+	// The token is re-read when rotated.
+	require.NoError(t, os.WriteFile(tf, []byte("def"), 0o600))
+	md, err = tc.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer def", md["authorization"])
+	assert.False(t, tc.RequireTransportSecurity())
+}
@@ -181,20 +181,44 @@ func Main(ctx context.Context, args ...string) error {
 		dlog.Error(ctx, err)
 		return err
 	}
-	proto := iptables.ProtocolIPv4
-	localhostCIDR := "127.0.0.1/32"
-	if len(iputil.Parse(os.Getenv("POD_IP"))) == 16 {
-		proto = iptables.ProtocolIPv6
-		localhostCIDR = "::1/128"
+	for _, proto := range iptablesProtocols(os.Getenv("POD_IP"), os.Getenv("POD_IPS")) {
+		localhostCIDR := "127.0.0.1/32"
+		if proto == iptables.ProtocolIPv6 {
+			localhostCIDR = "::1/128"
+		}
+		it, err := iptables.NewWithProtocol(proto)
+		if err != nil {
+			err = fmt.Errorf("unable to create iptables instance: %w", err)
+			dlog.Error(ctx, err)
+			return err
+		}
+		if err = cfg.configureIptables(ctx, it, lo, localhostCIDR); err != nil {
+			dlog.Error(ctx, err)
+			return err
+		}
 	}
-	it, err := iptables.NewWithProtocol(proto)
-	if err != nil {
-		err = fmt.Errorf("unable to create iptables instance: %w", err)
-		dlog.Error(ctx, err)
-		return err
+	return nil
+}
+
+// iptablesProtocols returns the iptables protocols that must be configured for a pod with the given
+// primary IP and comma separated list of IPs. A dual-stack pod has one IP of each family, and needs
+// both protocols. IPv4 is used when no IP can be parsed.
+func iptablesProtocols(podIP, podIPs string) []iptables.Protocol {
+	var v4, v6 bool
+	for _, s := range append(strings.Split(podIPs, ","), podIP) {
+		switch len(iputil.Parse(strings.TrimSpace(s))) {
+		case 4:
+			v4 = true
+		case 16:
+			v6 = true
+		}
 	}
-	if err = cfg.configureIptables(ctx, it, lo, localhostCIDR); err != nil {
-		dlog.Error(ctx, err)
+	var protos []iptables.Protocol
+	if v4 || !v6 {
+		protos = append(protos, iptables.ProtocolIPv4)
+	}
+	if v6 {
+		protos = append(protos, iptables.ProtocolIPv6)
 	}
-	return err
+	return protos
 }
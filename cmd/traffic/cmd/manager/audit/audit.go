@@ -0,0 +1,149 @@
+// Package audit records who connected to the traffic-manager, which workloads they intercepted, and when.
+// The events are written to the sinks that are configured using the AUDIT_SINKS environment variable.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// EventType is the type of audit event.
+type EventType string
+
+const (
+	ClientConnected    EventType = "client-connected"
+	ClientDisconnected EventType = "client-disconnected"
+	InterceptCreated   EventType = "intercept-created"
+	InterceptRemoved   EventType = "intercept-removed"
+)
+
+// Event is an audit event.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Type      EventType `json:"type"`
+	Client    string    `json:"client"` // user@hostname
+	InstallID string    `json:"installId,omitempty"`
+	SessionID string    `json:"sessionId"`
+	Namespace string    `json:"namespace,omitempty"`
+	Workload  string    `json:"workload,omitempty"`
+	Intercept string    `json:"intercept,omitempty"`
+}
+
+// ClientEvent returns an event of the given type for the client session with the given ID.
+func ClientEvent(tp EventType, sessionID string, client *rpc.ClientInfo) *Event {
+	return &Event{
+		Time:      time.Now(),
+		Type:      tp,
+		Client:    client.GetName(),
+		InstallID: client.GetInstallId(),
+		SessionID: sessionID,
+		Namespace: client.GetNamespace(),
+	}
+}
+
+// InterceptEvent returns an event of the given type for the given intercept of the given client.
+func InterceptEvent(tp EventType, ii *rpc.InterceptInfo, client *rpc.ClientInfo) *Event {
+	spec := ii.GetSpec()
+	ev := ClientEvent(tp, ii.GetClientSession().GetSessionId(), client)
+	ev.Namespace = spec.GetNamespace()
+	ev.Workload = spec.GetAgent()
+	ev.Intercept = spec.GetName()
+	return ev
+}
+
+func (e *Event) String() string {
+	s := fmt.Sprintf("%s %s", e.Type, e.Client)
+	if e.Intercept != "" {
+		s += fmt.Sprintf(" intercept %s of %s.%s", e.Intercept, e.Workload, e.Namespace)
+	}
+	return s
+}
+
+// A Sink writes audit events to some destination.
+type Sink interface {
+	Write(context.Context, *Event) error
+}
+
+// bufferSize is the number of events that can be queued before events are dropped.
+const bufferSize = 256
+
+// Logger dispatches audit events to its sinks. Events are queued, so that the code that records them
+// never waits for a slow sink.
+type Logger struct {
+	sinks  []Sink
+	events chan *Event
+}
+
+// NewLogger returns a Logger that writes to the given sinks.
+func NewLogger(sinks ...Sink) *Logger {
+	return &Logger{sinks: sinks, events: make(chan *Event, bufferSize)}
+}
+
+// LoadLogger returns a Logger that writes to the sinks that are configured in the environment, or nil
+// if no sinks are configured.
+func LoadLogger(ctx context.Context) (*Logger, error) {
+	env := managerutil.GetEnv(ctx)
+	if len(env.AuditSinks) == 0 {
+		return nil, nil
+	}
+	sinks := make([]Sink, len(env.AuditSinks))
+	for i, name := range env.AuditSinks {
+		var err error
+		if sinks[i], err = newSink(name, env); err != nil {
+			return nil, err
+		}
+	}
+	return NewLogger(sinks...), nil
+}
+
+// Record queues the given event. The event is dropped if the queue is full. Calls on a nil Logger are
+// no-ops.
+func (l *Logger) Record(ctx context.Context, ev *Event) {
+	if l == nil {
+		return
+	}
+	select {
+	case l.events <- ev:
+	default:
+		dlog.Errorf(ctx, "audit event queue is full, dropping event %s", ev)
+	}
+}
+
+// Run writes the queued events to the sinks until the given context is cancelled.
+func (l *Logger) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-l.events:
+			for _, s := range l.sinks {
+				if err := s.Write(ctx, ev); err != nil {
+					dlog.Errorf(ctx, "unable to write audit event %s: %v", ev, err)
+				}
+			}
+		}
+	}
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context that carries the given Logger.
+func WithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// GetLogger returns the Logger found in the given context, or nil if auditing isn't enabled.
+func GetLogger(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerKey{}).(*Logger)
+	return l
+}
+
+// Record queues the given event with the Logger found in the given context, if any.
+func Record(ctx context.Context, ev *Event) {
+	GetLogger(ctx).Record(ctx, ev)
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+type chanSink chan *Event
+
+func (s chanSink) Write(_ context.Context, ev *Event) error {
+	s <- ev
+	return nil
+}
+
+func testEvent() *Event {
+	client := &rpc.ClientInfo{Name: "alice@host", InstallId: "install-1", Namespace: "default"}
+	return InterceptEvent(InterceptCreated, &rpc.InterceptInfo{
+		Spec:          &rpc.InterceptSpec{Name: "echo", Agent: "echo", Namespace: "default"},
+		ClientSession: &rpc.SessionInfo{SessionId: "session-1"},
+	}, client)
+}
+
+func TestLogger(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	// A nil Logger is a no-op.
+	Record(ctx, testEvent())
+
+	s1 := make(chanSink, 1)
+	s2 := make(chanSink, 1)
+	l := NewLogger(s1, s2)
+	ctx = WithLogger(ctx, l)
+	go func() {
+		_ = l.Run(ctx)
+	}()
+
+	ev := testEvent()
+	Record(ctx, ev)
+	for _, s := range []chanSink{s1, s2} {
+		select {
+		case got := <-s:
+			assert.Equal(t, ev, got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
+
+func TestLoadLogger(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	l, err := LoadLogger(managerutil.WithEnv(ctx, &managerutil.Env{}))
+	require.NoError(t, err)
+	assert.Nil(t, l)
+
+	_, err = LoadLogger(managerutil.WithEnv(ctx, &managerutil.Env{AuditSinks: []string{"syslog"}}))
+	assert.ErrorContains(t, err, `unknown audit sink "syslog"`)
+
+	_, err = LoadLogger(managerutil.WithEnv(ctx, &managerutil.Env{AuditSinks: []string{"webhook"}}))
+	assert.ErrorContains(t, err, "AUDIT_WEBHOOK_URL")
+
+	l, err = LoadLogger(managerutil.WithEnv(ctx, &managerutil.Env{AuditSinks: []string{"stdout", "events"}}))
+	require.NoError(t, err)
+	assert.Len(t, l.sinks, 2)
+}
+
+func TestJSONSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ev := testEvent()
+	require.NoError(t, NewJSONSink(buf).Write(context.Background(), ev))
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "intercept-created", m["type"])
+	assert.Equal(t, "alice@host", m["client"])
+	assert.Equal(t, "session-1", m["sessionId"])
+	assert.Equal(t, "echo", m["workload"])
+	assert.Equal(t, "default", m["namespace"])
+}
+
+func TestEventsSink(t *testing.T) {
+	ki := fake.NewSimpleClientset()
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), ki)
+	require.NoError(t, NewEventsSink("ambassador").Write(ctx, testEvent()))
+
+	evs, err := ki.CoreV1().Events("ambassador").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, evs.Items, 1)
+	ke := evs.Items[0]
+	assert.Equal(t, "intercept-created", ke.Reason)
+	assert.Equal(t, "intercept-created alice@host intercept echo of echo.default", ke.Message)
+	assert.Equal(t, "traffic-manager", ke.InvolvedObject.Name)
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan *Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&ev) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- &ev
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	ev := testEvent()
+	require.NoError(t, NewWebhookSink(srv.URL).Write(ctx, ev))
+	got := <-received
+	assert.Equal(t, ev.Intercept, got.Intercept)
+	assert.True(t, ev.Time.Equal(got.Time))
+
+	assert.Error(t, NewWebhookSink(srv.URL+"/%zz").Write(ctx, ev))
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+// The names of the sinks that can be used in the AUDIT_SINKS environment variable.
+const (
+	StdoutSinkName  = "stdout"
+	EventsSinkName  = "events"
+	WebhookSinkName = "webhook"
+)
+
+func newSink(name string, env *managerutil.Env) (Sink, error) {
+	switch name {
+	case StdoutSinkName:
+		return NewJSONSink(os.Stdout), nil
+	case EventsSinkName:
+		return NewEventsSink(env.ManagerNamespace), nil
+	case WebhookSinkName:
+		if env.AuditWebhookURL == "" {
+			return nil, fmt.Errorf("the %s audit sink requires AUDIT_WEBHOOK_URL", WebhookSinkName)
+		}
+		return NewWebhookSink(env.AuditWebhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q", name)
+	}
+}
+
+type jsonSink struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a Sink that writes one JSON object per event to the given writer.
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonSink) Write(_ context.Context, ev *Event) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.enc.Encode(ev)
+}
+
+type eventsSink struct {
+	namespace string
+	podName   string
+}
+
+// NewEventsSink returns a Sink that creates a Kubernetes Event in the given namespace for each audit event.
+// The Events refer to the traffic-manager Deployment.
+func NewEventsSink(namespace string) Sink {
+	podName, _ := os.Hostname()
+	return &eventsSink{namespace: namespace, podName: podName}
+}
+
+func (s *eventsSink) Write(ctx context.Context, ev *Event) error {
+	t := meta.NewTime(ev.Time)
+	ke := &core.Event{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: agentmap.ManagerAppName + "-audit-",
+			Namespace:    s.namespace,
+		},
+		InvolvedObject: core.ObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       agentmap.ManagerAppName,
+			Namespace:  s.namespace,
+		},
+		Reason:              string(ev.Type),
+		Message:             ev.String(),
+		Type:                core.EventTypeNormal,
+		Source:              core.EventSource{Component: agentmap.ManagerAppName, Host: s.podName},
+		FirstTimestamp:      t,
+		LastTimestamp:       t,
+		Count:               1,
+		ReportingController: "telepresence.io/" + agentmap.ManagerAppName,
+		ReportingInstance:   s.podName,
+	}
+	_, err := k8sapi.GetK8sInterface(ctx).CoreV1().Events(s.namespace).Create(ctx, ke, meta.CreateOptions{})
+	return err
+}
+
+// webhookTimeout is the maximum time that a webhook may spend on each event.
+const webhookTimeout = 10 * time.Second
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a Sink that POSTs each event as a JSON object to the given URL.
+func NewWebhookSink(url string) Sink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+func (s *webhookSink) Write(ctx context.Context, ev *Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	rq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	rq.Header.Set("Content-Type", "application/json")
+	rs, err := s.client.Do(rq)
+	if err != nil {
+		return err
+	}
+	_ = rs.Body.Close()
+	if rs.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s responded with %s", s.url, rs.Status)
+	}
+	return nil
+}
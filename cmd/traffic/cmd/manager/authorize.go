@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// The resource attributes used in the SubjectAccessReview that authorizes an intercept. Platform teams
+// grant the verb on the resource, optionally limited to workload names, using ordinary RBAC Roles, e.g.
+//
+//	rules:
+//	- apiGroups: ["telepresence.getambassador.io"]
+//	  resources: ["intercepts"]
+//	  verbs: ["create"]
+const (
+	interceptResourceGroup = "telepresence.getambassador.io"
+	interceptResource      = "intercepts"
+	interceptVerb          = "create"
+)
+
+// The resource attributes used in the SubjectAccessReviews that authorize the administration of client
+// sessions. The resource is in the namespace of the traffic-manager, e.g.
+//
+//	rules:
+//	- apiGroups: ["telepresence.getambassador.io"]
+//	  resources: ["sessions"]
+//	  verbs: ["list", "delete"]
+const (
+	sessionResourceGroup = interceptResourceGroup
+	sessionResource      = "sessions"
+	sessionListVerb      = "list"
+	sessionDeleteVerb    = "delete"
+)
+
+// abbreviatedSessionIDLength is the length of the session IDs that are listed to clients that aren't
+// allowed to list the sessions of the traffic-manager.
+const abbreviatedSessionIDLength = 8
+
+// verifyClientIdentity replaces the Kubernetes user and groups of the given client with the ones that
+// its bearer token resolves to according to a TokenReview, and then discards the token. The identity is
+// left empty when the client didn't send a token, when the token can't be verified, and when no identity
+// is needed, because the traffic-manager then lacks the permission to create TokenReviews.
+func (s *service) verifyClientIdentity(ctx context.Context, client *rpc.ClientInfo) {
+	token := client.KubeToken
+	client.KubeToken = ""
+	client.KubeUser = ""
+	client.KubeGroups = nil
+	if token == "" || !s.verifiesClientIdentity(ctx) {
+		return
+	}
+	tr, err := k8sapi.GetK8sInterface(ctx).AuthenticationV1().TokenReviews().Create(ctx, &authn.TokenReview{
+		Spec: authn.TokenReviewSpec{Token: token},
+	}, meta.CreateOptions{})
+	if err != nil {
+		dlog.Errorf(ctx, "unable to verify the Kubernetes identity of client %s: %v", client.Name, err)
+		return
+	}
+	if !tr.Status.Authenticated {
+		dlog.Infof(ctx, "the Kubernetes identity of client %s could not be verified: %s", client.Name, tr.Status.Error)
+		return
+	}
+	client.KubeUser = tr.Status.User.Username
+	client.KubeGroups = tr.Status.User.Groups
+}
+
+// interceptsAreAuthorized returns true when there's an intercept policy or when SubjectAccessReviews
+// are enabled.
+func (s *service) interceptsAreAuthorized(ctx context.Context) bool {
+	return s.configWatcher.GetInterceptPolicy() != nil || managerutil.GetEnv(ctx).InterceptSubjectAccessReview
+}
+
+// verifiesClientIdentity returns true when the Kubernetes identity of clients is needed, because
+// intercepts are authorized or because the administration of sessions uses SubjectAccessReviews.
+func (s *service) verifiesClientIdentity(ctx context.Context) bool {
+	return s.interceptsAreAuthorized(ctx) || managerutil.GetEnv(ctx).SessionSubjectAccessReview
+}
+
+// authorizeIntercept returns an errcat.User error when the given client isn't allowed to intercept
+// the workload of the given spec. The client must be allowed by the intercept policy of the
+// traffic-manager ConfigMap, if there is one, and by a SubjectAccessReview when those are enabled.
+// Intercepts in namespaces that the traffic-manager doesn't manage are never allowed.
+//
+// The Kubernetes identity used in both checks is the one that verifyClientIdentity obtained when
+// the client arrived.
+func (s *service) authorizeIntercept(ctx context.Context, client *rpc.ClientInfo, spec *rpc.InterceptSpec) error {
+	if spec.Agent == "" {
+		// Local-only intercepts don't intercept anything in the cluster.
+		return nil
+	}
+	if !namespaces.IsManaged(ctx, spec.Namespace) {
+		return errcat.User.Newf("namespace %s is not managed by the traffic-manager", spec.Namespace)
+	}
+	if !s.interceptsAreAuthorized(ctx) {
+		return nil
+	}
+	user := client.KubeUser
+	if user == "" {
+		return errcat.User.Newf("unable to authorize intercept of %s.%s: the Kubernetes identity of the client could not be verified",
+			spec.Agent, spec.Namespace)
+	}
+	if !s.configWatcher.GetInterceptPolicy().Allows(user, client.KubeGroups, spec.Namespace, spec.Agent) {
+		return errcat.User.Newf("the intercept policy of the traffic-manager doesn't allow %s to intercept %s.%s",
+			identity(client), spec.Agent, spec.Namespace)
+	}
+	if !managerutil.GetEnv(ctx).InterceptSubjectAccessReview {
+		return nil
+	}
+	sar, err := k8sapi.GetK8sInterface(ctx).AuthorizationV1().SubjectAccessReviews().Create(ctx, &authz.SubjectAccessReview{
+		Spec: authz.SubjectAccessReviewSpec{
+			User:   user,
+			Groups: client.KubeGroups,
+			ResourceAttributes: &authz.ResourceAttributes{
+				Namespace: spec.Namespace,
+				Verb:      interceptVerb,
+				Group:     interceptResourceGroup,
+				Resource:  interceptResource,
+				Name:      spec.Agent,
+			},
+		},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to authorize intercept of %s.%s: %w", spec.Agent, spec.Namespace, err)
+	}
+	if !sar.Status.Allowed {
+		msg := fmt.Sprintf("%s is not allowed to intercept %s.%s", identity(client), spec.Agent, spec.Namespace)
+		if sar.Status.Reason != "" {
+			msg += ": " + sar.Status.Reason
+		}
+		return errcat.User.New(msg)
+	}
+	return nil
+}
+
+func identity(client *rpc.ClientInfo) string {
+	if client.KubeUser == "" {
+		return client.Name
+	}
+	return fmt.Sprintf("%s (%s)", client.Name, client.KubeUser)
+}
+
+// authorizeSessionAdministration returns a PermissionDenied error unless the given client is allowed to
+// perform the given verb on the sessions of the traffic-manager according to a SubjectAccessReview. No
+// client is allowed when those reviews aren't enabled.
+func (s *service) authorizeSessionAdministration(ctx context.Context, client *rpc.ClientInfo, verb string) error {
+	env := managerutil.GetEnv(ctx)
+	if !env.SessionSubjectAccessReview {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s client sessions: session administration is not enabled",
+			client.Name, verb)
+	}
+	if client.KubeUser == "" {
+		return status.Errorf(codes.PermissionDenied, "unable to authorize %s to %s client sessions: the Kubernetes identity of the client could not be verified",
+			client.Name, verb)
+	}
+	sar, err := k8sapi.GetK8sInterface(ctx).AuthorizationV1().SubjectAccessReviews().Create(ctx, &authz.SubjectAccessReview{
+		Spec: authz.SubjectAccessReviewSpec{
+			User:   client.KubeUser,
+			Groups: client.KubeGroups,
+			ResourceAttributes: &authz.ResourceAttributes{
+				Namespace: env.ManagerNamespace,
+				Verb:      verb,
+				Group:     sessionResourceGroup,
+				Resource:  sessionResource,
+			},
+		},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to authorize %s to %s client sessions: %v", identity(client), verb, err)
+	}
+	if !sar.Status.Allowed {
+		msg := fmt.Sprintf("%s is not allowed to %s client sessions", identity(client), verb)
+		if sar.Status.Reason != "" {
+			msg += ": " + sar.Status.Reason
+		}
+		return status.Error(codes.PermissionDenied, msg)
+	}
+	return nil
+}
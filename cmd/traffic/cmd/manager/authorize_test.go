@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/config"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type policyWatcher struct {
+	config.Watcher
+	policy *config.InterceptPolicy
+}
+
+func (w *policyWatcher) GetInterceptPolicy() *config.InterceptPolicy {
+	return w.policy
+}
+
+func (w *policyWatcher) GetClientConfigYaml() []byte {
+	return nil
+}
+
+func TestAuthorizeIntercept(t *testing.T) {
+	policy, err := config.ParseInterceptPolicy([]byte(`rules: [{groups: ["developers"], namespaces: ["dev-*"]}]`))
+	require.NoError(t, err)
+
+	ki := fake.NewSimpleClientset()
+	ki.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		sar.Status.Allowed = sar.Spec.User == "alice" && sar.Spec.ResourceAttributes.Name == "echo"
+		if !sar.Status.Allowed {
+			sar.Status.Reason = "no RBAC policy matched"
+		}
+		return true, sar, nil
+	})
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), ki)
+	s := &service{configWatcher: &policyWatcher{policy: policy}}
+
+	alice := &rpc.ClientInfo{Name: "alice@host", KubeUser: "alice", KubeGroups: []string{"developers"}}
+	bob := &rpc.ClientInfo{Name: "bob@host", KubeUser: "bob", KubeGroups: []string{"developers"}}
+	anonymous := &rpc.ClientInfo{Name: "anon@host"}
+	spec := func(agent, namespace string) *rpc.InterceptSpec {
+		return &rpc.InterceptSpec{Name: agent, Agent: agent, Namespace: namespace}
+	}
+	assertUserError := func(t *testing.T, err error, msg string) {
+		t.Helper()
+		require.Error(t, err)
+		assert.Equal(t, errcat.User, errcat.GetCategory(err))
+		assert.ErrorContains(t, err, msg)
+	}
+
+	t.Run("policy only", func(t *testing.T) {
+		ctx := managerutil.WithEnv(ctx, &managerutil.Env{})
+		assert.NoError(t, s.authorizeIntercept(ctx, bob, spec("web", "dev-bob")))
+		assertUserError(t, s.authorizeIntercept(ctx, bob, spec("web", "prod")), "doesn't allow bob@host (bob) to intercept web.prod")
+		assert.NoError(t, s.authorizeIntercept(ctx, anonymous, &rpc.InterceptSpec{Name: "local", Namespace: "prod"}),
+			"local-only intercepts are always allowed")
+	})
+
+	t.Run("subject access review", func(t *testing.T) {
+		ctx := managerutil.WithEnv(ctx, &managerutil.Env{InterceptSubjectAccessReview: true})
+		assert.NoError(t, s.authorizeIntercept(ctx, alice, spec("echo", "dev-alice")))
+		assertUserError(t, s.authorizeIntercept(ctx, alice, spec("web", "dev-alice")), "no RBAC policy matched")
+		assertUserError(t, s.authorizeIntercept(ctx, bob, spec("echo", "dev-bob")), "bob@host (bob) is not allowed")
+
+		s := &service{configWatcher: &policyWatcher{}}
+		assertUserError(t, s.authorizeIntercept(ctx, anonymous, spec("echo", "dev-alice")), "could not be verified")
+	})
+
+	t.Run("unverified identity", func(t *testing.T) {
+		ctx := managerutil.WithEnv(ctx, &managerutil.Env{})
+		assertUserError(t, s.authorizeIntercept(ctx, anonymous, spec("web", "dev-bob")), "could not be verified")
+
+		s := &service{configWatcher: &policyWatcher{}}
+		assert.NoError(t, s.authorizeIntercept(ctx, anonymous, spec("web", "prod")), "nothing is authorized without policy or reviews")
+	})
+}
+
+func TestVerifyClientIdentity(t *testing.T) {
+	ki := fake.NewSimpleClientset()
+	ki.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		if tr.Spec.Token == "alice-token" {
+			tr.Status.Authenticated = true
+			tr.Status.User = authn.UserInfo{Username: "alice", Groups: []string{"developers"}}
+		} else {
+			tr.Status.Error = "invalid token"
+		}
+		return true, tr, nil
+	})
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), ki)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{InterceptSubjectAccessReview: true})
+	s := &service{configWatcher: &policyWatcher{}}
+
+	client := &rpc.ClientInfo{Name: "alice@host", KubeToken: "alice-token", KubeUser: "system:admin"}
+	s.verifyClientIdentity(ctx, client)
+	assert.Equal(t, "alice", client.KubeUser)
+	assert.Equal(t, []string{"developers"}, client.KubeGroups)
+	assert.Empty(t, client.KubeToken, "the token is not retained")
+
+	client = &rpc.ClientInfo{Name: "mallory@host", KubeToken: "forged", KubeUser: "admin", KubeGroups: []string{"system:masters"}}
+	s.verifyClientIdentity(ctx, client)
+	assert.Empty(t, client.KubeUser, "a self-reported user is never used")
+	assert.Empty(t, client.KubeGroups, "self-reported groups are never used")
+
+	client = &rpc.ClientInfo{Name: "mallory@host", KubeUser: "admin", KubeGroups: []string{"system:masters"}}
+	s.verifyClientIdentity(ctx, client)
+	assert.Empty(t, client.KubeUser)
+	assert.Empty(t, client.KubeGroups)
+}
+
+func TestGetClientConfig_verifyClientIdentity(t *testing.T) {
+	policy, err := config.ParseInterceptPolicy([]byte(`rules: [{groups: ["developers"], namespaces: ["dev-*"]}]`))
+	require.NoError(t, err)
+	ctx := managerutil.WithEnv(dlog.NewTestContext(t, false), &managerutil.Env{})
+
+	s := &service{configWatcher: &policyWatcher{}}
+	cfg, err := s.GetClientConfig(ctx, nil)
+	require.NoError(t, err)
+	assert.False(t, cfg.VerifyClientIdentity, "clients don't send their token when nothing is authorized")
+
+	s = &service{configWatcher: &policyWatcher{policy: policy}}
+	cfg, err = s.GetClientConfig(ctx, nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.VerifyClientIdentity)
+
+	s = &service{configWatcher: &policyWatcher{}}
+	cfg, err = s.GetClientConfig(managerutil.WithEnv(ctx, &managerutil.Env{InterceptSubjectAccessReview: true}), nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.VerifyClientIdentity)
+
+	cfg, err = s.GetClientConfig(managerutil.WithEnv(ctx, &managerutil.Env{SessionSubjectAccessReview: true}), nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.VerifyClientIdentity)
+}
+
+func TestAuthorizeSessionAdministration(t *testing.T) {
+	ki := fake.NewSimpleClientset()
+	ki.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		ra := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = ra.Namespace == "ambassador" && ra.Resource == "sessions" &&
+			(sar.Spec.User == "admin" || sar.Spec.User == "alice" && ra.Verb == "list")
+		return true, sar, nil
+	})
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), ki)
+	s := &service{configWatcher: &policyWatcher{}}
+
+	admin := &rpc.ClientInfo{Name: "admin@host", KubeUser: "admin"}
+	alice := &rpc.ClientInfo{Name: "alice@host", KubeUser: "alice"}
+	anonymous := &rpc.ClientInfo{Name: "anon@host"}
+
+	t.Run("disabled", func(t *testing.T) {
+		ctx := managerutil.WithEnv(ctx, &managerutil.Env{ManagerNamespace: "ambassador"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(s.authorizeSessionAdministration(ctx, admin, sessionListVerb)))
+		assert.Equal(t, codes.PermissionDenied, status.Code(s.authorizeSessionAdministration(ctx, admin, sessionDeleteVerb)))
+	})
+
+	t.Run("subject access review", func(t *testing.T) {
+		ctx := managerutil.WithEnv(ctx, &managerutil.Env{ManagerNamespace: "ambassador", SessionSubjectAccessReview: true})
+		assert.NoError(t, s.authorizeSessionAdministration(ctx, admin, sessionListVerb))
+		assert.NoError(t, s.authorizeSessionAdministration(ctx, admin, sessionDeleteVerb))
+		assert.NoError(t, s.authorizeSessionAdministration(ctx, alice, sessionListVerb))
+		err := s.authorizeSessionAdministration(ctx, alice, sessionDeleteVerb)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.ErrorContains(t, err, "alice@host (alice) is not allowed to delete client sessions")
+		err = s.authorizeSessionAdministration(ctx, anonymous, sessionListVerb)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.ErrorContains(t, err, "could not be verified")
+	})
+}
@@ -117,54 +117,28 @@ func NewInfo(ctx context.Context) Info {
 			"unable to get namespace \"default\", but it is only necessary for compatibility with old licesnses: %v", err)
 	}
 
-	dummyIP := "1.1.1.1"
+	// The dummy IP of the primary IP family must be used first to get the correct error message.
+	dummyIPs := []string{"1.1.1.1", "1:1::1"}
 	if managerutil.AgentInjectorEnabled(ctx) {
 		oi.InjectorSvcIp, oi.InjectorSvcPort, err = getInjectorSvcIP(ctx, env, client)
 		if err != nil {
 			dlog.Warn(ctx, err)
 		} else if len(oi.InjectorSvcIp) == 16 {
-			// Must use an IPv6 IP to get the correct error message.
-			dummyIP = "1:1::1"
+			dummyIPs[0], dummyIPs[1] = dummyIPs[1], dummyIPs[0]
 		}
 	}
 
-	// make an attempt to create a service with ClusterIP that is out of range and then
-	// check the error message for the correct range as suggested tin the second answer here:
-	//   https://stackoverflow.com/questions/44190607/how-do-you-find-the-cluster-service-cidr-of-a-kubernetes-cluster
-	// This requires an additional permission to create a service, which the traffic-manager
-	// should have.
-	svc := corev1.Service{
-		TypeMeta: metav1.TypeMeta{
-			Kind: "Service",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: env.ManagerNamespace,
-			Name:      "t2-tst-dummy",
-		},
-		Spec: corev1.ServiceSpec{
-			Ports:     []corev1.ServicePort{{Port: 443}},
-			ClusterIP: dummyIP,
-		},
-	}
-
-	if _, err = client.Services(env.ManagerNamespace).Create(ctx, &svc, metav1.CreateOptions{}); err != nil {
-		svcCIDRrx := regexp.MustCompile(`range of valid IPs is (.*)$`)
-		if match := svcCIDRrx.FindStringSubmatch(err.Error()); match != nil {
-			var cidr *net.IPNet
-			if _, cidr, err = net.ParseCIDR(match[1]); err != nil {
-				dlog.Errorf(ctx, "unable to parse service CIDR %q", match[1])
-			} else {
-				dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
-				oi.ServiceSubnet = iputil.IPNetToRPC(cidr)
-			}
-		} else {
-			dlog.Errorf(ctx, "unable to extract service subnet from error message %q", err.Error())
+	// A dual-stack cluster has one service subnet for each IP family, so each family is probed. A
+	// failure to find the subnet of the secondary family is expected in a single-stack cluster.
+	for i, dummyIP := range dummyIPs {
+		if cidr := probeServiceSubnet(ctx, client, env.ManagerNamespace, dummyIP, i == 0); cidr != nil {
+			oi.ServiceSubnets = append(oi.ServiceSubnets, iputil.IPNetToRPC(cidr))
 		}
 	}
-
-	if err != nil {
-		dlog.Warn(ctx, err)
+	if len(oi.ServiceSubnets) > 0 {
+		oi.ServiceSubnet = oi.ServiceSubnets[0]
 	}
+
 	if oi.ServiceSubnet == nil && len(oi.InjectorSvcIp) > 0 {
 		// Using a "kubectl cluster-info dump" or scanning all services generates a lot of unwanted traffic
 		// and would quite possibly also require elevated permissions, so instead, we derive the service subnet
@@ -176,6 +150,7 @@ func NewInfo(ctx context.Context) Info {
 		ones := bits / 2
 		mask := net.CIDRMask(ones, bits) // will yield a 16 bit mask on IPv4 and 64 bit mask on IPv6.
 		oi.ServiceSubnet = &rpc.IPNet{Ip: ip.Mask(mask), Mask: int32(ones)}
+		oi.ServiceSubnets = []*rpc.IPNet{oi.ServiceSubnet}
 	}
 
 	podCIDRStrategy := env.PodCIDRStrategy
@@ -347,6 +322,53 @@ func (oi *info) watchNodeSubnets(ctx context.Context, mustSucceed bool) bool {
 	return true
 }
 
+// probeServiceSubnet makes an attempt to create a service with a ClusterIP that is out of range and then
+// checks the error message for the correct range, as suggested in the second answer here:
+//
+//	https://stackoverflow.com/questions/44190607/how-do-you-find-the-cluster-service-cidr-of-a-kubernetes-cluster
+//
+// The IP family of the given dummyIP decides what subnet that is found. This requires an additional
+// permission to create a service, which the traffic-manager should have. Failures are logged as errors
+// when primary is true.
+func probeServiceSubnet(ctx context.Context, client v1.CoreV1Interface, namespace, dummyIP string, primary bool) *net.IPNet {
+	logf := dlog.Debugf
+	if primary {
+		logf = dlog.Errorf
+	}
+	svc := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "t2-tst-dummy",
+		},
+		Spec: corev1.ServiceSpec{
+			Ports:     []corev1.ServicePort{{Port: 443}},
+			ClusterIP: dummyIP,
+		},
+	}
+	_, err := client.Services(namespace).Create(ctx, &svc, metav1.CreateOptions{})
+	if err == nil {
+		dlog.Debugf(ctx, "unexpectedly succeeded in creating service with ClusterIP %s", dummyIP)
+		_ = client.Services(namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+		return nil
+	}
+	svcCIDRrx := regexp.MustCompile(`range of valid IPs is (.*)$`)
+	match := svcCIDRrx.FindStringSubmatch(err.Error())
+	if match == nil {
+		logf(ctx, "unable to extract service subnet from error message %q", err.Error())
+		return nil
+	}
+	_, cidr, err := net.ParseCIDR(match[1])
+	if err != nil {
+		logf(ctx, "unable to parse service CIDR %q", match[1])
+		return nil
+	}
+	dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
+	return cidr
+}
+
 func getInjectorSvcIP(ctx context.Context, env *managerutil.Env, client v1.CoreV1Interface) ([]byte, int32, error) {
 	sc, err := client.Services(env.ManagerNamespace).Get(ctx, env.AgentInjectorName, metav1.GetOptions{})
 	if err != nil {
@@ -434,6 +456,7 @@ func (oi *info) clusterInfo() *rpc.ClusterInfo {
 
 	ci := &rpc.ClusterInfo{
 		ServiceSubnet:   oi.ServiceSubnet,
+		ServiceSubnets:  oi.ServiceSubnets,
 		PodSubnets:      make([]*rpc.IPNet, len(oi.PodSubnets)),
 		ManagerPodIp:    oi.ManagerPodIp,
 		ManagerPodPort:  oi.ManagerPodPort,
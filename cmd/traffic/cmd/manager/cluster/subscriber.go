@@ -107,6 +107,9 @@ func clusterInfoEqual(a, b *rpc.ClusterInfo) bool {
 	if !slices.EqualFunc(a.PodSubnets, b.PodSubnets, ipNetEQ) {
 		return false
 	}
+	if !slices.EqualFunc(a.ServiceSubnets, b.ServiceSubnets, ipNetEQ) {
+		return false
+	}
 	if !slices.EqualFunc(a.Routing.AlsoProxySubnets, b.Routing.AlsoProxySubnets, ipNetEQ) {
 		return false
 	}
@@ -26,13 +26,22 @@ type WatcherCallback func(watch.EventType, runtime.Object) error
 type Watcher interface {
 	Run(ctx context.Context) error
 	GetClientConfigYaml() []byte
+	GetInterceptPolicy() *InterceptPolicy
+	GetManagerConfig() *ManagerConfig
+
+	// Subscribe adds a listener that is called when the ManagerConfig changes.
+	Subscribe(l ManagerConfigListener)
 }
 
 type config struct {
 	sync.RWMutex
 	namespace string
 
-	clientYAML []byte
+	clientYAML        []byte
+	interceptPolicy   *InterceptPolicy
+	managerConfig     *ManagerConfig
+	managerConfigYAML string
+	listeners         []ManagerConfigListener
 }
 
 func NewWatcher(namespace string) Watcher {
@@ -115,7 +124,54 @@ func (c *config) refreshFile(ctx context.Context, data map[string]string) {
 		c.clientYAML = nil
 		dlog.Debugf(ctx, "Cleared client config")
 	}
+	if yml, ok := data[interceptPolicyFileName]; ok {
+		p, err := ParseInterceptPolicy([]byte(yml))
+		if err != nil {
+			// Keep the previous policy rather than allowing everything.
+			dlog.Errorf(ctx, "failed to parse %s: %v", interceptPolicyFileName, err)
+		} else {
+			c.interceptPolicy = p
+			dlog.Debugf(ctx, "Refreshed intercept policy: %s", yml)
+		}
+	} else if c.interceptPolicy != nil {
+		c.interceptPolicy = nil
+		dlog.Debugf(ctx, "Cleared intercept policy")
+	}
+	mcChanged := c.refreshManagerConfig(ctx, data)
+	mc := c.managerConfig
+	listeners := c.listeners
 	c.Unlock()
+
+	if mcChanged {
+		for _, l := range listeners {
+			l(ctx, mc)
+		}
+	}
+}
+
+// refreshManagerConfig updates the ManagerConfig from the given data and returns true if it changed.
+// The caller must hold the lock.
+func (c *config) refreshManagerConfig(ctx context.Context, data map[string]string) bool {
+	yml := data[managerConfigFileName]
+	if yml == c.managerConfigYAML {
+		return false
+	}
+	if yml == "" {
+		c.managerConfig = nil
+		c.managerConfigYAML = ""
+		dlog.Infof(ctx, "Cleared traffic-manager config")
+		return true
+	}
+	mc, err := ParseManagerConfig([]byte(yml))
+	if err != nil {
+		// Keep the previous config.
+		dlog.Errorf(ctx, "failed to parse %s: %v", managerConfigFileName, err)
+		return false
+	}
+	c.managerConfig = mc
+	c.managerConfigYAML = yml
+	dlog.Infof(ctx, "Reloaded traffic-manager config: %s", yml)
+	return true
 }
 
 func (c *config) GetClientConfigYaml() (ret []byte) {
@@ -124,3 +180,25 @@ func (c *config) GetClientConfigYaml() (ret []byte) {
 	c.RUnlock()
 	return
 }
+
+// GetManagerConfig returns the ManagerConfig of the traffic-manager ConfigMap, or nil if it has none.
+func (c *config) GetManagerConfig() (ret *ManagerConfig) {
+	c.RLock()
+	ret = c.managerConfig
+	c.RUnlock()
+	return
+}
+
+func (c *config) Subscribe(l ManagerConfigListener) {
+	c.Lock()
+	c.listeners = append(c.listeners, l)
+	c.Unlock()
+}
+
+// GetInterceptPolicy returns the intercept policy of the traffic-manager ConfigMap, or nil if it has none.
+func (c *config) GetInterceptPolicy() (ret *InterceptPolicy) {
+	c.RLock()
+	ret = c.interceptPolicy
+	c.RUnlock()
+	return
+}
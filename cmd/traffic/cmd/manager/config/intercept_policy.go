@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"sigs.k8s.io/yaml"
+)
+
+// interceptPolicyFileName is the key of the intercept policy in the traffic-manager ConfigMap.
+const interceptPolicyFileName = "intercept-policy.yaml"
+
+// InterceptPolicy restricts the namespaces and workloads that clients may intercept. The clients are
+// identified by the Kubernetes user and groups that the traffic-manager verified when they arrived.
+type InterceptPolicy struct {
+	Rules []InterceptRule `json:"rules"`
+}
+
+// InterceptRule allows the users and groups of the rule to intercept the workloads of the rule in the
+// namespaces of the rule. All names may use shell file name patterns, e.g. "dev-*". Omitted workloads
+// mean all workloads.
+type InterceptRule struct {
+	Users      []string `json:"users,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	Namespaces []string `json:"namespaces"`
+	Workloads  []string `json:"workloads,omitempty"`
+}
+
+// ParseInterceptPolicy parses an InterceptPolicy from YAML and validates its patterns.
+func ParseInterceptPolicy(data []byte) (*InterceptPolicy, error) {
+	var p InterceptPolicy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, err
+	}
+	for i, r := range p.Rules {
+		for _, ps := range [][]string{r.Users, r.Groups, r.Namespaces, r.Workloads} {
+			for _, pattern := range ps {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("rule %d: invalid pattern %q: %w", i, pattern, err)
+				}
+			}
+		}
+	}
+	return &p, nil
+}
+
+// Allows returns true if a rule of the policy allows the given user, or one of the given groups, to
+// intercept the given workload in the given namespace. A nil policy allows everything.
+func (p *InterceptPolicy) Allows(user string, groups []string, namespace, workload string) bool {
+	if p == nil {
+		return true
+	}
+	for _, r := range p.Rules {
+		subject := matchAny(r.Users, user) || slices.ContainsFunc(groups, func(g string) bool { return matchAny(r.Groups, g) })
+		if subject && matchAny(r.Namespaces, namespace) && (len(r.Workloads) == 0 || matchAny(r.Workloads, workload)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
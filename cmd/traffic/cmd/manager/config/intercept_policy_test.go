@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptPolicy_Allows(t *testing.T) {
+	p, err := ParseInterceptPolicy([]byte(`
+rules:
+- groups: ["developers"]
+  namespaces: ["dev-*"]
+- users: ["alice@example.com"]
+  namespaces: ["staging"]
+  workloads: ["echo-*"]
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		user      string
+		groups    []string
+		namespace string
+		workload  string
+		allowed   bool
+	}{
+		{"group and namespace match", "bob", []string{"system:authenticated", "developers"}, "dev-bob", "web", true},
+		{"group but not namespace", "bob", []string{"developers"}, "staging", "echo-server", false},
+		{"user and workload match", "alice@example.com", nil, "staging", "echo-server", true},
+		{"user but not workload", "alice@example.com", nil, "staging", "web", false},
+		{"no matching subject", "mallory", []string{"system:authenticated"}, "dev-bob", "web", false},
+		{"unknown identity", "", nil, "dev-bob", "web", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, p.Allows(tt.user, tt.groups, tt.namespace, tt.workload))
+		})
+	}
+
+	var np *InterceptPolicy
+	assert.True(t, np.Allows("", nil, "prod", "web"), "a nil policy allows everything")
+}
+
+func TestParseInterceptPolicy(t *testing.T) {
+	_, err := ParseInterceptPolicy([]byte(`rules: [{users: ["["], namespaces: ["*"]}]`))
+	assert.ErrorContains(t, err, "invalid pattern")
+
+	_, err = ParseInterceptPolicy([]byte(`rules: [{user: ["alice"], namespaces: ["*"]}]`))
+	assert.Error(t, err, "unknown fields are rejected")
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// managerConfigFileName is the key of the traffic-manager settings in the traffic-manager ConfigMap.
+const managerConfigFileName = "manager.yaml"
+
+// ManagerConfig contains the settings of the traffic-manager that are reloaded when the traffic-manager
+// ConfigMap changes. Settings that are omitted retain the value of their environment variable.
+type ManagerConfig struct {
+	LogLevel   string           `json:"logLevel,omitempty"`
+	AgentImage string           `json:"agentImage,omitempty"`
+	Timeouts   *ManagerTimeouts `json:"timeouts,omitempty"`
+}
+
+// ManagerTimeouts are the timeouts of the ManagerConfig.
+type ManagerTimeouts struct {
+	AgentArrival        *meta.Duration `json:"agentArrival,omitempty"`
+	InterceptIdleTTL    *meta.Duration `json:"interceptIdleTTL,omitempty"`
+	ClientConnectionTTL *meta.Duration `json:"clientConnectionTTL,omitempty"`
+}
+
+// ManagerConfigListener is called with the new ManagerConfig when it changes. The ManagerConfig is nil
+// when it was removed.
+type ManagerConfigListener func(ctx context.Context, mc *ManagerConfig)
+
+// ParseManagerConfig parses a ManagerConfig from YAML and validates it.
+func ParseManagerConfig(data []byte) (*ManagerConfig, error) {
+	var mc ManagerConfig
+	if err := yaml.UnmarshalStrict(data, &mc); err != nil {
+		return nil, err
+	}
+	if mc.LogLevel != "" {
+		if _, err := logrus.ParseLevel(mc.LogLevel); err != nil {
+			return nil, err
+		}
+	}
+	if t := mc.Timeouts; t != nil {
+		for n, d := range map[string]*meta.Duration{
+			"agentArrival":        t.AgentArrival,
+			"interceptIdleTTL":    t.InterceptIdleTTL,
+			"clientConnectionTTL": t.ClientConnectionTTL,
+		} {
+			if d != nil && d.Duration < 0 {
+				return nil, fmt.Errorf("timeouts.%s cannot be negative", n)
+			}
+		}
+	}
+	return &mc, nil
+}
+
+// ActiveManagerConfig returns the settings that are in effect, i.e. the given ManagerConfig with
+// omitted settings replaced by the values of their environment variables.
+func ActiveManagerConfig(ctx context.Context, mc *ManagerConfig) *ManagerConfig {
+	env := managerutil.GetEnv(ctx)
+	ac := &ManagerConfig{
+		LogLevel: env.LogLevel,
+		Timeouts: &ManagerTimeouts{
+			AgentArrival:        &meta.Duration{Duration: agentArrivalTimeout(mc, env)},
+			InterceptIdleTTL:    &meta.Duration{Duration: interceptIdleTTL(mc, env)},
+			ClientConnectionTTL: &meta.Duration{Duration: clientConnectionTTL(mc, env)},
+		},
+	}
+	if managerutil.GetAgentImageRetriever(ctx) != nil {
+		ac.AgentImage = managerutil.GetAgentImage(ctx)
+	}
+	if mc != nil && mc.LogLevel != "" {
+		ac.LogLevel = mc.LogLevel
+	}
+	return ac
+}
+
+// AgentArrivalTimeout returns the agent arrival timeout of the ManagerConfig of the Watcher in the
+// given context, or the value of AGENT_ARRIVAL_TIMEOUT when it's omitted.
+func AgentArrivalTimeout(ctx context.Context) time.Duration {
+	return agentArrivalTimeout(getManagerConfig(ctx), managerutil.GetEnv(ctx))
+}
+
+// InterceptIdleTTL returns the intercept idle TTL of the ManagerConfig of the Watcher in the given
+// context, or the value of INTERCEPT_IDLE_TTL when it's omitted.
+func InterceptIdleTTL(ctx context.Context) time.Duration {
+	return interceptIdleTTL(getManagerConfig(ctx), managerutil.GetEnv(ctx))
+}
+
+// ClientConnectionTTL returns the client connection TTL of the ManagerConfig of the Watcher in the
+// given context, or the value of CLIENT_CONNECTION_TTL when it's omitted.
+func ClientConnectionTTL(ctx context.Context) time.Duration {
+	return clientConnectionTTL(getManagerConfig(ctx), managerutil.GetEnv(ctx))
+}
+
+func getManagerConfig(ctx context.Context) *ManagerConfig {
+	if w := GetWatcher(ctx); w != nil {
+		return w.GetManagerConfig()
+	}
+	return nil
+}
+
+func agentArrivalTimeout(mc *ManagerConfig, env *managerutil.Env) time.Duration {
+	if mc != nil && mc.Timeouts != nil && mc.Timeouts.AgentArrival != nil {
+		return mc.Timeouts.AgentArrival.Duration
+	}
+	return env.AgentArrivalTimeout
+}
+
+func interceptIdleTTL(mc *ManagerConfig, env *managerutil.Env) time.Duration {
+	if mc != nil && mc.Timeouts != nil && mc.Timeouts.InterceptIdleTTL != nil {
+		return mc.Timeouts.InterceptIdleTTL.Duration
+	}
+	return env.InterceptIdleTTL
+}
+
+func clientConnectionTTL(mc *ManagerConfig, env *managerutil.Env) time.Duration {
+	if mc != nil && mc.Timeouts != nil && mc.Timeouts.ClientConnectionTTL != nil {
+		return mc.Timeouts.ClientConnectionTTL.Duration
+	}
+	return env.ClientConnectionTTL
+}
+
+type watcherKey struct{}
+
+// WithWatcher returns a context that carries the given Watcher.
+func WithWatcher(ctx context.Context, w Watcher) context.Context {
+	return context.WithValue(ctx, watcherKey{}, w)
+}
+
+// GetWatcher returns the Watcher found in the given context, or nil if there is none.
+func GetWatcher(ctx context.Context) Watcher {
+	w, _ := ctx.Value(watcherKey{}).(Watcher)
+	return w
+}
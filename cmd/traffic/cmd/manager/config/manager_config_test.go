@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func TestParseManagerConfig(t *testing.T) {
+	mc, err := ParseManagerConfig([]byte(`
+logLevel: debug
+agentImage: docker.io/datawire/tel2:2.19.0
+timeouts:
+  agentArrival: 1m
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "debug", mc.LogLevel)
+	assert.Equal(t, "docker.io/datawire/tel2:2.19.0", mc.AgentImage)
+	assert.Equal(t, time.Minute, mc.Timeouts.AgentArrival.Duration)
+	assert.Nil(t, mc.Timeouts.InterceptIdleTTL)
+
+	_, err = ParseManagerConfig([]byte("logLevel: chatty"))
+	assert.Error(t, err)
+
+	_, err = ParseManagerConfig([]byte("timeouts:\n  agentArrival: -1s"))
+	assert.ErrorContains(t, err, "timeouts.agentArrival cannot be negative")
+
+	_, err = ParseManagerConfig([]byte("agentImages: tel2"))
+	assert.Error(t, err)
+}
+
+func TestManagerConfigReload(t *testing.T) {
+	ctx := managerutil.WithEnv(dlog.NewTestContext(t, false), &managerutil.Env{
+		LogLevel:            "info",
+		AgentArrivalTimeout: 30 * time.Second,
+		InterceptIdleTTL:    time.Hour,
+		ClientConnectionTTL: 24 * time.Hour,
+	})
+	w := NewWatcher("ambassador").(*config)
+	ctx = WithWatcher(ctx, w)
+
+	var reloaded []*ManagerConfig
+	w.Subscribe(func(_ context.Context, mc *ManagerConfig) {
+		reloaded = append(reloaded, mc)
+	})
+
+	assert.Equal(t, 30*time.Second, AgentArrivalTimeout(ctx))
+	w.refreshFile(ctx, map[string]string{managerConfigFileName: "logLevel: debug\ntimeouts:\n  agentArrival: 1m\n"})
+	require.Len(t, reloaded, 1)
+	assert.Equal(t, time.Minute, AgentArrivalTimeout(ctx))
+	assert.Equal(t, time.Hour, InterceptIdleTTL(ctx))
+	assert.Equal(t, 24*time.Hour, ClientConnectionTTL(ctx))
+	assert.Equal(t, "debug", ActiveManagerConfig(ctx, w.GetManagerConfig()).LogLevel)
+
+	// Unchanged and invalid configs don't notify the listeners, and the previous config is retained.
+	w.refreshFile(ctx, map[string]string{managerConfigFileName: "logLevel: debug\ntimeouts:\n  agentArrival: 1m\n"})
+	w.refreshFile(ctx, map[string]string{managerConfigFileName: "logLevel: chatty"})
+	assert.Len(t, reloaded, 1)
+	assert.Equal(t, time.Minute, AgentArrivalTimeout(ctx))
+
+	w.refreshFile(ctx, nil)
+	require.Len(t, reloaded, 2)
+	assert.Nil(t, reloaded[1])
+	assert.Equal(t, 30*time.Second, AgentArrivalTimeout(ctx))
+	assert.Equal(t, "info", ActiveManagerConfig(ctx, nil).LogLevel)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/blang/semver/v4"
 
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 )
 
 func validateClient(client *rpc.ClientInfo) string {
@@ -76,6 +78,44 @@ func validateIntercept(spec *rpc.InterceptSpec) string {
 		return "namespace must not be empty"
 	case spec.Mechanism == "":
 		return "mechanism must not be empty"
+	case spec.Mirror && spec.Replace:
+		return "a mirroring intercept cannot replace the container"
+	case spec.Mirror && spec.Mechanism != "tcp":
+		return "a mirroring intercept must use the tcp mechanism"
+	case spec.Mirror && strings.EqualFold(spec.Protocol, "UDP"):
+		return "a mirroring intercept cannot intercept a UDP port"
+	case spec.TrafficPercent < 0 || spec.TrafficPercent > 100:
+		return "traffic percent must be between 0 and 100"
+	case spec.TrafficPercent > 0 && spec.Replace:
+		return "an intercept that replaces the container cannot split the traffic"
+	case spec.TrafficPercent > 0 && spec.Mechanism != "tcp":
+		return "an intercept that splits the traffic must use the tcp mechanism"
+	case spec.TrafficPercent > 0 && strings.EqualFold(spec.Protocol, "UDP"):
+		return "an intercept that splits the traffic cannot intercept a UDP port"
+	case spec.Ingest && (spec.Replace || spec.Mirror || spec.TrafficPercent > 0):
+		return "an ingest cannot replace the container, mirror, or split the traffic"
+	case spec.Ingest && (len(spec.HttpHeaders) > 0 || len(spec.GrpcMethods) > 0 || len(spec.GrpcMetadata) > 0 || spec.SessionAffinity != ""):
+		return "an ingest cannot have http headers, gRPC conditions, or session affinity"
+	case spec.TerminateTls && (spec.Ingest || spec.Mirror):
+		return "an ingest or a mirroring intercept cannot terminate TLS"
+	case spec.TerminateTls && spec.Mechanism != "tcp":
+		return "an intercept that terminates TLS must use the tcp mechanism"
+	case spec.TerminateTls && strings.EqualFold(spec.Protocol, "UDP"):
+		return "an intercept that terminates TLS cannot intercept a UDP port"
+	}
+	if _, err := matcher.ParseHeaderExpr(spec.HttpHeaders); err != nil {
+		return err.Error()
+	}
+	if _, err := matcher.NewGRPC(spec.GrpcMethods, spec.GrpcMetadata); err != nil {
+		return err.Error()
+	}
+	if _, err := matcher.NewAffinity(spec.SessionAffinity); err != nil {
+		return err.Error()
+	}
+	if spec.Pod != "" {
+		if _, _, err := state.ParsePodSelection(spec.Pod); err != nil {
+			return err.Error()
+		}
 	}
 
 	return ""
@@ -0,0 +1,310 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
+)
+
+// interceptSpecGVR is the resource of the InterceptSpec custom resources that declare intercepts. The
+// CRD is installed by the Helm chart when intercept.specs.enabled is true.
+var interceptSpecGVR = schema.GroupVersionResource{ //nolint:gochecknoglobals // constant
+	Group:    interceptResourceGroup,
+	Version:  "v1alpha1",
+	Resource: "interceptspecs",
+}
+
+// The phases reported in the status of an InterceptSpec.
+const (
+	declarationInvalid = "Invalid"
+	declarationReady   = "Ready"
+	declarationAdopted = "Adopted"
+)
+
+// interceptSpecSpec is the spec of an InterceptSpec resource.
+type interceptSpecSpec struct {
+	Workload    string   `json:"workload"`
+	Service     string   `json:"service,omitempty"`
+	Port        string   `json:"port,omitempty"`
+	HttpHeaders []string `json:"httpHeaders,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+}
+
+// declarations holds the InterceptSpec resources that are known to the traffic-manager, keyed by
+// <namespace>/<name>.
+type declarations struct {
+	sync.Mutex
+	objs    map[string]*unstructured.Unstructured
+	changed chan struct{}
+}
+
+func newDeclarations() *declarations {
+	return &declarations{
+		objs:    make(map[string]*unstructured.Unstructured),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+func declarationKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func (d *declarations) set(obj *unstructured.Unstructured) {
+	d.Lock()
+	d.objs[declarationKey(obj.GetNamespace(), obj.GetName())] = obj
+	d.Unlock()
+	d.signal()
+}
+
+func (d *declarations) remove(obj *unstructured.Unstructured) {
+	d.Lock()
+	delete(d.objs, declarationKey(obj.GetNamespace(), obj.GetName()))
+	d.Unlock()
+	d.signal()
+}
+
+func (d *declarations) signal() {
+	select {
+	case d.changed <- struct{}{}:
+	default:
+	}
+}
+
+// get returns the InterceptSpec with the given namespace and name, or nil if no such resource exists.
+func (d *declarations) get(namespace, name string) *unstructured.Unstructured {
+	if d == nil {
+		return nil
+	}
+	d.Lock()
+	defer d.Unlock()
+	return d.objs[declarationKey(namespace, name)]
+}
+
+func (d *declarations) all() []*unstructured.Unstructured {
+	d.Lock()
+	defer d.Unlock()
+	objs := make([]*unstructured.Unstructured, 0, len(d.objs))
+	for _, obj := range d.objs {
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+func (d *declarations) eventHandler() cache.ResourceEventHandler {
+	asUnstructured := func(obj any) *unstructured.Unstructured {
+		if t, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = t.Obj
+		}
+		u, _ := obj.(*unstructured.Unstructured)
+		return u
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if u := asUnstructured(obj); u != nil {
+				d.set(u)
+			}
+		},
+		UpdateFunc: func(_, obj any) {
+			if u := asUnstructured(obj); u != nil {
+				d.set(u)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if u := asUnstructured(obj); u != nil {
+				d.remove(u)
+			}
+		},
+	}
+}
+
+// declaredIntercept returns the intercept declared by the given InterceptSpec, or an error that
+// describes why the declaration is invalid.
+func declaredIntercept(obj *unstructured.Unstructured) (*rpc.DeclaredIntercept, error) {
+	um, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	var spec interceptSpecSpec
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(um, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Workload == "" {
+		return nil, errors.New("spec.workload must not be empty")
+	}
+	if _, err = matcher.ParseHeaderExpr(spec.HttpHeaders); err != nil {
+		return nil, fmt.Errorf("spec.httpHeaders: %w", err)
+	}
+	return &rpc.DeclaredIntercept{
+		Name:        obj.GetName(),
+		Namespace:   obj.GetNamespace(),
+		Workload:    spec.Workload,
+		Service:     spec.Service,
+		Port:        spec.Port,
+		HttpHeaders: spec.HttpHeaders,
+		Owner:       spec.Owner,
+	}, nil
+}
+
+// mayAdopt returns true if the given client is allowed to adopt the given declared intercept.
+func mayAdopt(di *rpc.DeclaredIntercept, client *rpc.ClientInfo) bool {
+	return di.Owner == "" || di.Owner == client.KubeUser || di.Owner == client.Name
+}
+
+// declarationStatus returns the status of the given InterceptSpec, given the intercept that adopted it,
+// if any.
+func declarationStatus(obj *unstructured.Unstructured, adopter *rpc.InterceptInfo) map[string]string {
+	if _, err := declaredIntercept(obj); err != nil {
+		return map[string]string{"phase": declarationInvalid, "message": err.Error()}
+	}
+	if adopter != nil {
+		return map[string]string{"phase": declarationAdopted, "interceptId": adopter.Id, "client": adopter.Spec.Client}
+	}
+	return map[string]string{"phase": declarationReady}
+}
+
+// runInterceptSpecs watches the InterceptSpec resources in the managed namespaces, and the intercepts
+// that adopt them, and keeps the status of the resources up to date.
+func (s *service) runInterceptSpecs(ctx context.Context) error {
+	di := agentconfig.GetDynamicInterface(ctx)
+	if di == nil {
+		return errors.New("unable to watch InterceptSpec resources: no dynamic interface available")
+	}
+	nss := managerutil.GetEnv(ctx).ManagedNamespaces
+	if len(nss) == 0 {
+		nss = []string{""}
+	}
+	for _, ns := range nss {
+		f := dynamicinformer.NewFilteredDynamicSharedInformerFactory(di, 0, ns, nil)
+		if _, err := f.ForResource(interceptSpecGVR).Informer().AddEventHandler(s.declarations.eventHandler()); err != nil {
+			return err
+		}
+		f.Start(ctx.Done())
+		f.WaitForCacheSync(ctx.Done())
+	}
+
+	interceptsCh := s.state.WatchIntercepts(ctx, func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.Spec.Declaration != "" && ii.Disposition != rpc.InterceptDispositionType_REMOVED
+	})
+	var intercepts map[string]*rpc.InterceptInfo
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case snapshot, ok := <-interceptsCh:
+			if !ok {
+				return nil
+			}
+			intercepts = snapshot.State
+		case <-s.declarations.changed:
+		}
+		s.reconcileDeclarations(ctx, di, intercepts)
+	}
+}
+
+// reconcileDeclarations updates the status of each InterceptSpec that doesn't reflect its validity or
+// the intercept that adopted it. Only the leader updates the resources.
+func (s *service) reconcileDeclarations(ctx context.Context, di dynamic.Interface, intercepts map[string]*rpc.InterceptInfo) {
+	if s.self.isStandby() {
+		return
+	}
+	adopters := make(map[string]*rpc.InterceptInfo, len(intercepts))
+	for _, ii := range intercepts {
+		adopters[declarationKey(ii.Spec.Namespace, ii.Spec.Declaration)] = ii
+	}
+	for _, obj := range s.declarations.all() {
+		want := declarationStatus(obj, adopters[declarationKey(obj.GetNamespace(), obj.GetName())])
+		if have, _, _ := unstructured.NestedStringMap(obj.Object, "status"); maps.Equal(have, want) {
+			continue
+		}
+		obj = obj.DeepCopy()
+		if err := unstructured.SetNestedStringMap(obj.Object, want, "status"); err != nil {
+			dlog.Errorf(ctx, "unable to set the status of InterceptSpec %s.%s: %v", obj.GetName(), obj.GetNamespace(), err)
+			continue
+		}
+		if _, err := di.Resource(interceptSpecGVR).Namespace(obj.GetNamespace()).UpdateStatus(ctx, obj, meta.UpdateOptions{}); err != nil {
+			dlog.Errorf(ctx, "unable to update the status of InterceptSpec %s.%s: %v", obj.GetName(), obj.GetNamespace(), err)
+		}
+	}
+}
+
+// adoptableDeclaration returns the intercept declared by the InterceptSpec with the given namespace and
+// name, provided that the given client may adopt it.
+func (s *service) adoptableDeclaration(namespace, name string, client *rpc.ClientInfo) (*rpc.DeclaredIntercept, error) {
+	if s.declarations == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the traffic-manager doesn't reconcile InterceptSpec resources")
+	}
+	obj := s.declarations.get(namespace, name)
+	if obj == nil {
+		return nil, status.Errorf(codes.NotFound, "InterceptSpec %s.%s not found", name, namespace)
+	}
+	di, err := declaredIntercept(obj)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "InterceptSpec %s.%s is invalid: %v", name, namespace, err)
+	}
+	if !mayAdopt(di, client) {
+		return nil, status.Errorf(codes.PermissionDenied, "InterceptSpec %s.%s is owned by %s", name, namespace, di.Owner)
+	}
+	return di, nil
+}
+
+func (s *service) GetDeclaredIntercept(ctx context.Context, rq *rpc.GetDeclaredInterceptRequest) (*rpc.DeclaredIntercept, error) {
+	ctx = managerutil.WithSessionInfo(ctx, rq.GetSession())
+	dlog.Debugf(ctx, "GetDeclaredIntercept %s called", rq.Name)
+	client := s.state.GetClient(rq.GetSession().GetSessionId())
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", rq.GetSession().GetSessionId())
+	}
+	ns := rq.Namespace
+	if ns == "" {
+		ns = client.Namespace
+	}
+	return s.adoptableDeclaration(ns, rq.Name, client)
+}
+
+// adoptDeclaration verifies that the intercept of the given spec may adopt the InterceptSpec that it
+// names, and applies the header conditions of that InterceptSpec to it. An InterceptSpec can only be
+// adopted by one intercept at a time.
+func (s *service) adoptDeclaration(sessionID string, spec *rpc.InterceptSpec) error {
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	di, err := s.adoptableDeclaration(spec.Namespace, spec.Declaration, client)
+	if err != nil {
+		return err
+	}
+	if spec.Agent != di.Workload {
+		return status.Errorf(codes.InvalidArgument, "InterceptSpec %s.%s declares an intercept of %s, not %s", di.Name, di.Namespace, di.Workload, spec.Agent)
+	}
+	adopters := s.state.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.Spec.Declaration == spec.Declaration && ii.Spec.Namespace == spec.Namespace && ii.Disposition != rpc.InterceptDispositionType_REMOVED
+	})
+	if len(adopters) > 0 {
+		clients := make([]string, 0, len(adopters))
+		for _, ii := range adopters {
+			clients = append(clients, ii.Spec.Client)
+		}
+		return status.Errorf(codes.AlreadyExists, "InterceptSpec %s.%s is already adopted by %s", di.Name, di.Namespace, strings.Join(clients, ", "))
+	}
+	spec.HttpHeaders = di.HttpHeaders
+	return nil
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func interceptSpecObject(name string, spec map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "telepresence.getambassador.io/v1alpha1",
+		"kind":       "InterceptSpec",
+		"metadata":   map[string]any{"name": name, "namespace": "preview"},
+		"spec":       spec,
+	}}
+}
+
+func Test_declaredIntercept(t *testing.T) {
+	di, err := declaredIntercept(interceptSpecObject("orders", map[string]any{
+		"workload":    "orders",
+		"port":        "http",
+		"httpHeaders": []any{"x-preview=pr-42"},
+		"owner":       "alice",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "orders", di.Name)
+	assert.Equal(t, "preview", di.Namespace)
+	assert.Equal(t, "orders", di.Workload)
+	assert.Equal(t, "http", di.Port)
+	assert.Equal(t, []string{"x-preview=pr-42"}, di.HttpHeaders)
+	assert.Equal(t, "alice", di.Owner)
+
+	_, err = declaredIntercept(interceptSpecObject("orders", map[string]any{"port": "http"}))
+	assert.ErrorContains(t, err, "spec.workload")
+	_, err = declaredIntercept(interceptSpecObject("orders", map[string]any{"workload": "orders", "httpHeaders": []any{"x-preview"}}))
+	assert.ErrorContains(t, err, "spec.httpHeaders")
+}
+
+func Test_mayAdopt(t *testing.T) {
+	client := &rpc.ClientInfo{Name: "alice@laptop", KubeUser: "alice@example.com"}
+	assert.True(t, mayAdopt(&rpc.DeclaredIntercept{}, client))
+	assert.True(t, mayAdopt(&rpc.DeclaredIntercept{Owner: "alice@example.com"}, client))
+	assert.True(t, mayAdopt(&rpc.DeclaredIntercept{Owner: "alice@laptop"}, client))
+	assert.False(t, mayAdopt(&rpc.DeclaredIntercept{Owner: "bob@example.com"}, client))
+}
+
+func Test_declarationStatus(t *testing.T) {
+	valid := interceptSpecObject("orders", map[string]any{"workload": "orders"})
+	assert.Equal(t, map[string]string{"phase": declarationReady}, declarationStatus(valid, nil))
+
+	adopter := &rpc.InterceptInfo{Id: "session:orders", Spec: &rpc.InterceptSpec{Client: "alice@laptop"}}
+	assert.Equal(t, map[string]string{
+		"phase":       declarationAdopted,
+		"interceptId": "session:orders",
+		"client":      "alice@laptop",
+	}, declarationStatus(valid, adopter))
+
+	invalid := interceptSpecObject("orders", map[string]any{})
+	assert.Equal(t, declarationInvalid, declarationStatus(invalid, nil)["phase"])
+}
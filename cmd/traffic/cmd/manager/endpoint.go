@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// checkExternalEndpoint checks that the given endpoint, which the workstations use to reach a UDP port of the
+// traffic-manager, is given as host:port and isn't an address in the cluster network. The pod IP can't be the
+// default, because the workstations reach it through the TUN-device, i.e. through the very gRPC tunnel that
+// the endpoint is meant to bypass.
+func checkExternalEndpoint(kind, endpoint string, env *managerutil.Env) error {
+	if endpoint == "" {
+		return fmt.Errorf("a %s endpoint that the workstations can reach without the cluster network is required", kind)
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid %s endpoint %q: %w", kind, endpoint, err)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.Equal(env.PodIP) {
+			return fmt.Errorf("invalid %s endpoint %q: the pod IP is only reachable through the cluster network", kind, endpoint)
+		}
+		for _, sn := range env.PodCIDRs {
+			if sn.Contains(ip) {
+				return fmt.Errorf("invalid %s endpoint %q: it is in the pod subnet %s", kind, endpoint, sn)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+	"strings"
+
+	dns2 "github.com/miekg/dns"
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+// lookupExternalName answers A, AAAA, and CNAME queries for names of ExternalName services the same way
+// that the cluster's DNS does, i.e. with a CNAME record for the service's external name, followed by the
+// records of the external name, as resolved by the traffic-manager. The client uses the CNAME record
+// to detect that the addresses must be reached from the cluster. The returned bool is false when the
+// request isn't for an ExternalName service.
+func (s *service) lookupExternalName(ctx context.Context, request *rpc.DNSRequest) (dnsproxy.RRs, int, bool) {
+	qType := uint16(request.Type)
+	switch qType {
+	case dns2.TypeA, dns2.TypeAAAA, dns2.TypeCNAME:
+	default:
+		return nil, 0, false
+	}
+	name := request.Name
+	if strings.Count(name, ".") == 1 {
+		// Single label names are services in the client's namespace.
+		client := s.state.GetClient(request.GetSession().GetSessionId())
+		if client == nil {
+			return nil, 0, false
+		}
+		name += client.Namespace + "."
+	}
+	target := externalName(ctx, name, s.ClusterInfo().ClusterDomain())
+	if target == "" {
+		return nil, 0, false
+	}
+	rrs := dnsproxy.RRs{&dns2.CNAME{
+		Hdr:    dnsproxy.NewHeader(request.Name, dns2.TypeCNAME),
+		Target: target,
+	}}
+	if qType == dns2.TypeCNAME {
+		return rrs, dns2.RcodeSuccess, true
+	}
+	trs, rCode, err := dnsproxy.Lookup(ctx, qType, target)
+	if err != nil {
+		dlog.Debugf(ctx, "LookupDNS of ExternalName target %s: %v", target, err)
+	}
+	return append(rrs, trs...), rCode, true
+}
+
+// externalName returns the fully qualified external name of the ExternalName service that the given
+// name designates, or an empty string when it doesn't designate such a service. The name must be
+// fully qualified and on the form <service>.<namespace>., <service>.<namespace>.svc., or
+// <service>.<namespace>.svc.<cluster domain>.
+func externalName(ctx context.Context, name, clusterDomain string) string {
+	ls := clusterNameLabels(name, clusterDomain)
+	if len(ls) == 3 && ls[2] == "svc" {
+		ls = ls[:2]
+	}
+	if len(ls) != 2 {
+		return ""
+	}
+	svcName, ns := ls[0], ls[1]
+	f := informer.GetFactory(ctx, ns)
+	if f == nil {
+		return ""
+	}
+	svc, err := f.Core().V1().Services().Lister().Services(ns).Get(svcName)
+	if err != nil || svc.Spec.Type != core.ServiceTypeExternalName || svc.Spec.ExternalName == "" {
+		return ""
+	}
+	return dns2.Fqdn(svc.Spec.ExternalName)
+}
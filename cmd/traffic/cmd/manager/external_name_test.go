@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+func Test_externalName(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset(
+		&core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       core.ServiceSpec{Type: core.ServiceTypeExternalName, ExternalName: "db.example.com"},
+		},
+		&core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+			Spec:       core.ServiceSpec{Type: core.ServiceTypeClusterIP, Selector: map[string]string{"app": "echo"}},
+		},
+	))
+	ctx = informer.WithFactory(ctx, "")
+	f := informer.GetFactory(ctx, "")
+	f.Core().V1().Services().Informer()
+	f.Start(ctx.Done())
+	f.WaitForCacheSync(ctx.Done())
+
+	for name, want := range map[string]string{
+		"db.default.":                   "db.example.com.",
+		"db.default.svc.":               "db.example.com.",
+		"db.default.svc.cluster.local.": "db.example.com.",
+		"db.other.svc.cluster.local.":   "",
+		"echo.default.":                 "",
+		"db.":                           "",
+		"db.example.com.":               "",
+	} {
+		assert.Equal(t, want, externalName(ctx, name, "cluster.local."), name)
+	}
+}
@@ -3,23 +3,20 @@ package manager
 import (
 	"context"
 
-	"google.golang.org/grpc/health/grpc_health_v1"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 )
 
-// Perhaps replace this health check stuff with something more normal, i.e.
-// based on HTTP, since we'll likely be running the Injector as an HTTP service
-// from this same executable anyhow.
-
-type HealthChecker struct{}
-
-func (s *HealthChecker) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
-	return &grpc_health_v1.HealthCheckResponse{
-		Status: grpc_health_v1.HealthCheckResponse_SERVING,
-	}, nil
-}
-
-func (s *HealthChecker) Watch(_ *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
-	return stream.Send(&grpc_health_v1.HealthCheckResponse{
-		Status: grpc_health_v1.HealthCheckResponse_SERVING,
-	})
+// newHealth returns the Health that reports the readiness of the traffic-manager. The components that
+// it waits for depend on the configuration: the workload watcher and the agent-injector webhook are
+// only started when the agent-injector is enabled, and a standby isn't ready until it's the leader.
+func newHealth(ctx context.Context) *managerutil.Health {
+	var components []string
+	if managerutil.AgentInjectorEnabled(ctx) && managerutil.GetAgentImageRetriever(ctx) != nil {
+		components = append(components, managerutil.WorkloadWatcherComponent, managerutil.AgentInjectorComponent)
+	}
+	if managerutil.GetEnv(ctx).LeaderElection {
+		components = append(components, managerutil.LeaderComponent)
+	}
+	return managerutil.NewHealth([]string{rpc.Manager_ServiceDesc.ServiceName}, components...)
 }
@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// WatchInterceptEvents sends an event for each change of the intercepts that match the request. Unlike
+// WatchIntercepts, which sends the full snapshot on every change, only the changed intercepts are sent.
+func (s *service) WatchInterceptEvents(rq *rpc.InterceptEventsRequest, stream rpc.Manager_WatchInterceptEventsServer) error {
+	ctx := stream.Context()
+	dlog.Debugf(ctx, "WatchInterceptEvents called: namespaces=%v, workload=%q, user=%q", rq.Namespaces, rq.Workload, rq.User)
+
+	snapshotCh := s.state.WatchIntercepts(ctx, func(_ string, ii *rpc.InterceptInfo) bool {
+		return interceptEventsMatch(rq, ii)
+	})
+	var known map[string]*rpc.InterceptInfo
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case snapshot, ok := <-snapshotCh:
+			if !ok {
+				return nil
+			}
+			var events []*rpc.InterceptEvent
+			events, known = interceptEvents(known, snapshot.State, s.clock.Now())
+			for _, ev := range events {
+				if err := stream.Send(ev); err != nil {
+					dlog.Debugf(ctx, "WatchInterceptEvents encountered a write error: %v", err)
+					return err
+				}
+			}
+		}
+	}
+}
+
+// interceptEventsMatch returns true if the given intercept is selected by the given request.
+func interceptEventsMatch(rq *rpc.InterceptEventsRequest, ii *rpc.InterceptInfo) bool {
+	spec := ii.Spec
+	return (len(rq.Namespaces) == 0 || slices.Contains(rq.Namespaces, spec.Namespace)) &&
+		(rq.Workload == "" || rq.Workload == spec.Agent) &&
+		(rq.User == "" || rq.User == spec.Client)
+}
+
+// interceptEvents compares the intercepts of a new snapshot with the previously known intercepts, and
+// returns the events that describe the changes, sorted by intercept ID, together with the new set of
+// known intercepts. An intercept with disposition REMOVED is considered removed. A nil known map means
+// that this is the first snapshot.
+func interceptEvents(known, current map[string]*rpc.InterceptInfo, now time.Time) ([]*rpc.InterceptEvent, map[string]*rpc.InterceptInfo) {
+	first := known == nil
+	ts := timestamppb.New(now)
+	next := make(map[string]*rpc.InterceptInfo, len(current))
+	var events []*rpc.InterceptEvent
+	for id, ii := range current {
+		if ii.Disposition == rpc.InterceptDispositionType_REMOVED {
+			continue
+		}
+		next[id] = ii
+		old, ok := known[id]
+		switch {
+		case !ok:
+			reason := "intercept created"
+			if first {
+				reason = "intercept exists"
+			}
+			events = append(events, &rpc.InterceptEvent{Type: rpc.InterceptEvent_ADDED_UNSPECIFIED, Intercept: ii, Reason: reason, Time: ts})
+		case !proto.Equal(old, ii):
+			events = append(events, &rpc.InterceptEvent{Type: rpc.InterceptEvent_UPDATED, Intercept: ii, Reason: updateReason(old, ii), Time: ts})
+		}
+	}
+	for id, old := range known {
+		if _, ok := next[id]; !ok {
+			reason := "intercept removed"
+			if ii, ok := current[id]; ok {
+				// Still present, but with disposition REMOVED. It's the most recent state.
+				old = ii
+			}
+			if old.Message != "" {
+				reason += ": " + old.Message
+			}
+			events = append(events, &rpc.InterceptEvent{Type: rpc.InterceptEvent_REMOVED, Intercept: old, Reason: reason, Time: ts})
+		}
+	}
+	slices.SortFunc(events, func(a, b *rpc.InterceptEvent) int {
+		return strings.Compare(a.Intercept.Id, b.Intercept.Id)
+	})
+	return events, next
+}
+
+// updateReason describes the difference between two states of an intercept.
+func updateReason(old, ii *rpc.InterceptInfo) string {
+	var changes []string
+	if old.Disposition != ii.Disposition {
+		changes = append(changes, fmt.Sprintf("disposition changed from %s to %s", old.Disposition, ii.Disposition))
+	}
+	if old.PodName != ii.PodName {
+		changes = append(changes, fmt.Sprintf("intercepted pod changed from %q to %q", old.PodName, ii.PodName))
+	}
+	reason := "intercept modified"
+	if len(changes) > 0 {
+		reason = strings.Join(changes, "; ")
+	}
+	if old.Message != ii.Message && ii.Message != "" {
+		reason += ": " + ii.Message
+	}
+	return reason
+}
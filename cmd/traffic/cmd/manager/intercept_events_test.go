@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_interceptEvents(t *testing.T) {
+	ii := func(id string, d rpc.InterceptDispositionType, msg string) *rpc.InterceptInfo {
+		return &rpc.InterceptInfo{
+			Id:          id,
+			Spec:        &rpc.InterceptSpec{Name: id, Agent: "echo", Namespace: "default", Client: "alice@host"},
+			Disposition: d,
+			Message:     msg,
+		}
+	}
+	now := time.Now()
+
+	events, known := interceptEvents(nil, map[string]*rpc.InterceptInfo{
+		"a": ii("a", rpc.InterceptDispositionType_ACTIVE, ""),
+	}, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, rpc.InterceptEvent_ADDED_UNSPECIFIED, events[0].Type)
+	assert.Equal(t, "intercept exists", events[0].Reason)
+	assert.Equal(t, now.UnixNano(), events[0].Time.AsTime().UnixNano())
+
+	events, known = interceptEvents(known, map[string]*rpc.InterceptInfo{
+		"a": ii("a", rpc.InterceptDispositionType_AGENT_ERROR, "agent crashed"),
+		"b": ii("b", rpc.InterceptDispositionType_WAITING, ""),
+	}, now)
+	require.Len(t, events, 2)
+	assert.Equal(t, rpc.InterceptEvent_UPDATED, events[0].Type)
+	assert.Equal(t, "disposition changed from ACTIVE to AGENT_ERROR: agent crashed", events[0].Reason)
+	assert.Equal(t, rpc.InterceptEvent_ADDED_UNSPECIFIED, events[1].Type)
+	assert.Equal(t, "intercept created", events[1].Reason)
+
+	// Unchanged intercepts yield no events.
+	events, known = interceptEvents(known, map[string]*rpc.InterceptInfo{
+		"a": ii("a", rpc.InterceptDispositionType_AGENT_ERROR, "agent crashed"),
+		"b": ii("b", rpc.InterceptDispositionType_WAITING, ""),
+	}, now)
+	assert.Empty(t, events)
+
+	// An intercept is removed when its disposition is REMOVED, and when it's deleted.
+	events, known = interceptEvents(known, map[string]*rpc.InterceptInfo{
+		"b": ii("b", rpc.InterceptDispositionType_REMOVED, "client left"),
+	}, now)
+	require.Len(t, events, 2)
+	assert.Equal(t, rpc.InterceptEvent_REMOVED, events[0].Type)
+	assert.Equal(t, "intercept removed: agent crashed", events[0].Reason)
+	assert.Equal(t, rpc.InterceptEvent_REMOVED, events[1].Type)
+	assert.Equal(t, "intercept removed: client left", events[1].Reason)
+	assert.True(t, proto.Equal(ii("b", rpc.InterceptDispositionType_REMOVED, "client left"), events[1].Intercept))
+	assert.Empty(t, known)
+
+	events, _ = interceptEvents(known, map[string]*rpc.InterceptInfo{}, now)
+	assert.Empty(t, events)
+}
+
+func Test_interceptEventsMatch(t *testing.T) {
+	ii := &rpc.InterceptInfo{Spec: &rpc.InterceptSpec{Agent: "echo", Namespace: "default", Client: "alice@host"}}
+	assert.True(t, interceptEventsMatch(&rpc.InterceptEventsRequest{}, ii))
+	assert.True(t, interceptEventsMatch(&rpc.InterceptEventsRequest{Namespaces: []string{"other", "default"}, Workload: "echo", User: "alice@host"}, ii))
+	assert.False(t, interceptEventsMatch(&rpc.InterceptEventsRequest{Namespaces: []string{"other"}}, ii))
+	assert.False(t, interceptEventsMatch(&rpc.InterceptEventsRequest{Workload: "hello"}, ii))
+	assert.False(t, interceptEventsMatch(&rpc.InterceptEventsRequest{User: "bob@host"}, ii))
+}
@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// LeaseName is the name of the Lease that the replicas of the traffic-manager use for leader election.
+const LeaseName = "traffic-manager"
+
+// The leader election timing used when none is configured. These are the defaults used by the
+// Kubernetes controller manager.
+const (
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+)
+
+var (
+	// errLeadershipLost is returned by runLeaderElection when the traffic-manager loses its leadership.
+	errLeadershipLost = errors.New("leadership lost")
+
+	// errStandby is returned to clients and agents that attempt to arrive at a standby traffic-manager.
+	// They will retry, and eventually arrive at the leader.
+	errStandby = status.Error(codes.Unavailable, "traffic-manager is a standby replica")
+)
+
+// runLeaderElection campaigns for the leadership among the replicas of the traffic-manager, and calls
+// lead once the leadership is acquired. The context passed to lead is cancelled when the leadership is
+// lost. Until then, the traffic-manager is a standby that reports that it isn't ready and refuses new
+// sessions, so that clients and agents all arrive at the leader.
+//
+// A leader that loses its leadership returns errLeadershipLost. It must then be restarted, because the
+// sessions that it holds will be recreated by their clients and agents with the new leader.
+func (s *service) runLeaderElection(ctx context.Context, lead func(context.Context) error) error {
+	env := managerutil.GetEnv(ctx)
+	id, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	leadErr := make(chan error, 1)
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  meta.ObjectMeta{Name: LeaseName, Namespace: env.ManagerNamespace},
+			Client:     k8sapi.GetK8sInterface(ctx).CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: id},
+		},
+		LeaseDuration:   durationOrDefault(env.LeaderElectionLeaseDuration, defaultLeaseDuration),
+		RenewDeadline:   durationOrDefault(env.LeaderElectionRenewDeadline, defaultRenewDeadline),
+		RetryPeriod:     durationOrDefault(env.LeaderElectionRetryPeriod, defaultRetryPeriod),
+		ReleaseOnCancel: true,
+		Name:            LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				dlog.Infof(ctx, "%s acquired the leadership", id)
+				s.standby.Store(false)
+				managerutil.SetReady(ctx, managerutil.LeaderComponent, true)
+				if err := lead(ctx); err != nil {
+					leadErr <- err
+					cancel()
+				}
+			},
+			OnStoppedLeading: func() {
+				dlog.Infof(ctx, "%s is no longer the leader", id)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					dlog.Infof(ctx, "%s is the leader", identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	dlog.Infof(ctx, "%s is a standby until it acquires the leadership", id)
+	le.Run(ctx)
+	select {
+	case err = <-leadErr:
+		return err
+	default:
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errLeadershipLost
+}
+
+// isStandby returns true when leader election is enabled and this traffic-manager isn't the leader.
+func (s *service) isStandby() bool {
+	return s.standby.Load()
+}
+
+func durationOrDefault(d, dflt time.Duration) time.Duration {
+	if d == 0 {
+		return dflt
+	}
+	return d
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func leaderTestContext(t *testing.T) context.Context {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset())
+	return managerutil.WithEnv(ctx, &managerutil.Env{
+		ManagerNamespace:            "ambassador",
+		LeaderElection:              true,
+		LeaderElectionLeaseDuration: 3 * time.Second,
+		LeaderElectionRenewDeadline: 2 * time.Second,
+		LeaderElectionRetryPeriod:   100 * time.Millisecond,
+	})
+}
+
+func TestRunLeaderElection(t *testing.T) {
+	ctx, cancel := context.WithCancel(leaderTestContext(t))
+	defer cancel()
+
+	s := &service{}
+	s.standby.Store(true)
+	s.self = s
+
+	_, err := s.ArriveAsClient(ctx, &rpc.ClientInfo{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	leading := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- s.runLeaderElection(ctx, func(ctx context.Context) error {
+			close(leading)
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	select {
+	case <-leading:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for leadership")
+	}
+	assert.False(t, s.isStandby())
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for leader election to end")
+	}
+}
+
+func TestRunLeaderElection_leadError(t *testing.T) {
+	ctx := leaderTestContext(t)
+	s := &service{}
+	s.standby.Store(true)
+	s.self = s
+
+	leadErr := errors.New("unable to lead")
+	err := s.runLeaderElection(ctx, func(context.Context) error {
+		return leadErr
+	})
+	require.ErrorIs(t, err, leadErr)
+}
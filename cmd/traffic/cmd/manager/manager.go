@@ -2,6 +2,7 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -17,7 +18,10 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -25,12 +29,17 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/audit"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
 )
 
@@ -68,6 +77,7 @@ func MainWithEnv(ctx context.Context) (err error) {
 	dlog.Infof(ctx, "%s %s [uid:%d,gid:%d]", DisplayName, version.Version, os.Getuid(), os.Getgid())
 
 	env := managerutil.GetEnv(ctx)
+	ctx = tunnel.WithIdleConfigs(ctx, env.TunnelIdleConfigs())
 	var tracer *tracing.TraceServer
 
 	if env.TracingGrpcPort != 0 {
@@ -93,6 +103,19 @@ func MainWithEnv(ctx context.Context) (err error) {
 		return fmt.Errorf("unable to create the Kubernetes Interface from InClusterConfig: %w", err)
 	}
 	ctx = k8sapi.WithK8sInterface(ctx, ki)
+	di, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create the Kubernetes dynamic Interface from InClusterConfig: %w", err)
+	}
+	ctx = agentconfig.WithDynamicInterface(ctx, di)
+	osc, err := openshift.NewRESTClient(ctx, cfg)
+	if err != nil {
+		// DeploymentConfigs are optional, so this isn't fatal.
+		dlog.Errorf(ctx, "unable to create the OpenShift apps REST client: %v", err)
+	} else if osc != nil {
+		dlog.Info(ctx, "OpenShift DeploymentConfigs are supported")
+		ctx = openshift.WithRESTClient(ctx, osc)
+	}
 
 	// Ensure that the manager has access to shard informer factories for all relevant namespaces.
 	//
@@ -102,9 +125,11 @@ func MainWithEnv(ctx context.Context) (err error) {
 	mgrFactory := false
 	if len(env.ManagedNamespaces) == 0 {
 		ctx = informer.WithFactory(ctx, "")
+		ctx = openshift.WithInformer(ctx, "")
 	} else {
 		for _, ns := range env.ManagedNamespaces {
 			ctx = informer.WithFactory(ctx, ns)
+			ctx = openshift.WithInformer(ctx, ns)
 		}
 		if !slices.Contains(env.ManagedNamespaces, env.ManagerNamespace) {
 			mgrFactory = true
@@ -112,6 +137,23 @@ func MainWithEnv(ctx context.Context) (err error) {
 		}
 	}
 
+	if env.ManagedNamespaceSelector != nil {
+		if len(env.ManagedNamespaces) > 0 {
+			return errors.New("MANAGED_NAMESPACES and MANAGED_NAMESPACE_SELECTOR are mutually exclusive")
+		}
+		selector, err := meta.LabelSelectorAsSelector(env.ManagedNamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("invalid MANAGED_NAMESPACE_SELECTOR: %w", err)
+		}
+		// The namespace watcher uses the cluster-wide informer factory, so it must be created before
+		// the factory is started.
+		nsWatcher, err := namespaces.NewWatcher(ctx, selector)
+		if err != nil {
+			return fmt.Errorf("unable to watch namespaces: %w", err)
+		}
+		ctx = namespaces.WithWatcher(ctx, nsWatcher)
+	}
+
 	var injectorCertGetter mutator.InjectorCertGetter
 	if managerutil.AgentInjectorEnabled(ctx) {
 		// The GetInjectorCertGetter and the mutator.Load both create SharedInformer instances
@@ -130,11 +172,21 @@ func MainWithEnv(ctx context.Context) (err error) {
 		f.WaitForCacheSync(ctx.Done())
 	}
 
+	auditLogger, err := audit.LoadLogger(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to initialize audit logging: %w", err)
+	}
+	ctx = audit.WithLogger(ctx, auditLogger)
+
 	mgr, g, err := NewServiceFunc(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to initialize traffic manager: %w", err)
 	}
 
+	if auditLogger != nil {
+		g.Go("audit", auditLogger.Run)
+	}
+
 	g.Go("cli-config", mgr.runConfigWatcher)
 
 	// Serve HTTP (including gRPC)
@@ -142,17 +194,34 @@ func MainWithEnv(ctx context.Context) (err error) {
 
 	g.Go("prometheus", mgr.servePrometheus)
 
-	if managerutil.AgentInjectorEnabled(ctx) {
-		g.Go("agent-injector", func(ctx context.Context) error {
-			if managerutil.GetAgentImageRetriever(ctx) == nil {
-				return nil
-			}
-			return mutator.ServeMutator(ctx, injectorCertGetter)
+	// The agent-injector, and the watchers that keep the agent configs up to date, are only run by
+	// the leader when leader election is enabled. A standby has its informers synced so that it's
+	// ready to take over.
+	injector := func(ctx context.Context) error {
+		if !managerutil.AgentInjectorEnabled(ctx) || managerutil.GetAgentImageRetriever(ctx) == nil {
+			return nil
+		}
+		go mgr.restoreReplacedContainers(ctx)
+		return mutator.ServeMutator(ctx, injectorCertGetter)
+	}
+	if env.LeaderElection {
+		g.Go("leader-election", func(ctx context.Context) error {
+			return mgr.runLeaderElection(ctx, injector)
 		})
+	} else if managerutil.AgentInjectorEnabled(ctx) {
+		g.Go("agent-injector", injector)
 	}
 
 	g.Go("session-gc", mgr.runSessionGCLoop)
 
+	if env.InterceptSpecsEnabled {
+		g.Go("intercept-specs", mgr.runInterceptSpecs)
+	}
+
+	if env.QUICPort != 0 {
+		g.Go("quic", mgr.serveQUIC)
+	}
+
 	if tracer != nil {
 		g.Go("tracer-grpc", func(c context.Context) error {
 			return tracer.ServeGrpc(c, env.TracingGrpcPort)
@@ -230,6 +299,21 @@ func (s *service) servePrometheus(ctx context.Context) error {
 	newGaugeFunc("tunnel_count", "Number of tunnels", s.state.CountTunnels)
 	newCounterFunc("tunnel_ingress_bytes", "Number of bytes tunneled from clients", s.state.CountTunnelIngress)
 	newCounterFunc("tunnel_egress_bytes", "Number bytes tunneled to clients", s.state.CountTunnelEgress)
+	newGaugeFunc("workload_event_queue_depth", "Number of workload events waiting to be distributed to clients",
+		s.state.CountPendingWorkloadEvents)
+
+	if m := mutator.GetMap(ctx); m != nil {
+		newGaugeFunc("watcher_queue_depth", "Number of pending tasks caused by the agent config watchers", func() int {
+			return m.QueueStats().Pending
+		})
+		newGaugeFunc("watcher_queue_max_shard_depth", "Number of pending tasks in the most loaded watcher shard", func() int {
+			return m.QueueStats().MaxShardPending
+		})
+		newCounterFunc("watcher_coalesced_tasks", "Number of watcher tasks that were replaced by a later task for the same object",
+			func() uint64 { return m.QueueStats().Coalesced })
+		newCounterFunc("watcher_processed_tasks", "Number of watcher tasks that have been processed",
+			func() uint64 { return m.QueueStats().Processed })
+	}
 
 	newGaugeFunc("active_http_request_count", "Number of currently served http requests", func() int {
 		return int(atomic.LoadInt32(&s.activeHttpRequests))
@@ -280,6 +364,15 @@ func (s *service) serveHTTP(ctx context.Context) error {
 
 	grpcHandler := grpc.NewServer(opts...)
 	httpHandler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/readyz" {
+			// A standby isn't ready, so that the traffic-manager service only routes to the leader.
+			if s.self.isStandby() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+			return
+		}
 		fmt.Fprintf(w, "Hello World from: %s\n", r.URL.Path)
 	}))
 
@@ -305,12 +398,39 @@ func (s *service) serveHTTP(ctx context.Context) error {
 		ErrorLog: lg,
 	}
 	s.self.RegisterServers(grpcHandler)
+	go func() {
+		// Tell health probes that the traffic-manager is going away as soon as the shutdown begins.
+		<-ctx.Done()
+		s.health.Shutdown()
+	}()
 	return sc.ListenAndServe(ctx, fmt.Sprintf("%s:%d", host, port))
 }
 
 func (s *service) RegisterServers(grpcHandler *grpc.Server) {
 	rpc.RegisterManagerServer(grpcHandler, s)
-	grpc_health_v1.RegisterHealthServer(grpcHandler, &HealthChecker{})
+	grpc_health_v1.RegisterHealthServer(grpcHandler, s.health)
+	reflection.Register(grpcHandler)
+}
+
+// replacedRestoreDelay is how long the traffic-manager waits before it restores the app containers that
+// were left replaced. It gives the clients of a previous traffic-manager, or of a previous leader, time to
+// arrive again and re-create their intercepts.
+const replacedRestoreDelay = time.Minute
+
+// restoreReplacedContainers restores the app containers that are marked as replaced in the agent configs
+// but that aren't intercepted once the clients had time to re-create their intercepts. It runs each time
+// the traffic-manager becomes the leader, so the intercepts that survive a failover are left untouched.
+func (s *service) restoreReplacedContainers(ctx context.Context) {
+	select {
+	case <-time.After(replacedRestoreDelay):
+	case <-ctx.Done():
+		return
+	}
+	mutator.GetMap(ctx).RestoreReplacedContainers(ctx, func(name, namespace string) bool {
+		return len(s.state.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+			return ii.Spec.Agent == name && ii.Spec.Namespace == namespace
+		})) > 0
+	})
 }
 
 func (s *service) runSessionGCLoop(ctx context.Context) error {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"runtime/debug"
 	"strings"
+	"sync"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
@@ -24,6 +25,47 @@ func (p ImageFromEnv) GetImage() string {
 	return string(p)
 }
 
+// ImageSetter is implemented by an ImageRetriever whose image can be replaced at runtime.
+type ImageSetter interface {
+	// SetImage replaces the image. An empty string restores the original image.
+	SetImage(ctx context.Context, img string) error
+}
+
+// ReloadableImage is an ImageRetriever that implements ImageSetter.
+type ReloadableImage struct {
+	sync.RWMutex
+	original string
+	current  string
+	onChange func(context.Context, string) error
+}
+
+// NewReloadableImage returns a ReloadableImage for the given image. The onChange function is called
+// when the image is replaced.
+func NewReloadableImage(img string, onChange func(context.Context, string) error) *ReloadableImage {
+	return &ReloadableImage{original: img, current: img, onChange: onChange}
+}
+
+func (r *ReloadableImage) GetImage() string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.current
+}
+
+func (r *ReloadableImage) SetImage(ctx context.Context, img string) error {
+	if img == "" {
+		img = r.original
+	}
+	r.Lock()
+	if img == r.current {
+		r.Unlock()
+		return nil
+	}
+	r.current = img
+	r.Unlock()
+	LogAgentImageInfo(ctx, img)
+	return r.onChange(ctx, img)
+}
+
 func LogAgentImageInfo(ctx context.Context, img string) {
 	dlog.Infof(ctx, "Using traffic-agent image %q", img)
 }
@@ -45,7 +87,7 @@ func WithAgentImageRetriever(ctx context.Context, onChange func(context.Context,
 		env.AgentRegistry = env.Registry
 	}
 	img := env.QualifiedAgentImage()
-	ctx = WithResolvedAgentImageRetriever(ctx, ImageFromEnv(img))
+	ctx = WithResolvedAgentImageRetriever(ctx, NewReloadableImage(img, onChange))
 	if img != "" {
 		LogAgentImageInfo(ctx, img)
 		if err := onChange(ctx, img); err != nil {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/log"
 )
 
 func WithSessionInfo(ctx context.Context, si *manager.SessionInfo) context.Context {
@@ -16,7 +17,7 @@ func WithSessionInfo(ctx context.Context, si *manager.SessionInfo) context.Conte
 
 func WithSessionID(ctx context.Context, sessionID string) context.Context {
 	ctx = context.WithValue(ctx, sessionContextKey{}, sessionID)
-	ctx = dlog.WithField(ctx, "session_id", sessionID)
+	ctx = dlog.WithField(ctx, log.FieldSession, sessionID)
 	return ctx
 }
 
@@ -3,14 +3,17 @@ package managerutil
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/datawire/dlib/derror"
 	"github.com/datawire/envconfig"
@@ -18,6 +21,8 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/log"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 // Env is the traffic-manager's environment. It does not define any defaults because all
@@ -31,6 +36,7 @@ import (
 type Env struct {
 	Registry            string        `env:"REGISTRY,                 parser=nonempty-string"`
 	LogLevel            string        `env:"LOG_LEVEL,                parser=logLevel"`
+	LogFormat           string        `env:"LOG_FORMAT,               parser=logFormat,   default="`
 	User                string        `env:"USER,                     parser=string,      default="`
 	ServerHost          string        `env:"SERVER_HOST,              parser=string,      default="`
 	ServerPort          uint16        `env:"SERVER_PORT,              parser=port-number"`
@@ -40,28 +46,82 @@ type Env struct {
 	ManagedNamespaces   []string      `env:"MANAGED_NAMESPACES,       parser=split-trim,  default="`
 	APIPort             uint16        `env:"AGENT_REST_API_PORT,      parser=port-number, default=0"`
 	AgentArrivalTimeout time.Duration `env:"AGENT_ARRIVAL_TIMEOUT,    parser=time.ParseDuration, default=0"`
+	WatcherShards       int           `env:"WATCHER_SHARDS,           parser=strconv.ParseInt, default=0"`
+	InterceptIdleTTL    time.Duration `env:"INTERCEPT_IDLE_TTL,       parser=time.ParseDuration, default=0"`
+
+	AgentConfigGCInterval time.Duration `env:"AGENT_CONFIG_GC_INTERVAL, parser=time.ParseDuration, default=0"`
+
+	ManagedNamespaceSelector *meta.LabelSelector `env:"MANAGED_NAMESPACE_SELECTOR, parser=json-label-selector, default="`
+
+	DialRateLimit   int `env:"DIAL_RATE_LIMIT,   parser=strconv.ParseInt, default=0"`
+	DialRateBurst   int `env:"DIAL_RATE_BURST,   parser=strconv.ParseInt, default=0"`
+	MaxPendingDials int `env:"MAX_PENDING_DIALS, parser=strconv.ParseInt, default=0"`
+
+	MaxInterceptsPerUser     int `env:"MAX_INTERCEPTS_PER_USER,     parser=strconv.ParseInt, default=0"`
+	MaxInterceptsPerWorkload int `env:"MAX_INTERCEPTS_PER_WORKLOAD, parser=strconv.ParseInt, default=0"`
+
+	InterceptSubjectAccessReview bool `env:"INTERCEPT_SUBJECT_ACCESS_REVIEW, parser=bool, default=false"`
+	InterceptSpecsEnabled        bool `env:"INTERCEPT_SPECS_ENABLED,         parser=bool, default=false"`
+	SessionSubjectAccessReview   bool `env:"SESSION_SUBJECT_ACCESS_REVIEW,   parser=bool, default=false"`
+
+	AuditSinks      []string `env:"AUDIT_SINKS,       parser=split-trim, default="`
+	AuditWebhookURL string   `env:"AUDIT_WEBHOOK_URL, parser=string,     default="`
+
+	LeaderElection              bool          `env:"LEADER_ELECTION,                parser=bool,               default=false"`
+	LeaderElectionLeaseDuration time.Duration `env:"LEADER_ELECTION_LEASE_DURATION, parser=time.ParseDuration, default=0"`
+	LeaderElectionRenewDeadline time.Duration `env:"LEADER_ELECTION_RENEW_DEADLINE, parser=time.ParseDuration, default=0"`
+	LeaderElectionRetryPeriod   time.Duration `env:"LEADER_ELECTION_RETRY_PERIOD,   parser=time.ParseDuration, default=0"`
 
 	TracingGrpcPort uint16            `env:"TRACING_GRPC_PORT,     parser=port-number,default=0"`
 	MaxReceiveSize  resource.Quantity `env:"GRPC_MAX_RECEIVE_SIZE, parser=quantity"`
 
+	TunnelTCPIdleTimeout       time.Duration `env:"TUNNEL_TCP_IDLE_TIMEOUT,        parser=time.ParseDuration, default=0"`
+	TunnelTCPKeepAliveInterval time.Duration `env:"TUNNEL_TCP_KEEPALIVE_INTERVAL,  parser=time.ParseDuration, default=0"`
+	TunnelTCPDeadPeerTimeout   time.Duration `env:"TUNNEL_TCP_DEAD_PEER_TIMEOUT,   parser=time.ParseDuration, default=0"`
+	TunnelUDPIdleTimeout       time.Duration `env:"TUNNEL_UDP_IDLE_TIMEOUT,        parser=time.ParseDuration, default=0"`
+	TunnelUDPKeepAliveInterval time.Duration `env:"TUNNEL_UDP_KEEPALIVE_INTERVAL,  parser=time.ParseDuration, default=0"`
+	TunnelUDPDeadPeerTimeout   time.Duration `env:"TUNNEL_UDP_DEAD_PEER_TIMEOUT,   parser=time.ParseDuration, default=0"`
+
+	QUICPort     uint16 `env:"QUIC_PORT,     parser=port-number, default=0"`
+	QUICEndpoint string `env:"QUIC_ENDPOINT, parser=string,      default="`
+
+	WireGuardPort     uint16 `env:"WIREGUARD_PORT,     parser=port-number, default=0"`
+	WireGuardSubnet   string `env:"WIREGUARD_SUBNET,   parser=string,      default="`
+	WireGuardEndpoint string `env:"WIREGUARD_ENDPOINT, parser=string,      default="`
+
 	PodCIDRStrategy string       `env:"POD_CIDR_STRATEGY, parser=nonempty-string"`
 	PodCIDRs        []*net.IPNet `env:"POD_CIDRS,         parser=split-ipnet, default="`
 	PodIP           net.IP       `env:"POD_IP,            parser=ip"`
 
-	AgentRegistry            string                      `env:"AGENT_REGISTRY,           parser=string,         default="`
-	AgentImageName           string                      `env:"AGENT_IMAGE_NAME,         parser=string,         default="`
-	AgentImageTag            string                      `env:"AGENT_IMAGE_TAG,          parser=string,         default="`
-	AgentImagePullPolicy     string                      `env:"AGENT_IMAGE_PULL_POLICY,  parser=string,         default="`
-	AgentImagePullSecrets    []core.LocalObjectReference `env:"AGENT_IMAGE_PULL_SECRETS, parser=json-local-refs,default="`
-	AgentInjectPolicy        agentconfig.InjectPolicy    `env:"AGENT_INJECT_POLICY,      parser=enable-policy,  default=Never"`
-	AgentAppProtocolStrategy k8sapi.AppProtocolStrategy  `env:"AGENT_APP_PROTO_STRATEGY, parser=app-proto-strategy, default=http2Probe"`
-	AgentLogLevel            string                      `env:"AGENT_LOG_LEVEL,          parser=logLevel,       defaultFrom=LogLevel"`
-	AgentPort                uint16                      `env:"AGENT_PORT,               parser=port-number,    default=0"`
-	AgentResources           *core.ResourceRequirements  `env:"AGENT_RESOURCES,          parser=json-resources, default="`
-	AgentInitResources       *core.ResourceRequirements  `env:"AGENT_INIT_RESOURCES,     parser=json-resources, default="`
-	AgentInjectorName        string                      `env:"AGENT_INJECTOR_NAME,      parser=string,         default="`
-	AgentInjectorSecret      string                      `env:"AGENT_INJECTOR_SECRET,    parser=string,         default="`
-	AgentSecurityContext     *core.SecurityContext       `env:"AGENT_SECURITY_CONTEXT,   parser=json-security-context, default="`
+	AgentRegistry            string                             `env:"AGENT_REGISTRY,           parser=string,         default="`
+	AgentImageName           string                             `env:"AGENT_IMAGE_NAME,         parser=string,         default="`
+	AgentImageTag            string                             `env:"AGENT_IMAGE_TAG,          parser=string,         default="`
+	AgentImagePullPolicy     string                             `env:"AGENT_IMAGE_PULL_POLICY,  parser=string,         default="`
+	AgentImagePullSecrets    []core.LocalObjectReference        `env:"AGENT_IMAGE_PULL_SECRETS, parser=json-local-refs,default="`
+	AgentInjectPolicy        agentconfig.InjectPolicy           `env:"AGENT_INJECT_POLICY,      parser=enable-policy,  default=Never"`
+	AgentAppProtocolStrategy k8sapi.AppProtocolStrategy         `env:"AGENT_APP_PROTO_STRATEGY, parser=app-proto-strategy, default=http2Probe"`
+	AgentLogLevel            string                             `env:"AGENT_LOG_LEVEL,          parser=logLevel,       defaultFrom=LogLevel"`
+	AgentPort                uint16                             `env:"AGENT_PORT,               parser=port-number,    default=0"`
+	AgentHealthPort          uint16                             `env:"AGENT_HEALTH_PORT,        parser=port-number,    default=0"`
+	AgentMetricsPort         uint16                             `env:"AGENT_METRICS_PORT,       parser=port-number,    default=0"`
+	AgentHealthProbe         string                             `env:"AGENT_HEALTH_PROBE,       parser=string,         default="`
+	AgentProbes              *agentconfig.Probes                `env:"AGENT_PROBES,             parser=json-probes,    default="`
+	AgentResources           *core.ResourceRequirements         `env:"AGENT_RESOURCES,          parser=json-resources, default="`
+	AgentInitResources       *core.ResourceRequirements         `env:"AGENT_INIT_RESOURCES,     parser=json-resources, default="`
+	AgentInjectorName        string                             `env:"AGENT_INJECTOR_NAME,      parser=string,         default="`
+	AgentInjectorSecret      string                             `env:"AGENT_INJECTOR_SECRET,    parser=string,         default="`
+	AgentSecurityContext     *core.SecurityContext              `env:"AGENT_SECURITY_CONTEXT,   parser=json-security-context, default="`
+	AgentNativeSidecar       bool                               `env:"AGENT_NATIVE_SIDECAR,     parser=bool,           default=false"`
+	AgentCapabilities        agentconfig.Capabilities           `env:"AGENT_CAPABILITIES,       parser=split-capabilities, default="`
+	AgentInitMode            agentconfig.InitMode               `env:"AGENT_INIT_MODE,          parser=init-mode,      default="`
+	AgentVolumes             *agentconfig.VolumeSettings        `env:"AGENT_VOLUMES,            parser=json-volumes,   default="`
+	AgentSATokenAudience     string                             `env:"AGENT_SA_TOKEN_AUDIENCE,  parser=string,         default="`
+	AgentSATokenExpiration   time.Duration                      `env:"AGENT_SA_TOKEN_EXPIRATION, parser=time.ParseDuration, default=0"`
+	AgentPortRange           *agentconfig.PortRange             `env:"AGENT_PORT_RANGE,         parser=port-range,     default="`
+	AgentPortStrategy        agentconfig.PortAllocationStrategy `env:"AGENT_PORT_STRATEGY,      parser=port-strategy,  default="`
+	AgentIstioCoexistence    bool                               `env:"AGENT_ISTIO_COEXISTENCE,  parser=bool,           default=false"`
+	AgentLinkerdCoexistence  bool                               `env:"AGENT_LINKERD_COEXISTENCE, parser=bool,          default=false"`
+	AgentTunnelCompression   string                             `env:"AGENT_TUNNEL_COMPRESSION, parser=compression,    default="`
 
 	ClientRoutingAlsoProxySubnets        []*net.IPNet  `env:"CLIENT_ROUTING_ALSO_PROXY_SUBNETS,  		parser=split-ipnet, default="`
 	ClientRoutingNeverProxySubnets       []*net.IPNet  `env:"CLIENT_ROUTING_NEVER_PROXY_SUBNETS, 		parser=split-ipnet, default="`
@@ -72,23 +132,66 @@ type Env struct {
 }
 
 func (e *Env) GeneratorConfig(qualifiedAgentImage string) (agentmap.GeneratorConfig, error) {
+	var sat *agentconfig.ServiceAccountToken
+	if e.AgentSATokenAudience != "" {
+		// The API server rejects a projected token with an expiry of less than ten minutes.
+		if e.AgentSATokenExpiration != 0 && e.AgentSATokenExpiration < 10*time.Minute {
+			return nil, fmt.Errorf("AGENT_SA_TOKEN_EXPIRATION %s is less than the minimum of 10m", e.AgentSATokenExpiration)
+		}
+		sat = &agentconfig.ServiceAccountToken{
+			Audience:          e.AgentSATokenAudience,
+			ExpirationSeconds: int64(e.AgentSATokenExpiration / time.Second),
+		}
+	}
+	var pa *agentconfig.PortAllocation
+	if e.AgentPortRange != nil || e.AgentPortStrategy != "" {
+		pa = &agentconfig.PortAllocation{Range: e.AgentPortRange, Strategy: e.AgentPortStrategy}
+	}
 	return &agentmap.BasicGeneratorConfig{
 		AgentPort:           e.AgentPort,
 		APIPort:             e.APIPort,
 		TracingPort:         e.TracingGrpcPort,
+		HealthPort:          e.AgentHealthPort,
+		MetricsPort:         e.AgentMetricsPort,
+		HealthProbe:         e.AgentHealthProbe,
+		Probes:              e.AgentProbes,
 		ManagerPort:         e.ServerPort,
 		QualifiedAgentImage: qualifiedAgentImage,
 		ManagerNamespace:    e.ManagerNamespace,
 		LogLevel:            e.AgentLogLevel,
+		LogFormat:           e.LogFormat,
 		InitResources:       e.AgentInitResources,
 		Resources:           e.AgentResources,
 		PullPolicy:          e.AgentImagePullPolicy,
 		PullSecrets:         e.AgentImagePullSecrets,
 		AppProtocolStrategy: e.AgentAppProtocolStrategy,
 		SecurityContext:     e.AgentSecurityContext,
+		NativeSidecar:       e.AgentNativeSidecar,
+		Capabilities:        e.AgentCapabilities,
+		InitMode:            e.AgentInitMode,
+		Volumes:             e.AgentVolumes,
+		ServiceAccountToken: sat,
+		PortAllocation:      pa,
+		TunnelCompression:   e.AgentTunnelCompression,
 	}, nil
 }
 
+// TunnelIdleConfigs returns the idle timeouts and keep-alive settings of the traffic-manager's tunnel endpoints.
+func (e *Env) TunnelIdleConfigs() tunnel.IdleConfigs {
+	return tunnel.IdleConfigs{
+		TCP: tunnel.IdleConfig{
+			Timeout:           e.TunnelTCPIdleTimeout,
+			KeepAliveInterval: e.TunnelTCPKeepAliveInterval,
+			DeadPeerTimeout:   e.TunnelTCPDeadPeerTimeout,
+		},
+		UDP: tunnel.IdleConfig{
+			Timeout:           e.TunnelUDPIdleTimeout,
+			KeepAliveInterval: e.TunnelUDPKeepAliveInterval,
+			DeadPeerTimeout:   e.TunnelUDPDeadPeerTimeout,
+		},
+	}
+}
+
 func (e *Env) QualifiedAgentImage() string {
 	img := e.AgentImageName
 	if img == "" {
@@ -106,6 +209,16 @@ func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 	fp := fhs[reflect.TypeOf("")]
 	fp.Parsers["string"] = fp.Parsers["possibly-empty-string"]
 	fp.Parsers["logLevel"] = fp.Parsers["logrus.ParseLevel"]
+	fp.Parsers["logFormat"] = func(str string) (any, error) {
+		return str, log.ValidFormat(str)
+	}
+	fp.Parsers["compression"] = func(str string) (any, error) {
+		c, err := tunnel.ParseCompression(str)
+		if err != nil || c == tunnel.NoCompression {
+			return "", err
+		}
+		return c.String(), nil
+	}
 	fp = fhs[reflect.TypeOf(true)]
 	fp.Parsers["bool"] = fp.Parsers["strconv.ParseBool"]
 	fhs[reflect.TypeOf(uint16(0))] = envconfig.FieldTypeHandler{
@@ -133,6 +246,14 @@ func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 		},
 		Setter: func(dst reflect.Value, src interface{}) { dst.SetInt(int64(src.(agentconfig.InjectPolicy))) },
 	}
+	fhs[reflect.TypeOf(agentconfig.InitMode(""))] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"init-mode": func(str string) (any, error) {
+				return agentconfig.ParseInitMode(str)
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.SetString(string(src.(agentconfig.InitMode))) },
+	}
 	fhs[reflect.TypeOf(resource.Quantity{})] = envconfig.FieldTypeHandler{
 		Parsers: map[string]func(string) (any, error){
 			"quantity": func(str string) (any, error) {
@@ -198,6 +319,24 @@ func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 		},
 		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.([]core.LocalObjectReference))) },
 	}
+	fhs[reflect.TypeOf(&meta.LabelSelector{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"json-label-selector": func(js string) (any, error) {
+				if js == "" {
+					return nil, nil
+				}
+				var ls *meta.LabelSelector
+				if err := json.Unmarshal([]byte(js), &ls); err != nil {
+					return nil, err
+				}
+				if _, err := meta.LabelSelectorAsSelector(ls); err != nil {
+					return nil, err
+				}
+				return ls, nil
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(*meta.LabelSelector))) },
+	}
 	fhs[reflect.TypeOf(&core.ResourceRequirements{})] = envconfig.FieldTypeHandler{
 		Parsers: map[string]func(string) (any, error){
 			"json-resources": func(js string) (any, error) {
@@ -213,6 +352,73 @@ func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 		},
 		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(*core.ResourceRequirements))) },
 	}
+	fhs[reflect.TypeOf(&agentconfig.Probes{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"json-probes": func(js string) (any, error) {
+				if js == "" {
+					return nil, nil
+				}
+				var ps *agentconfig.Probes
+				if err := json.Unmarshal([]byte(js), &ps); err != nil {
+					return nil, err
+				}
+				return ps, nil
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(*agentconfig.Probes))) },
+	}
+	fhs[reflect.TypeOf(&agentconfig.VolumeSettings{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"json-volumes": func(js string) (any, error) {
+				if js == "" {
+					return nil, nil
+				}
+				var vs *agentconfig.VolumeSettings
+				if err := json.Unmarshal([]byte(js), &vs); err != nil {
+					return nil, err
+				}
+				return vs, nil
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(*agentconfig.VolumeSettings))) },
+	}
+	fhs[reflect.TypeOf(&agentconfig.PortRange{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"port-range": func(str string) (any, error) {
+				if str == "" {
+					return (*agentconfig.PortRange)(nil), nil
+				}
+				return agentconfig.ParsePortRange(str)
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(*agentconfig.PortRange))) },
+	}
+	fhs[reflect.TypeOf(agentconfig.PortAllocationStrategy(""))] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"port-strategy": func(str string) (any, error) {
+				return agentconfig.ParsePortAllocationStrategy(str)
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) {
+			dst.SetString(string(src.(agentconfig.PortAllocationStrategy)))
+		},
+	}
+	fhs[reflect.TypeOf(agentconfig.Capabilities{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"split-capabilities": func(str string) (any, error) {
+				var cs agentconfig.Capabilities
+				for _, s := range strings.FieldsFunc(str, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+					c, err := agentconfig.ParseCapability(s)
+					if err != nil {
+						return nil, err
+					}
+					cs = append(cs, c)
+				}
+				return cs, nil
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(agentconfig.Capabilities))) },
+	}
 	fhs[reflect.TypeOf(&core.SecurityContext{})] = envconfig.FieldTypeHandler{
 		Parsers: map[string]func(string) (any, error){
 			"json-security-context": func(js string) (any, error) {
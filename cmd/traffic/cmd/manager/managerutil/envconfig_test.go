@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
@@ -81,6 +82,154 @@ func TestEnvconfig(t *testing.T) {
 				e.ClientRoutingNeverProxySubnets = []*net.IPNet{a, b}
 			},
 		},
+		"port allocation": {
+			Input: map[string]string{
+				"AGENT_PORT_RANGE":    "9900-9949",
+				"AGENT_PORT_STRATEGY": "hash",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AgentPortRange = &agentconfig.PortRange{First: 9900, Last: 9949}
+				e.AgentPortStrategy = agentconfig.PortAllocationHash
+			},
+		},
+		"log format": {
+			Input: map[string]string{
+				"LOG_FORMAT": "json",
+			},
+			Output: func(e *managerutil.Env) {
+				e.LogFormat = "json"
+			},
+		},
+		"intercept idle ttl": {
+			Input: map[string]string{
+				"INTERCEPT_IDLE_TTL": "15m",
+			},
+			Output: func(e *managerutil.Env) {
+				e.InterceptIdleTTL = 15 * time.Minute
+			},
+		},
+		"tunnel idle": {
+			Input: map[string]string{
+				"TUNNEL_TCP_IDLE_TIMEOUT":       "1h",
+				"TUNNEL_TCP_KEEPALIVE_INTERVAL": "30s",
+				"TUNNEL_TCP_DEAD_PEER_TIMEOUT":  "2m",
+				"TUNNEL_UDP_IDLE_TIMEOUT":       "5m",
+			},
+			Output: func(e *managerutil.Env) {
+				e.TunnelTCPIdleTimeout = time.Hour
+				e.TunnelTCPKeepAliveInterval = 30 * time.Second
+				e.TunnelTCPDeadPeerTimeout = 2 * time.Minute
+				e.TunnelUDPIdleTimeout = 5 * time.Minute
+			},
+		},
+		"agent tunnel compression": {
+			Input: map[string]string{
+				"AGENT_TUNNEL_COMPRESSION": "Zstd",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AgentTunnelCompression = "zstd"
+			},
+		},
+		"quic": {
+			Input: map[string]string{
+				"QUIC_PORT":     "4433",
+				"QUIC_ENDPOINT": "tm.example.com:4433",
+			},
+			Output: func(e *managerutil.Env) {
+				e.QUICPort = 4433
+				e.QUICEndpoint = "tm.example.com:4433"
+			},
+		},
+		"wireguard": {
+			Input: map[string]string{
+				"WIREGUARD_PORT":     "51820",
+				"WIREGUARD_SUBNET":   "10.250.0.0/16",
+				"WIREGUARD_ENDPOINT": "wg.example.com:51820",
+			},
+			Output: func(e *managerutil.Env) {
+				e.WireGuardPort = 51820
+				e.WireGuardSubnet = "10.250.0.0/16"
+				e.WireGuardEndpoint = "wg.example.com:51820"
+			},
+		},
+		"agent config gc interval": {
+			Input: map[string]string{
+				"AGENT_CONFIG_GC_INTERVAL": "5m",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AgentConfigGCInterval = 5 * time.Minute
+			},
+		},
+		"managed namespace selector": {
+			Input: map[string]string{
+				"MANAGED_NAMESPACE_SELECTOR": `{"matchLabels":{"telepresence":"enabled"}}`,
+			},
+			Output: func(e *managerutil.Env) {
+				e.ManagedNamespaceSelector = &meta.LabelSelector{MatchLabels: map[string]string{"telepresence": "enabled"}}
+			},
+		},
+		"dial limits": {
+			Input: map[string]string{
+				"DIAL_RATE_LIMIT":   "50",
+				"DIAL_RATE_BURST":   "100",
+				"MAX_PENDING_DIALS": "128",
+			},
+			Output: func(e *managerutil.Env) {
+				e.DialRateLimit = 50
+				e.DialRateBurst = 100
+				e.MaxPendingDials = 128
+			},
+		},
+		"intercept quotas": {
+			Input: map[string]string{
+				"MAX_INTERCEPTS_PER_USER":     "3",
+				"MAX_INTERCEPTS_PER_WORKLOAD": "1",
+			},
+			Output: func(e *managerutil.Env) {
+				e.MaxInterceptsPerUser = 3
+				e.MaxInterceptsPerWorkload = 1
+			},
+		},
+		"intercept subject access review": {
+			Input: map[string]string{
+				"INTERCEPT_SUBJECT_ACCESS_REVIEW": "true",
+			},
+			Output: func(e *managerutil.Env) {
+				e.InterceptSubjectAccessReview = true
+			},
+		},
+		"intercept specs": {
+			Input: map[string]string{
+				"INTERCEPT_SPECS_ENABLED": "true",
+			},
+			Output: func(e *managerutil.Env) {
+				e.InterceptSpecsEnabled = true
+			},
+		},
+		"audit sinks": {
+			Input: map[string]string{
+				"AUDIT_SINKS":       "stdout webhook",
+				"AUDIT_WEBHOOK_URL": "https://audit.example.com/events",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AuditSinks = []string{"stdout", "webhook"}
+				e.AuditWebhookURL = "https://audit.example.com/events"
+			},
+		},
+		"leader election": {
+			Input: map[string]string{
+				"LEADER_ELECTION":                "true",
+				"LEADER_ELECTION_LEASE_DURATION": "30s",
+				"LEADER_ELECTION_RENEW_DEADLINE": "20s",
+				"LEADER_ELECTION_RETRY_PERIOD":   "4s",
+			},
+			Output: func(e *managerutil.Env) {
+				e.LeaderElection = true
+				e.LeaderElectionLeaseDuration = 30 * time.Second
+				e.LeaderElectionRenewDeadline = 20 * time.Second
+				e.LeaderElectionRetryPeriod = 4 * time.Second
+			},
+		},
 	}
 
 	for tcName, tc := range testcases {
@@ -0,0 +1,89 @@
+package managerutil
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Names of the components whose readiness is reported by the traffic-manager's gRPC health service. Each
+// component is also reported as a service of its own, so that its status can be probed individually.
+const (
+	WorkloadWatcherComponent = "workload-watcher"
+	AgentInjectorComponent   = "agent-injector"
+	LeaderComponent          = "leader"
+)
+
+// Health is a gRPC health server that reports the traffic-manager as serving when all its components
+// are ready. The overall status is reported for the empty service name and for the services given to
+// NewHealth.
+type Health struct {
+	*health.Server
+	sync.Mutex
+	services   []string
+	components map[string]bool
+}
+
+// NewHealth returns a Health that reports the given services as serving once all the given components
+// are ready. Components start out as not ready.
+func NewHealth(services []string, components ...string) *Health {
+	h := &Health{
+		Server:     health.NewServer(),
+		services:   append([]string{""}, services...),
+		components: make(map[string]bool, len(components)),
+	}
+	for _, c := range components {
+		h.components[c] = false
+	}
+	h.update()
+	return h
+}
+
+// SetReady updates the readiness of the given component and the overall status. Components that
+// weren't given to NewHealth are ignored.
+func (h *Health) SetReady(component string, ready bool) {
+	h.Lock()
+	defer h.Unlock()
+	if old, ok := h.components[component]; ok && old != ready {
+		h.components[component] = ready
+		h.update()
+	}
+}
+
+func (h *Health) update() {
+	overall := grpc_health_v1.HealthCheckResponse_SERVING
+	for c, ready := range h.components {
+		st := grpc_health_v1.HealthCheckResponse_SERVING
+		if !ready {
+			st = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+			overall = st
+		}
+		h.SetServingStatus(c, st)
+	}
+	for _, s := range h.services {
+		h.SetServingStatus(s, overall)
+	}
+}
+
+type healthKey struct{}
+
+// WithHealth returns a context that carries the given Health.
+func WithHealth(ctx context.Context, h *Health) context.Context {
+	return context.WithValue(ctx, healthKey{}, h)
+}
+
+// GetHealth returns the Health found in the given context, or nil if there is none.
+func GetHealth(ctx context.Context) *Health {
+	h, _ := ctx.Value(healthKey{}).(*Health)
+	return h
+}
+
+// SetReady updates the readiness of the given component of the Health found in the given context. It
+// is a no-op if no such Health exists.
+func SetReady(ctx context.Context, component string, ready bool) {
+	if h := GetHealth(ctx); h != nil {
+		h.SetReady(component, ready)
+	}
+}
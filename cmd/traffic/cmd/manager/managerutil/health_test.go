@@ -0,0 +1,45 @@
+package managerutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	check := func(h *Health, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		rsp, err := h.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return rsp.Status
+	}
+	serving := grpc_health_v1.HealthCheckResponse_SERVING
+	notServing := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+
+	h := NewHealth([]string{"manager"})
+	assert.Equal(t, serving, check(h, ""))
+	assert.Equal(t, serving, check(h, "manager"))
+
+	h = NewHealth([]string{"manager"}, WorkloadWatcherComponent, AgentInjectorComponent)
+	ctx = WithHealth(ctx, h)
+	assert.Equal(t, notServing, check(h, ""))
+	assert.Equal(t, notServing, check(h, WorkloadWatcherComponent))
+
+	SetReady(ctx, WorkloadWatcherComponent, true)
+	assert.Equal(t, serving, check(h, WorkloadWatcherComponent))
+	assert.Equal(t, notServing, check(h, "manager"))
+
+	// Unknown components are ignored.
+	SetReady(ctx, LeaderComponent, false)
+	SetReady(ctx, AgentInjectorComponent, true)
+	assert.Equal(t, serving, check(h, ""))
+	assert.Equal(t, serving, check(h, "manager"))
+
+	SetReady(ctx, WorkloadWatcherComponent, false)
+	assert.Equal(t, notServing, check(h, "manager"))
+	assert.Equal(t, serving, check(h, AgentInjectorComponent))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/maps"
@@ -114,6 +115,10 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 		a.agentConfigs.Blacklist(pod.Name, pod.Namespace)
 		return nil, nil
 	}
+	if !namespaces.IsManaged(ctx, pod.Namespace) {
+		dlog.Debugf(ctx, "Skipping webhook for %s.%s because the namespace isn't managed", pod.Name, pod.Namespace)
+		return nil, nil
+	}
 
 	dlog.Debugf(ctx, "Handling admission request %s %s.%s", req.Operation, pod.Name, pod.Namespace)
 	env := managerutil.GetEnv(ctx)
@@ -183,13 +188,16 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 
 	var patches PatchOps
 	config := scx.AgentConfig()
+	if patches, err = addTLSSecretAnnotations(ctx, pod, config, patches); err != nil {
+		return nil, err
+	}
 	patches = disableAppContainer(ctx, pod, config, patches)
 	patches = addInitContainer(pod, config, patches)
 	patches = addAgentContainer(ctx, pod, config, patches)
 	patches = addPullSecrets(pod, config, patches)
 	patches = addAgentVolumes(pod, config, patches)
 	patches = hidePorts(pod, config, patches)
-	patches = addPodAnnotations(ctx, pod, patches)
+	patches = addPodAnnotations(ctx, pod, config, patches)
 	patches = addPodLabels(ctx, pod, config, patches)
 
 	if config.APIPort != 0 {
@@ -213,17 +221,6 @@ func (a *agentInjector) Uninstall(ctx context.Context) {
 	a.agentConfigs.DeleteMapsAndRolloutAll(ctx)
 }
 
-func needInitContainer(config *agentconfig.Sidecar) bool {
-	for _, cc := range config.Containers {
-		for _, ic := range cc.Intercepts {
-			if ic.Headless || ic.TargetPortNumeric {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 const sleeperImage = "alpine:latest"
 
 var sleeperArgs = []string{"sleep", "infinity"} //nolint:gochecknoglobals // constant
@@ -277,7 +274,7 @@ podContainers:
 }
 
 func addInitContainer(pod *core.Pod, config *agentconfig.Sidecar, patches PatchOps) PatchOps {
-	if !needInitContainer(config) {
+	if !agentconfig.NeedInitContainer(config) {
 		for i, oc := range pod.Spec.InitContainers {
 			if agentconfig.InitContainerName == oc.Name {
 				return append(patches, PatchOperation{
@@ -329,7 +326,7 @@ func addAgentVolumes(pod *core.Pod, ag *agentconfig.Sidecar, patches PatchOps) P
 			return patches
 		}
 	}
-	avs := agentconfig.AgentVolumes(ag.AgentName, pod)
+	avs := agentconfig.AgentVolumes(ag.AgentName, pod, ag.Volumes, ag.ServiceAccountToken)
 	if len(avs) == 0 {
 		return patches
 	}
@@ -361,6 +358,17 @@ func compareProbes(a, b *core.Probe) bool {
 	if a == nil || b == nil {
 		return a == b
 	}
+	// Zero timings are assigned defaults by Kubernetes, so only timings that are set in both probes are compared.
+	timingEqual := func(at, bt int32) bool {
+		return at == 0 || bt == 0 || at == bt
+	}
+	if !(timingEqual(a.InitialDelaySeconds, b.InitialDelaySeconds) &&
+		timingEqual(a.TimeoutSeconds, b.TimeoutSeconds) &&
+		timingEqual(a.PeriodSeconds, b.PeriodSeconds) &&
+		timingEqual(a.SuccessThreshold, b.SuccessThreshold) &&
+		timingEqual(a.FailureThreshold, b.FailureThreshold)) {
+		return false
+	}
 	ae := a.ProbeHandler.Exec
 	be := b.ProbeHandler.Exec
 	if ae == nil || be == nil {
@@ -416,7 +424,8 @@ func containerEqual(a, b *core.Container) bool {
 		cmpopts.IgnoreFields(core.Container{}, "ImagePullPolicy", "Resources", "TerminationMessagePath", "TerminationMessagePolicy"))
 }
 
-// addAgentContainer creates a patch operation to add the traffic-agent container.
+// addAgentContainer creates a patch operation to add the traffic-agent container. The container is added to the
+// pod's init-containers when it is a native sidecar.
 func addAgentContainer(
 	ctx context.Context,
 	pod *core.Pod,
@@ -429,8 +438,39 @@ func addAgentContainer(
 	}
 
 	refPodName := pod.Name + "." + pod.Namespace
-	for i := range pod.Spec.Containers {
-		pcn := &pod.Spec.Containers[i]
+	native := agentconfig.IsNativeSidecar(acn)
+	cns, path := pod.Spec.Containers, "/spec/containers"
+	ocns, opath := pod.Spec.InitContainers, "/spec/initContainers"
+	if native {
+		cns, path, ocns, opath = ocns, opath, cns, path
+	}
+
+	// indexIn returns the index to use in a patch for the container found at index i in the given list.
+	// An index in the init-containers must be adjusted when addInitContainer removed a preceding
+	// tel-agent-init container.
+	indexIn := func(listPath string, i int) string {
+		if listPath == "/spec/initContainers" && !agentconfig.NeedInitContainer(config) {
+			if ii := initContainerIndex(pod); ii >= 0 && ii < i {
+				i--
+			}
+		}
+		return listPath + "/" + strconv.Itoa(i)
+	}
+
+	// Remove the agent from the list where it no longer belongs.
+	for i := range ocns {
+		if ocns[i].Name == agentconfig.ContainerName {
+			dlog.Debugf(ctx, "Pod %s has container %s in %s but it belongs in %s", refPodName, agentconfig.ContainerName, opath, path)
+			patches = append(patches, PatchOperation{
+				Op:   "remove",
+				Path: indexIn(opath, i),
+			})
+			break
+		}
+	}
+
+	for i := range cns {
+		pcn := &cns[i]
 		if pcn.Name == agentconfig.ContainerName {
 			if containerEqual(pcn, acn) {
 				dlog.Infof(ctx, "Pod %s already has container %s and it isn't modified", refPodName, agentconfig.ContainerName)
@@ -439,20 +479,39 @@ func addAgentContainer(
 			dlog.Debugf(ctx, "Pod %s already has container %s but it is modified", refPodName, agentconfig.ContainerName)
 			return append(patches, PatchOperation{
 				Op:    "replace",
-				Path:  "/spec/containers/" + strconv.Itoa(i),
+				Path:  indexIn(path, i),
 				Value: acn,
 			})
 		}
 	}
 
+	if native && len(cns) == 0 && !agentconfig.NeedInitContainer(config) {
+		// No init-containers exist, and addInitContainer didn't create any.
+		return append(patches, PatchOperation{
+			Op:    "replace",
+			Path:  path,
+			Value: []core.Container{*acn},
+		})
+	}
 	return append(patches, PatchOperation{
 		Op:    "add",
-		Path:  "/spec/containers/-",
+		Path:  path + "/-",
 		Value: acn,
 	})
 }
 
-// addAgentContainer creates a patch operation to add the traffic-agent container.
+// initContainerIndex returns the index of the tel-agent-init container in the pod's init-containers, or -1
+// if it isn't found.
+func initContainerIndex(pod *core.Pod) int {
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == agentconfig.InitContainerName {
+			return i
+		}
+	}
+	return -1
+}
+
+// addPullSecrets creates patch operations that add the pull secrets of the traffic-agent image to the pod.
 func addPullSecrets(
 	pod *core.Pod,
 	config *agentconfig.Sidecar,
@@ -607,7 +666,28 @@ func hideContainerPorts(pod *core.Pod, app *core.Container, isReplace bool, port
 	return patches
 }
 
-func addPodAnnotations(_ context.Context, pod *core.Pod, patches PatchOps) PatchOps {
+// addTLSSecretAnnotations resolves the cert-manager Certificates that are referenced by the pod's annotations and
+// adds annotations for their secrets to the pod, so that the agent's TLS volumes are created from those secrets.
+func addTLSSecretAnnotations(ctx context.Context, pod *core.Pod, config *agentconfig.Sidecar, patches PatchOps) (PatchOps, error) {
+	sas, err := agentconfig.ResolveTLSCertificates(ctx, pod, config.AgentName)
+	if err != nil || len(sas) == 0 {
+		return patches, err
+	}
+
+	// The pod has annotations, or it wouldn't reference any certificates.
+	am := maps.Copy(pod.Annotations)
+	for k, v := range sas {
+		am[k] = v
+	}
+	pod.Annotations = am
+	return append(patches, PatchOperation{
+		Op:    "replace",
+		Path:  "/metadata/annotations",
+		Value: am,
+	}), nil
+}
+
+func addPodAnnotations(ctx context.Context, pod *core.Pod, config *agentconfig.Sidecar, patches PatchOps) PatchOps {
 	op := "replace"
 	changed := false
 	am := pod.Annotations
@@ -623,6 +703,32 @@ func addPodAnnotations(_ context.Context, pod *core.Pod, patches PatchOps) Patch
 		am[agentconfig.InjectAnnotation] = "enabled"
 	}
 
+	env := managerutil.GetEnv(ctx)
+	var ns *core.Namespace
+	nsRetrieved := false
+	hasProxy := func(detect func(*core.Pod, *core.Namespace) bool) bool {
+		if detect(pod, nil) {
+			return true
+		}
+		if !nsRetrieved {
+			ns = podNamespace(ctx, pod)
+			nsRetrieved = true
+		}
+		return ns != nil && detect(pod, ns)
+	}
+	addMeshAnnotations := func(meshAnnotations func(*core.Pod, *agentconfig.Sidecar) map[string]string) {
+		for k, v := range meshAnnotations(pod, config) {
+			changed = true
+			am[k] = v
+		}
+	}
+	if env.AgentIstioCoexistence && hasProxy(agentconfig.HasIstioProxy) {
+		addMeshAnnotations(agentconfig.IstioAnnotations)
+	}
+	if env.AgentLinkerdCoexistence && hasProxy(agentconfig.HasLinkerdProxy) {
+		addMeshAnnotations(agentconfig.LinkerdAnnotations)
+	}
+
 	if changed {
 		patches = append(patches, PatchOperation{
 			Op:    op,
@@ -633,6 +739,18 @@ func addPodAnnotations(_ context.Context, pod *core.Pod, patches PatchOps) Patch
 	return patches
 }
 
+// podNamespace returns the namespace of the given pod, or nil if it cannot be retrieved. The namespace is
+// used to determine if a service mesh will inject a proxy into the pod.
+func podNamespace(ctx context.Context, pod *core.Pod) *core.Namespace {
+	ns, err := k8sapi.GetK8sInterface(ctx).CoreV1().Namespaces().Get(ctx, pod.Namespace, meta.GetOptions{})
+	if err != nil {
+		// The traffic-manager might not be permitted to read namespaces, so this isn't fatal.
+		dlog.Debugf(ctx, "unable to get namespace %s to determine if a service mesh proxy will be injected: %v", pod.Namespace, err)
+		return nil
+	}
+	return ns
+}
+
 func addPodLabels(_ context.Context, pod *core.Pod, config agentconfig.SidecarExt, patches PatchOps) PatchOps {
 	op := "replace"
 	changed := false
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -234,6 +235,12 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		},
 	}
 
+	podNamedAndNumericPortProxy := *podNamedAndNumericPort.DeepCopy()
+	podNamedAndNumericPortProxy.Annotations[agentconfig.InitModeAnnotation] = string(agentconfig.InitModeProxy)
+
+	podNamedAndIgnoredNumericPort := *podNamedAndNumericPort.DeepCopy()
+	podNamedAndIgnoredNumericPort.Annotations[agentconfig.InjectIgnoreContainers] = "numeric-port-container"
+
 	podMultiPort := core.Pod{
 		ObjectMeta: meta.ObjectMeta{
 			Name:            podName("multi-port"),
@@ -501,12 +508,13 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			"found no service with a port that matches a container in pod <PODNAME>",
 		},
 		{
-			"Error Precondition: Sidecar has port collision",
+			"Agent port collision is avoided",
 			&core.Pod{
 				ObjectMeta: podObjectMeta("named-port", "service"),
 				Spec: core.PodSpec{
 					Containers: []core.Container{
 						{
+							Name: "some-container",
 							Ports: []core.ContainerPort{
 								{Name: "http", ContainerPort: int32(env.AgentPort)},
 							},
@@ -514,8 +522,35 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 					},
 				},
 			},
-			nil,
-			"is exposing the same port (9900) as the traffic-agent sidecar",
+			&agentconfig.Sidecar{
+				AgentName:    "named-port",
+				AgentImage:   "docker.io/datawire/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "named-port",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9901,
+								ContainerPort:     9900,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+			},
+			"",
 		},
 		{
 			"Named port",
@@ -670,6 +705,75 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"Named and numeric port containers in proxy init mode",
+			&podNamedAndNumericPortProxy,
+			&agentconfig.Sidecar{
+				AgentName:    "named-and-numeric",
+				AgentImage:   "docker.io/datawire/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "named-and-numeric",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				InitMode:     agentconfig.InitModeProxy,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "named-port-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/named-port-container",
+						Mounts:     []string{"/home/bob", "/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Named and ignored numeric port containers",
+			&podNamedAndIgnoredNumericPort,
+			&agentconfig.Sidecar{
+				AgentName:    "named-and-numeric",
+				AgentImage:   "docker.io/datawire/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "named-and-numeric",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "named-port-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/named-port-container",
+						Mounts:     []string{"/home/bob", "/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+			},
+			"",
+		},
 		{
 			"Multi-port container and service",
 			&podMultiPort,
@@ -1087,6 +1191,21 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_POD_UID
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.uid
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: docker.io/datawire/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1179,6 +1298,21 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_POD_UID
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.uid
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: docker.io/datawire/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1320,6 +1454,21 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_POD_UID
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.uid
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: docker.io/datawire/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1401,6 +1550,11 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: status.podIP
+    - name: POD_IPS
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: status.podIPs
     image: docker.io/datawire/tel2:2.13.3
     name: tel-agent-init
     resources: {}
@@ -1427,6 +1581,21 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_POD_UID
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.uid
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: docker.io/datawire/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1508,6 +1677,11 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: status.podIP
+    - name: POD_IPS
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: status.podIPs
     image: docker.io/datawire/tel2:2.13.3
     name: tel-agent-init
     resources: {}
@@ -1534,6 +1708,21 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_POD_UID
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.uid
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: docker.io/datawire/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1639,6 +1828,33 @@ func TestTrafficAgentInjector(t *testing.T) {
 										},
 									},
 								},
+								{
+									Name: "_TEL_AGENT_NAMESPACE",
+									ValueFrom: &core.EnvVarSource{
+										FieldRef: &core.ObjectFieldSelector{
+											APIVersion: "v1",
+											FieldPath:  "metadata.namespace",
+										},
+									},
+								},
+								{
+									Name: "_TEL_AGENT_POD_UID",
+									ValueFrom: &core.EnvVarSource{
+										FieldRef: &core.ObjectFieldSelector{
+											APIVersion: "v1",
+											FieldPath:  "metadata.uid",
+										},
+									},
+								},
+								{
+									Name: "_TEL_AGENT_NODE_NAME",
+									ValueFrom: &core.EnvVarSource{
+										FieldRef: &core.ObjectFieldSelector{
+											APIVersion: "v1",
+											FieldPath:  "spec.nodeName",
+										},
+									},
+								},
 							},
 							Resources:                core.ResourceRequirements{},
 							TerminationMessagePath:   "/dev/termination-log",
@@ -1741,6 +1957,21 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_POD_UID
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.uid
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     - name: A_TELEPRESENCE_MOUNTS
       value: /var/run/secrets/kubernetes.io/serviceaccount
     image: docker.io/datawire/tel2:2.13.3
@@ -1847,7 +2078,14 @@ func TestTrafficAgentInjector(t *testing.T) {
 				require.NoError(t, err)
 				var scx agentconfig.SidecarExt
 				if scx, actualErr = generateForPod(t, ctx, test.pod, gc); actualErr == nil {
-					actualErr = cw.store(ctx, scx)
+					if actualErr = cw.store(ctx, scx); actualErr == nil {
+						// The stored config is read back from the informer cache, so wait until it's there.
+						ac := scx.AgentConfig()
+						require.Eventually(t, func() bool {
+							stored, err := cw.Get(ctx, ac.AgentName, ac.Namespace)
+							return err == nil && stored != nil
+						}, 5*time.Second, time.Millisecond)
+					}
 				}
 			}
 			if actualErr == nil {
@@ -1911,3 +2149,56 @@ func generateForPod(t *testing.T, ctx context.Context, pod *core.Pod, gc agentma
 	}
 	return gc.Generate(ctx, wl, nil)
 }
+
+func TestAddPodAnnotations_istio(t *testing.T) {
+	config := &agentconfig.Sidecar{
+		ManagerPort: 8081,
+		Containers:  []*agentconfig.Container{{Intercepts: []*agentconfig.Intercept{{AgentPort: 9900, ContainerPort: 8080}}}},
+	}
+	clientset := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{
+		Name:   "mesh",
+		Labels: map[string]string{agentconfig.IstioInjectionNamespaceLabel: "enabled"},
+	}})
+	annotations := func(env *managerutil.Env, pod *core.Pod) map[string]string {
+		ctx := dlog.NewTestContext(t, false)
+		ctx = managerutil.WithEnv(ctx, env)
+		ctx = k8sapi.WithK8sInterface(ctx, clientset)
+		patches := addPodAnnotations(ctx, pod, config, nil)
+		require.Len(t, patches, 1)
+		return patches[0].Value.(map[string]string)
+	}
+
+	pod := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "mesh"}}
+	am := annotations(&managerutil.Env{}, pod)
+	assert.NotContains(t, am, agentconfig.IstioExcludeInboundPortsAnnotation, "coexistence is disabled")
+
+	env := &managerutil.Env{AgentIstioCoexistence: true}
+	am = annotations(env, pod)
+	assert.Equal(t, "9900", am[agentconfig.IstioExcludeInboundPortsAnnotation])
+	assert.Equal(t, "8081", am[agentconfig.IstioExcludeOutboundPortsAnnotation])
+	assert.Equal(t, "enabled", am[agentconfig.InjectAnnotation])
+
+	pod.Namespace = "default"
+	am = annotations(env, pod)
+	assert.NotContains(t, am, agentconfig.IstioExcludeInboundPortsAnnotation, "namespace without istio")
+}
+
+func TestAddPodAnnotations_linkerd(t *testing.T) {
+	config := &agentconfig.Sidecar{
+		ManagerPort: 8081,
+		Containers:  []*agentconfig.Container{{Intercepts: []*agentconfig.Intercept{{AgentPort: 9900, ContainerPort: 8080}}}},
+	}
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{AgentLinkerdCoexistence: true})
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset())
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+		Spec:       core.PodSpec{Containers: []core.Container{{Name: "echo"}, {Name: agentconfig.LinkerdProxyContainerName}}},
+	}
+	patches := addPodAnnotations(ctx, pod, config, nil)
+	require.Len(t, patches, 1)
+	am := patches[0].Value.(map[string]string)
+	assert.Equal(t, "9900", am[agentconfig.LinkerdSkipInboundPortsAnnotation])
+	assert.Equal(t, "8081", am[agentconfig.LinkerdSkipOutboundPortsAnnotation])
+	assert.NotContains(t, am, agentconfig.IstioExcludeInboundPortsAnnotation)
+}
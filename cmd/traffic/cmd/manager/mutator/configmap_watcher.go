@@ -59,7 +59,7 @@ func (c *configWatcher) watchConfigMap(ctx context.Context, ix cache.SharedIndex
 				if cm, ok := obj.(*core.ConfigMap); ok {
 					dlog.Debugf(ctx, "ADDED %s.%s", cm.Name, cm.Namespace)
 					c.getNamespaceLock(cm.Namespace)
-					c.handleAdd(ctx, cm)
+					c.handleAdd(cm)
 				}
 			},
 			DeleteFunc: func(obj any) {
@@ -72,61 +72,79 @@ func (c *configWatcher) watchConfigMap(ctx context.Context, ix cache.SharedIndex
 				if ok {
 					dlog.Debugf(ctx, "DELETED %s.%s", cm.Name, cm.Namespace)
 					c.getNamespaceLock(cm.Namespace)
-					c.handleDelete(ctx, cm)
+					c.handleDelete(cm)
 				}
 			},
 			UpdateFunc: func(oldObj, newObj any) {
 				if cm, ok := newObj.(*core.ConfigMap); ok {
 					dlog.Debugf(ctx, "UPDATED %s.%s", cm.Name, cm.Namespace)
-					c.handleUpdate(ctx, oldObj.(*core.ConfigMap), cm)
+					c.handleUpdate(oldObj.(*core.ConfigMap), cm)
 				}
 			},
 		})
 	return err
 }
 
-func (c *configWatcher) handleAdd(ctx context.Context, cm *core.ConfigMap) {
+// enqueueEntry enqueues a task that handles an added, updated, or deleted config entry. Entries share
+// shards with the workloads that they describe.
+func (c *configWatcher) enqueueEntry(e entry, deleted bool) {
+	c.queue.enqueue(workloadKey{
+		name:      e.name,
+		namespace: e.namespace,
+		kind:      "ConfigEntry",
+	}, taskFunc(func(ctx context.Context) {
+		if deleted {
+			c.handleDeleteEntry(ctx, e)
+		} else {
+			c.handleAddOrUpdateEntry(ctx, e)
+		}
+	}))
+}
+
+func (c *configWatcher) handleAdd(cm *core.ConfigMap) {
 	ns := cm.Namespace
 	for n, yml := range cm.Data {
-		c.handleAddOrUpdateEntry(ctx, entry{
+		c.enqueueEntry(entry{
 			name:      n,
 			namespace: ns,
 			value:     yml,
-		})
+		}, false)
 	}
 }
 
-func (c *configWatcher) handleDelete(ctx context.Context, cm *core.ConfigMap) {
+func (c *configWatcher) handleDelete(cm *core.ConfigMap) {
 	ns := cm.Namespace
 	for n, yml := range cm.Data {
-		c.handleDeleteEntry(ctx, entry{
+		c.enqueueEntry(entry{
 			name:      n,
 			namespace: ns,
 			value:     yml,
-		})
+		}, true)
 	}
 }
 
-func (c *configWatcher) handleUpdate(ctx context.Context, oldCm, newCm *core.ConfigMap) {
+func (c *configWatcher) handleUpdate(oldCm, newCm *core.ConfigMap) {
 	ns := newCm.Namespace
 	for n, newYml := range newCm.Data {
-		e := entry{
+		oldYml, ok := oldCm.Data[n]
+		if ok && oldYml == newYml {
+			// Unchanged. Must not be enqueued, because it would replace a pending change.
+			continue
+		}
+		c.enqueueEntry(entry{
 			name:      n,
 			namespace: ns,
 			value:     newYml,
-		}
-		if oldYml, ok := oldCm.Data[n]; ok {
-			e.oldValue = oldYml
-		}
-		c.handleAddOrUpdateEntry(ctx, e)
+			oldValue:  oldYml,
+		}, false)
 	}
 	for n, oldYml := range oldCm.Data {
 		if _, ok := newCm.Data[n]; !ok {
-			c.handleDeleteEntry(ctx, entry{
+			c.enqueueEntry(entry{
 				name:      n,
 				namespace: ns,
 				value:     oldYml,
-			})
+			}, true)
 		}
 	}
 }
@@ -0,0 +1,152 @@
+package mutator
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// maxCleanedEntries is the number of cleaned entries that the GCReport retains.
+const maxCleanedEntries = 100
+
+// CleanedEntry describes an entry that was removed from a telepresence-agents ConfigMap by the
+// garbage collector.
+type CleanedEntry struct {
+	Name      string
+	Namespace string
+	Kind      string
+	Reason    string
+	Time      time.Time
+}
+
+// GCReport describes what the garbage collector of orphaned agent config entries has done.
+type GCReport struct {
+	// LastRun is the time of the last completed run, or zero if no run has completed.
+	LastRun time.Time
+
+	// Cleaned are the most recently cleaned entries, oldest first.
+	Cleaned []CleanedEntry
+}
+
+// GCReport returns a copy of the current garbage collection report.
+func (c *configWatcher) GCReport() GCReport {
+	c.gcLock.Lock()
+	defer c.gcLock.Unlock()
+	return GCReport{LastRun: c.gcReport.LastRun, Cleaned: slices.Clone(c.gcReport.Cleaned)}
+}
+
+// runGC removes orphaned entries from the telepresence-agents ConfigMaps at the given interval until
+// the context is cancelled.
+func (c *configWatcher) runGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.collectOrphans(ctx)
+		}
+	}
+}
+
+// collectOrphans removes the entries of the telepresence-agents ConfigMaps that describe workloads
+// that no longer exist, that have injection disabled, or that are in namespaces that are no longer
+// managed. The removal is picked up by the ConfigMap watcher, which rolls back the injected agents of
+// workloads that still exist. Manually added entries are never removed.
+func (c *configWatcher) collectOrphans(ctx context.Context) {
+	var cleaned []CleanedEntry
+	for _, ix := range c.cms {
+		for _, obj := range ix.GetStore().List() {
+			if cm, ok := obj.(*core.ConfigMap); ok {
+				cleaned = append(cleaned, c.removeOrphans(ctx, cm)...)
+			}
+		}
+	}
+
+	c.gcLock.Lock()
+	defer c.gcLock.Unlock()
+	c.gcReport.LastRun = time.Now()
+	c.gcReport.Cleaned = append(c.gcReport.Cleaned, cleaned...)
+	if excess := len(c.gcReport.Cleaned) - maxCleanedEntries; excess > 0 {
+		c.gcReport.Cleaned = slices.Delete(c.gcReport.Cleaned, 0, excess)
+	}
+}
+
+// removeOrphans removes the orphaned entries of the given ConfigMap using one update, and returns
+// what was removed.
+func (c *configWatcher) removeOrphans(ctx context.Context, cm *core.ConfigMap) []CleanedEntry {
+	orphans := make(map[string]CleanedEntry)
+	for name, yml := range cm.Data {
+		e := entry{name: name, namespace: cm.Namespace, value: yml}
+		if kind, reason := c.orphanReason(ctx, &e); reason != "" {
+			orphans[name] = CleanedEntry{Name: name, Namespace: cm.Namespace, Kind: kind, Reason: reason}
+		}
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+	var cleaned []CleanedEntry
+	err := c.Update(ctx, cm.Namespace, func(cm *core.ConfigMap) (bool, error) {
+		cleaned = cleaned[:0]
+		for name, ce := range orphans {
+			if _, ok := cm.Data[name]; ok {
+				delete(cm.Data, name)
+				cleaned = append(cleaned, ce)
+			}
+		}
+		return len(cleaned) > 0, nil
+	})
+	if err != nil {
+		dlog.Errorf(ctx, "unable to remove orphaned entries from ConfigMap %s.%s: %v", agentconfig.ConfigMap, cm.Namespace, err)
+		return nil
+	}
+	now := time.Now()
+	for i := range cleaned {
+		ce := &cleaned[i]
+		ce.Time = now
+		dlog.Infof(ctx, "Removed agent config entry %s.%s: %s", ce.Name, ce.Namespace, ce.Reason)
+	}
+	slices.SortFunc(cleaned, func(a, b CleanedEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cleaned
+}
+
+// orphanReason returns the kind of the workload of the given entry and the reason why the entry is
+// orphaned. The reason is empty when the entry isn't orphaned.
+func (c *configWatcher) orphanReason(ctx context.Context, e *entry) (kind, reason string) {
+	scx, err := agentconfig.UnmarshalYAML([]byte(e.value))
+	if err != nil {
+		dlog.Errorf(ctx, "unable to decode agent config entry %s.%s: %v", e.name, e.namespace, err)
+		return "", ""
+	}
+	ac := scx.AgentConfig()
+	if ac.Manual {
+		return ac.WorkloadKind, ""
+	}
+	if !namespaces.IsManaged(ctx, e.namespace) {
+		return ac.WorkloadKind, "namespace is no longer managed"
+	}
+	_, wl, err := e.workload(ctx)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ac.WorkloadKind, "workload no longer exists"
+		}
+		dlog.Errorf(ctx, "unable to check agent config entry %s.%s: %v", e.name, e.namespace, err)
+		return ac.WorkloadKind, ""
+	}
+	switch wl.GetPodTemplate().Annotations[agentconfig.InjectAnnotation] {
+	case "false", "disabled":
+		return ac.WorkloadKind, "injection is disabled"
+	}
+	return ac.WorkloadKind, ""
+}
@@ -0,0 +1,103 @@
+package mutator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+func gcDeployment(name, inject string) *apps.Deployment {
+	return &apps.Deployment{
+		TypeMeta: meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: apps.DeploymentSpec{
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{
+					Annotations: map[string]string{agentconfig.InjectAnnotation: inject},
+				},
+			},
+		},
+	}
+}
+
+func gcEntry(t *testing.T, name string, manual bool) string {
+	t.Helper()
+	yml, err := (&agentconfig.Sidecar{
+		AgentName:    name,
+		Namespace:    "default",
+		WorkloadName: name,
+		WorkloadKind: "Deployment",
+		Manual:       manual,
+	}).Marshal()
+	require.NoError(t, err)
+	return string(yml)
+}
+
+func TestCollectOrphans(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	clientset := fake.NewSimpleClientset(
+		gcDeployment("echo", "enabled"),
+		gcDeployment("disabled", "false"),
+		&core.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      agentconfig.ConfigMap,
+				Namespace: "default",
+			},
+			Data: map[string]string{
+				"echo":     gcEntry(t, "echo", false),
+				"disabled": gcEntry(t, "disabled", false),
+				"gone":     gcEntry(t, "gone", false),
+				"manual":   gcEntry(t, "manual", true),
+			},
+		},
+	)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{})
+	ctx = k8sapi.WithK8sInterface(ctx, clientset)
+	ctx = informer.WithFactory(ctx, "")
+	cw := NewWatcher("").(*configWatcher)
+	cw.Start(ctx)
+
+	assert.True(t, cw.GCReport().LastRun.IsZero())
+	cw.collectOrphans(ctx)
+
+	gr := cw.GCReport()
+	assert.False(t, gr.LastRun.IsZero())
+	require.Len(t, gr.Cleaned, 2)
+	assert.Equal(t, "disabled", gr.Cleaned[0].Name)
+	assert.Equal(t, "injection is disabled", gr.Cleaned[0].Reason)
+	assert.Equal(t, "gone", gr.Cleaned[1].Name)
+	assert.Equal(t, "Deployment", gr.Cleaned[1].Kind)
+	assert.Equal(t, "workload no longer exists", gr.Cleaned[1].Reason)
+
+	cm, err := clientset.CoreV1().ConfigMaps("default").Get(ctx, agentconfig.ConfigMap, meta.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, cm.Data, 2)
+	assert.Contains(t, cm.Data, "echo")
+	assert.Contains(t, cm.Data, "manual")
+
+	// Nothing more is cleaned once the cache has caught up.
+	require.Eventually(t, func() bool {
+		cm, err := tpAgentsConfigMap(ctx, "default")
+		return err == nil && len(cm.Data) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	cw.collectOrphans(ctx)
+	assert.Len(t, cw.GCReport().Cleaned, 2)
+}
@@ -182,6 +182,8 @@ func ServeMutator(ctx context.Context, injectorCertGetter InjectorCertGetter) er
 			return nil
 		}
 		// the injectorReady was closed with no errors.
+		managerutil.SetReady(ctx, managerutil.AgentInjectorComponent, true)
+		defer managerutil.SetReady(ctx, managerutil.AgentInjectorComponent, false)
 		return cw.Wait(ctx)
 	})
 	return serveAndWatchTLS(ctx, &server, fmt.Sprintf(":%d", port), injectorCertGetter, injectorReady)
@@ -93,27 +93,54 @@ func (c *configWatcher) watchServices(ctx context.Context, ix cache.SharedIndexI
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj any) {
 				if svc, ok := obj.(*core.Service); ok {
-					c.updateSvc(ctx, svc, false)
+					c.enqueueUpdateSvc(svc, false)
 				}
 			},
 			DeleteFunc: func(obj any) {
 				if svc, ok := obj.(*core.Service); ok {
-					c.updateSvc(ctx, svc, true)
+					c.enqueueUpdateSvc(svc, true)
 				} else if dfsu, ok := obj.(*cache.DeletedFinalStateUnknown); ok {
 					if svc, ok := dfsu.Obj.(*core.Service); ok {
-						c.updateSvc(ctx, svc, true)
+						c.enqueueUpdateSvc(svc, true)
 					}
 				}
 			},
 			UpdateFunc: func(oldObj, newObj any) {
 				if newSvc, ok := newObj.(*core.Service); ok {
-					c.updateSvc(ctx, newSvc, true)
+					c.enqueueUpdateSvc(newSvc, true)
 				}
 			},
 		})
 	return err
 }
 
+// serviceUpdate is the task that updates the config entries that are affected by a service.
+type serviceUpdate struct {
+	c        *configWatcher
+	svc      *core.Service
+	trustUID bool
+}
+
+func (u *serviceUpdate) run(ctx context.Context) {
+	u.c.updateSvc(ctx, u.svc, u.trustUID)
+}
+
+// coalesce ensures that the UID is trusted if it was trusted by the pending update.
+func (u *serviceUpdate) coalesce(pending task) task {
+	if pu, ok := pending.(*serviceUpdate); ok && pu.trustUID {
+		u.trustUID = true
+	}
+	return u
+}
+
+func (c *configWatcher) enqueueUpdateSvc(svc *core.Service, trustUID bool) {
+	c.queue.enqueue(workloadKey{
+		name:      svc.Name,
+		namespace: svc.Namespace,
+		kind:      "Service",
+	}, &serviceUpdate{c: c, svc: svc, trustUID: trustUID})
+}
+
 func (c *configWatcher) updateSvc(ctx context.Context, svc *core.Service, trustUID bool) {
 	// Does the snapshot contain workloads that we didn't find using the service's Spec.Selector?
 	// If so, include them, or if workload for the config entry isn't found, delete that entry
@@ -28,6 +28,7 @@ import (
 	"github.com/datawire/dlib/dtime"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
@@ -45,6 +46,9 @@ type Map interface {
 	Blacklist(podName, namespace string)
 	Whitelist(podName, namespace string)
 	IsBlacklisted(podName, namespace string) bool
+	QueueStats() QueueStats
+	GCReport() GCReport
+	RestoreReplacedContainers(ctx context.Context, isIntercepted func(name, namespace string) bool)
 
 	store(ctx context.Context, acx agentconfig.SidecarExt) error
 	remove(ctx context.Context, name, namespace string) error
@@ -184,12 +188,12 @@ func isRolloutNeededForPod(ctx context.Context, ac *agentconfig.Sidecar, name, n
 	}
 	podIc := agentmap.InitContainer(pod)
 	if podIc == nil {
-		if needInitContainer(ac) {
+		if agentconfig.NeedInitContainer(ac) {
 			return fmt.Sprintf("Rollout of %s.%s is necessary. An init-container is desired but the pod %s doesn't have one",
 				name, namespace, pod.GetName())
 		}
 	} else {
-		if !needInitContainer(ac) {
+		if !agentconfig.NeedInitContainer(ac) {
 			return fmt.Sprintf("Rollout of %s.%s is necessary. No init-container is desired but the pod %s has one",
 				name, namespace, pod.GetName())
 		}
@@ -246,6 +250,10 @@ func (c *configWatcher) triggerRollout(ctx context.Context, wl k8sapi.Workload,
 		triggerRolloutReplicaSet(ctx, wl, rs, span)
 		return
 	}
+	if _, ok := agentmap.DeploymentConfigImpl(wl); ok {
+		triggerRolloutDeploymentConfig(ctx, wl, span)
+		return
+	}
 	restartAnnotation := fmt.Sprintf(
 		`{"spec": {"template": {"metadata": {"annotations": {"%s": "%s"}}}}}`,
 		AnnRestartedAt,
@@ -261,6 +269,19 @@ func (c *configWatcher) triggerRollout(ctx context.Context, wl k8sapi.Workload,
 	dlog.Infof(ctx, "Successfully rolled out %s.%s", wl.GetName(), wl.GetNamespace())
 }
 
+func triggerRolloutDeploymentConfig(ctx context.Context, wl k8sapi.Workload, span trace.Span) {
+	// A DeploymentConfig might lack a ConfigChange trigger, in which case patching the pod template
+	// won't recreate the pods. A new deployment is therefore requested explicitly.
+	span.AddEvent("tel2.do-rollout")
+	if err := agentmap.TriggerDeploymentConfigRollout(ctx, wl); err != nil {
+		err = fmt.Errorf("unable to instantiate %s %s.%s: %v", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+		dlog.Error(ctx, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	dlog.Infof(ctx, "Successfully rolled out %s.%s", wl.GetName(), wl.GetNamespace())
+}
+
 func triggerRolloutReplicaSet(ctx context.Context, wl k8sapi.Workload, rs *appsv1.ReplicaSet, span trace.Span) {
 	// Rollout of a replicatset will not recreate the pods. In order for that to happen, the
 	// set must be scaled down and then up again.
@@ -392,6 +413,67 @@ func (c *configWatcher) regenerateAgentMaps(ctx context.Context, ns string, gc a
 	return err
 }
 
+// RestoreReplacedContainers restores the app containers that were replaced by intercepts of a previous
+// traffic-manager, or of a previous leader, and that aren't intercepted now according to the given
+// function. A container that is still marked as replaced would otherwise remain disabled after the
+// intercept that replaced it is long gone. The rollouts of the restored workloads are triggered by the
+// configmap watchers.
+func (c *configWatcher) RestoreReplacedContainers(ctx context.Context, isIntercepted func(name, namespace string) bool) {
+	nss := managerutil.GetEnv(ctx).ManagedNamespaces
+	if len(nss) == 0 {
+		nss = []string{""}
+	}
+	for _, wns := range nss {
+		cml, err := tpAgentsInformer(ctx, wns).Lister().List(labels.Everything())
+		if err != nil {
+			dlog.Errorf(ctx, "unable to list configmaps %s: %v", whereWeWatch(wns), err)
+			continue
+		}
+		for _, cm := range cml {
+			ns := cm.Namespace
+			err = c.Update(ctx, ns, func(cm *core.ConfigMap) (bool, error) {
+				return restoreReplaced(ctx, cm, isIntercepted)
+			})
+			if err != nil {
+				dlog.Errorf(ctx, "unable to restore replaced containers in namespace %s: %v", ns, err)
+			}
+		}
+	}
+}
+
+// restoreReplaced clears the replace policy of all containers in the entries of the given
+// telepresence-agents configmap that aren't intercepted, and returns true if any entry changed.
+func restoreReplaced(ctx context.Context, cm *core.ConfigMap, isIntercepted func(name, namespace string) bool) (changed bool, err error) {
+	for n, y := range cm.Data {
+		if isIntercepted(n, cm.Namespace) {
+			continue
+		}
+		scx, err := agentconfig.UnmarshalYAML([]byte(y))
+		if err != nil {
+			dlog.Errorf(ctx, "failed to decode ConfigMap entry %q into an agent config: %v", n, err)
+			continue
+		}
+		replaced := false
+		for _, cn := range scx.AgentConfig().Containers {
+			if cn.Replace {
+				cn.Replace = false
+				replaced = true
+			}
+		}
+		if !replaced {
+			continue
+		}
+		yml, err := scx.Marshal()
+		if err != nil {
+			return false, err
+		}
+		dlog.Infof(ctx, "Restoring the replaced app containers of %s.%s", n, cm.Namespace)
+		cm.Data[n] = string(yml)
+		changed = true
+	}
+	return changed, nil
+}
+
 type workloadKey struct {
 	name      string
 	namespace string
@@ -404,12 +486,18 @@ type configWatcher struct {
 	nsLocks         *xsync.MapOf[string, *sync.RWMutex]
 	blacklistedPods *xsync.MapOf[string, time.Time]
 	startedAt       time.Time
+	queue           *workQueue
+
+	gcLock   sync.Mutex
+	gcReport GCReport
 
 	cms []cache.SharedIndexInformer
 	svs []cache.SharedIndexInformer
 	dps []cache.SharedIndexInformer
 	rss []cache.SharedIndexInformer
 	sss []cache.SharedIndexInformer
+	dss []cache.SharedIndexInformer
+	dcs []cache.SharedIndexInformer
 
 	self Map // For extension
 }
@@ -514,6 +602,7 @@ func (c *configWatcher) SetSelf(self Map) {
 func (c *configWatcher) StartWatchers(ctx context.Context) error {
 	c.startedAt = time.Now()
 	ctx, c.cancel = context.WithCancel(ctx)
+	c.queue.run(ctx)
 	for _, si := range c.svs {
 		if err := c.watchServices(ctx, si); err != nil {
 			return err
@@ -534,18 +623,75 @@ func (c *configWatcher) StartWatchers(ctx context.Context) error {
 			return err
 		}
 	}
+	for _, si := range c.dss {
+		if err := c.watchWorkloads(ctx, si); err != nil {
+			return err
+		}
+	}
+	for _, si := range c.dcs {
+		if err := c.watchWorkloads(ctx, si); err != nil {
+			return err
+		}
+	}
 	for _, ci := range c.cms {
 		if err := c.watchConfigMap(ctx, ci); err != nil {
 			return err
 		}
 	}
+	if nw := namespaces.GetWatcher(ctx); nw != nil {
+		nw.Subscribe(func(ns string, _ bool) {
+			c.queue.enqueue(workloadKey{namespace: ns, kind: "Namespace"}, taskFunc(func(ctx context.Context) {
+				c.namespaceChanged(ctx, ns)
+			}))
+		})
+	}
+	if iv := managerutil.GetEnv(ctx).AgentConfigGCInterval; iv > 0 {
+		go c.runGC(ctx, iv)
+	}
 	return nil
 }
 
+// namespaceChanged is called when the given namespace starts or stops being managed. The workloads of
+// a namespace that starts being managed are processed as if they were just added. The agents of a
+// namespace that stops being managed are removed.
+func (c *configWatcher) namespaceChanged(ctx context.Context, ns string) {
+	if !namespaces.IsManaged(ctx, ns) {
+		lock := c.getNamespaceLock(ns)
+		lock.Lock()
+		defer lock.Unlock()
+		c.deleteMapAndRollout(ctx, ns)
+		return
+	}
+	for _, ixs := range [][]cache.SharedIndexInformer{c.dps, c.rss, c.sss, c.dss, c.dcs} {
+		for _, ix := range ixs {
+			objs, err := ix.GetIndexer().ByIndex(cache.NamespaceIndex, ns)
+			if err != nil {
+				dlog.Errorf(ctx, "unable to list workloads in namespace %s: %v", ns, err)
+				continue
+			}
+			for _, obj := range objs {
+				if wl, ok := WorkloadFromAny(obj); ok && len(wl.GetOwnerReferences()) == 0 {
+					c.queue.enqueue(keyOfWorkload(wl), &workloadUpdate{c: c, wl: wl})
+				}
+			}
+		}
+	}
+}
+
+// QueueStats returns statistics for the queue that holds the work caused by the watchers.
+func (c *configWatcher) QueueStats() QueueStats {
+	if c.queue == nil {
+		return QueueStats{}
+	}
+	return c.queue.stats()
+}
+
 func (c *configWatcher) Wait(ctx context.Context) error {
 	if err := c.StartWatchers(ctx); err != nil {
 		return err
 	}
+	managerutil.SetReady(ctx, managerutil.WorkloadWatcherComponent, true)
+	defer managerutil.SetReady(ctx, managerutil.WorkloadWatcherComponent, false)
 	<-ctx.Done()
 	return nil
 }
@@ -616,11 +762,7 @@ func (c *configWatcher) getNamespaceLock(ns string) *sync.RWMutex {
 }
 
 func (c *configWatcher) getRolloutLock(wl k8sapi.Workload) *sync.Mutex {
-	lock, _ := c.rolloutLocks.LoadOrCompute(workloadKey{
-		name:      wl.GetName(),
-		namespace: wl.GetNamespace(),
-		kind:      wl.GetKind(),
-	}, func() *sync.Mutex {
+	lock, _ := c.rolloutLocks.LoadOrCompute(keyOfWorkload(wl), func() *sync.Mutex {
 		return &sync.Mutex{}
 	})
 	return lock
@@ -724,8 +866,11 @@ func (c *configWatcher) startPods(ctx context.Context, ns string) cache.SharedIn
 			pod.Finalizers = nil
 
 			ps := &pod.Status
-			// We're just interested in the podIP/podIPs
-			ps.Conditions = nil
+			// We're just interested in the podIP/podIPs, and in the Ready condition, which determines
+			// if the pod is published by a headless service.
+			ps.Conditions = slices.DeleteFunc(ps.Conditions, func(c core.PodCondition) bool {
+				return c.Type != core.PodReady
+			})
 
 			// Strip everything but the State from the container statuses. We need
 			// the state to determine if a pod is running.
@@ -770,6 +915,7 @@ func (c *configWatcher) Start(ctx context.Context) {
 	if len(nss) == 0 {
 		nss = []string{""}
 	}
+	c.queue = newWorkQueue(env.WatcherShards)
 
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
@@ -789,50 +935,67 @@ func (c *configWatcher) Start(ctx context.Context) {
 	c.dps = make([]cache.SharedIndexInformer, len(nss))
 	c.rss = make([]cache.SharedIndexInformer, len(nss))
 	c.sss = make([]cache.SharedIndexInformer, len(nss))
+	c.dss = make([]cache.SharedIndexInformer, len(nss))
+	c.dcs = make([]cache.SharedIndexInformer, 0, len(nss))
 	for i, ns := range nss {
 		c.cms[i] = c.startConfigMap(ctx, ns)
 		c.svs[i] = c.startServices(ctx, ns)
 		c.dps[i] = c.startDeployments(ctx, ns)
 		c.rss[i] = c.startReplicaSets(ctx, ns)
 		c.sss[i] = c.startStatefulSets(ctx, ns)
+		c.dss[i] = c.startDaemonSets(ctx, ns)
 		c.startPods(ctx, ns)
 		f := informer.GetFactory(ctx, ns)
 		f.Start(ctx.Done())
 		f.WaitForCacheSync(ctx.Done())
+		if ix := c.startDeploymentConfigs(ctx, ns); ix != nil {
+			c.dcs = append(c.dcs, ix)
+			go ix.Run(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), ix.HasSynced)
+		}
 	}
 }
 
 func (c *configWatcher) DeleteMapsAndRolloutAll(ctx context.Context) {
 	c.cancel() // No more updates from watcher
-	now := meta.NewDeleteOptions(0)
-	api := k8sapi.GetK8sInterface(ctx).CoreV1()
 	c.nsLocks.Range(func(ns string, lock *sync.RWMutex) bool {
 		lock.Lock()
 		defer lock.Unlock()
-		wlm, err := data(ctx, ns)
+		c.deleteMapAndRollout(ctx, ns)
+		return true
+	})
+}
+
+// deleteMapAndRollout deletes the telepresence-agents ConfigMap of the given namespace and triggers
+// a rollout of the workloads that have agents, so that they restart without them. The caller must
+// hold the namespace lock.
+func (c *configWatcher) deleteMapAndRollout(ctx context.Context, ns string) {
+	wlm, err := data(ctx, ns)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to get configmap %s.%s: %v", agentconfig.ConfigMap, ns, err)
+		return
+	}
+	if wlm == nil {
+		return
+	}
+	for k, v := range wlm {
+		e := &entry{name: k, namespace: ns, value: v}
+		scx, wl, err := e.workload(ctx)
 		if err != nil {
-			dlog.Errorf(ctx, "unable to get configmap %s.%s: %v", agentconfig.ConfigMap, ns, err)
-			return true
-		}
-		for k, v := range wlm {
-			e := &entry{name: k, namespace: ns, value: v}
-			scx, wl, err := e.workload(ctx)
-			if err != nil {
-				if !errors.IsNotFound(err) {
-					dlog.Errorf(ctx, "unable to get workload for %s.%s %s: %v", k, ns, v, err)
-				}
-				continue
-			}
-			ac := scx.AgentConfig()
-			if ac.Create || ac.Manual {
-				// Deleted before it was generated or manually added, just ignore
-				continue
+			if !errors.IsNotFound(err) {
+				dlog.Errorf(ctx, "unable to get workload for %s.%s %s: %v", k, ns, v, err)
 			}
-			c.triggerRollout(ctx, wl, nil)
+			continue
 		}
-		if err := api.ConfigMaps(ns).Delete(ctx, agentconfig.ConfigMap, *now); err != nil {
-			dlog.Errorf(ctx, "unable to delete ConfigMap %s-%s: %v", agentconfig.ConfigMap, ns, err)
+		ac := scx.AgentConfig()
+		if ac.Create || ac.Manual {
+			// Deleted before it was generated or manually added, just ignore
+			continue
 		}
-		return true
-	})
+		c.triggerRollout(ctx, wl, nil)
+	}
+	api := k8sapi.GetK8sInterface(ctx).CoreV1()
+	if err := api.ConfigMaps(ns).Delete(ctx, agentconfig.ConfigMap, *meta.NewDeleteOptions(0)); err != nil {
+		dlog.Errorf(ctx, "unable to delete ConfigMap %s-%s: %v", agentconfig.ConfigMap, ns, err)
+	}
 }
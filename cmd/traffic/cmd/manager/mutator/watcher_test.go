@@ -0,0 +1,56 @@
+package mutator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestRestoreReplaced(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	entry := func(name string, replace bool) string {
+		yml, err := (&agentconfig.Sidecar{
+			AgentName:    name,
+			Namespace:    "default",
+			WorkloadName: name,
+			WorkloadKind: "Deployment",
+			Containers: []*agentconfig.Container{
+				{Name: "app", Replace: agentconfig.ReplacePolicy(replace)},
+				{Name: "other"},
+			},
+		}).Marshal()
+		require.NoError(t, err)
+		return string(yml)
+	}
+	cm := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Name: agentconfig.ConfigMap, Namespace: "default"},
+		Data: map[string]string{
+			"echo":     entry("echo", false),
+			"replaced": entry("replaced", true),
+		},
+	}
+	echo := cm.Data["echo"]
+
+	intercepted := func(name, _ string) bool { return name == "replaced" }
+	changed, err := restoreReplaced(ctx, cm, intercepted)
+	require.NoError(t, err)
+	assert.False(t, changed, "an intercepted container is not restored")
+	assert.Equal(t, entry("replaced", true), cm.Data["replaced"])
+
+	notIntercepted := func(string, string) bool { return false }
+	changed, err = restoreReplaced(ctx, cm, notIntercepted)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, echo, cm.Data["echo"])
+	assert.Equal(t, entry("replaced", false), cm.Data["replaced"])
+
+	changed, err = restoreReplaced(ctx, cm, notIntercepted)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
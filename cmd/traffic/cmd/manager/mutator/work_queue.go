@@ -0,0 +1,160 @@
+package mutator
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShardCount is the number of shards used by the watcher's work queue unless configured otherwise.
+const DefaultShardCount = 8
+
+// task is a unit of work that is performed by a shard worker.
+type task interface {
+	run(ctx context.Context)
+}
+
+// coalescer is implemented by tasks that must retain information from the pending task that they replace.
+type coalescer interface {
+	coalesce(pending task) task
+}
+
+// taskFunc is a task that doesn't need to retain anything from the task that it replaces.
+type taskFunc func(ctx context.Context)
+
+func (f taskFunc) run(ctx context.Context) {
+	f(ctx)
+}
+
+type shard struct {
+	sync.Mutex
+	pending map[workloadKey]task
+	order   []workloadKey
+	wakeup  chan struct{}
+}
+
+// workQueue distributes the work caused by informer events over a fixed number of shards. Each shard
+// is served by one worker, so the number of concurrent tasks is bounded by the number of shards, and
+// the informer's event handlers never block. Bursts of events for the same object, typically caused
+// by a rollout, are coalesced into one task.
+type workQueue struct {
+	shards    []*shard
+	coalesced atomic.Uint64
+	processed atomic.Uint64
+}
+
+func newWorkQueue(shardCount int) *workQueue {
+	if shardCount < 1 {
+		shardCount = DefaultShardCount
+	}
+	q := &workQueue{shards: make([]*shard, shardCount)}
+	for i := range q.shards {
+		q.shards[i] = &shard{
+			pending: make(map[workloadKey]task),
+			wakeup:  make(chan struct{}, 1),
+		}
+	}
+	return q
+}
+
+func (q *workQueue) shardFor(k workloadKey) *shard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(k.namespace))
+	_, _ = h.Write([]byte{'/'})
+	_, _ = h.Write([]byte(k.name))
+	return q.shards[h.Sum32()%uint32(len(q.shards))]
+}
+
+// enqueue adds the given task to the shard that the key belongs to. A pending task with the same key is
+// replaced. All tasks for the same namespace and name end up in the same shard, so they are never run
+// concurrently.
+func (q *workQueue) enqueue(k workloadKey, t task) {
+	s := q.shardFor(k)
+	s.Lock()
+	if pt, ok := s.pending[k]; ok {
+		if c, ok := t.(coalescer); ok {
+			t = c.coalesce(pt)
+		}
+		q.coalesced.Add(1)
+	} else {
+		s.order = append(s.order, k)
+	}
+	s.pending[k] = t
+	s.Unlock()
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// run starts one worker per shard. The workers stop when the context is cancelled.
+func (q *workQueue) run(ctx context.Context) {
+	for _, s := range q.shards {
+		go q.serve(ctx, s)
+	}
+}
+
+func (q *workQueue) serve(ctx context.Context, s *shard) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wakeup:
+		}
+		for ctx.Err() == nil {
+			s.Lock()
+			if len(s.order) == 0 {
+				s.Unlock()
+				break
+			}
+			k := s.order[0]
+			s.order = s.order[1:]
+			t := s.pending[k]
+			delete(s.pending, k)
+			s.Unlock()
+			t.run(ctx)
+			q.processed.Add(1)
+		}
+	}
+}
+
+// depths returns the number of pending tasks in each shard.
+func (q *workQueue) depths() []int {
+	ds := make([]int, len(q.shards))
+	for i, s := range q.shards {
+		s.Lock()
+		ds[i] = len(s.order)
+		s.Unlock()
+	}
+	return ds
+}
+
+// QueueStats describes the state of the watcher's work queue.
+type QueueStats struct {
+	// Pending is the total number of pending tasks.
+	Pending int
+
+	// MaxShardPending is the number of pending tasks in the shard that has the most.
+	MaxShardPending int
+
+	// Coalesced is the number of tasks that have been replaced by a later task with the same key.
+	Coalesced uint64
+
+	// Processed is the number of tasks that have been run.
+	Processed uint64
+}
+
+func (q *workQueue) stats() QueueStats {
+	st := QueueStats{
+		Coalesced: q.coalesced.Load(),
+		Processed: q.processed.Load(),
+	}
+	for _, d := range q.depths() {
+		st.Pending += d
+		if d > st.MaxShardPending {
+			st.MaxShardPending = d
+		}
+	}
+	return st
+}
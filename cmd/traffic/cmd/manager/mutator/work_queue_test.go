@@ -0,0 +1,62 @@
+package mutator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTask struct {
+	mu    *sync.Mutex
+	ran   *[]string
+	value string
+	first string
+}
+
+func (r *recordingTask) run(context.Context) {
+	r.mu.Lock()
+	*r.ran = append(*r.ran, r.first+">"+r.value)
+	r.mu.Unlock()
+}
+
+func (r *recordingTask) coalesce(pending task) task {
+	if pr, ok := pending.(*recordingTask); ok {
+		r.first = pr.first
+	}
+	return r
+}
+
+func Test_workQueue(t *testing.T) {
+	q := newWorkQueue(4)
+	mu := sync.Mutex{}
+	var ran []string
+	add := func(name, value string) {
+		q.enqueue(workloadKey{name: name, namespace: "default", kind: "Deployment"},
+			&recordingTask{mu: &mu, ran: &ran, value: value, first: value})
+	}
+
+	// Nothing runs until the workers are started, so these are coalesced
+	for i := 0; i < 10; i++ {
+		add("a", string(rune('0'+i)))
+	}
+	add("b", "x")
+	st := q.stats()
+	assert.Equal(t, 2, st.Pending)
+	assert.Equal(t, uint64(9), st.Coalesced)
+	assert.Len(t, q.depths(), 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.run(ctx)
+	require.Eventually(t, func() bool {
+		return q.stats().Processed == 2
+	}, 5*time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"0>9", "x>x"}, ran)
+	mu.Unlock()
+	assert.Equal(t, 0, q.stats().Pending)
+}
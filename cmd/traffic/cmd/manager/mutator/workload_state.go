@@ -5,6 +5,8 @@ import (
 	core "k8s.io/api/core/v1"
 
 	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 )
 
 type WorkloadState int
@@ -49,6 +51,33 @@ func statefulSetState(d *appsv1.StatefulSet) WorkloadState {
 	return WorkloadStateAvailable
 }
 
+func daemonSetState(d *appsv1.DaemonSet) WorkloadState {
+	if d.Status.NumberUnavailable > 0 && d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled {
+		return WorkloadStateProgressing
+	}
+	return WorkloadStateAvailable
+}
+
+func deploymentConfigState(d *openshift.DeploymentConfig) WorkloadState {
+	for _, c := range d.Status.Conditions {
+		switch c.Type {
+		case openshift.DeploymentProgressing:
+			if c.Status == core.ConditionTrue {
+				return WorkloadStateProgressing
+			}
+		case openshift.DeploymentAvailable:
+			if c.Status == core.ConditionTrue {
+				return WorkloadStateAvailable
+			}
+		case openshift.DeploymentReplicaFailure:
+			if c.Status == core.ConditionTrue {
+				return WorkloadStateFailure
+			}
+		}
+	}
+	return WorkloadStateUnknown
+}
+
 func GetWorkloadState(wl k8sapi.Workload) WorkloadState {
 	if d, ok := k8sapi.DeploymentImpl(wl); ok {
 		return deploymentState(d)
@@ -59,5 +88,11 @@ func GetWorkloadState(wl k8sapi.Workload) WorkloadState {
 	if s, ok := k8sapi.StatefulSetImpl(wl); ok {
 		return statefulSetState(s)
 	}
+	if d, ok := agentmap.DaemonSetImpl(wl); ok {
+		return daemonSetState(d)
+	}
+	if d, ok := agentmap.DeploymentConfigImpl(wl); ok {
+		return deploymentConfigState(d)
+	}
 	return WorkloadStateUnknown
 }
@@ -15,9 +15,11 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 )
 
 func (c *configWatcher) startDeployments(ctx context.Context, ns string) cache.SharedIndexInformer {
@@ -84,9 +86,55 @@ func (c *configWatcher) startStatefulSets(ctx context.Context, ns string) cache.
 	return ix
 }
 
+func (c *configWatcher) startDaemonSets(ctx context.Context, ns string) cache.SharedIndexInformer {
+	f := informer.GetFactory(ctx, ns)
+	ix := f.Apps().V1().DaemonSets().Informer()
+	_ = ix.SetTransform(func(o any) (any, error) {
+		// Strip the parts of the daemonset that we don't care about. Saves memory
+		if dep, ok := o.(*apps.DaemonSet); ok {
+			om := &dep.ObjectMeta
+			if an := om.Annotations; an != nil {
+				delete(an, core.LastAppliedConfigAnnotation)
+			}
+			dep.ManagedFields = nil
+			dep.Finalizers = nil
+		}
+		return o, nil
+	})
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for DaemonSet %s: %v", whereWeWatch(ns), err)
+	})
+	return ix
+}
+
+// startDeploymentConfigs returns the informer for OpenShift DeploymentConfigs, or nil when the cluster
+// doesn't serve DeploymentConfigs. The informer is started by the caller.
+func (c *configWatcher) startDeploymentConfigs(ctx context.Context, ns string) cache.SharedIndexInformer {
+	ix := openshift.GetInformer(ctx, ns)
+	if ix == nil {
+		return nil
+	}
+	_ = ix.SetTransform(func(o any) (any, error) {
+		// Strip the parts of the deploymentconfig that we don't care about. Saves memory
+		if dep, ok := o.(*openshift.DeploymentConfig); ok {
+			om := &dep.ObjectMeta
+			if an := om.Annotations; an != nil {
+				delete(an, core.LastAppliedConfigAnnotation)
+			}
+			dep.ManagedFields = nil
+			dep.Finalizers = nil
+		}
+		return o, nil
+	})
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for DeploymentConfig %s: %v", whereWeWatch(ns), err)
+	})
+	return ix
+}
+
 func WorkloadFromAny(obj any) (k8sapi.Workload, bool) {
 	if ro, ok := obj.(runtime.Object); ok {
-		if wl, err := k8sapi.WrapWorkload(ro); err == nil {
+		if wl, err := agentmap.WrapWorkload(ro); err == nil {
 			return wl, true
 		}
 	}
@@ -97,25 +145,25 @@ func (c *configWatcher) watchWorkloads(ctx context.Context, ix cache.SharedIndex
 	_, err := ix.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj any) {
-				if wl, ok := WorkloadFromAny(obj); ok && len(wl.GetOwnerReferences()) == 0 {
-					c.updateWorkload(ctx, wl, nil, GetWorkloadState(wl))
+				if wl, ok := WorkloadFromAny(obj); ok && len(wl.GetOwnerReferences()) == 0 && namespaces.IsManaged(ctx, wl.GetNamespace()) {
+					c.queue.enqueue(keyOfWorkload(wl), &workloadUpdate{c: c, wl: wl})
 				}
 			},
 			DeleteFunc: func(obj any) {
 				if wl, ok := WorkloadFromAny(obj); ok {
 					if len(wl.GetOwnerReferences()) == 0 {
-						c.deleteWorkload(ctx, wl)
+						c.enqueueDeleteWorkload(wl)
 					}
 				} else if dfsu, ok := obj.(*cache.DeletedFinalStateUnknown); ok {
 					if wl, ok = WorkloadFromAny(dfsu.Obj); ok && len(wl.GetOwnerReferences()) == 0 {
-						c.deleteWorkload(ctx, wl)
+						c.enqueueDeleteWorkload(wl)
 					}
 				}
 			},
 			UpdateFunc: func(oldObj, newObj any) {
-				if wl, ok := WorkloadFromAny(newObj); ok && len(wl.GetOwnerReferences()) == 0 {
+				if wl, ok := WorkloadFromAny(newObj); ok && len(wl.GetOwnerReferences()) == 0 && namespaces.IsManaged(ctx, wl.GetNamespace()) {
 					if oldWl, ok := WorkloadFromAny(oldObj); ok {
-						c.updateWorkload(ctx, wl, oldWl, GetWorkloadState(wl))
+						c.queue.enqueue(keyOfWorkload(wl), &workloadUpdate{c: c, wl: wl, oldWl: oldWl})
 					}
 				}
 			},
@@ -123,6 +171,40 @@ func (c *configWatcher) watchWorkloads(ctx context.Context, ix cache.SharedIndex
 	return err
 }
 
+func keyOfWorkload(wl k8sapi.Workload) workloadKey {
+	return workloadKey{
+		name:      wl.GetName(),
+		namespace: wl.GetNamespace(),
+		kind:      wl.GetKind(),
+	}
+}
+
+// workloadUpdate is the task that updates the config entry for an added or modified workload.
+type workloadUpdate struct {
+	c     *configWatcher
+	wl    k8sapi.Workload
+	oldWl k8sapi.Workload
+}
+
+func (u *workloadUpdate) run(ctx context.Context) {
+	u.c.updateWorkload(ctx, u.wl, u.oldWl, GetWorkloadState(u.wl))
+}
+
+// coalesce retains the old workload of the pending update, so that the comparison made by updateWorkload
+// spans all the modifications that were coalesced.
+func (u *workloadUpdate) coalesce(pending task) task {
+	if pu, ok := pending.(*workloadUpdate); ok {
+		u.oldWl = pu.oldWl
+	}
+	return u
+}
+
+func (c *configWatcher) enqueueDeleteWorkload(wl k8sapi.Workload) {
+	c.queue.enqueue(keyOfWorkload(wl), taskFunc(func(ctx context.Context) {
+		c.deleteWorkload(ctx, wl)
+	}))
+}
+
 func (c *configWatcher) deleteWorkload(ctx context.Context, wl k8sapi.Workload) {
 	scx, err := c.Get(ctx, wl.GetName(), wl.GetNamespace())
 	if err != nil {
@@ -0,0 +1,134 @@
+// Package namespaces keeps track of the namespaces that a traffic-manager manages when those namespaces
+// are selected using a label selector rather than listed explicitly. Namespaces start and stop being
+// managed as they gain or lose the labels of the selector.
+package namespaces
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+// A Listener is called when a namespace starts or stops being managed. Listeners are called from the
+// informer's event handler and must not block.
+type Listener func(ns string, managed bool)
+
+// Watcher maintains the set of namespaces that match a label selector.
+type Watcher struct {
+	sync.RWMutex
+	selector  labels.Selector
+	managed   map[string]struct{}
+	listeners []Listener
+}
+
+// NewWatcher returns a Watcher that uses the Namespaces informer of the cluster-wide informer factory
+// found in the given context. The factory must be started after this call.
+func NewWatcher(ctx context.Context, selector labels.Selector) (*Watcher, error) {
+	w := &Watcher{selector: selector, managed: make(map[string]struct{})}
+	ix := informer.GetFactory(ctx, "").Core().V1().Namespaces().Informer()
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for namespaces: %v", err)
+	})
+	_, err := ix.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if ns, ok := obj.(*core.Namespace); ok {
+				w.update(ctx, ns.Name, w.matches(ns))
+			}
+		},
+		UpdateFunc: func(_, newObj any) {
+			if ns, ok := newObj.(*core.Namespace); ok {
+				w.update(ctx, ns.Name, w.matches(ns))
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if dfsu, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = dfsu.Obj
+			}
+			if ns, ok := obj.(*core.Namespace); ok {
+				w.update(ctx, ns.Name, false)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Watcher) matches(ns *core.Namespace) bool {
+	return ns.DeletionTimestamp == nil && w.selector.Matches(labels.Set(ns.Labels))
+}
+
+func (w *Watcher) update(ctx context.Context, ns string, managed bool) {
+	w.Lock()
+	if _, ok := w.managed[ns]; ok == managed {
+		w.Unlock()
+		return
+	}
+	if managed {
+		w.managed[ns] = struct{}{}
+		dlog.Infof(ctx, "Namespace %s is now managed", ns)
+	} else {
+		delete(w.managed, ns)
+		dlog.Infof(ctx, "Namespace %s is no longer managed", ns)
+	}
+	ls := w.listeners
+	w.Unlock()
+	for _, l := range ls {
+		l(ns, managed)
+	}
+}
+
+// IsManaged returns true if the given namespace matches the selector.
+func (w *Watcher) IsManaged(ns string) bool {
+	w.RLock()
+	_, ok := w.managed[ns]
+	w.RUnlock()
+	return ok
+}
+
+// List returns the sorted names of the managed namespaces.
+func (w *Watcher) List() []string {
+	w.RLock()
+	nss := make([]string, 0, len(w.managed))
+	for ns := range w.managed {
+		nss = append(nss, ns)
+	}
+	w.RUnlock()
+	slices.Sort(nss)
+	return nss
+}
+
+// Subscribe adds a Listener that is called for all subsequent changes.
+func (w *Watcher) Subscribe(l Listener) {
+	w.Lock()
+	w.listeners = append(slices.Clip(w.listeners), l)
+	w.Unlock()
+}
+
+type watcherKey struct{}
+
+// WithWatcher returns a context that carries the given Watcher.
+func WithWatcher(ctx context.Context, w *Watcher) context.Context {
+	return context.WithValue(ctx, watcherKey{}, w)
+}
+
+// GetWatcher returns the Watcher found in the given context, or nil if the namespaces aren't selected
+// using a label selector.
+func GetWatcher(ctx context.Context) *Watcher {
+	w, _ := ctx.Value(watcherKey{}).(*Watcher)
+	return w
+}
+
+// IsManaged returns true unless the context carries a Watcher that doesn't manage the given namespace.
+func IsManaged(ctx context.Context, ns string) bool {
+	w := GetWatcher(ctx)
+	return w == nil || w.IsManaged(ns)
+}
@@ -0,0 +1,79 @@
+package namespaces
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+func namespace(name string, lbs map[string]string) *core.Namespace {
+	return &core.Namespace{ObjectMeta: meta.ObjectMeta{Name: name, Labels: lbs}}
+}
+
+func TestWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	ki := fake.NewSimpleClientset(
+		namespace("dev", map[string]string{"telepresence": "enabled"}),
+		namespace("prod", nil),
+	)
+	ctx = informer.WithFactory(k8sapi.WithK8sInterface(ctx, ki), "")
+
+	selector, err := labels.Parse("telepresence=enabled")
+	require.NoError(t, err)
+	w, err := NewWatcher(ctx, selector)
+	require.NoError(t, err)
+
+	type change struct {
+		ns      string
+		managed bool
+	}
+	changes := make(chan change, 10)
+	w.Subscribe(func(ns string, managed bool) {
+		changes <- change{ns, managed}
+	})
+	f := informer.GetFactory(ctx, "")
+	f.Start(ctx.Done())
+	f.WaitForCacheSync(ctx.Done())
+
+	expectChange := func(expected change) {
+		t.Helper()
+		select {
+		case c := <-changes:
+			assert.Equal(t, expected, c)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %v", expected)
+		}
+	}
+	expectChange(change{"dev", true})
+	assert.True(t, w.IsManaged("dev"))
+	assert.False(t, w.IsManaged("prod"))
+	assert.Equal(t, []string{"dev"}, w.List())
+
+	api := ki.CoreV1().Namespaces()
+	_, err = api.Update(ctx, namespace("prod", map[string]string{"telepresence": "enabled"}), meta.UpdateOptions{})
+	require.NoError(t, err)
+	expectChange(change{"prod", true})
+
+	_, err = api.Update(ctx, namespace("dev", nil), meta.UpdateOptions{})
+	require.NoError(t, err)
+	expectChange(change{"dev", false})
+
+	require.NoError(t, api.Delete(ctx, "prod", meta.DeleteOptions{}))
+	expectChange(change{"prod", false})
+	assert.Empty(t, w.List())
+
+	assert.True(t, IsManaged(ctx, "dev"), "everything is managed without a watcher")
+	assert.False(t, IsManaged(WithWatcher(ctx, w), "dev"))
+}
@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+
+	dns2 "github.com/miekg/dns"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+// clusterNameLabels returns the labels of the given fully qualified name, with the cluster domain
+// removed when present.
+func clusterNameLabels(name, clusterDomain string) []string {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, clusterDomain), ".")
+	return strings.Split(name, ".")
+}
+
+// lookupPodName answers A and AAAA queries for pod names from the traffic-manager's pod cache, so that
+// they resolve regardless of how the cluster's DNS is configured to treat pod records. Two forms are
+// recognized, both with an optional cluster domain suffix:
+//
+//	<a-b-c-d>.<namespace>.pod             the pod with IP a.b.c.d
+//	<hostname>.<subdomain>.<namespace>.svc the pod with the given hostname and subdomain, e.g. a StatefulSet pod
+//
+// The returned bool is false when the request isn't for a pod name, or when no such pod is found, in
+// which case the cluster's DNS gets to decide.
+func (s *service) lookupPodName(ctx context.Context, request *rpc.DNSRequest) (dnsproxy.RRs, int, bool) {
+	qType := uint16(request.Type)
+	if qType != dns2.TypeA && qType != dns2.TypeAAAA {
+		return nil, 0, false
+	}
+	var ips []net.IP
+	switch ls := clusterNameLabels(request.Name, s.ClusterInfo().ClusterDomain()); {
+	case len(ls) == 3 && ls[2] == "pod":
+		if ip := podNameIP(ls[0]); ip != nil && podWithIPExists(ctx, ls[1], ip) {
+			ips = []net.IP{ip}
+		}
+	case len(ls) == 4 && ls[3] == "svc":
+		ips = subdomainPodIPs(ctx, ls[2], ls[0], ls[1])
+	}
+	if len(ips) == 0 {
+		return nil, 0, false
+	}
+	var rrs dnsproxy.RRs
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			if qType == dns2.TypeA {
+				rrs = append(rrs, &dns2.A{Hdr: dnsproxy.NewHeader(request.Name, qType), A: ip4})
+			}
+		} else if qType == dns2.TypeAAAA {
+			rrs = append(rrs, &dns2.AAAA{Hdr: dnsproxy.NewHeader(request.Name, qType), AAAA: ip})
+		}
+	}
+	return rrs, dns2.RcodeSuccess, true
+}
+
+// podNameIP returns the IP of a pod name label, i.e. an IP where the dots or colons are replaced by
+// dashes, or nil if the label isn't such an IP.
+func podNameIP(label string) net.IP {
+	if ip := net.ParseIP(strings.ReplaceAll(label, "-", ".")); ip != nil {
+		return ip
+	}
+	return net.ParseIP(strings.ReplaceAll(label, "-", ":"))
+}
+
+func listPods(ctx context.Context, ns string) []*core.Pod {
+	f := informer.GetFactory(ctx, ns)
+	if f == nil {
+		return nil
+	}
+	pods, err := f.Core().V1().Pods().Lister().Pods(ns).List(labels.Everything())
+	if err != nil {
+		dlog.Errorf(ctx, "unable to list pods in namespace %s: %v", ns, err)
+		return nil
+	}
+	return pods
+}
+
+func podIPs(pod *core.Pod) []net.IP {
+	ips := make([]net.IP, 0, len(pod.Status.PodIPs))
+	for _, pip := range pod.Status.PodIPs {
+		if ip := net.ParseIP(pip.IP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// podWithIPExists returns true if a pod in the given namespace has the given IP.
+func podWithIPExists(ctx context.Context, ns string, ip net.IP) bool {
+	return slices.ContainsFunc(listPods(ctx, ns), func(pod *core.Pod) bool {
+		return slices.ContainsFunc(podIPs(pod), ip.Equal)
+	})
+}
+
+// subdomainPodIPs returns the IPs of the pods in the given namespace that have the given hostname and
+// subdomain, and that are published by the headless service that is named by the subdomain.
+func subdomainPodIPs(ctx context.Context, ns, hostname, subdomain string) []net.IP {
+	f := informer.GetFactory(ctx, ns)
+	if f == nil {
+		return nil
+	}
+	svc, err := f.Core().V1().Services().Lister().Services(ns).Get(subdomain)
+	if err != nil || svc.Spec.ClusterIP != core.ClusterIPNone {
+		return nil
+	}
+	var ips []net.IP
+	for _, pod := range listPods(ctx, ns) {
+		if pod.Spec.Hostname == hostname && pod.Spec.Subdomain == subdomain && pod.DeletionTimestamp == nil &&
+			(svc.Spec.PublishNotReadyAddresses || podIsReady(pod)) {
+			ips = append(ips, podIPs(pod)...)
+		}
+	}
+	return ips
+}
+
+func podIsReady(pod *core.Pod) bool {
+	return slices.ContainsFunc(pod.Status.Conditions, func(c core.PodCondition) bool {
+		return c.Type == core.PodReady && c.Status == core.ConditionTrue
+	})
+}
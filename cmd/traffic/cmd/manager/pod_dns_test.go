@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+func Test_podNameIP(t *testing.T) {
+	assert.Equal(t, net.ParseIP("10.1.2.3"), podNameIP("10-1-2-3"))
+	assert.Equal(t, net.ParseIP("2001:db8::1"), podNameIP("2001-db8--1"))
+	assert.Nil(t, podNameIP("web-0"))
+}
+
+func Test_clusterNameLabels(t *testing.T) {
+	assert.Equal(t, []string{"10-1-2-3", "default", "pod"}, clusterNameLabels("10-1-2-3.default.pod.cluster.local.", "cluster.local."))
+	assert.Equal(t, []string{"web-0", "nginx", "default", "svc"}, clusterNameLabels("web-0.nginx.default.svc.", "cluster.local."))
+}
+
+func Test_podDNS(t *testing.T) {
+	pod := func(name, ip string, ready bool) *core.Pod {
+		st := core.ConditionFalse
+		if ready {
+			st = core.ConditionTrue
+		}
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       core.PodSpec{Hostname: name, Subdomain: "nginx"},
+			Status: core.PodStatus{
+				PodIP:      ip,
+				PodIPs:     []core.PodIP{{IP: ip}},
+				Conditions: []core.PodCondition{{Type: core.PodReady, Status: st}},
+			},
+		}
+	}
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset(
+		&core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Spec:       core.ServiceSpec{ClusterIP: core.ClusterIPNone, Selector: map[string]string{"app": "nginx"}},
+		},
+		pod("web-0", "10.1.2.3", true),
+		pod("web-1", "10.1.2.4", false),
+	))
+	ctx = informer.WithFactory(ctx, "")
+	f := informer.GetFactory(ctx, "")
+	f.Core().V1().Services().Informer()
+	f.Core().V1().Pods().Informer()
+	f.Start(ctx.Done())
+	f.WaitForCacheSync(ctx.Done())
+
+	assert.True(t, podWithIPExists(ctx, "default", net.ParseIP("10.1.2.4")))
+	assert.False(t, podWithIPExists(ctx, "default", net.ParseIP("10.1.2.5")))
+	assert.False(t, podWithIPExists(ctx, "other", net.ParseIP("10.1.2.3")))
+
+	assert.Equal(t, []net.IP{net.ParseIP("10.1.2.3")}, subdomainPodIPs(ctx, "default", "web-0", "nginx"))
+	assert.Empty(t, subdomainPodIPs(ctx, "default", "web-1", "nginx"), "pod isn't ready")
+	assert.Empty(t, subdomainPodIPs(ctx, "default", "web-0", "other"))
+}
@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// quicEndpoint is the QUIC endpoint of the traffic-manager. Clients that use it open their tunnels as streams
+// of a QUIC connection instead of as gRPC Tunnel calls. A connection is authenticated using a token that the
+// client obtains using the QUICEndpoint call, and it can only carry the tunnels of the session of that token.
+type quicEndpoint struct {
+	sync.Mutex
+	listener    *quic.Listener
+	endpoint    string
+	fingerprint []byte
+	tokens      map[string]string // session ID keyed by token
+	sessions    map[string]string // token keyed by session ID
+}
+
+func newQUICEndpoint(ctx context.Context, env *managerutil.Env) (*quicEndpoint, error) {
+	if err := checkExternalEndpoint("QUIC", env.QUICEndpoint, env); err != nil {
+		return nil, err
+	}
+	cert, fp, err := tunnel.GenerateQUICCertificate()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := quic.ListenAddr(":"+strconv.Itoa(int(env.QUICPort)), tunnel.QUICServerConfig(cert), &quic.Config{})
+	if err != nil {
+		return nil, err
+	}
+	context.AfterFunc(ctx, func() { _ = ln.Close() })
+	dlog.Infof(ctx, "QUIC endpoint listening on port %d, advertised as %s", env.QUICPort, env.QUICEndpoint)
+	return &quicEndpoint{
+		listener:    ln,
+		endpoint:    env.QUICEndpoint,
+		fingerprint: fp,
+		tokens:      make(map[string]string),
+		sessions:    make(map[string]string),
+	}, nil
+}
+
+// token returns the token of the given session. A new token is created for a session that has none, and
+// it's removed when the given done channel is closed.
+func (q *quicEndpoint) token(ctx context.Context, sessionID string, done <-chan struct{}) ([]byte, error) {
+	q.Lock()
+	defer q.Unlock()
+	if t, ok := q.sessions[sessionID]; ok {
+		return []byte(t), nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	t := string(b)
+	q.tokens[t] = sessionID
+	q.sessions[sessionID] = t
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			q.Lock()
+			delete(q.tokens, t)
+			delete(q.sessions, sessionID)
+			q.Unlock()
+		}
+	}()
+	return b, nil
+}
+
+// sessionOf returns the ID of the session that the given token belongs to.
+func (q *quicEndpoint) sessionOf(token []byte) (string, bool) {
+	q.Lock()
+	defer q.Unlock()
+	sessionID, ok := q.tokens[string(token)]
+	return sessionID, ok
+}
+
+// serveQUIC accepts the connections of the QUIC endpoint until the given context is done.
+func (s *service) serveQUIC(ctx context.Context) error {
+	q := s.quic
+	for {
+		conn, err := q.listener.Accept(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveQUICConn(ctx, conn)
+	}
+}
+
+// serveQUICConn authenticates the given connection, and then serves each stream of the connection as a tunnel
+// of the session that the connection belongs to. The connection is closed when the session ends.
+func (s *service) serveQUICConn(ctx context.Context, conn quic.Connection) {
+	var sessionID string
+	err := tunnel.AcceptQUICHandshake(ctx, conn, func(token []byte) (ok bool) {
+		sessionID, ok = s.quic.sessionOf(token)
+		return ok
+	})
+	if err != nil {
+		dlog.Debugf(ctx, "QUIC connection from %s rejected: %v", conn.RemoteAddr(), err)
+		_ = conn.CloseWithError(quic.ApplicationErrorCode(codes.Unauthenticated), "")
+		return
+	}
+	ctx = managerutil.WithSessionInfo(ctx, &rpc.SessionInfo{SessionId: sessionID})
+	done, err := s.state.SessionDone(sessionID)
+	if err != nil {
+		_ = conn.CloseWithError(quic.ApplicationErrorCode(codes.NotFound), "")
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.CloseWithError(0, "")
+	}()
+	dlog.Debugf(ctx, "QUIC connection from %s accepted", conn.RemoteAddr())
+	for {
+		qs, err := conn.AcceptStream(ctx)
+		if err != nil {
+			dlog.Debugf(ctx, "QUIC connection from %s ended: %v", conn.RemoteAddr(), err)
+			return
+		}
+		go func() {
+			if err := s.serveQUICStream(ctx, sessionID, qs); err != nil {
+				dlog.Error(ctx, err)
+			}
+		}()
+	}
+}
+
+func (s *service) serveQUICStream(ctx context.Context, sessionID string, qs quic.Stream) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := tunnel.NewServerStream(ctx, tunnel.NewQUICStream(ctx, qs))
+	if err != nil {
+		return fmt.Errorf("failed to connect QUIC stream: %w", err)
+	}
+	if stream.SessionID() != sessionID {
+		return fmt.Errorf("QUIC stream of session %s can't carry a tunnel of session %s", sessionID, stream.SessionID())
+	}
+	return s.state.Tunnel(ctx, stream)
+}
+
+func (s *service) QUICEndpoint(ctx context.Context, session *rpc.SessionInfo) (*rpc.QUICEndpointResponse, error) {
+	ctx = managerutil.WithSessionInfo(ctx, session)
+	dlog.Debug(ctx, "QUICEndpoint called")
+	if s.quic == nil {
+		return nil, status.Error(codes.Unimplemented, "QUIC is not enabled in the traffic-manager")
+	}
+	sessionID := session.GetSessionId()
+	if s.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	done, err := s.state.SessionDone(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.quic.token(s.ctx, sessionID, done)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &rpc.QUICEndpointResponse{
+		Endpoint:        s.quic.endpoint,
+		CertFingerprint: s.quic.fingerprint,
+		Token:           token,
+	}, nil
+}
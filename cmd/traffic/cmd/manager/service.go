@@ -2,30 +2,40 @@ package manager
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	dns2 "github.com/miekg/dns"
+	"github.com/puzpuzpuz/xsync/v3"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/datawire/dlib/derror"
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/audit"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/cluster"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/config"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -48,9 +58,14 @@ type Service interface {
 	ClusterInfo() cluster.Info
 
 	// unexported methods.
+	isStandby() bool
 	runConfigWatcher(context.Context) error
+	runInterceptSpecs(context.Context) error
+	restoreReplacedContainers(context.Context)
+	runLeaderElection(context.Context, func(context.Context) error) error
 	runSessionGCLoop(context.Context) error
 	serveHTTP(context.Context) error
+	serveQUIC(context.Context) error
 	servePrometheus(context.Context) error
 }
 
@@ -61,9 +76,23 @@ type service struct {
 	state              state.State
 	clusterInfo        cluster.Info
 	configWatcher      config.Watcher
+	health             *managerutil.Health
+	declarations       *declarations
 	activeHttpRequests int32
 	activeGrpcRequests int32
 
+	// standby is true while leader election is enabled and another traffic-manager is the leader.
+	standby atomic.Bool
+
+	// quic is the QUIC endpoint, or nil when QUIC isn't enabled.
+	quic *quicEndpoint
+
+	// wireGuard is the WireGuard endpoint, or nil when WireGuard isn't enabled.
+	wireGuard *wireGuard
+
+	// evicted holds the time of eviction of client sessions that were evicted using EvictClientSession.
+	evicted *xsync.MapOf[string, time.Time]
+
 	// Possibly extended version of the service. Use when calling interface methods.
 	self Service
 
@@ -80,8 +109,9 @@ func (wall) Now() time.Time {
 
 func NewService(ctx context.Context) (Service, *dgroup.Group, error) {
 	ret := &service{
-		clock: wall{},
-		id:    uuid.New().String(),
+		clock:   wall{},
+		id:      uuid.New().String(),
+		evicted: xsync.NewMapOf[string, time.Time](),
 	}
 
 	if managerutil.AgentInjectorEnabled(ctx) {
@@ -92,6 +122,26 @@ func NewService(ctx context.Context) (Service, *dgroup.Group, error) {
 		}
 	}
 	ret.configWatcher = config.NewWatcher(managerutil.GetEnv(ctx).ManagerNamespace)
+	ret.configWatcher.Subscribe(ret.managerConfigChanged)
+	ctx = config.WithWatcher(ctx, ret.configWatcher)
+	ret.standby.Store(managerutil.GetEnv(ctx).LeaderElection)
+	ret.health = newHealth(ctx)
+	if env := managerutil.GetEnv(ctx); env.QUICPort != 0 {
+		var err error
+		if ret.quic, err = newQUICEndpoint(ctx, env); err != nil {
+			return nil, nil, fmt.Errorf("unable to start QUIC endpoint: %w", err)
+		}
+	}
+	if env := managerutil.GetEnv(ctx); env.WireGuardPort != 0 {
+		var err error
+		if ret.wireGuard, err = newWireGuard(ctx, env); err != nil {
+			return nil, nil, fmt.Errorf("unable to start WireGuard endpoint: %w", err)
+		}
+	}
+	if managerutil.GetEnv(ctx).InterceptSpecsEnabled {
+		ret.declarations = newDeclarations()
+	}
+	ctx = managerutil.WithHealth(ctx, ret.health)
 	ret.ctx = ctx
 	// These are context dependent so build them once the pool is up
 	ret.clusterInfo = cluster.NewInfo(ctx)
@@ -132,6 +182,39 @@ func (s *service) runConfigWatcher(ctx context.Context) error {
 	return s.configWatcher.Run(ctx)
 }
 
+// managerConfigChanged applies the settings of a reloaded ManagerConfig that aren't read on demand.
+func (s *service) managerConfigChanged(ctx context.Context, mc *config.ManagerConfig) {
+	ac := config.ActiveManagerConfig(ctx, mc)
+	s.state.SetDefaultLogLevel(ctx, ac.LogLevel)
+
+	ir := managerutil.GetAgentImageRetriever(ctx)
+	if ir == nil {
+		return
+	}
+	var img string
+	if mc != nil {
+		img = mc.AgentImage
+	}
+	if is, ok := ir.(managerutil.ImageSetter); ok {
+		if err := is.SetImage(ctx, img); err != nil {
+			dlog.Errorf(ctx, "unable to reload the traffic-agent image: %v", err)
+		}
+	} else if img != "" {
+		dlog.Warnf(ctx, "the traffic-agent image cannot be changed without a restart of the traffic-manager")
+	}
+}
+
+// GetManagerConfig returns the active settings of the traffic-manager that can be reloaded from the
+// traffic-manager ConfigMap.
+func (s *service) GetManagerConfig(ctx context.Context, _ *empty.Empty) (*rpc.ManagerConfig, error) {
+	dlog.Debug(ctx, "GetManagerConfig called")
+	data, err := yaml.Marshal(config.ActiveManagerConfig(ctx, s.configWatcher.GetManagerConfig()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &rpc.ManagerConfig{ConfigYaml: data}, nil
+}
+
 // Version returns the version information of the Manager.
 func (*service) Version(context.Context, *empty.Empty) (*rpc.VersionInfo2, error) {
 	return &rpc.VersionInfo2{Name: DisplayName, Version: version.Version}, nil
@@ -165,17 +248,24 @@ func (s *service) GetTelepresenceAPI(ctx context.Context, e *empty.Empty) (*rpc.
 func (s *service) ArriveAsClient(ctx context.Context, client *rpc.ClientInfo) (*rpc.SessionInfo, error) {
 	dlog.Debugf(ctx, "ArriveAsClient called, namespace: %s", client.Namespace)
 
+	if s.isStandby() {
+		return nil, errStandby
+	}
+
 	if val := validateClient(client); val != "" {
 		return nil, status.Errorf(codes.InvalidArgument, val)
 	}
 
+	s.verifyClientIdentity(ctx, client)
 	installId := client.GetInstallId()
 
 	IncrementCounter(s.state.GetConnectCounter(), client.Name, client.InstallId)
 	SetGauge(s.state.GetConnectActiveStatus(), client.Name, client.InstallId, nil, 1)
 
+	sessionID := s.state.AddClient(client, s.clock.Now())
+	audit.Record(ctx, audit.ClientEvent(audit.ClientConnected, sessionID, client))
 	return &rpc.SessionInfo{
-		SessionId: s.state.AddClient(client, s.clock.Now()),
+		SessionId: sessionID,
 		ClusterId: s.clusterInfo.ID(),
 		InstallId: &installId,
 	}, nil
@@ -185,6 +275,10 @@ func (s *service) ArriveAsClient(ctx context.Context, client *rpc.ClientInfo) (*
 func (s *service) ArriveAsAgent(ctx context.Context, agent *rpc.AgentInfo) (*rpc.SessionInfo, error) {
 	dlog.Debugf(ctx, "ArriveAsAgent %s called", agent.PodName)
 
+	if s.isStandby() {
+		return nil, errStandby
+	}
+
 	if val := validateAgent(agent); val != "" {
 		return nil, status.Errorf(codes.InvalidArgument, val)
 	}
@@ -207,7 +301,8 @@ func (s *service) GetClientConfig(ctx context.Context, _ *empty.Empty) (*rpc.CLI
 	dlog.Debug(ctx, "GetClientConfig called")
 
 	return &rpc.CLIConfig{
-		ConfigYaml: s.configWatcher.GetClientConfigYaml(),
+		ConfigYaml:           s.configWatcher.GetClientConfigYaml(),
+		VerifyClientIdentity: s.verifiesClientIdentity(ctx),
 	}, nil
 }
 
@@ -217,6 +312,10 @@ func (s *service) Remain(ctx context.Context, req *rpc.RemainRequest) (*empty.Em
 	// dlog.Debug(ctx, "Remain called")
 	sessionID := req.GetSession().GetSessionId()
 	if ok := s.state.MarkSession(req, s.clock.Now()); !ok {
+		if _, ok = s.evicted.Load(sessionID); ok {
+			// Aborted tells the client that it must not attempt to refresh the session.
+			return nil, status.Errorf(codes.Aborted, "Session %q was evicted", sessionID)
+		}
 		return nil, status.Errorf(codes.NotFound, "Session %q not found", sessionID)
 	}
 
@@ -236,6 +335,88 @@ func (s *service) Depart(ctx context.Context, session *rpc.SessionInfo) (*empty.
 	return &empty.Empty{}, nil
 }
 
+// ListClientSessions returns the client sessions that are connected to the traffic-manager. The session
+// IDs are abbreviated unless the client of the given session is allowed to list sessions, because the ID
+// of a session is all that is needed to act on its behalf.
+func (s *service) ListClientSessions(ctx context.Context, session *rpc.SessionInfo) (*rpc.ClientSessionList, error) {
+	ctx = managerutil.WithSessionInfo(ctx, session)
+	dlog.Debug(ctx, "ListClientSessions called")
+	caller := s.state.GetClient(session.GetSessionId())
+	if caller == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", session.GetSessionId())
+	}
+	abbreviate := false
+	if err := s.authorizeSessionAdministration(ctx, caller, sessionListVerb); err != nil {
+		if status.Code(err) != codes.PermissionDenied {
+			return nil, err
+		}
+		dlog.Debug(ctx, err)
+		abbreviate = true
+	}
+	intercepts := s.state.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.Disposition != rpc.InterceptDispositionType_REMOVED
+	})
+	clients := s.state.GetAllClients()
+	sessions := make([]*rpc.ClientSession, 0, len(clients))
+	for sessionID, ci := range clients {
+		cs := &rpc.ClientSession{
+			SessionId:  sessionID,
+			Namespaces: []string{ci.Namespace},
+		}
+		if ss := s.state.GetSession(sessionID); ss != nil {
+			cs.LastActive = timestamppb.New(ss.LastMarked())
+		}
+		cs.Client = proto.Clone(ci).(*rpc.ClientInfo)
+		cs.Client.ApiKey = ""
+		for _, ii := range intercepts {
+			if ii.ClientSession.SessionId == sessionID {
+				cs.Intercepts = append(cs.Intercepts, ii.Spec.Name)
+				if !slices.Contains(cs.Namespaces, ii.Spec.Namespace) {
+					cs.Namespaces = append(cs.Namespaces, ii.Spec.Namespace)
+				}
+			}
+		}
+		sort.Strings(cs.Intercepts)
+		sort.Strings(cs.Namespaces)
+		sessions = append(sessions, cs)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].SessionId < sessions[j].SessionId
+	})
+	if abbreviate {
+		for _, cs := range sessions {
+			if len(cs.SessionId) > abbreviatedSessionIDLength {
+				cs.SessionId = cs.SessionId[:abbreviatedSessionIDLength]
+			}
+		}
+	}
+	return &rpc.ClientSessionList{Sessions: sessions}, nil
+}
+
+// EvictClientSession forcibly terminates a client session and removes its intercepts. The client
+// is told that its session was evicted the next time it calls Remain, so it won't refresh it. The
+// client that requests the eviction must be allowed to delete sessions.
+func (s *service) EvictClientSession(ctx context.Context, req *rpc.EvictClientSessionRequest) (*empty.Empty, error) {
+	ctx = managerutil.WithSessionInfo(ctx, req.GetSession())
+	sessionID := req.GetSessionId()
+	dlog.Debugf(ctx, "EvictClientSession called for session %s", sessionID)
+	caller := s.state.GetClient(req.GetSession().GetSessionId())
+	if caller == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", req.GetSession().GetSessionId())
+	}
+	if err := s.authorizeSessionAdministration(ctx, caller, sessionDeleteVerb); err != nil {
+		return nil, err
+	}
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	dlog.Infof(ctx, "Evicting client session %s of %s on behalf of %s", sessionID, client.Name, identity(caller))
+	s.evicted.Store(sessionID, s.clock.Now())
+	s.state.RemoveSession(ctx, sessionID)
+	return &empty.Empty{}, nil
+}
+
 // WatchAgentPods notifies a client of the set of known Agents.
 func (s *service) WatchAgentPods(session *rpc.SessionInfo, stream rpc.Manager_WatchAgentPodsServer) error {
 	ctx := managerutil.WithSessionInfo(stream.Context(), session)
@@ -491,6 +672,10 @@ func (s *service) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 					// Don't return intercepts for different agents.
 					return false
 				}
+				if !state.PodSelected(ctx, info.Spec, agent) {
+					// Don't return intercepts for pods that weren't selected.
+					return false
+				}
 				// Don't return intercepts that aren't in a "agent-owned" state.
 				switch info.Disposition {
 				case rpc.InterceptDispositionType_WAITING,
@@ -560,6 +745,14 @@ func (s *service) PrepareIntercept(ctx context.Context, request *rpc.CreateInter
 	dlog.Debugf(ctx, "PrepareIntercept %s called", request.InterceptSpec.Name)
 	span := trace.SpanFromContext(ctx)
 	tracing.RecordInterceptSpec(span, request.InterceptSpec)
+	sessionID := request.GetSession().GetSessionId()
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	if err = s.authorizeIntercept(ctx, client, request.InterceptSpec); err != nil {
+		return &rpc.PreparedIntercept{Error: err.Error(), ErrorCategory: int32(errcat.GetCategory(err))}, nil
+	}
 	return s.state.PrepareIntercept(ctx, request)
 }
 
@@ -592,6 +785,23 @@ func (s *service) CreateIntercept(ctx context.Context, ciReq *rpc.CreateIntercep
 		return nil, status.Errorf(codes.InvalidArgument, val)
 	}
 
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	if err := s.authorizeIntercept(ctx, client, spec); err != nil {
+		if errcat.GetCategory(err) == errcat.User {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if spec.Declaration != "" {
+		if err := s.adoptDeclaration(sessionID, spec); err != nil {
+			return nil, err
+		}
+	}
+
 	if ciReq.InterceptSpec.Replace {
 		_, err := s.state.PrepareIntercept(ctx, ciReq)
 		if err != nil {
@@ -615,6 +825,7 @@ func (s *service) CreateIntercept(ctx context.Context, ciReq *rpc.CreateIntercep
 		}
 	}
 
+	audit.Record(ctx, audit.InterceptEvent(audit.InterceptCreated, interceptInfo, client))
 	SetGauge(s.state.GetInterceptActiveStatus(), client.Name, client.InstallId, &spec.Name, 1)
 
 	IncrementInterceptCounterFunc(s.state.GetInterceptCounter(), client.Name, client.InstallId, spec)
@@ -693,7 +904,7 @@ func (s *service) ReviewIntercept(ctx context.Context, rIReq *rpc.ReviewIntercep
 
 	intercept := s.state.UpdateIntercept(ceptID, func(intercept *rpc.InterceptInfo) {
 		// Sanity check: The reviewing agent must be an agent for the intercept.
-		if intercept.Spec.Namespace != agent.Namespace || intercept.Spec.Agent != agent.Name {
+		if intercept.Spec.Namespace != agent.Namespace || intercept.Spec.Agent != agent.Name || !state.PodSelected(ctx, intercept.Spec, agent) {
 			return
 		}
 		if mutator.GetMap(ctx).IsBlacklisted(agent.PodName, agent.Namespace) {
@@ -708,6 +919,7 @@ func (s *service) ReviewIntercept(ctx context.Context, rIReq *rpc.ReviewIntercep
 			intercept.Message = rIReq.Message
 			intercept.PodIp = rIReq.PodIp
 			intercept.PodName = agent.PodName
+			intercept.NodeName = agent.NodeName
 			intercept.FtpPort = rIReq.FtpPort
 			intercept.SftpPort = rIReq.SftpPort
 			intercept.MountPoint = rIReq.MountPoint
@@ -749,6 +961,16 @@ func (s *service) Tunnel(server rpc.Manager_TunnelServer) error {
 	return s.state.Tunnel(ctx, stream)
 }
 
+func (s *service) MuxTunnel(server rpc.Manager_MuxTunnelServer) error {
+	return tunnel.ServeMux(server.Context(), server, func(ctx context.Context, gs tunnel.GRPCStream) error {
+		stream, err := tunnel.NewServerStream(ctx, gs)
+		if err != nil {
+			return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
+		}
+		return s.state.Tunnel(ctx, stream)
+	})
+}
+
 func (s *service) WatchDial(session *rpc.SessionInfo, stream rpc.Manager_WatchDialServer) error {
 	ctx := managerutil.WithSessionInfo(stream.Context(), session)
 	dlog.Debugf(ctx, "WatchDial called")
@@ -814,6 +1036,15 @@ func (s *service) LookupDNS(ctx context.Context, request *rpc.DNSRequest) (*rpc.
 	qtn := dns2.TypeToString[qType]
 	dlog.Debugf(ctx, "LookupDNS %s %s", request.Name, qtn)
 
+	if rrs, rCode, ok := s.lookupExternalName(ctx, request); ok {
+		dlog.Debugf(ctx, "LookupDNS of ExternalName service: %s %s -> %s %s", request.Name, qtn, dns2.RcodeToString[rCode], rrs)
+		return dnsproxy.ToRPC(rrs, rCode)
+	}
+	if rrs, rCode, ok := s.lookupPodName(ctx, request); ok {
+		dlog.Debugf(ctx, "LookupDNS of pod: %s %s -> %s", request.Name, qtn, rrs)
+		return dnsproxy.ToRPC(rrs, rCode)
+	}
+
 	rrs, rCode, err := s.state.AgentsLookupDNS(ctx, request.GetSession().GetSessionId(), request)
 	if err != nil {
 		dlog.Errorf(ctx, "AgentsLookupDNS %s %s: %v", request.Name, qtn, err)
@@ -949,10 +1180,93 @@ func (s *service) WatchWorkloads(request *rpc.WorkloadEventsRequest, stream rpc.
 	return ww.Watch(ctx, stream)
 }
 
+func (s *service) ListWorkloads(ctx context.Context, request *rpc.ListWorkloadsRequest) (*rpc.ListWorkloadsResponse, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.SessionInfo)
+	dlog.Debugf(ctx, "ListWorkloads called")
+
+	if request.SessionInfo == nil {
+		return nil, status.Error(codes.InvalidArgument, "SessionInfo is required")
+	}
+	clientInfo := s.state.GetClient(request.SessionInfo.SessionId)
+	if clientInfo == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", request.SessionInfo.SessionId)
+	}
+	if request.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size cannot be negative")
+	}
+	rs, err := s.state.ListWorkloads(ctx, request, clientInfo.Namespace)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_token: %v", err)
+	}
+	return rs, nil
+}
+
+// GetAgentConfigGCReport returns a report of the orphaned telepresence-agents ConfigMap entries that
+// have been removed.
+func (s *service) GetAgentConfigGCReport(ctx context.Context, _ *empty.Empty) (*rpc.AgentConfigGCReport, error) {
+	rs := &rpc.AgentConfigGCReport{}
+	m := mutator.GetMap(ctx)
+	if m == nil {
+		return rs, nil
+	}
+	gr := m.GCReport()
+	if !gr.LastRun.IsZero() {
+		rs.LastRun = timestamppb.New(gr.LastRun)
+	}
+	rs.Cleaned = make([]*rpc.CleanedAgentConfig, len(gr.Cleaned))
+	for i, ce := range gr.Cleaned {
+		rs.Cleaned[i] = &rpc.CleanedAgentConfig{
+			Name:      ce.Name,
+			Namespace: ce.Namespace,
+			Kind:      ce.Kind,
+			Reason:    ce.Reason,
+			Time:      timestamppb.New(ce.Time),
+		}
+	}
+	return rs, nil
+}
+
+// GetConnectionStats returns the statistics of the active tunneled connections of the given client session.
+func (s *service) GetConnectionStats(ctx context.Context, session *rpc.SessionInfo) (*rpc.ConnectionStatsResponse, error) {
+	ctx = managerutil.WithSessionInfo(ctx, session)
+	dlog.Debug(ctx, "GetConnectionStats called")
+	scm := s.state.GetSessionConsumptionMetrics(session.GetSessionId())
+	if scm == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", session.GetSessionId())
+	}
+	css := scm.Connections()
+	rs := &rpc.ConnectionStatsResponse{Connections: make([]*rpc.ConnectionStats, len(css))}
+	for i, cs := range css {
+		st := &rpc.ConnectionStats{
+			ConnId:          cs.ID.String(),
+			FromClientBytes: cs.FromClientBytes.Load(),
+			ToClientBytes:   cs.ToClientBytes.Load(),
+			StartTime:       timestamppb.New(cs.StartTime),
+		}
+		if rtt := cs.RTT(); rtt > 0 {
+			st.Rtt = durationpb.New(rtt)
+		}
+		rs.Connections[i] = st
+	}
+	return rs, nil
+}
+
 const agentSessionTTL = 15 * time.Second
 
 // expire removes stale sessions.
 func (s *service) expire(ctx context.Context) {
 	now := s.clock.Now()
-	s.state.ExpireSessions(ctx, now.Add(-managerutil.GetEnv(ctx).ClientConnectionTTL), now.Add(-agentSessionTTL))
+	connTTL := config.ClientConnectionTTL(ctx)
+	s.state.ExpireSessions(ctx, now.Add(-connTTL), now.Add(-agentSessionTTL))
+	s.state.ExpireIdleIntercepts(ctx, now, config.InterceptIdleTTL(ctx))
+
+	// An evicted client that still runs will learn about the eviction within seconds, but a client that
+	// is asleep might not call Remain until much later. Its session would have expired by then anyway.
+	evictedBefore := now.Add(-connTTL)
+	s.evicted.Range(func(sessionID string, at time.Time) bool {
+		if at.Before(evictedBefore) {
+			s.evicted.Delete(sessionID)
+		}
+		return true
+	})
 }
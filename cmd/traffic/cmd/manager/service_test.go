@@ -4,20 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"net"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/proto"
 	empty "google.golang.org/protobuf/types/known/emptypb"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	k8sVersion "k8s.io/apimachinery/pkg/version"
 	fakeDiscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/datawire/dlib/dhttp"
 	"github.com/datawire/dlib/dlog"
@@ -282,7 +293,115 @@ func TestConnect(t *testing.T) {
 	require.NoError(err)
 }
 
-func getTestClientConn(ctx context.Context, t *testing.T) *grpc.ClientConn {
+func TestEvictClientSession(t *testing.T) {
+	dlog.SetFallbackLogger(dlog.WrapTB(t, false))
+	ctx := dlog.NewTestContext(t, true)
+	require := require.New(t)
+
+	testClients := testdata.GetTestClients(t)
+	conn := getTestClientConn(ctx, t, func(fc *fake.Clientset, env *managerutil.Env) {
+		env.SessionSubjectAccessReview = true
+		fc.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+			tr.Status.Authenticated = true
+			tr.Status.User = authn.UserInfo{Username: strings.TrimSuffix(tr.Spec.Token, "-token")}
+			return true, tr, nil
+		})
+		fc.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+			sar.Status.Allowed = sar.Spec.User == "admin"
+			return true, sar, nil
+		})
+	})
+	defer conn.Close()
+	client := rpc.NewManagerClient(conn)
+
+	alice := proto.Clone(testClients["alice"]).(*rpc.ClientInfo)
+	alice.ApiKey = "secret"
+	alice.KubeToken = "alice-token"
+	aliceSess, err := client.ArriveAsClient(ctx, alice)
+	require.NoError(err)
+	bob := proto.Clone(testClients["bob"]).(*rpc.ClientInfo)
+	bob.KubeToken = "bob-token"
+	bobSess, err := client.ArriveAsClient(ctx, bob)
+	require.NoError(err)
+	admin := proto.Clone(testClients["bob"]).(*rpc.ClientInfo)
+	admin.Name = "admin@host"
+	admin.KubeToken = "admin-token"
+	adminSess, err := client.ArriveAsClient(ctx, admin)
+	require.NoError(err)
+
+	_, err = client.ListClientSessions(ctx, &rpc.SessionInfo{SessionId: "unknown"})
+	require.Equal(codes.NotFound, status.Code(err))
+
+	sl, err := client.ListClientSessions(ctx, adminSess)
+	require.NoError(err)
+	require.Len(sl.Sessions, 3)
+	for _, cs := range sl.Sessions {
+		require.Empty(cs.Client.ApiKey)
+		require.Equal([]string{"default"}, cs.Namespaces)
+		require.NotNil(cs.LastActive)
+	}
+
+	// Clients that aren't allowed to list sessions only see abbreviated session IDs.
+	sl, err = client.ListClientSessions(ctx, bobSess)
+	require.NoError(err)
+	require.Len(sl.Sessions, 3)
+	for _, cs := range sl.Sessions {
+		require.Len(cs.SessionId, abbreviatedSessionIDLength)
+	}
+
+	_, err = client.EvictClientSession(ctx, &rpc.EvictClientSessionRequest{Session: bobSess, SessionId: aliceSess.SessionId})
+	require.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = client.EvictClientSession(ctx, &rpc.EvictClientSessionRequest{Session: adminSess, SessionId: aliceSess.SessionId})
+	require.NoError(err)
+
+	// Alice is told that the session was evicted, bob's session remains.
+	_, err = client.Remain(ctx, &rpc.RemainRequest{Session: aliceSess})
+	require.Equal(codes.Aborted, status.Code(err))
+	_, err = client.Remain(ctx, &rpc.RemainRequest{Session: bobSess})
+	require.NoError(err)
+
+	sl, err = client.ListClientSessions(ctx, adminSess)
+	require.NoError(err)
+	require.Len(sl.Sessions, 2)
+	require.True(slices.ContainsFunc(sl.Sessions, func(cs *rpc.ClientSession) bool {
+		return cs.SessionId == bobSess.SessionId && cs.Client.Name == bob.Name
+	}))
+
+	_, err = client.EvictClientSession(ctx, &rpc.EvictClientSessionRequest{Session: adminSess, SessionId: aliceSess.SessionId})
+	require.Equal(codes.NotFound, status.Code(err))
+}
+
+func TestEvictClientSession_disabled(t *testing.T) {
+	dlog.SetFallbackLogger(dlog.WrapTB(t, false))
+	ctx := dlog.NewTestContext(t, true)
+	require := require.New(t)
+
+	testClients := testdata.GetTestClients(t)
+	conn := getTestClientConn(ctx, t)
+	defer conn.Close()
+	client := rpc.NewManagerClient(conn)
+
+	aliceSess, err := client.ArriveAsClient(ctx, testClients["alice"])
+	require.NoError(err)
+	bobSess, err := client.ArriveAsClient(ctx, testClients["bob"])
+	require.NoError(err)
+
+	sl, err := client.ListClientSessions(ctx, bobSess)
+	require.NoError(err)
+	require.Len(sl.Sessions, 2)
+	for _, cs := range sl.Sessions {
+		require.Len(cs.SessionId, abbreviatedSessionIDLength)
+	}
+
+	_, err = client.EvictClientSession(ctx, &rpc.EvictClientSessionRequest{Session: bobSess, SessionId: aliceSess.SessionId})
+	require.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = client.Remain(ctx, &rpc.RemainRequest{Session: aliceSess})
+	require.NoError(err)
+}
+
+func getTestClientConn(ctx context.Context, t *testing.T, opts ...func(*fake.Clientset, *managerutil.Env)) *grpc.ClientConn {
 	const bufsize = 64 * 1024
 	var cancel func()
 	ctx, cancel = context.WithCancel(ctx)
@@ -318,6 +437,9 @@ func getTestClientConn(ctx context.Context, t *testing.T) *grpc.ClientConn {
 			Mask: net.CIDRMask(16, 32),
 		}},
 	}
+	for _, opt := range opts {
+		opt(fakeClient, &env)
+	}
 	ctx = managerutil.WithEnv(ctx, &env)
 	ctx = mutator.WithMap(ctx, mutator.Load(ctx))
 
@@ -353,6 +475,38 @@ func getTestClientConn(ctx context.Context, t *testing.T) *grpc.ClientConn {
 	return conn
 }
 
+func TestHealthAndReflection(t *testing.T) {
+	dlog.SetFallbackLogger(dlog.WrapTB(t, false))
+	ctx := dlog.NewTestContext(t, true)
+	conn := getTestClientConn(ctx, t)
+	defer conn.Close()
+
+	// The agent-injector is enabled, but it isn't started by the test, so the manager isn't serving.
+	hc := grpc_health_v1.NewHealthClient(conn)
+	for _, svc := range []string{"", rpc.Manager_ServiceDesc.ServiceName, managerutil.AgentInjectorComponent} {
+		hr, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: svc})
+		require.NoError(t, err)
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, hr.Status)
+	}
+	_, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "no-such-service"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	}))
+	rsp, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, svc := range rsp.GetListServicesResponse().GetService() {
+		names = append(names, svc.Name)
+	}
+	assert.Contains(t, names, rpc.Manager_ServiceDesc.ServiceName)
+	assert.Contains(t, names, grpc_health_v1.Health_ServiceDesc.ServiceName)
+	require.NoError(t, stream.CloseSend())
+}
+
 func Test_hasDomainSuffix(t *testing.T) {
 	tests := []struct {
 		name   string
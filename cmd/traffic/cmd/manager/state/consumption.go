@@ -1,6 +1,10 @@
 package state
 
 import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -35,6 +39,96 @@ type SessionConsumptionMetrics struct {
 	FromClientBytes *tunnel.CounterProbe
 	// data from the traffic manager to the client.
 	ToClientBytes *tunnel.CounterProbe
+
+	connsMu sync.Mutex
+	conns   map[tunnel.ConnID]*ConnectionStats
+}
+
+// ConnectionStats contains the statistics of one active tunneled connection of a client session.
+type ConnectionStats struct {
+	ID              tunnel.ConnID
+	StartTime       time.Time
+	FromClientBytes atomic.Uint64
+	ToClientBytes   atomic.Uint64
+	rtt             atomic.Int64
+}
+
+// RTT returns the round-trip time of the dial of the connection, or zero if the dial hasn't completed.
+func (cs *ConnectionStats) RTT() time.Duration {
+	return time.Duration(cs.rtt.Load())
+}
+
+// dialCompleted records the round-trip time of the dial, unless it has been recorded already.
+func (cs *ConnectionStats) dialCompleted() {
+	cs.rtt.CompareAndSwap(0, int64(time.Since(cs.StartTime)))
+}
+
+// AddConnection starts tracking the statistics of the connection with the given ID.
+func (m *SessionConsumptionMetrics) AddConnection(id tunnel.ConnID) *ConnectionStats {
+	cs := &ConnectionStats{ID: id, StartTime: time.Now()}
+	m.connsMu.Lock()
+	if m.conns == nil {
+		m.conns = make(map[tunnel.ConnID]*ConnectionStats)
+	}
+	m.conns[id] = cs
+	m.connsMu.Unlock()
+	return cs
+}
+
+// RemoveConnection stops tracking the statistics of the given connection.
+func (m *SessionConsumptionMetrics) RemoveConnection(cs *ConnectionStats) {
+	m.connsMu.Lock()
+	if m.conns[cs.ID] == cs {
+		delete(m.conns, cs.ID)
+	}
+	m.connsMu.Unlock()
+}
+
+// Connections returns the statistics of the active connections, ordered by the total number of bytes
+// transferred, highest first.
+func (m *SessionConsumptionMetrics) Connections() []*ConnectionStats {
+	m.connsMu.Lock()
+	css := make([]*ConnectionStats, 0, len(m.conns))
+	for _, cs := range m.conns {
+		css = append(css, cs)
+	}
+	m.connsMu.Unlock()
+	total := func(cs *ConnectionStats) uint64 {
+		return cs.FromClientBytes.Load() + cs.ToClientBytes.Load()
+	}
+	slices.SortFunc(css, func(a, b *ConnectionStats) int {
+		return cmp.Compare(total(b), total(a))
+	})
+	return css
+}
+
+// connStatsStream is a tunnel.Stream of a client that updates the ConnectionStats of its connection.
+type connStatsStream struct {
+	tunnel.Stream
+	stats *ConnectionStats
+}
+
+func (s *connStatsStream) Receive(ctx context.Context) (tunnel.Message, error) {
+	m, err := s.Stream.Receive(ctx)
+	if err == nil {
+		s.update(m, &s.stats.FromClientBytes)
+	}
+	return m, err
+}
+
+func (s *connStatsStream) Send(ctx context.Context, m tunnel.Message) error {
+	// The message might be released when it has been sent, so it must be inspected first.
+	s.update(m, &s.stats.ToClientBytes)
+	return s.Stream.Send(ctx, m)
+}
+
+func (s *connStatsStream) update(m tunnel.Message, bytes *atomic.Uint64) {
+	switch m.Code() {
+	case tunnel.Normal:
+		bytes.Add(uint64(len(m.Payload())))
+	case tunnel.DialOK, tunnel.DialReject:
+		s.stats.dialCompleted()
+	}
 }
 
 func (m *SessionConsumptionMetrics) ConnectDuration() time.Duration {
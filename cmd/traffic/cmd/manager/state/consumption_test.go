@@ -1,9 +1,15 @@
 package state
 
 import (
+	"context"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 func (s *suiteState) TestRefreshSessionConsumptionMetrics() {
@@ -37,3 +43,34 @@ func (s *suiteState) TestRefreshSessionConsumptionMetrics() {
 	assert.True(s.T(), ccs1.(*clientSessionState).ConsumptionMetrics().ConnectDuration() > 42*time.Second)
 	assert.Equal(s.T(), 41*time.Second, ccs3.(*clientSessionState).ConsumptionMetrics().ConnectDuration())
 }
+
+func (s *suiteState) TestConnectionStats() {
+	ctx := context.Background()
+	scm := NewSessionConsumptionMetrics()
+	id1 := tunnel.NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("10.0.0.1"), 1001, 80)
+	id2 := tunnel.NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("10.0.0.2"), 1002, 80)
+
+	cs1 := scm.AddConnection(id1)
+	client, server := tunnel.NewPipe(id1, "session-1")
+	stream := &connStatsStream{Stream: server, stats: cs1}
+	assert.Zero(s.T(), cs1.RTT())
+	require.NoError(s.T(), stream.Send(ctx, tunnel.NewMessage(tunnel.DialOK, nil)))
+	assert.NotZero(s.T(), cs1.RTT())
+	_, err := client.Receive(ctx)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), stream.Send(ctx, tunnel.NewMessage(tunnel.Normal, make([]byte, 10))))
+	_, err = client.Receive(ctx)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), client.Send(ctx, tunnel.NewMessage(tunnel.Normal, make([]byte, 20))))
+	_, err = stream.Receive(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), uint64(10), cs1.ToClientBytes.Load())
+	assert.Equal(s.T(), uint64(20), cs1.FromClientBytes.Load())
+
+	cs2 := scm.AddConnection(id2)
+	cs2.ToClientBytes.Store(100)
+	assert.Equal(s.T(), []*ConnectionStats{cs2, cs1}, scm.Connections())
+
+	scm.RemoveConnection(cs2)
+	assert.Equal(s.T(), []*ConnectionStats{cs1}, scm.Connections())
+}
@@ -0,0 +1,221 @@
+package state
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+const (
+	// maxQueuedDials is the default max number of dial requests that can be waiting for dispatch to one
+	// session.
+	maxQueuedDials = 256
+
+	// maxConcurrentDials is the max number of dial requests that can be dispatched to one session and
+	// still be waiting for the session to connect.
+	maxConcurrentDials = 64
+)
+
+type dialPriority int
+
+const (
+	// dialPriorityNormal is used for dials that originate from a client.
+	dialPriorityNormal dialPriority = iota
+
+	// dialPriorityHigh is used for dials that originate from a traffic-agent, i.e. intercepted traffic
+	// that is on its way to a client.
+	dialPriorityHigh
+
+	dialPriorityCount
+)
+
+// dialLimits are the limits that apply to the dial requests of a session.
+type dialLimits struct {
+	// rate is the number of dials per second that a client session may originate. Zero means no limit.
+	rate rate.Limit
+
+	// burst is the number of dials that a client session may originate at once. Defaults to the rate.
+	burst int
+
+	// maxQueued is the max number of dial requests that can be waiting for dispatch to a session.
+	// Defaults to maxQueuedDials.
+	maxQueued int
+}
+
+func dialLimitsFromEnv(env *managerutil.Env) dialLimits {
+	return dialLimits{
+		rate:      rate.Limit(env.DialRateLimit),
+		burst:     env.DialRateBurst,
+		maxQueued: env.MaxPendingDials,
+	}
+}
+
+// newLimiter returns the rate limiter for the dials that a client session originates, or nil if the
+// rate isn't limited.
+func (dl dialLimits) newLimiter() *rate.Limiter {
+	if dl.rate <= 0 {
+		return nil
+	}
+	burst := dl.burst
+	if burst <= 0 {
+		burst = max(int(dl.rate), 1)
+	}
+	return rate.NewLimiter(dl.rate, burst)
+}
+
+// pendingDial is a dial request that is either waiting to be dispatched or has been dispatched and
+// awaits its connection.
+type pendingDial struct {
+	ctx context.Context
+	dr  *rpc.DialRequest
+
+	// dispatched receives nil when the request has been sent to the session, or an error if the request
+	// was shed from the queue.
+	dispatched chan error
+
+	// holdsSlot is true when the request holds a concurrency slot.
+	holdsSlot bool
+}
+
+// dialQueue is a bounded and prioritized queue of dial requests for one session. Requests are
+// dispatched on the channel returned by dials, but never more than maxConcurrentDials at a time. A
+// session that stops reading its dials will therefore cause the queue to fill up, at which point new
+// requests are shed instead of blocking their callers.
+type dialQueue struct {
+	sync.Mutex
+	queues    [dialPriorityCount][]*pendingDial
+	inFlight  int
+	maxQueued int
+	wakeup    chan struct{}
+	out       chan *rpc.DialRequest
+}
+
+// newDialQueue returns a queue that holds at most maxQueued requests, or maxQueuedDials requests when
+// maxQueued is zero.
+func newDialQueue(ctx context.Context, maxQueued int) *dialQueue {
+	if maxQueued <= 0 {
+		maxQueued = maxQueuedDials
+	}
+	q := &dialQueue{
+		maxQueued: maxQueued,
+		wakeup:    make(chan struct{}, 1),
+		out:       make(chan *rpc.DialRequest),
+	}
+	go q.run(ctx)
+	return q
+}
+
+func (q *dialQueue) dials() <-chan *rpc.DialRequest {
+	return q.out
+}
+
+func (q *dialQueue) signal() {
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (q *dialQueue) queued() int {
+	n := 0
+	for _, pq := range q.queues {
+		n += len(pq)
+	}
+	return n
+}
+
+// push adds the request to the queue. If the queue is full, then the oldest request with a lower
+// priority is shed to make room for it. A codes.ResourceExhausted error is returned when no such
+// request exists.
+func (q *dialQueue) push(ctx context.Context, dr *rpc.DialRequest, prio dialPriority) (*pendingDial, error) {
+	pd := &pendingDial{ctx: ctx, dr: dr, dispatched: make(chan error, 1)}
+	q.Lock()
+	if q.queued() >= q.maxQueued {
+		shed := false
+		for p := dialPriorityNormal; p < prio; p++ {
+			if pq := q.queues[p]; len(pq) > 0 {
+				pq[0].dispatched <- status.Error(codes.ResourceExhausted, "dial request shed in favor of a request with higher priority")
+				q.queues[p] = pq[1:]
+				shed = true
+				break
+			}
+		}
+		if !shed {
+			q.Unlock()
+			return nil, status.Errorf(codes.ResourceExhausted, "too many pending dial requests (max %d)", q.maxQueued)
+		}
+	}
+	q.queues[prio] = append(q.queues[prio], pd)
+	q.Unlock()
+	q.signal()
+	return pd, nil
+}
+
+// done must be called when the caller of push no longer waits for the given request. It either removes
+// the request from the queue or, if it has been dispatched, releases its concurrency slot.
+func (q *dialQueue) done(pd *pendingDial) {
+	q.Lock()
+	if pd.holdsSlot {
+		q.inFlight--
+	} else {
+		for p, pq := range q.queues {
+			for i, qd := range pq {
+				if qd == pd {
+					q.queues[p] = append(pq[:i:i], pq[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	q.Unlock()
+	q.signal()
+}
+
+// next returns the oldest request with the highest priority and reserves a concurrency slot for it,
+// provided that the concurrency limit hasn't been reached. Requests whose context is done are discarded.
+func (q *dialQueue) next() *pendingDial {
+	q.Lock()
+	defer q.Unlock()
+	if q.inFlight >= maxConcurrentDials {
+		return nil
+	}
+	for p := dialPriorityCount - 1; p >= dialPriorityNormal; p-- {
+		for len(q.queues[p]) > 0 {
+			pd := q.queues[p][0]
+			q.queues[p] = q.queues[p][1:]
+			if pd.ctx.Err() == nil {
+				pd.holdsSlot = true
+				q.inFlight++
+				return pd
+			}
+		}
+	}
+	return nil
+}
+
+func (q *dialQueue) run(ctx context.Context) {
+	defer close(q.out)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wakeup:
+		}
+		for pd := q.next(); pd != nil; pd = q.next() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-pd.ctx.Done():
+				continue
+			case q.out <- pd.dr:
+				pd.dispatched <- nil
+			}
+		}
+	}
+}
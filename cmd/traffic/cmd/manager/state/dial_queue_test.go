@@ -0,0 +1,128 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_dialQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newDialQueue(ctx, 0)
+
+	queued := func() int {
+		q.Lock()
+		defer q.Unlock()
+		return q.queued()
+	}
+
+	// Nobody reads the dials, so the first request blocks the dispatcher and the rest are queued.
+	var pds []*pendingDial
+	for i := 0; i <= maxQueuedDials; i++ {
+		pd, err := q.push(ctx, &rpc.DialRequest{ConnId: []byte{byte(i)}}, dialPriorityNormal)
+		require.NoError(t, err)
+		pds = append(pds, pd)
+		if i == 0 {
+			require.Eventually(t, func() bool { return queued() == 0 }, 5*time.Second, time.Millisecond)
+		}
+	}
+	require.Equal(t, maxQueuedDials, queued())
+
+	// The queue is full, so a request with normal priority is rejected.
+	_, err := q.push(ctx, &rpc.DialRequest{}, dialPriorityNormal)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// A request with high priority sheds the oldest queued request with normal priority.
+	hp, err := q.push(ctx, &rpc.DialRequest{ConnId: []byte("hp")}, dialPriorityHigh)
+	require.NoError(t, err)
+	select {
+	case err = <-pds[1].dispatched:
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	default:
+		t.Fatal("expected the oldest queued request to be shed")
+	}
+
+	// The blocked request is dispatched first, then the one with high priority.
+	assert.Equal(t, []byte{0}, (<-q.dials()).ConnId)
+	assert.NoError(t, <-pds[0].dispatched)
+	assert.Equal(t, []byte("hp"), (<-q.dials()).ConnId)
+	assert.NoError(t, <-hp.dispatched)
+	assert.Equal(t, []byte{2}, (<-q.dials()).ConnId)
+
+	cancel()
+	for range q.dials() {
+	}
+}
+
+func Test_dialQueueConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newDialQueue(ctx, 0)
+
+	var pds []*pendingDial
+	for i := 0; i <= maxConcurrentDials; i++ {
+		pd, err := q.push(ctx, &rpc.DialRequest{}, dialPriorityNormal)
+		require.NoError(t, err)
+		pds = append(pds, pd)
+	}
+	for i := 0; i < maxConcurrentDials; i++ {
+		<-q.dials()
+		require.NoError(t, <-pds[i].dispatched)
+	}
+
+	// The concurrency limit is reached, so the last request isn't dispatched until a slot is released.
+	select {
+	case <-q.dials():
+		t.Fatal("concurrency limit exceeded")
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.done(pds[0])
+	<-q.dials()
+	assert.NoError(t, <-pds[maxConcurrentDials].dispatched)
+}
+
+func Test_dialQueueMaxQueued(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newDialQueue(ctx, 2)
+
+	// Nobody reads the dials, so the first request blocks the dispatcher and the next two are queued.
+	_, err := q.push(ctx, &rpc.DialRequest{}, dialPriorityNormal)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		q.Lock()
+		defer q.Unlock()
+		return q.queued() == 0
+	}, 5*time.Second, time.Millisecond)
+	for i := 0; i < 2; i++ {
+		_, err = q.push(ctx, &rpc.DialRequest{}, dialPriorityNormal)
+		require.NoError(t, err)
+	}
+	_, err = q.push(ctx, &rpc.DialRequest{}, dialPriorityNormal)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.ErrorContains(t, err, "max 2")
+}
+
+func Test_dialLimits(t *testing.T) {
+	assert.Nil(t, dialLimits{}.newLimiter())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	css := newClientSessionState(ctx, time.Now(), dialLimits{rate: 1, burst: 3})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, css.allowDial())
+	}
+	err := css.allowDial()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The burst defaults to the rate.
+	assert.Equal(t, 10, dialLimits{rate: 10}.newLimiter().Burst())
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"sync"
+	"sort"
 	"time"
 
+	"github.com/miekg/dns"
+
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
@@ -18,8 +20,17 @@ import (
 // RcodeNoAgents means that no agents replied to the DNS request.
 const RcodeNoAgents = 3841
 
-// AgentsLookupDNS will send the given request to all agents currently intercepted by the client identified with
-// the clientSessionID, it will then wait for results to arrive, collect those results, and return the result.
+const (
+	// maxLookupAgents is the max number of agents that a DNS request is sent to.
+	maxLookupAgents = 3
+
+	// agentLookupTimeout is the max time to wait for an agent to respond to a DNS request.
+	agentLookupTimeout = time.Second
+)
+
+// AgentsLookupDNS will send the given request to the agents currently intercepted by the client identified with
+// the clientSessionID, or if no such agents exist, to agents in the client's namespace. The first successful
+// answer is returned. If no agent answers successfully, then the best of the unsuccessful answers is returned.
 func (s *state) AgentsLookupDNS(ctx context.Context, clientSessionID string, request *rpc.DNSRequest) (dnsproxy.RRs, int, error) {
 	rs := s.agentsLookup(ctx, clientSessionID, request)
 	if len(rs) == 0 {
@@ -73,7 +84,10 @@ func (s *state) WatchLookupDNS(agentSessionID string) <-chan *rpc.DNSRequest {
 	return nil
 }
 
-func (s *state) agentsLookup(ctx context.Context, clientSessionID string, request *rpc.DNSRequest) []*rpc.DNSResponse {
+// lookupCandidates returns the IDs of the agents that should receive the DNS request, ordered by their
+// number of consecutive lookup failures so that agents that fail to answer, e.g. because their pod is
+// restarting, are used last.
+func (s *state) lookupCandidates(ctx context.Context, clientSessionID string) []string {
 	agents := s.getAgentsInterceptedByClient(clientSessionID)
 	if len(agents) == 0 {
 		if client, ok := s.clients.Load(clientSessionID); ok {
@@ -84,57 +98,91 @@ func (s *state) agentsLookup(ctx context.Context, clientSessionID string, reques
 			agents = s.getAgentsInNamespace(client.Namespace)
 		}
 	}
-	aCount := len(agents)
+	ids := make([]string, 0, len(agents))
+	failures := make(map[string]int32, len(agents))
+	for aID := range agents {
+		if as, ok := s.GetSession(aID).(*agentSessionState); ok {
+			ids = append(ids, aID)
+			failures[aID] = as.dnsFailures.Load()
+		}
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return failures[ids[i]] < failures[ids[j]]
+	})
+	if len(ids) > maxLookupAgents {
+		ids = ids[:maxLookupAgents]
+	}
+	return ids
+}
+
+// agentsLookup sends the request to several agents in parallel. It returns as soon as one of them answers
+// successfully, or when all of them have answered or timed out.
+func (s *state) agentsLookup(ctx context.Context, clientSessionID string, request *rpc.DNSRequest) []*rpc.DNSResponse {
+	ids := s.lookupCandidates(ctx, clientSessionID)
+	aCount := len(ids)
 	if aCount == 0 {
 		return nil
 	}
-	if aCount > 2 {
-		// Send the lookup to max two agents
-		aCount = 2
-	}
 
+	// Every lookup posts exactly one response, which is nil when the agent failed to answer.
 	rsBuf := make(chan *rpc.DNSResponse, aCount)
-
-	timout, cancel := context.WithTimeout(ctx, time.Second)
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	wg := sync.WaitGroup{}
-	wg.Add(aCount)
-	for aID := range agents {
+	rid := requestId(request)
+	for _, aID := range ids {
 		go func(aID string) {
-			rid := requestId(request)
-			defer func() {
-				s.endLookup(aID, rid)
-				wg.Done()
-			}()
-
-			rsCh := s.startLookup(aID, rid, request)
-			if rsCh == nil {
-				return
-			}
-			select {
-			case <-timout.Done():
-			case rs, ok := <-rsCh:
-				if ok {
-					rsBuf <- rs
-				}
-			}
+			rsBuf <- s.agentLookup(ctx, aID, rid, request)
 		}(aID)
-		aCount--
-		if aCount == 0 {
-			break
-		}
 	}
-	wg.Wait() // wait for timeout or that all agents have responded
-	bz := len(rsBuf)
-	rs := make([]*rpc.DNSResponse, bz)
-	for i := 0; i < bz; i++ {
-		rs[i] = <-rsBuf
+
+	var rs []*rpc.DNSResponse
+	for i := 0; i < aCount; i++ {
+		r := <-rsBuf
+		if r == nil {
+			continue
+		}
+		if r.RCode == dns.RcodeSuccess {
+			// First successful answer wins. The cancel will end the remaining lookups.
+			return []*rpc.DNSResponse{r}
+		}
+		rs = append(rs, r)
 	}
 	return rs
 }
 
-func (s *state) startLookup(agentSessionID, rid string, request *rpc.DNSRequest) <-chan *rpc.DNSResponse {
+// agentLookup sends the request to one agent and waits for its answer. The agent's failure count is
+// updated accordingly. A nil response is returned if the agent didn't answer in time.
+func (s *state) agentLookup(ctx context.Context, aID, rid string, request *rpc.DNSRequest) *rpc.DNSResponse {
+	defer s.endLookup(aID, rid)
+	ctx, cancel := context.WithTimeout(ctx, agentLookupTimeout)
+	defer cancel()
+
+	rsCh := s.startLookup(ctx, aID, rid, request)
+	if rsCh == nil {
+		return nil
+	}
+	var r *rpc.DNSResponse
+	select {
+	case <-ctx.Done():
+		if ctx.Err() == context.Canceled {
+			// Another agent answered first. That's not a failure.
+			return nil
+		}
+	case r = <-rsCh:
+	}
+	if as, ok := s.GetSession(aID).(*agentSessionState); ok {
+		if r == nil {
+			dlog.Debugf(ctx, "agent %s failed to answer DNS request %s", aID, rid)
+			as.dnsFailures.Add(1)
+		} else {
+			as.dnsFailures.Store(0)
+		}
+	}
+	return r
+}
+
+func (s *state) startLookup(ctx context.Context, agentSessionID, rid string, request *rpc.DNSRequest) <-chan *rpc.DNSResponse {
 	var (
 		rch chan *rpc.DNSResponse
 		as  *agentSessionState
@@ -143,7 +191,8 @@ func (s *state) startLookup(agentSessionID, rid string, request *rpc.DNSRequest)
 	s.mu.Lock()
 	if as, ok = s.GetSession(agentSessionID).(*agentSessionState); ok {
 		if rch, ok = as.dnsResponses[rid]; !ok {
-			rch = make(chan *rpc.DNSResponse)
+			// Buffered, so that a response that arrives before the lookup waits for it isn't dropped.
+			rch = make(chan *rpc.DNSResponse, 1)
 			as.dnsResponses[rid] = rch
 		}
 	}
@@ -161,7 +210,10 @@ func (s *state) startLookup(agentSessionID, rid string, request *rpc.DNSRequest)
 					}
 				}
 			}()
-			as.dnsRequests <- request
+			select {
+			case <-ctx.Done():
+			case as.dnsRequests <- request:
+			}
 		}()
 	}
 	return rch
@@ -0,0 +1,54 @@
+package state
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func (s *suiteState) TestAgentsLookupDNS() {
+	ctx := managerutil.WithEnv(s.ctx, &managerutil.Env{ManagerNamespace: "ambassador"})
+	st := NewState(ctx).(*state)
+	now := time.Now()
+	clientID := st.AddClient(&rpc.ClientInfo{Name: "client", Namespace: "ns"}, now)
+	silentID := st.AddAgent(&rpc.AgentInfo{Name: "silent", Namespace: "ns"}, now)
+	answerID := st.AddAgent(&rpc.AgentInfo{Name: "answering", Namespace: "ns"}, now)
+
+	// The answering agent responds to all requests. The silent agent never reads its requests.
+	go func() {
+		for rq := range st.WatchLookupDNS(answerID) {
+			st.PostLookupDNSResponse(ctx, &rpc.DNSAgentResponse{
+				Session:  &rpc.SessionInfo{SessionId: answerID},
+				Request:  rq,
+				Response: &rpc.DNSResponse{RCode: dns.RcodeSuccess},
+			})
+		}
+	}()
+
+	request := &rpc.DNSRequest{
+		Session: &rpc.SessionInfo{SessionId: clientID},
+		Name:    "example.ns.",
+		Type:    uint32(dns.TypeA),
+	}
+
+	// The answer from the responding agent is returned without waiting for the silent agent to time out.
+	start := time.Now()
+	_, rCode, err := st.AgentsLookupDNS(ctx, clientID, request)
+	s.Require().NoError(err)
+	s.Equal(dns.RcodeSuccess, rCode)
+	s.Less(time.Since(start), agentLookupTimeout)
+
+	// The silent agent wasn't given time to fail, so no failure is recorded.
+	silent := st.GetSession(silentID).(*agentSessionState)
+	s.Equal(int32(0), silent.dnsFailures.Load())
+
+	// When the silent agent is the only one asked, it times out and gets a failure recorded.
+	st.agents.Delete(answerID)
+	_, rCode, err = st.AgentsLookupDNS(ctx, clientID, request)
+	s.Require().NoError(err)
+	s.Equal(RcodeNoAgents, rCode)
+	s.Equal(int32(1), silent.dnsFailures.Load())
+}
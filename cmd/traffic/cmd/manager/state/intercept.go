@@ -25,6 +25,7 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/config"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
@@ -66,7 +67,12 @@ func (s *state) PrepareIntercept(
 			return nil, err
 		}
 		dlog.Errorf(ctx, "PrepareIntercept error %v", err)
-		return &managerrpc.PreparedIntercept{Error: err.Error(), ErrorCategory: int32(errcat.GetCategory(err))}, nil
+		pi := &managerrpc.PreparedIntercept{Error: err.Error(), ErrorCategory: int32(errcat.GetCategory(err))}
+		var ape *AmbiguousPortError
+		if errors.As(err, &ape) {
+			pi.AmbiguousPorts = ape.Ports
+		}
+		return pi, nil
 	}
 
 	spec := cr.InterceptSpec
@@ -125,7 +131,7 @@ func (s *state) ensureAgent(parentCtx context.Context, wl k8sapi.Workload, exten
 		}
 		return sce.AgentConfig(), nil
 	}
-	ctx, cancel := context.WithTimeout(parentCtx, managerutil.GetEnv(parentCtx).AgentArrivalTimeout)
+	ctx, cancel := context.WithTimeout(parentCtx, config.AgentArrivalTimeout(parentCtx))
 	defer cancel()
 
 	failedCreateCh, err := watchFailedInjectionEvents(ctx, wl.GetName(), wl.GetNamespace())
@@ -514,8 +520,12 @@ func unmarshalConfigMapEntry(y string, name, namespace string) (agentconfig.Side
 }
 
 // findIntercept finds the intercept configuration that matches the given InterceptSpec's service/service port.
+// When more than one service port matches and the spec lacks a port identifier, the port is selected using the
+// application protocol heuristics of selectPreferredPort. An *AmbiguousPortError that lists all matching ports
+// is returned if that selection fails.
 func findIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (foundCN *agentconfig.Container, foundIC *agentconfig.Intercept, err error) {
 	spi := agentconfig.PortIdentifier(spec.ServicePortIdentifier)
+	var found []portCandidate
 	for _, cn := range ac.Containers {
 		for _, ic := range cn.Intercepts {
 			if !(spec.ServiceName == "" || spec.ServiceName == ic.ServiceName) {
@@ -524,35 +534,40 @@ func findIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (fou
 			if !(spi == "" || agentconfig.IsInterceptFor(spi, ic)) {
 				continue
 			}
-			if foundIC == nil {
-				foundCN = cn
-				foundIC = ic
-				continue
+			found = append(found, portCandidate{cn: cn, ic: ic})
+		}
+	}
+	switch len(found) {
+	case 0:
+	case 1:
+		return found[0].cn, found[0].ic, nil
+	default:
+		var msg string
+		switch {
+		case spec.ServiceName == "" && spi == "":
+			if pc, ok := selectPreferredPort(found); ok {
+				return pc.cn, pc.ic, nil
 			}
-			var msg string
-			switch {
-			case spec.ServiceName == "" && spi == "":
-				msg = fmt.Sprintf("%s %s.%s has multiple interceptable service ports.\n"+
-					"Please specify the service and/or service port you want to intercept "+
-					"by passing the --service=<svc> and/or --port=<local:svcPortName> flag.",
-					ac.WorkloadKind, ac.WorkloadName, ac.Namespace)
-			case spec.ServiceName == "":
-				msg = fmt.Sprintf("%s %s.%s has multiple interceptable services with port %s.\n"+
-					"Please specify the service you want to intercept by passing the --service=<svc> flag.",
-					ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spi)
-			case spi == "":
-				msg = fmt.Sprintf("%s %s.%s has multiple interceptable ports in service %s.\n"+
-					"Please specify the port you want to intercept by passing the --port=<local:svcPortName> flag.",
-					ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spec.ServiceName)
-			default:
-				msg = fmt.Sprintf("%s %s.%s intercept config is broken. Service %s, port %s is declared more than once\n",
-					ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spec.ServiceName, spi)
+			msg = fmt.Sprintf("%s %s.%s has multiple interceptable service ports.\n"+
+				"Please specify the service and/or service port you want to intercept "+
+				"by passing the --service=<svc> and/or --port=<local:svcPortName> flag.",
+				ac.WorkloadKind, ac.WorkloadName, ac.Namespace)
+		case spec.ServiceName == "":
+			msg = fmt.Sprintf("%s %s.%s has multiple interceptable services with port %s.\n"+
+				"Please specify the service you want to intercept by passing the --service=<svc> flag.",
+				ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spi)
+		case spi == "":
+			if pc, ok := selectPreferredPort(found); ok {
+				return pc.cn, pc.ic, nil
 			}
-			return nil, nil, errcat.User.New(msg)
+			msg = fmt.Sprintf("%s %s.%s has multiple interceptable ports in service %s.\n"+
+				"Please specify the port you want to intercept by passing the --port=<local:svcPortName> flag.",
+				ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spec.ServiceName)
+		default:
+			return nil, nil, errcat.User.Newf("%s %s.%s intercept config is broken. Service %s, port %s is declared more than once\n",
+				ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spec.ServiceName, spi)
 		}
-	}
-	if foundIC != nil {
-		return foundCN, foundIC, nil
+		return nil, nil, errcat.User.New(&AmbiguousPortError{msg: msg, Ports: interceptablePorts(found)})
 	}
 
 	ss := ""
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+// ParsePodSelection parses the pod selection of an intercept spec. The selection is either the name of
+// a pod, in which case the name is returned, or a label selector. A selector must use an operator, because
+// a single label key is indistinguishable from a pod name.
+func ParsePodSelection(s string) (string, labels.Selector, error) {
+	if len(validation.IsDNS1123Subdomain(s)) == 0 {
+		return s, nil, nil
+	}
+	sel, err := labels.Parse(s)
+	if err != nil {
+		return "", nil, fmt.Errorf("pod %q is neither a pod name nor a label selector: %w", s, err)
+	}
+	return "", sel, nil
+}
+
+// PodSelected returns true if the given agent runs in a pod that is selected by the pod selection of
+// the given intercept spec. All pods are selected when the spec has no pod selection.
+func PodSelected(ctx context.Context, spec *rpc.InterceptSpec, agent *rpc.AgentInfo) bool {
+	if spec.Pod == "" {
+		return true
+	}
+	name, sel, err := ParsePodSelection(spec.Pod)
+	if err != nil {
+		return false
+	}
+	if sel == nil {
+		return agent.PodName == name
+	}
+	f := informer.GetFactory(ctx, agent.Namespace)
+	if f == nil {
+		return false
+	}
+	pod, err := f.Core().V1().Pods().Lister().Pods(agent.Namespace).Get(agent.PodName)
+	if err != nil {
+		dlog.Debugf(ctx, "unable to get pod %s.%s: %v", agent.PodName, agent.Namespace, err)
+		return false
+	}
+	return sel.Matches(labels.Set(pod.Labels))
+}
@@ -0,0 +1,48 @@
+package state
+
+import (
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+func (s *suiteState) TestPodSelected() {
+	pod := func(name, version string) *core.Pod {
+		return &core.Pod{ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web", "version": version},
+		}}
+	}
+	ctx := informer.WithFactory(k8sapi.WithK8sInterface(s.ctx, fake.NewSimpleClientset(pod("web-0", "v1"), pod("web-1", "v2"))), "")
+	f := informer.GetFactory(ctx, "")
+	f.Core().V1().Pods().Informer()
+	f.Start(ctx.Done())
+	f.WaitForCacheSync(ctx.Done())
+
+	agent := func(podName string) *manager.AgentInfo {
+		return &manager.AgentInfo{Name: "web", Namespace: "default", PodName: podName}
+	}
+	spec := func(pod string) *manager.InterceptSpec {
+		return &manager.InterceptSpec{Agent: "web", Namespace: "default", Pod: pod}
+	}
+
+	s.True(PodSelected(ctx, spec(""), agent("web-0")))
+	s.True(PodSelected(ctx, spec("web-1"), agent("web-1")))
+	s.False(PodSelected(ctx, spec("web-1"), agent("web-0")))
+	s.True(PodSelected(ctx, spec("version=v2"), agent("web-1")))
+	s.False(PodSelected(ctx, spec("version=v2"), agent("web-0")))
+	s.True(PodSelected(ctx, spec("version!=v2"), agent("web-0")))
+	s.False(PodSelected(ctx, spec("version=v2"), agent("web-2")))
+
+	_, _, err := ParsePodSelection("version in (v1")
+	s.Error(err)
+	name, sel, err := ParsePodSelection("web-0")
+	s.NoError(err)
+	s.Equal("web-0", name)
+	s.Nil(sel)
+}
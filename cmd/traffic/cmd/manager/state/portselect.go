@@ -0,0 +1,68 @@
+package state
+
+import (
+	"strings"
+
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// AmbiguousPortError is returned when an intercept request matches more than one service port of a workload
+// and no port could be selected. It lists the matching ports so that a client can ask the user to pick one.
+type AmbiguousPortError struct {
+	msg   string
+	Ports []*managerrpc.InterceptablePort
+}
+
+func (e *AmbiguousPortError) Error() string {
+	return e.msg
+}
+
+type portCandidate struct {
+	cn *agentconfig.Container
+	ic *agentconfig.Intercept
+}
+
+// isWebAppProtocol returns true if the given application protocol is one that is commonly intercepted, such
+// as HTTP or gRPC. The check covers both the IANA service names and the kubernetes.io prefixed names.
+func isWebAppProtocol(appProto string) bool {
+	switch strings.ToLower(strings.TrimPrefix(appProto, "kubernetes.io/")) {
+	case "http", "https", "http2", "h2c", "grpc", "grpc-web", "ws", "wss":
+		return true
+	default:
+		return false
+	}
+}
+
+// selectPreferredPort selects a port among candidates that all matched an intercept request. A port is
+// selected only when it is the only candidate with a web application protocol, so ports that serve
+// metrics, admin, or debug endpoints don't cause ambiguity. The second return value is false when no
+// such selection can be made.
+func selectPreferredPort(pcs []portCandidate) (portCandidate, bool) {
+	var found portCandidate
+	n := 0
+	for _, pc := range pcs {
+		if isWebAppProtocol(pc.ic.AppProtocol) {
+			found = pc
+			n++
+		}
+	}
+	return found, n == 1
+}
+
+func interceptablePorts(pcs []portCandidate) []*managerrpc.InterceptablePort {
+	ips := make([]*managerrpc.InterceptablePort, len(pcs))
+	for i, pc := range pcs {
+		ic := pc.ic
+		ips[i] = &managerrpc.InterceptablePort{
+			ServiceName:     ic.ServiceName,
+			ServicePortName: ic.ServicePortName,
+			ServicePort:     int32(ic.ServicePort),
+			Protocol:        string(ic.Protocol),
+			AppProtocol:     ic.AppProtocol,
+			ContainerName:   pc.cn.Name,
+			ContainerPort:   int32(ic.ContainerPort),
+		}
+	}
+	return ips
+}
@@ -0,0 +1,58 @@
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func Test_findIntercept(t *testing.T) {
+	sidecar := func(ics ...*agentconfig.Intercept) *agentconfig.Sidecar {
+		return &agentconfig.Sidecar{
+			WorkloadKind: "Deployment",
+			WorkloadName: "echo",
+			Namespace:    "default",
+			Containers:   []*agentconfig.Container{{Name: "echo", Intercepts: ics}},
+		}
+	}
+	http := &agentconfig.Intercept{ServiceName: "echo", ServicePortName: "http", ServicePort: 80, Protocol: "TCP", AppProtocol: "http", ContainerPort: 8080}
+	grpc := &agentconfig.Intercept{ServiceName: "echo", ServicePortName: "api", ServicePort: 9000, Protocol: "TCP", AppProtocol: "kubernetes.io/h2c", ContainerPort: 9000}
+	metrics := &agentconfig.Intercept{ServiceName: "echo", ServicePortName: "metrics", ServicePort: 9090, Protocol: "TCP", ContainerPort: 9090}
+	admin := &agentconfig.Intercept{ServiceName: "echo", ServicePortName: "admin", ServicePort: 9091, Protocol: "TCP", ContainerPort: 9091}
+
+	t.Run("single web port is preferred", func(t *testing.T) {
+		_, ic, err := findIntercept(sidecar(metrics, http), &managerrpc.InterceptSpec{})
+		require.NoError(t, err)
+		assert.Equal(t, http, ic)
+	})
+
+	t.Run("explicit port wins", func(t *testing.T) {
+		_, ic, err := findIntercept(sidecar(metrics, http), &managerrpc.InterceptSpec{ServicePortIdentifier: "metrics"})
+		require.NoError(t, err)
+		assert.Equal(t, metrics, ic)
+	})
+
+	for name, ics := range map[string][]*agentconfig.Intercept{
+		"several web ports": {http, grpc, metrics},
+		"no web ports":      {metrics, admin},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := findIntercept(sidecar(ics...), &managerrpc.InterceptSpec{ServiceName: "echo"})
+			var ape *AmbiguousPortError
+			require.True(t, errors.As(err, &ape))
+			require.Len(t, ape.Ports, len(ics))
+			for i, ic := range ics {
+				p := ape.Ports[i]
+				assert.Equal(t, ic.ServicePortName, p.ServicePortName)
+				assert.Equal(t, int32(ic.ServicePort), p.ServicePort)
+				assert.Equal(t, ic.AppProtocol, p.AppProtocol)
+				assert.Equal(t, "echo", p.ContainerName)
+			}
+		})
+	}
+}
@@ -7,10 +7,11 @@ import (
 
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 )
 
 func TestPresence(t *testing.T) {
-	ctx := dlog.NewTestContext(t, false)
+	ctx := managerutil.WithEnv(dlog.NewTestContext(t, false), &managerutil.Env{})
 
 	p := NewState(ctx)
 
@@ -0,0 +1,58 @@
+package state
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// clientUser returns the user of the given client. Clients report their name as user@hostname, so
+// the same user will be counted once for each workstation that they connect from.
+func clientUser(client *rpc.ClientInfo) string {
+	user, _, _ := strings.Cut(client.Name, "@")
+	return user
+}
+
+// checkInterceptQuotas returns a ResourceExhausted error when the intercept with the given id and spec
+// would exceed the configured maximum number of concurrent intercepts for the user of the given client,
+// or for the intercepted workload. Intercepts that were removed are not counted. The caller must hold
+// the state's lock, so that no other intercept is added until this one is stored.
+func (s *state) checkInterceptQuotas(ctx context.Context, client *rpc.ClientInfo, interceptID string, spec *rpc.InterceptSpec) error {
+	env := managerutil.GetEnv(ctx)
+	maxPerUser := env.MaxInterceptsPerUser
+	maxPerWorkload := env.MaxInterceptsPerWorkload
+	if maxPerUser <= 0 && maxPerWorkload <= 0 {
+		return nil
+	}
+
+	user := clientUser(client)
+	userCount := 0
+	workloadCount := 0
+	for id, ii := range s.intercepts.LoadAll() {
+		if id == interceptID || ii.Disposition == rpc.InterceptDispositionType_REMOVED {
+			continue
+		}
+		if ii.Spec.Agent == spec.Agent && ii.Spec.Namespace == spec.Namespace {
+			workloadCount++
+		}
+		if ic := s.GetClient(ii.ClientSession.SessionId); ic != nil && clientUser(ic) == user {
+			userCount++
+		}
+	}
+
+	if maxPerUser > 0 && userCount >= maxPerUser {
+		return status.Errorf(codes.ResourceExhausted,
+			"user %q already has %d concurrent intercepts, which is the maximum allowed by the traffic-manager", user, userCount)
+	}
+	if maxPerWorkload > 0 && workloadCount >= maxPerWorkload {
+		return status.Errorf(codes.ResourceExhausted,
+			"workload %s.%s already has %d concurrent intercepts, which is the maximum allowed by the traffic-manager",
+			spec.Agent, spec.Namespace, workloadCount)
+	}
+	return nil
+}
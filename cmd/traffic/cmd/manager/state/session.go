@@ -3,12 +3,14 @@ package state
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v3"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -41,7 +43,7 @@ type sessionState struct {
 	cancel              context.CancelFunc
 	lastMarked          int64
 	awaitingBidiPipeMap *xsync.MapOf[tunnel.ConnID, awaitingBidiPipe]
-	dials               chan *rpc.DialRequest
+	dials               *dialQueue
 }
 
 // EstablishBidiPipe registers the given stream as waiting for a matching stream to arrive in a call
@@ -63,15 +65,35 @@ func (ss *sessionState) EstablishBidiPipe(ctx context.Context, stream tunnel.Str
 	carrier := propagation.MapCarrier{}
 	propagator.Inject(ctx, carrier)
 	dr.TraceContext = carrier
+
+	// Intercepted traffic that is on its way from an agent to a client has precedence.
+	prio := dialPriorityNormal
+	if strings.HasPrefix(stream.SessionID(), AgentSessionIDPrefix) {
+		prio = dialPriorityHigh
+	}
+
+	// Wait for the client/agent to connect. Allow extra time for the call, and for the time spent in the dial queue.
+	ctx, cancel := context.WithTimeout(ctx, stream.DialTimeout()+stream.RoundtripLatency())
+	defer cancel()
+	pd, err := ss.dials.push(ctx, dr, prio)
+	if err != nil {
+		ss.awaitingBidiPipeMap.Delete(id)
+		return nil, err
+	}
+	defer ss.dials.done(pd)
 	select {
+	case <-ctx.Done():
+		ss.awaitingBidiPipeMap.Delete(id)
+		return nil, status.Error(codes.DeadlineExceeded, "timeout while waiting for dial request dispatch")
 	case <-ss.Done():
 		return nil, status.Error(codes.Canceled, "session cancelled")
-	case ss.dials <- dr:
+	case err = <-pd.dispatched:
+		if err != nil {
+			ss.awaitingBidiPipeMap.Delete(id)
+			return nil, err
+		}
 	}
 
-	// Wait for the client/agent to connect. Allow extra time for the call
-	ctx, cancel := context.WithTimeout(ctx, stream.DialTimeout()+stream.RoundtripLatency())
-	defer cancel()
 	select {
 	case <-ctx.Done():
 		return nil, status.Error(codes.DeadlineExceeded, "timeout while establishing bidipipe")
@@ -129,11 +151,10 @@ func (ss *sessionState) Active() bool {
 
 func (ss *sessionState) Cancel() {
 	ss.cancel()
-	close(ss.dials)
 }
 
 func (ss *sessionState) Dials() <-chan *rpc.DialRequest {
-	return ss.dials
+	return ss.dials.dials()
 }
 
 func (ss *sessionState) Done() <-chan struct{} {
@@ -148,13 +169,13 @@ func (ss *sessionState) SetLastMarked(lastMarked time.Time) {
 	atomic.StoreInt64(&ss.lastMarked, lastMarked.UnixNano())
 }
 
-func newSessionState(ctx context.Context, now time.Time) sessionState {
+func newSessionState(ctx context.Context, now time.Time, dl dialLimits) sessionState {
 	ctx, cancel := context.WithCancel(ctx)
 	return sessionState{
 		doneCh:              ctx.Done(),
 		cancel:              cancel,
 		lastMarked:          now.UnixNano(),
-		dials:               make(chan *rpc.DialRequest),
+		dials:               newDialQueue(ctx, dl.maxQueued),
 		awaitingBidiPipeMap: xsync.NewMapOf[tunnel.ConnID, awaitingBidiPipe](),
 	}
 }
@@ -164,16 +185,49 @@ type clientSessionState struct {
 	pool *tunnel.Pool
 
 	consumptionMetrics *SessionConsumptionMetrics
+
+	// dialLimiter limits the rate of the dials that the client originates. It's nil when the rate
+	// isn't limited.
+	dialLimiter *rate.Limiter
+
+	// trafficBytes is the number of bytes tunneled to and from the client when lastTraffic was updated.
+	trafficBytes atomic.Uint64
+	lastTraffic  atomic.Int64
 }
 
 func (css *clientSessionState) ConsumptionMetrics() *SessionConsumptionMetrics {
 	return css.consumptionMetrics
 }
 
-func newClientSessionState(ctx context.Context, ts time.Time) *clientSessionState {
+// LastActive returns the time when the client was last active, i.e. when it last sent a keepalive, or
+// when it was last seen tunneling traffic. Traffic is detected by comparing the number of tunneled bytes
+// with the number from the previous call, so the given now is recorded when that number has changed.
+func (css *clientSessionState) LastActive(now time.Time) time.Time {
+	scm := css.consumptionMetrics
+	bytes := scm.FromClientBytes.GetValue() + scm.ToClientBytes.GetValue()
+	if css.trafficBytes.Swap(bytes) != bytes {
+		css.lastTraffic.Store(now.UnixNano())
+	}
+	la := css.LastMarked()
+	if lt := time.Unix(0, css.lastTraffic.Load()); lt.After(la) {
+		la = lt
+	}
+	return la
+}
+
+// allowDial returns a codes.ResourceExhausted error when the client has exceeded its dial rate.
+func (css *clientSessionState) allowDial() error {
+	if css.dialLimiter != nil && !css.dialLimiter.Allow() {
+		return status.Errorf(codes.ResourceExhausted, "dial rate limit of %g/s exceeded", float64(css.dialLimiter.Limit()))
+	}
+	return nil
+}
+
+func newClientSessionState(ctx context.Context, ts time.Time, dl dialLimits) *clientSessionState {
 	return &clientSessionState{
-		sessionState: newSessionState(ctx, ts),
+		sessionState: newSessionState(ctx, ts, dl),
 		pool:         tunnel.NewPool(),
+		dialLimiter:  dl.newLimiter(),
 
 		consumptionMetrics: NewSessionConsumptionMetrics(),
 	}
@@ -183,12 +237,13 @@ type agentSessionState struct {
 	sessionState
 	dnsRequests  chan *rpc.DNSRequest
 	dnsResponses map[string]chan *rpc.DNSResponse
+	dnsFailures  atomic.Int32
 	active       atomic.Bool
 }
 
-func newAgentSessionState(ctx context.Context, ts time.Time) *agentSessionState {
+func newAgentSessionState(ctx context.Context, ts time.Time, dl dialLimits) *agentSessionState {
 	as := &agentSessionState{
-		sessionState: newSessionState(ctx, ts),
+		sessionState: newSessionState(ctx, ts, dl),
 		dnsRequests:  make(chan *rpc.DNSRequest),
 		dnsResponses: make(map[string]chan *rpc.DNSResponse),
 	}
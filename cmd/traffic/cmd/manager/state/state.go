@@ -24,6 +24,7 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/audit"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/watchable"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
@@ -48,7 +49,9 @@ type State interface {
 	CountTunnels() int
 	CountTunnelIngress() uint64
 	CountTunnelEgress() uint64
+	CountPendingWorkloadEvents() int
 	ExpireSessions(context.Context, time.Time, time.Time)
+	ExpireIdleIntercepts(context.Context, time.Time, time.Duration)
 	GetAgent(sessionID string) *rpc.AgentInfo
 	GetActiveAgent(sessionID string) *rpc.AgentInfo
 	GetAllClients() map[string]*rpc.ClientInfo
@@ -72,9 +75,11 @@ type State interface {
 	RestoreAppContainer(context.Context, *rpc.InterceptInfo) error
 	FinalizeIntercept(ctx context.Context, intercept *rpc.InterceptInfo)
 	LoadMatchingIntercepts(filter func(string, *rpc.InterceptInfo) bool) map[string]*rpc.InterceptInfo
+	ListWorkloads(context.Context, *rpc.ListWorkloadsRequest, string) (*rpc.ListWorkloadsResponse, error)
 	RemoveSession(context.Context, string)
 	SessionDone(string) (<-chan struct{}, error)
 	SetTempLogLevel(context.Context, *rpc.LogLevelRequest)
+	SetDefaultLogLevel(ctx context.Context, level string)
 	SetAllClientSessionsFinalizer(finalizer allClientSessionsFinalizer)
 	SetAllInterceptsFinalizer(finalizer allInterceptsFinalizer)
 	SetPrometheusMetrics(connectCounterVec *prometheus.CounterVec,
@@ -135,6 +140,7 @@ type state struct {
 	interceptStates            *xsync.MapOf[string, *interceptState]
 	timedLogLevel              log.TimedLevel
 	llSubs                     *loglevelSubscribers
+	dialLimits                 dialLimits                            // limits of the dials of each session
 	workloadWatchers           *xsync.MapOf[string, WorkloadWatcher] // workload watchers, created on demand and keyed by namespace
 	tunnelCounter              int32
 	tunnelIngressCounter       uint64
@@ -160,6 +166,7 @@ func NewState(ctx context.Context) State {
 		workloadWatchers: xsync.NewMapOf[string, WorkloadWatcher](),
 		timedLogLevel:    log.NewTimedLevel(loglevel, log.SetLevel),
 		llSubs:           newLoglevelSubscribers(),
+		dialLimits:       dialLimitsFromEnv(managerutil.GetEnv(ctx)),
 	}
 	s.self = s
 	return s
@@ -209,7 +216,7 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 	var agentList []*rpc.AgentInfo
 	if agentSet, ok := s.agentsByName.Load(intercept.Spec.Agent); ok {
 		agentSet.Range(func(_ string, agent *rpc.AgentInfo) bool {
-			if agent.Namespace == intercept.Spec.Namespace {
+			if agent.Namespace == intercept.Spec.Namespace && PodSelected(s.backgroundCtx, intercept.Spec, agent) {
 				agentList = append(agentList, agent)
 			}
 			return true
@@ -217,6 +224,9 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 	}
 
 	switch {
+	case len(agentList) == 0 && intercept.Spec.Pod != "":
+		errCode = rpc.InterceptDispositionType_NO_AGENT
+		errMsg = fmt.Sprintf("No agent found for %q in a pod matching %q", intercept.Spec.Agent, intercept.Spec.Pod)
 	case len(agentList) == 0:
 		errCode = rpc.InterceptDispositionType_NO_AGENT
 		errMsg = fmt.Sprintf("No agent found for %q", intercept.Spec.Agent)
@@ -274,6 +284,7 @@ func (s *state) RemoveSession(ctx context.Context, sessionID string) {
 				return nil, true
 			})
 		} else if client, isClient := s.clients.LoadAndDelete(sessionID); isClient {
+			audit.Record(ctx, audit.ClientEvent(audit.ClientDisconnected, sessionID, client))
 			scm := sess.(*clientSessionState).consumptionMetrics
 			atomic.AddUint64(&s.tunnelIngressCounter, scm.FromClientBytes.GetValue())
 			atomic.AddUint64(&s.tunnelEgressCounter, scm.ToClientBytes.GetValue())
@@ -332,6 +343,40 @@ func (s *state) ExpireSessions(ctx context.Context, clientMoment, agentMoment ti
 	})
 }
 
+// ExpireIdleIntercepts removes the intercepts that have been idle for longer than their idle TTL at the
+// given time. An intercept is idle when its client neither sends keepalives nor tunnels any traffic. The
+// idle TTL is declared by the intercept's spec, or given by defaultTTL when the spec doesn't declare one.
+// A zero TTL means that the intercept never expires.
+func (s *state) ExpireIdleIntercepts(ctx context.Context, now time.Time, defaultTTL time.Duration) {
+	lastActive := make(map[string]time.Time)
+	for interceptID, ii := range s.intercepts.LoadAll() {
+		if ii.Disposition == rpc.InterceptDispositionType_REMOVED {
+			continue
+		}
+		ttl := defaultTTL
+		if it := ii.Spec.IdleTtl; it != nil {
+			ttl = it.AsDuration()
+		}
+		if ttl <= 0 {
+			continue
+		}
+		sessionID := ii.ClientSession.SessionId
+		la, ok := lastActive[sessionID]
+		if !ok {
+			css, isClient := s.GetSession(sessionID).(*clientSessionState)
+			if !isClient {
+				continue
+			}
+			la = css.LastActive(now)
+			lastActive[sessionID] = la
+		}
+		if idle := now.Sub(la); idle > ttl {
+			dlog.Infof(ctx, "Intercept %s expired after being idle for %s", interceptID, idle.Truncate(time.Second))
+			s.self.RemoveIntercept(ctx, interceptID)
+		}
+	}
+}
+
 // SessionDone returns a channel that is closed when the session with the given ID terminates.  If
 // there is no such currently-live session, then an already-closed channel is returned.
 func (s *state) SessionDone(id string) (<-chan struct{}, error) {
@@ -359,7 +404,7 @@ func (s *state) addClient(sessionID string, client *rpc.ClientInfo, now time.Tim
 	if oldClient, hasConflict := s.clients.LoadOrStore(sessionID, client); hasConflict {
 		panic(fmt.Errorf("duplicate id %q, existing %+v, new %+v", sessionID, oldClient, client))
 	}
-	s.sessions.Store(sessionID, newClientSessionState(s.backgroundCtx, now))
+	s.sessions.Store(sessionID, newClientSessionState(s.backgroundCtx, now, s.dialLimits))
 	s.mu.Unlock()
 	return sessionID
 }
@@ -397,6 +442,15 @@ func (s *state) CountTunnelIngress() uint64 {
 	return atomic.LoadUint64(&s.tunnelIngressCounter)
 }
 
+func (s *state) CountPendingWorkloadEvents() int {
+	n := 0
+	s.workloadWatchers.Range(func(_ string, ww WorkloadWatcher) bool {
+		n += ww.PendingEvents()
+		return true
+	})
+	return n
+}
+
 func (s *state) CountTunnelEgress() uint64 {
 	return atomic.LoadUint64(&s.tunnelEgressCounter)
 }
@@ -416,7 +470,7 @@ func (s *state) AddAgent(agent *rpc.AgentInfo, now time.Time) string {
 		return xsync.NewMapOf[string, *rpc.AgentInfo]()
 	})
 	agn.Store(sessionID, agent)
-	s.sessions.Store(sessionID, newAgentSessionState(s.backgroundCtx, now))
+	s.sessions.Store(sessionID, newAgentSessionState(s.backgroundCtx, now, s.dialLimits))
 
 	for interceptID, intercept := range s.intercepts.LoadAll() {
 		if intercept.Disposition == rpc.InterceptDispositionType_REMOVED {
@@ -518,6 +572,9 @@ func (s *state) AddIntercept(ctx context.Context, sessionID, clusterID string, c
 
 	spec := cir.InterceptSpec
 	interceptID := fmt.Sprintf("%s:%s", sessionID, spec.Name)
+	if err = s.checkInterceptQuotas(ctx, client, interceptID, spec); err != nil {
+		return nil, nil, err
+	}
 	installID := client.GetInstallId()
 	clientSession := rpc.SessionInfo{
 		SessionId: sessionID,
@@ -647,6 +704,7 @@ func (s *state) UpdateClient(sessionID string, apply func(*rpc.ClientInfo)) *rpc
 
 func (s *state) RemoveIntercept(ctx context.Context, interceptID string) {
 	if intercept, didDelete := s.intercepts.LoadAndDelete(interceptID); didDelete {
+		audit.Record(ctx, audit.InterceptEvent(audit.InterceptRemoved, intercept, s.GetClient(intercept.ClientSession.SessionId)))
 		s.FinalizeIntercept(ctx, intercept)
 	}
 }
@@ -711,6 +769,11 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 		}
 	case *clientSessionState:
 		scm = sst.ConsumptionMetrics()
+		if scm != nil {
+			cs := scm.AddConnection(stream.ID())
+			defer scm.RemoveConnection(cs)
+			stream = &connStatsStream{Stream: stream, stats: cs}
+		}
 	default:
 	}
 
@@ -749,6 +812,11 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 		peerSession, _ = s.sessions.Load(peerID)
 	} else {
 		span.SetAttributes(attribute.String("session-type", "userd"))
+		if css, isClient := ss.(*clientSessionState); isClient {
+			if err := css.allowDial(); err != nil {
+				return err
+			}
+		}
 		peerSession, err = s.getAgentForDial(ctx, sessionID, stream.ID().Destination())
 		if err != nil {
 			return err
@@ -839,6 +907,12 @@ func (s *state) SetTempLogLevel(ctx context.Context, logLevelRequest *rpc.LogLev
 	s.llSubs.notify(ctx, logLevelRequest)
 }
 
+// SetDefaultLogLevel sets the log-level that the traffic-manager uses when no temporary log-level is
+// in effect.
+func (s *state) SetDefaultLogLevel(ctx context.Context, level string) {
+	s.timedLogLevel.SetDefault(ctx, level)
+}
+
 // InitialTempLogLevel returns the temporary log-level if it exists, along with the remaining
 // duration for it, which might be zero, in which case the log-level is valid until a new
 // level is requested.
@@ -8,9 +8,13 @@ import (
 	"github.com/puzpuzpuz/xsync/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/test"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
 )
@@ -45,7 +49,7 @@ func (fc *FakeClock) Now() time.Time {
 }
 
 func (s *suiteState) TestStateInternal() {
-	ctx := context.Background()
+	ctx := managerutil.WithEnv(context.Background(), &managerutil.Env{})
 
 	testAgents := testdata.GetTestAgents(s.T())
 	testClients := testdata.GetTestClients(s.T())
@@ -174,8 +178,8 @@ func (s *suiteState) TestAddClient() {
 func (s *suiteState) TestRemoveSession() {
 	// given
 	now := time.Now()
-	s.state.sessions.Store("session-1", newClientSessionState(s.ctx, now))
-	s.state.sessions.Store("session-2", newAgentSessionState(s.ctx, now))
+	s.state.sessions.Store("session-1", newClientSessionState(s.ctx, now, dialLimits{}))
+	s.state.sessions.Store("session-2", newAgentSessionState(s.ctx, now, dialLimits{}))
 
 	// when
 	s.state.RemoveSession(s.ctx, "session-1")
@@ -185,6 +189,88 @@ func (s *suiteState) TestRemoveSession() {
 	assert.Equal(s.T(), s.state.sessions.Size(), 0)
 }
 
+func (s *suiteState) TestExpireIdleIntercepts() {
+	// given
+	s.state.self = s.state
+	clock := &FakeClock{}
+	c1 := s.state.AddClient(&manager.ClientInfo{Name: "my-client"}, clock.Now())
+	addIntercept := func(name string, ttl time.Duration) string {
+		spec := &manager.InterceptSpec{Name: name}
+		if ttl > 0 {
+			spec.IdleTtl = durationpb.New(ttl)
+		}
+		id := c1 + ":" + name
+		s.state.intercepts.Store(id, &manager.InterceptInfo{
+			Id:            id,
+			Spec:          spec,
+			Disposition:   manager.InterceptDispositionType_ACTIVE,
+			ClientSession: &manager.SessionInfo{SessionId: c1},
+		})
+		return id
+	}
+	short := addIntercept("short", 30*time.Second)
+	long := addIntercept("long", 0)
+
+	// then an intercept expires when neither keepalives nor traffic is seen during its TTL
+	clock.When = 20
+	s.True(s.state.MarkSession(&manager.RemainRequest{Session: &manager.SessionInfo{SessionId: c1}}, clock.Now()))
+	clock.When = 45
+	s.state.ExpireIdleIntercepts(s.ctx, clock.Now(), time.Minute)
+	_, ok := s.state.GetIntercept(short)
+	s.True(ok)
+
+	s.state.GetSessionConsumptionMetrics(c1).ToClientBytes.Increment(100)
+	clock.When = 70
+	s.state.ExpireIdleIntercepts(s.ctx, clock.Now(), time.Minute)
+	_, ok = s.state.GetIntercept(short)
+	s.True(ok, "traffic keeps the intercept alive")
+
+	clock.When = 101
+	s.state.ExpireIdleIntercepts(s.ctx, clock.Now(), time.Minute)
+	_, ok = s.state.GetIntercept(short)
+	s.False(ok)
+	_, ok = s.state.GetIntercept(long)
+	s.True(ok, "the default TTL applies to intercepts without a TTL")
+
+	clock.When = 131
+	s.state.ExpireIdleIntercepts(s.ctx, clock.Now(), time.Minute)
+	_, ok = s.state.GetIntercept(long)
+	s.False(ok)
+}
+
+func (s *suiteState) TestInterceptQuotas() {
+	// given
+	s.state.self = s.state
+	ctx := managerutil.WithEnv(s.ctx, &managerutil.Env{MaxInterceptsPerUser: 2, MaxInterceptsPerWorkload: 2})
+	now := time.Now()
+	alice1 := s.state.AddClient(&manager.ClientInfo{Name: "alice@host1"}, now)
+	alice2 := s.state.AddClient(&manager.ClientInfo{Name: "alice@host2"}, now)
+	bob := s.state.AddClient(&manager.ClientInfo{Name: "bob@host3"}, now)
+	addIntercept := func(sessionID, name, workload string) error {
+		_, _, err := s.state.AddIntercept(ctx, sessionID, "cluster", &manager.CreateInterceptRequest{
+			InterceptSpec: &manager.InterceptSpec{Name: name, Agent: workload, Namespace: "default"},
+		})
+		return err
+	}
+
+	// then the user quota counts the intercepts of all the user's clients
+	s.NoError(addIntercept(alice1, "a", "echo-a"))
+	s.NoError(addIntercept(alice2, "b", "echo-b"))
+	err := addIntercept(alice1, "c", "echo-c")
+	s.Equal(codes.ResourceExhausted, status.Code(err))
+	s.Contains(err.Error(), `user "alice"`)
+
+	// and the workload quota counts the intercepts of all users
+	s.NoError(addIntercept(bob, "a", "echo-a"))
+	err = addIntercept(bob, "a2", "echo-a")
+	s.Equal(codes.ResourceExhausted, status.Code(err))
+	s.Contains(err.Error(), "workload echo-a.default")
+
+	// and removed intercepts are not counted
+	s.state.RemoveIntercept(ctx, alice1+":a")
+	s.NoError(addIntercept(alice1, "c", "echo-c"))
+}
+
 func TestSuiteState(testing *testing.T) {
 	suite.Run(testing, new(suiteState))
 }
@@ -161,6 +161,10 @@ func rpcKind(s string) rpc.WorkloadInfo_Kind {
 		return rpc.WorkloadInfo_REPLICASET
 	case "statefulset":
 		return rpc.WorkloadInfo_STATEFULSET
+	case "daemonset":
+		return rpc.WorkloadInfo_DAEMONSET
+	case "deploymentconfig":
+		return rpc.WorkloadInfo_DEPLOYMENTCONFIG
 	default:
 		return rpc.WorkloadInfo_UNSPECIFIED
 	}
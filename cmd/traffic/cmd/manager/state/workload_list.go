@@ -0,0 +1,187 @@
+package state
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/namespaces"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
+)
+
+// workloadListKey is the sort key of a listed workload. It's also used as the page token, so that the
+// next page starts after the last workload of the previous page, regardless of workloads being added
+// or deleted in between.
+func workloadListKey(wl k8sapi.Workload) string {
+	return wl.GetNamespace() + "/" + wl.GetName() + "/" + wl.GetKind()
+}
+
+// ListWorkloads returns a page of the workloads in the given namespaces. The workloads are found using
+// the namespace index of the shared informer caches, so no calls are made to the API server.
+func (s *state) ListWorkloads(ctx context.Context, rq *rpc.ListWorkloadsRequest, clientNamespace string) (*rpc.ListWorkloadsResponse, error) {
+	var after string
+	if rq.PageToken != "" {
+		ab, err := base64.RawURLEncoding.DecodeString(rq.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		after = string(ab)
+	}
+	nss := slices.Clone(rq.Namespaces)
+	if len(nss) == 0 {
+		nss = []string{clientNamespace}
+	}
+	slices.Sort(nss)
+	nss = slices.Compact(nss)
+
+	var wls []k8sapi.Workload
+	for _, ns := range nss {
+		if namespaces.IsManaged(ctx, ns) {
+			wls = append(wls, rootWorkloads(ctx, ns, rq.NamePrefix)...)
+		}
+	}
+	slices.SortFunc(wls, func(a, b k8sapi.Workload) int {
+		return strings.Compare(workloadListKey(a), workloadListKey(b))
+	})
+
+	intercepted := s.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.Disposition == rpc.InterceptDispositionType_ACTIVE && slices.Contains(nss, ii.Spec.Namespace)
+	})
+	svcCache := make(map[string][]*core.Service)
+	rs := &rpc.ListWorkloadsResponse{}
+	var lastKey string
+	for _, wl := range wls {
+		key := workloadListKey(wl)
+		if key <= after {
+			continue
+		}
+		wi := s.listedWorkload(ctx, wl, intercepted, svcCache, rq.Filter)
+		if wi == nil {
+			continue
+		}
+		if rq.PageSize > 0 && len(rs.Workloads) == int(rq.PageSize) {
+			// There's at least one more workload, so another page is needed.
+			rs.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+			break
+		}
+		rs.Workloads = append(rs.Workloads, wi)
+		lastKey = key
+	}
+	return rs, nil
+}
+
+// listedWorkload returns the WorkloadInfo for the given workload, or nil if it's excluded by the filter.
+func (s *state) listedWorkload(
+	ctx context.Context,
+	wl k8sapi.Workload,
+	intercepted map[string]*rpc.InterceptInfo,
+	svcCache map[string][]*core.Service,
+	filter rpc.ListWorkloadsRequest_Filter,
+) *rpc.WorkloadInfo {
+	name, ns := wl.GetName(), wl.GetNamespace()
+	as := rpc.WorkloadInfo_NO_AGENT_UNSPECIFIED
+	var iClients []*rpc.WorkloadInfo_Intercept
+	if len(s.getAgentsByName(name, ns)) > 0 {
+		as = rpc.WorkloadInfo_INSTALLED
+		for _, ii := range intercepted {
+			if ii.Spec.Agent == name && ii.Spec.Namespace == ns {
+				as = rpc.WorkloadInfo_INTERCEPTED
+				iClients = append(iClients, &rpc.WorkloadInfo_Intercept{Client: ii.Spec.Client})
+			}
+		}
+	}
+	switch filter {
+	case rpc.ListWorkloadsRequest_INSTALLED_AGENTS:
+		if as == rpc.WorkloadInfo_NO_AGENT_UNSPECIFIED {
+			return nil
+		}
+	case rpc.ListWorkloadsRequest_INTERCEPTED:
+		if as != rpc.WorkloadInfo_INTERCEPTED {
+			return nil
+		}
+	}
+
+	svcs, ok := svcCache[ns]
+	if !ok {
+		svcs = listServices(ctx, ns)
+		svcCache[ns] = svcs
+	}
+	tpl := wl.GetPodTemplate()
+	podLabels := labels.Set(tpl.Labels)
+	var wis []*rpc.WorkloadInfo_Service
+	for _, svc := range svcs {
+		if labels.SelectorFromValidatedSet(svc.Spec.Selector).Matches(podLabels) {
+			ports := make([]*rpc.WorkloadInfo_Service_Port, len(svc.Spec.Ports))
+			for i, p := range svc.Spec.Ports {
+				ports[i] = &rpc.WorkloadInfo_Service_Port{Name: p.Name, Port: p.Port}
+			}
+			wis = append(wis, &rpc.WorkloadInfo_Service{Name: svc.Name, Uid: string(svc.UID), Ports: ports})
+		}
+	}
+	if len(wis) == 0 && filter != rpc.ListWorkloadsRequest_EVERYTHING_UNSPECIFIED {
+		return nil
+	}
+
+	wi := rpcWorkload(wl, as, iClients)
+	wi.Uid = string(wl.GetUID())
+	wi.Services = wis
+	if _, err := agentconfig.HostNetworkPorts(tpl); err != nil {
+		wi.NotInterceptableReason = err.Error()
+	}
+	return wi
+}
+
+// rootWorkloads returns the workloads in the given namespace that aren't owned by another workload.
+func rootWorkloads(ctx context.Context, ns, namePrefix string) []k8sapi.Workload {
+	f := informer.GetFactory(ctx, ns)
+	if f == nil {
+		return nil
+	}
+	ai := f.Apps().V1()
+	ixs := []cache.SharedIndexInformer{
+		ai.Deployments().Informer(),
+		ai.ReplicaSets().Informer(),
+		ai.StatefulSets().Informer(),
+		ai.DaemonSets().Informer(),
+	}
+	if ix := openshift.GetInformer(ctx, ns); ix != nil {
+		ixs = append(ixs, ix)
+	}
+	var wls []k8sapi.Workload
+	for _, ix := range ixs {
+		objs, err := ix.GetIndexer().ByIndex(cache.NamespaceIndex, ns)
+		if err != nil {
+			continue
+		}
+		for _, obj := range objs {
+			if wl, ok := mutator.WorkloadFromAny(obj); ok && len(wl.GetOwnerReferences()) == 0 && strings.HasPrefix(wl.GetName(), namePrefix) {
+				wls = append(wls, wl)
+			}
+		}
+	}
+	return wls
+}
+
+func listServices(ctx context.Context, ns string) []*core.Service {
+	f := informer.GetFactory(ctx, ns)
+	if f == nil {
+		return nil
+	}
+	svcs, err := f.Core().V1().Services().Lister().Services(ns).List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(svcs, func(svc *core.Service) bool {
+		return len(svc.Spec.Selector) == 0
+	})
+}
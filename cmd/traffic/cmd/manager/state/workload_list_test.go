@@ -0,0 +1,112 @@
+package state
+
+import (
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+func (s *suiteState) TestListWorkloads() {
+	podTemplate := func(app string) core.PodTemplateSpec {
+		return core.PodTemplateSpec{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{"app": app}}}
+	}
+	deployment := func(name string) *apps.Deployment {
+		return &apps.Deployment{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+			Spec:       apps.DeploymentSpec{Template: podTemplate(name)},
+		}
+	}
+	service := func(name string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("svc-uid-" + name)},
+			Spec: core.ServiceSpec{
+				Selector: map[string]string{"app": name},
+				Ports:    []core.ServicePort{{Name: "http", Port: 80}},
+			},
+		}
+	}
+	objs := []runtime.Object{
+		deployment("web"),
+		deployment("api"),
+		deployment("worker"),
+		&apps.ReplicaSet{
+			ObjectMeta: meta.ObjectMeta{
+				Name:            "web-5d8f9",
+				Namespace:       "default",
+				OwnerReferences: []meta.OwnerReference{{Kind: "Deployment", Name: "web"}},
+			},
+			Spec: apps.ReplicaSetSpec{Template: podTemplate("web")},
+		},
+		&apps.StatefulSet{
+			ObjectMeta: meta.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       apps.StatefulSetSpec{Template: podTemplate("db")},
+		},
+		service("web"),
+		service("api"),
+		service("db"),
+	}
+	ctx := informer.WithFactory(k8sapi.WithK8sInterface(s.ctx, fake.NewSimpleClientset(objs...)), "")
+	f := informer.GetFactory(ctx, "")
+	ai := f.Apps().V1()
+	ai.Deployments().Informer()
+	ai.ReplicaSets().Informer()
+	ai.StatefulSets().Informer()
+	ai.DaemonSets().Informer()
+	f.Core().V1().Services().Informer()
+	f.Start(ctx.Done())
+	f.WaitForCacheSync(ctx.Done())
+
+	s.state.AddAgent(&manager.AgentInfo{Name: "api", Namespace: "default", PodName: "api-xyz"}, time.Now())
+
+	names := func(rs *manager.ListWorkloadsResponse) []string {
+		ns := make([]string, len(rs.Workloads))
+		for i, wl := range rs.Workloads {
+			ns[i] = wl.Name
+		}
+		return ns
+	}
+	list := func(rq *manager.ListWorkloadsRequest) *manager.ListWorkloadsResponse {
+		rs, err := s.state.ListWorkloads(ctx, rq, "default")
+		s.Require().NoError(err)
+		return rs
+	}
+
+	rs := list(&manager.ListWorkloadsRequest{Filter: manager.ListWorkloadsRequest_INTERCEPTABLE})
+	s.Equal([]string{"api", "db", "web"}, names(rs))
+	s.Empty(rs.NextPageToken)
+	web := rs.Workloads[2]
+	s.Equal(manager.WorkloadInfo_DEPLOYMENT, web.Kind)
+	s.Equal("uid-web", web.Uid)
+	s.Require().Len(web.Services, 1)
+	s.Equal("web", web.Services[0].Name)
+	s.Equal(int32(80), web.Services[0].Ports[0].Port)
+	s.Equal(manager.WorkloadInfo_INSTALLED, rs.Workloads[0].AgentState)
+
+	s.Equal([]string{"api", "db", "web", "worker"}, names(list(&manager.ListWorkloadsRequest{})))
+	s.Equal([]string{"web", "worker"}, names(list(&manager.ListWorkloadsRequest{NamePrefix: "w"})))
+	s.Equal([]string{"api"}, names(list(&manager.ListWorkloadsRequest{Filter: manager.ListWorkloadsRequest_INSTALLED_AGENTS})))
+	s.Empty(list(&manager.ListWorkloadsRequest{Filter: manager.ListWorkloadsRequest_INTERCEPTED}).Workloads)
+	s.Empty(list(&manager.ListWorkloadsRequest{Namespaces: []string{"other"}}).Workloads)
+
+	// Pagination
+	rq := &manager.ListWorkloadsRequest{Filter: manager.ListWorkloadsRequest_INTERCEPTABLE, PageSize: 2}
+	rs = list(rq)
+	s.Equal([]string{"api", "db"}, names(rs))
+	s.Require().NotEmpty(rs.NextPageToken)
+	rq.PageToken = rs.NextPageToken
+	rs = list(rq)
+	s.Equal([]string{"web"}, names(rs))
+	s.Empty(rs.NextPageToken)
+
+	_, err := s.state.ListWorkloads(ctx, &manager.ListWorkloadsRequest{PageToken: "!"}, "default")
+	s.Error(err)
+}
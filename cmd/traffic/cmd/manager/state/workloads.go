@@ -17,6 +17,7 @@ import (
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 )
 
 type EventType int
@@ -47,35 +48,98 @@ func (e EventType) String() string {
 
 type WorkloadWatcher interface {
 	Subscribe(ctx context.Context) <-chan []WorkloadEvent
+
+	// PendingEvents returns the number of events that are waiting to be distributed to subscribers.
+	PendingEvents() int
+}
+
+type eventKey struct {
+	kind string
+	name string
+}
+
+// eventBatch is an ordered set of workload events in which a later event for a workload replaces the
+// earlier one, so its size is bounded by the number of workloads regardless of how many events arrive.
+type eventBatch struct {
+	events []WorkloadEvent
+	index  map[eventKey]int
+}
+
+func (b *eventBatch) add(we WorkloadEvent) {
+	k := eventKey{kind: we.Workload.GetKind(), name: we.Workload.GetName()}
+	if i, ok := b.index[k]; ok {
+		if b.events[i].Type == EventTypeAdd && we.Type == EventTypeUpdate {
+			// The subscriber hasn't seen the add yet.
+			we.Type = EventTypeAdd
+		}
+		b.events[i] = we
+		return
+	}
+	if b.index == nil {
+		b.index = make(map[eventKey]int)
+	}
+	b.index[k] = len(b.events)
+	b.events = append(b.events, we)
+}
+
+func (b *eventBatch) take() []WorkloadEvent {
+	evs := b.events
+	b.events = nil
+	b.index = nil
+	return evs
+}
+
+// subscription buffers the events for one subscriber, so that a slow subscriber never blocks the
+// distribution of events to other subscribers.
+type subscription struct {
+	sync.Mutex
+	batch  eventBatch
+	wakeup chan struct{}
+}
+
+func (s *subscription) post(events []WorkloadEvent) {
+	s.Lock()
+	for _, we := range events {
+		s.batch.add(we)
+	}
+	s.Unlock()
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscription) pending() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.batch.events)
 }
 
 type wlWatcher struct {
 	sync.Mutex
-	subscriptions map[uuid.UUID]chan<- []WorkloadEvent
+	subscriptions map[uuid.UUID]*subscription
 	timer         *time.Timer
-	events        []WorkloadEvent
+	batch         eventBatch
 }
 
 func NewWorkloadWatcher(ctx context.Context, ns string) (WorkloadWatcher, error) {
 	w := new(wlWatcher)
-	w.subscriptions = make(map[uuid.UUID]chan<- []WorkloadEvent)
+	w.subscriptions = make(map[uuid.UUID]*subscription)
 	w.timer = time.AfterFunc(time.Duration(math.MaxInt64), func() {
 		w.Lock()
-		ss := make([]chan<- []WorkloadEvent, len(w.subscriptions))
+		ss := make([]*subscription, len(w.subscriptions))
 		i := 0
 		for _, sub := range w.subscriptions {
 			ss[i] = sub
 			i++
 		}
-		events := w.events
-		w.events = nil
+		events := w.batch.take()
 		w.Unlock()
+		if len(events) == 0 {
+			return
+		}
 		for _, s := range ss {
-			select {
-			case <-ctx.Done():
-				return
-			case s <- events:
-			}
+			s.post(events)
 		}
 	})
 
@@ -89,19 +153,53 @@ func NewWorkloadWatcher(ctx context.Context, ns string) (WorkloadWatcher, error)
 func (w *wlWatcher) Subscribe(ctx context.Context) <-chan []WorkloadEvent {
 	ch := make(chan []WorkloadEvent)
 	id := uuid.New()
+	sub := &subscription{wakeup: make(chan struct{}, 1)}
 	w.Lock()
-	w.subscriptions[id] = ch
+	w.subscriptions[id] = sub
 	w.Unlock()
 	go func() {
-		<-ctx.Done()
-		close(ch)
-		w.Lock()
-		delete(w.subscriptions, id)
-		w.Unlock()
+		defer func() {
+			close(ch)
+			w.Lock()
+			delete(w.subscriptions, id)
+			w.Unlock()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sub.wakeup:
+			}
+			sub.Lock()
+			events := sub.batch.take()
+			sub.Unlock()
+			if len(events) == 0 {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- events:
+			}
+		}
 	}()
 	return ch
 }
 
+func (w *wlWatcher) PendingEvents() int {
+	w.Lock()
+	n := len(w.batch.events)
+	ss := make([]*subscription, 0, len(w.subscriptions))
+	for _, sub := range w.subscriptions {
+		ss = append(ss, sub)
+	}
+	w.Unlock()
+	for _, sub := range ss {
+		n += sub.pending()
+	}
+	return n
+}
+
 func compareOptions() []cmp.Option {
 	return []cmp.Option{
 		// Ignore frequently changing fields of no interest
@@ -115,6 +213,14 @@ func compareOptions() []cmp.Option {
 			}))
 		}),
 
+		// Only the Conditions are of interest in the DeploymentConfigStatus.
+		cmp.Comparer(func(a, b openshift.DeploymentConfigStatus) bool {
+			// Only compare the DeploymentConfigCondition's type and status
+			return cmp.Equal(a.Conditions, b.Conditions, cmp.Comparer(func(c1, c2 openshift.DeploymentConfigCondition) bool {
+				return c1.Type == c2.Type && c1.Status == c2.Status
+			}))
+		}),
+
 		// Treat a nil map or slice as empty.
 		cmpopts.EquateEmpty(),
 
@@ -176,12 +282,20 @@ func (w *wlWatcher) addEventHandler(ctx context.Context, ns string) error {
 	if err := w.watchWorkloads(ai.StatefulSets().Informer(), ns); err != nil {
 		return err
 	}
+	if err := w.watchWorkloads(ai.DaemonSets().Informer(), ns); err != nil {
+		return err
+	}
+	if ix := openshift.GetInformer(ctx, ns); ix != nil {
+		if err := w.watchWorkloads(ix, ns); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (w *wlWatcher) handleEvent(we WorkloadEvent) {
 	w.Lock()
-	w.events = append(w.events, we)
+	w.batch.add(we)
 	w.Unlock()
 
 	// Defers sending until things been quiet for a while
@@ -0,0 +1,188 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/wireguard"
+)
+
+// defaultWireGuardSubnet is the subnet from which the clients are assigned their addresses inside the
+// WireGuard tunnel. The addresses are only used by the userspace network stacks at each end of the tunnel,
+// so they never conflict with the addresses of the cluster or the workstation.
+const defaultWireGuardSubnet = "198.18.0.0/16"
+
+var errNoFreeAddress = errors.New("no free addresses in the WireGuard subnet") //nolint:gochecknoglobals // constant
+
+// wireGuard is the WireGuard endpoint of the traffic-manager. Clients that use it send their outbound traffic
+// through it instead of through gRPC tunnels, and the traffic-manager dials the destinations.
+type wireGuard struct {
+	sync.Mutex
+	device    *wireguard.Device
+	publicKey wireguard.Key
+	endpoint  string
+	subnet    netip.Prefix
+	next      netip.Addr
+	peers     map[string]wireGuardPeer // keyed by session ID
+	used      map[netip.Addr]struct{}
+}
+
+type wireGuardPeer struct {
+	publicKey wireguard.Key
+	ip        netip.Addr
+}
+
+func newWireGuard(ctx context.Context, env *managerutil.Env) (*wireGuard, error) {
+	sn := env.WireGuardSubnet
+	if sn == "" {
+		sn = defaultWireGuardSubnet
+	}
+	subnet, err := netip.ParsePrefix(sn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WireGuard subnet: %w", err)
+	}
+	if !subnet.Addr().Is4() || subnet.Bits() > 30 {
+		return nil, fmt.Errorf("invalid WireGuard subnet %s: must be an IPv4 subnet with room for at least two clients", subnet)
+	}
+	subnet = subnet.Masked()
+
+	endpoint := env.WireGuardEndpoint
+	if err = checkExternalEndpoint("WireGuard", endpoint, env); err != nil {
+		return nil, err
+	}
+
+	key, err := wireguard.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	dev, err := wireguard.NewServer(ctx, key, env.WireGuardPort)
+	if err != nil {
+		return nil, err
+	}
+	context.AfterFunc(ctx, dev.Close)
+	dlog.Infof(ctx, "WireGuard endpoint listening on port %d, advertised as %s", env.WireGuardPort, endpoint)
+	return &wireGuard{
+		device:    dev,
+		publicKey: key.PublicKey(),
+		endpoint:  endpoint,
+		subnet:    subnet,
+		next:      subnet.Addr().Next(),
+		peers:     make(map[string]wireGuardPeer),
+		used:      make(map[netip.Addr]struct{}),
+	}, nil
+}
+
+// addPeer adds a peer with the given public key for the given session, and returns the address that the peer
+// must use inside the tunnel. A session that already has a peer keeps its address, but its key is replaced.
+// The peer is removed when the given done channel is closed.
+func (w *wireGuard) addPeer(ctx context.Context, sessionID string, publicKey wireguard.Key, done <-chan struct{}) (netip.Addr, error) {
+	w.Lock()
+	defer w.Unlock()
+	p, ok := w.peers[sessionID]
+	if ok {
+		if p.publicKey == publicKey {
+			return p.ip, nil
+		}
+		if err := w.device.RemovePeer(p.publicKey); err != nil {
+			return netip.Addr{}, err
+		}
+	} else {
+		ip, err := w.allocateIP()
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		p.ip = ip
+	}
+	if err := w.device.AddPeer(publicKey, netip.PrefixFrom(p.ip, 32), netip.AddrPort{}, 0); err != nil {
+		delete(w.peers, sessionID)
+		delete(w.used, p.ip)
+		return netip.Addr{}, err
+	}
+	p.publicKey = publicKey
+	w.peers[sessionID] = p
+	dlog.Debugf(ctx, "WireGuard peer %s added with address %s", publicKey, p.ip)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			w.removePeer(ctx, sessionID, publicKey)
+		}
+	}()
+	return p.ip, nil
+}
+
+// removePeer removes the peer of the given session, unless its key has been replaced.
+func (w *wireGuard) removePeer(ctx context.Context, sessionID string, publicKey wireguard.Key) {
+	w.Lock()
+	defer w.Unlock()
+	p, ok := w.peers[sessionID]
+	if !ok || p.publicKey != publicKey {
+		return
+	}
+	delete(w.peers, sessionID)
+	delete(w.used, p.ip)
+	if err := w.device.RemovePeer(publicKey); err != nil {
+		dlog.Error(ctx, err)
+		return
+	}
+	dlog.Debugf(ctx, "WireGuard peer %s removed", publicKey)
+}
+
+// allocateIP returns the next free address of the subnet. The first address of the subnet is never used, and
+// neither is the last, because it's the broadcast address.
+func (w *wireGuard) allocateIP() (netip.Addr, error) {
+	first := w.subnet.Addr().Next()
+	ip := w.next
+	for range 1 << (32 - w.subnet.Bits()) {
+		if !w.subnet.Contains(ip.Next()) {
+			// ip is the broadcast address.
+			ip = first
+		}
+		if _, ok := w.used[ip]; !ok {
+			w.used[ip] = struct{}{}
+			w.next = ip.Next()
+			return ip, nil
+		}
+		ip = ip.Next()
+	}
+	return netip.Addr{}, errNoFreeAddress
+}
+
+func (s *service) WireGuardPeer(ctx context.Context, request *rpc.WireGuardPeerRequest) (*rpc.WireGuardPeerResponse, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debug(ctx, "WireGuardPeer called")
+	if s.wireGuard == nil {
+		return nil, status.Error(codes.Unimplemented, "WireGuard is not enabled in the traffic-manager")
+	}
+	publicKey, err := wireguard.ParseKey(request.GetPublicKey())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sessionID := request.GetSession().GetSessionId()
+	done, err := s.state.SessionDone(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := s.wireGuard.addPeer(s.ctx, sessionID, publicKey, done)
+	if err != nil {
+		if errors.Is(err, errNoFreeAddress) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &rpc.WireGuardPeerResponse{
+		PublicKey: s.wireGuard.publicKey.String(),
+		Endpoint:  s.wireGuard.endpoint,
+		ClientIp:  ip.AsSlice(),
+	}, nil
+}
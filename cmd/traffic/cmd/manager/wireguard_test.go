@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func Test_wireGuardAllocateIP(t *testing.T) {
+	subnet := netip.MustParsePrefix("10.250.0.0/29")
+	w := &wireGuard{
+		subnet: subnet,
+		next:   subnet.Addr().Next(),
+		used:   make(map[netip.Addr]struct{}),
+	}
+
+	// The network and broadcast addresses are never allocated.
+	var ips []string
+	for range 6 {
+		ip, err := w.allocateIP()
+		require.NoError(t, err)
+		ips = append(ips, ip.String())
+	}
+	assert.Equal(t, []string{"10.250.0.1", "10.250.0.2", "10.250.0.3", "10.250.0.4", "10.250.0.5", "10.250.0.6"}, ips)
+	_, err := w.allocateIP()
+	assert.ErrorIs(t, err, errNoFreeAddress)
+
+	// Released addresses are reused once the allocation wraps around.
+	delete(w.used, netip.MustParseAddr("10.250.0.3"))
+	ip, err := w.allocateIP()
+	require.NoError(t, err)
+	assert.Equal(t, "10.250.0.3", ip.String())
+}
+
+func Test_newWireGuardEndpoint(t *testing.T) {
+	_, pods, _ := net.ParseCIDR("10.244.0.0/16")
+	env := func(endpoint string) *managerutil.Env {
+		return &managerutil.Env{
+			WireGuardPort:     51820,
+			WireGuardEndpoint: endpoint,
+			PodIP:             net.IPv4(10, 244, 1, 5),
+			PodCIDRs:          []*net.IPNet{pods},
+		}
+	}
+	ctx := context.Background()
+	_, err := newWireGuard(ctx, env(""))
+	assert.ErrorContains(t, err, "endpoint")
+	_, err = newWireGuard(ctx, env("wg.example.com"))
+	assert.ErrorContains(t, err, "invalid WireGuard endpoint")
+	_, err = newWireGuard(ctx, env("10.244.1.5:51820"))
+	assert.ErrorContains(t, err, "pod IP")
+	_, err = newWireGuard(ctx, env("10.244.7.1:51820"))
+	assert.ErrorContains(t, err, "pod subnet")
+}
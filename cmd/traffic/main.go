@@ -37,7 +37,7 @@ func main() {
 
 	if cmd, cmdOK := cmds[name]; cmdOK {
 		ctx := context.Background()
-		ctx = log.MakeBaseLogger(ctx, os.Getenv("LOG_LEVEL"))
+		ctx = log.MakeBaseLogger(ctx, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
 		if err := cmd(ctx, args...); err != nil {
 			dlog.Errorf(ctx, "quit: %v", err)
 			os.Exit(1)
@@ -54,4 +54,5 @@ func (s *connectedSuite) Test_StatusWithJSON() {
 	s.Require().NotNil(status.TrafficManager)
 	s.NotEmpty(status.TrafficManager.Version)
 	s.NotEmpty(status.TrafficManager.TrafficAgent)
+	s.NotNil(status.ConnectionQuality)
 }
@@ -92,6 +92,31 @@ func (s *multipleInterceptsSuite) TestGatherLogs_NoK8sLogs() {
 	require.Equal(0, yamlCount, fileNames)
 }
 
+func (s *multipleInterceptsSuite) TestGatherLogs_ClusterState() {
+	require := s.Require()
+	outputDir := s.T().TempDir()
+	ctx := s.Context()
+	outputFile := filepath.Join(outputDir, "allLogs.zip")
+	s.cleanLogDir(ctx)
+	itest.TelepresenceOk(ctx, "gather-logs", "--output-file", outputFile, "--daemons=None")
+	_, _, _, fileNames := s.getZipData(outputFile)
+	require.Contains(fileNames, "events."+s.AppNamespace()+".txt")
+	require.Contains(fileNames, "events."+s.ManagerNamespace()+".txt")
+	require.Contains(fileNames, "agent-injector-webhook-"+s.ManagerNamespace()+".yaml")
+	descMatch := regexp.MustCompile(fmt.Sprintf(`^%s-[0-9a-z-]+\.%s\.description\.txt$`, s.svcRegex(), s.AppNamespace()))
+	descCount := 0
+	for _, n := range fileNames {
+		if descMatch.MatchString(n) {
+			descCount++
+		}
+	}
+	require.GreaterOrEqual(descCount, s.ServiceCount(), fileNames)
+
+	itest.TelepresenceOk(ctx, "gather-logs", "--output-file", outputFile, "--daemons=None", "--get-cluster-state=false")
+	_, _, _, fileNames = s.getZipData(outputFile)
+	require.NotContains(fileNames, "events."+s.AppNamespace()+".txt")
+}
+
 func (s *connectedSuite) TestGatherLogs_OnlyMappedLogs() {
 	const svc = "echo"
 	require := s.Require()
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/telepresenceio/telepresence/v2/integration_test/itest"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/redact"
 )
 
 type interceptEnvSuite struct {
@@ -53,3 +54,33 @@ func (s *interceptEnvSuite) Test_ExcludeVariables() {
 	s.Contains(file, "TEST=DATA")
 	s.Contains(file, "INTERCEPT=ENV")
 }
+
+func (s *interceptEnvSuite) Test_RedactSecrets() {
+	ctx := s.Context()
+	s.TelepresenceHelmInstallOK(ctx, false)
+	defer s.UninstallTrafficManager(ctx, s.ManagerNamespace())
+
+	s.ApplyApp(ctx, "echo_with_secret_env", "deploy/echo-easy")
+	defer func() {
+		s.DeleteSvcAndWorkload(ctx, "deploy", "echo-easy")
+		_ = s.Kubectl(ctx, "delete", "secret", "echo-easy-db")
+	}()
+
+	s.TelepresenceConnect(ctx)
+	readEnv := func(args ...string) string {
+		env := filepath.Join(s.T().TempDir(), "echo.env")
+		itest.TelepresenceOk(ctx, append([]string{"intercept", "echo-easy", "--env-file", env}, args...)...)
+		defer itest.TelepresenceOk(ctx, "leave", "echo-easy")
+		dt, err := os.ReadFile(env)
+		s.Require().NoError(err)
+		return string(dt)
+	}
+
+	file := readEnv()
+	s.Contains(file, "TEST=DATA")
+	s.Contains(file, "DATABASE_PASSWORD="+redact.Mask)
+	s.NotContains(file, "SUPER_SECRET_PASSWORD")
+
+	file = readEnv("--no-redact")
+	s.Contains(file, "DATABASE_PASSWORD=SUPER_SECRET_PASSWORD")
+}
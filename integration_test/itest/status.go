@@ -16,11 +16,13 @@ type StatusResponse struct {
 	UserDaemon          *cmd.UserDaemonStatus          `json:"user_daemon,omitempty"`
 	TrafficManager      *cmd.TrafficManagerStatus      `json:"traffic_manager,omitempty"`
 	ContainerizedDaemon *cmd.ContainerizedDaemonStatus `json:"daemon,omitempty"`
+	ConnectionQuality   *cmd.ConnectionQuality         `json:"connection_quality,omitempty"`
 	Connections         []struct {
 		RootDaemon          *cmd.RootDaemonStatus          `json:"root_daemon,omitempty"`
 		UserDaemon          *cmd.UserDaemonStatus          `json:"user_daemon,omitempty"`
 		TrafficManager      *cmd.TrafficManagerStatus      `json:"traffic_manager,omitempty"`
 		ContainerizedDaemon *cmd.ContainerizedDaemonStatus `json:"daemon,omitempty"`
+		ConnectionQuality   *cmd.ConnectionQuality         `json:"connection_quality,omitempty"`
 	} `json:"connections,omitempty"`
 	Error string `json:"err,omitempty"`
 }
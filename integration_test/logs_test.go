@@ -0,0 +1,26 @@
+package integration_test
+
+import (
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/integration_test/itest"
+)
+
+func (s *multipleInterceptsSuite) TestLogs_ManagerOnly() {
+	stdout := itest.TelepresenceOk(s.Context(), "logs", "traffic-manager", "--since", "1h")
+	s.Contains(stdout, "[traffic-manager-")
+	s.NotContains(stdout, "["+s.Name()+"-")
+}
+
+func (s *multipleInterceptsSuite) TestLogs_OneAgentOnly() {
+	stdout := itest.TelepresenceOk(s.Context(), "logs", s.Name()+"-1")
+	s.Contains(stdout, "["+s.Name()+"-1-")
+	s.NotContains(stdout, "[traffic-manager-")
+	s.NotContains(stdout, "["+s.Name()+"-0-")
+}
+
+func (s *multipleInterceptsSuite) TestLogs_UnknownWorkload() {
+	_, stderr, err := itest.Telepresence(s.Context(), "logs", "no-such-workload")
+	s.Error(err)
+	s.True(strings.Contains(stderr, "not found"), stderr)
+}
@@ -0,0 +1,101 @@
+package agentconfig
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// Capability is a feature that a traffic-agent may or may not support. The injected agent container is
+// adjusted to the capabilities of the agent image.
+type Capability string
+
+const (
+	// CapabilityVarRunSecretsMounts means that the agent accepts app container volume mounts under
+	// /var/run/secrets/. Older agents fail when such mounts are present.
+	CapabilityVarRunSecretsMounts Capability = "var-run-secrets-mounts"
+
+	// CapabilityHealthServer means that the agent runs a health server that can answer gRPC and HTTP
+	// probes.
+	CapabilityHealthServer Capability = "health-server"
+)
+
+// ParseCapability returns the Capability with the given name, or an error if no such capability is known.
+func ParseCapability(s string) (Capability, error) {
+	c := Capability(s)
+	if _, ok := capabilityTable[c]; !ok {
+		return "", fmt.Errorf("unknown agent capability %q", s)
+	}
+	return c, nil
+}
+
+// Capabilities is a set of capabilities supported by a traffic-agent.
+type Capabilities []Capability
+
+// Has returns true if the given capability is in the set.
+func (cs Capabilities) Has(c Capability) bool {
+	return slices.Contains(cs, c)
+}
+
+// capabilityTable maps each known capability to the first version, per major version, of the agent that
+// has it. Agents with a major version that is greater than all majors listed for a capability have it,
+// agents with a lesser major version don't.
+var capabilityTable = map[Capability][]semver.Version{ //nolint:gochecknoglobals // constant
+	CapabilityVarRunSecretsMounts: {
+		{Major: 1, Minor: 13, Patch: 14}, // Smart agent
+		{Major: 2, Minor: 13, Patch: 3},  // OSS agent
+	},
+	CapabilityHealthServer: {
+		{Major: 2, Minor: 19, Patch: 1},
+	},
+}
+
+// hasCapability checks the capabilityTable to determine if the given agent version has the given capability.
+func hasCapability(av semver.Version, c Capability) bool {
+	maxMajor := uint64(0)
+	for _, since := range capabilityTable[c] {
+		if since.Major == av.Major {
+			return av.GTE(since)
+		}
+		if since.Major > maxMajor {
+			maxMajor = since.Major
+		}
+	}
+	return av.Major > maxMajor
+}
+
+// VersionCapabilities returns the capabilities of the agent with the given version. A zero version means
+// that the version couldn't be determined, which is typical for development builds, so such an agent is
+// assumed to have all capabilities.
+func VersionCapabilities(av semver.Version) Capabilities {
+	cs := make(Capabilities, 0, len(capabilityTable))
+	for c := range capabilityTable {
+		if av.Major == 0 || hasCapability(av, c) {
+			cs = append(cs, c)
+		}
+	}
+	slices.Sort(cs)
+	return cs
+}
+
+// AgentCapabilities returns the capabilities of the agent described by the given config. Capabilities that
+// are declared explicitly in the config take precedence. Otherwise, the capabilities are derived from the
+// version in the tag of the agent image.
+func AgentCapabilities(ctx context.Context, config *Sidecar) Capabilities {
+	if len(config.Capabilities) > 0 {
+		return config.Capabilities
+	}
+	var av semver.Version
+	if sep := strings.LastIndexByte(config.AgentImage, ':'); sep > 0 {
+		var err error
+		if av, err = semver.Parse(config.AgentImage[sep+1:]); err != nil {
+			dlog.Errorf(ctx, "unable to parse agent version from image name %s", config.AgentImage)
+		}
+	}
+	return VersionCapabilities(av)
+}
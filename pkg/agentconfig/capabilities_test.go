@@ -0,0 +1,58 @@
+package agentconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCapabilities(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Capabilities
+	}{
+		{"0.0.0", Capabilities{CapabilityHealthServer, CapabilityVarRunSecretsMounts}},
+		{"1.13.13", Capabilities{}},
+		{"1.13.14", Capabilities{CapabilityVarRunSecretsMounts}},
+		{"2.13.2", Capabilities{}},
+		{"2.13.3", Capabilities{CapabilityVarRunSecretsMounts}},
+		{"2.19.0", Capabilities{CapabilityVarRunSecretsMounts}},
+		{"2.19.1", Capabilities{CapabilityHealthServer, CapabilityVarRunSecretsMounts}},
+		{"3.0.0", Capabilities{CapabilityHealthServer, CapabilityVarRunSecretsMounts}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.want, VersionCapabilities(semver.MustParse(tt.version)))
+		})
+	}
+}
+
+func TestAgentCapabilities(t *testing.T) {
+	ctx := context.Background()
+	caps := AgentCapabilities(ctx, &Sidecar{AgentImage: "docker.io/datawire/tel2:2.13.2"})
+	assert.False(t, caps.Has(CapabilityVarRunSecretsMounts))
+
+	// Capabilities that are declared in the config take precedence over the image version.
+	caps = AgentCapabilities(ctx, &Sidecar{
+		AgentImage:   "docker.io/datawire/tel2:2.13.2",
+		Capabilities: Capabilities{CapabilityVarRunSecretsMounts},
+	})
+	assert.True(t, caps.Has(CapabilityVarRunSecretsMounts))
+	assert.False(t, caps.Has(CapabilityHealthServer))
+
+	// An image without a semver tag is assumed to be a development build that has all capabilities.
+	caps = AgentCapabilities(ctx, &Sidecar{AgentImage: "localhost:5000/tel2"})
+	assert.True(t, caps.Has(CapabilityHealthServer))
+}
+
+func TestParseCapability(t *testing.T) {
+	c, err := ParseCapability("health-server")
+	require.NoError(t, err)
+	assert.Equal(t, CapabilityHealthServer, c)
+
+	_, err = ParseCapability("teleport")
+	assert.ErrorContains(t, err, "unknown agent capability")
+}
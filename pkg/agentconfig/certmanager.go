@@ -0,0 +1,143 @@
+package agentconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+const (
+	// TerminatingTLSCertificateAnnotation references a cert-manager Certificate. The secret of that Certificate
+	// is used as if it had been declared using the TerminatingTLSSecretAnnotation.
+	TerminatingTLSCertificateAnnotation = DomainPrefix + "inject-terminating-tls-certificate"
+
+	// OriginatingTLSCertificateAnnotation references a cert-manager Certificate. The secret of that Certificate
+	// is used as if it had been declared using the OriginatingTLSSecretAnnotation.
+	OriginatingTLSCertificateAnnotation = DomainPrefix + "inject-originating-tls-certificate"
+
+	// TLSCertificateWaitAnnotation declares for how long the injection will wait for referenced Certificates
+	// to become ready, e.g. "10s". The secret is used without waiting when this annotation is absent.
+	TLSCertificateWaitAnnotation = DomainPrefix + "inject-tls-certificate-wait"
+)
+
+// certificateResource is the cert-manager Certificate resource.
+var certificateResource = schema.GroupVersionResource{ //nolint:gochecknoglobals // constant
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+
+type dynamicInterfaceKey struct{}
+
+// WithDynamicInterface returns a context that holds the given dynamic.Interface. The interface is used when
+// resolving cert-manager Certificates.
+func WithDynamicInterface(ctx context.Context, di dynamic.Interface) context.Context {
+	return context.WithValue(ctx, dynamicInterfaceKey{}, di)
+}
+
+// GetDynamicInterface returns the dynamic.Interface of the given context, or nil if there is none.
+func GetDynamicInterface(ctx context.Context) dynamic.Interface {
+	if di, ok := ctx.Value(dynamicInterfaceKey{}).(dynamic.Interface); ok {
+		return di
+	}
+	return nil
+}
+
+// ResolveTLSCertificates resolves the cert-manager Certificates referenced by the TerminatingTLSCertificateAnnotation
+// and OriginatingTLSCertificateAnnotation of the given pod, and returns the TerminatingTLSSecretAnnotation and
+// OriginatingTLSSecretAnnotation that declare their secrets. A secret annotation that is already present in the pod
+// takes precedence over the corresponding certificate annotation. As with the secret annotations, the name of a
+// certificate may contain the expansion "$AGENT_NAME".
+func ResolveTLSCertificates(ctx context.Context, pod *core.Pod, agentName string) (map[string]string, error) {
+	pas := pod.ObjectMeta.Annotations
+	refs := map[string]string{
+		TerminatingTLSCertificateAnnotation: TerminatingTLSSecretAnnotation,
+		OriginatingTLSCertificateAnnotation: OriginatingTLSSecretAnnotation,
+	}
+	var wait time.Duration
+	if wa, ok := pas[TLSCertificateWaitAnnotation]; ok {
+		var err error
+		if wait, err = time.ParseDuration(wa); err != nil {
+			return nil, fmt.Errorf("unable to parse annotation %s: %w", TLSCertificateWaitAnnotation, err)
+		}
+	}
+	env := dos.MapEnv{
+		"AGENT_NAME":      agentName,
+		"_TEL_AGENT_NAME": agentName,
+	}
+	var sas map[string]string
+	for ca, sa := range refs {
+		cert, ok := pas[ca]
+		if !ok {
+			continue
+		}
+		if _, ok = pas[sa]; ok {
+			dlog.Warnf(ctx, "annotation %s is ignored because annotation %s is present", ca, sa)
+			continue
+		}
+		secret, err := certificateSecret(ctx, pod.Namespace, env.ExpandEnv(cert), wait)
+		if err != nil {
+			return nil, err
+		}
+		if sas == nil {
+			sas = make(map[string]string, len(refs))
+		}
+		sas[sa] = secret
+	}
+	return sas, nil
+}
+
+// certificateSecret returns the name of the secret of the given cert-manager Certificate. When wait is
+// greater than zero, it will wait at most that long for the Certificate to become ready.
+func certificateSecret(ctx context.Context, namespace, name string, wait time.Duration) (string, error) {
+	di := GetDynamicInterface(ctx)
+	if di == nil {
+		return "", fmt.Errorf("unable to resolve certificate %s.%s: no dynamic interface available", name, namespace)
+	}
+	ci := di.Resource(certificateResource).Namespace(namespace)
+	deadline := time.Now().Add(wait)
+	for {
+		cert, err := ci.Get(ctx, name, meta.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("unable to get certificate %s.%s: %w", name, namespace, err)
+		}
+		secret, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName")
+		if secret == "" {
+			return "", fmt.Errorf("certificate %s.%s has no secretName", name, namespace)
+		}
+		if wait <= 0 || isCertificateReady(cert) {
+			return secret, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timeout waiting for certificate %s.%s to become ready", name, namespace)
+		}
+		select {
+		case <-ctx.Done():
+			return "", errors.Join(fmt.Errorf("waiting for certificate %s.%s to become ready", name, namespace), ctx.Err())
+		case <-time.After(certificatePollInterval):
+		}
+	}
+}
+
+const certificatePollInterval = 500 * time.Millisecond
+
+// isCertificateReady returns true if the given Certificate has a Ready condition with status True.
+func isCertificateReady(cert *unstructured.Unstructured) bool {
+	conds, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	for _, c := range conds {
+		if cm, ok := c.(map[string]any); ok && cm["type"] == "Ready" {
+			return cm["status"] == string(meta.ConditionTrue)
+		}
+	}
+	return false
+}
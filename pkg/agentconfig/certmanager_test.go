@@ -0,0 +1,128 @@
+package agentconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func certificate(name, secretName string, ready bool) *unstructured.Unstructured {
+	status := "False"
+	if ready {
+		status = "True"
+	}
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": "default",
+		},
+		"spec": map[string]any{
+			"secretName": secretName,
+		},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": status},
+			},
+		},
+	}}
+}
+
+func certContext(t *testing.T, objs ...runtime.Object) context.Context {
+	scheme := runtime.NewScheme()
+	gvrToListKind := map[schema.GroupVersionResource]string{certificateResource: "CertificateList"}
+	di := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, gvrToListKind, objs...)
+	return WithDynamicInterface(dlog.NewTestContext(t, false), di)
+}
+
+func TestResolveTLSCertificates(t *testing.T) {
+	pod := func(annotations map[string]string) *core.Pod {
+		return &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default", Annotations: annotations}}
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]string
+		wantErr     string
+	}{
+		{
+			name: "no certificates",
+		},
+		{
+			name: "terminating and originating",
+			annotations: map[string]string{
+				TerminatingTLSCertificateAnnotation: "echo-server",
+				OriginatingTLSCertificateAnnotation: "$AGENT_NAME-client",
+			},
+			want: map[string]string{
+				TerminatingTLSSecretAnnotation: "echo-server-tls",
+				OriginatingTLSSecretAnnotation: "echo-client-tls",
+			},
+		},
+		{
+			name: "secret annotation takes precedence",
+			annotations: map[string]string{
+				TerminatingTLSCertificateAnnotation: "echo-server",
+				TerminatingTLSSecretAnnotation:      "my-secret",
+			},
+		},
+		{
+			name:        "missing certificate",
+			annotations: map[string]string{TerminatingTLSCertificateAnnotation: "other"},
+			wantErr:     "unable to get certificate other.default",
+		},
+		{
+			name: "wait for ready certificate",
+			annotations: map[string]string{
+				TerminatingTLSCertificateAnnotation: "echo-server",
+				TLSCertificateWaitAnnotation:        "5s",
+			},
+			want: map[string]string{TerminatingTLSSecretAnnotation: "echo-server-tls"},
+		},
+		{
+			name: "wait for pending certificate",
+			annotations: map[string]string{
+				TerminatingTLSCertificateAnnotation: "pending",
+				TLSCertificateWaitAnnotation:        "10ms",
+			},
+			wantErr: "timeout waiting for certificate pending.default",
+		},
+		{
+			name: "invalid wait",
+			annotations: map[string]string{
+				TerminatingTLSCertificateAnnotation: "echo-server",
+				TLSCertificateWaitAnnotation:        "forever",
+			},
+			wantErr: "unable to parse annotation " + TLSCertificateWaitAnnotation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := certContext(t,
+				certificate("echo-server", "echo-server-tls", true),
+				certificate("echo-client", "echo-client-tls", true),
+				certificate("pending", "pending-tls", false))
+			start := time.Now()
+			sas, err := ResolveTLSCertificates(ctx, pod(tt.annotations), "echo")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sas)
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
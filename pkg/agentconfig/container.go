@@ -3,11 +3,14 @@ package agentconfig
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
-	"github.com/blang/semver/v4"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
@@ -32,6 +35,13 @@ func AgentContainer(
 	if len(ports) == 0 {
 		return nil
 	}
+	if config.MetricsPort != 0 {
+		ports = append(ports, core.ContainerPort{
+			Name:          MetricsPortName,
+			ContainerPort: int32(config.MetricsPort),
+			Protocol:      core.ProtocolTCP,
+		})
+	}
 
 	evs := make([]core.EnvVar, 0, len(config.Containers)*5)
 	efs := make([]core.EnvFromSource, 0, len(config.Containers)*3)
@@ -63,19 +73,40 @@ func AgentContainer(
 					FieldPath:  "metadata.name",
 				},
 			},
+		},
+		core.EnvVar{
+			Name: EnvPrefixAgent + "NAMESPACE",
+			ValueFrom: &core.EnvVarSource{
+				FieldRef: &core.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.namespace",
+				},
+			},
+		},
+		core.EnvVar{
+			Name: EnvPrefixAgent + "POD_UID",
+			ValueFrom: &core.EnvVarSource{
+				FieldRef: &core.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.uid",
+				},
+			},
+		},
+		core.EnvVar{
+			Name: EnvPrefixAgent + "NODE_NAME",
+			ValueFrom: &core.EnvVarSource{
+				FieldRef: &core.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "spec.nodeName",
+				},
+			},
 		})
 
 	mounts := make([]core.VolumeMount, 0, len(config.Containers)*3)
-	var agentVersion semver.Version
-	if sep := strings.LastIndexByte(config.AgentImage, ':'); sep > 0 {
-		var err error
-		if agentVersion, err = semver.Parse(config.AgentImage[sep+1:]); err != nil {
-			dlog.Errorf(ctx, "unable to parse agent version from image name %s", config.AgentImage)
-		}
-	}
+	caps := AgentCapabilities(ctx, config)
 	EachContainer(pod, config, func(app *core.Container, cc *Container) {
 		var volPaths []string
-		volPaths, mounts = appendAppContainerVolumeMounts(app, cc, mounts, pod.ObjectMeta.Annotations, agentVersion)
+		volPaths, mounts = appendAppContainerVolumeMounts(app, cc, mounts, pod.ObjectMeta.Annotations, caps)
 		if len(volPaths) > 0 {
 			evs = append(evs, core.EnvVar{
 				Name:  cc.EnvPrefix + EnvInterceptMounts,
@@ -114,6 +145,13 @@ func AgentContainer(
 			MountPath: OriginatingTLSMountPoint,
 		})
 	}
+	if config.ServiceAccountToken != nil {
+		mounts = append(mounts, core.VolumeMount{
+			Name:      TokenVolumeName,
+			MountPath: TokenMountPoint,
+			ReadOnly:  true,
+		})
+	}
 	if _, ok := pod.ObjectMeta.Annotations[TerminatingTLSSecretAnnotation]; ok {
 		mounts = append(mounts, core.VolumeMount{
 			Name:      TerminatingTLSVolumeName,
@@ -133,25 +171,25 @@ func AgentContainer(
 	}
 
 	ac := &core.Container{
-		Name:         ContainerName,
-		Image:        config.AgentImage,
-		Args:         []string{"agent"},
-		Ports:        ports,
-		Env:          evs,
-		EnvFrom:      efs,
-		VolumeMounts: mounts,
-		ReadinessProbe: &core.Probe{
-			ProbeHandler: core.ProbeHandler{
-				Exec: &core.ExecAction{
-					Command: []string{"/bin/stat", "/tmp/agent/ready"},
-				},
-			},
-		},
+		Name:            ContainerName,
+		Image:           config.AgentImage,
+		Args:            []string{"agent"},
+		Ports:           ports,
+		Env:             evs,
+		EnvFrom:         efs,
+		VolumeMounts:    mounts,
+		ReadinessProbe:  readinessProbe(config, caps),
+		LivenessProbe:   livenessProbe(config, caps),
+		StartupProbe:    startupProbe(config, caps),
 		ImagePullPolicy: core.PullPolicy(config.PullPolicy),
 	}
 	if r := config.Resources; r != nil {
 		ac.Resources = *r
 	}
+	if UseNativeSidecar(pod, config) {
+		always := core.ContainerRestartPolicyAlways
+		ac.RestartPolicy = &always
+	}
 
 	appSc := config.SecurityContext
 	if appSc == nil {
@@ -168,6 +206,86 @@ func AgentContainer(
 	return ac
 }
 
+// UseNativeSidecar returns true if the traffic-agent should be injected into the given pod as a native
+// sidecar. The pod's NativeSidecarAnnotation, when present, takes precedence over the config.
+func UseNativeSidecar(pod *core.Pod, config *Sidecar) bool {
+	if a, ok := pod.ObjectMeta.Annotations[NativeSidecarAnnotation]; ok {
+		if use, err := strconv.ParseBool(a); err == nil {
+			return use
+		}
+	}
+	return config.NativeSidecar
+}
+
+// IsNativeSidecar returns true if the given container is a native sidecar, i.e. an init-container
+// that is restarted for as long as the pod is running.
+func IsNativeSidecar(cn *core.Container) bool {
+	return cn.RestartPolicy != nil && *cn.RestartPolicy == core.ContainerRestartPolicyAlways
+}
+
+// readinessProbe returns a probe that uses the agent's health server, or an exec probe when no
+// health port is configured or when the agent is too old to have a health server.
+func readinessProbe(config *Sidecar, caps Capabilities) *core.Probe {
+	var ps *ProbeSettings
+	if config.Probes != nil {
+		ps = config.Probes.Readiness
+	}
+	return agentProbe(config, caps, ps)
+}
+
+// livenessProbe returns the liveness probe of the agent, or nil if no such probe is configured.
+func livenessProbe(config *Sidecar, caps Capabilities) *core.Probe {
+	if config.Probes == nil || config.Probes.Liveness == nil {
+		return nil
+	}
+	return agentProbe(config, caps, config.Probes.Liveness)
+}
+
+// startupProbe returns the startup probe of the agent, or nil if no such probe is configured.
+func startupProbe(config *Sidecar, caps Capabilities) *core.Probe {
+	if config.Probes == nil || config.Probes.Startup == nil {
+		return nil
+	}
+	return agentProbe(config, caps, config.Probes.Startup)
+}
+
+// agentProbe returns a probe with the given settings and a handler that is determined by the config's
+// health port and probe type.
+func agentProbe(config *Sidecar, caps Capabilities, ps *ProbeSettings) *core.Probe {
+	p := &core.Probe{ProbeHandler: probeHandler(config, caps)}
+	if ps != nil {
+		p.InitialDelaySeconds = ps.InitialDelaySeconds
+		p.TimeoutSeconds = ps.TimeoutSeconds
+		p.PeriodSeconds = ps.PeriodSeconds
+		p.SuccessThreshold = ps.SuccessThreshold
+		p.FailureThreshold = ps.FailureThreshold
+	}
+	return p
+}
+
+func probeHandler(config *Sidecar, caps Capabilities) core.ProbeHandler {
+	if config.HealthPort == 0 || config.HealthProbe == HealthProbeExec || !caps.Has(CapabilityHealthServer) {
+		return core.ProbeHandler{
+			Exec: &core.ExecAction{
+				Command: []string{"/bin/stat", "/tmp/agent/ready"},
+			},
+		}
+	}
+	if config.HealthProbe == HealthProbeHTTP {
+		return core.ProbeHandler{
+			HTTPGet: &core.HTTPGetAction{
+				Path: HealthPath,
+				Port: intstr.FromInt32(int32(config.HealthPort)),
+			},
+		}
+	}
+	return core.ProbeHandler{
+		GRPC: &core.GRPCAction{
+			Port: int32(config.HealthPort),
+		},
+	}
+}
+
 // Find security context of the first container (with both intercepts and a set security context) and ensure
 // that any env interpolations in it are prefixed with the env-prefix of the corresponding config container.
 func firstAppSecurityContext(pod *core.Pod, config *Sidecar) (*core.SecurityContext, error) {
@@ -213,6 +331,16 @@ func InitContainer(config *Sidecar) *core.Container {
 					},
 				},
 			},
+			{
+				// Comma separated list of the pod's IPs. Contains one IP per IP family in a dual-stack cluster.
+				Name: "POD_IPS",
+				ValueFrom: &core.EnvVarSource{
+					FieldRef: &core.ObjectFieldSelector{
+						APIVersion: "v1",
+						FieldPath:  "status.podIPs",
+					},
+				},
+			},
 		},
 		VolumeMounts: []core.VolumeMount{{
 			Name:      ConfigVolumeName,
@@ -230,7 +358,10 @@ func InitContainer(config *Sidecar) *core.Container {
 	return ic
 }
 
-func AgentVolumes(agentName string, pod *core.Pod) []core.Volume {
+// AgentVolumes returns the volumes that the traffic-agent needs in the given pod. The emptyDir volumes are
+// configured using the given VolumeSettings, which may be nil. A projected token volume is included when
+// the given ServiceAccountToken is non-nil.
+func AgentVolumes(agentName string, pod *core.Pod, vs *VolumeSettings, sat *ServiceAccountToken) []core.Volume {
 	var items []core.KeyToPath
 	if agentName != "" {
 		items = []core.KeyToPath{{
@@ -267,13 +398,13 @@ func AgentVolumes(agentName string, pod *core.Pod) []core.Volume {
 		{
 			Name: ExportsVolumeName,
 			VolumeSource: core.VolumeSource{
-				EmptyDir: &core.EmptyDirVolumeSource{},
+				EmptyDir: vs.exportEmptyDir(),
 			},
 		},
 		{
 			Name: TempVolumeName,
 			VolumeSource: core.VolumeSource{
-				EmptyDir: &core.EmptyDirVolumeSource{},
+				EmptyDir: vs.tmpEmptyDir(),
 			},
 		},
 	}
@@ -285,6 +416,9 @@ func AgentVolumes(agentName string, pod *core.Pod) []core.Volume {
 		"AGENT_NAME":      agentName,
 		"_TEL_AGENT_NAME": agentName,
 	}
+	if sat != nil {
+		volumes = append(volumes, tokenVolume(sat))
+	}
 	vCount := len(volumes)
 	volumes = appendSecretVolume(env, TerminatingTLSSecretAnnotation, TerminatingTLSVolumeName, pod, volumes)
 	volumes = appendSecretVolume(env, OriginatingTLSSecretAnnotation, OriginatingTLSVolumeName, pod, volumes)
@@ -297,6 +431,28 @@ func AgentVolumes(agentName string, pod *core.Pod) []core.Volume {
 	return volumes
 }
 
+func tokenVolume(sat *ServiceAccountToken) core.Volume {
+	var exp *int64
+	if sat.ExpirationSeconds > 0 {
+		es := sat.ExpirationSeconds
+		exp = &es
+	}
+	return core.Volume{
+		Name: TokenVolumeName,
+		VolumeSource: core.VolumeSource{
+			Projected: &core.ProjectedVolumeSource{
+				Sources: []core.VolumeProjection{{
+					ServiceAccountToken: &core.ServiceAccountTokenProjection{
+						Audience:          sat.Audience,
+						ExpirationSeconds: exp,
+						Path:              TokenFile,
+					},
+				}},
+			},
+		},
+	}
+}
+
 func appendSecretVolume(env dos.Env, annotation, volumeName string, pod *core.Pod, volumes []core.Volume) []core.Volume {
 	if secret, ok := pod.ObjectMeta.Annotations[annotation]; ok {
 		volumes = append(volumes, core.Volume{
@@ -330,20 +486,12 @@ func appendAppContainerVolumeMounts(
 	cc *Container,
 	mounts []core.VolumeMount,
 	annotations map[string]string,
-	av semver.Version,
+	caps Capabilities,
 ) ([]string, []core.VolumeMount) {
 	ignoredVolumeMounts := GetIgnoredVolumeMounts(annotations)
 
 	// Older agents will error if we include /var/run/secrets/ volumes here, so we don't.
-	stripVarRunSecret := false
-	if av.Major == 1 && (av.Minor < 13 || av.Minor == 13 && av.Patch <= 13) {
-		// Smart agent <=1.13.13
-		stripVarRunSecret = true
-	}
-	if av.Major == 2 && (av.Minor < 13 || av.Minor == 13 && av.Patch <= 2) {
-		// OSS agent <=2.13.2
-		stripVarRunSecret = true
-	}
+	stripVarRunSecret := !caps.Has(CapabilityVarRunSecretsMounts)
 
 	volPaths := make([]string, 0, len(app.VolumeMounts))
 	pfx := EnvPrefixApp + cc.EnvPrefix
@@ -473,3 +621,67 @@ func GetIgnoredVolumeMounts(annotations map[string]string) IgnoredVolumeMounts {
 	}
 	return nil
 }
+
+// IgnoredContainers is a list of names of containers that the traffic-agent must never intercept.
+type IgnoredContainers []string
+
+func (ic IgnoredContainers) IsContainerIgnored(name string) bool {
+	return slices.Contains(ic, name)
+}
+
+// GetIgnoredContainers returns the container names declared by the InjectIgnoreContainers annotation in
+// the given annotations. The annotation value is a comma separated list of container names.
+func GetIgnoredContainers(annotations map[string]string) IgnoredContainers {
+	if ca, ok := annotations[InjectIgnoreContainers]; ok {
+		cSlice := strings.Split(ca, ",")
+		cs := make(IgnoredContainers, 0, len(cSlice))
+		for _, c := range cSlice {
+			if c = strings.TrimSpace(c); c != "" {
+				cs = append(cs, c)
+			}
+		}
+		return cs
+	}
+	return nil
+}
+
+// GetPullSecrets returns the image pull secrets declared by the PullSecretsAnnotation in the given annotations.
+// The annotation value is a comma separated list of secret names.
+func GetPullSecrets(annotations map[string]string) ([]core.LocalObjectReference, error) {
+	psa, ok := annotations[PullSecretsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	psSlice := strings.Split(psa, ",")
+	pss := make([]core.LocalObjectReference, 0, len(psSlice))
+	for _, ps := range psSlice {
+		if ps = strings.TrimSpace(ps); ps != "" {
+			if errs := validation.IsDNS1123Subdomain(ps); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid secret name %q in annotation %s: %s", ps, PullSecretsAnnotation, strings.Join(errs, ", "))
+			}
+			pss = append(pss, core.LocalObjectReference{Name: ps})
+		}
+	}
+	return pss, nil
+}
+
+// MergePullSecrets returns the union of the given pull secrets, retaining the order in which they are first found.
+func MergePullSecrets(a, b []core.LocalObjectReference) []core.LocalObjectReference {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	ms := make([]core.LocalObjectReference, 0, len(a)+len(b))
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for _, pss := range [][]core.LocalObjectReference{a, b} {
+		for _, ps := range pss {
+			if _, ok := seen[ps.Name]; !ok {
+				seen[ps.Name] = struct{}{}
+				ms = append(ms, ps)
+			}
+		}
+	}
+	return ms
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func Test_prefixInterpolated(t *testing.T) {
@@ -98,3 +101,213 @@ func Test_ReplacePolicy(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, string(data), `{"replace":1}`)
 }
+
+func Test_readinessProbe(t *testing.T) {
+	v := func(s string) semver.Version {
+		return semver.MustParse(s)
+	}
+	tests := []struct {
+		name   string
+		config Sidecar
+		av     semver.Version
+		check  func(*testing.T, *core.Probe)
+	}{
+		{
+			"no health port",
+			Sidecar{},
+			v("2.19.1"),
+			func(t *testing.T, p *core.Probe) { require.NotNil(t, p.Exec) },
+		},
+		{
+			"old agent",
+			Sidecar{HealthPort: 9899},
+			v("2.19.0"),
+			func(t *testing.T, p *core.Probe) { require.NotNil(t, p.Exec) },
+		},
+		{
+			"grpc",
+			Sidecar{HealthPort: 9899},
+			v("2.19.1"),
+			func(t *testing.T, p *core.Probe) {
+				require.NotNil(t, p.GRPC)
+				assert.Equal(t, int32(9899), p.GRPC.Port)
+			},
+		},
+		{
+			"unknown version",
+			Sidecar{HealthPort: 9899, HealthProbe: HealthProbeGRPC},
+			semver.Version{},
+			func(t *testing.T, p *core.Probe) { require.NotNil(t, p.GRPC) },
+		},
+		{
+			"http",
+			Sidecar{HealthPort: 9899, HealthProbe: HealthProbeHTTP},
+			v("2.20.0"),
+			func(t *testing.T, p *core.Probe) {
+				require.NotNil(t, p.HTTPGet)
+				assert.Equal(t, HealthPath, p.HTTPGet.Path)
+				assert.Equal(t, 9899, p.HTTPGet.Port.IntValue())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, readinessProbe(&tt.config, VersionCapabilities(tt.av)))
+		})
+	}
+}
+
+func Test_UseNativeSidecar(t *testing.T) {
+	pod := func(ann string) *core.Pod {
+		p := &core.Pod{}
+		if ann != "" {
+			p.Annotations = map[string]string{NativeSidecarAnnotation: ann}
+		}
+		return p
+	}
+	tests := []struct {
+		name   string
+		pod    *core.Pod
+		config Sidecar
+		want   bool
+	}{
+		{"default", pod(""), Sidecar{}, false},
+		{"config", pod(""), Sidecar{NativeSidecar: true}, true},
+		{"annotation enables", pod("true"), Sidecar{}, true},
+		{"annotation disables", pod("false"), Sidecar{NativeSidecar: true}, false},
+		{"invalid annotation", pod("maybe"), Sidecar{NativeSidecar: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UseNativeSidecar(tt.pod, &tt.config))
+		})
+	}
+}
+
+func Test_agentProbes(t *testing.T) {
+	config := Sidecar{
+		HealthPort: 9899,
+		Probes: &Probes{
+			Readiness: &ProbeSettings{PeriodSeconds: 5, FailureThreshold: 6},
+			Startup:   &ProbeSettings{FailureThreshold: 30},
+		},
+	}
+	caps := VersionCapabilities(semver.MustParse("2.20.0"))
+	rp := readinessProbe(&config, caps)
+	require.NotNil(t, rp.GRPC)
+	assert.Equal(t, int32(5), rp.PeriodSeconds)
+	assert.Equal(t, int32(6), rp.FailureThreshold)
+
+	sp := startupProbe(&config, caps)
+	require.NotNil(t, sp)
+	require.NotNil(t, sp.GRPC)
+	assert.Equal(t, int32(30), sp.FailureThreshold)
+
+	assert.Nil(t, livenessProbe(&config, caps))
+
+	config.HealthProbe = HealthProbeExec
+	require.NotNil(t, readinessProbe(&config, caps).Exec)
+}
+
+func TestGetIgnoredContainers(t *testing.T) {
+	assert.Nil(t, GetIgnoredContainers(nil))
+
+	ics := GetIgnoredContainers(map[string]string{InjectIgnoreContainers: "metrics, istio-proxy,"})
+	assert.Equal(t, IgnoredContainers{"metrics", "istio-proxy"}, ics)
+	assert.True(t, ics.IsContainerIgnored("istio-proxy"))
+	assert.False(t, ics.IsContainerIgnored("app"))
+}
+
+func TestGetPullSecrets(t *testing.T) {
+	pss, err := GetPullSecrets(nil)
+	require.NoError(t, err)
+	assert.Nil(t, pss)
+
+	pss, err = GetPullSecrets(map[string]string{PullSecretsAnnotation: "regcred, other-cred,"})
+	require.NoError(t, err)
+	assert.Equal(t, []core.LocalObjectReference{{Name: "regcred"}, {Name: "other-cred"}}, pss)
+
+	_, err = GetPullSecrets(map[string]string{PullSecretsAnnotation: "Not_Valid"})
+	assert.ErrorContains(t, err, "invalid secret name")
+}
+
+func TestMergePullSecrets(t *testing.T) {
+	a := []core.LocalObjectReference{{Name: "a"}, {Name: "b"}}
+	b := []core.LocalObjectReference{{Name: "b"}, {Name: "c"}}
+	assert.Equal(t, []core.LocalObjectReference{{Name: "a"}, {Name: "b"}, {Name: "c"}}, MergePullSecrets(a, b))
+	assert.Equal(t, a, MergePullSecrets(a, nil))
+	assert.Equal(t, b, MergePullSecrets(nil, b))
+}
+
+func TestAgentVolumes_emptyDirSettings(t *testing.T) {
+	emptyDirs := func(vols []core.Volume) map[string]*core.EmptyDirVolumeSource {
+		m := make(map[string]*core.EmptyDirVolumeSource)
+		for _, v := range vols {
+			if v.EmptyDir != nil {
+				m[v.Name] = v.EmptyDir
+			}
+		}
+		return m
+	}
+	pod := &core.Pod{}
+
+	eds := emptyDirs(AgentVolumes("echo", pod, nil, nil))
+	assert.Equal(t, &core.EmptyDirVolumeSource{}, eds[ExportsVolumeName])
+	assert.Equal(t, &core.EmptyDirVolumeSource{}, eds[TempVolumeName])
+
+	limit := resource.MustParse("64Mi")
+	vs := &VolumeSettings{
+		Tmp: &core.EmptyDirVolumeSource{Medium: core.StorageMediumMemory, SizeLimit: &limit},
+	}
+	eds = emptyDirs(AgentVolumes("echo", pod, vs, nil))
+	assert.Equal(t, &core.EmptyDirVolumeSource{}, eds[ExportsVolumeName])
+	assert.Equal(t, vs.Tmp, eds[TempVolumeName])
+	assert.NotSame(t, vs.Tmp, eds[TempVolumeName])
+}
+
+func TestAgentVolumes_serviceAccountToken(t *testing.T) {
+	pod := &core.Pod{}
+	for _, v := range AgentVolumes("echo", pod, nil, nil) {
+		assert.NotEqual(t, TokenVolumeName, v.Name)
+	}
+
+	var tv *core.Volume
+	vols := AgentVolumes("echo", pod, nil, &ServiceAccountToken{Audience: "traffic-manager", ExpirationSeconds: 3600})
+	for i := range vols {
+		if vols[i].Name == TokenVolumeName {
+			tv = &vols[i]
+		}
+	}
+	require.NotNil(t, tv)
+	require.NotNil(t, tv.Projected)
+	require.Len(t, tv.Projected.Sources, 1)
+	sat := tv.Projected.Sources[0].ServiceAccountToken
+	require.NotNil(t, sat)
+	assert.Equal(t, "traffic-manager", sat.Audience)
+	assert.Equal(t, TokenFile, sat.Path)
+	require.NotNil(t, sat.ExpirationSeconds)
+	assert.Equal(t, int64(3600), *sat.ExpirationSeconds)
+
+	vols = AgentVolumes("echo", pod, nil, &ServiceAccountToken{Audience: "traffic-manager"})
+	assert.Nil(t, vols[len(vols)-1].Projected.Sources[0].ServiceAccountToken.ExpirationSeconds)
+}
+
+func Test_appendAppContainerEnvFrom(t *testing.T) {
+	app := &core.Container{EnvFrom: []core.EnvFromSource{
+		{ConfigMapRef: &core.ConfigMapEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "cfg"}}},
+		{SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "db"}}},
+		{Prefix: "API_", SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "api"}}},
+	}}
+	efs := appendAppContainerEnvFrom(app, &Container{EnvPrefix: "A_"}, nil)
+	var prefixes []string
+	for _, ef := range efs {
+		prefixes = append(prefixes, ef.Prefix)
+	}
+	assert.Equal(t, []string{
+		EnvPrefixApp + "A_",
+		EnvPrefixApp + "A_",
+		EnvPrefixApp + "A_API_",
+	}, prefixes, "each source is added once")
+	assert.Equal(t, "db", efs[1].SecretRef.Name)
+	assert.Empty(t, app.EnvFrom[1].Prefix, "the app container is unchanged")
+}
@@ -0,0 +1,95 @@
+package agentconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+)
+
+// HostNetworkPortsAnnotation declares a range of host ports, e.g. "21000-21009", that the traffic-agent may
+// listen to when it is injected into a pod that uses the host network. Such a pod shares the network namespace
+// of its node, so the agent's default ports might conflict with other ports on the node.
+const HostNetworkPortsAnnotation = DomainPrefix + "inject-host-network-ports"
+
+// PortRange is an inclusive range of port numbers.
+type PortRange struct {
+	First uint16
+	Last  uint16
+}
+
+// ParsePortRange parses a port range in the form "<first>-<last>", or a single port number.
+func ParsePortRange(s string) (*PortRange, error) {
+	first, last, found := strings.Cut(strings.TrimSpace(s), "-")
+	f, err := strconv.ParseUint(strings.TrimSpace(first), 10, 16)
+	if err != nil || f == 0 {
+		return nil, fmt.Errorf("invalid port range %q", s)
+	}
+	l := f
+	if found {
+		if l, err = strconv.ParseUint(strings.TrimSpace(last), 10, 16); err != nil || l < f {
+			return nil, fmt.Errorf("invalid port range %q", s)
+		}
+	}
+	return &PortRange{First: uint16(f), Last: uint16(l)}, nil
+}
+
+// Size returns the number of ports in the range.
+func (r *PortRange) Size() int {
+	return int(r.Last) - int(r.First) + 1
+}
+
+// Contains returns true if the given port is in the range.
+func (r *PortRange) Contains(port uint16) bool {
+	return port >= r.First && port <= r.Last
+}
+
+func (r *PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// MarshalText makes a PortRange marshal into its string form.
+func (r *PortRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses the string form of a PortRange.
+func (r *PortRange) UnmarshalText(data []byte) error {
+	pr, err := ParsePortRange(string(data))
+	if err != nil {
+		return err
+	}
+	*r = *pr
+	return nil
+}
+
+// HostNetworkPorts returns the range of host ports that the traffic-agent may use in the given pod, or
+// nil if the pod doesn't use the host network. An error is returned when the pod uses the host network
+// and doesn't declare a valid range using the HostNetworkPortsAnnotation, because the agent cannot be
+// injected safely into such a pod. The error is suitable as a reason for why the workload cannot be
+// intercepted.
+func HostNetworkPorts(pod *core.PodTemplateSpec) (*PortRange, error) {
+	if !pod.Spec.HostNetwork {
+		return nil, nil
+	}
+	rs, ok := pod.Annotations[HostNetworkPortsAnnotation]
+	if !ok {
+		return nil, fmt.Errorf(
+			"the pod uses hostNetwork, so the ports of the %s would conflict with ports on the node; use the annotation %s to declare a range of free host ports",
+			ContainerName, HostNetworkPortsAnnotation)
+	}
+	r, err := ParsePortRange(rs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse annotation %s: %w", HostNetworkPortsAnnotation, err)
+	}
+	for _, cn := range pod.Spec.Containers {
+		for _, p := range cn.Ports {
+			if r.Contains(uint16(p.ContainerPort)) {
+				return nil, fmt.Errorf("the host network port range %s declared by annotation %s contains port %d of container %s",
+					r, HostNetworkPortsAnnotation, p.ContainerPort, cn.Name)
+			}
+		}
+	}
+	return r, nil
+}
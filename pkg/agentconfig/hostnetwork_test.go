@@ -0,0 +1,71 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePortRange(t *testing.T) {
+	r, err := ParsePortRange("21000-21009")
+	require.NoError(t, err)
+	assert.Equal(t, &PortRange{First: 21000, Last: 21009}, r)
+	assert.Equal(t, 10, r.Size())
+	assert.Equal(t, "21000-21009", r.String())
+
+	r, err = ParsePortRange(" 21000 ")
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Size())
+
+	for _, s := range []string{"", "0", "x-21000", "21000-", "21009-21000", "21000-70000"} {
+		_, err = ParsePortRange(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestHostNetworkPorts(t *testing.T) {
+	pod := func(hostNetwork bool, annotations map[string]string) *core.PodTemplateSpec {
+		return &core.PodTemplateSpec{
+			ObjectMeta: meta.ObjectMeta{Annotations: annotations},
+			Spec: core.PodSpec{
+				HostNetwork: hostNetwork,
+				Containers: []core.Container{{
+					Name:  "app",
+					Ports: []core.ContainerPort{{ContainerPort: 8080}},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name        string
+		hostNetwork bool
+		annotations map[string]string
+		want        *PortRange
+		wantErr     string
+	}{
+		{name: "pod network", annotations: map[string]string{HostNetworkPortsAnnotation: "21000-21009"}},
+		{name: "no range", hostNetwork: true, wantErr: "the pod uses hostNetwork"},
+		{name: "invalid range", hostNetwork: true, annotations: map[string]string{HostNetworkPortsAnnotation: "high"}, wantErr: "unable to parse"},
+		{name: "conflict", hostNetwork: true, annotations: map[string]string{HostNetworkPortsAnnotation: "8000-8999"}, wantErr: "contains port 8080"},
+		{
+			name:        "range",
+			hostNetwork: true,
+			annotations: map[string]string{HostNetworkPortsAnnotation: "21000-21009"},
+			want:        &PortRange{First: 21000, Last: 21009},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HostNetworkPorts(pod(tt.hostNetwork, tt.annotations))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+)
+
+// InitMode determines how traffic to container ports that cannot be renamed, i.e. ports targeted by a
+// numeric service targetPort, is routed to the traffic-agent.
+type InitMode string
+
+const (
+	// InitModeIPTables injects the tel-agent-init init-container, which sets up iptables rules that
+	// route traffic from numeric ports to the traffic-agent. The init-container requires the NET_ADMIN
+	// capability.
+	//
+	// This is the default mode.
+	InitModeIPTables InitMode = "iptables"
+
+	// InitModeProxy never injects the tel-agent-init init-container. The traffic-agent only proxies
+	// container ports that it can take over by renaming them, so service ports with a numeric
+	// targetPort cannot be intercepted. This mode works in clusters that disallow NET_ADMIN, such as
+	// GKE Autopilot or namespaces that enforce the "baseline" or "restricted" Pod Security Standards.
+	InitModeProxy InitMode = "proxy"
+)
+
+// InitModeAnnotation declares the InitMode to use. It can be set on a pod template or on a namespace.
+const InitModeAnnotation = DomainPrefix + "agent-init-mode"
+
+// PodSecurityEnforceLabel is the namespace label that declares the enforced Pod Security Standard.
+const PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// ParseInitMode returns the InitMode with the given name. An empty string yields an empty InitMode.
+func ParseInitMode(s string) (InitMode, error) {
+	switch m := InitMode(s); m {
+	case "", InitModeIPTables, InitModeProxy:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid init mode %q, must be %q or %q", s, InitModeIPTables, InitModeProxy)
+	}
+}
+
+// GetInitMode returns the InitMode declared by the InitModeAnnotation in the given annotations, or an
+// empty InitMode when no such annotation is present.
+func GetInitMode(annotations map[string]string) (InitMode, error) {
+	m, err := ParseInitMode(annotations[InitModeAnnotation])
+	if err != nil {
+		return "", fmt.Errorf("unable to parse annotation %s: %w", InitModeAnnotation, err)
+	}
+	return m, nil
+}
+
+// NamespaceInitMode returns the InitMode declared by the InitModeAnnotation of the given namespace. When no
+// such annotation is present, InitModeProxy is returned if the namespace enforces a Pod Security Standard
+// that disallows the NET_ADMIN capability. An empty InitMode is returned when the namespace has no opinion.
+func NamespaceInitMode(ns *core.Namespace) (InitMode, error) {
+	m, err := GetInitMode(ns.Annotations)
+	if err != nil || m != "" {
+		return m, err
+	}
+	switch ns.Labels[PodSecurityEnforceLabel] {
+	case "baseline", "restricted":
+		return InitModeProxy, nil
+	}
+	return "", nil
+}
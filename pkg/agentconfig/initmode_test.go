@@ -0,0 +1,54 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseInitMode(t *testing.T) {
+	for _, s := range []string{"", "iptables", "proxy"} {
+		m, err := ParseInitMode(s)
+		require.NoError(t, err)
+		assert.Equal(t, InitMode(s), m)
+	}
+	_, err := ParseInitMode("nat")
+	assert.Error(t, err)
+}
+
+func TestNamespaceInitMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		want        InitMode
+		wantErr     bool
+	}{
+		{name: "no opinion"},
+		{name: "privileged", labels: map[string]string{PodSecurityEnforceLabel: "privileged"}},
+		{name: "baseline", labels: map[string]string{PodSecurityEnforceLabel: "baseline"}, want: InitModeProxy},
+		{name: "restricted", labels: map[string]string{PodSecurityEnforceLabel: "restricted"}, want: InitModeProxy},
+		{
+			name:        "annotation",
+			annotations: map[string]string{InitModeAnnotation: "iptables"},
+			labels:      map[string]string{PodSecurityEnforceLabel: "restricted"},
+			want:        InitModeIPTables,
+		},
+		{name: "invalid annotation", annotations: map[string]string{InitModeAnnotation: "nat"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := &core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns", Annotations: tt.annotations, Labels: tt.labels}}
+			m, err := NamespaceInitMode(ns)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package agentconfig
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+)
+
+const (
+	// IstioProxyContainerName is the name of the sidecar container that Istio injects into a pod.
+	IstioProxyContainerName = "istio-proxy"
+
+	// IstioStatusAnnotation is added to a pod by Istio's injector when the istio-proxy has been injected.
+	IstioStatusAnnotation = "sidecar.istio.io/status"
+
+	// IstioInjectLabel is used on a pod to control whether Istio's injector should inject the istio-proxy.
+	IstioInjectLabel = "sidecar.istio.io/inject"
+
+	// IstioInjectionNamespaceLabel enables Istio's injector for all pods in a namespace.
+	IstioInjectionNamespaceLabel = "istio-injection"
+
+	// IstioRevisionLabel enables Istio's injector of a specific control plane revision for a pod or namespace.
+	IstioRevisionLabel = "istio.io/rev"
+
+	// IstioExcludeInboundPortsAnnotation is a comma-separated list of inbound ports that the istio-proxy
+	// must not capture.
+	IstioExcludeInboundPortsAnnotation = "traffic.sidecar.istio.io/excludeInboundPorts"
+
+	// IstioExcludeOutboundPortsAnnotation is a comma-separated list of outbound ports that the istio-proxy
+	// must not capture.
+	IstioExcludeOutboundPortsAnnotation = "traffic.sidecar.istio.io/excludeOutboundPorts"
+
+	// IstioProxyConfigAnnotation overrides the mesh-wide ProxyConfig for a pod.
+	IstioProxyConfigAnnotation = "proxy.istio.io/config"
+)
+
+// HasIstioProxy returns true if the given pod has an istio-proxy container, or if Istio's injector is
+// expected to inject one. The namespace of the pod is consulted when it is non-nil, because Istio's
+// injector might run after the traffic-agent injector, in which case the istio-proxy container isn't
+// yet present.
+func HasIstioProxy(pod *core.Pod, ns *core.Namespace) bool {
+	isProxy := func(cn core.Container) bool { return cn.Name == IstioProxyContainerName }
+	if slices.ContainsFunc(pod.Spec.Containers, isProxy) || slices.ContainsFunc(pod.Spec.InitContainers, isProxy) {
+		return true
+	}
+	if _, ok := pod.Annotations[IstioStatusAnnotation]; ok {
+		return true
+	}
+	inject, ok := pod.Labels[IstioInjectLabel]
+	if !ok {
+		inject, ok = pod.Annotations[IstioInjectLabel]
+	}
+	if ok {
+		return inject == "true"
+	}
+	if _, ok := pod.Labels[IstioRevisionLabel]; ok {
+		return true
+	}
+	if ns != nil {
+		if _, ok := ns.Labels[IstioRevisionLabel]; ok {
+			return true
+		}
+		return ns.Labels[IstioInjectionNamespaceLabel] == "enabled"
+	}
+	return false
+}
+
+// IstioAnnotations returns the annotations that make the istio-proxy of the given pod leave the traffic
+// of the traffic-agent alone. Inbound traffic to the agent's ports, and to container ports that are
+// redirected to the agent by its init-container, is excluded from capture, and so is the agent's outbound
+// traffic to the traffic-manager. The istio-proxy is also told to hold the start of the other containers
+// until it is ready, unless the pod already overrides its proxy config. The returned map contains only
+// annotations that differ from those of the pod, merged with the pod's existing values.
+func IstioAnnotations(pod *core.Pod, config *Sidecar) map[string]string {
+	am := make(map[string]string)
+	if v, changed := MergePortList(pod.Annotations[IstioExcludeInboundPortsAnnotation], meshInboundPorts(config)); changed {
+		am[IstioExcludeInboundPortsAnnotation] = v
+	}
+	if v, changed := MergePortList(pod.Annotations[IstioExcludeOutboundPortsAnnotation], []uint16{config.ManagerPort}); changed {
+		am[IstioExcludeOutboundPortsAnnotation] = v
+	}
+	if _, ok := pod.Annotations[IstioProxyConfigAnnotation]; !ok {
+		am[IstioProxyConfigAnnotation] = "holdApplicationUntilProxyStarts: true"
+	}
+	return am
+}
+
+// meshInboundPorts returns the inbound ports that a service mesh proxy must not capture, because they
+// are served by the traffic-agent.
+func meshInboundPorts(config *Sidecar) []uint16 {
+	var inbound []uint16
+	for _, cc := range config.Containers {
+		for _, ic := range cc.Intercepts {
+			inbound = append(inbound, ic.AgentPort)
+			if ic.TargetPortNumeric {
+				inbound = append(inbound, ic.ContainerPort)
+			}
+		}
+	}
+	if config.HealthPort != 0 {
+		inbound = append(inbound, config.HealthPort)
+	}
+	return inbound
+}
+
+// MergePortList adds the given ports to a comma-separated list of ports, unless they are already
+// present. The merged list is returned together with a boolean that is true if ports were added.
+func MergePortList(list string, ports []uint16) (string, bool) {
+	var ps []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			ps = append(ps, s)
+		}
+	}
+	changed := false
+	for _, p := range ports {
+		if p == 0 {
+			continue
+		}
+		if s := strconv.Itoa(int(p)); !slices.Contains(ps, s) {
+			ps = append(ps, s)
+			changed = true
+		}
+	}
+	return strings.Join(ps, ","), changed
+}
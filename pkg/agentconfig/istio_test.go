@@ -0,0 +1,79 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHasIstioProxy(t *testing.T) {
+	ns := func(labels map[string]string) *core.Namespace {
+		return &core.Namespace{ObjectMeta: meta.ObjectMeta{Labels: labels}}
+	}
+	tests := []struct {
+		name string
+		pod  core.Pod
+		ns   *core.Namespace
+		want bool
+	}{
+		{"no istio", core.Pod{}, nil, false},
+		{"container", core.Pod{Spec: core.PodSpec{Containers: []core.Container{{Name: IstioProxyContainerName}}}}, nil, true},
+		{"native sidecar", core.Pod{Spec: core.PodSpec{InitContainers: []core.Container{{Name: IstioProxyContainerName}}}}, nil, true},
+		{"status", core.Pod{ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{IstioStatusAnnotation: "{}"}}}, nil, true},
+		{"inject label", core.Pod{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{IstioInjectLabel: "true"}}}, nil, true},
+		{"revision label", core.Pod{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{IstioRevisionLabel: "canary"}}}, nil, true},
+		{"namespace", core.Pod{}, ns(map[string]string{IstioInjectionNamespaceLabel: "enabled"}), true},
+		{"namespace revision", core.Pod{}, ns(map[string]string{IstioRevisionLabel: "canary"}), true},
+		{"namespace disabled", core.Pod{}, ns(map[string]string{IstioInjectionNamespaceLabel: "disabled"}), false},
+		{
+			"pod opts out",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{IstioInjectLabel: "false"}}},
+			ns(map[string]string{IstioInjectionNamespaceLabel: "enabled"}),
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HasIstioProxy(&tt.pod, tt.ns))
+		})
+	}
+}
+
+func TestIstioAnnotations(t *testing.T) {
+	config := &Sidecar{
+		ManagerPort: 8081,
+		HealthPort:  9899,
+		Containers: []*Container{{
+			Intercepts: []*Intercept{
+				{AgentPort: 9900, ContainerPort: 8080},
+				{AgentPort: 9901, ContainerPort: 8443, TargetPortNumeric: true},
+			},
+		}},
+	}
+	pod := &core.Pod{}
+	assert.Equal(t, map[string]string{
+		IstioExcludeInboundPortsAnnotation:  "9900,9901,8443,9899",
+		IstioExcludeOutboundPortsAnnotation: "8081",
+		IstioProxyConfigAnnotation:          "holdApplicationUntilProxyStarts: true",
+	}, IstioAnnotations(pod, config))
+
+	pod.Annotations = map[string]string{
+		IstioExcludeInboundPortsAnnotation:  "22, 9900",
+		IstioExcludeOutboundPortsAnnotation: "8081",
+		IstioProxyConfigAnnotation:          "concurrency: 2",
+	}
+	assert.Equal(t, map[string]string{
+		IstioExcludeInboundPortsAnnotation: "22,9900,9901,8443,9899",
+	}, IstioAnnotations(pod, config), "existing values are retained and unchanged annotations are omitted")
+}
+
+func TestMergePortList(t *testing.T) {
+	v, changed := MergePortList("", []uint16{80, 0, 80})
+	assert.Equal(t, "80", v)
+	assert.True(t, changed)
+	v, changed = MergePortList("80, 443", []uint16{443})
+	assert.Equal(t, "80,443", v)
+	assert.False(t, changed)
+}
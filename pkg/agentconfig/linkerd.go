@@ -0,0 +1,63 @@
+package agentconfig
+
+import (
+	"slices"
+
+	core "k8s.io/api/core/v1"
+)
+
+const (
+	// LinkerdProxyContainerName is the name of the sidecar container that Linkerd injects into a pod.
+	LinkerdProxyContainerName = "linkerd-proxy"
+
+	// LinkerdProxyVersionAnnotation is added to a pod by Linkerd's injector when the linkerd-proxy has
+	// been injected.
+	LinkerdProxyVersionAnnotation = "linkerd.io/proxy-version"
+
+	// LinkerdInjectAnnotation is used on a pod or namespace to control whether Linkerd's injector should
+	// inject the linkerd-proxy.
+	LinkerdInjectAnnotation = "linkerd.io/inject"
+
+	// LinkerdSkipInboundPortsAnnotation is a comma-separated list of inbound ports that the linkerd-proxy
+	// must not capture.
+	LinkerdSkipInboundPortsAnnotation = "config.linkerd.io/skip-inbound-ports"
+
+	// LinkerdSkipOutboundPortsAnnotation is a comma-separated list of outbound ports that the linkerd-proxy
+	// must not capture.
+	LinkerdSkipOutboundPortsAnnotation = "config.linkerd.io/skip-outbound-ports"
+)
+
+// HasLinkerdProxy returns true if the given pod has a linkerd-proxy container, or if Linkerd's injector
+// is expected to inject one. The namespace of the pod is consulted when it is non-nil, because Linkerd's
+// injector might run after the traffic-agent injector, in which case the linkerd-proxy container isn't
+// yet present.
+func HasLinkerdProxy(pod *core.Pod, ns *core.Namespace) bool {
+	isProxy := func(cn core.Container) bool { return cn.Name == LinkerdProxyContainerName }
+	if slices.ContainsFunc(pod.Spec.Containers, isProxy) || slices.ContainsFunc(pod.Spec.InitContainers, isProxy) {
+		return true
+	}
+	if _, ok := pod.Annotations[LinkerdProxyVersionAnnotation]; ok {
+		return true
+	}
+	inject, ok := pod.Annotations[LinkerdInjectAnnotation]
+	if !ok && ns != nil {
+		inject = ns.Annotations[LinkerdInjectAnnotation]
+	}
+	return inject == "enabled" || inject == "ingress"
+}
+
+// LinkerdAnnotations returns the annotations that make the linkerd-proxy of the given pod skip the
+// traffic of the traffic-agent. Inbound traffic to the agent's ports, and to container ports that are
+// redirected to the agent by its init-container, is skipped, and so is the agent's outbound traffic to
+// the traffic-manager. The returned map contains only annotations that differ from those of the pod,
+// merged with the pod's existing values.
+func LinkerdAnnotations(pod *core.Pod, config *Sidecar) map[string]string {
+	am := make(map[string]string)
+	if v, changed := MergePortList(pod.Annotations[LinkerdSkipInboundPortsAnnotation], meshInboundPorts(config)); changed {
+		am[LinkerdSkipInboundPortsAnnotation] = v
+	}
+	if v, changed := MergePortList(pod.Annotations[LinkerdSkipOutboundPortsAnnotation], []uint16{config.ManagerPort}); changed {
+		am[LinkerdSkipOutboundPortsAnnotation] = v
+	}
+	return am
+}
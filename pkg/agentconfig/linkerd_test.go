@@ -0,0 +1,50 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHasLinkerdProxy(t *testing.T) {
+	annotated := func(v string) meta.ObjectMeta {
+		return meta.ObjectMeta{Annotations: map[string]string{LinkerdInjectAnnotation: v}}
+	}
+	tests := []struct {
+		name string
+		pod  core.Pod
+		ns   *core.Namespace
+		want bool
+	}{
+		{"no linkerd", core.Pod{}, nil, false},
+		{"container", core.Pod{Spec: core.PodSpec{Containers: []core.Container{{Name: LinkerdProxyContainerName}}}}, nil, true},
+		{"native sidecar", core.Pod{Spec: core.PodSpec{InitContainers: []core.Container{{Name: LinkerdProxyContainerName}}}}, nil, true},
+		{"proxy version", core.Pod{ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{LinkerdProxyVersionAnnotation: "stable-2.14"}}}, nil, true},
+		{"pod enabled", core.Pod{ObjectMeta: annotated("enabled")}, nil, true},
+		{"pod ingress", core.Pod{ObjectMeta: annotated("ingress")}, nil, true},
+		{"namespace enabled", core.Pod{}, &core.Namespace{ObjectMeta: annotated("enabled")}, true},
+		{"pod opts out", core.Pod{ObjectMeta: annotated("disabled")}, &core.Namespace{ObjectMeta: annotated("enabled")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HasLinkerdProxy(&tt.pod, tt.ns))
+		})
+	}
+}
+
+func TestLinkerdAnnotations(t *testing.T) {
+	config := &Sidecar{
+		ManagerPort: 8081,
+		Containers: []*Container{{
+			Intercepts: []*Intercept{{AgentPort: 9900, ContainerPort: 8080, TargetPortNumeric: true}},
+		}},
+	}
+	pod := &core.Pod{ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{
+		LinkerdSkipOutboundPortsAnnotation: "25,8081",
+	}}}
+	assert.Equal(t, map[string]string{
+		LinkerdSkipInboundPortsAnnotation: "9900,8080",
+	}, LinkerdAnnotations(pod, config))
+}
@@ -0,0 +1,120 @@
+package agentconfig
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	core "k8s.io/api/core/v1"
+)
+
+// PortAllocationStrategy determines how the ports that the traffic-agent listens to are chosen from a
+// PortRange.
+type PortAllocationStrategy string
+
+const (
+	// PortAllocationSequential allocates the first free ports of the range, in the order that they are
+	// requested. This is the default.
+	PortAllocationSequential PortAllocationStrategy = "sequential"
+
+	// PortAllocationHash allocates the first free port at or after an offset that is computed from a hash
+	// of the workload and the container port. The port of an intercepted container port will then remain
+	// stable when other ports of the workload are added or removed, and different workloads are unlikely
+	// to use the same ports.
+	PortAllocationHash PortAllocationStrategy = "hash"
+)
+
+// ParsePortAllocationStrategy returns the PortAllocationStrategy with the given name. An empty string
+// yields an empty strategy.
+func ParsePortAllocationStrategy(s string) (PortAllocationStrategy, error) {
+	switch ps := PortAllocationStrategy(s); ps {
+	case "", PortAllocationSequential, PortAllocationHash:
+		return ps, nil
+	default:
+		return "", fmt.Errorf("invalid port allocation strategy %q, must be %q or %q", s, PortAllocationSequential, PortAllocationHash)
+	}
+}
+
+// PortAllocation configures how the ports that the traffic-agent listens to are allocated.
+type PortAllocation struct {
+	// Range is the range of ports to allocate from.
+	Range *PortRange `json:"range,omitempty"`
+
+	// Strategy is the allocation strategy. Defaults to PortAllocationSequential.
+	Strategy PortAllocationStrategy `json:"strategy,omitempty"`
+}
+
+// PortAllocator allocates ports from a PortRange, avoiding the ports that are used by the containers
+// of a pod and ports that are reserved by the traffic-agent.
+type PortAllocator struct {
+	portRange *PortRange
+	strategy  PortAllocationStrategy
+	key       string
+	used      map[uint16]struct{}
+	allocated map[int32]uint16
+}
+
+// NewPortAllocator returns a PortAllocator for the given PortAllocation. The key, typically the namespace
+// and name of the workload, is used by the PortAllocationHash strategy. Ports declared by any container or
+// init-container in the given pod, other than the traffic-agent, are never allocated, nor are the given
+// reserved ports.
+func NewPortAllocator(pa *PortAllocation, key string, pod *core.PodTemplateSpec, reserved ...uint16) *PortAllocator {
+	pr := &PortAllocator{
+		portRange: pa.Range,
+		strategy:  pa.Strategy,
+		key:       key,
+		used:      make(map[uint16]struct{}),
+		allocated: make(map[int32]uint16),
+	}
+	if pr.strategy == "" {
+		pr.strategy = PortAllocationSequential
+	}
+	for _, p := range reserved {
+		if p != 0 {
+			pr.used[p] = struct{}{}
+		}
+	}
+	addPorts := func(cns []core.Container) {
+		for i := range cns {
+			cn := &cns[i]
+			if cn.Name == ContainerName || cn.Name == InitContainerName {
+				continue
+			}
+			for _, p := range cn.Ports {
+				pr.used[uint16(p.ContainerPort)] = struct{}{}
+			}
+		}
+	}
+	addPorts(pod.Spec.InitContainers)
+	addPorts(pod.Spec.Containers)
+	return pr
+}
+
+// Allocate returns the port allocated for the given container port. The same port is returned when a
+// container port is allocated more than once. An error is returned when the range is exhausted.
+func (pr *PortAllocator) Allocate(containerPort int32) (uint16, error) {
+	if p, ok := pr.allocated[containerPort]; ok {
+		return p, nil
+	}
+	size := pr.portRange.Size()
+	offset := 0
+	if pr.strategy == PortAllocationHash {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(pr.key + ":" + strconv.Itoa(int(containerPort))))
+		offset = int(h.Sum32() % uint32(size))
+	}
+	for i := 0; i < size; i++ {
+		p := uint16(int(pr.portRange.First) + (offset+i)%size)
+		if _, ok := pr.used[p]; !ok {
+			pr.used[p] = struct{}{}
+			pr.allocated[containerPort] = p
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unable to allocate a port for the %s from range %s; all ports are in use", ContainerName, pr.portRange)
+}
+
+// Allocated returns the number of allocated ports.
+func (pr *PortAllocator) Allocated() int {
+	return len(pr.allocated)
+}
@@ -0,0 +1,86 @@
+package agentconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func TestParsePortAllocationStrategy(t *testing.T) {
+	for _, s := range []string{"", "sequential", "hash"} {
+		ps, err := ParsePortAllocationStrategy(s)
+		require.NoError(t, err)
+		assert.Equal(t, PortAllocationStrategy(s), ps)
+	}
+	_, err := ParsePortAllocationStrategy("random")
+	assert.ErrorContains(t, err, `invalid port allocation strategy "random"`)
+}
+
+func TestPortAllocation_JSON(t *testing.T) {
+	pa := &PortAllocation{Range: &PortRange{First: 9900, Last: 9949}, Strategy: PortAllocationHash}
+	data, err := json.Marshal(pa)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"range":"9900-9949","strategy":"hash"}`, string(data))
+	var rt PortAllocation
+	require.NoError(t, json.Unmarshal(data, &rt))
+	assert.Equal(t, pa, &rt)
+}
+
+func TestPortAllocator(t *testing.T) {
+	pod := &core.PodTemplateSpec{Spec: core.PodSpec{
+		InitContainers: []core.Container{{Name: "sidecar", Ports: []core.ContainerPort{{ContainerPort: 9902}}}},
+		Containers: []core.Container{
+			{Name: "app", Ports: []core.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 9900}}},
+			{Name: ContainerName, Ports: []core.ContainerPort{{ContainerPort: 9903}}},
+		},
+	}}
+	pr := &PortRange{First: 9900, Last: 9904}
+
+	t.Run("sequential", func(t *testing.T) {
+		pa := NewPortAllocator(&PortAllocation{Range: pr}, "default/echo", pod, 9901)
+		p, err := pa.Allocate(8080)
+		require.NoError(t, err)
+		assert.Equal(t, uint16(9903), p, "ports of the app, init-containers and reserved ports are skipped")
+		p, err = pa.Allocate(8080)
+		require.NoError(t, err)
+		assert.Equal(t, uint16(9903), p, "same container port yields the same port")
+		p, err = pa.Allocate(8081)
+		require.NoError(t, err)
+		assert.Equal(t, uint16(9904), p)
+		_, err = pa.Allocate(8082)
+		assert.ErrorContains(t, err, "from range 9900-9904")
+		assert.Equal(t, 2, pa.Allocated())
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		pa := &PortAllocation{Range: &PortRange{First: 20000, Last: 29999}, Strategy: PortAllocationHash}
+		a := NewPortAllocator(pa, "default/echo", pod)
+		pa8080, err := a.Allocate(8080)
+		require.NoError(t, err)
+		pa8081, err := a.Allocate(8081)
+		require.NoError(t, err)
+		assert.NotEqual(t, pa8080, pa8081)
+
+		// The port is stable regardless of allocation order.
+		b := NewPortAllocator(pa, "default/echo", pod)
+		p, err := b.Allocate(8081)
+		require.NoError(t, err)
+		assert.Equal(t, pa8081, p)
+		p, err = b.Allocate(8080)
+		require.NoError(t, err)
+		assert.Equal(t, pa8080, p)
+
+		// All ports are found, even when the range is almost exhausted.
+		c := NewPortAllocator(&PortAllocation{Range: pr, Strategy: PortAllocationHash}, "default/echo", pod, 9901)
+		var ps []uint16
+		for i := int32(0); i < 2; i++ {
+			p, err = c.Allocate(8080 + i)
+			require.NoError(t, err)
+			ps = append(ps, p)
+		}
+		assert.ElementsMatch(t, []uint16{9903, 9904}, ps)
+	})
+}
@@ -0,0 +1,50 @@
+package agentconfig
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+)
+
+// Injection contains the containers and volumes that the agent injector adds to a pod.
+type Injection struct {
+	// Container is the traffic-agent container. It is injected as an init-container when
+	// it is a native sidecar.
+	Container *core.Container `json:"container"`
+
+	// InitContainer is the tel-agent-init init-container, or nil when no such container is needed.
+	InitContainer *core.Container `json:"initContainer,omitempty"`
+
+	// Volumes are the volumes that the traffic-agent needs.
+	Volumes []core.Volume `json:"volumes"`
+}
+
+// PreviewInjection returns the containers and volumes that the agent injector would add to the given pod
+// when using the given config, without making any calls to the cluster. Note that TLS secrets that the
+// injector would resolve from cert-manager Certificates aren't included.
+func PreviewInjection(ctx context.Context, pod *core.Pod, config *Sidecar) *Injection {
+	inj := &Injection{
+		Container: AgentContainer(ctx, pod, config),
+		Volumes:   AgentVolumes(config.AgentName, pod, config.Volumes, config.ServiceAccountToken),
+	}
+	if NeedInitContainer(config) {
+		inj.InitContainer = InitContainer(config)
+	}
+	return inj
+}
+
+// NeedInitContainer returns true if the given config has intercepts that require the tel-agent-init
+// init-container to set up iptables rules.
+func NeedInitContainer(config *Sidecar) bool {
+	if config.InitMode == InitModeProxy {
+		return false
+	}
+	for _, cc := range config.Containers {
+		for _, ic := range cc.Intercepts {
+			if ic.Headless || ic.TargetPortNumeric {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestPreviewInjection(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	pod := &core.Pod{
+		Spec: core.PodSpec{
+			Containers: []core.Container{{
+				Name:  "echo",
+				Ports: []core.ContainerPort{{ContainerPort: 8080}},
+			}},
+		},
+	}
+	config := &Sidecar{
+		AgentImage: "docker.io/datawire/tel2:2.19.1",
+		AgentName:  "echo",
+		Containers: []*Container{{
+			Name:      "echo",
+			EnvPrefix: "A_",
+			Intercepts: []*Intercept{{
+				ServiceName:       "echo",
+				ServicePort:       80,
+				TargetPortNumeric: true,
+				Protocol:          core.ProtocolTCP,
+				AgentPort:         9900,
+				ContainerPort:     8080,
+			}},
+		}},
+	}
+
+	inj := PreviewInjection(ctx, pod, config)
+	require.NotNil(t, inj.Container)
+	assert.Equal(t, ContainerName, inj.Container.Name)
+	require.NotNil(t, inj.InitContainer)
+	assert.Equal(t, InitContainerName, inj.InitContainer.Name)
+	assert.Equal(t, AgentVolumes("echo", pod, nil, nil), inj.Volumes)
+
+	config.InitMode = InitModeProxy
+	inj = PreviewInjection(ctx, pod, config)
+	assert.Nil(t, inj.InitContainer)
+	for _, p := range inj.Container.Ports {
+		assert.NotEqual(t, MetricsPortName, p.Name)
+	}
+
+	config.MetricsPort = 9898
+	inj = PreviewInjection(ctx, pod, config)
+	assert.Contains(t, inj.Container.Ports, core.ContainerPort{Name: MetricsPortName, ContainerPort: 9898, Protocol: core.ProtocolTCP})
+}
@@ -0,0 +1,129 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ResourcesAnnotation is a pod annotation that overrides the resource requirements of the injected
+// traffic-agent and tel-agent-init containers. The value is a JSON or YAML object, e.g.
+//
+//	agent:
+//	  requests:
+//	    cpu: 50m
+//	  limits:
+//	    memory: 128Mi
+//	init:
+//	  limits:
+//	    cpu: 100m
+const ResourcesAnnotation = DomainPrefix + "agent-resources"
+
+// ResourceOverrides are the resource requirements declared using the ResourcesAnnotation.
+type ResourceOverrides struct {
+	// Agent overrides the resources of the traffic-agent container.
+	Agent *core.ResourceRequirements `json:"agent,omitempty"`
+
+	// Init overrides the resources of the tel-agent-init container.
+	Init *core.ResourceRequirements `json:"init,omitempty"`
+}
+
+// GetResourceOverrides parses and validates the ResourcesAnnotation found in the given annotations. A nil
+// result is returned when no such annotation exists.
+func GetResourceOverrides(annotations map[string]string) (*ResourceOverrides, error) {
+	ra, ok := annotations[ResourcesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var ro ResourceOverrides
+	if err := yaml.UnmarshalStrict([]byte(ra), &ro); err != nil {
+		return nil, fmt.Errorf("unable to parse annotation %s: %w", ResourcesAnnotation, err)
+	}
+	if err := validateResources(ro.Agent); err != nil {
+		return nil, fmt.Errorf("invalid agent resources in annotation %s: %w", ResourcesAnnotation, err)
+	}
+	if err := validateResources(ro.Init); err != nil {
+		return nil, fmt.Errorf("invalid init resources in annotation %s: %w", ResourcesAnnotation, err)
+	}
+	return &ro, nil
+}
+
+// validateResources ensures that only cpu and memory are declared, and that the requirements are consistent.
+func validateResources(rr *core.ResourceRequirements) error {
+	if rr == nil {
+		return nil
+	}
+	for _, rl := range []core.ResourceList{rr.Requests, rr.Limits} {
+		for n := range rl {
+			if n != core.ResourceCPU && n != core.ResourceMemory {
+				return fmt.Errorf("unsupported resource %s", n)
+			}
+		}
+	}
+	return checkRequestsWithinLimits(rr)
+}
+
+// checkRequestsWithinLimits ensures that no quantity is negative and that no request exceeds its
+// corresponding limit.
+func checkRequestsWithinLimits(rr *core.ResourceRequirements) error {
+	if rr == nil {
+		return nil
+	}
+	for _, rl := range []core.ResourceList{rr.Requests, rr.Limits} {
+		for n, q := range rl {
+			if q.Sign() < 0 {
+				return fmt.Errorf("negative %s quantity %s", n, q.String())
+			}
+		}
+	}
+	for n, rq := range rr.Requests {
+		if lq, ok := rr.Limits[n]; ok && rq.Cmp(lq) > 0 {
+			return fmt.Errorf("%s request %s exceeds limit %s", n, rq.String(), lq.String())
+		}
+	}
+	return nil
+}
+
+// mergeResources returns a copy of base where the requests and limits declared by override replace
+// the ones in base.
+func mergeResources(base, override *core.ResourceRequirements) *core.ResourceRequirements {
+	if override == nil {
+		return base
+	}
+	var merged core.ResourceRequirements
+	if base != nil {
+		merged = *base.DeepCopy()
+	}
+	merge := func(dst *core.ResourceList, src core.ResourceList) {
+		if len(src) == 0 {
+			return
+		}
+		if *dst == nil {
+			*dst = make(core.ResourceList, len(src))
+		}
+		for n, q := range src {
+			(*dst)[n] = q
+		}
+	}
+	merge(&merged.Requests, override.Requests)
+	merge(&merged.Limits, override.Limits)
+	return &merged
+}
+
+// Apply returns the agent and init resources that result from applying the overrides to the given
+// resources. An error is returned if a merged request exceeds its limit.
+func (ro *ResourceOverrides) Apply(agentRes, initRes *core.ResourceRequirements) (*core.ResourceRequirements, *core.ResourceRequirements, error) {
+	if ro == nil {
+		return agentRes, initRes, nil
+	}
+	agentRes = mergeResources(agentRes, ro.Agent)
+	if err := checkRequestsWithinLimits(agentRes); err != nil {
+		return nil, nil, fmt.Errorf("invalid agent resources after applying annotation %s: %w", ResourcesAnnotation, err)
+	}
+	initRes = mergeResources(initRes, ro.Init)
+	if err := checkRequestsWithinLimits(initRes); err != nil {
+		return nil, nil, fmt.Errorf("invalid init resources after applying annotation %s: %w", ResourcesAnnotation, err)
+	}
+	return agentRes, initRes, nil
+}
@@ -0,0 +1,107 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetResourceOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		ann     string
+		wantErr string
+		check   func(*testing.T, *ResourceOverrides)
+	}{
+		{
+			name: "yaml",
+			ann:  "agent:\n  requests:\n    cpu: 50m\ninit:\n  limits:\n    memory: 64Mi\n",
+			check: func(t *testing.T, ro *ResourceOverrides) {
+				require.NotNil(t, ro.Agent)
+				assert.Equal(t, "50m", ro.Agent.Requests.Cpu().String())
+				require.NotNil(t, ro.Init)
+				assert.Equal(t, "64Mi", ro.Init.Limits.Memory().String())
+			},
+		},
+		{
+			name: "json",
+			ann:  `{"agent":{"limits":{"cpu":"1","memory":"256Mi"}}}`,
+			check: func(t *testing.T, ro *ResourceOverrides) {
+				require.NotNil(t, ro.Agent)
+				assert.Equal(t, "256Mi", ro.Agent.Limits.Memory().String())
+				assert.Nil(t, ro.Init)
+			},
+		},
+		{
+			name:    "unknown key",
+			ann:     `{"sidecar":{}}`,
+			wantErr: "unable to parse",
+		},
+		{
+			name:    "bad quantity",
+			ann:     `{"agent":{"limits":{"cpu":"lots"}}}`,
+			wantErr: "unable to parse",
+		},
+		{
+			name:    "unsupported resource",
+			ann:     `{"agent":{"limits":{"ephemeral-storage":"1Gi"}}}`,
+			wantErr: "unsupported resource",
+		},
+		{
+			name:    "request exceeds limit",
+			ann:     `{"init":{"requests":{"cpu":"2"},"limits":{"cpu":"1"}}}`,
+			wantErr: "exceeds limit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ro, err := GetResourceOverrides(map[string]string{ResourcesAnnotation: tt.ann})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, ro)
+		})
+	}
+
+	ro, err := GetResourceOverrides(nil)
+	require.NoError(t, err)
+	assert.Nil(t, ro)
+}
+
+func TestResourceOverrides_Apply(t *testing.T) {
+	base := &core.ResourceRequirements{
+		Requests: core.ResourceList{core.ResourceCPU: resource.MustParse("10m")},
+		Limits:   core.ResourceList{core.ResourceCPU: resource.MustParse("100m"), core.ResourceMemory: resource.MustParse("128Mi")},
+	}
+	ro := &ResourceOverrides{
+		Agent: &core.ResourceRequirements{
+			Limits: core.ResourceList{core.ResourceMemory: resource.MustParse("512Mi")},
+		},
+	}
+	agentRes, initRes, err := ro.Apply(base, base)
+	require.NoError(t, err)
+	assert.Equal(t, "512Mi", agentRes.Limits.Memory().String())
+	assert.Equal(t, "100m", agentRes.Limits.Cpu().String())
+	assert.Equal(t, "10m", agentRes.Requests.Cpu().String())
+	assert.Same(t, base, initRes)
+	assert.Equal(t, "128Mi", base.Limits.Memory().String(), "base must not be modified")
+
+	ro = &ResourceOverrides{
+		Init: &core.ResourceRequirements{
+			Requests: core.ResourceList{core.ResourceCPU: resource.MustParse("200m")},
+		},
+	}
+	_, _, err = ro.Apply(base, base)
+	require.ErrorContains(t, err, "exceeds limit")
+
+	var nilRo *ResourceOverrides
+	agentRes, initRes, err = nilRo.Apply(base, nil)
+	require.NoError(t, err)
+	assert.Same(t, base, agentRes)
+	assert.Nil(t, initRes)
+}
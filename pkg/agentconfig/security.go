@@ -0,0 +1,42 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// SecurityContextAnnotation is a pod annotation that declares the securityContext of the injected
+// traffic-agent container. The value is a JSON or YAML encoded core.SecurityContext, e.g.
+//
+//	runAsUser: 1000
+//	runAsNonRoot: true
+//	seccompProfile:
+//	  type: RuntimeDefault
+//	capabilities:
+//	  drop: ["ALL"]
+//
+// When neither this annotation nor the Sidecar's SecurityContext is set, the agent inherits the securityContext
+// of the first intercepted container.
+const SecurityContextAnnotation = DomainPrefix + "agent-security-context"
+
+// GetSecurityContext parses the SecurityContextAnnotation found in the given annotations. A nil result is
+// returned when no such annotation exists.
+func GetSecurityContext(annotations map[string]string) (*core.SecurityContext, error) {
+	sa, ok := annotations[SecurityContextAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var sc core.SecurityContext
+	if err := yaml.UnmarshalStrict([]byte(sa), &sc); err != nil {
+		return nil, fmt.Errorf("unable to parse annotation %s: %w", SecurityContextAnnotation, err)
+	}
+	if sc.RunAsNonRoot != nil && *sc.RunAsNonRoot && sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		return nil, fmt.Errorf("invalid annotation %s: runAsNonRoot cannot be combined with runAsUser 0", SecurityContextAnnotation)
+	}
+	if sp := sc.SeccompProfile; sp != nil && sp.Type == core.SeccompProfileTypeLocalhost && (sp.LocalhostProfile == nil || *sp.LocalhostProfile == "") {
+		return nil, fmt.Errorf("invalid annotation %s: seccompProfile of type Localhost must declare a localhostProfile", SecurityContextAnnotation)
+	}
+	return &sc, nil
+}
@@ -0,0 +1,40 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func TestGetSecurityContext(t *testing.T) {
+	sc, err := GetSecurityContext(nil)
+	require.NoError(t, err)
+	assert.Nil(t, sc)
+
+	sc, err = GetSecurityContext(map[string]string{SecurityContextAnnotation: `
+runAsUser: 1000
+runAsNonRoot: true
+seccompProfile:
+  type: RuntimeDefault
+capabilities:
+  drop: ["ALL"]
+`})
+	require.NoError(t, err)
+	require.NotNil(t, sc.RunAsUser)
+	assert.Equal(t, int64(1000), *sc.RunAsUser)
+	require.NotNil(t, sc.RunAsNonRoot)
+	assert.True(t, *sc.RunAsNonRoot)
+	assert.Equal(t, core.SeccompProfileTypeRuntimeDefault, sc.SeccompProfile.Type)
+	assert.Equal(t, []core.Capability{"ALL"}, sc.Capabilities.Drop)
+
+	_, err = GetSecurityContext(map[string]string{SecurityContextAnnotation: `{"runAsUser": 0, "runAsNonRoot": true}`})
+	assert.ErrorContains(t, err, "runAsNonRoot")
+
+	_, err = GetSecurityContext(map[string]string{SecurityContextAnnotation: `{"seccompProfile": {"type": "Localhost"}}`})
+	assert.ErrorContains(t, err, "localhostProfile")
+
+	_, err = GetSecurityContext(map[string]string{SecurityContextAnnotation: `{"runAsUsr": 1000}`})
+	assert.ErrorContains(t, err, "unable to parse")
+}
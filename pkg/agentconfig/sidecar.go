@@ -31,6 +31,9 @@ const (
 	ExportsMountPoint        = "/tel_app_exports"
 	TempVolumeName           = "tel-agent-tmp"
 	TempMountPoint           = "/tmp"
+	TokenVolumeName          = "traffic-agent-token"
+	TokenMountPoint          = "/var/run/secrets/telepresence.io/serviceaccount"
+	TokenFile                = "token"
 	EnvPrefix                = "_TEL_"
 	EnvPrefixAgent           = EnvPrefix + "AGENT_"
 	EnvPrefixApp             = EnvPrefix + "APP_"
@@ -41,12 +44,36 @@ const (
 	// EnvInterceptMounts mount points propagated to client during intercept.
 	EnvInterceptMounts = "TELEPRESENCE_MOUNTS"
 
+	// EnvInterceptSecrets comma separated names of the environment variables that are sourced from
+	// Secrets, propagated to client during intercept. See Container.SecretEnv.
+	EnvInterceptSecrets = "TELEPRESENCE_SECRET_ENV"
+
 	// EnvAPIPort is the port number of the Telepresence API server, when it is enabled.
 	EnvAPIPort = "TELEPRESENCE_API_PORT"
 
+	// MetricsPortName is the name of the agent container's port for Prometheus metrics, so that a
+	// PodMonitor can select it.
+	MetricsPortName = "tel-metrics"
+
+	// HealthPath is the path that answers HTTP readiness probes on the agent's health port.
+	HealthPath = "/healthz"
+
+	// HealthProbeGRPC declares that the agent's readiness is checked using a gRPC probe.
+	HealthProbeGRPC = "grpc"
+
+	// HealthProbeHTTP declares that the agent's readiness is checked using an httpGet probe.
+	HealthProbeHTTP = "http"
+
+	// HealthProbeExec declares that the agent's readiness is checked using an exec probe, even when
+	// the agent has a health server.
+	HealthProbeExec = "exec"
+
 	DomainPrefix                         = "telepresence.getambassador.io/"
 	InjectAnnotation                     = DomainPrefix + "inject-" + ContainerName
 	InjectIgnoreVolumeMounts             = DomainPrefix + "inject-ignore-volume-mounts"
+	InjectIgnoreContainers               = DomainPrefix + "inject-ignore-containers"
+	NativeSidecarAnnotation              = DomainPrefix + "inject-native-sidecar"
+	PullSecretsAnnotation                = DomainPrefix + "agent-image-pull-secrets"
 	TerminatingTLSSecretAnnotation       = DomainPrefix + "inject-terminating-tls-secret"
 	OriginatingTLSSecretAnnotation       = DomainPrefix + "inject-originating-tls-secret"
 	LegacyTerminatingTLSSecretAnnotation = "getambassador.io/inject-terminating-tls-secret"
@@ -118,6 +145,63 @@ type Intercept struct {
 	AgentPort uint16 `json:"agentPort,omitempty"`
 }
 
+// ProbeSettings declares the timings of a probe. A zero value means that the Kubernetes default is used.
+type ProbeSettings struct {
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	TimeoutSeconds      int32 `json:"timeoutSeconds,omitempty"`
+	PeriodSeconds       int32 `json:"periodSeconds,omitempty"`
+	SuccessThreshold    int32 `json:"successThreshold,omitempty"`
+	FailureThreshold    int32 `json:"failureThreshold,omitempty"`
+}
+
+// Probes configures the probes of the traffic-agent container. All probes use the same handler, which is
+// determined by the health port and probe type of the Sidecar.
+type Probes struct {
+	// Readiness are the settings of the readiness probe. The readiness probe is always present.
+	Readiness *ProbeSettings `json:"readiness,omitempty"`
+
+	// Liveness are the settings of the liveness probe. No liveness probe is used when this is nil.
+	Liveness *ProbeSettings `json:"liveness,omitempty"`
+
+	// Startup are the settings of the startup probe. No startup probe is used when this is nil.
+	Startup *ProbeSettings `json:"startup,omitempty"`
+}
+
+// VolumeSettings configures the emptyDir volumes of the traffic-agent.
+type VolumeSettings struct {
+	// Export configures the volume where the agent exports the app container mounts.
+	Export *core.EmptyDirVolumeSource `json:"export,omitempty"`
+
+	// Tmp configures the volume that the agent uses for temporary files.
+	Tmp *core.EmptyDirVolumeSource `json:"tmp,omitempty"`
+}
+
+func (vs *VolumeSettings) exportEmptyDir() *core.EmptyDirVolumeSource {
+	if vs == nil || vs.Export == nil {
+		return &core.EmptyDirVolumeSource{}
+	}
+	return vs.Export.DeepCopy()
+}
+
+func (vs *VolumeSettings) tmpEmptyDir() *core.EmptyDirVolumeSource {
+	if vs == nil || vs.Tmp == nil {
+		return &core.EmptyDirVolumeSource{}
+	}
+	return vs.Tmp.DeepCopy()
+}
+
+// ServiceAccountToken configures a projected service-account token that is mounted into the traffic-agent
+// container. The agent uses this token, rather than the token of the app, when it authenticates to the
+// traffic-manager.
+type ServiceAccountToken struct {
+	// Audience is the intended audience of the token.
+	Audience string `json:"audience,omitempty"`
+
+	// ExpirationSeconds is the requested validity duration of the token. The kubelet rotates the token
+	// before it expires. Kubernetes uses a default of one hour when this is zero.
+	ExpirationSeconds int64 `json:"expirationSeconds,omitempty"`
+}
+
 // Container describes one container that can have one or several intercepts.
 type Container struct {
 	// Name of the intercepted container
@@ -135,6 +219,12 @@ type Container struct {
 	// Mounts are the actual mount points that are mounted by this container
 	Mounts []string `json:"Mounts,omitempty"`
 
+	// SecretEnv are the names of the environment variables of this container that are sourced from
+	// Secrets. A name that ends with "*" denotes the variables of a Secret that is declared using envFrom,
+	// because their names aren't known up front. The agent replaces it with the names of the variables of
+	// the app container that start with the same prefix.
+	SecretEnv []string `json:"secretEnv,omitempty"`
+
 	// Replace is whether the agent should replace the intercepted container
 	Replace ReplacePolicy `json:"replace,omitempty"`
 }
@@ -165,6 +255,9 @@ type Sidecar struct {
 	// LogLevel used for all traffic-agent logging
 	LogLevel string `json:"logLevel,omitempty"`
 
+	// LogFormat used for all traffic-agent logging, "text" or "json"
+	LogFormat string `json:"logFormat,omitempty"`
+
 	// The name of the workload that the pod originates from
 	WorkloadName string `json:"workloadName,omitempty"`
 
@@ -183,6 +276,25 @@ type Sidecar struct {
 	// The port used by the agent's GRPC tracing server
 	TracingPort uint16 `json:"tracingPort,omitempty"`
 
+	// The port used by the agent's health server. The readiness of the agent is determined
+	// using an exec probe when this port is zero.
+	HealthPort uint16 `json:"healthPort,omitempty"`
+
+	// The port used by the agent's Prometheus metrics server. No metrics are served when this
+	// port is zero.
+	MetricsPort uint16 `json:"metricsPort,omitempty"`
+
+	// The type of probe used when checking the readiness of the agent using its health server.
+	// One of HealthProbeGRPC, HealthProbeHTTP, or HealthProbeExec. Defaults to HealthProbeGRPC.
+	HealthProbe string `json:"healthProbe,omitempty"`
+
+	// Probes configures the timings of the agent's readiness probe, and optional liveness and startup probes.
+	Probes *Probes `json:"probes,omitempty"`
+
+	// TunnelCompression is the compression that the agent offers for the payload of the tunnels that it
+	// creates to the traffic-manager. One of "snappy" or "zstd". No compression is offered when it's empty.
+	TunnelCompression string `json:"tunnelCompression,omitempty"`
+
 	// Resources for the sidecar
 	Resources *core.ResourceRequirements `json:"resources,omitempty"`
 
@@ -192,8 +304,31 @@ type Sidecar struct {
 	// The intercepts managed by the agent
 	Containers []*Container `json:"containers,omitempty"`
 
-	// SecurityContext for the sidecar
+	// SecurityContext for the sidecar. The sidecar inherits the securityContext of the first intercepted
+	// container when this is nil.
 	SecurityContext *core.SecurityContext `json:"securityContext,omitempty"`
+
+	// NativeSidecar is true when the traffic-agent should be injected as a Kubernetes native sidecar, i.e.
+	// an init-container with restartPolicy Always. Requires Kubernetes 1.28 or later. Can be overridden
+	// for individual pods using the NativeSidecarAnnotation.
+	NativeSidecar bool `json:"nativeSidecar,omitempty"`
+
+	// Capabilities declares the capabilities of the agent image. The capabilities are derived from the
+	// version of the agent image when this is empty.
+	Capabilities Capabilities `json:"capabilities,omitempty"`
+
+	// InitMode determines if the tel-agent-init init-container is used to route traffic from numeric
+	// container ports to the agent. Defaults to InitModeIPTables.
+	InitMode InitMode `json:"initMode,omitempty"`
+
+	// Volumes configures the size limit and medium of the agent's emptyDir volumes.
+	Volumes *VolumeSettings `json:"volumes,omitempty"`
+
+	// ServiceAccountToken, when set, makes the agent use a projected service-account token.
+	ServiceAccountToken *ServiceAccountToken `json:"serviceAccountToken,omitempty"`
+
+	// PortAllocation is the range and strategy used when allocating the ports that the agent listens to.
+	PortAllocation *PortAllocation `json:"portAllocation,omitempty"`
 }
 
 func (s *Sidecar) AgentConfig() *Sidecar {
@@ -0,0 +1,143 @@
+package agentmap
+
+import (
+	"context"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	typedApps "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
+)
+
+// DaemonSetKind is the kind of a DaemonSet workload. The k8sapi package doesn't know about DaemonSets, so
+// this package provides the k8sapi.Workload implementation for them.
+const DaemonSetKind = "DaemonSet"
+
+type daemonSet struct {
+	*apps.DaemonSet
+}
+
+// DaemonSet returns a k8sapi.Workload that wraps the given DaemonSet.
+func DaemonSet(d *apps.DaemonSet) k8sapi.Workload {
+	return &daemonSet{DaemonSet: d}
+}
+
+// DaemonSetImpl returns the DaemonSet that is wrapped by the given object, if any.
+func DaemonSetImpl(o k8sapi.Object) (*apps.DaemonSet, bool) {
+	if s, ok := o.(*daemonSet); ok {
+		return s.DaemonSet, true
+	}
+	return nil, false
+}
+
+// WrapWorkload is like k8sapi.WrapWorkload but also wraps DaemonSets and DeploymentConfigs.
+func WrapWorkload(workload runtime.Object) (k8sapi.Workload, error) {
+	switch w := workload.(type) {
+	case *apps.DaemonSet:
+		return DaemonSet(w), nil
+	case *openshift.DeploymentConfig:
+		return DeploymentConfig(w), nil
+	}
+	return k8sapi.WrapWorkload(workload)
+}
+
+// getWorkloadDirect is like k8sapi.GetWorkload but also considers DaemonSets and DeploymentConfigs. An
+// empty workloadKind makes DaemonSets and then DeploymentConfigs the last kinds to be searched.
+func getWorkloadDirect(ctx context.Context, name, namespace, workloadKind string) (k8sapi.Workload, error) {
+	switch workloadKind {
+	case DaemonSetKind:
+		return getDaemonSetDirect(ctx, name, namespace)
+	case DeploymentConfigKind:
+		return getDeploymentConfigDirect(ctx, name, namespace)
+	case "":
+		wl, err := k8sapi.GetWorkload(ctx, name, namespace, workloadKind)
+		if err == nil || !k8sErrors.IsNotFound(err) {
+			return wl, err
+		}
+		if wl, err = getDaemonSetDirect(ctx, name, namespace); err == nil || !k8sErrors.IsNotFound(err) {
+			return wl, err
+		}
+		if wl, err = getDeploymentConfigDirect(ctx, name, namespace); err == nil || !k8sErrors.IsNotFound(err) {
+			return wl, err
+		}
+		return nil, k8sErrors.NewNotFound(core.Resource("workload"), name+"."+namespace)
+	default:
+		return k8sapi.GetWorkload(ctx, name, namespace, workloadKind)
+	}
+}
+
+func getDaemonSetDirect(ctx context.Context, name, namespace string) (k8sapi.Workload, error) {
+	d, err := daemonSets(ctx, namespace).Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return DaemonSet(d), nil
+}
+
+func daemonSets(ctx context.Context, namespace string) typedApps.DaemonSetInterface {
+	return k8sapi.GetK8sInterface(ctx).AppsV1().DaemonSets(namespace)
+}
+
+func (o *daemonSet) ki(ctx context.Context) typedApps.DaemonSetInterface {
+	return daemonSets(ctx, o.Namespace)
+}
+
+func (o *daemonSet) GetKind() string {
+	return DaemonSetKind
+}
+
+func (o *daemonSet) Delete(ctx context.Context) error {
+	return o.ki(ctx).Delete(ctx, o.Name, meta.DeleteOptions{})
+}
+
+func (o *daemonSet) GetPodTemplate() *core.PodTemplateSpec {
+	return &o.Spec.Template
+}
+
+func (o *daemonSet) Patch(ctx context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	d, err := o.ki(ctx).Patch(ctx, o.Name, pt, data, meta.PatchOptions{}, subresources...)
+	if err == nil {
+		o.DaemonSet = d
+	}
+	return err
+}
+
+func (o *daemonSet) Refresh(ctx context.Context) error {
+	d, err := o.ki(ctx).Get(ctx, o.Name, meta.GetOptions{})
+	if err == nil {
+		o.DaemonSet = d
+	}
+	return err
+}
+
+// Replicas returns the number of nodes that should run the daemon pod.
+func (o *daemonSet) Replicas() int {
+	return int(o.Status.DesiredNumberScheduled)
+}
+
+func (o *daemonSet) Selector() (labels.Selector, error) {
+	return meta.LabelSelectorAsSelector(o.Spec.Selector)
+}
+
+func (o *daemonSet) Update(ctx context.Context) error {
+	d, err := o.ki(ctx).Update(ctx, o.DaemonSet, meta.UpdateOptions{})
+	if err == nil {
+		o.DaemonSet = d
+	}
+	return err
+}
+
+func (o *daemonSet) Updated(origGeneration int64) bool {
+	st := &o.Status
+	return o.ObjectMeta.Generation >= origGeneration &&
+		st.ObservedGeneration == o.ObjectMeta.Generation &&
+		st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+		st.NumberAvailable == st.DesiredNumberScheduled
+}
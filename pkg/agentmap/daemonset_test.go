@@ -0,0 +1,60 @@
+package agentmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWrapWorkload_DaemonSet(t *testing.T) {
+	ds := &apps.DaemonSet{ObjectMeta: meta.ObjectMeta{Name: "node-agent", Namespace: "default"}}
+	wl, err := WrapWorkload(ds)
+	require.NoError(t, err)
+	assert.Equal(t, DaemonSetKind, wl.GetKind())
+	impl, ok := DaemonSetImpl(wl)
+	require.True(t, ok)
+	assert.Same(t, ds, impl)
+
+	wl, err = WrapWorkload(&apps.Deployment{})
+	require.NoError(t, err)
+	_, ok = DaemonSetImpl(wl)
+	assert.False(t, ok)
+}
+
+func TestDaemonSet_Updated(t *testing.T) {
+	ds := &apps.DaemonSet{
+		ObjectMeta: meta.ObjectMeta{Generation: 2},
+		Status: apps.DaemonSetStatus{
+			ObservedGeneration:     2,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 2,
+			NumberAvailable:        3,
+		},
+	}
+	wl := DaemonSet(ds)
+	assert.Equal(t, 3, wl.Replicas())
+	assert.False(t, wl.Updated(2), "not all nodes are updated")
+	ds.Status.UpdatedNumberScheduled = 3
+	assert.True(t, wl.Updated(2))
+	assert.False(t, wl.Updated(3), "generation not yet observed")
+}
+
+func TestGetWorkload_DaemonSet(t *testing.T) {
+	ds := &apps.DaemonSet{ObjectMeta: meta.ObjectMeta{Name: "node-agent", Namespace: "default"}}
+	ai := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Apps().V1()
+	require.NoError(t, ai.DaemonSets().Informer().GetIndexer().Add(ds))
+
+	for _, kind := range []string{DaemonSetKind, ""} {
+		wl, err := getWorkload(ai, "node-agent", "default", kind)
+		require.NoError(t, err)
+		assert.Equal(t, DaemonSetKind, wl.GetKind())
+	}
+	_, err := getWorkload(ai, "other", "default", "")
+	assert.True(t, k8sErrors.IsNotFound(err))
+}
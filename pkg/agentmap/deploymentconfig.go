@@ -0,0 +1,164 @@
+package agentmap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
+)
+
+// DeploymentConfigKind is the kind of an OpenShift DeploymentConfig workload.
+const DeploymentConfigKind = "DeploymentConfig"
+
+type deploymentConfig struct {
+	*openshift.DeploymentConfig
+}
+
+// DeploymentConfig returns a k8sapi.Workload that wraps the given DeploymentConfig.
+func DeploymentConfig(d *openshift.DeploymentConfig) k8sapi.Workload {
+	return &deploymentConfig{DeploymentConfig: d}
+}
+
+// DeploymentConfigImpl returns the DeploymentConfig that is wrapped by the given object, if any.
+func DeploymentConfigImpl(o k8sapi.Object) (*openshift.DeploymentConfig, bool) {
+	if s, ok := o.(*deploymentConfig); ok {
+		return s.DeploymentConfig, true
+	}
+	return nil, false
+}
+
+// getDeploymentConfig returns the DeploymentConfig with the given name and namespace. The DeploymentConfig
+// informer is used when present in the context. A NotFound error is returned when the cluster doesn't
+// serve DeploymentConfigs.
+func getDeploymentConfig(ctx context.Context, name, namespace string) (k8sapi.Workload, error) {
+	if ix := openshift.GetInformer(ctx, namespace); ix != nil {
+		o, ok, err := ix.GetIndexer().GetByKey(namespace + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, k8sErrors.NewNotFound(openshift.Resource(openshift.DeploymentConfigs), name)
+		}
+		return DeploymentConfig(o.(*openshift.DeploymentConfig)), nil
+	}
+	return getDeploymentConfigDirect(ctx, name, namespace)
+}
+
+func getDeploymentConfigDirect(ctx context.Context, name, namespace string) (k8sapi.Workload, error) {
+	rc := openshift.GetRESTClient(ctx)
+	if rc == nil {
+		return nil, k8sErrors.NewNotFound(openshift.Resource(openshift.DeploymentConfigs), name)
+	}
+	d, err := openshift.Get(ctx, rc, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return DeploymentConfig(d), nil
+}
+
+// TriggerDeploymentConfigRollout starts a new deployment of the given DeploymentConfig using the
+// OpenShift API. This will recreate the pods even when the DeploymentConfig has no ConfigChange trigger.
+func TriggerDeploymentConfigRollout(ctx context.Context, wl k8sapi.Workload) error {
+	o, ok := wl.(*deploymentConfig)
+	if !ok {
+		return fmt.Errorf("%s %s.%s is not a %s", wl.GetKind(), wl.GetName(), wl.GetNamespace(), DeploymentConfigKind)
+	}
+	rc, err := deploymentConfigClient(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := openshift.Instantiate(ctx, rc, o.Name, o.Namespace)
+	if err == nil {
+		o.DeploymentConfig = d
+	}
+	return err
+}
+
+func deploymentConfigClient(ctx context.Context) (rest.Interface, error) {
+	if rc := openshift.GetRESTClient(ctx); rc != nil {
+		return rc, nil
+	}
+	return nil, errors.New("the cluster doesn't serve OpenShift DeploymentConfigs")
+}
+
+func (o *deploymentConfig) GetKind() string {
+	return DeploymentConfigKind
+}
+
+func (o *deploymentConfig) Delete(ctx context.Context) error {
+	rc, err := deploymentConfigClient(ctx)
+	if err != nil {
+		return err
+	}
+	return openshift.Delete(ctx, rc, o.Name, o.Namespace)
+}
+
+func (o *deploymentConfig) GetPodTemplate() *core.PodTemplateSpec {
+	if o.Spec.Template == nil {
+		o.Spec.Template = &core.PodTemplateSpec{}
+	}
+	return o.Spec.Template
+}
+
+func (o *deploymentConfig) Patch(ctx context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	rc, err := deploymentConfigClient(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := openshift.Patch(ctx, rc, o.Name, o.Namespace, pt, data, subresources...)
+	if err == nil {
+		o.DeploymentConfig = d
+	}
+	return err
+}
+
+func (o *deploymentConfig) Refresh(ctx context.Context) error {
+	rc, err := deploymentConfigClient(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := openshift.Get(ctx, rc, o.Name, o.Namespace)
+	if err == nil {
+		o.DeploymentConfig = d
+	}
+	return err
+}
+
+func (o *deploymentConfig) Replicas() int {
+	return int(o.Spec.Replicas)
+}
+
+func (o *deploymentConfig) Selector() (labels.Selector, error) {
+	return labels.SelectorFromValidatedSet(o.Spec.Selector), nil
+}
+
+// Update replaces the pod template of the DeploymentConfig. Other parts of the spec are retained, because
+// the openshift.DeploymentConfig doesn't declare all fields of the resource.
+func (o *deploymentConfig) Update(ctx context.Context) error {
+	data, err := json.Marshal([]map[string]any{{
+		"op":    "replace",
+		"path":  "/spec/template",
+		"value": o.GetPodTemplate(),
+	}})
+	if err != nil {
+		return err
+	}
+	return o.Patch(ctx, types.JSONPatchType, data)
+}
+
+func (o *deploymentConfig) Updated(origGeneration int64) bool {
+	st := &o.Status
+	return o.ObjectMeta.Generation >= origGeneration &&
+		st.ObservedGeneration == o.ObjectMeta.Generation &&
+		st.UpdatedReplicas == o.Spec.Replicas &&
+		st.AvailableReplicas == o.Spec.Replicas
+}
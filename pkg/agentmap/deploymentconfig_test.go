@@ -0,0 +1,101 @@
+package agentmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
+)
+
+func TestWrapWorkload_DeploymentConfig(t *testing.T) {
+	dc := &openshift.DeploymentConfig{ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"}}
+	wl, err := WrapWorkload(dc)
+	require.NoError(t, err)
+	assert.Equal(t, DeploymentConfigKind, wl.GetKind())
+	impl, ok := DeploymentConfigImpl(wl)
+	require.True(t, ok)
+	assert.Same(t, dc, impl)
+	assert.NotNil(t, wl.GetPodTemplate())
+}
+
+func TestDeploymentConfig_Updated(t *testing.T) {
+	dc := &openshift.DeploymentConfig{
+		ObjectMeta: meta.ObjectMeta{Generation: 2},
+		Spec: openshift.DeploymentConfigSpec{
+			Replicas: 3,
+			Selector: map[string]string{"app": "echo"},
+		},
+		Status: openshift.DeploymentConfigStatus{
+			ObservedGeneration: 2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  3,
+		},
+	}
+	wl := DeploymentConfig(dc)
+	assert.Equal(t, 3, wl.Replicas())
+	sel, err := wl.Selector()
+	require.NoError(t, err)
+	assert.True(t, sel.Matches(labels.Set{"app": "echo", "version": "1"}))
+	assert.False(t, wl.Updated(2), "not all replicas are updated")
+	dc.Status.UpdatedReplicas = 3
+	assert.True(t, wl.Updated(2))
+	assert.False(t, wl.Updated(3), "generation not yet observed")
+}
+
+func TestGetWorkload_DeploymentConfig(t *testing.T) {
+	dc := &openshift.DeploymentConfig{ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"}}
+	rc := &core.ReplicationController{ObjectMeta: meta.ObjectMeta{
+		Name:      "echo-7",
+		Namespace: "default",
+		OwnerReferences: []meta.OwnerReference{{
+			APIVersion: openshift.SchemeGroupVersion.String(),
+			Kind:       DeploymentConfigKind,
+			Name:       "echo",
+			Controller: &[]bool{true}[0],
+		}},
+	}}
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset(rc))
+	ctx = informer.WithFactory(ctx, "")
+	ctx = openshift.WithRESTClient(ctx, &rest.RESTClient{})
+	ctx = openshift.WithInformer(ctx, "")
+	require.NoError(t, openshift.GetInformer(ctx, "default").GetIndexer().Add(dc))
+
+	for _, kind := range []string{DeploymentConfigKind, ""} {
+		wl, err := GetWorkload(ctx, "echo", "default", kind)
+		require.NoError(t, err)
+		assert.Equal(t, DeploymentConfigKind, wl.GetKind())
+	}
+	_, err := GetWorkload(ctx, "other", "default", "")
+	assert.True(t, k8sErrors.IsNotFound(err))
+
+	pod := &core.Pod{ObjectMeta: meta.ObjectMeta{
+		Name:            "echo-7-abcde",
+		Namespace:       "default",
+		OwnerReferences: []meta.OwnerReference{{Kind: "ReplicationController", Name: "echo-7", Controller: &[]bool{true}[0]}},
+	}}
+	wl, err := FindOwnerWorkload(ctx, k8sapi.Pod(pod))
+	require.NoError(t, err)
+	assert.Equal(t, DeploymentConfigKind, wl.GetKind())
+	assert.Equal(t, "echo", wl.GetName())
+
+	// The name of the ReplicationController doesn't reveal the DeploymentConfig.
+	rc.Name = "echo-rc"
+	_, err = k8sapi.GetK8sInterface(ctx).CoreV1().ReplicationControllers("default").Create(ctx, rc, meta.CreateOptions{})
+	require.NoError(t, err)
+	pod.OwnerReferences[0].Name = "echo-rc"
+	wl, err = FindOwnerWorkload(ctx, k8sapi.Pod(pod))
+	require.NoError(t, err)
+	assert.Equal(t, "echo", wl.GetName())
+}
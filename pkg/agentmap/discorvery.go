@@ -17,10 +17,16 @@ import (
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 )
 
 var ReplicaSetNameRx = regexp.MustCompile(`\A(.+)-[a-f0-9]+\z`)
 
+// ReplicationControllerNameRx matches the name of a ReplicationController that was created by a
+// DeploymentConfig. The name of such a controller is the name of the DeploymentConfig followed by
+// the version of the deployment.
+var ReplicationControllerNameRx = regexp.MustCompile(`\A(.+)-[0-9]+\z`)
+
 func FindOwnerWorkload(ctx context.Context, obj k8sapi.Object) (k8sapi.Workload, error) {
 	dlog.Debugf(ctx, "FindOwnerWorkload(%s,%s,%s)", obj.GetName(), obj.GetNamespace(), obj.GetKind())
 	lbs := obj.GetLabels()
@@ -41,6 +47,9 @@ func FindOwnerWorkload(ctx context.Context, obj k8sapi.Object) (k8sapi.Workload,
 					}
 				}
 			}
+			if or.Kind == "ReplicationController" {
+				return findReplicationControllerOwner(ctx, or.Name, ns)
+			}
 			wl, err := GetWorkload(ctx, or.Name, ns, or.Kind)
 			if err != nil {
 				return nil, err
@@ -54,14 +63,46 @@ func FindOwnerWorkload(ctx context.Context, obj k8sapi.Object) (k8sapi.Workload,
 	return nil, fmt.Errorf("unable to find workload owner for %s.%s", obj.GetName(), obj.GetNamespace())
 }
 
+// findReplicationControllerOwner returns the DeploymentConfig that controls the ReplicationController with
+// the given name. Pods of a DeploymentConfig are owned by such a ReplicationController.
+func findReplicationControllerOwner(ctx context.Context, name, namespace string) (k8sapi.Workload, error) {
+	// Try the common case first. Strip the version from the name and try to get the DeploymentConfig.
+	if m := ReplicationControllerNameRx.FindStringSubmatch(name); m != nil {
+		if wl, err := GetWorkload(ctx, m[1], namespace, DeploymentConfigKind); err == nil {
+			return wl, nil
+		}
+	}
+	rc, err := k8sapi.GetK8sInterface(ctx).CoreV1().ReplicationControllers(namespace).Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if or := meta.GetControllerOf(rc); or != nil && or.Kind == DeploymentConfigKind {
+		return GetWorkload(ctx, or.Name, namespace, DeploymentConfigKind)
+	}
+	return nil, k8sapi.UnsupportedWorkloadKindError("ReplicationController")
+}
+
 func GetWorkload(ctx context.Context, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
 	dlog.Debugf(ctx, "GetWorkload(%s,%s,%s)", name, namespace, workloadKind)
 	f := informer.GetFactory(ctx, namespace)
 	if f == nil {
 		dlog.Debugf(ctx, "fetching %s %s.%s using direct API call", workloadKind, name, namespace)
-		return k8sapi.GetWorkload(ctx, name, namespace, workloadKind)
+		return getWorkloadDirect(ctx, name, namespace, workloadKind)
+	}
+	switch workloadKind {
+	case DeploymentConfigKind:
+		return getDeploymentConfig(ctx, name, namespace)
+	case "":
+		obj, err = getWorkload(f.Apps().V1(), name, namespace, workloadKind)
+		if err != nil && k8sErrors.IsNotFound(err) && openshift.GetRESTClient(ctx) != nil {
+			if dc, dcErr := getDeploymentConfig(ctx, name, namespace); dcErr == nil || !k8sErrors.IsNotFound(dcErr) {
+				return dc, dcErr
+			}
+		}
+		return obj, err
+	default:
+		return getWorkload(f.Apps().V1(), name, namespace, workloadKind)
 	}
-	return getWorkload(f.Apps().V1(), name, namespace, workloadKind)
 }
 
 func getWorkload(ai apps.Interface, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
@@ -72,8 +113,10 @@ func getWorkload(ai apps.Interface, name, namespace, workloadKind string) (obj k
 		return getReplicaSet(ai, name, namespace)
 	case "StatefulSet":
 		return getStatefulSet(ai, name, namespace)
+	case DaemonSetKind:
+		return getDaemonSet(ai, name, namespace)
 	case "":
-		for _, wk := range []string{"Deployment", "ReplicaSet", "StatefulSet"} {
+		for _, wk := range []string{"Deployment", "ReplicaSet", "StatefulSet", DaemonSetKind} {
 			if obj, err = getWorkload(ai, name, namespace, wk); err == nil {
 				return obj, nil
 			}
@@ -111,6 +154,14 @@ func getStatefulSet(ai apps.Interface, name, namespace string) (k8sapi.Workload,
 	return k8sapi.StatefulSet(ss), nil
 }
 
+func getDaemonSet(ai apps.Interface, name, namespace string) (k8sapi.Workload, error) {
+	ds, err := ai.DaemonSets().Lister().DaemonSets(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return DaemonSet(ds), nil
+}
+
 func findServicesForPod(ctx context.Context, pod *core.PodTemplateSpec, svcName string) ([]k8sapi.Object, error) {
 	switch {
 	case svcName != "":
@@ -280,7 +331,11 @@ func IsPodRunning(pod *core.Pod) bool {
 
 // AgentContainer returns the pod's traffic-agent container, or nil if the pod doesn't have a traffic-agent.
 func AgentContainer(pod *core.Pod) *core.Container {
-	return containerByName(agentconfig.ContainerName, pod.Spec.Containers)
+	if cn := containerByName(agentconfig.ContainerName, pod.Spec.Containers); cn != nil {
+		return cn
+	}
+	// The agent might be injected as a native sidecar.
+	return containerByName(agentconfig.ContainerName, pod.Spec.InitContainers)
 }
 
 // InitContainer returns the pod's tel-agent-init init-container, or nil if the pod doesn't have a tel-agent-init.
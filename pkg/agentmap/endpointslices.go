@@ -0,0 +1,100 @@
+package agentmap
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// findEndpointSlices returns all EndpointSlices that belong to the given service. A service
+// may have many slices, because each slice holds at most 100 endpoints by default. The slices
+// are listed using a direct API call, because they are only needed in rare cases.
+func findEndpointSlices(ctx context.Context, svc *core.Service) ([]*discovery.EndpointSlice, error) {
+	sel := labels.SelectorFromSet(labels.Set{discovery.LabelServiceName: svc.Name})
+	dlog.Debugf(ctx, "fetching endpoint slices for service %s.%s using direct API call", svc.Name, svc.Namespace)
+	l, err := k8sapi.GetK8sInterface(ctx).DiscoveryV1().EndpointSlices(svc.Namespace).List(ctx, meta.ListOptions{LabelSelector: sel.String()})
+	if err != nil {
+		return nil, err
+	}
+	ess := make([]*discovery.EndpointSlice, len(l.Items))
+	for i := range l.Items {
+		ess[i] = &l.Items[i]
+	}
+	return ess, nil
+}
+
+// resolveTargetPortFromEndpointSlices uses the EndpointSlices of the given service to find the
+// port number that a symbolic targetPort of the given service port resolves to. This is necessary
+// when the port name isn't declared by the containers in the pod template, e.g. because the
+// template has been modified or the port is declared by an injected container.
+//
+// Endpoints that are terminating are only considered when no other endpoints exist, because a
+// terminating endpoint may stem from a pod with an outdated port declaration. Slices are only
+// trusted when their address type matches an IP family, so FQDN slices are ignored.
+//
+// The returned ServicePort has a numeric targetPort. The second return value is false if no
+// port could be resolved.
+func resolveTargetPortFromEndpointSlices(ctx context.Context, svc *core.Service, port *core.ServicePort) (*core.ServicePort, bool) {
+	if port.TargetPort.Type != intstr.String {
+		return nil, false
+	}
+	ess, err := findEndpointSlices(ctx, svc)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to list endpoint slices for service %s.%s: %v", svc.Name, svc.Namespace, err)
+		return nil, false
+	}
+	proto := port.Protocol
+	if proto == "" {
+		proto = core.ProtocolTCP
+	}
+
+	var terminatingPort int32
+	for _, es := range ess {
+		if es.AddressType == discovery.AddressTypeFQDN {
+			continue
+		}
+		for _, ep := range es.Ports {
+			if ep.Port == nil || ep.Name == nil || *ep.Name != port.Name {
+				continue
+			}
+			epProto := core.ProtocolTCP
+			if ep.Protocol != nil {
+				epProto = *ep.Protocol
+			}
+			if epProto != proto {
+				continue
+			}
+			for ei := range es.Endpoints {
+				if isTerminating(&es.Endpoints[ei]) {
+					if terminatingPort == 0 {
+						terminatingPort = *ep.Port
+					}
+					continue
+				}
+				return numericPort(port, *ep.Port), true
+			}
+		}
+	}
+	if terminatingPort != 0 {
+		return numericPort(port, terminatingPort), true
+	}
+	return nil, false
+}
+
+func isTerminating(ep *discovery.Endpoint) bool {
+	c := ep.Conditions
+	return c.Terminating != nil && *c.Terminating
+}
+
+func numericPort(port *core.ServicePort, pn int32) *core.ServicePort {
+	np := *port
+	np.TargetPort = intstr.FromInt32(pn)
+	return &np
+}
@@ -0,0 +1,104 @@
+package agentmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+func Test_resolveTargetPortFromEndpointSlices(t *testing.T) {
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+	}
+	slice := func(name string, terminating bool, ports ...discovery.EndpointPort) *discovery.EndpointSlice {
+		return &discovery.EndpointSlice{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{discovery.LabelServiceName: "echo"},
+			},
+			AddressType: discovery.AddressTypeIPv4,
+			Endpoints: []discovery.Endpoint{{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discovery.EndpointConditions{Terminating: ptr.To(terminating)},
+			}},
+			Ports: ports,
+		}
+	}
+	port := func(name string, proto core.Protocol, number int32) discovery.EndpointPort {
+		return discovery.EndpointPort{Name: ptr.To(name), Protocol: ptr.To(proto), Port: ptr.To(number)}
+	}
+	svcPort := &core.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromString("web")}
+
+	tests := []struct {
+		name   string
+		slices []*discovery.EndpointSlice
+		port   *core.ServicePort
+		want   int32
+	}{
+		{
+			name:   "single slice",
+			slices: []*discovery.EndpointSlice{slice("a", false, port("http", core.ProtocolTCP, 8080))},
+			port:   svcPort,
+			want:   8080,
+		},
+		{
+			name: "prefers non-terminating",
+			slices: []*discovery.EndpointSlice{
+				slice("a", true, port("http", core.ProtocolTCP, 8080)),
+				slice("b", false, port("http", core.ProtocolTCP, 8081)),
+			},
+			port: svcPort,
+			want: 8081,
+		},
+		{
+			name:   "only terminating",
+			slices: []*discovery.EndpointSlice{slice("a", true, port("http", core.ProtocolTCP, 8080))},
+			port:   svcPort,
+			want:   8080,
+		},
+		{
+			name:   "protocol mismatch",
+			slices: []*discovery.EndpointSlice{slice("a", false, port("http", core.ProtocolUDP, 8080))},
+			port:   svcPort,
+		},
+		{
+			name:   "name mismatch",
+			slices: []*discovery.EndpointSlice{slice("a", false, port("grpc", core.ProtocolTCP, 8080))},
+			port:   svcPort,
+		},
+		{
+			name:   "numeric target port",
+			slices: []*discovery.EndpointSlice{slice("a", false, port("http", core.ProtocolTCP, 8080))},
+			port:   &core.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromInt32(9090)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := fake.NewSimpleClientset()
+			ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+			for _, es := range tt.slices {
+				_, err := cs.DiscoveryV1().EndpointSlices(es.Namespace).Create(ctx, es, meta.CreateOptions{})
+				require.NoError(t, err)
+			}
+			rp, ok := resolveTargetPortFromEndpointSlices(ctx, svc, tt.port)
+			if tt.want == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, intstr.FromInt32(tt.want), rp.TargetPort)
+			assert.Equal(t, tt.port.Name, rp.Name)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package agentmap
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -11,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation"
 
+	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
@@ -40,15 +43,27 @@ type BasicGeneratorConfig struct {
 	AgentPort           uint16
 	APIPort             uint16
 	TracingPort         uint16
+	HealthPort          uint16
+	MetricsPort         uint16
+	HealthProbe         string
+	Probes              *agentconfig.Probes
 	QualifiedAgentImage string
 	ManagerNamespace    string
 	LogLevel            string
+	LogFormat           string
 	InitResources       *core.ResourceRequirements
 	Resources           *core.ResourceRequirements
 	PullPolicy          string
 	PullSecrets         []core.LocalObjectReference
 	AppProtocolStrategy k8sapi.AppProtocolStrategy
 	SecurityContext     *core.SecurityContext
+	NativeSidecar       bool
+	Capabilities        agentconfig.Capabilities
+	InitMode            agentconfig.InitMode
+	Volumes             *agentconfig.VolumeSettings
+	ServiceAccountToken *agentconfig.ServiceAccountToken
+	PortAllocation      *agentconfig.PortAllocation
+	TunnelCompression   string
 }
 
 func (cfg *BasicGeneratorConfig) Generate(
@@ -61,7 +76,12 @@ func (cfg *BasicGeneratorConfig) Generate(
 
 	pod := wl.GetPodTemplate()
 	pod.Namespace = wl.GetNamespace()
+	hostPorts, err := agentconfig.HostNetworkPorts(pod)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+	}
 	cns := pod.Spec.Containers
+	healthPort := cfg.HealthPort
 	for i := range cns {
 		cn := &cns[i]
 		if cn.Name == agentconfig.ContainerName {
@@ -69,34 +89,60 @@ func (cfg *BasicGeneratorConfig) Generate(
 		}
 		ports := cn.Ports
 		for pi := range ports {
-			if ports[pi].ContainerPort == int32(cfg.AgentPort) {
-				return nil, fmt.Errorf(
-					"the %s.%s pod container %s is exposing the same port (%d) as the %s sidecar",
-					pod.Name, pod.Namespace, cn.Name, cfg.AgentPort, agentconfig.ContainerName)
+			if ports[pi].ContainerPort == int32(healthPort) {
+				dlog.Warnf(ctx,
+					"the %s.%s pod container %s is exposing the same port (%d) as the %s health server. Falling back to exec readiness probe",
+					pod.Name, pod.Namespace, cn.Name, healthPort, agentconfig.ContainerName)
+				healthPort = 0
 			}
 		}
 	}
+	portAllocation := cfg.portAllocation(hostPorts)
+	var reserved []uint16
+	if hostPorts == nil {
+		reserved = []uint16{cfg.APIPort, cfg.TracingPort, healthPort, cfg.MetricsPort}
+	}
+	allocator := agentconfig.NewPortAllocator(portAllocation, wl.GetNamespace()+"/"+wl.GetName(), pod, reserved...)
 
-	svcs, err := findServicesForPod(ctx, pod, pod.Annotations[ServiceNameAnnotation])
+	ro, err := agentconfig.GetResourceOverrides(pod.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	resources, initResources, err := ro.Apply(cfg.Resources, cfg.InitResources)
+	if err != nil {
+		return nil, err
+	}
+	securityContext, err := agentconfig.GetSecurityContext(pod.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	if securityContext == nil {
+		securityContext = cfg.SecurityContext
+	}
+	pullSecrets, err := agentconfig.GetPullSecrets(pod.Annotations)
 	if err != nil {
 		return nil, err
 	}
 
-	var ccs []*agentconfig.Container
-	pns := make(map[int32]uint16)
-	portNumber := func(cnPort int32) uint16 {
-		if p, ok := pns[cnPort]; ok {
-			// Port already mapped. Reuse that mapping
-			return p
-		}
-		p := cfg.AgentPort + uint16(len(pns))
-		pns[cnPort] = p
-		return p
+	initMode, err := resolveInitMode(ctx, pod, cfg.InitMode)
+	if err != nil {
+		return nil, err
+	}
+	if hostPorts != nil && initMode != agentconfig.InitModeProxy {
+		// The iptables rules of the init-container would be applied to the node.
+		dlog.Debugf(ctx, "using agent init mode %q because pod %s.%s uses hostNetwork", agentconfig.InitModeProxy, pod.Name, pod.Namespace)
+		initMode = agentconfig.InitModeProxy
 	}
 
+	svcs, err := findServicesForPod(ctx, pod, pod.Annotations[ServiceNameAnnotation])
+	if err != nil {
+		return nil, err
+	}
+
+	var ccs []*agentconfig.Container
 	for _, svc := range svcs {
 		svcImpl, _ := k8sapi.ServiceImpl(svc)
-		if ccs, err = appendAgentContainerConfigs(ctx, svcImpl, pod, portNumber, ccs, existingConfig, cfg.AppProtocolStrategy); err != nil {
+		if ccs, err = appendAgentContainerConfigs(ctx, svcImpl, pod, allocator.Allocate, ccs, existingConfig, cfg.AppProtocolStrategy, initMode); err != nil {
 			return nil, err
 		}
 	}
@@ -104,36 +150,94 @@ func (cfg *BasicGeneratorConfig) Generate(
 		return nil, fmt.Errorf("found no service with a port that matches a container in pod %s.%s", pod.Name, pod.Namespace)
 	}
 
+	apiPort, tracingPort, metricsPort := cfg.APIPort, cfg.TracingPort, cfg.MetricsPort
+	if hostPorts != nil {
+		if err = allocateHostPorts(hostPorts, allocator.Allocated(), &apiPort, &tracingPort, &healthPort, &metricsPort); err != nil {
+			return nil, fmt.Errorf("%s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+		}
+	}
+
 	ag := &agentconfig.Sidecar{
-		AgentImage:      cfg.QualifiedAgentImage,
-		AgentName:       wl.GetName(),
-		LogLevel:        cfg.LogLevel,
-		Namespace:       wl.GetNamespace(),
-		WorkloadName:    wl.GetName(),
-		WorkloadKind:    wl.GetKind(),
-		ManagerHost:     ManagerAppName + "." + cfg.ManagerNamespace,
-		ManagerPort:     cfg.ManagerPort,
-		APIPort:         cfg.APIPort,
-		TracingPort:     cfg.TracingPort,
-		Containers:      ccs,
-		InitResources:   cfg.InitResources,
-		Resources:       cfg.Resources,
-		PullPolicy:      cfg.PullPolicy,
-		PullSecrets:     cfg.PullSecrets,
-		SecurityContext: cfg.SecurityContext,
+		AgentImage:          cfg.QualifiedAgentImage,
+		AgentName:           wl.GetName(),
+		LogLevel:            cfg.LogLevel,
+		LogFormat:           cfg.LogFormat,
+		Namespace:           wl.GetNamespace(),
+		WorkloadName:        wl.GetName(),
+		WorkloadKind:        wl.GetKind(),
+		ManagerHost:         ManagerAppName + "." + cfg.ManagerNamespace,
+		ManagerPort:         cfg.ManagerPort,
+		APIPort:             apiPort,
+		TracingPort:         tracingPort,
+		HealthPort:          healthPort,
+		MetricsPort:         metricsPort,
+		HealthProbe:         cfg.HealthProbe,
+		Probes:              cfg.Probes,
+		Containers:          ccs,
+		InitResources:       initResources,
+		Resources:           resources,
+		PullPolicy:          cfg.PullPolicy,
+		PullSecrets:         agentconfig.MergePullSecrets(cfg.PullSecrets, pullSecrets),
+		SecurityContext:     securityContext,
+		NativeSidecar:       cfg.NativeSidecar,
+		Capabilities:        cfg.Capabilities,
+		InitMode:            initMode,
+		Volumes:             cfg.Volumes,
+		ServiceAccountToken: cfg.ServiceAccountToken,
+		TunnelCompression:   cfg.TunnelCompression,
+		PortAllocation:      cfg.PortAllocation,
 	}
 	ag.RecordInSpan(span)
 	return ag, nil
 }
 
+// portAllocation returns the PortAllocation to use for the agent ports. The range of a pod that uses the
+// host network is always allocated sequentially, because allocateHostPorts assigns the ports that follow
+// the agent ports. The default range starts at the AgentPort.
+func (cfg *BasicGeneratorConfig) portAllocation(hostPorts *agentconfig.PortRange) *agentconfig.PortAllocation {
+	if hostPorts != nil {
+		return &agentconfig.PortAllocation{Range: hostPorts, Strategy: agentconfig.PortAllocationSequential}
+	}
+	pa := agentconfig.PortAllocation{Strategy: agentconfig.PortAllocationSequential}
+	if cfg.PortAllocation != nil {
+		pa = *cfg.PortAllocation
+		if pa.Strategy == "" {
+			pa.Strategy = agentconfig.PortAllocationSequential
+		}
+	}
+	if pa.Range == nil {
+		pa.Range = &agentconfig.PortRange{First: cfg.AgentPort, Last: math.MaxUint16}
+	}
+	return &pa
+}
+
+// allocateHostPorts allocates the ports that the agent listens to, other than the agent ports that are
+// already allocated from the start of the given range. The given ports are updated in place. A port that
+// is zero is disabled and stays zero.
+func allocateHostPorts(hostPorts *agentconfig.PortRange, agentPorts int, ports ...*uint16) error {
+	next := int(hostPorts.First) + agentPorts
+	for _, pp := range ports {
+		if *pp != 0 {
+			*pp = uint16(next)
+			next++
+		}
+	}
+	if need := next - int(hostPorts.First); need > hostPorts.Size() {
+		return fmt.Errorf("the host network port range %s declared by annotation %s is too small; the %s needs %d ports",
+			hostPorts, agentconfig.HostNetworkPortsAnnotation, agentconfig.ContainerName, need)
+	}
+	return nil
+}
+
 func appendAgentContainerConfigs(
 	ctx context.Context,
 	svc *core.Service,
 	pod *core.PodTemplateSpec,
-	portNumber func(int32) uint16,
+	portNumber func(int32) (uint16, error),
 	ccs []*agentconfig.Container,
 	existingConfig agentconfig.SidecarExt,
 	aps k8sapi.AppProtocolStrategy,
+	initMode agentconfig.InitMode,
 ) ([]*agentconfig.Container, error) {
 	portNameOrNumber := pod.Annotations[ServicePortAnnotation]
 	ports, err := filterServicePorts(svc, portNameOrNumber)
@@ -141,12 +245,34 @@ func appendAgentContainerConfigs(
 		return nil, err
 	}
 	ignoredVolumeMounts := agentconfig.GetIgnoredVolumeMounts(pod.ObjectMeta.Annotations)
+	cns := pod.Spec.Containers
+	if ignoredContainers := agentconfig.GetIgnoredContainers(pod.ObjectMeta.Annotations); len(ignoredContainers) > 0 {
+		cns = slices.DeleteFunc(slices.Clone(cns), func(cn core.Container) bool {
+			return ignoredContainers.IsContainerIgnored(cn.Name)
+		})
+	}
 nextSvcPort:
 	for _, port := range ports {
-		cn, i := findContainerMatchingPort(&port, pod.Spec.Containers)
+		cn, i := findContainerMatchingPort(&port, cns)
+		if cn == nil && port.TargetPort.Type == intstr.String {
+			// The symbolic port isn't declared by any container in the pod template. The
+			// EndpointSlices of the service might still know what number it resolves to.
+			if rp, ok := resolveTargetPortFromEndpointSlices(ctx, svc, &port); ok {
+				dlog.Debugf(ctx, "targetPort %q of service %s.%s resolved to %d using endpoint slices",
+					port.TargetPort.StrVal, svc.Name, svc.Namespace, rp.TargetPort.IntVal)
+				port = *rp
+				cn, i = findContainerMatchingPort(&port, cns)
+			}
+		}
 		if cn == nil || cn.Name == agentconfig.ContainerName {
 			continue
 		}
+		if port.TargetPort.Type == intstr.Int && initMode == agentconfig.InitModeProxy {
+			// Traffic to a numeric port can only be routed to the agent using iptables.
+			dlog.Warnf(ctx, "port %d of service %s.%s cannot be intercepted because its targetPort is numeric and the agent init mode is %q",
+				port.Port, svc.Name, svc.Namespace, initMode)
+			continue
+		}
 		var appPort core.ContainerPort
 		if i < 0 {
 			// Can only happen if the service port is numeric, so it's safe to use TargetPort.IntVal here
@@ -158,6 +284,10 @@ nextSvcPort:
 			appPort = cn.Ports[i]
 		}
 
+		agentPort, err := portNumber(appPort.ContainerPort)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", pod.Name, pod.Namespace, err)
+		}
 		ic := &agentconfig.Intercept{
 			ServiceName:       svc.Name,
 			ServiceUID:        svc.UID,
@@ -166,7 +296,7 @@ nextSvcPort:
 			TargetPortNumeric: port.TargetPort.Type == intstr.Int,
 			Protocol:          port.Protocol,
 			AppProtocol:       k8sapi.GetAppProto(ctx, aps, &port),
-			AgentPort:         portNumber(appPort.ContainerPort),
+			AgentPort:         agentPort,
 			ContainerPortName: appPort.Name,
 			ContainerPort:     uint16(appPort.ContainerPort),
 		}
@@ -203,6 +333,7 @@ nextSvcPort:
 			EnvPrefix:  CapsBase26(uint64(len(ccs))) + "_",
 			MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
 			Mounts:     mounts,
+			SecretEnv:  secretEnv(cn),
 			Intercepts: []*agentconfig.Intercept{ic},
 			Replace:    replaceContainer,
 		})
@@ -210,6 +341,23 @@ nextSvcPort:
 	return ccs, nil
 }
 
+// secretEnv returns the names of the environment variables of the given container that are sourced
+// from Secrets. A Secret declared using envFrom is represented by its prefix followed by "*".
+func secretEnv(cn *core.Container) []string {
+	var names []string
+	for _, e := range cn.Env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			names = append(names, e.Name)
+		}
+	}
+	for _, e := range cn.EnvFrom {
+		if e.SecretRef != nil {
+			names = append(names, e.Prefix+"*")
+		}
+	}
+	return names
+}
+
 // filterServicePorts iterates through a list of ports in a service and
 // only returns the ports that match the given nameOrNumber. All ports will
 // be returned if nameOrNumber is equal to the empty string.
@@ -0,0 +1,70 @@
+package agentmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestAllocateHostPorts(t *testing.T) {
+	r := &agentconfig.PortRange{First: 21000, Last: 21003}
+
+	apiPort, tracingPort, healthPort := uint16(0), uint16(15766), uint16(8081)
+	require.NoError(t, allocateHostPorts(r, 2, &apiPort, &tracingPort, &healthPort))
+	assert.Equal(t, uint16(0), apiPort, "disabled ports stay disabled")
+	assert.Equal(t, uint16(21002), tracingPort)
+	assert.Equal(t, uint16(21003), healthPort)
+
+	apiPort, tracingPort, healthPort = 9901, 15766, 8081
+	assert.ErrorContains(t, allocateHostPorts(r, 2, &apiPort, &tracingPort, &healthPort), "needs 5 ports")
+}
+
+func TestBasicGeneratorConfig_portAllocation(t *testing.T) {
+	cfg := &BasicGeneratorConfig{AgentPort: 9900}
+	assert.Equal(t, &agentconfig.PortAllocation{
+		Range:    &agentconfig.PortRange{First: 9900, Last: 65535},
+		Strategy: agentconfig.PortAllocationSequential,
+	}, cfg.portAllocation(nil))
+
+	cfg.PortAllocation = &agentconfig.PortAllocation{Strategy: agentconfig.PortAllocationHash}
+	pa := cfg.portAllocation(nil)
+	assert.Equal(t, agentconfig.PortAllocationHash, pa.Strategy)
+	assert.Equal(t, &agentconfig.PortRange{First: 9900, Last: 65535}, pa.Range)
+	assert.Nil(t, cfg.PortAllocation.Range, "configured allocation is not modified")
+
+	hostPorts := &agentconfig.PortRange{First: 21000, Last: 21009}
+	assert.Equal(t, &agentconfig.PortAllocation{
+		Range:    hostPorts,
+		Strategy: agentconfig.PortAllocationSequential,
+	}, cfg.portAllocation(hostPorts), "host network ports are allocated sequentially")
+}
+
+func Test_secretEnv(t *testing.T) {
+	cn := &core.Container{
+		Env: []core.EnvVar{
+			{Name: "PLAIN", Value: "plain"},
+			{Name: "FIELD", ValueFrom: &core.EnvVarSource{FieldRef: &core.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+			{Name: "PASSWORD", ValueFrom: &core.EnvVarSource{SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: "db"},
+				Key:                  "password",
+			}}},
+		},
+		EnvFrom: []core.EnvFromSource{
+			{ConfigMapRef: &core.ConfigMapEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "cfg"}}},
+			{Prefix: "API_", SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "api"}}},
+		},
+	}
+	assert.Equal(t, []string{"PASSWORD", "API_*"}, secretEnv(cn))
+	assert.Nil(t, secretEnv(&core.Container{}))
+
+	// A Secret without a prefix is represented by a lone "*", which the agent replaces by the names of
+	// the variables that it receives from the Secret.
+	cn = &core.Container{EnvFrom: []core.EnvFromSource{
+		{SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "db"}}},
+	}}
+	assert.Equal(t, []string{"*"}, secretEnv(cn))
+}
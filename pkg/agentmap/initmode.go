@@ -0,0 +1,39 @@
+package agentmap
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// resolveInitMode determines the InitMode to use for the given pod template. The InitModeAnnotation of
+// the pod template has the highest priority, followed by the InitMode of the pod's namespace, and finally
+// the given default. An empty InitMode means InitModeIPTables, which keeps the configs of workloads that
+// use the default mode unchanged.
+func resolveInitMode(ctx context.Context, pod *core.PodTemplateSpec, dflt agentconfig.InitMode) (agentconfig.InitMode, error) {
+	m, err := agentconfig.GetInitMode(pod.Annotations)
+	if err != nil {
+		return "", err
+	}
+	if m == "" {
+		ns, err := k8sapi.GetK8sInterface(ctx).CoreV1().Namespaces().Get(ctx, pod.Namespace, meta.GetOptions{})
+		if err != nil {
+			// The traffic-manager might not be permitted to read namespaces, so this isn't fatal.
+			dlog.Debugf(ctx, "unable to get namespace %s to determine the agent init mode: %v", pod.Namespace, err)
+		} else if m, err = agentconfig.NamespaceInitMode(ns); err != nil {
+			return "", err
+		}
+	}
+	if m == "" {
+		m = dflt
+	}
+	if m == agentconfig.InitModeIPTables {
+		m = ""
+	}
+	return m, nil
+}
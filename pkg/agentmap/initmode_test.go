@@ -0,0 +1,86 @@
+package agentmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func Test_resolveInitMode(t *testing.T) {
+	namespace := func(annotations, labels map[string]string) *core.Namespace {
+		return &core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "default", Annotations: annotations, Labels: labels}}
+	}
+	tests := []struct {
+		name    string
+		ns      *core.Namespace
+		pod     map[string]string
+		dflt    agentconfig.InitMode
+		want    agentconfig.InitMode
+		wantErr bool
+	}{
+		{
+			name: "default",
+			dflt: agentconfig.InitModeIPTables,
+		},
+		{
+			name: "configured default",
+			dflt: agentconfig.InitModeProxy,
+			want: agentconfig.InitModeProxy,
+		},
+		{
+			name: "restricted namespace",
+			ns:   namespace(nil, map[string]string{agentconfig.PodSecurityEnforceLabel: "restricted"}),
+			want: agentconfig.InitModeProxy,
+		},
+		{
+			name: "privileged namespace",
+			ns:   namespace(nil, map[string]string{agentconfig.PodSecurityEnforceLabel: "privileged"}),
+			dflt: agentconfig.InitModeProxy,
+			want: agentconfig.InitModeProxy,
+		},
+		{
+			name: "namespace annotation overrides label",
+			ns: namespace(
+				map[string]string{agentconfig.InitModeAnnotation: "iptables"},
+				map[string]string{agentconfig.PodSecurityEnforceLabel: "baseline"}),
+			dflt: agentconfig.InitModeProxy,
+		},
+		{
+			name: "pod annotation overrides namespace",
+			ns:   namespace(map[string]string{agentconfig.InitModeAnnotation: "iptables"}, nil),
+			pod:  map[string]string{agentconfig.InitModeAnnotation: "proxy"},
+			want: agentconfig.InitModeProxy,
+		},
+		{
+			name:    "invalid pod annotation",
+			pod:     map[string]string{agentconfig.InitModeAnnotation: "nat"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := fake.NewSimpleClientset()
+			ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+			if tt.ns != nil {
+				_, err := cs.CoreV1().Namespaces().Create(ctx, tt.ns, meta.CreateOptions{})
+				require.NoError(t, err)
+			}
+			pod := &core.PodTemplateSpec{ObjectMeta: meta.ObjectMeta{Namespace: "default", Annotations: tt.pod}}
+			m, err := resolveInitMode(ctx, pod, tt.dflt)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m)
+		})
+	}
+}
@@ -5,16 +5,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"strconv"
 
+	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
 )
 
 type Permissions fs.FileMode
 
+// TempSuffix is the suffix of the temporary files that are written and then renamed when an entry is saved.
+// Code that lists the entries of a cache directory must ignore files with this suffix.
+const TempSuffix = ".tmp"
+
 const (
 	Public  Permissions = 0o644
 	Private Permissions = 0o600
@@ -34,7 +42,34 @@ func SaveToUserCache(ctx context.Context, object any, file string, perm Permissi
 	if err := dos.MkdirAll(ctx, dir, 0o755); err != nil {
 		return err
 	}
-	return dos.WriteFile(ctx, fullFilePath, jsonContent, (fs.FileMode(perm)))
+	return writeFileAtomic(ctx, fullFilePath, jsonContent, fs.FileMode(perm))
+}
+
+// writeFileAtomic writes the data to a temporary file in the same directory as the given file, syncs it, and
+// then renames it to the given file. A reader will therefore see either the old or the new content, and never
+// a partially written file, even when the writer crashes.
+func writeFileAtomic(ctx context.Context, path string, data []byte, perm fs.FileMode) (err error) {
+	tmpPath := fmt.Sprintf("%s.%s%s", path, strconv.FormatUint(rand.Uint64(), 36), TempSuffix)
+	f, err := dos.OpenFile(ctx, tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = dos.Remove(ctx, tmpPath)
+		}
+	}()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = dos.Rename(ctx, tmpPath, path)
+	}
+	return err
 }
 
 func LoadFromUserCache(ctx context.Context, dest any, file string) error {
@@ -44,13 +79,37 @@ func LoadFromUserCache(ctx context.Context, dest any, file string) error {
 	if err != nil {
 		return err
 	}
+	return parseEntry(ctx, path, jsonContent, dest)
+}
+
+// parseEntry unmarshals the JSON content of the given path into dest. A file that isn't valid JSON is removed
+// and reported as non-existent. Other errors, such as a type mismatch between the JSON and dest, are returned
+// unchanged.
+func parseEntry(ctx context.Context, path string, jsonContent []byte, dest any) error {
 	if err := json.Unmarshal(jsonContent, &dest); err != nil {
-		return fmt.Errorf("failed to parse JSON from file %s: %w", path, err)
+		var se *json.SyntaxError
+		if !(errors.As(err, &se) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			return err
+		}
+		// The file is corrupt, most likely because it was written by an older version that didn't write
+		// atomically and crashed while doing so. It's useless, so it is removed and reported as non-existent.
+		dlog.Warnf(ctx, "removing %s because its JSON can't be parsed: %v", path, err)
+		if rmErr := dos.Remove(ctx, path); rmErr != nil && !os.IsNotExist(rmErr) {
+			return fmt.Errorf("failed to parse JSON from file %s: %w", path, err)
+		}
+		return &fs.PathError{Op: "load", Path: path, Err: fs.ErrNotExist}
 	}
 	return nil
 }
 
+// DeleteFromUserCache deletes the given entry while holding its lock, so that it isn't resurrected by a
+// concurrent UpdateUserCache. It is not an error if the entry doesn't exist.
 func DeleteFromUserCache(ctx context.Context, file string) error {
+	unlock, err := LockUserCache(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	ctx = dos.WithLockedFs(ctx)
 	if err := dos.Remove(ctx, filepath.Join(filelocation.AppUserCacheDir(ctx), file)); err != nil && !os.IsNotExist(err) {
 		return err
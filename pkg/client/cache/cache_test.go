@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+func TestLoadFromUserCache_unparsable(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	dir := filelocation.AppUserCacheDir(ctx)
+	var e sensitiveEntry
+
+	// A truncated file is removed and reported as non-existent.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "truncated.json"), []byte(`{"token":"se`), 0o600))
+	assert.ErrorIs(t, LoadFromUserCache(ctx, &e, "truncated.json"), fs.ErrNotExist)
+	assert.NoFileExists(t, filepath.Join(dir, "truncated.json"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "garbage.json"), []byte(`{"token":}`), 0o600))
+	assert.ErrorIs(t, LoadFromUserCache(ctx, &e, "garbage.json"), fs.ErrNotExist)
+	assert.NoFileExists(t, filepath.Join(dir, "garbage.json"))
+
+	// Valid JSON that doesn't match the destination is returned unchanged, and the file is kept.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mismatch.json"), []byte(`{"token":42}`), 0o600))
+	err := LoadFromUserCache(ctx, &e, "mismatch.json")
+	var te *json.UnmarshalTypeError
+	assert.ErrorAs(t, err, &te)
+	assert.NotErrorIs(t, err, fs.ErrNotExist)
+	assert.FileExists(t, filepath.Join(dir, "mismatch.json"))
+}
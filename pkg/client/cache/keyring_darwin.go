@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/datawire/dlib/dexec"
+)
+
+const (
+	// exitItemNotFound is the exit code of the security command when the item can't be found.
+	exitItemNotFound = 44
+
+	// exitDuplicateItem is the exit code of the security command when the item already exists.
+	exitDuplicateItem = 45
+)
+
+// The key is stored as a generic password in the login keychain.
+func loadKey(ctx context.Context) ([]byte, error) {
+	cmd := dexec.CommandContext(ctx, "security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	cmd.DisableLogging = true
+	out, err := cmd.Output()
+	if err != nil {
+		var ee *dexec.ExitError
+		if errors.As(err, &ee) && ee.ExitCode() == exitItemNotFound {
+			return nil, errKeyNotFound
+		}
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
+
+// storeKey adds the key to the keychain unless a key exists already. The existing key isn't replaced,
+// because it might have been stored by another process that is using it. The command is passed on stdin
+// to the interactive mode of the security command, so that the key isn't visible in the process list.
+func storeKey(ctx context.Context, key []byte) error {
+	cmd := dexec.CommandContext(ctx, "security", "-i")
+	cmd.DisableLogging = true
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -s %s -a %s -l \"Telepresence cache key\" -w %s\n",
+		keyringService, keyringAccount, base64.StdEncoding.EncodeToString(key)))
+	if err := cmd.Run(); err != nil {
+		var ee *dexec.ExitError
+		if errors.As(err, &ee) && ee.ExitCode() == exitDuplicateItem {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
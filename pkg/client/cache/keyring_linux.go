@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The key is stored using the freedesktop.org Secret Service API, which is implemented by GNOME Keyring,
+// KWallet, and KeePassXC.
+const (
+	secretsName              = "org.freedesktop.secrets"
+	secretsPath              = "/org/freedesktop/secrets"
+	secretsDefaultCollection = "/org/freedesktop/secrets/aliases/default"
+	secretsService           = "org.freedesktop.Secret.Service"
+	secretsCollection        = "org.freedesktop.Secret.Collection"
+	secretsItem              = "org.freedesktop.Secret.Item"
+	secretsSession           = "org.freedesktop.Secret.Session"
+)
+
+var errKeyringLocked = errors.New("the keyring is locked") //nolint:gochecknoglobals // constant
+
+// secret is the Secret struct of the Secret Service API.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+func withSecretService(ctx context.Context, f func(*dbus.Conn, dbus.ObjectPath) error) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+	defer conn.Close()
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = conn.Object(secretsName, secretsPath).CallWithContext(ctx, secretsService+".OpenSession", 0, "plain", dbus.MakeVariant("")).
+		Store(&output, &session)
+	if err != nil {
+		return fmt.Errorf("failed to open secret service session: %w", err)
+	}
+	defer conn.Object(secretsName, session).CallWithContext(ctx, secretsSession+".Close", 0)
+	return f(conn, session)
+}
+
+func keyAttributes() map[string]string {
+	return map[string]string{"service": keyringService, "account": keyringAccount}
+}
+
+func loadKey(ctx context.Context) (key []byte, err error) {
+	err = withSecretService(ctx, func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		var unlocked, locked []dbus.ObjectPath
+		err := conn.Object(secretsName, secretsPath).CallWithContext(ctx, secretsService+".SearchItems", 0, keyAttributes()).
+			Store(&unlocked, &locked)
+		if err != nil {
+			return err
+		}
+		if len(unlocked) == 0 {
+			if len(locked) > 0 {
+				return errKeyringLocked
+			}
+			return errKeyNotFound
+		}
+		var s secret
+		if err = conn.Object(secretsName, unlocked[0]).CallWithContext(ctx, secretsItem+".GetSecret", 0, session).Store(&s); err != nil {
+			return err
+		}
+		key = s.Value
+		return nil
+	})
+	return key, err
+}
+
+// storeKey adds the key to the default collection unless a key exists already. The existing key isn't replaced,
+// because it might have been stored by another process that is using it.
+func storeKey(ctx context.Context, key []byte) error {
+	return withSecretService(ctx, func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		var unlocked, locked []dbus.ObjectPath
+		err := conn.Object(secretsName, secretsPath).CallWithContext(ctx, secretsService+".SearchItems", 0, keyAttributes()).
+			Store(&unlocked, &locked)
+		if err != nil {
+			return err
+		}
+		if len(unlocked) > 0 {
+			return nil
+		}
+		if len(locked) > 0 {
+			return errKeyringLocked
+		}
+		props := map[string]dbus.Variant{
+			secretsItem + ".Label":      dbus.MakeVariant("Telepresence cache key"),
+			secretsItem + ".Attributes": dbus.MakeVariant(keyAttributes()),
+		}
+		var item, prompt dbus.ObjectPath
+		err = conn.Object(secretsName, secretsDefaultCollection).CallWithContext(ctx, secretsCollection+".CreateItem", 0,
+			props, secret{Session: session, Value: key, ContentType: "application/octet-stream"}, false).
+			Store(&item, &prompt)
+		if err != nil {
+			return err
+		}
+		if prompt != "/" {
+			// Unlocking the collection requires user interaction.
+			return errKeyringLocked
+		}
+		return nil
+	})
+}
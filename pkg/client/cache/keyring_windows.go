@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+// The key is protected using DPAPI, so that only the current user can decrypt it, and stored in the user cache.
+const keyFile = "cache.key"
+
+func keyPath(ctx context.Context) string {
+	return filepath.Join(filelocation.AppUserCacheDir(ctx), keyFile)
+}
+
+func loadKey(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(keyPath(ctx))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errKeyNotFound
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty key file")
+	}
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	if err = windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data))) //nolint:errcheck // nothing to do about it
+	return bytes.Clone(unsafe.Slice(out.Data, out.Size)), nil
+}
+
+// storeKey writes the key file unless it exists already. The existing key isn't replaced, because it might
+// have been stored by another process that is using it.
+func storeKey(ctx context.Context, key []byte) error {
+	in := windows.DataBlob{Size: uint32(len(key)), Data: &key[0]}
+	var out windows.DataBlob
+	if err := windows.CryptProtectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data))) //nolint:errcheck // nothing to do about it
+	ctx = dos.WithLockedFs(ctx)
+	path := keyPath(ctx)
+	if err := dos.MkdirAll(ctx, filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := dos.OpenFile(ctx, path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	_, err = f.Write(unsafe.Slice(out.Data, out.Size))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = dos.Remove(ctx, path)
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rogpeppe/go-internal/lockedfile"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+// locksDirName is the directory of the lock files. They are kept apart from the entries, so that they don't
+// show up when the entries of a directory are listed or watched.
+const locksDirName = "locks"
+
+// LockUserCache acquires an advisory lock for the given entry that is honored by all processes that use this
+// function, and returns a function that releases it. The lock is held on a separate file, because an entry is
+// replaced when it's saved. The lock is not reentrant, and it should be held briefly.
+//
+// A no-op lock is returned when the file system doesn't support locking, which might be the case when the user
+// cache is mounted into a container.
+func LockUserCache(ctx context.Context, file string) (func(), error) {
+	dir := filepath.Join(filelocation.AppUserCacheDir(ctx), locksDirName)
+	if err := dos.MkdirAll(ctx, dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, strings.ReplaceAll(filepath.ToSlash(file), "/", "_")+".lock")
+
+	// Create the lock file using dos, so that it gets the correct owner when created by a process that runs as root.
+	f, err := dos.OpenFile(ctx, path, os.O_RDONLY|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+	unlock, err := lockedfile.MutexAt(path).Lock()
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			dlog.Debugf(ctx, "unable to lock %s: %v", path, err)
+			return func() {}, nil
+		}
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// UpdateUserCache loads the given entry into dest, calls update, and saves dest if update returns true. The entry
+// is locked during the whole cycle, so that concurrent updates from other processes aren't lost. An error that
+// satisfies os.IsNotExist is returned, and update isn't called, when the entry doesn't exist.
+func UpdateUserCache(ctx context.Context, dest any, file string, perm Permissions, update func() bool) error {
+	unlock, err := LockUserCache(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err = LoadFromUserCache(ctx, dest, file); err != nil {
+		return err
+	}
+	if !update() {
+		return nil
+	}
+	return SaveToUserCache(ctx, dest, file, perm)
+}
+
+// TouchUserCache sets the modification time of the given entry to now while holding its lock.
+func TouchUserCache(ctx context.Context, file string) error {
+	unlock, err := LockUserCache(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	now := time.Now()
+	return os.Chtimes(filepath.Join(filelocation.AppUserCacheDir(ctx), file), now, now)
+}
+
+// DeleteStaleFromUserCache deletes the given entry if it hasn't been modified within the given duration. The age
+// is checked while holding the lock of the entry, so an entry that is updated concurrently is retained. The
+// returned boolean is true if the entry was deleted.
+func DeleteStaleFromUserCache(ctx context.Context, file string, maxAge time.Duration) (bool, error) {
+	unlock, err := LockUserCache(ctx, file)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	ctx = dos.WithLockedFs(ctx)
+	path := filepath.Join(filelocation.AppUserCacheDir(ctx), file)
+	fi, err := dos.Stat(ctx, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return false, err
+	}
+	if time.Since(fi.ModTime()) <= maxAge {
+		return false, nil
+	}
+	if err = dos.Remove(ctx, path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
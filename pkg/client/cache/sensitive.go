@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+const (
+	// keyringService and keyringAccount identify the key that encrypts sensitive entries in the OS keyring.
+	keyringService = "telepresence"
+	keyringAccount = "cache-key"
+
+	sealedVersion = 1
+)
+
+var errKeyNotFound = errors.New("key not found in keyring") //nolint:gochecknoglobals // constant
+
+// sealedEntry is what's stored in the user cache for a sensitive entry when the OS keyring is available.
+type sealedEntry struct {
+	Version int    `json:"tp_sealed"`
+	Data    []byte `json:"data"`
+}
+
+//nolint:gochecknoglobals // the key is retrieved from the OS keyring once it's available
+var cacheKey struct {
+	sync.Mutex
+	key    []byte
+	warned bool
+}
+
+// getCacheKey returns the key that encrypts the sensitive entries. The key is created and stored in the OS
+// keyring if it doesn't exist. Only a key that was retrieved successfully is cached, so a keyring that is
+// temporarily unavailable, e.g. because it is locked, is tried again on the next call.
+func getCacheKey(ctx context.Context) ([]byte, error) {
+	cacheKey.Lock()
+	defer cacheKey.Unlock()
+	if cacheKey.key != nil {
+		return cacheKey.key, nil
+	}
+	key, err := loadKey(ctx)
+	if errors.Is(err, errKeyNotFound) {
+		key = make([]byte, 32)
+		if _, err = rand.Read(key); err == nil {
+			if err = storeKey(ctx, key); err == nil {
+				// Another process might have stored its key at the same time, so use what the keyring has.
+				key, err = loadKey(ctx)
+			}
+		}
+	}
+	if err == nil && len(key) != 32 {
+		err = fmt.Errorf("invalid key length %d", len(key))
+	}
+	if err != nil {
+		if !cacheKey.warned {
+			dlog.Warnf(ctx, "Unable to use the OS keyring, sensitive cache entries will not be encrypted: %v", err)
+			cacheKey.warned = true
+		} else {
+			dlog.Debugf(ctx, "Unable to use the OS keyring: %v", err)
+		}
+		return nil, err
+	}
+	cacheKey.key = key
+	return key, nil
+}
+
+// SaveSensitiveToUserCache is like SaveToUserCache, but the entry is encrypted using a key that is stored in the
+// OS keyring. The entry is saved unencrypted, but readable by the current user only, when the keyring isn't
+// available.
+func SaveSensitiveToUserCache(ctx context.Context, object any, file string) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	key, err := getCacheKey(ctx)
+	if err != nil {
+		return SaveToUserCache(ctx, json.RawMessage(data), file, Private)
+	}
+	sealed, err := seal(key, data, file)
+	if err != nil {
+		return err
+	}
+	return SaveToUserCache(ctx, &sealedEntry{Version: sealedVersion, Data: sealed}, file, Private)
+}
+
+// LoadSensitiveFromUserCache loads an entry that was saved using SaveSensitiveToUserCache. An unencrypted entry,
+// saved by an older version or when the OS keyring was unavailable, is encrypted and saved again. An entry that
+// can't be decrypted is reported as non-existent.
+func LoadSensitiveFromUserCache(ctx context.Context, dest any, file string) error {
+	ctx = dos.WithLockedFs(ctx)
+	path := filepath.Join(filelocation.AppUserCacheDir(ctx), file)
+	data, err := dos.ReadFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	var se sealedEntry
+	if json.Unmarshal(data, &se) == nil && se.Version > 0 {
+		key, err := getCacheKey(ctx)
+		if err == nil {
+			if se.Version != sealedVersion {
+				err = fmt.Errorf("unsupported version %d", se.Version)
+			} else {
+				data, err = unseal(key, se.Data, file)
+			}
+		}
+		if err != nil {
+			dlog.Warnf(ctx, "unable to decrypt %s: %v", path, err)
+			return &fs.PathError{Op: "decrypt", Path: path, Err: fs.ErrNotExist}
+		}
+		return parseEntry(ctx, path, data, dest)
+	}
+	if err = parseEntry(ctx, path, data, dest); err != nil {
+		return err
+	}
+	if _, err = getCacheKey(ctx); err == nil {
+		dlog.Debugf(ctx, "encrypting %s", path)
+		if err = SaveSensitiveToUserCache(ctx, dest, file); err != nil {
+			dlog.Warnf(ctx, "unable to encrypt %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// seal encrypts the data using AES-GCM. The name of the file is authenticated, so that an entry can't be
+// swapped with another one.
+func seal(key, data []byte, file string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, []byte(filepath.ToSlash(file))), nil
+}
+
+func unseal(key, sealed []byte, file string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	ns := gcm.NonceSize()
+	if len(sealed) < ns {
+		return nil, errors.New("sealed data is too short")
+	}
+	return gcm.Open(nil, sealed[:ns], sealed[ns:], []byte(filepath.ToSlash(file)))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
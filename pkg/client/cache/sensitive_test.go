@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+type sensitiveEntry struct {
+	Token string `json:"token"`
+}
+
+// withTestKey makes getCacheKey return a random key instead of the key of the OS keyring.
+func withTestKey(t *testing.T) context.Context {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	cacheKey.Lock()
+	cacheKey.key = key
+	cacheKey.Unlock()
+	t.Cleanup(func() {
+		cacheKey.Lock()
+		cacheKey.key = nil
+		cacheKey.Unlock()
+	})
+	return filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+}
+
+func readSealed(t *testing.T, ctx context.Context, file string) *sealedEntry {
+	data, err := os.ReadFile(filepath.Join(filelocation.AppUserCacheDir(ctx), file))
+	require.NoError(t, err)
+	var se sealedEntry
+	require.NoError(t, json.Unmarshal(data, &se))
+	return &se
+}
+
+func TestSealUnseal(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	data := []byte(`{"token":"secret"}`)
+
+	sealed, err := seal(key, data, "session.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "secret")
+
+	unsealed, err := unseal(key, sealed, "session.json")
+	require.NoError(t, err)
+	assert.Equal(t, data, unsealed)
+
+	// The name of the file is authenticated, so the data can't be moved to another file.
+	_, err = unseal(key, sealed, "other.json")
+	assert.Error(t, err)
+
+	_, err = unseal(key, sealed[:4], "session.json")
+	assert.Error(t, err)
+}
+
+func TestSensitiveUserCache(t *testing.T) {
+	ctx := withTestKey(t)
+	require.NoError(t, SaveSensitiveToUserCache(ctx, &sensitiveEntry{Token: "secret"}, "session.json"))
+
+	se := readSealed(t, ctx, "session.json")
+	assert.Equal(t, sealedVersion, se.Version)
+	assert.NotContains(t, string(se.Data), "secret")
+
+	var e sensitiveEntry
+	require.NoError(t, LoadSensitiveFromUserCache(ctx, &e, "session.json"))
+	assert.Equal(t, "secret", e.Token)
+}
+
+func TestSensitiveUserCache_moved(t *testing.T) {
+	ctx := withTestKey(t)
+	require.NoError(t, SaveSensitiveToUserCache(ctx, &sensitiveEntry{Token: "secret"}, "a.json"))
+	dir := filelocation.AppUserCacheDir(ctx)
+	require.NoError(t, os.Rename(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")))
+
+	var e sensitiveEntry
+	err := LoadSensitiveFromUserCache(ctx, &e, "b.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, e.Token)
+}
+
+func TestSensitiveUserCache_undecryptable(t *testing.T) {
+	ctx := withTestKey(t)
+	require.NoError(t, SaveSensitiveToUserCache(ctx, &sensitiveEntry{Token: "secret"}, "session.json"))
+
+	// Entries sealed with another key can't be decrypted.
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	cacheKey.Lock()
+	cacheKey.key = key
+	cacheKey.Unlock()
+
+	var e sensitiveEntry
+	err = LoadSensitiveFromUserCache(ctx, &e, "session.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestSensitiveUserCache_migratePlaintext(t *testing.T) {
+	ctx := withTestKey(t)
+	require.NoError(t, SaveToUserCache(ctx, &sensitiveEntry{Token: "secret"}, "session.json", Private))
+
+	var e sensitiveEntry
+	require.NoError(t, LoadSensitiveFromUserCache(ctx, &e, "session.json"))
+	assert.Equal(t, "secret", e.Token)
+
+	// The plaintext entry was encrypted and saved again.
+	se := readSealed(t, ctx, "session.json")
+	assert.Equal(t, sealedVersion, se.Version)
+	assert.NotContains(t, string(se.Data), "secret")
+
+	e = sensitiveEntry{}
+	require.NoError(t, LoadSensitiveFromUserCache(ctx, &e, "session.json"))
+	assert.Equal(t, "secret", e.Token)
+}
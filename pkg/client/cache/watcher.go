@@ -2,8 +2,12 @@ package cache
 
 import (
 	"context"
+	"io/fs"
 	"math"
+	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -13,33 +17,80 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
 )
 
+const (
+	// coalesceDelay is the time to wait for more events after an event has been received, so that a burst
+	// of modifications results in one call to onChange.
+	coalesceDelay = 5 * time.Millisecond
+
+	// pollInterval is the interval between the scans of a directory that can't be watched.
+	pollInterval = 250 * time.Millisecond
+)
+
 // WatchUserCache uses a file system watcher that receives events when one of the given files changes
 // and calls the given function when that happens.
 // All files in the given subDir are watched when the list of files is empty.
+//
+// The directory is polled instead when the file system doesn't support notifications.
 func WatchUserCache(ctx context.Context, subDir string, onChange func(context.Context) error, files ...string) error {
+	return watchUserCache(ctx, subDir, false, onChange, files)
+}
+
+// WaitUserCache calls the given condition initially, and then every time one of the given files in the
+// subDir changes, until the condition returns true, an error, or the context is done. All files in the
+// given subDir are watched when the list of files is empty.
+func WaitUserCache(ctx context.Context, subDir string, condition func(context.Context) (bool, error), files ...string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var result error
+	met := false
+	err := watchUserCache(ctx, subDir, true, func(ctx context.Context) error {
+		// The condition is called by a timer, so calls might overlap.
+		mu.Lock()
+		defer mu.Unlock()
+		if met {
+			return nil
+		}
+		ok, err := condition(ctx)
+		if ok || err != nil {
+			met = true
+			result = err
+			cancel()
+		}
+		return nil
+	}, files)
+	mu.Lock()
+	defer mu.Unlock()
+	switch {
+	case err != nil:
+		return err
+	case met:
+		return result
+	default:
+		return ctx.Err()
+	}
+}
+
+func watchUserCache(ctx context.Context, subDir string, initial bool, onChange func(context.Context) error, files []string) error {
 	dir := filepath.Join(filelocation.AppUserCacheDir(ctx), subDir)
 
 	// Ensure that the user cache directory exists.
 	if err := dos.MkdirAll(ctx, dir, 0o755); err != nil {
 		return err
 	}
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return err
-	}
-	defer watcher.Close()
 
-	// The directory containing the files must be watched because editing a
-	// file will typically end with renaming the original and then creating
-	// a new file. A watcher that follows the inode will not see when the new
-	// file is created.
-	if err = watcher.Add(dir); err != nil {
-		return err
+	isOfInterest := func(string) bool { return true }
+	if len(files) > 0 {
+		paths := make([]string, len(files))
+		for i := range files {
+			paths[i] = filepath.Join(dir, files[i])
+		}
+		isOfInterest = func(s string) bool {
+			return slices.Contains(paths, s)
+		}
 	}
 
-	// The delay timer will initially sleep forever. It's reset to a very short
-	// delay when the file is modified.
-	delay := time.AfterFunc(time.Duration(math.MaxInt64), func() {
+	call := func() {
 		select {
 		case <-ctx.Done():
 			return
@@ -48,23 +99,34 @@ func WatchUserCache(ctx context.Context, subDir string, onChange func(context.Co
 				dlog.Error(ctx, err)
 			}
 		}
-	})
-	defer delay.Stop()
+	}
 
-	isOfInterest := func(string) bool { return true }
-	if len(files) > 0 {
-		for i := range files {
-			files[i] = filepath.Join(dir, files[i])
-		}
-		isOfInterest = func(s string) bool {
-			for _, file := range files {
-				if s == file {
-					return true
-				}
-			}
-			return false
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		// The directory containing the files must be watched because editing a
+		// file will typically end with renaming the original and then creating
+		// a new file. A watcher that follows the inode will not see when the new
+		// file is created.
+		if err = watcher.Add(dir); err != nil {
+			_ = watcher.Close()
 		}
 	}
+	if err != nil {
+		dlog.Debugf(ctx, "unable to watch %s, falling back to polling: %v", dir, err)
+		return pollUserCache(ctx, dir, initial, call, isOfInterest)
+	}
+	defer watcher.Close()
+
+	// The delay timer will initially sleep forever. It's reset to a very short
+	// delay when the file is modified. It fires at once when an initial call
+	// is requested. This happens after the watch is established, so no change is missed.
+	initialDelay := time.Duration(math.MaxInt64)
+	if initial {
+		initialDelay = 0
+	}
+	delay := time.AfterFunc(initialDelay, call)
+	defer delay.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -72,10 +134,63 @@ func WatchUserCache(ctx context.Context, subDir string, onChange func(context.Co
 		case err = <-watcher.Errors:
 			dlog.Error(ctx, err)
 		case event := <-watcher.Events:
-			if event.Op&(fsnotify.Remove|fsnotify.Write|fsnotify.Create) != 0 && isOfInterest(event.Name) {
+			if event.Op&(fsnotify.Remove|fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 && isOfInterest(event.Name) {
 				// The file was created, modified, or removed. Let's defer the call to onChange just
 				// a little bit in case there are more modifications to it.
-				delay.Reset(5 * time.Millisecond)
+				delay.Reset(coalesceDelay)
+			}
+		}
+	}
+}
+
+// pollUserCache scans the given directory periodically, and calls onChange when a file of interest has been
+// created, replaced, resized, or removed. A change of the modification time alone isn't considered a change,
+// because that's how daemons tell that they are alive.
+func pollUserCache(ctx context.Context, dir string, initial bool, onChange func(), isOfInterest func(string) bool) error {
+	scan := func() map[string]fs.FileInfo {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			dlog.Error(ctx, err)
+		}
+		fis := make(map[string]fs.FileInfo, len(entries))
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !isOfInterest(path) {
+				continue
+			}
+			if fi, err := os.Stat(path); err == nil {
+				fis[path] = fi
+			}
+		}
+		return fis
+	}
+	changed := func(old, current map[string]fs.FileInfo) bool {
+		if len(old) != len(current) {
+			return true
+		}
+		for path, ofi := range old {
+			cfi, ok := current[path]
+			if !ok || cfi.Size() != ofi.Size() || !os.SameFile(cfi, ofi) {
+				return true
+			}
+		}
+		return false
+	}
+
+	fis := scan()
+	if initial {
+		onChange()
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if current := scan(); changed(fis, current) {
+				fis = current
+				onChange()
 			}
 		}
 	}
@@ -2,27 +2,37 @@ package cmd
 
 import (
 	"encoding/json"
+	"net"
 
 	"github.com/spf13/cobra"
 	empty "google.golang.org/protobuf/types/known/emptypb"
+	"sigs.k8s.io/yaml"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 )
 
 func configCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use: "config",
 	}
-	cmd.AddCommand(configView())
+	cmd.AddCommand(configView(), configApply())
 	return cmd
 }
 
-const clientOnlyFlag = "client-only"
+const (
+	clientOnlyFlag = "client-only"
+	clusterFlag    = "cluster"
+)
 
 func configView() *cobra.Command {
 	cmd := &cobra.Command{
@@ -35,10 +45,74 @@ func configView() *cobra.Command {
 			ann.Session: ann.Optional,
 		},
 	}
-	cmd.Flags().BoolP(clientOnlyFlag, "c", false, "Only view config from client file.")
+	flags := cmd.Flags()
+	flags.BoolP(clientOnlyFlag, "c", false, "Only view config from client file.")
+	flags.Bool(clusterFlag, false, "View the active config of the traffic-manager.")
+	cmd.MarkFlagsMutuallyExclusive(clientOnlyFlag, clusterFlag)
+	return cmd
+}
+
+func configApply() *cobra.Command {
+	var alsoProxy, neverProxy []string
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Args:  cobra.NoArgs,
+		Short: "Apply routing configuration to the current connection",
+		Long: "Apply the also-proxy and never-proxy subnets of the kubeconfig extension of the connected context, " +
+			"together with the subnets given by flags, to the current connection. The routes are updated " +
+			"without reconnecting, so existing intercepts and connections are retained.",
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runConfigApply(cmd, alsoProxy, neverProxy)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringSliceVar(&alsoProxy, "also-proxy", nil, "Additional comma separated list of CIDR to proxy")
+	flags.StringSliceVar(&neverProxy, "never-proxy", nil, "Additional comma separated list of CIDR to never proxy")
 	return cmd
 }
 
+func runConfigApply(cmd *cobra.Command, alsoProxy, neverProxy []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	kc, err := GetCommandKubeConfig(cmd)
+	if err != nil {
+		return err
+	}
+	rq := &daemonRpc.SetProxySubnetsRequest{}
+	if rq.AlsoProxySubnets, err = subnetsToRPC(kc.AlsoProxy, alsoProxy); err != nil {
+		return errcat.User.Newf("invalid also-proxy: %v", err)
+	}
+	if rq.NeverProxySubnets, err = subnetsToRPC(kc.NeverProxy, neverProxy); err != nil {
+		return errcat.User.Newf("invalid never-proxy: %v", err)
+	}
+	ctx := cmd.Context()
+	if _, err = daemon.GetUserClient(ctx).SetProxySubnets(ctx, rq); err != nil {
+		return err
+	}
+	ioutil.Printf(cmd.OutOrStdout(), "Applied %d also-proxy and %d never-proxy subnets\n", len(rq.AlsoProxySubnets), len(rq.NeverProxySubnets))
+	return nil
+}
+
+// subnetsToRPC returns the given subnets together with the given CIDRs in RPC form.
+func subnetsToRPC(sns []*iputil.Subnet, cidrs []string) ([]*manager.IPNet, error) {
+	rs := make([]*manager.IPNet, 0, len(sns)+len(cidrs))
+	for _, sn := range sns {
+		rs = append(rs, iputil.IPNetToRPC((*net.IPNet)(sn)))
+	}
+	for _, c := range cidrs {
+		_, sn, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, iputil.IPNetToRPC(sn))
+	}
+	return rs, nil
+}
+
 // GetCommandKubeConfig will return the fully resolved client.Kubeconfig for the given command.
 func GetCommandKubeConfig(cmd *cobra.Command) (*client.Kubeconfig, error) {
 	ctx := cmd.Context()
@@ -65,6 +139,9 @@ func GetCommandKubeConfig(cmd *cobra.Command) (*client.Kubeconfig, error) {
 }
 
 func runConfigView(cmd *cobra.Command, _ []string) error {
+	if cluster, _ := cmd.Flags().GetBool(clusterFlag); cluster {
+		return runClusterConfigView(cmd)
+	}
 	var cfg client.SessionConfig
 	clientOnly, _ := cmd.Flags().GetBool(clientOnlyFlag)
 	if !clientOnly {
@@ -94,12 +171,15 @@ func runConfigView(cmd *cobra.Command, _ []string) error {
 		cfg.Routing.AlsoProxy = kc.AlsoProxy
 		cfg.Routing.NeverProxy = kc.NeverProxy
 		cfg.Routing.AllowConflicting = kc.AllowConflictingSubnets
+		cfg.Routing.ExcludePorts = kc.ProxyExcludePorts
 		if dns := kc.DNS; dns != nil {
 			cfg.DNS.ExcludeSuffixes = dns.ExcludeSuffixes
 			cfg.DNS.IncludeSuffixes = dns.IncludeSuffixes
 			cfg.DNS.LookupTimeout = dns.LookupTimeout.Duration
 			cfg.DNS.LocalIP = dns.LocalIP.IP()
 			cfg.DNS.RemoteIP = dns.RemoteIP.IP()
+			cfg.DNS.Overrides = dns.Overrides
+			cfg.DNS.NegativeCacheTTL = dns.NegativeCacheTTL.Duration
 		}
 		if mgr := kc.Manager; mgr != nil {
 			cfg.ManagerNamespace = mgr.Namespace
@@ -126,3 +206,24 @@ func runConfigView(cmd *cobra.Command, _ []string) error {
 	output.Object(ctx, &cfg, true)
 	return nil
 }
+
+// runClusterConfigView outputs the active settings of the traffic-manager.
+func runClusterConfigView(cmd *cobra.Command) error {
+	cmd.Annotations = map[string]string{
+		ann.Session: ann.Required,
+	}
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	mc, err := daemon.GetUserClient(ctx).GetManagerConfig(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	var cfg map[string]any
+	if err = yaml.Unmarshal(mc.ConfigYaml, &cfg); err != nil {
+		return err
+	}
+	output.Object(ctx, cfg, true)
+	return nil
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func Test_subnetsToRPC(t *testing.T) {
+	_, sn, err := net.ParseCIDR("10.1.0.0/16")
+	require.NoError(t, err)
+	rs, err := subnetsToRPC([]*iputil.Subnet{(*iputil.Subnet)(sn)}, []string{"192.168.10.0/24", "fd00::/64"})
+	require.NoError(t, err)
+	require.Len(t, rs, 3)
+	assert.Equal(t, "10.1.0.0/16", iputil.IPNetFromRPC(rs[0]).String())
+	assert.Equal(t, "192.168.10.0/24", iputil.IPNetFromRPC(rs[1]).String())
+	assert.Equal(t, "fd00::/64", iputil.IPNetFromRPC(rs[2]).String())
+
+	_, err = subnetsToRPC(nil, []string{"192.168.10.0"})
+	assert.Error(t, err)
+}
@@ -1,13 +1,33 @@
 package cmd
 
 import (
+	"net"
+
 	"github.com/spf13/cobra"
 
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 )
 
+// ConnectOutput is the object that is printed by "telepresence connect" when formatted
+// output is requested.
+type ConnectOutput struct {
+	Name              string           `json:"name,omitempty" yaml:"name,omitempty"`
+	KubernetesServer  string           `json:"kubernetes_server,omitempty" yaml:"kubernetes_server,omitempty"`
+	KubernetesContext string           `json:"kubernetes_context,omitempty" yaml:"kubernetes_context,omitempty"`
+	Namespace         string           `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ManagerNamespace  string           `json:"manager_namespace,omitempty" yaml:"manager_namespace,omitempty"`
+	ManagerVersion    string           `json:"manager_version,omitempty" yaml:"manager_version,omitempty"`
+	MappedNamespaces  []string         `json:"mapped_namespaces,omitempty" yaml:"mapped_namespaces,omitempty"`
+	Subnets           []*iputil.Subnet `json:"subnets,omitempty" yaml:"subnets,omitempty"`
+	DNSAddress        net.IP           `json:"dns_address,omitempty" yaml:"dns_address,omitempty"`
+	AlreadyConnected  bool             `json:"already_connected,omitempty" yaml:"already_connected,omitempty"`
+}
+
 func connectCmd() *cobra.Command {
 	var request *daemon.CobraRequest
 
@@ -22,9 +42,44 @@ func connectCmd() *cobra.Command {
 			if err := request.CommitFlags(cmd); err != nil {
 				return err
 			}
-			return connect.RunConnect(cmd, args)
+			if err := connect.RunConnect(cmd, args); err != nil {
+				return err
+			}
+			if len(args) == 0 && output.WantsFormatted(cmd) {
+				if s := daemon.GetSession(cmd.Context()); s != nil {
+					output.Object(cmd.Context(), newConnectOutput(s.Info, !s.Started), true)
+				}
+			}
+			return nil
 		},
 	}
 	request = daemon.InitRequest(cmd)
+	cmd.Flags().BoolVar(&request.SwitchContext, "switch-context", false, ``+
+		`Let an already connected daemon disconnect and then connect using the given context or kubeconfig instead of failing. `+
+		`This is a full disconnect and reconnect. The routes, DNS configuration, and intercepts of the current session are removed`)
 	return cmd
 }
+
+func newConnectOutput(ci *connector.ConnectInfo, alreadyConnected bool) *ConnectOutput {
+	co := &ConnectOutput{
+		Name:              ci.ConnectionName,
+		KubernetesServer:  ci.ClusterServer,
+		KubernetesContext: ci.ClusterContext,
+		Namespace:         ci.Namespace,
+		ManagerNamespace:  ci.ManagerNamespace,
+		MappedNamespaces:  ci.MappedNamespaces,
+		AlreadyConnected:  alreadyConnected,
+	}
+	if mv := ci.ManagerVersion; mv != nil {
+		co.ManagerVersion = mv.Version
+	}
+	if ds := ci.DaemonStatus; ds != nil {
+		for _, subnet := range ds.Subnets {
+			co.Subnets = append(co.Subnets, (*iputil.Subnet)(iputil.IPNetFromRPC(subnet)))
+		}
+		if dns := ds.GetOutboundConfig().GetDns(); dns != nil {
+			co.DNSAddress = dns.RemoteIp
+		}
+	}
+	return co
+}
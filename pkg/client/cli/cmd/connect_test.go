@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func Test_newConnectOutput(t *testing.T) {
+	podSubnet := &net.IPNet{IP: net.IP{10, 244, 0, 0}, Mask: net.CIDRMask(16, 32)}
+	svcSubnet := &net.IPNet{IP: net.IP{10, 96, 0, 0}, Mask: net.CIDRMask(12, 32)}
+	ci := &connector.ConnectInfo{
+		ConnectionName:   "kind-default",
+		ClusterServer:    "https://127.0.0.1:6443",
+		ClusterContext:   "kind",
+		Namespace:        "default",
+		ManagerNamespace: "ambassador",
+		MappedNamespaces: []string{"default", "dev"},
+		ManagerVersion:   &manager.VersionInfo2{Name: "Traffic Manager", Version: "v2.20.0"},
+		DaemonStatus: &daemonRpc.DaemonStatus{
+			Subnets: []*manager.IPNet{iputil.IPNetToRPC(podSubnet), iputil.IPNetToRPC(svcSubnet)},
+			OutboundConfig: &daemonRpc.OutboundInfo{
+				Dns: &daemonRpc.DNSConfig{RemoteIp: net.IP{10, 96, 0, 10}},
+			},
+		},
+	}
+
+	assert.Equal(t, &ConnectOutput{
+		Name:              "kind-default",
+		KubernetesServer:  "https://127.0.0.1:6443",
+		KubernetesContext: "kind",
+		Namespace:         "default",
+		ManagerNamespace:  "ambassador",
+		ManagerVersion:    "v2.20.0",
+		MappedNamespaces:  []string{"default", "dev"},
+		Subnets:           []*iputil.Subnet{(*iputil.Subnet)(podSubnet), (*iputil.Subnet)(svcSubnet)},
+		DNSAddress:        net.IP{10, 96, 0, 10},
+		AlreadyConnected:  true,
+	}, newConnectOutput(ci, true))
+
+	t.Run("no outbound DNS", func(t *testing.T) {
+		ci := &connector.ConnectInfo{
+			ConnectionName: "kind-default",
+			DaemonStatus:   &daemonRpc.DaemonStatus{Subnets: []*manager.IPNet{iputil.IPNetToRPC(podSubnet)}},
+		}
+		co := newConnectOutput(ci, false)
+		assert.Equal(t, []*iputil.Subnet{(*iputil.Subnet)(podSubnet)}, co.Subnets)
+		assert.Nil(t, co.DNSAddress)
+	})
+
+	t.Run("no daemon status or manager version", func(t *testing.T) {
+		ci := &connector.ConnectInfo{ConnectionName: "kind-default", Namespace: "default"}
+		assert.Equal(t, &ConnectOutput{Name: "kind-default", Namespace: "default"}, newConnectOutput(ci, false))
+	})
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+func daemonsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemons",
+		Short: "Administer the local telepresence daemons",
+	}
+	cmd.AddCommand(daemonsList(), daemonsDescribe(), daemonsStop())
+	return cmd
+}
+
+func daemonsList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Args:  cobra.NoArgs,
+		Short: "List the running daemons and the names that the --use flag can match",
+		RunE:  listDaemons,
+	}
+}
+
+func daemonsDescribe() *cobra.Command {
+	return &cobra.Command{
+		Use:               "describe <name>",
+		Args:              cobra.ExactArgs(1),
+		Short:             "Describe a running daemon",
+		RunE:              describeDaemon,
+		ValidArgsFunction: daemonNames,
+	}
+}
+
+func daemonsStop() *cobra.Command {
+	return &cobra.Command{
+		Use:               "stop <name>",
+		Args:              cobra.ExactArgs(1),
+		Short:             "Tell a running daemon to quit",
+		RunE:              stopDaemon,
+		ValidArgsFunction: daemonNames,
+	}
+}
+
+func daemonNames(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	infos, err := daemon.LoadInfos(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if id := info.DaemonID(); id != nil {
+			names = append(names, id.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func listDaemons(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	infos, err := daemon.LoadInfos(ctx)
+	if err != nil {
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, infos, false)
+		return nil
+	}
+	if len(infos) == 0 {
+		ioutil.Println(output.Info(ctx), "No daemons are running")
+		return nil
+	}
+
+	rd := output.NewRenderer(ctx, cmd.OutOrStdout())
+	rows := make([][]string, 0, len(infos)+1)
+	if !rd.Quiet() {
+		rows = append(rows, []string{"NAME", "KUBE CONTEXT", "NAMESPACE", "CONTAINER", "EXPOSED PORTS", "AGE", "HEALTH"})
+	}
+	for _, info := range infos {
+		id := info.DaemonID()
+		if id == nil {
+			continue
+		}
+		if rd.Quiet() {
+			rows = append(rows, []string{id.Name})
+			continue
+		}
+		rows = append(rows, []string{
+			id.Name,
+			info.KubeContext,
+			info.Namespace,
+			daemonContainer(info),
+			strings.Join(info.ExposedPorts, ","),
+			daemonAge(info),
+			daemonHealth(info),
+		})
+	}
+	rd.Table("  ", rows)
+	return nil
+}
+
+func describeDaemon(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := daemon.LoadNamedInfo(ctx, strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, info, false)
+		return nil
+	}
+
+	kvf := ioutil.DefaultKeyValueFormatter()
+	kvf.Add("Name", info.DaemonID().Name)
+	kvf.Add("Kubernetes context", info.KubeContext)
+	kvf.Add("Namespace", info.Namespace)
+	kvf.Add("Container", daemonContainer(info))
+	if info.InDocker && info.DaemonPort > 0 {
+		kvf.Add("Daemon port", strconv.Itoa(info.DaemonPort))
+	}
+	if len(info.ExposedPorts) > 0 {
+		kvf.Add("Exposed ports", strings.Join(info.ExposedPorts, ", "))
+	}
+	if info.Hostname != "" {
+		kvf.Add("Hostname", info.Hostname)
+	}
+	if info.UserSpace {
+		if info.SOCKSPort > 0 {
+			kvf.Add("SOCKS port", strconv.Itoa(info.SOCKSPort))
+		}
+		if info.HTTPProxyPort > 0 {
+			kvf.Add("HTTP proxy port", strconv.Itoa(info.HTTPProxyPort))
+		}
+	}
+	kvf.Add("Age", daemonAge(info))
+	kvf.Add("Health", daemonHealth(info))
+	if h := info.Health; h != nil {
+		if h.Cluster != "" {
+			kvf.Add("Kubernetes server", h.Cluster)
+		}
+		if !h.LastManagerPing.IsZero() {
+			kvf.Add("Last manager ping", since(h.LastManagerPing)+" ago")
+		}
+		if h.Error != "" {
+			kvf.Add("Error", h.Error)
+		}
+	}
+	if len(info.Intercepts) > 0 {
+		ics := make([]string, len(info.Intercepts))
+		for i, ic := range info.Intercepts {
+			ics[i] = fmt.Sprintf("%s (%s)", ic.Name, ic.Disposition)
+		}
+		kvf.Add("Intercepts", strings.Join(ics, ", "))
+	}
+	kvf.Println(cmd.OutOrStdout())
+	return nil
+}
+
+func stopDaemon(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := daemon.LoadNamedInfo(ctx, strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	if err = connect.QuitDaemon(ctx, info); err != nil {
+		return err
+	}
+	ioutil.Printf(output.Out(ctx), "Daemon %s stopped\n", info.DaemonID().Name)
+	return nil
+}
+
+// daemonContainer returns the short ID of the container that the daemon runs in, or "no" when the
+// daemon runs on the host.
+func daemonContainer(info *daemon.Info) string {
+	if !info.InDocker {
+		return "no"
+	}
+	cid := info.Options["cid"]
+	if cid == "" {
+		return "yes"
+	}
+	if len(cid) > 12 {
+		cid = cid[:12]
+	}
+	return cid
+}
+
+func daemonAge(info *daemon.Info) string {
+	if info.StartedAt.IsZero() {
+		return "unknown"
+	}
+	return since(info.StartedAt)
+}
+
+// staleManagerPing is the age at which the last successful call to the traffic-manager indicates a problem.
+// Daemons call the traffic-manager every five seconds.
+const staleManagerPing = 30 * time.Second
+
+// daemonHealth returns a one-word summary of the health that the daemon has stored in its info.
+func daemonHealth(info *daemon.Info) string {
+	h := info.Health
+	switch {
+	case h == nil:
+		return "no session"
+	case h.Error != "":
+		return "error"
+	case time.Since(h.LastManagerPing) > staleManagerPing:
+		return "stale"
+	default:
+		return "ok"
+	}
+}
+
+func since(t time.Time) string {
+	return time.Since(t).Truncate(time.Second).String()
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+func dnsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "Administer the DNS resolver of the current connection",
+	}
+	cmd.AddCommand(dnsFlush())
+	return cmd
+}
+
+func dnsFlush() *cobra.Command {
+	return &cobra.Command{
+		Use:   "flush",
+		Args:  cobra.NoArgs,
+		Short: "Remove all cached entries from the DNS resolver",
+		RunE:  flushDNS,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func flushDNS(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if _, err := daemon.GetUserClient(ctx).FlushDNSCache(ctx, &empty.Empty{}); err != nil {
+		return err
+	}
+	ioutil.Println(cmd.OutOrStdout(), "DNS cache flushed")
+	return nil
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"archive/zip"
 	"bufio"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -20,6 +21,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/redact"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
@@ -32,6 +34,8 @@ type gatherLogsCommand struct {
 	trafficManager bool
 	anon           bool
 	podYaml        bool
+	clusterState   bool
+	noRedact       bool
 }
 
 func gatherLogs() *cobra.Command {
@@ -61,6 +65,9 @@ telepresence gather-logs --traffic-manager=False --traffic-agents=echo-easy-6848
 
 # Get logs from everything except the daemons
 telepresence gather-logs --daemons=None
+
+# Get all logs but skip the pod descriptions, events, and webhook status
+telepresence gather-logs --get-cluster-state=false
 `,
 
 		RunE: gl.gatherLogs,
@@ -75,6 +82,11 @@ telepresence gather-logs --daemons=None
 	flags.StringVar(&gl.trafficAgents, "traffic-agents", "all", "Traffic-agents to collect logs from: all, name substring, None")
 	flags.BoolVarP(&gl.anon, "anonymize", "a", false, "To anonymize pod names + namespaces from the logs")
 	flags.BoolVarP(&gl.podYaml, "get-pod-yaml", "y", false, "Get the yaml of any pods you are getting logs for")
+	flags.BoolVar(&gl.clusterState, "get-cluster-state", true,
+		"Get pod descriptions, recent events, and the status of the traffic-manager's mutating webhook")
+	flags.BoolVar(&gl.noRedact, "no-redact", false,
+		"Don't mask the values of environment variables that are sourced from Secrets. Only the values of the "+
+			"current intercepts are known, so values of intercepts that have ended are not masked")
 	return cmd
 }
 
@@ -143,7 +155,9 @@ func (gl *gatherLogsCommand) gatherLogs(cmd *cobra.Command, _ []string) error {
 	scout.SetMetadatum(ctx, "traffic_manager_logs", gl.trafficManager)
 	scout.SetMetadatum(ctx, "traffic_agent_logs", gl.trafficAgents)
 	scout.SetMetadatum(ctx, "get_pod_yaml", gl.podYaml)
+	scout.SetMetadatum(ctx, "get_cluster_state", gl.clusterState)
 	scout.SetMetadatum(ctx, "anonymized_logs", gl.anon)
+	scout.SetMetadatum(ctx, "redacted_logs", !gl.noRedact)
 	scout.Report(ctx, "used_gather_logs")
 
 	var az *anonymizer
@@ -190,7 +204,8 @@ func (gl *gatherLogsCommand) gatherLogs(cmd *cobra.Command, _ []string) error {
 				if empty {
 					continue
 				}
-				dstFile := filepath.Join(exportDir, entry.Name())
+				// Rotated logs might be compressed. They're decompressed so that they can be anonymized.
+				dstFile := filepath.Join(exportDir, strings.TrimSuffix(entry.Name(), ".gz"))
 				if err := copyFiles(dstFile, srcFile); err != nil {
 					// We don't want to fail / exit abruptly if we can't copy certain
 					// files, but we do want the user to know we were unsuccessful
@@ -201,15 +216,22 @@ func (gl *gatherLogsCommand) gatherLogs(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
+	var rd *redact.Redactor
+	if !gl.noRedact {
+		if rd, err = secretRedactor(ctx); err != nil {
+			// Logs are never exported unredacted unless that was requested.
+			return errcat.User.Newf("unable to get the values to redact: %v. Use --no-redact to export the logs without masking", err)
+		}
+	}
+
 	// Zip up all the files we've created in the zip directory and return that to the user
 	dirEntries, err := os.ReadDir(exportDir)
-	files := make([]string, len(dirEntries))
 	if err != nil {
 		return errcat.User.New(err)
 	}
-	for i, entry := range dirEntries {
+	files := make([]string, 0, len(dirEntries))
+	for _, entry := range dirEntries {
 		if entry.IsDir() {
-			files = files[:len(files)-1]
 			continue
 		}
 
@@ -220,7 +242,14 @@ func (gl *gatherLogsCommand) gatherLogs(cmd *cobra.Command, _ []string) error {
 				fmt.Fprintf(cmd.ErrOrStderr(), "error anonymizing %s: %s\n", fullFileName, err)
 			}
 		}
-		files[i] = fullFileName
+		if rd != nil {
+			if err := rd.File(fullFileName); err != nil {
+				// A file that can't be redacted is left out rather than exported unredacted.
+				fmt.Fprintf(cmd.ErrOrStderr(), "error redacting %s, so it is not exported: %s\n", fullFileName, err)
+				continue
+			}
+		}
+		files = append(files, fullFileName)
 	}
 
 	if err := zipFiles(files, gl.outputFile); err != nil {
@@ -235,10 +264,11 @@ func (gl *gatherLogsCommand) gatherClusterLogs(ctx context.Context, exportDir st
 	// To get logs from the components in the kubernetes cluster, we ask the
 	// traffic-manager.
 	rq := &connector.LogsRequest{
-		TrafficManager: gl.trafficManager,
-		Agents:         gl.trafficAgents,
-		GetPodYaml:     gl.podYaml,
-		ExportDir:      exportDir,
+		TrafficManager:  gl.trafficManager,
+		Agents:          gl.trafficAgents,
+		GetPodYaml:      gl.podYaml,
+		GetClusterState: gl.clusterState,
+		ExportDir:       exportDir,
 	}
 	userD := daemon.GetUserClient(ctx)
 	if userD != nil {
@@ -260,6 +290,24 @@ func (gl *gatherLogsCommand) gatherClusterLogs(ctx context.Context, exportDir st
 	return nil
 }
 
+// secretRedactor returns a redact.Redactor that masks the values of the environment variables of the
+// current intercepts that are sourced from Secrets.
+func secretRedactor(ctx context.Context) (*redact.Redactor, error) {
+	var values []string
+	if userD := daemon.GetUserClient(ctx); userD != nil {
+		ws, err := userD.List(ctx, &connector.ListRequest{Filter: connector.ListRequest_INTERCEPTS})
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range ws.Workloads {
+			for _, ii := range w.InterceptInfos {
+				values = append(values, redact.SecretValues(ii.Environment)...)
+			}
+		}
+	}
+	return redact.NewRedactor(values), nil
+}
+
 func isEmpty(file string) (bool, error) {
 	s, err := os.Stat(file)
 	if err != nil {
@@ -268,13 +316,24 @@ func isEmpty(file string) (bool, error) {
 	return s.Size() == 0, err
 }
 
-// copyFiles copies files from one location into another.
+// copyFiles copies files from one location into another. A gzip compressed source is decompressed unless the
+// destination is gzip compressed too.
 func copyFiles(dstFile, srcFile string) error {
-	srcWriter, err := os.Open(srcFile)
+	srcFd, err := os.Open(srcFile)
 	if err != nil {
 		return err
 	}
-	defer srcWriter.Close()
+	defer srcFd.Close()
+
+	var srcWriter io.Reader = srcFd
+	if strings.HasSuffix(srcFile, ".gz") && !strings.HasSuffix(dstFile, ".gz") {
+		zr, err := gzip.NewReader(srcFd)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		srcWriter = zr
+	}
 
 	dstWriter, err := os.Create(dstFile)
 	if err != nil {
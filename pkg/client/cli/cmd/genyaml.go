@@ -24,7 +24,6 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/flags"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
-	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 )
 
 type genYAMLCommand struct {
@@ -51,7 +50,7 @@ configmap; you can do this by running "genyaml config", "genyaml container", and
 NOTE: It is recommended that you not do this unless strictly necessary. Instead, we suggest letting
 telepresence's webhook injector configure the traffic agents on demand.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return errcat.User.New("please run genyaml as \"genyaml config\", \"genyaml container\", \"genyaml initcontainer\", or \"genyaml volume\"")
+			return errcat.User.New("please run genyaml as \"genyaml config\", \"genyaml container\", \"genyaml initcontainer\", \"genyaml volume\", or \"genyaml preview\"")
 		},
 	}
 	flags := cmd.PersistentFlags()
@@ -62,6 +61,7 @@ telepresence's webhook injector configure the traffic agents on demand.`,
 		genContainerSubCommand(&info),
 		genInitContainerSubCommand(&info),
 		genVolumeSubCommand(&info),
+		genPreviewSubCommand(&info),
 	)
 	return cmd
 }
@@ -136,7 +136,7 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 		if i.workloadName == "" {
 			return nil, errcat.User.New("either --input or --workload must be provided")
 		}
-		return tracing.GetWorkload(ctx, i.workloadName, i.namespace, "")
+		return agentmap.GetWorkload(ctx, i.workloadName, i.namespace, "")
 	}
 	b, err := getInput(i.inputFile)
 	if err != nil {
@@ -144,7 +144,7 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 	}
 
 	scheme := runtime.NewScheme()
-	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{})
+	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{}, &apps.DaemonSet{})
 	codecFactory := serializer.NewCodecFactory(scheme)
 	deserializer := codecFactory.UniversalDeserializer()
 
@@ -152,9 +152,9 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 	if err != nil {
 		return nil, errcat.User.Newf("unable to parse yaml in %s: %w", i.inputFile, err)
 	}
-	wl, err := k8sapi.WrapWorkload(obj)
+	wl, err := agentmap.WrapWorkload(obj)
 	if err != nil {
-		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, or StatefulSet", kind)
+		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, StatefulSet, or DaemonSet", kind)
 	}
 	if wl.GetNamespace() == "" {
 		if d, ok := k8sapi.DeploymentImpl(wl); ok {
@@ -163,6 +163,8 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 			r.Namespace = i.namespace
 		} else if s, ok := k8sapi.StatefulSetImpl(wl); ok {
 			s.Namespace = i.namespace
+		} else if ds, ok := agentmap.DaemonSetImpl(wl); ok {
+			ds.Namespace = i.namespace
 		}
 	}
 	return wl, nil
@@ -196,7 +198,7 @@ func (i *genYAMLCommand) withK8sInterface(ctx context.Context, flagMap map[strin
 		}
 	}
 
-	configLoader := configFlags.ToRawKubeConfigLoader()
+	configLoader := client.KubeconfigLoader(configFlags)
 	restConfig, err := configLoader.ClientConfig()
 	if err != nil {
 		return nil, errcat.Config.New(err)
@@ -407,12 +409,8 @@ func (g *genInitContainerInfo) run(cmd *cobra.Command, kubeFlags map[string]stri
 		return err
 	}
 
-	for _, cc := range cm.Containers {
-		for _, ic := range cc.Intercepts {
-			if ic.Headless || ic.TargetPortNumeric {
-				return g.writeObjToOutput(agentconfig.InitContainer(cm))
-			}
-		}
+	if agentconfig.NeedInitContainer(cm) {
+		return g.writeObjToOutput(agentconfig.InitContainer(cm))
 	}
 	return errcat.User.New("deployment does not need an init container")
 }
@@ -483,7 +481,76 @@ func (g *genVolumeInfo) run(cmd *cobra.Command, kubeFlags map[string]string) err
 		},
 		ObjectMeta: podTpl.ObjectMeta,
 		Spec:       podTpl.Spec,
-	})
+	}, cm.Volumes, cm.ServiceAccountToken)
 
 	return g.writeObjToOutput(&volumes)
 }
+
+type genPreviewInfo struct {
+	*genYAMLCommand
+}
+
+func genPreviewSubCommand(yamlInfo *genYAMLCommand) *cobra.Command {
+	info := genPreviewInfo{genYAMLCommand: yamlInfo}
+	kubeFlags := allKubeFlags()
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Args:  cobra.NoArgs,
+		Short: "Generate YAML for everything that the agent injector would inject.",
+		Long: `Generate YAML for the traffic-agent container, the init container, and the volumes that the agent
+injector would inject into the pods of a workload. The cluster isn't accessed when both --input and --config
+are given, which makes it possible to audit injections before the agent injector is enabled.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return info.run(cmd, flags.Map(kubeFlags))
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&info.inputFile, "input", "i", "",
+		"Optional path to the yaml containing the workload definition (i.e. Deployment, StatefulSet, etc). Pass '-' for stdin. Loaded from cluster by default")
+	flags.StringVarP(&info.workloadName, "workload", "w", "",
+		"Name of the workload. If given, the configmap entry will be retrieved telepresence-agents configmap, mutually exclusive to --config")
+	flags.StringVarP(&info.configFile, "config", "c", "", "Path to the yaml containing the generated configmap entry, mutually exclusive to --workload")
+	flags.AddFlagSet(kubeFlags)
+	return cmd
+}
+
+func (g *genPreviewInfo) run(cmd *cobra.Command, kubeFlags map[string]string) error {
+	ctx := cmd.Context()
+	if g.inputFile == "" || g.configFile == "" {
+		var err error
+		if ctx, err = g.withK8sInterface(ctx, kubeFlags); err != nil {
+			return err
+		}
+	}
+
+	cm, err := g.loadConfigMapEntry(ctx)
+	if err != nil {
+		return err
+	}
+	if g.inputFile == "" {
+		g.workloadName = cm.WorkloadName
+	}
+
+	wl, err := g.loadWorkload(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Sanity check
+	if wl.GetName() != cm.WorkloadName {
+		return errcat.User.Newf("name %q of loaded workload is different from %q loaded configmap entry", wl.GetName(), cm.WorkloadName)
+	}
+	if wl.GetKind() != cm.WorkloadKind {
+		return errcat.User.Newf("kind %q of loaded workload is different from %q loaded configmap entry", wl.GetKind(), cm.WorkloadKind)
+	}
+
+	podTpl := wl.GetPodTemplate()
+	return g.writeObjToOutput(agentconfig.PreviewInjection(ctx, &core.Pod{
+		TypeMeta: meta.TypeMeta{
+			Kind:       "pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: podTpl.ObjectMeta,
+		Spec:       podTpl.Spec,
+	}, cm))
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+const previewDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: echo
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: echo
+  template:
+    metadata:
+      labels:
+        app: echo
+    spec:
+      containers:
+      - name: echo
+        image: jmalloc/echo-server
+        ports:
+        - containerPort: 8080
+`
+
+const previewConfig = `agentName: echo
+agentImage: docker.io/datawire/tel2:2.19.1
+namespace: default
+workloadName: echo
+workloadKind: Deployment
+managerHost: traffic-manager.ambassador
+managerPort: 8081
+containers:
+- name: echo
+  envPrefix: A_
+  mountPoint: /tel_app_mounts/echo
+  intercepts:
+  - serviceName: echo
+    servicePort: 80
+    targetPortNumeric: true
+    protocol: TCP
+    agentPort: 9900
+    containerPort: 8080
+`
+
+func Test_genPreview(t *testing.T) {
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "deployment.yaml")
+	configFile := filepath.Join(dir, "config.yaml")
+	outputFile := filepath.Join(dir, "preview.yaml")
+	require.NoError(t, os.WriteFile(inputFile, []byte(previewDeployment), 0o600))
+	require.NoError(t, os.WriteFile(configFile, []byte(previewConfig), 0o600))
+
+	// Point out a kubeconfig that doesn't exist to ensure that the cluster isn't used.
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "no-such-kubeconfig"))
+
+	cmd := genYAML()
+	cmd.SetArgs([]string{"preview", "--input", inputFile, "--config", configFile, "--output", outputFile})
+	require.NoError(t, cmd.ExecuteContext(dlog.NewTestContext(t, false)))
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var inj agentconfig.Injection
+	require.NoError(t, yaml.Unmarshal(data, &inj))
+	require.NotNil(t, inj.Container)
+	assert.Equal(t, agentconfig.ContainerName, inj.Container.Name)
+	require.NotNil(t, inj.InitContainer)
+	assert.Equal(t, agentconfig.InitContainerName, inj.InitContainer.Name)
+	assert.NotEmpty(t, inj.Volumes)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+)
+
+func ingestCmd() *cobra.Command {
+	ic := &intercept.Command{}
+	cmd := &cobra.Command{
+		Use:   "ingest [flags] <ingest_name> [-- <command with arguments...>]",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Ingest a container",
+		Long: `Ingest a container, i.e. make its environment and volume mounts available locally without routing any
+of its traffic to this workstation. An ingest never affects the traffic to the workload, and any number of
+clients can ingest the same container. Use "telepresence leave <ingest_name>" to end it.`,
+		Annotations: map[string]string{
+			ann.Session:           ann.Required,
+			ann.UpdateCheckFormat: ann.Tel2,
+		},
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		RunE:              ic.Run,
+		ValidArgsFunction: ic.ValidArgs,
+	}
+	ic.AddIngestFlags(cmd)
+	return cmd
+}
@@ -11,7 +11,7 @@ func interceptCmd() *cobra.Command {
 	ic := &intercept.Command{}
 	cmd := &cobra.Command{
 		Use:   "intercept [flags] <intercept_base_name> [-- <command with arguments...>]",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  cobra.ArbitraryArgs,
 		Short: "Intercept a service",
 		Annotations: map[string]string{
 			ann.Session:           ann.Required,
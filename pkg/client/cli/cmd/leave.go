@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -21,19 +22,37 @@ import (
 )
 
 func leave() *cobra.Command {
-	return &cobra.Command{
-		Use:  "leave [flags] <intercept_name>",
-		Args: cobra.ExactArgs(1),
+	var spec string
+	cmd := &cobra.Command{
+		Use: "leave [flags] <intercept_name>",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if spec != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 
 		Short: "Remove existing intercept",
 		Annotations: map[string]string{
 			ann.Session: ann.Required,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var names []string
+			if spec != "" {
+				gs, err := intercept.LoadGroupSpec(spec)
+				if err != nil {
+					return errcat.User.New(err)
+				}
+				for _, e := range gs.Intercepts {
+					names = append(names, e.Name)
+				}
+			} else {
+				names = []string{strings.TrimSpace(args[0])}
+			}
 			if err := connect.InitCommand(cmd); err != nil {
 				return err
 			}
-			return removeIntercept(cmd.Context(), strings.TrimSpace(args[0]))
+			return removeIntercepts(cmd.Context(), names)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			shellCompDir := cobra.ShellCompDirectiveNoFileComp
@@ -65,6 +84,20 @@ func leave() *cobra.Command {
 			return completions, shellCompDir
 		},
 	}
+	cmd.Flags().StringVar(&spec, "spec", "", "Remove all intercepts of the group declared in this YAML file")
+	return cmd
+}
+
+// removeIntercepts removes the intercepts with the given names in reverse order. All intercepts are
+// attempted, and the errors are joined.
+func removeIntercepts(ctx context.Context, names []string) error {
+	var errs []error
+	for i := len(names) - 1; i >= 0; i-- {
+		if err := removeIntercept(ctx, names[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func removeIntercept(ctx context.Context, name string) error {
@@ -72,6 +105,10 @@ func removeIntercept(ctx context.Context, name string) error {
 	ic, err := userD.GetIntercept(ctx, &manager.GetInterceptRequest{Name: name})
 	if err != nil {
 		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			if intercept.Forget(ctx, name) {
+				// The intercept ended when the daemon died, and will no longer be resumed.
+				return nil
+			}
 			// User probably misspelled the name of the intercept
 			return errcat.User.Newf("Intercept named %q not found", name)
 		}
@@ -92,5 +129,8 @@ func removeIntercept(ctx context.Context, name string) error {
 			err = nil
 		}
 	}
+	if err == nil {
+		intercept.Forget(ctx, name)
+	}
 	return err
 }
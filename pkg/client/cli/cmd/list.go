@@ -54,10 +54,9 @@ func list() *cobra.Command {
 	flags.BoolVar(&s.debug, "debug", false, "include debugging information")
 	flags.StringVarP(&s.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
 
-	flags.BoolVarP(&s.watch, "watch", "w", false, "watch a namespace. --agents and --intercepts are disabled if this flag is set")
-	wf := flags.Lookup("watch")
-	wf.Hidden = true
-	wf.Deprecated = `Use "--output json-stream" instead of "--watch"`
+	flags.BoolVarP(&s.watch, "watch", "w", false,
+		"keep running and print incremental updates as workloads, agents, and intercepts change. "+
+			"Use together with --output json-stream to get one JSON object per update")
 
 	_ = cmd.RegisterFlagCompletionFunc("namespace", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		shellCompDir := cobra.ShellCompDirectiveNoFileComp
@@ -115,7 +114,10 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 	}
 
 	formattedOutput := output.WantsFormatted(cmd)
-	if !output.WantsStream(cmd) {
+	if s.watch && formattedOutput && !output.WantsStream(cmd) {
+		return errcat.User.New(`--watch can only be combined with --output json-stream`)
+	}
+	if !(s.watch || output.WantsStream(cmd)) {
 		r, err := userD.List(ctx, &connector.ListRequest{Filter: filter, Namespace: s.namespace}, grpc.MaxCallRecvMsgSize(int(maxRecSize)))
 		if err != nil {
 			return err
@@ -144,6 +146,8 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 		}
 	}()
 
+	var prev []*connector.WorkloadInfo
+	first := true
 	for {
 		select {
 		case r, ok := <-ch:
@@ -153,7 +157,18 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 			if r.err != nil {
 				return errcat.NoDaemonLogs.Newf("%v", r.err)
 			}
-			s.printList(ctx, r.workloadInfoSnapshot.Workloads, stdout, formattedOutput)
+			wls := r.workloadInfoSnapshot.Workloads
+			switch {
+			case !s.watch:
+				s.printList(ctx, wls, stdout, formattedOutput)
+			case first && !formattedOutput:
+				// Start with the full list, and then print the changes.
+				s.printList(ctx, wls, stdout, false)
+			default:
+				printEvents(ctx, diffWorkloads(prev, wls), stdout, formattedOutput)
+			}
+			prev = wls
+			first = false
 		case <-ctx.Done():
 			return nil
 		}
@@ -165,23 +180,24 @@ func (s *listCommand) printList(ctx context.Context, workloads []*connector.Work
 		if formattedOut {
 			output.Object(ctx, []struct{}{}, false)
 		} else {
-			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, or ReplicaSets)")
+			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, ReplicaSets, DaemonSets, or DeploymentConfigs)")
 		}
 		return
 	}
 
+	r := output.NewRenderer(ctx, stdout)
 	state := func(workload *connector.WorkloadInfo) string {
 		if iis := workload.InterceptInfos; len(iis) > 0 {
 			return intercept.DescribeIntercepts(ctx, iis, "", s.debug)
 		}
 		ai := workload.Sidecar
 		if ai != nil {
-			return "ready to intercept (traffic-agent already installed)"
+			return r.Colorize(output.Green, "ready to intercept (traffic-agent already installed)")
 		}
 		if workload.NotInterceptableReason != "" {
-			return "not interceptable (traffic-agent not installed): " + workload.NotInterceptableReason
+			return r.Colorize(output.Yellow, "not interceptable (traffic-agent not installed): "+workload.NotInterceptableReason)
 		} else {
-			return "ready to intercept (traffic-agent not yet installed)"
+			return r.Colorize(output.Green, "ready to intercept (traffic-agent not yet installed)")
 		}
 	}
 
@@ -215,36 +231,26 @@ func (s *listCommand) printList(ctx context.Context, workloads []*connector.Work
 			}
 			ns = depNs
 		}
-		nameLen := 0
-		for _, dep := range workloads {
-			n := dep.Name
-			if n == "" {
+		rows := make([][]string, len(workloads))
+		for i, workload := range workloads {
+			var n, st string
+			if workload.Name == "" {
 				// Local-only, so use name of first intercept
-				n = dep.InterceptInfos[0].Spec.Name
+				n = workload.InterceptInfos[0].Spec.Name
+				st = "local-only intercept"
+			} else {
+				n = workload.Name
+				st = state(workload)
 			}
-			nl := len(n)
 			if includeNs {
-				nl += len(dep.Namespace) + 1
+				n += "." + workload.Namespace
 			}
-			if nl > nameLen {
-				nameLen = nl
-			}
-		}
-		for _, workload := range workloads {
-			if workload.Name == "" {
-				// Local-only, so use name of first intercept
-				n := workload.InterceptInfos[0].Spec.Name
-				if includeNs {
-					n += "." + workload.Namespace
-				}
-				fmt.Fprintf(stdout, "%-*s: local-only intercept\n", nameLen, n)
+			if r.Quiet() {
+				rows[i] = []string{n}
 			} else {
-				n := workload.Name
-				if includeNs {
-					n += "." + workload.Namespace
-				}
-				fmt.Fprintf(stdout, "%-*s: %s\n", nameLen, n, state(workload))
+				rows[i] = []string{n, st}
 			}
 		}
+		r.Table(": ", rows)
 	}
 }
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+)
+
+// Event types that are emitted by "telepresence list --watch".
+const (
+	eventWorkloadAdded    = "workload-added"
+	eventWorkloadRemoved  = "workload-removed"
+	eventAgentInstalled   = "agent-installed"
+	eventAgentRemoved     = "agent-removed"
+	eventInterceptStarted = "intercept-started"
+	eventInterceptChanged = "intercept-changed"
+	eventInterceptEnded   = "intercept-ended"
+)
+
+// workloadEvent describes one incremental change between two consecutive workload snapshots.
+type workloadEvent struct {
+	Event     string `json:"event"               yaml:"event"`
+	Workload  string `json:"workload"            yaml:"workload"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Intercept string `json:"intercept,omitempty" yaml:"intercept,omitempty"`
+	State     string `json:"state,omitempty"     yaml:"state,omitempty"`
+	Message   string `json:"message,omitempty"   yaml:"message,omitempty"`
+}
+
+type workloadKey struct {
+	name      string
+	namespace string
+}
+
+func keyOf(wl *connector.WorkloadInfo) workloadKey {
+	if wl.Name == "" && len(wl.InterceptInfos) > 0 {
+		// Local-only, so use name and namespace of first intercept
+		spec := wl.InterceptInfos[0].Spec
+		return workloadKey{name: spec.Name, namespace: spec.Namespace}
+	}
+	return workloadKey{name: wl.Name, namespace: wl.Namespace}
+}
+
+func workloadMap(wls []*connector.WorkloadInfo) map[workloadKey]*connector.WorkloadInfo {
+	m := make(map[workloadKey]*connector.WorkloadInfo, len(wls))
+	for _, wl := range wls {
+		m[keyOf(wl)] = wl
+	}
+	return m
+}
+
+func interceptMap(wl *connector.WorkloadInfo) map[string]*manager.InterceptInfo {
+	if wl == nil {
+		return nil
+	}
+	m := make(map[string]*manager.InterceptInfo, len(wl.InterceptInfos))
+	for _, ii := range wl.InterceptInfos {
+		m[ii.Id] = ii
+	}
+	return m
+}
+
+// diffWorkloads compares two snapshots and returns the events needed to get from the old to the new
+// snapshot. The events are sorted by namespace and workload name.
+func diffWorkloads(oldWls, newWls []*connector.WorkloadInfo) []*workloadEvent {
+	om := workloadMap(oldWls)
+	nm := workloadMap(newWls)
+	keys := make([]workloadKey, 0, len(om)+len(nm))
+	for k := range om {
+		keys = append(keys, k)
+	}
+	for k := range nm {
+		if _, ok := om[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace == keys[j].namespace {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].namespace < keys[j].namespace
+	})
+
+	var events []*workloadEvent
+	for _, k := range keys {
+		ow, nw := om[k], nm[k]
+		ev := func(event string) *workloadEvent {
+			return &workloadEvent{Event: event, Workload: k.name, Namespace: k.namespace}
+		}
+		switch {
+		case ow == nil:
+			e := ev(eventWorkloadAdded)
+			e.Message = nw.NotInterceptableReason
+			events = append(events, e)
+			if nw.Sidecar != nil {
+				events = append(events, ev(eventAgentInstalled))
+			}
+		case nw == nil:
+			events = append(events, ev(eventWorkloadRemoved))
+		case ow.Sidecar == nil && nw.Sidecar != nil:
+			events = append(events, ev(eventAgentInstalled))
+		case ow.Sidecar != nil && nw.Sidecar == nil:
+			events = append(events, ev(eventAgentRemoved))
+		}
+
+		oim, nim := interceptMap(ow), interceptMap(nw)
+		if nw != nil {
+			for _, ii := range nw.InterceptInfos {
+				oi, ok := oim[ii.Id]
+				switch {
+				case !ok:
+					e := ev(eventInterceptStarted)
+					e.Intercept, e.State, e.Message = ii.Spec.Name, ii.Disposition.String(), ii.Message
+					events = append(events, e)
+				case oi.Disposition != ii.Disposition || oi.Message != ii.Message:
+					e := ev(eventInterceptChanged)
+					e.Intercept, e.State, e.Message = ii.Spec.Name, ii.Disposition.String(), ii.Message
+					events = append(events, e)
+				}
+			}
+		}
+		if ow != nil {
+			for _, ii := range ow.InterceptInfos {
+				if _, ok := nim[ii.Id]; !ok {
+					e := ev(eventInterceptEnded)
+					e.Intercept = ii.Spec.Name
+					events = append(events, e)
+				}
+			}
+		}
+	}
+	return events
+}
+
+// printEvents prints the given events, either as JSON lines when formatted output is requested, or
+// as human-readable lines otherwise.
+func printEvents(ctx context.Context, events []*workloadEvent, stdout io.Writer, formattedOut bool) {
+	if formattedOut {
+		for _, e := range events {
+			output.Object(ctx, e, true)
+		}
+		return
+	}
+	r := output.NewRenderer(ctx, stdout)
+	for _, e := range events {
+		n := e.Workload
+		if e.Namespace != "" {
+			n += "." + e.Namespace
+		}
+		if r.Quiet() {
+			fmt.Fprintf(r, "%s %s\n", e.Event, n)
+			continue
+		}
+		var msg string
+		switch e.Event {
+		case eventWorkloadAdded:
+			msg = r.Colorize(output.Green, "added")
+			if e.Message != "" {
+				msg += ", " + r.Colorize(output.Yellow, "not interceptable: "+e.Message)
+			}
+		case eventWorkloadRemoved:
+			msg = r.Colorize(output.Yellow, "removed")
+		case eventAgentInstalled:
+			msg = r.Colorize(output.Green, "traffic-agent installed")
+		case eventAgentRemoved:
+			msg = r.Colorize(output.Yellow, "traffic-agent removed")
+		case eventInterceptStarted, eventInterceptChanged:
+			c := output.Yellow
+			switch e.State {
+			case manager.InterceptDispositionType_ACTIVE.String():
+				c = output.Green
+			case manager.InterceptDispositionType_WAITING.String():
+			default:
+				c = output.Red
+			}
+			verb := "started"
+			if e.Event == eventInterceptChanged {
+				verb = "changed"
+			}
+			msg = fmt.Sprintf("intercept %s %s: %s", e.Intercept, verb, r.Colorize(c, e.State))
+			if e.Message != "" {
+				msg += " (" + e.Message + ")"
+			}
+		case eventInterceptEnded:
+			msg = fmt.Sprintf("intercept %s ended", e.Intercept)
+		}
+		fmt.Fprintf(r, "%s: %s\n", n, msg)
+	}
+}
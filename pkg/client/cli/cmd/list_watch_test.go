@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_diffWorkloads(t *testing.T) {
+	ii := func(id string, d manager.InterceptDispositionType) *manager.InterceptInfo {
+		return &manager.InterceptInfo{Id: id, Spec: &manager.InterceptSpec{Name: "echo", Namespace: "default"}, Disposition: d}
+	}
+	sidecar := &connector.WorkloadInfo_Sidecar{Json: []byte("{}")}
+
+	echo := &connector.WorkloadInfo{Name: "echo", Namespace: "default"}
+	echoAgent := &connector.WorkloadInfo{Name: "echo", Namespace: "default", Sidecar: sidecar}
+	echoWaiting := &connector.WorkloadInfo{
+		Name: "echo", Namespace: "default", Sidecar: sidecar,
+		InterceptInfos: []*manager.InterceptInfo{ii("1", manager.InterceptDispositionType_WAITING)},
+	}
+	echoActive := &connector.WorkloadInfo{
+		Name: "echo", Namespace: "default", Sidecar: sidecar,
+		InterceptInfos: []*manager.InterceptInfo{ii("1", manager.InterceptDispositionType_ACTIVE)},
+	}
+	hello := &connector.WorkloadInfo{Name: "hello", Namespace: "default", NotInterceptableReason: "no service"}
+
+	tests := []struct {
+		name     string
+		old, new []*connector.WorkloadInfo
+		want     []*workloadEvent
+	}{
+		{
+			name: "initial",
+			new:  []*connector.WorkloadInfo{hello, echo},
+			want: []*workloadEvent{
+				{Event: eventWorkloadAdded, Workload: "echo", Namespace: "default"},
+				{Event: eventWorkloadAdded, Workload: "hello", Namespace: "default", Message: "no service"},
+			},
+		},
+		{
+			name: "no change",
+			old:  []*connector.WorkloadInfo{echo, hello},
+			new:  []*connector.WorkloadInfo{echo, hello},
+		},
+		{
+			name: "removed",
+			old:  []*connector.WorkloadInfo{echo, hello},
+			new:  []*connector.WorkloadInfo{echo},
+			want: []*workloadEvent{{Event: eventWorkloadRemoved, Workload: "hello", Namespace: "default"}},
+		},
+		{
+			name: "agent installed",
+			old:  []*connector.WorkloadInfo{echo},
+			new:  []*connector.WorkloadInfo{echoAgent},
+			want: []*workloadEvent{{Event: eventAgentInstalled, Workload: "echo", Namespace: "default"}},
+		},
+		{
+			name: "intercept started",
+			old:  []*connector.WorkloadInfo{echoAgent},
+			new:  []*connector.WorkloadInfo{echoWaiting},
+			want: []*workloadEvent{{Event: eventInterceptStarted, Workload: "echo", Namespace: "default", Intercept: "echo", State: "WAITING"}},
+		},
+		{
+			name: "intercept changed",
+			old:  []*connector.WorkloadInfo{echoWaiting},
+			new:  []*connector.WorkloadInfo{echoActive},
+			want: []*workloadEvent{{Event: eventInterceptChanged, Workload: "echo", Namespace: "default", Intercept: "echo", State: "ACTIVE"}},
+		},
+		{
+			name: "intercept ended",
+			old:  []*connector.WorkloadInfo{echoActive},
+			new:  []*connector.WorkloadInfo{echoAgent},
+			want: []*workloadEvent{{Event: eventInterceptEnded, Workload: "echo", Namespace: "default", Intercept: "echo"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffWorkloads(tt.old, tt.new))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -11,10 +12,12 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
 
 const defaultDuration = 30 * time.Minute
@@ -23,6 +26,7 @@ type logLevelCommand struct {
 	duration   time.Duration
 	localOnly  bool
 	remoteOnly bool
+	profile    bool
 }
 
 func logLevelArg(cmd *cobra.Command, args []string) error {
@@ -61,6 +65,9 @@ func loglevel() *cobra.Command {
 	flags.DurationVarP(&lls.duration, "duration", "d", defaultDuration, "The time that the log-level will be in effect (0s means indefinitely)")
 	flags.BoolVarP(&lls.localOnly, "local-only", "l", false, "Only affect the user and root daemons")
 	flags.BoolVarP(&lls.remoteOnly, "remote-only", "r", false, "Only affect the traffic-manager and traffic-agents")
+	flags.BoolVarP(&lls.profile, "profile", "p", false,
+		"Also start debug HTTP listeners on the user and root daemons that serve pprof profiles, goroutine dumps, "+
+			"and runtime metrics during the given duration")
 	return cmd
 }
 
@@ -69,6 +76,8 @@ func (lls *logLevelCommand) setTempLogLevel(cmd *cobra.Command, args []string) e
 	switch {
 	case lls.localOnly && lls.remoteOnly:
 		return errcat.User.New("the local-only and remote-only options are mutually exclusive")
+	case lls.profile && lls.remoteOnly:
+		return errcat.User.New("the profile and remote-only options are mutually exclusive")
 	case lls.localOnly:
 		rq.Scope = connector.LogLevelRequest_LOCAL_ONLY
 	case lls.remoteOnly:
@@ -80,6 +89,22 @@ func (lls *logLevelCommand) setTempLogLevel(cmd *cobra.Command, args []string) e
 	}
 	ctx := cmd.Context()
 	userD := daemon.GetUserClient(ctx)
-	_, err := userD.SetLogLevel(ctx, rq)
-	return err
+	if _, err := userD.SetLogLevel(ctx, rq); err != nil || !lls.profile {
+		return err
+	}
+	pi, err := userD.Profiling(ctx, &daemonRpc.ProfilingRequest{Duration: rq.Duration})
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	printProfilingAddress(out, "User daemon", pi.UserDaemonAddress)
+	printProfilingAddress(out, "Root daemon", pi.RootDaemonAddress)
+	return nil
+}
+
+func printProfilingAddress(out io.Writer, daemonName, addr string) {
+	if addr != "" {
+		ioutil.Printf(out, "%s debug listener: http://%s/debug/pprof/, http://%s/debug/goroutines, http://%s/debug/metrics\n",
+			daemonName, addr, addr, addr)
+	}
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+type logsCommand struct {
+	follow bool
+	since  time.Duration
+}
+
+func logsCmd() *cobra.Command {
+	lc := &logsCommand{}
+	cmd := &cobra.Command{
+		Use:   "logs [traffic-manager|<workload>]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Stream the logs of the traffic-manager and the traffic-agents",
+		Long: `Stream the logs of the traffic-manager and the traffic-agents.
+
+The logs of the traffic-manager and of all traffic-agents in the connected namespace are streamed unless
+"traffic-manager" or the name of a workload is given, in which case only the logs of the traffic-manager
+or of the traffic-agents of that workload are streamed. Each line is prefixed with the name of its pod.`,
+		Example: `  telepresence logs -f --since 10m
+  telepresence logs traffic-manager
+  telepresence logs my-service -f`,
+		RunE: lc.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	flags := cmd.Flags()
+	flags.BoolVarP(&lc.follow, "follow", "f", false, "Keep streaming the logs as they are written")
+	flags.DurationVar(&lc.since, "since", 0, "Only show the lines that were written during this duration, e.g. 10m")
+	return cmd
+}
+
+func (lc *logsCommand) run(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	rq := &connector.StreamLogsRequest{Follow: lc.follow}
+	if len(args) > 0 {
+		rq.Name = args[0]
+	}
+	if lc.since > 0 {
+		rq.Since = durationpb.New(lc.since)
+	}
+	stream, err := daemon.GetUserClient(ctx).StreamLogs(ctx, rq)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	for {
+		ll, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		ioutil.Printf(out, "[%s] %s\n", ll.Pod, ll.Line)
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
 )
 
 func quit() *cobra.Command {
@@ -14,14 +16,20 @@ func quit() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Short: "Tell telepresence daemons to quit",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			// Intercepts that end because the user quits must not be resumed.
 			if quitDaemons {
 				connect.Quit(cmd.Context())
+				intercept.ForgetAll(cmd.Context(), "")
 			} else {
 				cmd.Annotations = map[string]string{ann.UserDaemon: ann.Optional}
 				if err := connect.InitCommand(cmd); err != nil {
 					return err
 				}
-				connect.Disconnect(cmd.Context())
+				ctx := cmd.Context()
+				connect.Disconnect(ctx)
+				if ud := daemon.GetUserClient(ctx); ud != nil {
+					intercept.ForgetAll(ctx, ud.DaemonID().Name)
+				}
 			}
 			return nil
 		},
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+func resume() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume [flags] [<intercept_name>...]",
+		Args:  cobra.ArbitraryArgs,
+		Short: "Recreate intercepts that ended when the daemons stopped",
+		Long: `Recreate the intercepts of the current connection that ended because the daemons stopped, e.g. when
+the user daemon crashed or the machine rebooted. The intercepts are recreated using the flags, the handler
+command, the mounts, and the env files that they were created with. All such intercepts are resumed when no
+names are given.
+
+Intercepts that are left using "telepresence leave", or that end because of "telepresence quit", are never
+resumed.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			formatted := output.WantsFormatted(cmd)
+			ctx := dos.WithStdio(cmd.Context(), cmd)
+			return intercept.Resume(ctx, args, formatted, !formatted && output.WantsQuiet(cmd))
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/service"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+func serviceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Run the telepresence daemons as services of the operating system",
+	}
+	cmd.AddCommand(serviceInstall(), serviceUninstall())
+	return cmd
+}
+
+func serviceInstall() *cobra.Command {
+	linger := false
+	cmd := &cobra.Command{
+		Use:   "install",
+		Args:  cobra.NoArgs,
+		Short: "Install the root and user daemons as services",
+		Long: `Install the root and user daemons as services of the operating system's service manager, so that
+telepresence connect never needs admin privileges after the installation.
+
+On Linux, the daemons are systemd services that are started on demand when a client connects to their
+sockets. On macOS, they are launchd services that are kept running.
+
+The user daemon stops when the user logs out, unless --linger is used.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			cfg, err := service.NewConfig(ctx, linger)
+			if err != nil {
+				return err
+			}
+			// The services must own the sockets, so the daemons that are running now must quit.
+			connect.Quit(ctx)
+			if err = service.Install(ctx, cfg); err != nil {
+				return err
+			}
+			ioutil.Println(output.Out(ctx), "Telepresence daemons installed as services")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&linger, "linger", false, "keep the user daemon running when the user logs out")
+	return cmd
+}
+
+func serviceUninstall() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Args:  cobra.NoArgs,
+		Short: "Stop the daemon services and remove them",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if err := service.Uninstall(ctx); err != nil {
+				return err
+			}
+			ioutil.Println(output.Out(ctx), "Telepresence daemon services removed")
+			return nil
+		},
+	}
+}
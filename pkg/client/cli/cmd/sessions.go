@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func sessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Administer the client sessions of the traffic-manager",
+	}
+	cmd.AddCommand(sessionsList(), sessionsKill())
+	return cmd
+}
+
+func sessionsList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Args:  cobra.NoArgs,
+		Short: "List the client sessions that are connected to the traffic-manager",
+		Long: "List the client sessions that are connected to the traffic-manager. The session IDs are abbreviated " +
+			"unless the traffic-manager authorizes the user to list sessions.",
+		RunE: listSessions,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func sessionsKill() *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <session_id>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Evict a client session from the traffic-manager and remove its intercepts",
+		RunE:  killSession,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func listSessions(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	r, err := daemon.GetUserClient(ctx).ListClientSessions(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, r.Sessions, false)
+		return nil
+	}
+
+	rd := output.NewRenderer(ctx, cmd.OutOrStdout())
+	rows := make([][]string, 0, len(r.Sessions)+1)
+	if !rd.Quiet() {
+		rows = append(rows, []string{"SESSION ID", "CLIENT", "VERSION", "NAMESPACES", "INTERCEPTS", "LAST ACTIVE"})
+	}
+	for _, s := range r.Sessions {
+		if rd.Quiet() {
+			rows = append(rows, []string{s.SessionId})
+			continue
+		}
+		lastActive := "unknown"
+		if s.LastActive != nil {
+			lastActive = time.Since(s.LastActive.AsTime()).Truncate(time.Second).String() + " ago"
+		}
+		rows = append(rows, []string{
+			s.SessionId,
+			s.Client.GetName(),
+			s.Client.GetVersion(),
+			strings.Join(s.Namespaces, ","),
+			strings.Join(s.Intercepts, ","),
+			lastActive,
+		})
+	}
+	rd.Table("  ", rows)
+	return nil
+}
+
+func killSession(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	sessionID := strings.TrimSpace(args[0])
+	if sessionID == daemon.GetSession(ctx).Info.GetSessionInfo().GetSessionId() {
+		return errcat.User.New("refusing to evict the session of this client, use telepresence quit instead")
+	}
+	_, err := daemon.GetUserClient(ctx).EvictClientSession(ctx, &manager.SessionInfo{SessionId: sessionID})
+	switch status.Code(err) {
+	case codes.NotFound:
+		return errcat.User.Newf("client session %q not found", sessionID)
+	case codes.PermissionDenied:
+		return errcat.User.New(status.Convert(err).Message())
+	}
+	return err
+}
@@ -6,12 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
@@ -24,9 +30,10 @@ import (
 )
 
 type StatusInfo struct {
-	RootDaemon     RootDaemonStatus     `json:"root_daemon" yaml:"root_daemon"`
-	UserDaemon     UserDaemonStatus     `json:"user_daemon" yaml:"user_daemon"`
-	TrafficManager TrafficManagerStatus `json:"traffic_manager" yaml:"traffic_manager"`
+	RootDaemon        RootDaemonStatus     `json:"root_daemon" yaml:"root_daemon"`
+	UserDaemon        UserDaemonStatus     `json:"user_daemon" yaml:"user_daemon"`
+	TrafficManager    TrafficManagerStatus `json:"traffic_manager" yaml:"traffic_manager"`
+	ConnectionQuality *ConnectionQuality   `json:"connection_quality,omitempty" yaml:"connection_quality,omitempty"`
 }
 
 type MultiConnectStatusInfo struct {
@@ -46,6 +53,37 @@ type RootDaemonStatus struct {
 	APIVersion           int32            `json:"api_version,omitempty" yaml:"api_version,omitempty"`
 	DNS                  *client.DNSSnake `json:"dns,omitempty" yaml:"dns,omitempty"`
 	*client.RoutingSnake `yaml:",inline"`
+	SubnetConflicts      []SubnetConflict `json:"subnet_conflicts,omitempty" yaml:"subnet_conflicts,omitempty"`
+	DNSCache             *DNSCacheStats   `json:"dns_cache,omitempty" yaml:"dns_cache,omitempty"`
+	Connections          []ConnectionInfo `json:"connections,omitempty" yaml:"connections,omitempty"`
+}
+
+// ConnectionInfo describes one of the connections to a cluster that the root daemon maintains.
+type ConnectionInfo struct {
+	Name            string           `json:"name" yaml:"name"`
+	SessionID       string           `json:"session_id,omitempty" yaml:"session_id,omitempty"`
+	Namespace       string           `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Subnets         []*iputil.Subnet `json:"subnets,omitempty" yaml:"subnets,omitempty"`
+	DNSDomains      []string         `json:"dns_domains,omitempty" yaml:"dns_domains,omitempty"`
+	SubnetConflicts []SubnetConflict `json:"subnet_conflicts,omitempty" yaml:"subnet_conflicts,omitempty"`
+}
+
+// DNSCacheStats contains statistics for the cache of the root daemon's DNS resolver.
+type DNSCacheStats struct {
+	Entries         int32 `json:"entries" yaml:"entries"`
+	NegativeEntries int32 `json:"negative_entries" yaml:"negative_entries"`
+	Hits            int64 `json:"hits" yaml:"hits"`
+	Misses          int64 `json:"misses" yaml:"misses"`
+}
+
+// SubnetConflict is a cluster subnet that overlaps with a route on the host, or with a subnet of
+// another connection.
+type SubnetConflict struct {
+	Subnet     *iputil.Subnet `json:"subnet" yaml:"subnet"`
+	Route      *iputil.Subnet `json:"route" yaml:"route"`
+	Interface  string         `json:"interface,omitempty" yaml:"interface,omitempty"`
+	Connection string         `json:"connection,omitempty" yaml:"connection,omitempty"`
+	Remapped   bool           `json:"remapped,omitempty" yaml:"remapped,omitempty"`
 }
 
 type UserDaemonStatus struct {
@@ -67,6 +105,7 @@ type UserDaemonStatus struct {
 	ManagerNamespace  string                   `json:"manager_namespace,omitempty" yaml:"manager_namespace,omitempty"`
 	MappedNamespaces  []string                 `json:"mapped_namespaces,omitempty" yaml:"mapped_namespaces,omitempty"`
 	Intercepts        []ConnectStatusIntercept `json:"intercepts,omitempty" yaml:"intercepts,omitempty"`
+	LastManagerPing   *time.Time               `json:"last_manager_ping,omitempty" yaml:"last_manager_ping,omitempty"`
 	versionName       string
 }
 
@@ -77,10 +116,46 @@ type ContainerizedDaemonStatus struct {
 }
 
 type TrafficManagerStatus struct {
-	Name         string `json:"name,omitempty" yaml:"name,omitempty"`
-	Version      string `json:"version,omitempty" yaml:"version,omitempty"`
-	TrafficAgent string `json:"traffic_agent,omitempty" yaml:"traffic_agent,omitempty"`
-	extendedInfo ioutil.KeyValueProvider
+	Name          string               `json:"name,omitempty" yaml:"name,omitempty"`
+	Version       string               `json:"version,omitempty" yaml:"version,omitempty"`
+	TrafficAgent  string               `json:"traffic_agent,omitempty" yaml:"traffic_agent,omitempty"`
+	AgentConfigGC *AgentConfigGCStatus `json:"agent_config_gc,omitempty" yaml:"agent_config_gc,omitempty"`
+	Tunnels       []TunnelStats        `json:"tunnels,omitempty" yaml:"tunnels,omitempty"`
+	extendedInfo  ioutil.KeyValueProvider
+}
+
+// TunnelStats contains the traffic-manager's statistics of one active tunneled connection.
+type TunnelStats struct {
+	ConnID          string        `json:"conn_id" yaml:"conn_id"`
+	FromClientBytes uint64        `json:"from_client_bytes" yaml:"from_client_bytes"`
+	ToClientBytes   uint64        `json:"to_client_bytes" yaml:"to_client_bytes"`
+	StartTime       time.Time     `json:"start_time" yaml:"start_time"`
+	RTT             time.Duration `json:"rtt,omitempty" yaml:"rtt,omitempty"`
+}
+
+// ConnectionQuality contains measurements that help telling problems with the cluster from problems with
+// Telepresence.
+type ConnectionQuality struct {
+	ManagerRTT          time.Duration `json:"manager_rtt,omitempty" yaml:"manager_rtt,omitempty"`
+	TunnelBytesSent     uint64        `json:"tunnel_bytes_sent_last_minute" yaml:"tunnel_bytes_sent_last_minute"`
+	TunnelBytesReceived uint64        `json:"tunnel_bytes_received_last_minute" yaml:"tunnel_bytes_received_last_minute"`
+	DNSLookupLatency    time.Duration `json:"dns_lookup_latency,omitempty" yaml:"dns_lookup_latency,omitempty"`
+	PortForwardRestarts int64         `json:"port_forward_restarts" yaml:"port_forward_restarts"`
+}
+
+// AgentConfigGCStatus reports what the traffic-manager's garbage collector of orphaned agent config
+// entries has done.
+type AgentConfigGCStatus struct {
+	LastRun *time.Time           `json:"last_run,omitempty" yaml:"last_run,omitempty"`
+	Cleaned []CleanedAgentConfig `json:"cleaned,omitempty" yaml:"cleaned,omitempty"`
+}
+
+type CleanedAgentConfig struct {
+	Name      string    `json:"name" yaml:"name"`
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	Kind      string    `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Reason    string    `json:"reason" yaml:"reason"`
+	Time      time.Time `json:"time" yaml:"time"`
 }
 
 type ConnectStatusIntercept struct {
@@ -91,6 +166,11 @@ type ConnectStatusIntercept struct {
 const (
 	multiDaemonFlag = "multi-daemon"
 	jsonFlag        = "json"
+	allFlag         = "all"
+	tunnelsFlag     = "tunnels"
+
+	// maxTunnels is the maximum number of tunnels that are shown by status --tunnels.
+	maxTunnels = 10
 )
 
 func statusCmd() *cobra.Command {
@@ -107,6 +187,8 @@ func statusCmd() *cobra.Command {
 	}
 	flags := cmd.Flags()
 	flags.Bool(multiDaemonFlag, false, "always use multi-daemon output format, even if there's only one daemon connected")
+	flags.Bool(allFlag, false, "show the routes and DNS domains of all connections that the root daemon maintains")
+	flags.Bool(tunnelsFlag, false, fmt.Sprintf("show the %d busiest active tunneled connections", maxTunnels))
 	flags.BoolP(jsonFlag, "j", false, "output as json object")
 	flags.Lookup(jsonFlag).Hidden = true
 	return cmd
@@ -137,6 +219,8 @@ func run(cmd *cobra.Command, _ []string) error {
 		}
 	}
 	ctx := cmd.Context()
+	all, _ := cmd.Flags().GetBool(allFlag)
+	tunnels, _ := cmd.Flags().GetBool(tunnelsFlag)
 
 	var sis []ioutil.WriterTos
 	if len(mdErr) > 0 {
@@ -146,14 +230,14 @@ func run(cmd *cobra.Command, _ []string) error {
 			if err != nil {
 				return err
 			}
-			sis[i], err = getStatusInfo(udCtx, info)
+			sis[i], err = getStatusInfo(udCtx, info, all, tunnels)
 			_ = daemon.GetUserClient(udCtx).Close()
 			if err != nil {
 				return err
 			}
 		}
 	} else {
-		si, err := getStatusInfo(ctx, nil)
+		si, err := getStatusInfo(ctx, nil, all, tunnels)
 		if err != nil {
 			return err
 		}
@@ -185,7 +269,7 @@ func run(cmd *cobra.Command, _ []string) error {
 	if output.WantsFormatted(cmd) {
 		output.Object(ctx, &as, true)
 	} else {
-		_, _ = ioutil.WriteAllTo(cmd.OutOrStdout(), as.WriterTos()...)
+		_, _ = ioutil.WriteAllTo(output.CmdRenderer(cmd), as.WriterTos()...)
 	}
 	return nil
 }
@@ -205,8 +289,9 @@ var GetTrafficManagerStatusExtras = func(context.Context, daemon.UserClient) iou
 }
 
 func (s *StatusInfo) WriterTos() []io.WriterTo {
+	var wts []io.WriterTo
 	if s.UserDaemon.InDocker {
-		return []io.WriterTo{
+		wts = []io.WriterTo{
 			&ContainerizedDaemonStatus{
 				UserDaemonStatus: &s.UserDaemon,
 				DNS:              s.RootDaemon.DNS,
@@ -214,8 +299,13 @@ func (s *StatusInfo) WriterTos() []io.WriterTo {
 			},
 			&s.TrafficManager,
 		}
+	} else {
+		wts = []io.WriterTo{&s.UserDaemon, &s.RootDaemon, &s.TrafficManager}
+	}
+	if s.ConnectionQuality != nil {
+		wts = append(wts, s.ConnectionQuality)
 	}
-	return []io.WriterTo{&s.UserDaemon, &s.RootDaemon, &s.TrafficManager}
+	return wts
 }
 
 func (s *StatusInfo) MarshalJSON() ([]byte, error) {
@@ -227,8 +317,9 @@ func (s *StatusInfo) MarshalYAML() (any, error) {
 }
 
 func (s *StatusInfo) toMap() map[string]any {
+	var m map[string]any
 	if s.UserDaemon.InDocker {
-		return map[string]any{
+		m = map[string]any{
 			"daemon": &ContainerizedDaemonStatus{
 				UserDaemonStatus: &s.UserDaemon,
 				DNS:              s.RootDaemon.DNS,
@@ -236,15 +327,23 @@ func (s *StatusInfo) toMap() map[string]any {
 			},
 			"traffic_manager": &s.TrafficManager,
 		}
+	} else {
+		m = map[string]any{
+			"user_daemon":     &s.UserDaemon,
+			"root_daemon":     &s.RootDaemon,
+			"traffic_manager": &s.TrafficManager,
+		}
 	}
-	return map[string]any{
-		"user_daemon":     &s.UserDaemon,
-		"root_daemon":     &s.RootDaemon,
-		"traffic_manager": &s.TrafficManager,
+	if s.ConnectionQuality != nil {
+		m["connection_quality"] = s.ConnectionQuality
 	}
+	return m
 }
 
-func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
+// getStatusInfo returns the status of the user daemon of the given context, and of the root daemon and
+// traffic-manager that it is connected to. The status of all the root daemon's connections is included
+// when all is true, and the statistics of the busiest tunnels are included when tunnels is true.
+func getStatusInfo(ctx context.Context, di *daemon.Info, all, tunnels bool) (*StatusInfo, error) {
 	wt := &StatusInfo{}
 	userD := daemon.GetUserClient(ctx)
 	if userD == nil {
@@ -274,6 +373,14 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 		us.versionName = "User daemon"
 	}
 
+	if di == nil {
+		di, _ = daemon.LoadInfo(ctx, userD.DaemonID().InfoFileName())
+	}
+	if h := di.GetHealth(); h != nil && !h.LastManagerPing.IsZero() {
+		lp := h.LastManagerPing
+		us.LastManagerPing = &lp
+	}
+
 	status, err := userD.Status(ctx, &empty.Empty{})
 	if err != nil {
 		return nil, err
@@ -292,6 +399,7 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 		us.Namespace = status.Namespace
 		us.ManagerNamespace = status.ManagerNamespace
 		us.MappedNamespaces = status.MappedNamespaces
+		wt.ConnectionQuality = connectionQuality(status.ConnectionQuality, status.GetDaemonStatus().GetConnectionQuality())
 	case connector.ConnectInfo_UNAUTHORIZED:
 		us.Status = "Not authorized to connect"
 		us.Error = status.ErrorText
@@ -333,7 +441,9 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 			rs.DNS.IncludeSuffixes = dns.IncludeSuffixes
 			rs.DNS.Excludes = dns.Excludes
 			rs.DNS.Mappings.FromRPC(dns.Mappings)
+			rs.DNS.Overrides = dns.Overrides
 			rs.DNS.LookupTimeout = dns.LookupTimeout.AsDuration()
+			rs.DNS.NegativeCacheTTL = dns.NegativeCacheTtl.AsDuration()
 			rs.RoutingSnake = &client.RoutingSnake{}
 			for _, subnet := range rStatus.Subnets {
 				rs.RoutingSnake.Subnets = append(rs.RoutingSnake.Subnets, (*iputil.Subnet)(iputil.IPNetFromRPC(subnet)))
@@ -347,6 +457,31 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 			for _, subnet := range obc.AllowConflictingSubnets {
 				rs.RoutingSnake.AllowConflicting = append(rs.RoutingSnake.AllowConflicting, (*iputil.Subnet)(iputil.IPNetFromRPC(subnet)))
 			}
+			rs.RoutingSnake.ExcludePorts = obc.ProxyExcludePorts
+		}
+		rs.SubnetConflicts = subnetConflicts(rStatus.SubnetConflicts)
+		if all {
+			for _, c := range rStatus.Connections {
+				ci := ConnectionInfo{
+					Name:            c.Name,
+					SessionID:       c.GetSession().GetSessionId(),
+					Namespace:       c.Namespace,
+					DNSDomains:      c.DnsDomains,
+					SubnetConflicts: subnetConflicts(c.SubnetConflicts),
+				}
+				for _, subnet := range c.Subnets {
+					ci.Subnets = append(ci.Subnets, (*iputil.Subnet)(iputil.IPNetFromRPC(subnet)))
+				}
+				rs.Connections = append(rs.Connections, ci)
+			}
+		}
+		if cs := rStatus.DnsCacheStats; cs != nil {
+			rs.DNSCache = &DNSCacheStats{
+				Entries:         cs.Entries,
+				NegativeEntries: cs.NegativeEntries,
+				Hits:            cs.Hits,
+				Misses:          cs.Misses,
+			}
 		}
 	}
 
@@ -357,6 +492,14 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 		if af, err := userD.AgentImageFQN(ctx, &empty.Empty{}); err == nil {
 			tm.TrafficAgent = af.FQN
 		}
+		if gr, err := userD.GetAgentConfigGCReport(ctx, &empty.Empty{}); err == nil {
+			tm.AgentConfigGC = agentConfigGCStatus(gr)
+		}
+		if tunnels {
+			if cs, err := userD.GetConnectionStats(ctx, &empty.Empty{}); err == nil {
+				tm.Tunnels = tunnelStats(cs.Connections)
+			}
+		}
 		tm.extendedInfo = GetTrafficManagerStatusExtras(ctx, userD)
 	}
 
@@ -446,7 +589,10 @@ func (s *MultiConnectStatusInfo) WriterTos() []io.WriterTo {
 func (cs *ContainerizedDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 	n := 0
 	if cs.UserDaemonStatus.Running {
-		n += ioutil.Printf(out, "%s %s: Running\n", cs.UserDaemonStatus.versionName, cs.UserDaemonStatus.Name)
+		n += ioutil.Printf(out, "%s %s: %s\n", cs.UserDaemonStatus.versionName, cs.UserDaemonStatus.Name, output.Colorize(out, output.Green, "Running"))
+		if output.IsQuiet(out) {
+			return int64(n), nil
+		}
 		kvf := ioutil.DefaultKeyValueFormatter()
 		kvf.Prefix = "  "
 		kvf.Indent = "  "
@@ -459,15 +605,32 @@ func (cs *ContainerizedDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 		}
 		n += kvf.Println(out)
 	} else {
-		n += ioutil.Println(out, "Daemon: Not running")
+		n += ioutil.Printf(out, "Daemon: %s\n", output.Colorize(out, output.Yellow, "Not running"))
 	}
 	return int64(n), nil
 }
 
+func subnetConflicts(rcs []*daemonRpc.SubnetConflict) []SubnetConflict {
+	var scs []SubnetConflict
+	for _, c := range rcs {
+		scs = append(scs, SubnetConflict{
+			Subnet:     (*iputil.Subnet)(iputil.IPNetFromRPC(c.Subnet)),
+			Route:      (*iputil.Subnet)(iputil.IPNetFromRPC(c.Route)),
+			Interface:  c.Interface,
+			Connection: c.Connection,
+			Remapped:   c.Remapped,
+		})
+	}
+	return scs
+}
+
 func (ds *RootDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 	n := 0
 	if ds.Running {
-		n += ioutil.Printf(out, "%s: Running\n", ds.Name)
+		n += ioutil.Printf(out, "%s: %s\n", ds.Name, output.Colorize(out, output.Green, "Running"))
+		if output.IsQuiet(out) {
+			return int64(n), nil
+		}
 		kvf := ioutil.DefaultKeyValueFormatter()
 		kvf.Prefix = "  "
 		kvf.Indent = "  "
@@ -478,13 +641,57 @@ func (ds *RootDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 		if ds.RoutingSnake != nil {
 			printRouting(kvf, ds.RoutingSnake)
 		}
+		printSubnetConflicts(kvf, ds.SubnetConflicts)
+		if cs := ds.DNSCache; cs != nil {
+			kvf.Add("DNS cache", fmt.Sprintf("%d entries (%d negative), %d hits, %d misses", cs.Entries, cs.NegativeEntries, cs.Hits, cs.Misses))
+		}
+		if len(ds.Connections) > 0 {
+			printConnections(kvf, ds.Connections)
+		}
 		n += kvf.Println(out)
 	} else {
-		n += ioutil.Println(out, "Root Daemon: Not running")
+		n += ioutil.Printf(out, "Root Daemon: %s\n", output.Colorize(out, output.Yellow, "Not running"))
 	}
 	return int64(n), nil
 }
 
+func printSubnetConflicts(kvf *ioutil.KeyValueFormatter, scs []SubnetConflict) {
+	if len(scs) == 0 {
+		return
+	}
+	out := &strings.Builder{}
+	ioutil.Printf(out, "(%d subnets)", len(scs))
+	for _, c := range scs {
+		if c.Connection != "" {
+			ioutil.Printf(out, "\n- %s overlaps %s of connection %s", c.Subnet, c.Route, c.Connection)
+		} else {
+			ioutil.Printf(out, "\n- %s overlaps %s on %s", c.Subnet, c.Route, c.Interface)
+		}
+		if c.Remapped {
+			ioutil.Printf(out, " (remapped)")
+		}
+	}
+	kvf.Add("Subnet conflicts", out.String())
+}
+
+func printConnections(kvf *ioutil.KeyValueFormatter, cis []ConnectionInfo) {
+	out := &strings.Builder{}
+	ioutil.Printf(out, "(%d connections)", len(cis))
+	for _, ci := range cis {
+		ckvf := ioutil.DefaultKeyValueFormatter()
+		ckvf.Prefix = "  "
+		ckvf.Indent = "  "
+		if ci.Namespace != "" {
+			ckvf.Add("Namespace", ci.Namespace)
+		}
+		ckvf.Add("Subnets", fmt.Sprintf("%v", ci.Subnets))
+		ckvf.Add("DNS domains", fmt.Sprintf("%v", ci.DNSDomains))
+		printSubnetConflicts(ckvf, ci.SubnetConflicts)
+		ioutil.Printf(out, "\n- %s\n%s", ci.Name, ckvf.String())
+	}
+	kvf.Add("Connections", out.String())
+}
+
 func printDNS(kvf *ioutil.KeyValueFormatter, d *client.DNSSnake) {
 	dnsKvf := ioutil.DefaultKeyValueFormatter()
 	kvf.Indent = "  "
@@ -509,7 +716,19 @@ func printDNS(kvf *ioutil.KeyValueFormatter, d *client.DNSSnake) {
 		}
 		dnsKvf.Add("Mappings", "\n"+mappingsKvf.String())
 	}
+	if len(d.Overrides) > 0 {
+		overridesKvf := ioutil.DefaultKeyValueFormatter()
+		names := maps.Keys(d.Overrides)
+		slices.Sort(names)
+		for _, name := range names {
+			overridesKvf.Add(name, d.Overrides[name])
+		}
+		dnsKvf.Add("Overrides", "\n"+overridesKvf.String())
+	}
 	dnsKvf.Add("Timeout", fmt.Sprintf("%v", d.LookupTimeout))
+	if d.NegativeCacheTTL > 0 {
+		dnsKvf.Add("Negative cache TTL", fmt.Sprintf("%v", d.NegativeCacheTTL))
+	}
 	kvf.Add("DNS", "\n"+dnsKvf.String())
 }
 
@@ -529,19 +748,25 @@ func printRouting(kvf *ioutil.KeyValueFormatter, r *client.RoutingSnake) {
 	printSubnets("Also Proxy", r.AlsoProxy)
 	printSubnets("Never Proxy", r.NeverProxy)
 	printSubnets("Allow conflicts for", r.AllowConflicting)
+	if len(r.ExcludePorts) > 0 {
+		kvf.Add("Never Proxy ports", strings.Join(r.ExcludePorts, ", "))
+	}
 }
 
 func (cs *UserDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 	n := 0
 	if cs.Running {
-		n += ioutil.Printf(out, "%s: Running\n", cs.versionName)
+		n += ioutil.Printf(out, "%s: %s\n", cs.versionName, output.Colorize(out, output.Green, "Running"))
+		if output.IsQuiet(out) {
+			return int64(n), nil
+		}
 		kvf := ioutil.DefaultKeyValueFormatter()
 		kvf.Prefix = "  "
 		kvf.Indent = "  "
 		cs.print(kvf)
 		n += kvf.Println(out)
 	} else {
-		n += ioutil.Println(out, "User Daemon: Not running")
+		n += ioutil.Printf(out, "User Daemon: %s\n", output.Colorize(out, output.Yellow, "Not running"))
 	}
 	return int64(n), nil
 }
@@ -561,6 +786,9 @@ func (cs *UserDaemonStatus) print(kvf *ioutil.KeyValueFormatter) {
 	}
 	kvf.Add("Namespace", cs.Namespace)
 	kvf.Add("Manager namespace", cs.ManagerNamespace)
+	if cs.LastManagerPing != nil {
+		kvf.Add("Last manager ping", time.Since(*cs.LastManagerPing).Truncate(time.Second).String()+" ago")
+	}
 	if len(cs.MappedNamespaces) > 0 {
 		kvf.Add("Mapped namespaces", fmt.Sprintf("%v", cs.MappedNamespaces))
 	}
@@ -583,6 +811,97 @@ func (cs *UserDaemonStatus) print(kvf *ioutil.KeyValueFormatter) {
 	kvf.Add("Intercepts", out.String())
 }
 
+func agentConfigGCStatus(gr *manager.AgentConfigGCReport) *AgentConfigGCStatus {
+	if gr.LastRun == nil {
+		return nil
+	}
+	lr := gr.LastRun.AsTime()
+	gs := &AgentConfigGCStatus{LastRun: &lr}
+	for _, ce := range gr.Cleaned {
+		gs.Cleaned = append(gs.Cleaned, CleanedAgentConfig{
+			Name:      ce.Name,
+			Namespace: ce.Namespace,
+			Kind:      ce.Kind,
+			Reason:    ce.Reason,
+			Time:      ce.Time.AsTime(),
+		})
+	}
+	return gs
+}
+
+// connectionQuality combines the measurements that are made by the user daemon and the root daemon.
+func connectionQuality(ucq, rcq *daemonRpc.ConnectionQuality) *ConnectionQuality {
+	if ucq == nil && rcq == nil {
+		// Daemons that are too old to measure the quality.
+		return nil
+	}
+	return &ConnectionQuality{
+		ManagerRTT:          ucq.GetManagerRtt().AsDuration(),
+		TunnelBytesSent:     rcq.GetTunnelBytesSent(),
+		TunnelBytesReceived: rcq.GetTunnelBytesReceived(),
+		DNSLookupLatency:    rcq.GetDnsLookupLatency().AsDuration(),
+		PortForwardRestarts: ucq.GetPortForwardRestarts() + rcq.GetPortForwardRestarts(),
+	}
+}
+
+func (cq *ConnectionQuality) WriteTo(out io.Writer) (int64, error) {
+	if output.IsQuiet(out) {
+		return 0, nil
+	}
+	n := ioutil.Println(out, "Connection quality:")
+	kvf := ioutil.DefaultKeyValueFormatter()
+	kvf.Prefix = "  "
+	kvf.Indent = "  "
+	kvf.Add("Manager RTT", formatLatency(cq.ManagerRTT))
+	kvf.Add("Tunnel throughput", fmt.Sprintf("sent %s, received %s during the last minute",
+		formatBytes(cq.TunnelBytesSent), formatBytes(cq.TunnelBytesReceived)))
+	kvf.Add("DNS lookup latency", formatLatency(cq.DNSLookupLatency))
+	kvf.Add("Port-forward restarts", strconv.FormatInt(cq.PortForwardRestarts, 10))
+	n += kvf.Println(out)
+	return int64(n), nil
+}
+
+// formatLatency formats the given latency, or returns "unknown" when it hasn't been measured.
+func formatLatency(d time.Duration) string {
+	if d <= 0 {
+		return "unknown"
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+// tunnelStats returns the maxTunnels first of the given connections, which the traffic-manager sorts
+// with the busiest first.
+func tunnelStats(css []*manager.ConnectionStats) []TunnelStats {
+	if len(css) > maxTunnels {
+		css = css[:maxTunnels]
+	}
+	tss := make([]TunnelStats, len(css))
+	for i, cs := range css {
+		tss[i] = TunnelStats{
+			ConnID:          cs.ConnId,
+			FromClientBytes: cs.FromClientBytes,
+			ToClientBytes:   cs.ToClientBytes,
+			StartTime:       cs.StartTime.AsTime(),
+			RTT:             cs.Rtt.AsDuration(),
+		}
+	}
+	return tss
+}
+
+// formatBytes formats the given number of bytes using binary prefixes.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func (ts *TrafficManagerStatus) MarshalJSON() ([]byte, error) {
 	m, err := ts.toMap()
 	if err != nil {
@@ -609,13 +928,22 @@ func (ts *TrafficManagerStatus) toMap() (map[string]any, error) {
 	m["name"] = ts.Name
 	m["traffic_agent"] = ts.TrafficAgent
 	m["version"] = ts.Version
+	if ts.AgentConfigGC != nil {
+		m["agent_config_gc"] = ts.AgentConfigGC
+	}
+	if len(ts.Tunnels) > 0 {
+		m["tunnels"] = ts.Tunnels
+	}
 	return m, nil
 }
 
 func (ts *TrafficManagerStatus) WriteTo(out io.Writer) (int64, error) {
 	n := 0
 	if ts.Name != "" {
-		n += ioutil.Printf(out, "%s: Connected\n", ts.Name)
+		n += ioutil.Printf(out, "%s: %s\n", ts.Name, output.Colorize(out, output.Green, "Connected"))
+		if output.IsQuiet(out) {
+			return int64(n), nil
+		}
 		kvf := ioutil.DefaultKeyValueFormatter()
 		kvf.Prefix = "  "
 		kvf.Indent = "  "
@@ -623,12 +951,43 @@ func (ts *TrafficManagerStatus) WriteTo(out io.Writer) (int64, error) {
 		if ts.TrafficAgent != "" {
 			kvf.Add("Traffic Agent", ts.TrafficAgent)
 		}
+		if gc := ts.AgentConfigGC; gc != nil {
+			gcOut := &strings.Builder{}
+			fmt.Fprintf(gcOut, "%d cleaned, last run %s\n", len(gc.Cleaned), gc.LastRun.Local().Format(time.RFC3339))
+			if len(gc.Cleaned) > 0 {
+				subKvf := ioutil.DefaultKeyValueFormatter()
+				subKvf.Indent = "  "
+				for _, ce := range gc.Cleaned {
+					subKvf.Add(ce.Name+"."+ce.Namespace, ce.Reason)
+				}
+				subKvf.Println(gcOut)
+			}
+			kvf.Add("Agent config GC", gcOut.String())
+		}
+		if ts.Tunnels != nil {
+			tOut := &strings.Builder{}
+			fmt.Fprintf(tOut, "%d active\n", len(ts.Tunnels))
+			if len(ts.Tunnels) > 0 {
+				subKvf := ioutil.DefaultKeyValueFormatter()
+				subKvf.Indent = "  "
+				for _, t := range ts.Tunnels {
+					rtt := "pending"
+					if t.RTT > 0 {
+						rtt = t.RTT.Round(time.Microsecond).String()
+					}
+					subKvf.Add(t.ConnID, fmt.Sprintf("sent %s, received %s, rtt %s, age %s",
+						formatBytes(t.FromClientBytes), formatBytes(t.ToClientBytes), rtt, time.Since(t.StartTime).Round(time.Second)))
+				}
+				subKvf.Println(tOut)
+			}
+			kvf.Add("Tunnels", tOut.String())
+		}
 		if ts.extendedInfo != nil {
 			ts.extendedInfo.AddTo(kvf)
 		}
 		n += kvf.Println(out)
 	} else {
-		n += ioutil.Println(out, "Traffic Manager: Not connected")
+		n += ioutil.Printf(out, "Traffic Manager: %s\n", output.Colorize(out, output.Yellow, "Not connected"))
 	}
 	return int64(n), nil
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+func Test_connectionQuality(t *testing.T) {
+	assert.Nil(t, connectionQuality(nil, nil))
+
+	ucq := &daemonRpc.ConnectionQuality{
+		ManagerRtt:          durationpb.New(12 * time.Millisecond),
+		PortForwardRestarts: 1,
+	}
+	rcq := &daemonRpc.ConnectionQuality{
+		TunnelBytesSent:     2048,
+		TunnelBytesReceived: 4096,
+		DnsLookupLatency:    durationpb.New(3 * time.Millisecond),
+		PortForwardRestarts: 2,
+	}
+	cq := connectionQuality(ucq, rcq)
+	require.NotNil(t, cq)
+	assert.Equal(t, &ConnectionQuality{
+		ManagerRTT:          12 * time.Millisecond,
+		TunnelBytesSent:     2048,
+		TunnelBytesReceived: 4096,
+		DNSLookupLatency:    3 * time.Millisecond,
+		PortForwardRestarts: 3,
+	}, cq)
+
+	out := &bytes.Buffer{}
+	_, err := cq.WriteTo(out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "12ms")
+	assert.Contains(t, out.String(), "sent 2.0 KiB, received 4.0 KiB during the last minute")
+
+	// The root daemon of a user daemon that runs in a container doesn't report.
+	cq = connectionQuality(ucq, nil)
+	require.NotNil(t, cq)
+	assert.Equal(t, "unknown", formatLatency(cq.DNSLookupLatency))
+
+	si := &StatusInfo{ConnectionQuality: cq}
+	data, err := json.Marshal(si)
+	require.NoError(t, err)
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Contains(t, m, "connection_quality")
+}
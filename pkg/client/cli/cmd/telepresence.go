@@ -134,9 +134,9 @@ func OnlySubcommands(cmd *cobra.Command, args []string) error {
 
 func WithSubCommands(ctx context.Context) context.Context {
 	return MergeSubCommands(ctx,
-		configCmd(), connectCmd(), currentClusterId(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(),
-		interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), quit(), statusCmd(),
-		testVPN(), uninstall(), uploadTraces(), version(), listNamespaces(), listContexts(),
+		configCmd(), connectCmd(), currentClusterId(), daemonsCmd(), dnsCmd(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(),
+		ingestCmd(), interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), logsCmd(), loglevel(), quit(), resume(), serviceCmd(), sessionsCmd(), statusCmd(),
+		testVPN(), traceCmd(), uninstall(), uploadTraces(), version(), listNamespaces(), listContexts(),
 	)
 }
 
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
+)
+
+type traceCommand struct {
+	outputFile string
+	endpoint   string
+	remotePort int32
+}
+
+func traceCmd() *cobra.Command {
+	tc := &traceCommand{}
+	cmd := &cobra.Command{
+		Use:   "trace [flags] [<command> [args...]]",
+		Short: "Trace a telepresence command and export the spans of the client, traffic-manager, and traffic-agents",
+		Long: `Trace a telepresence command and export the spans of the client, traffic-manager, and traffic-agents.
+
+The given telepresence command, e.g. "connect" or "intercept", is run, and the spans of all traces that
+started while it ran are collected from the user and root daemons, the traffic-manager, and the
+traffic-agents. Without a command, the spans of all traces that are retained by the components are
+collected.
+
+The spans are written to a file in OTLP JSON format, or in the binary format of "gather-traces" when the
+file name ends with ".gz". They are pushed to an OTLP collector, such as Jaeger, when an endpoint is given.`,
+		Example: `  telepresence trace -o connect.json connect --context my-context
+  telepresence trace --endpoint localhost:4317 intercept my-service --port 8080`,
+		RunE: tc.run,
+		Annotations: map[string]string{
+			ann.UserDaemon: ann.Required,
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	flags := cmd.Flags()
+	// Flags that follow the traced command belong to that command.
+	flags.SetInterspersed(false)
+	flags.StringVarP(&tc.outputFile, "output-file", "o", "",
+		`The file to write the spans to, "./traces.json" unless an endpoint is given`)
+	flags.StringVar(&tc.endpoint, "endpoint", "", "The host:port of an OTLP collector that accepts spans using gRPC")
+	flags.Int32VarP(&tc.remotePort, "port", "p", 15766,
+		"The remote port where traffic manager and agent are exposing traces. "+
+			"Corresponds to tracing.grpcPort in the helm chart values")
+	return cmd
+}
+
+func (tc *traceCommand) run(cmd *cobra.Command, args []string) error {
+	if tc.outputFile == "" && tc.endpoint == "" {
+		tc.outputFile = "./traces.json"
+	}
+	ctx := cmd.Context()
+
+	// Run the traced command first, because it might be the command that starts the daemons.
+	var cmdErr error
+	start := time.Now()
+	if len(args) > 0 {
+		if args[0] == "trace" {
+			return errcat.User.New("trace cannot trace itself")
+		}
+		traced := proc.CommandContext(ctx, client.GetExe(ctx), args...)
+		traced.DisableLogging = true
+		traced.Stdin = cmd.InOrStdin()
+		traced.Stdout = cmd.OutOrStdout()
+		traced.Stderr = cmd.ErrOrStderr()
+		cmdErr = traced.Run()
+	}
+	end := time.Now()
+
+	spans, err := tc.gather(cmd)
+	if err != nil {
+		return errors.Join(cmdErr, err)
+	}
+	if len(args) > 0 {
+		spans = tracing.SpansOfTracesStartedBetween(spans, start, end)
+	}
+	if tc.outputFile != "" {
+		if err = writeSpans(tc.outputFile, spans); err != nil {
+			return errors.Join(cmdErr, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Traces saved as %s\n", tc.outputFile)
+	}
+	if tc.endpoint != "" {
+		if err = uploadSpans(ctx, tc.endpoint, spans); err != nil {
+			return errors.Join(cmdErr, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Traces uploaded to %s\n", tc.endpoint)
+	}
+	return cmdErr
+}
+
+// gather collects the spans of all components using a temporary gather-traces file.
+func (tc *traceCommand) gather(cmd *cobra.Command) ([]*tracepb.ResourceSpans, error) {
+	if err := connect.InitCommand(cmd); err != nil {
+		return nil, err
+	}
+	ctx := cmd.Context()
+	tmpDir, err := os.MkdirTemp("", "telepresence-trace")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	tracesFile := filepath.Join(tmpDir, "traces.gz")
+
+	r, err := daemon.GetUserClient(ctx).GatherTraces(ctx, &connector.TracesRequest{TracingFile: tracesFile, RemotePort: tc.remotePort})
+	if err != nil {
+		return nil, err
+	}
+	if err = errcat.FromResult(r); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(tracesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zipR, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unzip %s: %w", tracesFile, err)
+	}
+	defer zipR.Close()
+	return tracing.NewProtoReader(zipR, func() *tracepb.ResourceSpans { return &tracepb.ResourceSpans{} }).ReadAll(ctx)
+}
+
+// writeSpans writes the given spans to the given file. The file is written in the binary format of gather-traces
+// when its name ends with ".gz", and in OTLP JSON format otherwise.
+func writeSpans(file string, spans []*tracepb.ResourceSpans) (err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := f.Close(); err == nil {
+			err = cErr
+		}
+	}()
+	if strings.HasSuffix(file, ".gz") {
+		zipW := gzip.NewWriter(f)
+		pw := tracing.NewProtoWriter(zipW)
+		for _, span := range spans {
+			if err = pw.Encode(span); err != nil {
+				return err
+			}
+		}
+		return zipW.Close()
+	}
+	data, err := tracing.MarshalJSON(spans)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// uploadSpans pushes the given spans to the OTLP collector at the given endpoint.
+func uploadSpans(ctx context.Context, endpoint string, spans []*tracepb.ResourceSpans) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
+	tc := traceClient(endpoint)
+	if err := tc.Start(ctx); err != nil {
+		return err
+	}
+	dlog.Debugf(ctx, "Starting upload of %d resource spans", len(spans))
+	err := tc.UploadTraces(ctx, spans)
+	if sErr := tc.Stop(ctx); err == nil {
+		err = sErr
+	}
+	return err
+}
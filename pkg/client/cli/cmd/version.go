@@ -17,6 +17,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
@@ -35,7 +36,7 @@ func version() *cobra.Command {
 	}
 }
 
-func addDaemonVersions(ctx context.Context, kvf *ioutil.KeyValueFormatter) {
+func addDaemonVersions(ctx context.Context, r *output.Renderer, kvf *ioutil.KeyValueFormatter) {
 	remote := false
 	userD := daemon.GetUserClient(ctx)
 	if userD != nil {
@@ -48,9 +49,9 @@ func addDaemonVersions(ctx context.Context, kvf *ioutil.KeyValueFormatter) {
 		case err == nil:
 			kvf.Add(version.Name, version.Version)
 		case err == connect.ErrNoRootDaemon:
-			kvf.Add("Root Daemon", "not running")
+			kvf.Add("Root Daemon", r.Colorize(output.Yellow, "not running"))
 		default:
-			kvf.Add("Root Daemon", fmt.Sprintf("error: %v", err))
+			kvf.Add("Root Daemon", r.Colorize(output.Red, fmt.Sprintf("error: %v", err)))
 		}
 	}
 
@@ -65,23 +66,28 @@ func addDaemonVersions(ctx context.Context, kvf *ioutil.KeyValueFormatter) {
 			case codes.OK:
 				kvf.Add("Traffic Agent", af.FQN)
 			case codes.Unimplemented:
-				kvf.Add("Traffic Agent", "not reported by traffic-manager")
+				kvf.Add("Traffic Agent", r.Colorize(output.Yellow, "not reported by traffic-manager"))
 			case codes.Unavailable:
-				kvf.Add("Traffic Agent", "not currently available")
+				kvf.Add("Traffic Agent", r.Colorize(output.Yellow, "not currently available"))
 			default:
-				kvf.Add("Traffic Agent", fmt.Sprintf("error: %v", err))
+				kvf.Add("Traffic Agent", r.Colorize(output.Red, fmt.Sprintf("error: %v", err)))
 			}
 		case status.Code(err) == codes.Unavailable:
-			kvf.Add("Traffic Manager", "not connected")
+			kvf.Add("Traffic Manager", r.Colorize(output.Yellow, "not connected"))
 		default:
-			kvf.Add("Traffic Manager", fmt.Sprintf("error: %v", err))
+			kvf.Add("Traffic Manager", r.Colorize(output.Red, fmt.Sprintf("error: %v", err)))
 		}
 	} else {
-		kvf.Add("User Daemon", "not running")
+		kvf.Add("User Daemon", r.Colorize(output.Yellow, "not running"))
 	}
 }
 
 func printVersion(cmd *cobra.Command, _ []string) error {
+	r := output.CmdRenderer(cmd)
+	if r.Quiet() {
+		ioutil.Println(r, client.Version())
+		return nil
+	}
 	kvf := ioutil.DefaultKeyValueFormatter()
 	kvf.Add(client.DisplayName, client.Version())
 
@@ -102,18 +108,18 @@ func printVersion(cmd *cobra.Command, _ []string) error {
 			}
 			udCtx, err := connect.ExistingDaemon(ctx, info)
 			if err != nil {
-				subKvf.Add("User Daemon", fmt.Sprintf("error: %v", err))
+				subKvf.Add("User Daemon", r.Colorize(output.Red, fmt.Sprintf("error: %v", err)))
 			}
-			addDaemonVersions(udCtx, subKvf)
+			addDaemonVersions(udCtx, r, subKvf)
 			ud := daemon.GetUserClient(udCtx)
 			kvf.Add("Connection "+ud.DaemonID().Name, "\n"+subKvf.String())
 			_ = ud.Close()
 		}
 	} else {
-		addDaemonVersions(ctx, kvf)
+		addDaemonVersions(ctx, r, kvf)
 	}
 
-	kvf.Println(cmd.OutOrStdout())
+	kvf.Println(r)
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -18,6 +19,8 @@ import (
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -28,6 +31,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/service"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
@@ -71,6 +75,10 @@ func quitHostConnector(ctx context.Context) {
 	ud := daemon.GetUserClient(udCtx)
 	_, _ = ud.Quit(ctx, &emptypb.Empty{})
 	_ = ud.Close()
+	if service.Installed(ctx) {
+		// The sockets are owned by the service manager, so they don't vanish when the daemons quit.
+		return
+	}
 	_ = socket.WaitUntilVanishes("user daemon", socket.UserDaemonPath(ctx), 5*time.Second)
 
 	// User daemon is responsible for killing the root daemon, but we kill it here too to cater for
@@ -102,6 +110,39 @@ func quitDockerDaemons(ctx context.Context) {
 	}
 }
 
+// QuitDaemon tells the daemon described by the given info to quit and waits for it to remove its info. The
+// info is deleted, and the container of a containerized daemon is stopped, when the daemon doesn't comply.
+func QuitDaemon(ctx context.Context, info *daemon.Info) error {
+	id := info.DaemonID()
+	file := id.InfoFileName()
+	udCtx, err := ExistingDaemon(ctx, info)
+	if err == nil {
+		ud := daemon.GetUserClient(udCtx)
+		_, err = ud.Quit(ctx, &emptypb.Empty{})
+		_ = ud.Close()
+		if err == nil {
+			err = daemon.WaitUntilVanishes(ctx, file, 5*time.Second)
+		}
+	}
+	if !(info.InDocker || service.Installed(ctx)) {
+		// The user daemon is responsible for killing the root daemon, but we kill it here too to cater for
+		// the fact that the user daemon might have been killed ungracefully.
+		if waitErr := socket.WaitUntilVanishes("root daemon", socket.RootDaemonPath(ctx), 5*time.Second); waitErr != nil {
+			quitRootDaemon(ctx)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	dlog.Errorf(ctx, "unable to quit daemon %s gracefully: %v", id, err)
+	if cid := info.Options["cid"]; cid != "" {
+		if err = docker.StopContainer(docker.EnableClient(ctx), cid); err != nil {
+			return err
+		}
+	}
+	return daemon.DeleteInfo(ctx, file)
+}
+
 func ExistingDaemon(ctx context.Context, info *daemon.Info) (context.Context, error) {
 	var err error
 	var conn *grpc.ClientConn
@@ -117,6 +158,10 @@ func ExistingDaemon(ctx context.Context, info *daemon.Info) (context.Context, er
 }
 
 func ExistingHostDaemon(ctx context.Context, id *daemon.Identifier) (context.Context, error) {
+	if addr := client.GetEnv(ctx).UserDaemonAddress; addr != "" {
+		return connectRemoteDaemon(ctx, addr, id)
+	}
+
 	// Try dialing the host daemon using the well-known socket.
 	socketName := socket.UserDaemonPath(ctx)
 	conn, err := socket.Dial(ctx, socketName, false)
@@ -134,6 +179,11 @@ func ExistingHostDaemon(ctx context.Context, id *daemon.Identifier) (context.Con
 
 // Quit shuts down all daemons.
 func Quit(ctx context.Context) {
+	if infos, err := daemon.LoadInfos(ctx); err == nil {
+		for _, info := range infos {
+			printEndingIntercepts(ctx, info)
+		}
+	}
 	stdout := output.Out(ctx)
 	ioutil.Print(stdout, "Telepresence Daemons quitting...")
 	for _, quitFunc := range QuitDaemonFuncs {
@@ -147,6 +197,9 @@ func Disconnect(ctx context.Context) {
 	if ud := daemon.GetUserClient(ctx); ud == nil {
 		ioutil.Println(output.Out(ctx), "Not connected")
 	} else {
+		if info, err := daemon.LoadInfo(ctx, ud.DaemonID().InfoFileName()); err == nil {
+			printEndingIntercepts(ctx, info)
+		}
 		_, err := ud.Disconnect(ctx, &emptypb.Empty{})
 		switch {
 		case err == nil:
@@ -159,6 +212,19 @@ func Disconnect(ctx context.Context) {
 	}
 }
 
+// printEndingIntercepts uses the intercept summaries of the given daemon info to tell the user
+// about the intercepts that will end when the daemon disconnects.
+func printEndingIntercepts(ctx context.Context, info *daemon.Info) {
+	if len(info.Intercepts) == 0 {
+		return
+	}
+	names := make([]string, len(info.Intercepts))
+	for i, ic := range info.Intercepts {
+		names[i] = ic.Name
+	}
+	ioutil.Printf(output.Info(ctx), "Ending intercepts in connection %s: %s\n", info.Name, strings.Join(names, ", "))
+}
+
 func RunConnect(cmd *cobra.Command, args []string) error {
 	if err := InitCommand(cmd); err != nil {
 		return err
@@ -180,6 +246,10 @@ func DiscoverDaemon(ctx context.Context, match *regexp.Regexp, daemonID *daemon.
 	if match == nil && !cr.Implicit {
 		match = regexp.MustCompile(`\A` + regexp.QuoteMeta(daemonID.Name) + `\z`)
 	}
+	if addr := client.GetEnv(ctx).UserDaemonAddress; addr != "" && !cr.Docker {
+		// The daemon is controlled over TCP, possibly on another host, so the daemon cache is of no use.
+		return connectRemoteDaemon(ctx, addr, daemonID)
+	}
 	info, err := daemon.LoadMatchingInfo(ctx, match)
 	if err != nil {
 		if os.IsNotExist(err) && !cr.Docker {
@@ -191,7 +261,15 @@ func DiscoverDaemon(ctx context.Context, match *regexp.Regexp, daemonID *daemon.
 		return ctx, err
 	}
 	if len(cr.ExposedPorts) > 0 && !slices.Equal(info.ExposedPorts, cr.ExposedPorts) {
-		return ctx, errcat.User.New("exposed ports differ. Please quit and reconnect")
+		return ctx, errcat.ExposedPortsDiffer.New("exposed ports differ. Please quit and reconnect")
+	}
+	if cr.UserSpace && !info.UserSpace {
+		return ctx, errcat.User.New("the daemon doesn't handle networking in user space. Please quit and reconnect")
+	}
+	// The root daemon must not be started for a daemon that handles networking in user space.
+	if cr.UserSpace = info.UserSpace; cr.UserSpace {
+		cr.SOCKSPort = uint16(info.SOCKSPort)
+		cr.HTTPProxyPort = uint16(info.HTTPProxyPort)
 	}
 	return ExistingDaemon(ctx, info)
 }
@@ -234,6 +312,10 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 		return ctx, err
 	}
 
+	if cr.UserSpace && (cr.Docker || cliInContainer) {
+		return ctx, errcat.User.New("--userspace cannot be used with a containerized daemon")
+	}
+
 	var conn *grpc.ClientConn
 	if cr.Docker && !cliInContainer {
 		// Ensure that the logfile is present before the daemon starts so that it isn't created with
@@ -266,15 +348,30 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 			args = append(args, "--embed-network")
 			args = append(args, "--name", "docker-"+hn)
 		}
+		if cr.UserSpace {
+			// An empty --socks-address means that no SOCKS5 proxy is started.
+			socksAddress := ""
+			if cr.SOCKSPort != 0 {
+				socksAddress = net.JoinHostPort("127.0.0.1", strconv.Itoa(int(cr.SOCKSPort)))
+			}
+			args = append(args, "--userspace-network", "--socks-address="+socksAddress)
+			if cr.HTTPProxyPort != 0 {
+				args = append(args, "--http-proxy-address", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(cr.HTTPProxyPort))))
+			}
+		}
 		err = daemon.SaveInfo(ctx,
 			&daemon.Info{
-				InDocker:     cliInContainer,
-				DaemonPort:   0,
-				Name:         daemonID.Name,
-				KubeContext:  daemonID.KubeContext,
-				Namespace:    daemonID.Namespace,
-				ExposedPorts: cr.ExposedPorts,
-				Hostname:     cr.Hostname,
+				InDocker:      cliInContainer,
+				DaemonPort:    0,
+				Name:          daemonID.Name,
+				KubeContext:   daemonID.KubeContext,
+				Namespace:     daemonID.Namespace,
+				ExposedPorts:  cr.ExposedPorts,
+				Hostname:      cr.Hostname,
+				UserSpace:     cr.UserSpace,
+				SOCKSPort:     int(cr.SOCKSPort),
+				HTTPProxyPort: int(cr.HTTPProxyPort),
+				StartedAt:     time.Now(),
 			}, daemonID.InfoFileName())
 		if err != nil {
 			return ctx, err
@@ -286,7 +383,7 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 		}()
 
 		if err = proc.StartInBackground(false, args...); err != nil {
-			return ctx, errcat.NoDaemonLogs.Newf("failed to launch the connector service: %w", err)
+			return ctx, errcat.DaemonLaunchFailed.Newf("failed to launch the connector service: %w", err)
 		}
 		conn, err = socket.Dial(ctx, socket.UserDaemonPath(ctx), true)
 	}
@@ -318,6 +415,25 @@ func getConnectorVersion(ctx context.Context, cc connector.ConnectorClient) (*co
 	return vi, err
 }
 
+// connectRemoteDaemon connects to a user daemon that listens to the given TCP address. Mutual TLS is used
+// when the client certificate, key, and CA certificate are given in the environment.
+func connectRemoteDaemon(ctx context.Context, addr string, daemonID *daemon.Identifier) (context.Context, error) {
+	env := client.GetEnv(ctx)
+	creds := insecure.NewCredentials()
+	if env.UserDaemonTLSCert != "" || env.UserDaemonTLSKey != "" || env.UserDaemonTLSCA != "" {
+		tc, err := client.MutualTLSConfig(env.UserDaemonTLSCert, env.UserDaemonTLSKey, env.UserDaemonTLSCA, false)
+		if err != nil {
+			return ctx, errcat.Config.New(err)
+		}
+		creds = credentials.NewTLS(tc)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds), grpc.WithNoProxy())
+	if err != nil {
+		return ctx, err
+	}
+	return newUserDaemon(ctx, conn, daemonID)
+}
+
 func newUserDaemon(ctx context.Context, conn *grpc.ClientConn, daemonID *daemon.Identifier) (context.Context, error) {
 	vi, err := getConnectorVersion(ctx, connector.NewConnectorClient(conn))
 	if err != nil {
@@ -431,10 +547,18 @@ func connectSession(ctx context.Context, useLine string, userD daemon.UserClient
 
 	connectResult := func(ci *connector.ConnectInfo) (*daemon.Session, error) {
 		var msg string
-		cat := errcat.Unknown
+		code := errcat.ConnectFailed
 		switch ci.Error {
 		case connector.ConnectInfo_UNSPECIFIED:
 			ioutil.Printf(output.Info(ctx), "Connected to context %s, namespace %s (%s)\n", ci.ClusterContext, ci.Namespace, ci.ClusterServer)
+			if request.UserSpace {
+				if request.SOCKSPort != 0 {
+					ioutil.Printf(output.Info(ctx), "The cluster is available through the SOCKS5 proxy at 127.0.0.1:%d\n", request.SOCKSPort)
+				}
+				if request.HTTPProxyPort != 0 {
+					ioutil.Printf(output.Info(ctx), "The cluster is available through the HTTP proxy at http://127.0.0.1:%d\n", request.HTTPProxyPort)
+				}
+			}
 			err := warnMngrVersion(ci)
 			if err != nil {
 				dlog.Error(ctx, err)
@@ -444,13 +568,25 @@ func connectSession(ctx context.Context, useLine string, userD daemon.UserClient
 			return session(ci, false), nil
 		case connector.ConnectInfo_MUST_RESTART:
 			msg = "Cluster configuration changed, please quit telepresence and reconnect"
+			code = errcat.MustRestart
 		default:
 			msg = ci.ErrorText
+			switch ci.Error {
+			case connector.ConnectInfo_UNAUTHORIZED, connector.ConnectInfo_UNAUTHENTICATED:
+				code = errcat.Unauthorized
+			case connector.ConnectInfo_CLUSTER_FAILED:
+				code = errcat.ClusterUnreachable
+			case connector.ConnectInfo_TRAFFIC_MANAGER_FAILED:
+				code = errcat.TrafficManagerFailed
+			case connector.ConnectInfo_DAEMON_FAILED:
+				code = errcat.RootDaemonFailed
+			}
 			if ci.ErrorCategory != 0 {
-				cat = errcat.Category(ci.ErrorCategory)
+				err := errcat.WithCode(errcat.Category(ci.ErrorCategory).Newf("connector.Connect: %s", msg), code)
+				return nil, &ConnectError{error: err, code: ci.Error}
 			}
 		}
-		return nil, &ConnectError{error: cat.Newf("connector.Connect: %s", msg), code: ci.Error}
+		return nil, &ConnectError{error: code.Newf("connector.Connect: %s", msg), code: ci.Error}
 	}
 
 	if request.Implicit {
@@ -475,24 +611,58 @@ func connectSession(ctx context.Context, useLine string, userD daemon.UserClient
 
 	if !userD.Containerized() {
 		daemonID := userD.DaemonID()
-		err = daemon.SaveInfo(ctx,
-			&daemon.Info{
-				InDocker:     false,
-				Name:         daemonID.Name,
-				KubeContext:  daemonID.KubeContext,
-				Namespace:    daemonID.Namespace,
-				ExposedPorts: request.ExposedPorts,
-				Hostname:     request.Hostname,
-			}, daemonID.InfoFileName())
+		info := &daemon.Info{
+			InDocker:     false,
+			Name:         daemonID.Name,
+			KubeContext:  daemonID.KubeContext,
+			Namespace:    daemonID.Namespace,
+			ExposedPorts: request.ExposedPorts,
+			Hostname:     request.Hostname,
+			StartedAt:    time.Now(),
+		}
+		if oldInfo, err := daemon.LoadInfo(ctx, daemonID.InfoFileName()); err == nil {
+			// Retain the intercept summaries maintained by an already connected daemon.
+			info.Intercepts = oldInfo.Intercepts
+			if !oldInfo.StartedAt.IsZero() {
+				info.StartedAt = oldInfo.StartedAt
+			}
+		}
+		err = daemon.SaveInfo(ctx, info, daemonID.InfoFileName())
 		if err != nil {
 			return nil, errcat.NoDaemonLogs.New(err)
 		}
 	}
+	var switchedFrom *daemon.Identifier
+	if request.SwitchContext {
+		switchedFrom = currentConnection(ctx, userD)
+	}
 	if ci, err = userD.Connect(ctx, &request.ConnectRequest); err != nil {
 		if !userD.Containerized() {
 			_ = daemon.DeleteInfo(ctx, userD.DaemonID().InfoFileName())
 		}
 		return nil, err
 	}
+	if switchedFrom != nil && ci.Error == connector.ConnectInfo_UNSPECIFIED && switchedFrom.Name != ci.ConnectionName {
+		// The daemon has replaced the session, so the info of the old connection is no longer valid.
+		_ = daemon.DeleteInfo(ctx, switchedFrom.InfoFileName())
+	}
 	return connectResult(ci)
 }
+
+// currentConnection returns the identifier of the connection that the given user daemon currently
+// serves, or nil if it isn't connected. Intercepts that will end when the connection is replaced are
+// printed.
+func currentConnection(ctx context.Context, userD daemon.UserClient) *daemon.Identifier {
+	ci, err := userD.Status(ctx, &emptypb.Empty{})
+	if err != nil || ci.Error == connector.ConnectInfo_DISCONNECTED {
+		return nil
+	}
+	id, err := daemon.NewIdentifier(ci.ConnectionName, ci.ClusterContext, ci.Namespace, userD.Containerized())
+	if err != nil {
+		return nil
+	}
+	if info, err := daemon.LoadInfo(ctx, id.InfoFileName()); err == nil {
+		printEndingIntercepts(ctx, info)
+	}
+	return id
+}
@@ -49,15 +49,16 @@ func launchDaemon(ctx context.Context, cr *daemon.Request) error {
 	if os.Getenv("SCOUT_DISABLE") == "1" {
 		args = append(args, "--disable-metriton")
 	}
-	args = append(args, logDir, filelocation.AppUserConfigDir(ctx))
+	args = append(args, logDir, filelocation.AppUserConfigDir(ctx), filelocation.AppUserCacheDir(ctx))
 	return proc.StartInBackgroundAsRoot(ctx, args...)
 }
 
 // ensureRootDaemonRunning ensures that the daemon is running.
 func ensureRootDaemonRunning(ctx context.Context) error {
 	cr := daemon.GetRequest(ctx)
-	if cr != nil && cr.Docker {
-		// Never start root daemon when connecting using a docker container.
+	if cr != nil && (cr.Docker || cr.UserSpace) {
+		// Never start root daemon when connecting using a docker container, or when all
+		// networking is handled in user space.
 		return nil
 	}
 	if addr := client.GetEnv(ctx).UserDaemonAddress; addr != "" {
@@ -87,7 +88,7 @@ func quitRootDaemon(ctx context.Context) {
 
 func mkdir(dirType, path string) error {
 	if err := os.MkdirAll(path, 0o700); err != nil {
-		return errcat.NoDaemonLogs.Newf("unable to ensure that %s directory %q exists: %w", dirType, path, err)
+		return errcat.DirectoryCreationFailed.Newf("unable to ensure that %s directory %q exists: %w", dirType, path, err)
 	}
 	return nil
 }
@@ -31,20 +31,20 @@ func versionCheck(ctx context.Context, daemonBinary string) error {
 				return nil
 			}
 		}
-		return errcat.User.Newf("version mismatch. Client %s != remote user daemon %s", version.Version, uv)
+		return errcat.VersionMismatch.Newf("version mismatch. Client %s != remote user daemon %s", version.Version, uv)
 	}
 	if !version.Structured.EQ(uv) {
 		// OSS Version mismatch. We never allow this
-		return errcat.User.Newf("version mismatch. Client %s != user daemon %s, please run 'telepresence quit -s' and reconnect",
+		return errcat.VersionMismatch.Newf("version mismatch. Client %s != user daemon %s, please run 'telepresence quit -s' and reconnect",
 			version.Version, uv)
 	}
 	if daemonBinary != "" && userD.Executable() != daemonBinary {
-		return errcat.User.Newf("executable mismatch. Connector using %s, configured to use %s, please run 'telepresence quit -s' and reconnect",
+		return errcat.ExecutableMismatch.Newf("executable mismatch. Connector using %s, configured to use %s, please run 'telepresence quit -s' and reconnect",
 			userD.Executable(), daemonBinary)
 	}
 	vr, err := userD.RootDaemonVersion(ctx, &empty.Empty{})
 	if err == nil && version.Version != vr.Version {
-		return errcat.User.Newf("version mismatch. Client %s != Root Daemon %s, please run 'telepresence quit -s' and reconnect",
+		return errcat.VersionMismatch.Newf("version mismatch. Client %s != Root Daemon %s, please run 'telepresence quit -s' and reconnect",
 			version.Version, vr.Version)
 	}
 	return nil
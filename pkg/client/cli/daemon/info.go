@@ -18,14 +18,77 @@ import (
 )
 
 type Info struct {
-	Options      map[string]string `json:"options,omitempty"`
-	InDocker     bool              `json:"in_docker,omitempty"`
-	Name         string            `json:"name,omitempty"`
-	KubeContext  string            `json:"kube_context,omitempty"`
-	Namespace    string            `json:"namespace,omitempty"`
-	DaemonPort   int               `json:"daemon_port,omitempty"`
-	ExposedPorts []string          `json:"exposed_ports,omitempty"`
-	Hostname     string            `json:"hostname,omitempty"`
+	Options       map[string]string `json:"options,omitempty"`
+	InDocker      bool              `json:"in_docker,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	KubeContext   string            `json:"kube_context,omitempty"`
+	Namespace     string            `json:"namespace,omitempty"`
+	DaemonPort    int               `json:"daemon_port,omitempty"`
+	ExposedPorts  []string          `json:"exposed_ports,omitempty"`
+	Hostname      string            `json:"hostname,omitempty"`
+	UserSpace     bool              `json:"user_space,omitempty"`
+	SOCKSPort     int               `json:"socks_port,omitempty"`
+	HTTPProxyPort int               `json:"http_proxy_port,omitempty"`
+	StartedAt     time.Time         `json:"started_at,omitempty"`
+
+	// Intercepts is a summary of the intercepts that are active in the daemon. It is updated by
+	// the daemon whenever the set of intercepts, or their state, changes.
+	Intercepts []*InterceptSummary `json:"intercepts,omitempty"`
+
+	// Health is updated by the daemon when it keeps the Info alive. It is nil while the daemon
+	// has no session.
+	Health *Health `json:"health,omitempty"`
+}
+
+// Health is a summary of the state of a daemon's session, suitable for presentation by tools that
+// want to show the health of a daemon without dialing it.
+type Health struct {
+	// LastManagerPing is the time of the last successful call to the traffic-manager.
+	LastManagerPing time.Time `json:"last_manager_ping,omitempty"`
+
+	// Cluster is the Kubernetes server that the daemon is connected to.
+	Cluster string `json:"cluster,omitempty"`
+
+	InterceptCount int `json:"intercept_count,omitempty"`
+
+	// Error is the error of the last failed call to the traffic-manager. It is cleared when a call succeeds.
+	Error string `json:"error,omitempty"`
+}
+
+// InterceptSummary is a brief description of an intercept, suitable for presentation by tools that
+// want to show per-connection activity without dialing the daemon.
+type InterceptSummary struct {
+	Name        string `json:"name"`
+	Workload    string `json:"workload,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Disposition string `json:"disposition,omitempty"`
+	MountPoint  string `json:"mount_point,omitempty"`
+}
+
+func equalHealth(a, b *Health) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetHealth returns the health of the daemon, or nil when the info is nil or has no health.
+func (info *Info) GetHealth() *Health {
+	if info == nil {
+		return nil
+	}
+	return info.Health
+}
+
+// MountPoints returns the mount points of the intercepts that have mounts.
+func (info *Info) MountPoints() []string {
+	var mps []string
+	for _, ic := range info.Intercepts {
+		if ic.MountPoint != "" {
+			mps = append(mps, ic.MountPoint)
+		}
+	}
+	return mps
 }
 
 func (info *Info) DaemonID() *Identifier {
@@ -36,6 +99,7 @@ func (info *Info) DaemonID() *Identifier {
 const (
 	daemonsDirName    = "daemons"
 	keepAliveInterval = 5 * time.Second
+	staleAge          = keepAliveInterval + 600*time.Millisecond
 )
 
 func LoadInfo(ctx context.Context, file string) (*Info, error) {
@@ -50,6 +114,20 @@ func SaveInfo(ctx context.Context, object *Info, file string) error {
 	return cache.SaveToUserCache(ctx, object, filepath.Join(daemonsDirName, file), cache.Public)
 }
 
+// UpdateInfo loads the Info stored in the given file, calls the given function with it, and saves
+// the result if the function returns true. It is not an error if the file doesn't exist, and the
+// function isn't called in that case.
+func UpdateInfo(ctx context.Context, file string, update func(*Info) bool) error {
+	var info Info
+	err := cache.UpdateUserCache(ctx, &info, filepath.Join(daemonsDirName, file), cache.Public, func() bool {
+		return update(&info)
+	})
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
+
 func DeleteInfo(ctx context.Context, file string) error {
 	return cache.DeleteFromUserCache(ctx, filepath.Join(daemonsDirName, file))
 }
@@ -58,20 +136,42 @@ func InfoExists(ctx context.Context, file string) (bool, error) {
 	return cache.ExistsInCache(ctx, filepath.Join(daemonsDirName, file))
 }
 
+// WatchInfos calls onChange when the given files in the daemons directory are created, removed,
+// or modified. All files in the directory are watched when no files are given. Daemons modify their
+// Info when their intercepts change, so this is also a way to get notified about such changes.
 func WatchInfos(ctx context.Context, onChange func(context.Context) error, files ...string) error {
 	return cache.WatchUserCache(ctx, daemonsDirName, onChange, files...)
 }
 
+// WaitUntilAllVanishes waits until all daemon info files have been removed from the cache.
 func WaitUntilAllVanishes(ctx context.Context, ttw time.Duration) error {
-	giveUp := time.Now().Add(ttw)
-	for giveUp.After(time.Now()) {
+	return waitForInfos(ctx, ttw, func(ctx context.Context) (bool, error) {
 		files, err := infoFiles(ctx)
-		if err != nil || len(files) == 0 {
-			return err
+		return len(files) == 0, err
+	})
+}
+
+// WaitUntilVanishes waits for the given info file to be removed from the cache.
+func WaitUntilVanishes(ctx context.Context, file string, ttw time.Duration) error {
+	return waitForInfos(ctx, ttw, func(ctx context.Context) (bool, error) {
+		exists, err := InfoExists(ctx, file)
+		return !exists, err
+	}, file)
+}
+
+// waitForInfos waits until the given condition is true. The condition is checked when the given files in the
+// daemons directory change, or any file in the directory when no files are given.
+func waitForInfos(ctx context.Context, ttw time.Duration, condition func(context.Context) (bool, error), files ...string) error {
+	wc, cancel := context.WithTimeout(ctx, ttw)
+	defer cancel()
+	err := cache.WaitUserCache(wc, daemonsDirName, condition, files...)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		if len(files) == 1 {
+			return fmt.Errorf("timeout while waiting for daemon file %s to vanish", files[0])
 		}
-		time.Sleep(250 * time.Millisecond)
+		return errors.New("timeout while waiting for daemon files to vanish")
 	}
-	return errors.New("timeout while waiting for daemon files to vanish")
+	return err
 }
 
 func DeleteAllInfos(ctx context.Context) error {
@@ -91,11 +191,17 @@ func LoadInfos(ctx context.Context) ([]*Info, error) {
 		return nil, err
 	}
 
-	DaemonInfos := make([]*Info, len(files))
-	for i, file := range files {
-		if err = cache.LoadFromUserCache(ctx, &DaemonInfos[i], filepath.Join(daemonsDirName, file.Name())); err != nil {
+	DaemonInfos := make([]*Info, 0, len(files))
+	for _, file := range files {
+		var di *Info
+		if err = cache.LoadFromUserCache(ctx, &di, filepath.Join(daemonsDirName, file.Name())); err != nil {
+			if os.IsNotExist(err) {
+				// Removed after it was listed, or removed because it was corrupt.
+				continue
+			}
 			return nil, err
 		}
+		DaemonInfos = append(DaemonInfos, di)
 	}
 	return DaemonInfos, nil
 }
@@ -112,16 +218,29 @@ func infoFiles(ctx context.Context) ([]fs.DirEntry, error) {
 	for _, file := range files {
 		fi, err := file.Info()
 		if err != nil {
+			if os.IsNotExist(err) {
+				// A temporary file that has been renamed, or an info that has been deleted.
+				continue
+			}
 			return nil, err
 		}
 		age := time.Since(fi.ModTime())
-		if age > keepAliveInterval+600*time.Millisecond {
-			// File has gone stale
-			dlog.Debugf(ctx, "Deleting stale info %s with age = %s", file.Name(), age)
-			if err = cache.DeleteFromUserCache(ctx, filepath.Join(daemonsDirName, file.Name())); err != nil {
+		switch {
+		case age > staleAge:
+			// File has gone stale, or is a temporary file left behind by a writer that crashed. The age is
+			// checked again while holding the lock, because the daemon might have touched it just now.
+			deleted, err := cache.DeleteStaleFromUserCache(ctx, filepath.Join(daemonsDirName, file.Name()), staleAge)
+			if err != nil {
 				return nil, err
 			}
-		} else {
+			if deleted {
+				dlog.Debugf(ctx, "Deleted stale info %s with age = %s", file.Name(), age)
+			} else if !strings.HasSuffix(file.Name(), cache.TempSuffix) {
+				active = append(active, file)
+			}
+		case strings.HasSuffix(file.Name(), cache.TempSuffix):
+			// Being written.
+		default:
 			active = append(active, file)
 		}
 	}
@@ -153,10 +272,34 @@ func (m MultipleDaemonsError) Error() string {
 	}
 	sb.WriteString(" or ")
 	sb.WriteString(m[i].DaemonID().Name)
-	sb.WriteString(" using the --use <match> flag")
+	sb.WriteString(" using the --use <match> flag. Use \"telepresence daemons list\" to see the running daemons")
 	return sb.String()
 }
 
+// LoadNamedInfo returns the Info of the running daemon with the given name, or an error that
+// lists the names of the running daemons when no such daemon exists.
+func LoadNamedInfo(ctx context.Context, name string) (*Info, error) {
+	infos, err := LoadInfos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		id := info.DaemonID()
+		if id == nil {
+			continue
+		}
+		if id.Name == name {
+			return info, nil
+		}
+		names = append(names, id.Name)
+	}
+	if len(names) == 0 {
+		return nil, errcat.User.Newf("no daemon named %q is running", name)
+	}
+	return nil, errcat.User.Newf("no daemon named %q is running, the running daemons are: %s", name, strings.Join(names, ", "))
+}
+
 func LoadMatchingInfo(ctx context.Context, match *regexp.Regexp) (*Info, error) {
 	if match == nil {
 		infos, err := LoadInfos(ctx)
@@ -218,27 +361,56 @@ func CancelWhenRmFromCache(ctx context.Context, cancel context.CancelFunc, filen
 // any file with a modification time older than the current time minus two keepAliveIntervals
 // can be considered stale and should be removed.
 //
+// The health of the Info is updated on each tick when the given health function returns a
+// Health that differs from the one last stored. The function may be nil. It returns a nil Health
+// when the daemon has no session, and the stored Health is then cleared. It returns false when
+// the health can't be determined at the moment, and the stored Health is then left as is.
+//
 // The alive poll ends and the Info is deleted when the context is cancelled.
-func KeepInfoAlive(ctx context.Context, file string) error {
-	daemonFile := filepath.Join(filelocation.AppUserCacheDir(ctx), daemonsDirName, file)
+func KeepInfoAlive(ctx context.Context, file string, health func() (*Health, bool)) error {
+	cacheFile := filepath.Join(daemonsDirName, file)
 	ticker := time.NewTicker(keepAliveInterval)
 	defer ticker.Stop()
-	now := time.Now()
+	var lastHealth *Health
 	for {
-		if err := os.Chtimes(daemonFile, now, now); err != nil {
+		var h *Health
+		changed := false
+		if health != nil {
+			var known bool
+			if h, known = health(); known {
+				changed = !equalHealth(h, lastHealth)
+			}
+		}
+		var err error
+		if changed {
+			// Saving the Info updates its modification time too.
+			var info Info
+			err = cache.UpdateUserCache(ctx, &info, cacheFile, cache.Public, func() bool {
+				info.Health = h
+				return true
+			})
+			if err == nil {
+				lastHealth = h
+			}
+		} else {
+			// The timestamps are updated while holding the lock of the file, so that it isn't deleted as stale
+			// by another process that checked its age just before.
+			err = cache.TouchUserCache(ctx, cacheFile)
+		}
+		if err != nil {
 			if os.IsNotExist(err) {
 				// File is removed, so stop trying to update its timestamps
 				dlog.Debugf(ctx, "Daemon info %s does not exist", file)
 				return nil
 			}
-			return fmt.Errorf("failed to update timestamp on %s: %w", daemonFile, err)
+			return fmt.Errorf("failed to update %s: %w", cacheFile, err)
 		}
 		select {
 		case <-ctx.Done():
 			dlog.Debugf(ctx, "Deleting daemon info %s because context was cancelled", file)
 			_ = DeleteInfo(ctx, file)
 			return nil
-		case now = <-ticker.C:
+		case <-ticker.C:
 		}
 	}
 }
@@ -0,0 +1,199 @@
+package daemon_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+func TestUpdateInfo(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	const file = "test-default.json"
+
+	// Updating a non-existent info is a no-op.
+	called := false
+	require.NoError(t, daemon.UpdateInfo(ctx, file, func(*daemon.Info) bool {
+		called = true
+		return true
+	}))
+	assert.False(t, called)
+
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{Name: "test", Namespace: "default"}, file))
+	require.NoError(t, daemon.UpdateInfo(ctx, file, func(info *daemon.Info) bool {
+		info.Intercepts = []*daemon.InterceptSummary{
+			{Name: "echo", Workload: "echo", Namespace: "default", Disposition: "ACTIVE", MountPoint: "/tmp/echo"},
+			{Name: "hello", Workload: "hello", Namespace: "default", Disposition: "WAITING"},
+		}
+		return true
+	}))
+
+	info, err := daemon.LoadInfo(ctx, file)
+	require.NoError(t, err)
+	assert.Equal(t, "test", info.Name)
+	require.Len(t, info.Intercepts, 2)
+	assert.Equal(t, "ACTIVE", info.Intercepts[0].Disposition)
+	assert.Equal(t, []string{"/tmp/echo"}, info.MountPoints())
+}
+
+func TestLoadNamedInfo(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+
+	_, err := daemon.LoadNamedInfo(ctx, "kind-default")
+	assert.ErrorContains(t, err, `no daemon named "kind-default" is running`)
+
+	started := time.Now().Truncate(time.Second)
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{KubeContext: "kind", Namespace: "default", StartedAt: started}, "kind-default.json"))
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{Name: "other", KubeContext: "kind", Namespace: "other"}, "other.json"))
+
+	info, err := daemon.LoadNamedInfo(ctx, "kind-default")
+	require.NoError(t, err)
+	assert.Equal(t, "default", info.Namespace)
+	assert.True(t, started.Equal(info.StartedAt))
+
+	_, err = daemon.LoadNamedInfo(ctx, "kind")
+	assert.ErrorContains(t, err, "the running daemons are: kind-default, other")
+}
+
+func TestLoadInfos_corruptAndTemporary(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	dir := filepath.Join(filelocation.AppUserCacheDir(ctx), "daemons")
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{Name: "good", Namespace: "default"}, "good.json"))
+
+	// A truncated file, written by a daemon that crashed, and a temporary file of an ongoing write.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"name":"ba`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.json.x1y2.tmp"), []byte(`{"name":"ot`), 0o644))
+
+	infos, err := daemon.LoadInfos(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "good", infos[0].Name)
+
+	// The corrupt file is removed, but the temporary file is left to its writer.
+	assert.NoFileExists(t, filepath.Join(dir, "bad.json"))
+	assert.FileExists(t, filepath.Join(dir, "other.json.x1y2.tmp"))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = daemon.LoadInfo(ctx, "bad.json")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUpdateInfo_concurrent(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	const file = "test-default.json"
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{Name: "test", Namespace: "default"}, file))
+
+	// Each update is a read-modify-write cycle, so updates would be lost without locking.
+	const count = 20
+	var wg sync.WaitGroup
+	wg.Add(count)
+	for i := range count {
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, daemon.UpdateInfo(ctx, file, func(info *daemon.Info) bool {
+				info.Intercepts = append(info.Intercepts, &daemon.InterceptSummary{Name: strconv.Itoa(i)})
+				return true
+			}))
+		}()
+	}
+	wg.Wait()
+
+	info, err := daemon.LoadInfo(ctx, file)
+	require.NoError(t, err)
+	assert.Len(t, info.Intercepts, count)
+}
+
+func TestWaitUntilVanishes(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	const file = "test-default.json"
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{Name: "test", Namespace: "default"}, file))
+
+	err := daemon.WaitUntilVanishes(ctx, file, 100*time.Millisecond)
+	assert.ErrorContains(t, err, "timeout while waiting for daemon file test-default.json to vanish")
+
+	time.AfterFunc(100*time.Millisecond, func() {
+		_ = daemon.DeleteInfo(ctx, file)
+	})
+	start := time.Now()
+	require.NoError(t, daemon.WaitUntilAllVanishes(ctx, 5*time.Second))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestKeepInfoAlive_health(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	const file = "test-default.json"
+	old := &daemon.Health{Cluster: "https://127.0.0.1:6443", Error: "connection refused"}
+	require.NoError(t, daemon.SaveInfo(ctx, &daemon.Info{Name: "test", Namespace: "default", Health: old}, file))
+
+	// The daemon is busy at first, then it has a session, and then the session ends.
+	ping := time.Now().Truncate(time.Second)
+	results := []struct {
+		health *daemon.Health
+		known  bool
+	}{
+		{nil, false},
+		{&daemon.Health{LastManagerPing: ping, Cluster: "https://127.0.0.1:6443", InterceptCount: 1}, true},
+		{nil, true},
+	}
+	var mu sync.Mutex
+	call := 0
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- daemon.KeepInfoAlive(ctx, file, func() (*daemon.Health, bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			r := results[min(call, len(results)-1)]
+			call++
+			return r.health, r.known
+		})
+	}()
+	storedHealth := func() *daemon.Health {
+		info, err := daemon.LoadInfo(ctx, file)
+		if !assert.NoError(t, err) {
+			return old
+		}
+		return info.Health
+	}
+
+	// The stored health remains while the daemon is busy.
+	cleared := false
+	require.Eventually(t, func() bool {
+		h := storedHealth()
+		if h == nil {
+			cleared = true
+			return false
+		}
+		return *h != *old
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.False(t, cleared)
+	h := storedHealth()
+	assert.True(t, ping.Equal(h.LastManagerPing))
+	assert.Equal(t, "https://127.0.0.1:6443", h.Cluster)
+	assert.Equal(t, 1, h.InterceptCount)
+	assert.Empty(t, h.Error)
+
+	// The stored health is cleared when the session ends.
+	require.Eventually(t, func() bool { return storedHealth() == nil }, 10*time.Second, 10*time.Millisecond)
+	info, err := daemon.LoadInfo(ctx, file)
+	require.NoError(t, err)
+	assert.Equal(t, "test", info.Name)
+
+	cancel()
+	require.NoError(t, <-done)
+	exists, err := daemon.InfoExists(context.WithoutCancel(ctx), file)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
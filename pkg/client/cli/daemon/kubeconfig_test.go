@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+func writeKubeconfig(t *testing.T, name, cluster, server string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: `+cluster+`
+  cluster:
+    server: `+server+`
+contexts:
+- name: `+cluster+`
+  context:
+    cluster: `+cluster+`
+    user: `+cluster+`
+users:
+- name: `+cluster+`
+current-context: `+cluster+`
+`), 0o600))
+	return path
+}
+
+func Test_repeatedKubeconfigFlag(t *testing.T) {
+	kc1 := writeKubeconfig(t, "one.yaml", "one", "https://one.example.com")
+	kc2 := writeKubeconfig(t, "two.yaml", "two", "https://two.example.com")
+
+	cmd := &cobra.Command{Use: "connect"}
+	cr := InitRequest(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--kubeconfig", kc1, "--kubeconfig", kc2, "--context", "two"}))
+	assert.Equal(t, kc1+string(os.PathListSeparator)+kc2, *cr.kubeConfig.KubeConfig)
+
+	// Both files are merged, so the context from the second file is found, and the current-context
+	// of the first file wins.
+	rc, err := client.KubeconfigLoader(cr.kubeConfig).RawConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "one", rc.CurrentContext)
+	assert.Contains(t, rc.Contexts, "one")
+	assert.Contains(t, rc.Contexts, "two")
+
+	rs, err := client.KubeconfigLoader(cr.kubeConfig).ClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://two.example.com", rs.Host)
+
+	cmd = &cobra.Command{Use: "connect"}
+	InitRequest(cmd)
+	assert.Error(t, cmd.ParseFlags([]string{"--kubeconfig", kc1, "--kubeconfig", "-"}))
+}
@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"io"
 	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/types/known/durationpb"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -23,6 +27,7 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/slice"
@@ -40,6 +45,17 @@ type Request struct {
 	// Hostname used by a containerized daemon. Only valid when Docker == true
 	Hostname string
 
+	// If set, then the user daemon handles all networking in user space, and no root daemon is used.
+	UserSpace bool
+
+	// Port of the SOCKS5 proxy that provides access to the cluster, or zero when no SOCKS5 proxy is used.
+	// Only valid when UserSpace == true
+	SOCKSPort uint16
+
+	// Port of the HTTP proxy that provides access to the cluster, or zero when no HTTP proxy is used.
+	// Only valid when UserSpace == true
+	HTTPProxyPort uint16
+
 	// Match expression to use when finding an existing connection by name
 	Use *regexp.Regexp
 
@@ -52,6 +68,14 @@ type Request struct {
 
 	// proxyVia holds the string version for the --proxy-via flag values.
 	proxyVia []string
+
+	// proxy holds the string version of the --proxy flag values.
+	proxy []string
+
+	// latency, jitter, and drop hold the values of the network chaos flags.
+	latency time.Duration
+	jitter  time.Duration
+	drop    string
 }
 
 type CobraRequest struct {
@@ -88,6 +112,14 @@ func InitRequest(cmd *cobra.Command) *CobraRequest {
 	nwFlags.StringSliceVar(&cr.AllowConflictingSubnets,
 		"allow-conflicting-subnets", nil, ``+
 			`Comma separated list of CIDR that will be allowed to conflict with local subnets`)
+	nwFlags.StringToStringVar(&cr.DnsOverrides,
+		"dns-override", nil, ``+
+			`Hostname to IP address mapping, on the form <host>=<ip>, that the DNS resolver answers with locally `+
+			`instead of consulting the cluster. Can be repeated`)
+	nwFlags.StringSliceVar(&cr.ProxyExcludePorts,
+		"proxy-exclude-ports", nil, ``+
+			`Comma separated list of destination ports, on the form <port>[/<protocol>], that are never proxied, `+
+			`even when the destination IP is in a proxied subnet`)
 
 	// Docker flags
 	nwFlags.Bool(global.FlagDocker, false, "Start, or connect to, daemon in a docker container")
@@ -98,7 +130,22 @@ func InitRequest(cmd *cobra.Command) *CobraRequest {
 		"hostname", "", ``+
 			`Hostname used by a containerized daemon`)
 
+	// User space networking flags
+	nwFlags.BoolVar(&cr.UserSpace,
+		"userspace", false, ``+
+			`Handle all networking in user space. No root daemon or TUN device is used, and the cluster is `+
+			`instead made available through a SOCKS5 proxy on localhost`)
+	nwFlags.Uint16Var(&cr.SOCKSPort,
+		"socks-port", 1080, ``+
+			`Port of the SOCKS5 proxy. Only valid when --userspace is used`)
+	nwFlags.StringSliceVar(&cr.proxy,
+		"proxy", nil, ``+
+			`URL of a local proxy that provides access to the cluster, e.g. socks5://:1080 or http://:3128. `+
+			`Can be repeated to use both a SOCKS5 and an HTTP proxy. Only the given proxies are started. Implies `+
+			`--userspace, so no root daemon or TUN device is used`)
+
 	flags.AddFlagSet(nwFlags)
+	cmd.MarkFlagsMutuallyExclusive("proxy", "socks-port")
 
 	dbgFlags := pflag.NewFlagSet("Debug and Profiling flags", 0)
 	dbgFlags.Uint16Var(&cr.UserDaemonProfilingPort,
@@ -109,16 +156,51 @@ func InitRequest(cmd *cobra.Command) *CobraRequest {
 	_ = dbgFlags.MarkHidden("rootd-profiling-port")
 	flags.AddFlagSet(dbgFlags)
 
+	chaosFlags := pflag.NewFlagSet("Network chaos flags", 0)
+	chaosFlags.DurationVar(&cr.latency,
+		"latency", 0, ``+
+			`Artificial latency added to each packet that is sent to the cluster, e.g. 200ms`)
+	chaosFlags.DurationVar(&cr.jitter,
+		"jitter", 0, ``+
+			`Maximum random deviation from the artificial latency, in either direction, e.g. 50ms`)
+	chaosFlags.StringVar(&cr.drop,
+		"drop", "", ``+
+			`Percentage of the packets sent to the cluster that are dropped, e.g. 1%`)
+	flags.AddFlagSet(chaosFlags)
+
 	cr.kubeConfig = genericclioptions.NewConfigFlags(false)
 	cr.KubeFlags = make(map[string]string)
 	cr.kubeFlagSet = pflag.NewFlagSet("Kubernetes flags", 0)
 	cr.kubeConfig.AddFlags(cr.kubeFlagSet)
+	if kf := cr.kubeFlagSet.Lookup("kubeconfig"); kf != nil {
+		kf.Value = &kubeconfigValue{Value: kf.Value}
+		kf.Usage += ". Can be repeated, and the files will then be merged"
+	}
 	flags.AddFlagSet(cr.kubeFlagSet)
 	_ = cmd.RegisterFlagCompletionFunc("namespace", cr.autocompleteNamespace)
 	_ = cmd.RegisterFlagCompletionFunc("cluster", cr.autocompleteCluster)
 	return &cr
 }
 
+// kubeconfigValue wraps the value of the --kubeconfig flag so that the flag can be repeated. The files given by
+// repeated flags are joined using the OS specific path list separator, which is how client.KubeconfigLoader
+// expects several files to be listed.
+type kubeconfigValue struct {
+	pflag.Value
+	set bool
+}
+
+func (v *kubeconfigValue) Set(s string) error {
+	if v.set {
+		if s == "-" || v.String() == "-" {
+			return errors.New(`"--kubeconfig -" cannot be combined with other --kubeconfig flags`)
+		}
+		s = v.String() + string(os.PathListSeparator) + s
+	}
+	v.set = true
+	return v.Value.Set(s)
+}
+
 type requestKey struct{}
 
 func (cr *CobraRequest) CommitFlags(cmd *cobra.Command) error {
@@ -161,6 +243,18 @@ func (cr *Request) Commit(ctx context.Context) (context.Context, error) {
 	if err != nil {
 		return ctx, errcat.User.New(err)
 	}
+	cr.TunnelChaos, err = parseTunnelChaos(cr.latency, cr.jitter, cr.drop)
+	if err != nil {
+		return ctx, errcat.User.New(err)
+	}
+	if len(cr.proxy) > 0 {
+		// Only the proxies that are given are started, so the default SOCKS5 port doesn't apply.
+		cr.SOCKSPort, cr.HTTPProxyPort, err = parseProxies(cr.proxy)
+		if err != nil {
+			return ctx, errcat.User.New(err)
+		}
+		cr.UserSpace = true
+	}
 	if len(cr.KubeconfigData) > 0 {
 		kc, err := clientcmd.Load(cr.KubeconfigData)
 		if err != nil {
@@ -183,6 +277,79 @@ func (cr *Request) Commit(ctx context.Context) (context.Context, error) {
 	return context.WithValue(ctx, requestKey{}, cr), nil
 }
 
+// parseProxies returns the ports of the SOCKS5 proxy and the HTTP proxy that the given proxy URLs declare.
+// A port is zero when no proxy of its kind is declared. Only one proxy of each kind can be declared.
+func parseProxies(urls []string) (socksPort, httpPort uint16, err error) {
+	for _, u := range urls {
+		scheme, port, err := parseProxyURL(u)
+		if err != nil {
+			return 0, 0, err
+		}
+		pp := &socksPort
+		if scheme == "http" {
+			pp = &httpPort
+		}
+		if *pp != 0 {
+			return 0, 0, fmt.Errorf("invalid proxy %q: only one %s proxy can be used", u, scheme)
+		}
+		*pp = port
+	}
+	return socksPort, httpPort, nil
+}
+
+// parseProxyURL parses the URL of a local proxy on the form <scheme>://[host]:port and returns its scheme
+// and port. The scheme must be socks5 or http. The host is optional, but must be localhost or 127.0.0.1 when
+// given, because the proxy always listens on 127.0.0.1.
+func parseProxyURL(s string) (string, uint16, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid proxy URL %q: %w", s, err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "http" {
+		return "", 0, fmt.Errorf("unsupported proxy scheme %q in %q. Only socks5 and http are supported", u.Scheme, s)
+	}
+	switch u.Hostname() {
+	case "", "localhost", "127.0.0.1":
+	default:
+		return "", 0, fmt.Errorf("proxy host in %q must be localhost or 127.0.0.1, because the proxy always listens on 127.0.0.1", s)
+	}
+	if u.Port() == "" {
+		return "", 0, fmt.Errorf("proxy URL %q has no port", s)
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil || port == 0 {
+		return "", 0, fmt.Errorf("invalid port in proxy URL %q", s)
+	}
+	return u.Scheme, uint16(port), nil
+}
+
+// parseTunnelChaos returns the TunnelChaos described by the values of the --latency, --jitter, and --drop
+// flags, or nil when none of them are set. The drop is a percentage, optionally followed by a '%'.
+func parseTunnelChaos(latency, jitter time.Duration, drop string) (*daemon.TunnelChaos, error) {
+	if latency < 0 {
+		return nil, fmt.Errorf("invalid latency %s, it cannot be negative", latency)
+	}
+	if jitter < 0 {
+		return nil, fmt.Errorf("invalid jitter %s, it cannot be negative", jitter)
+	}
+	var dropPercent float64
+	if drop != "" {
+		var err error
+		dropPercent, err = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(drop), "%"), 32)
+		if err != nil || dropPercent < 0 || dropPercent > 100 {
+			return nil, fmt.Errorf("invalid drop %q, it must be a percentage between 0 and 100", drop)
+		}
+	}
+	if latency == 0 && jitter == 0 && dropPercent == 0 {
+		return nil, nil
+	}
+	return &daemon.TunnelChaos{
+		Latency:     durationpb.New(latency),
+		Jitter:      durationpb.New(jitter),
+		DropPercent: float32(dropPercent),
+	}, nil
+}
+
 type prefixViaWL struct {
 	subnet   netip.Prefix
 	symbolic string
@@ -274,9 +441,8 @@ func parseSubnetViaWorkload(dps string) (prefixViaWL, error) {
 
 func (cr *Request) addKubeconfigEnv() {
 	// Certain options' default are bound to the connector daemon process; this is notably true of the kubeconfig file(s) to use,
-	// and since those files can be specified, both as a --kubeconfig flag and in the KUBECONFIG setting, and since the flag won't
-	// accept multiple path entries, we need to pass the environment setting to the connector daemon so that it can set it every
-	// time it receives a new config.
+	// and since those files can be specified, both as a --kubeconfig flag and in the KUBECONFIG setting, we need to pass the
+	// environment setting to the connector daemon so that it can set it every time it receives a new config.
 	cr.Environment = make(map[string]string, 2)
 	addEnv := func(key string) {
 		if v, ok := os.LookupEnv(key); ok {
@@ -354,7 +520,11 @@ func NewDefaultRequest() *Request {
 func GetKubeStartingConfig(cmd *cobra.Command) (*api.Config, error) {
 	pathOpts := clientcmd.NewDefaultPathOptions()
 	if kcFlag := cmd.Flag("kubeconfig"); kcFlag != nil && kcFlag.Changed {
-		pathOpts.ExplicitFileFlag = kcFlag.Value.String()
+		if files := filepath.SplitList(kcFlag.Value.String()); len(files) > 1 {
+			pathOpts.LoadingRules.Precedence = files
+		} else {
+			pathOpts.ExplicitFileFlag = kcFlag.Value.String()
+		}
 	}
 	return pathOpts.GetStartingConfig()
 }
@@ -363,7 +533,7 @@ func (cr *CobraRequest) GetAllNamespaces(cmd *cobra.Command) ([]string, error) {
 	if err := cr.CommitFlags(cmd); err != nil {
 		return nil, err
 	}
-	rs, err := cr.kubeConfig.ToRESTConfig()
+	rs, err := client.KubeconfigLoader(cr.kubeConfig).ClientConfig()
 	if err != nil {
 		return nil, errcat.NoDaemonLogs.Newf("ToRESTConfig: %v", err)
 	}
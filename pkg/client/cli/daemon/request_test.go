@@ -4,6 +4,7 @@ import (
 	"net/netip"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
 )
@@ -200,3 +201,98 @@ func Test_parseProxyVias(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseProxyURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantScheme string
+		want       uint16
+		wantErr    bool
+	}{
+		{url: "socks5://:1080", wantScheme: "socks5", want: 1080},
+		{url: "socks5://localhost:1081", wantScheme: "socks5", want: 1081},
+		{url: "socks5://127.0.0.1:1082", wantScheme: "socks5", want: 1082},
+		{url: "socks5://[::1]:1083", wantErr: true},
+		{url: "socks5://127.0.0.2:1084", wantErr: true},
+		{url: "http://:3128", wantScheme: "http", want: 3128},
+		{url: "https://:3128", wantErr: true},
+		{url: "socks5://10.0.0.1:1080", wantErr: true},
+		{url: "socks5://localhost", wantErr: true},
+		{url: "socks5://:0", wantErr: true},
+		{url: "socks5://:70000", wantErr: true},
+		{url: "socks4://:1080", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			scheme, got, err := parseProxyURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseProxyURL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if scheme != tt.wantScheme {
+				t.Errorf("parseProxyURL() got scheme = %v, want %v", scheme, tt.wantScheme)
+			}
+			if got != tt.want {
+				t.Errorf("parseProxyURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseProxies(t *testing.T) {
+	tests := []struct {
+		name      string
+		urls      []string
+		wantSOCKS uint16
+		wantHTTP  uint16
+		wantErr   bool
+	}{
+		{name: "socks5 only", urls: []string{"socks5://:1081"}, wantSOCKS: 1081},
+		{name: "http only", urls: []string{"http://:3128"}, wantHTTP: 3128},
+		{name: "both", urls: []string{"http://:3128", "socks5://:1081"}, wantSOCKS: 1081, wantHTTP: 3128},
+		{name: "two socks5", urls: []string{"socks5://:1081", "socks5://:1082"}, wantErr: true},
+		{name: "two http", urls: []string{"http://:3128", "http://localhost:3129"}, wantErr: true},
+		{name: "invalid", urls: []string{"socks5://:1081", "https://:3128"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socksPort, httpPort, err := parseProxies(tt.urls)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseProxies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if socksPort != tt.wantSOCKS || httpPort != tt.wantHTTP {
+				t.Errorf("parseProxies() = %d, %d, want %d, %d", socksPort, httpPort, tt.wantSOCKS, tt.wantHTTP)
+			}
+		})
+	}
+}
+
+func Test_parseTunnelChaos(t *testing.T) {
+	tc, err := parseTunnelChaos(0, 0, "")
+	if err != nil || tc != nil {
+		t.Errorf("parseTunnelChaos() = %v, %v, want nil, nil", tc, err)
+	}
+
+	tc, err = parseTunnelChaos(200*time.Millisecond, 50*time.Millisecond, "1%")
+	if err != nil {
+		t.Fatalf("parseTunnelChaos() error = %v", err)
+	}
+	if tc.GetLatency().AsDuration() != 200*time.Millisecond || tc.GetJitter().AsDuration() != 50*time.Millisecond || tc.GetDropPercent() != 1 {
+		t.Errorf("parseTunnelChaos() = %v", tc)
+	}
+
+	tc, err = parseTunnelChaos(0, 0, "2.5")
+	if err != nil || tc.GetDropPercent() != 2.5 {
+		t.Errorf("parseTunnelChaos() = %v, %v, want 2.5 percent", tc, err)
+	}
+
+	for _, drop := range []string{"-1%", "101%", "some"} {
+		if _, err = parseTunnelChaos(0, 0, drop); err == nil {
+			t.Errorf("parseTunnelChaos() with drop %q didn't return an error", drop)
+		}
+	}
+	if _, err = parseTunnelChaos(-time.Second, 0, ""); err == nil {
+		t.Error("parseTunnelChaos() with negative latency didn't return an error")
+	}
+}
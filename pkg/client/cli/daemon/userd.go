@@ -10,6 +10,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
 )
 
 type UserClient interface {
@@ -37,7 +38,32 @@ type userClient struct {
 var NewUserClientFunc = NewUserClient //nolint:gochecknoglobals // extension point
 
 func NewUserClient(conn *grpc.ClientConn, daemonID *Identifier, version semver.Version, name string, executable string) UserClient {
-	return &userClient{ConnectorClient: connector.NewConnectorClient(conn), conn: conn, daemonID: daemonID, version: version, name: name, executable: executable}
+	u := &userClient{conn: conn, daemonID: daemonID, version: version, name: name, executable: executable}
+	u.ConnectorClient = connector.NewConnectorClient(&namedConn{ClientConn: conn, u: u})
+	return u
+}
+
+// namedConn identifies the connection of the user client in all calls, so that a user daemon that maintains
+// several connections knows which one a call concerns. The name is read on each call, because it changes
+// when the user daemon assigns a name to a new connection.
+type namedConn struct {
+	*grpc.ClientConn
+	u *userClient
+}
+
+func (c *namedConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.ClientConn.Invoke(c.withName(ctx), method, args, reply, opts...)
+}
+
+func (c *namedConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.ClientConn.NewStream(c.withName(ctx), desc, method, opts...)
+}
+
+func (c *namedConn) withName(ctx context.Context) context.Context {
+	if id := c.u.daemonID; id != nil {
+		ctx = client.WithConnectionName(ctx, id.Name)
+	}
+	return ctx
 }
 
 type Session struct {
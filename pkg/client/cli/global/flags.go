@@ -10,6 +10,8 @@ const (
 	FlagUse      = "use"
 	FlagOutput   = "output"
 	FlagNoReport = "no-report"
+	FlagNoColor  = "no-color"
+	FlagQuiet    = "quiet"
 )
 
 func Flags(hasKubeFlags bool) *pflag.FlagSet {
@@ -24,5 +26,7 @@ func Flags(hasKubeFlags bool) *pflag.FlagSet {
 	flags.Bool(FlagNoReport, false, "Turn off anonymous crash reports and log submission on failure")
 	flags.String(FlagUse, "", "Match expression that uniquely identifies the daemon container")
 	flags.String(FlagOutput, "default", "Set the output format, supported values are 'json', 'yaml', and 'default'")
+	flags.Bool(FlagNoColor, false, "Disable colors in the output. Colors are also disabled when NO_COLOR is set")
+	flags.Bool(FlagQuiet, false, "Only print essential information. Has no effect when formatted output is requested")
 	return flags
 }
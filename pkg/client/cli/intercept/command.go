@@ -3,8 +3,10 @@ package intercept
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
@@ -15,6 +17,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 )
 
 type Command struct {
@@ -26,10 +29,21 @@ type Command struct {
 	LocalOnly      bool   // --local-only
 	LocalMountPort uint16 // --local-mount-port
 
-	Replace bool // whether --replace was passed
+	Replace      bool          // whether --replace was passed
+	Mirror       bool          // --mirror
+	TerminateTLS bool          // --terminate-tls
+	TTL          time.Duration // --ttl
+
+	TrafficPercent int      // --traffic-percent
+	HttpHeaders    []string // --http-header
+	GrpcMethods    []string // --grpc-method
+	GrpcMetadata   []string // --grpc-metadata
+	Affinity       string   // --session-affinity
+	Pod            string   // --pod
 
 	EnvFile  string   // --env-file
 	EnvJSON  string   // --env-json
+	NoRedact bool     // --no-redact
 	Mount    string   // --mount // "true", "false", or desired mount point // only valid if !localOnly
 	MountSet bool     // whether --mount was passed
 	ToPod    []string // --to-pod
@@ -48,6 +62,13 @@ type Command struct {
 	FormattedOutput bool
 	DetailedOutput  bool
 	Silent          bool
+
+	Spec  string     // --spec
+	group *GroupSpec // the group loaded from the --spec file
+
+	Adopt string // --adopt
+
+	Ingest bool // the command is "telepresence ingest", which routes no traffic
 }
 
 func (a *Command) AddFlags(cmd *cobra.Command) {
@@ -56,6 +77,8 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.StringVarP(&a.Port, "port", "p", "", ``+
 		`Local port to forward to. If intercepting a service with multiple ports, `+
 		`use <local port>:<svcPortIdentifier>, where the identifier is the port name or port number. `+
+		`When the identifier is omitted, a port with an HTTP or gRPC app protocol is preferred, and you will `+
+		`be asked to choose when that doesn't resolve the ambiguity. `+
 		`With --docker-run and a daemon that doesn't run in docker', use <local port>:<container port> or `+
 		`<local port>:<container port>:<svcPortIdentifier>.`,
 	)
@@ -70,12 +93,98 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.BoolVarP(&a.LocalOnly, "local-only", "l", false, ``+
 		`Declare a local-only intercept for the purpose of getting direct outbound access to the intercept's namespace`)
 
+	a.addHandlerFlags(flagSet)
+
+	flagSet.StringP("namespace", "n", "", "If present, the namespace scope for this CLI request")
+
+	flagSet.StringVar(&a.Mechanism, "mechanism", "tcp", "Which extension `mechanism` to use")
+
+	flagSet.BoolVarP(&a.Replace, "replace", "", false,
+		`Indicates if the traffic-agent should replace application containers in workload pods. `+
+			`The default behavior is for the agent sidecar to be installed alongside existing containers. `+
+			`Use this when the local process must be the only consumer, e.g. of a queue. The replaced containers `+
+			`are restored when the intercept ends.`)
+
+	flagSet.BoolVar(&a.Mirror, "mirror", false, ``+
+		`Send copies of the requests to the local handler while the intercepted container keeps serving them. `+
+		`The responses from the local handler are discarded.`)
+
+	flagSet.BoolVar(&a.TerminateTLS, "terminate-tls", false, ``+
+		`Have the traffic-agent terminate the TLS of the intercepted connections, so that the local handler `+
+		`receives plaintext. The certificate is taken from the secret that the pod declares using the `+
+		`telepresence.getambassador.io/inject-terminating-tls-secret annotation.`)
+
+	flagSet.IntVar(&a.TrafficPercent, "traffic-percent", 100, ``+
+		`The percentage of the connections to route to the local handler. The remaining connections `+
+		`continue to be served by the intercepted container.`)
+
+	flagSet.StringArrayVar(&a.HttpHeaders, "http-header", nil, ``+
+		`Only consider requests with headers that match this condition as intercepted by the API server. `+
+		`The condition is on the form <name><op><value> where <op> is one of =, =~ (regexp), ^= (prefix), `+
+		`$= (suffix), or their negations !=, !~, !^=, !$=. Separate alternative conditions with ||. `+
+		`Can be repeated, and all repetitions must match.`)
+
+	flagSet.StringArrayVar(&a.GrpcMethods, "grpc-method", nil, ``+
+		`Only consider gRPC calls to this method as intercepted by the API server. The method is on the form `+
+		`<package>.<service>/<method>, where the method can be * to match all methods of the service. `+
+		`Can be repeated, and one of the repetitions must match.`)
+
+	flagSet.StringArrayVar(&a.GrpcMetadata, "grpc-metadata", nil, ``+
+		`Only consider gRPC calls with metadata that match this condition as intercepted by the API server. `+
+		`The condition has the same form as the one used by --http-header. `+
+		`Can be repeated, and all repetitions must match.`)
+
+	flagSet.StringVar(&a.Pod, "pod", "", ``+
+		`Only intercept the traffic of this pod of the workload. The value is either a pod name or a label `+
+		`selector, e.g. statefulset.kubernetes.io/pod-name=web-2 or version!=v1.`)
+
+	flagSet.StringVar(&a.Affinity, "session-affinity", "", ``+
+		`Keep considering requests as intercepted once a request with the same session key has matched the `+
+		`--http-header and gRPC conditions. The session key is given as cookie:<name> or header:<name>.`)
+
+	flagSet.StringVar(&a.Spec, "spec", "", ``+
+		`Create the group of intercepts declared in this YAML file instead of a single intercept. The other `+
+		`flags apply to all intercepts of the group. Either all or none of the intercepts are created, and `+
+		`"telepresence leave --spec" removes them all.`)
+
+	flagSet.StringVar(&a.Adopt, "adopt", "", ``+
+		`Create the intercept declared by this InterceptSpec resource in the connected namespace, with this `+
+		`client as its local handler. The workload, service, service port, and http headers are then taken `+
+		`from the resource. A command to run must be given after --.`)
+
+	// Hide these flags. They are still functional but deprecated. Using them will yield a deprecation message.
+	flagSet.Lookup("local-only").Hidden = true
+	flagSet.Lookup("namespace").Hidden = true
+}
+
+// AddIngestFlags adds the flags of the "telepresence ingest" command. An ingest is an intercept that
+// provides the environment and volume mounts of the ingested container without routing any traffic.
+func (a *Command) AddIngestFlags(cmd *cobra.Command) {
+	a.Ingest = true
+	a.Mechanism = "tcp"
+	a.TrafficPercent = 100
+	flagSet := cmd.Flags()
+	flagSet.StringVarP(&a.AgentName, "workload", "w", "", "Name of workload (Deployment, ReplicaSet) to ingest, if different from <name>")
+	flagSet.StringVarP(&a.Port, "port", "p", "", ``+
+		`The service port identifier, i.e. the port name or port number, that selects the container to `+
+		`ingest when the service has more than one port`)
+	flagSet.StringVar(&a.ServiceName, "service", "", "Name of service that selects the container to ingest. If not provided, we will try to auto-detect one")
+	a.addHandlerFlags(flagSet)
+}
+
+// addHandlerFlags adds the flags that control the environment, the volume mounts, and the command or
+// container that handles the intercept locally.
+func (a *Command) addHandlerFlags(flagSet *pflag.FlagSet) {
 	flagSet.StringVarP(&a.EnvFile, "env-file", "e", "", ``+
 		`Also emit the remote environment to an env file in Docker Compose format. `+
 		`See https://docs.docker.com/compose/env-file/ for more information on the limitations of this format.`)
 
 	flagSet.StringVarP(&a.EnvJSON, "env-json", "j", "", `Also emit the remote environment to a file as a JSON blob.`)
 
+	flagSet.BoolVar(&a.NoRedact, "no-redact", false, ``+
+		`Don't mask the values of the environment variables that are sourced from Secrets in the files `+
+		`written by --env-file and --env-json`)
+
 	flagSet.StringVar(&a.Mount, "mount", "true", ``+
 		`The absolute path for the root directory where volumes will be mounted, $TELEPRESENCE_ROOT. Use "true" to `+
 		`have Telepresence pick a random mount point (default). Use "false" to disable filesystem mounting entirely.`)
@@ -102,10 +211,6 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.StringVar(&a.DockerMount, "docker-mount", "", ``+
 		`The volume mount point in docker. Defaults to same as "--mount"`)
 
-	flagSet.StringP("namespace", "n", "", "If present, the namespace scope for this CLI request")
-
-	flagSet.StringVar(&a.Mechanism, "mechanism", "tcp", "Which extension `mechanism` to use")
-
 	flagSet.StringVar(&a.WaitMessage, "wait-message", "", "Message to print when intercept handler has started")
 
 	flagSet.BoolVar(&a.DetailedOutput, "detailed-output", false,
@@ -114,52 +219,122 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.Uint16Var(&a.LocalMountPort, "local-mount-port", 0,
 		`Do not mount remote directories. Instead, expose this port on localhost to an external mounter`)
 
-	flagSet.BoolVarP(&a.Replace, "replace", "", false,
-		`Indicates if the traffic-agent should replace application containers in workload pods. `+
-			`The default behavior is for the agent sidecar to be installed alongside existing containers.`)
-
-	// Hide these flags. They are still functional but deprecated. Using them will yield a deprecation message.
-	flagSet.Lookup("local-only").Hidden = true
-	flagSet.Lookup("namespace").Hidden = true
+	flagSet.DurationVar(&a.TTL, "ttl", 0, ``+
+		`The duration that the intercept may remain idle, i.e. without keepalives or traffic from this client, `+
+		`before the traffic-manager removes it. Overrides the default configured in the traffic-manager.`)
 }
 
 func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	flags.DeprecationIfChanged(cmd, "local-only", "use telepresence connect to set the namespace")
 	flags.DeprecationIfChanged(cmd, "namespace", "use telepresence connect to set the namespace")
-	if len(positional) > 1 && cmd.Flags().ArgsLenAtDash() != 1 {
-		return errcat.User.New("commands to be run with intercept must come after options")
+	switch {
+	case a.Adopt != "":
+		if len(positional) > 0 && cmd.Flags().ArgsLenAtDash() != 0 {
+			return errcat.InvalidInterceptFlags.New("--adopt cannot be used with an intercept name. A command to run must come after --")
+		}
+		if err := a.validateAdopt(cmd); err != nil {
+			return err
+		}
+		a.Name = a.Adopt
+		a.Cmdline = positional
+	case a.Spec != "":
+		if len(positional) > 0 {
+			return errcat.InvalidInterceptFlags.New("--spec cannot be used with an intercept name or a command")
+		}
+	case len(positional) == 0:
+		return errcat.InvalidInterceptFlags.New("an intercept name or --spec is required")
+	case len(positional) > 1 && cmd.Flags().ArgsLenAtDash() != 1:
+		return errcat.InvalidInterceptFlags.New("commands to be run with intercept must come after options")
+	default:
+		a.Name = positional[0]
+		a.Cmdline = positional[1:]
 	}
-	a.Name = positional[0]
-	a.Cmdline = positional[1:]
 	a.FormattedOutput = output.WantsFormatted(cmd)
+	if !a.FormattedOutput && output.WantsQuiet(cmd) {
+		a.Silent = true
+	}
+	if a.TTL < 0 {
+		return errcat.InvalidInterceptFlags.New("--ttl cannot be negative")
+	}
+	if a.Mirror && a.Replace {
+		return errcat.InvalidInterceptFlags.New("--mirror and --replace are mutually exclusive")
+	}
+	if a.Mirror && a.TerminateTLS {
+		return errcat.InvalidInterceptFlags.New("--mirror and --terminate-tls are mutually exclusive")
+	}
+	if _, err := matcher.ParseHeaderExpr(a.HttpHeaders); err != nil {
+		return errcat.InvalidInterceptFlags.Newf("--http-header: %v", err)
+	}
+	if _, err := matcher.NewGRPC(a.GrpcMethods, a.GrpcMetadata); err != nil {
+		return errcat.InvalidInterceptFlags.New(err)
+	}
+	if _, err := matcher.NewAffinity(a.Affinity); err != nil {
+		return errcat.InvalidInterceptFlags.Newf("--session-affinity: %v", err)
+	}
+	if a.Affinity != "" && len(a.HttpHeaders) == 0 && len(a.GrpcMethods) == 0 && len(a.GrpcMetadata) == 0 {
+		return errcat.InvalidInterceptFlags.New("--session-affinity requires --http-header, --grpc-method, or --grpc-metadata")
+	}
+	if a.TrafficPercent < 1 || a.TrafficPercent > 100 {
+		return errcat.InvalidInterceptFlags.New("--traffic-percent must be between 1 and 100")
+	}
+	if a.TrafficPercent < 100 && a.Replace {
+		return errcat.InvalidInterceptFlags.New("--traffic-percent and --replace are mutually exclusive")
+	}
+	if a.Spec != "" {
+		return a.validateGroup(cmd)
+	}
 	if a.LocalOnly {
 		// Not actually intercepting anything -- check that the flags make sense for that
 		if a.AgentName != "" {
-			return errcat.User.New("a local-only intercept cannot have a workload")
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have a workload")
 		}
 		if a.ServiceName != "" {
-			return errcat.User.New("a local-only intercept cannot have a service")
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have a service")
 		}
 		if cmd.Flag("port").Changed {
-			return errcat.User.New("a local-only intercept cannot have a port")
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have a port")
+		}
+		if cmd.Flag("ttl").Changed {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have a ttl")
+		}
+		if a.Mirror {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot mirror")
+		}
+		if a.TerminateTLS {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot terminate TLS")
+		}
+		if len(a.HttpHeaders) > 0 {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have http headers")
+		}
+		if len(a.GrpcMethods) > 0 || len(a.GrpcMetadata) > 0 {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have gRPC conditions")
+		}
+		if a.Affinity != "" {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have session affinity")
+		}
+		if a.Pod != "" {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have a pod")
+		}
+		if cmd.Flag("traffic-percent").Changed {
+			return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have a traffic percent")
 		}
 		if cmd.Flag("mount").Changed {
 			if doMount, _ := a.GetMountPoint(); doMount {
-				return errcat.User.New("a local-only intercept cannot have mounts")
+				return errcat.InvalidInterceptFlags.New("a local-only intercept cannot have mounts")
 			}
 		}
 		return nil
 	}
 
 	if a.LocalMountPort > 0 && client.GetConfig(cmd.Context()).Intercept().UseFtp {
-		return errcat.User.New("only SFTP can be used with --local-mount-port. Client is configured to perform remote mounts using FTP")
+		return errcat.InvalidInterceptFlags.New("only SFTP can be used with --local-mount-port. Client is configured to perform remote mounts using FTP")
 	}
 
 	// Actually intercepting something
 	if a.AgentName == "" {
 		a.AgentName = a.Name
 	}
-	if a.Port == "" {
+	if a.Port == "" && !a.Ingest {
 		a.Port = strconv.Itoa(client.GetConfig(cmd.Context()).Intercept().DefaultPort)
 	}
 	a.MountSet = cmd.Flag("mount").Changed
@@ -174,7 +349,7 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 		drCount++
 	}
 	if drCount > 1 {
-		return errcat.User.New("only one of --docker-run, --docker-build, or --docker-debug can be used")
+		return errcat.InvalidInterceptFlags.New("only one of --docker-run, --docker-build, or --docker-debug can be used")
 	}
 	a.DockerRun = drCount == 1
 	if a.DockerRun {
@@ -193,6 +368,9 @@ func (a *Command) Run(cmd *cobra.Command, positional []string) error {
 		return err
 	}
 	ctx := dos.WithStdio(cmd.Context(), cmd)
+	if a.group != nil {
+		return a.runGroup(ctx)
+	}
 	_, err := NewState(a).Run(ctx)
 	return err
 }
@@ -200,7 +378,7 @@ func (a *Command) Run(cmd *cobra.Command, positional []string) error {
 func (a *Command) ValidateDockerArgs() error {
 	for _, arg := range a.Cmdline {
 		if arg == "-d" || arg == "--detach" {
-			return errcat.User.New("running docker container in background using -d or --detach is not supported")
+			return errcat.InvalidInterceptFlags.New("running docker container in background using -d or --detach is not supported")
 		}
 	}
 	return nil
@@ -263,3 +441,21 @@ func (a *Command) GetMountPoint() (bool, string) {
 	}
 	return true, a.Mount
 }
+
+// validateAdopt validates the flags of a command that adopts an intercept declared by an InterceptSpec.
+func (a *Command) validateAdopt(cmd *cobra.Command) error {
+	switch {
+	case a.Spec != "":
+		return errcat.InvalidInterceptFlags.New("--adopt and --spec are mutually exclusive")
+	case a.LocalOnly:
+		return errcat.InvalidInterceptFlags.New("--adopt cannot be used with --local-only")
+	case a.DockerRun || a.DockerBuild != "" || a.DockerDebug != "":
+		return errcat.InvalidInterceptFlags.New("--adopt cannot be used with --docker-run, --docker-build, or --docker-debug")
+	}
+	for _, f := range []string{"workload", "service", "http-header"} {
+		if cmd.Flag(f).Changed {
+			return errcat.InvalidInterceptFlags.Newf("--%s cannot be used with --adopt. It's declared by the InterceptSpec", f)
+		}
+	}
+	return nil
+}
@@ -33,7 +33,7 @@ func (s *state) prepareDockerRun(ctx context.Context) error {
 	// Ensure that the image is ready to run before we create the intercept.
 	if buildContext == "" {
 		if idx < 0 {
-			return errcat.User.New(`unable to find the image name. When using --docker-run, the syntax after "--" must be [OPTIONS] IMAGE [COMMAND] [ARG...]`)
+			return errcat.InvalidInterceptFlags.New(`unable to find the image name. When using --docker-run, the syntax after "--" must be [OPTIONS] IMAGE [COMMAND] [ARG...]`)
 		}
 		return docker.PullImage(ctx, imageName)
 	}
@@ -44,7 +44,7 @@ func (s *state) prepareDockerRun(ctx context.Context) error {
 	//
 	// telepresence intercept hello --docker-build ./some/path -- -it --add-host foo IMAGE --port 8080
 	if (idx < 0 || imageName != "IMAGE") && len(s.Cmdline) > 0 {
-		return errcat.User.New(`` +
+		return errcat.InvalidInterceptFlags.New(`` +
 			`the string "IMAGE", acting as a placeholder for image ID, must be included after "--" when using "--docker-build", so ` +
 			`that flags intended for docker run can be distinguished from the command and arguments intended for the container.`)
 	}
@@ -123,7 +123,7 @@ func (dr *dockerRun) wait(ctx context.Context) error {
 	}
 
 	if dr.err != nil {
-		return errcat.NoDaemonLogs.New(dr.err)
+		return errcat.DockerRunFailed.New(dr.err)
 	}
 
 	sigCh := make(chan os.Signal, 1)
@@ -159,7 +159,7 @@ func (dr *dockerRun) wait(ctx context.Context) error {
 			// Errors caused by context or signal termination doesn't count.
 			err = nil
 		} else {
-			err = errcat.NoDaemonLogs.New(err)
+			err = errcat.DockerRunFailed.New(err)
 		}
 	}
 	return err
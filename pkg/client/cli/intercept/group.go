@@ -0,0 +1,171 @@
+package intercept
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// GroupSpec is the content of the file given to --spec. It declares a group of intercepts that are
+// created together, and that share the options given on the command line.
+type GroupSpec struct {
+	Intercepts []*GroupEntry `json:"intercepts"`
+}
+
+// GroupEntry declares one intercept of a GroupSpec. Its fields correspond to the intercept flags that
+// differ between the intercepts of a group.
+type GroupEntry struct {
+	Name        string   `json:"name"`
+	Workload    string   `json:"workload,omitempty"`
+	Service     string   `json:"service,omitempty"`
+	Port        string   `json:"port,omitempty"`
+	Pod         string   `json:"pod,omitempty"`
+	Mount       string   `json:"mount,omitempty"`
+	EnvFile     string   `json:"envFile,omitempty"`
+	EnvJSON     string   `json:"envJson,omitempty"`
+	HttpHeaders []string `json:"httpHeaders,omitempty"`
+}
+
+// LoadGroupSpec reads and validates the GroupSpec in the given file.
+func LoadGroupSpec(path string) (*GroupSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var gs GroupSpec
+	if err = yaml.UnmarshalStrict(data, &gs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	if len(gs.Intercepts) == 0 {
+		return nil, fmt.Errorf("%s declares no intercepts", path)
+	}
+	names := make(map[string]struct{}, len(gs.Intercepts))
+	for i, e := range gs.Intercepts {
+		if e == nil || e.Name == "" {
+			return nil, fmt.Errorf("intercept %d in %s has no name", i, path)
+		}
+		if _, dup := names[e.Name]; dup {
+			return nil, fmt.Errorf("intercept %q is declared more than once in %s", e.Name, path)
+		}
+		names[e.Name] = struct{}{}
+	}
+	return &gs, nil
+}
+
+// validateGroup validates the flags of a command that creates the group of intercepts declared in the
+// file given to --spec, and loads that file.
+func (a *Command) validateGroup(cmd *cobra.Command) error {
+	switch {
+	case a.LocalOnly:
+		return errcat.InvalidInterceptFlags.New("--spec cannot be used with --local-only")
+	case a.DockerRun || a.DockerBuild != "" || a.DockerDebug != "":
+		return errcat.InvalidInterceptFlags.New("--spec cannot be used with --docker-run, --docker-build, or --docker-debug")
+	}
+	for _, f := range []string{"workload", "service", "port", "pod", "mount", "env-file", "env-json", "local-mount-port"} {
+		if cmd.Flag(f).Changed {
+			return errcat.InvalidInterceptFlags.Newf("--%s cannot be used with --spec. Declare it for each intercept in the spec instead", f)
+		}
+	}
+	gs, err := LoadGroupSpec(a.Spec)
+	if err != nil {
+		return errcat.InvalidInterceptFlags.New(err)
+	}
+	a.group = gs
+	return nil
+}
+
+// groupCommands returns a command for each intercept of the group. The commands share the options of
+// this command.
+func (a *Command) groupCommands(ctx context.Context) []*Command {
+	cmds := make([]*Command, len(a.group.Intercepts))
+	for i, e := range a.group.Intercepts {
+		c := *a
+		c.group = nil
+		c.Silent = true
+		c.Name = e.Name
+		c.AgentName = e.Workload
+		if c.AgentName == "" {
+			c.AgentName = e.Name
+		}
+		c.ServiceName = e.Service
+		c.Port = e.Port
+		if c.Port == "" {
+			c.Port = strconv.Itoa(client.GetConfig(ctx).Intercept().DefaultPort)
+		}
+		c.Pod = e.Pod
+		c.Mount = e.Mount
+		c.MountSet = e.Mount != ""
+		if !c.MountSet {
+			c.Mount = "true"
+		}
+		c.EnvFile = e.EnvFile
+		c.EnvJSON = e.EnvJSON
+		c.HttpHeaders = append(c.HttpHeaders[:len(c.HttpHeaders):len(c.HttpHeaders)], e.HttpHeaders...)
+		cmds[i] = &c
+	}
+	return cmds
+}
+
+// runGroup creates the intercepts of the group in the order that they are declared. If one of them
+// can't be created, then those that were created are removed, so that either all or none of the
+// intercepts exist when the command ends.
+func (a *Command) runGroup(ctx context.Context) error {
+	ctx = scout.NewReporter(ctx, "cli")
+	scout.Start(ctx)
+	defer scout.Close(ctx)
+
+	cmds := a.groupCommands(ctx)
+	states := make([]*state, 0, len(cmds))
+	for _, c := range cmds {
+		s := &state{Command: c}
+		s.self = s
+		acquired, err := s.create(ctx)
+		if acquired {
+			states = append(states, s)
+		}
+		if err != nil {
+			a.leaveGroup(ctx, states)
+			return fmt.Errorf("intercept %s: %w", c.Name, err)
+		}
+	}
+
+	infos := make([]*Info, len(states))
+	for i, s := range states {
+		infos[i] = s.info
+	}
+	switch {
+	case a.FormattedOutput:
+		output.Object(ctx, infos, true)
+	case !a.Silent:
+		out := output.NewRenderer(ctx, dos.Stdout(ctx))
+		fmt.Fprintf(out, "Created %d intercepts from %s\n", len(infos), a.Spec)
+		for _, info := range infos {
+			_, _ = info.WriteTo(out)
+			_, _ = fmt.Fprintln(out)
+		}
+	}
+	return nil
+}
+
+// leaveGroup removes the given intercepts in reverse order.
+func (a *Command) leaveGroup(ctx context.Context, states []*state) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	for i := len(states) - 1; i >= 0; i-- {
+		if err := states[i].leave(ctx); err != nil {
+			dlog.Errorf(ctx, "unable to remove intercept %s: %v", states[i].Name(), err)
+		}
+	}
+}
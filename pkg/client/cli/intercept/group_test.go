@@ -0,0 +1,58 @@
+package intercept
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGroupSpec(t *testing.T) {
+	write := func(content string) string {
+		p := filepath.Join(t.TempDir(), "group.yaml")
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+		return p
+	}
+
+	gs, err := LoadGroupSpec(write(`
+intercepts:
+  - name: orders
+    port: "8080"
+    httpHeaders: ["x-dev=alice"]
+  - name: payments
+    workload: payments-v2
+    port: "8081:http"
+    mount: "false"
+`))
+	require.NoError(t, err)
+	require.Len(t, gs.Intercepts, 2)
+	assert.Equal(t, "payments-v2", gs.Intercepts[1].Workload)
+
+	a := &Command{Spec: "group.yaml", HttpHeaders: []string{"x-team=core"}, group: gs}
+	cmds := a.groupCommands(context.Background())
+	require.Len(t, cmds, 2)
+	assert.Equal(t, "orders", cmds[0].AgentName)
+	assert.Equal(t, []string{"x-team=core", "x-dev=alice"}, cmds[0].HttpHeaders)
+	assert.True(t, cmds[0].Silent)
+	doMount, _ := cmds[0].GetMountPoint()
+	assert.True(t, doMount)
+	assert.Equal(t, "payments", cmds[1].Name)
+	assert.Equal(t, "payments-v2", cmds[1].AgentName)
+	assert.Equal(t, "8081:http", cmds[1].Port)
+	assert.Equal(t, []string{"x-team=core"}, cmds[1].HttpHeaders)
+	doMount, _ = cmds[1].GetMountPoint()
+	assert.False(t, doMount)
+
+	for _, bad := range []string{
+		`intercepts: []`,
+		`intercepts: [{port: "8080"}]`,
+		`intercepts: [{name: a}, {name: a}]`,
+		`intercepts: [{name: a, unknown: b}]`,
+	} {
+		_, err = LoadGroupSpec(write(bad))
+		assert.Error(t, err, bad)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
 
@@ -34,6 +35,7 @@ type Info struct {
 	Disposition   string            `json:"disposition,omitempty"     yaml:"disposition,omitempty"`
 	Message       string            `json:"message,omitempty"         yaml:"message,omitempty"`
 	WorkloadKind  string            `json:"workload_kind,omitempty"   yaml:"workload_kind,omitempty"`
+	NodeName      string            `json:"node_name,omitempty"       yaml:"node_name,omitempty"`
 	TargetHost    string            `json:"target_host,omitempty"     yaml:"target_host,omitempty"`
 	TargetPort    int32             `json:"target_port,omitempty"     yaml:"target_port,omitempty"`
 	ServicePortID string            `json:"service_port_id,omitempty" yaml:"service_port_id,omitempty"`
@@ -45,6 +47,7 @@ type Info struct {
 	Global        bool              `json:"global,omitempty"          yaml:"global,omitempty"`
 	PreviewURL    string            `json:"preview_url,omitempty"     yaml:"preview_url,omitempty"`
 	Ingress       *Ingress          `json:"ingress,omitempty"         yaml:"ingress,omitempty"`
+	Ingest        bool              `json:"ingest,omitempty"          yaml:"ingest,omitempty"`
 	debug         bool
 }
 
@@ -106,6 +109,7 @@ func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		Disposition:   ii.Disposition.String(),
 		Message:       ii.Message,
 		WorkloadKind:  spec.WorkloadKind,
+		NodeName:      ii.NodeName,
 		TargetHost:    spec.TargetHost,
 		TargetPort:    spec.TargetPort,
 		Mount:         NewMount(ctx, ii, mountError),
@@ -117,6 +121,7 @@ func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		Global:        spec.Mechanism == "tcp",
 		PreviewURL:    PreviewURL(ii.PreviewDomain),
 		Ingress:       NewIngress(ii.PreviewSpec),
+		Ingest:        spec.Ingest,
 	}
 }
 
@@ -126,25 +131,34 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 	kvf.Add("Intercept name", ii.Name)
 	kvf.Add("State", func() string {
 		msg := ""
+		color := output.Green
 		if manager.InterceptDispositionType_value[ii.Disposition] > int32(manager.InterceptDispositionType_WAITING) {
 			msg += "error: "
+			color = output.Red
+		} else if ii.Disposition != manager.InterceptDispositionType_ACTIVE.String() {
+			color = output.Yellow
 		}
 		msg += ii.Disposition
 		if ii.Message != "" {
 			msg += ": " + ii.Message
 		}
-		return msg
+		return output.Colorize(w, color, msg)
 	}())
 	kvf.Add("Workload kind", ii.WorkloadKind)
+	if ii.NodeName != "" {
+		kvf.Add("Node", ii.NodeName)
+	}
 
 	if ii.debug {
 		kvf.Add("ID", ii.ID)
 	}
 
-	kvf.Add(
-		"Destination",
-		net.JoinHostPort(ii.TargetHost, fmt.Sprintf("%d", ii.TargetPort)),
-	)
+	if !ii.Ingest {
+		kvf.Add(
+			"Destination",
+			net.JoinHostPort(ii.TargetHost, fmt.Sprintf("%d", ii.TargetPort)),
+		)
+	}
 
 	if ii.ServicePortID != "" {
 		kvf.Add("Service Port Identifier", ii.ServicePortID)
@@ -0,0 +1,207 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cache"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// persistedDirName is the user cache directory where the commands that created the active intercepts are
+// persisted, in one subdirectory for each connection. A command is removed when its intercept is left, and
+// retained when the intercept ends because the daemon died, so that "telepresence resume" can recreate it.
+const persistedDirName = "intercepts"
+
+func persistedFile(connection, name string) string {
+	return filepath.Join(persistedDirName, connection, name+".json")
+}
+
+// persist saves the command of the given state in the user cache. Its relative file paths are made
+// absolute, so that they remain valid when the intercept is resumed from another directory.
+func (s *state) persist(ctx context.Context) {
+	ud := daemon.GetUserClient(ctx)
+	if ud == nil {
+		return
+	}
+	c := *s.Command
+	c.Silent = false
+	c.FormattedOutput = false
+	c.DetailedOutput = false
+	c.Spec = ""
+	c.EnvFile = absPath(c.EnvFile)
+	c.EnvJSON = absPath(c.EnvJSON)
+	if c.MountSet {
+		if _, err := strconv.ParseBool(c.Mount); err != nil {
+			c.Mount = absPath(c.Mount)
+		}
+	}
+	if c.DockerBuild != "" && isDir(c.DockerBuild) {
+		c.DockerBuild = absPath(c.DockerBuild)
+	}
+	if c.DockerDebug != "" && isDir(c.DockerDebug) {
+		c.DockerDebug = absPath(c.DockerDebug)
+	}
+	if err := cache.SaveToUserCache(ctx, &c, persistedFile(ud.DaemonID().Name, s.Name()), cache.Private); err != nil {
+		dlog.Warnf(ctx, "unable to persist intercept %s, it cannot be resumed: %v", s.Name(), err)
+	}
+}
+
+func absPath(path string) string {
+	if path != "" {
+		if ap, err := filepath.Abs(path); err == nil {
+			return ap
+		}
+	}
+	return path
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// Forget removes the persisted command of the intercept with the given name in the current connection. It
+// returns true if such a command existed.
+func Forget(ctx context.Context, name string) bool {
+	ud := daemon.GetUserClient(ctx)
+	if ud == nil {
+		return false
+	}
+	file := persistedFile(ud.DaemonID().Name, name)
+	if ok, err := cache.ExistsInCache(ctx, file); err != nil || !ok {
+		return false
+	}
+	if err := cache.DeleteFromUserCache(ctx, file); err != nil {
+		dlog.Warnf(ctx, "unable to remove persisted intercept %s: %v", name, err)
+	}
+	return true
+}
+
+// ForgetAll removes the persisted commands of all intercepts in the given connection, or in all connections
+// when the connection is empty.
+func ForgetAll(ctx context.Context, connection string) {
+	dir := filepath.Join(filelocation.AppUserCacheDir(ctx), persistedDirName, connection)
+	if err := os.RemoveAll(dir); err != nil {
+		dlog.Warnf(ctx, "unable to remove persisted intercepts: %v", err)
+	}
+}
+
+// loadPersisted returns the persisted commands of the intercepts in the given connection, sorted by name.
+func loadPersisted(ctx context.Context, connection string) ([]*Command, error) {
+	files, err := os.ReadDir(filepath.Join(filelocation.AppUserCacheDir(ctx), persistedDirName, connection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return nil, err
+	}
+	cmds := make([]*Command, 0, len(files))
+	for _, file := range files {
+		fn := file.Name()
+		if file.IsDir() || !strings.HasSuffix(fn, ".json") {
+			// Not an entry, or a temporary file that is being written.
+			continue
+		}
+		var c Command
+		if err = cache.LoadFromUserCache(ctx, &c, filepath.Join(persistedDirName, connection, fn)); err != nil {
+			if os.IsNotExist(err) {
+				// Removed after it was listed, or removed because it was corrupt.
+				continue
+			}
+			return nil, err
+		}
+		cmds = append(cmds, &c)
+	}
+	slices.SortFunc(cmds, func(a, b *Command) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cmds, nil
+}
+
+// Resume recreates the persisted intercepts of the current connection that aren't active, or only those
+// with the given names when names are given. Intercepts that run a handler are resumed concurrently, and
+// Resume then returns when all handlers have ended.
+func Resume(ctx context.Context, names []string, formattedOutput, silent bool) error {
+	ud := daemon.GetUserClient(ctx)
+	cmds, err := loadPersisted(ctx, ud.DaemonID().Name)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		selected := make([]*Command, 0, len(names))
+		for _, name := range names {
+			i := slices.IndexFunc(cmds, func(c *Command) bool { return c.Name == name })
+			if i < 0 {
+				return errcat.User.Newf("no intercept named %q can be resumed", name)
+			}
+			selected = append(selected, cmds[i])
+		}
+		cmds = selected
+	}
+
+	var resumable []*Command
+	for _, c := range cmds {
+		if _, err := ud.GetIntercept(ctx, &manager.GetInterceptRequest{Name: c.Name}); err == nil {
+			if !silent && !formattedOutput {
+				ioutil.Printf(output.Info(ctx), "Intercept %s is already active\n", c.Name)
+			}
+			continue
+		}
+		c.FormattedOutput = formattedOutput
+		c.Silent = silent
+		resumable = append(resumable, c)
+	}
+	if len(resumable) == 0 {
+		if !silent && !formattedOutput {
+			ioutil.Println(output.Info(ctx), "No intercepts to resume")
+		}
+		return nil
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		errs  []error
+		infos []*Info
+	)
+	run := func(c *Command) {
+		info, err := NewState(c).Run(ctx)
+		mu.Lock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("intercept %s: %w", c.Name, err))
+		} else if info != nil {
+			infos = append(infos, info)
+		}
+		mu.Unlock()
+	}
+	for _, c := range resumable {
+		if len(c.Cmdline) > 0 || c.DockerRun {
+			wg.Add(1)
+			go func(c *Command) {
+				defer wg.Done()
+				run(c)
+			}(c)
+		} else {
+			run(c)
+		}
+	}
+	wg.Wait()
+	if formattedOutput {
+		output.Object(ctx, infos, true)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,41 @@
+package intercept
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cache"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+func Test_loadPersisted(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+
+	cmds, err := loadPersisted(ctx, "conn")
+	require.NoError(t, err)
+	assert.Empty(t, cmds)
+
+	web := &Command{Name: "web", AgentName: "web", Port: "8080", Cmdline: []string{"python", "-m", "http.server"}, TTL: time.Minute}
+	api := &Command{Name: "api", AgentName: "api", Port: "9090", EnvFile: "/tmp/api.env", Mount: "false", MountSet: true}
+	require.NoError(t, cache.SaveToUserCache(ctx, web, persistedFile("conn", web.Name), cache.Private))
+	require.NoError(t, cache.SaveToUserCache(ctx, api, persistedFile("conn", api.Name), cache.Private))
+	require.NoError(t, cache.SaveToUserCache(ctx, web, persistedFile("other", web.Name), cache.Private))
+
+	cmds, err = loadPersisted(ctx, "conn")
+	require.NoError(t, err)
+	assert.Equal(t, []*Command{api, web}, cmds)
+
+	ForgetAll(ctx, "conn")
+	cmds, err = loadPersisted(ctx, "conn")
+	require.NoError(t, err)
+	assert.Empty(t, cmds)
+
+	// Other connections are unaffected.
+	cmds, err = loadPersisted(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, []*Command{web}, cmds)
+}
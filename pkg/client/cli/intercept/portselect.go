@@ -0,0 +1,115 @@
+package intercept
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/common"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// AmbiguousPorts is the formatted output of an intercept that failed because the traffic-manager couldn't
+// determine which service port to intercept.
+type AmbiguousPorts struct {
+	Ports []*manager.InterceptablePort `json:"ports" yaml:"ports"`
+}
+
+// selectPort is called when the traffic-manager reports that the intercept matches several service ports.
+// The ports are emitted together with the error when formatted output is requested. When stdin is a
+// terminal, the user is asked to pick a port and the request is submitted again. The result is returned
+// unmodified in all other cases.
+func (s *state) selectPort(ctx context.Context, ir *connector.CreateInterceptRequest, r *connector.InterceptResult) (*connector.InterceptResult, error) {
+	if r.Error != common.InterceptError_AMBIGUOUS_PORT || len(r.AmbiguousPorts) == 0 {
+		return r, nil
+	}
+	if s.FormattedOutput {
+		output.Object(ctx, &AmbiguousPorts{Ports: r.AmbiguousPorts}, false)
+		return r, nil
+	}
+	if s.Silent || !isTerminal(dos.Stdin(ctx)) {
+		return r, nil
+	}
+	p, err := promptForPort(dos.Stdin(ctx), dos.Stdout(ctx), r.ErrorText, r.AmbiguousPorts)
+	if err != nil {
+		return nil, errcat.WithCode(errcat.User.New(err), errcat.AmbiguousPort)
+	}
+	spi, err := portIdentifier(p)
+	if err != nil {
+		return nil, err
+	}
+	ir.Spec.ServiceName = p.ServiceName
+	ir.Spec.ServicePortIdentifier = spi.String()
+	return daemon.GetUserClient(ctx).CreateIntercept(ctx, ir)
+}
+
+// promptForPort lists the given ports on out and reads the number of the selected port from in.
+func promptForPort(in io.Reader, out io.Writer, msg string, ports []*manager.InterceptablePort) (*manager.InterceptablePort, error) {
+	// Only the first line of the message is relevant. The rest is advice on what flags to use.
+	if ix := strings.IndexByte(msg, '\n'); ix > 0 {
+		msg = msg[:ix]
+	}
+	fmt.Fprintln(out, msg)
+	for i, p := range ports {
+		fmt.Fprintf(out, "%4d: %s\n", i+1, describePort(p))
+	}
+	fmt.Fprintf(out, "Select the port to intercept [1-%d]: ", len(ports))
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return nil, fmt.Errorf("no port selected: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(ports) {
+		return nil, fmt.Errorf("invalid port selection %q", strings.TrimSpace(line))
+	}
+	return ports[n-1], nil
+}
+
+// describePort returns a one-line description of the given port, e.g.
+// "service echo, port http 80/TCP (http), container echo port 8080".
+func describePort(p *manager.InterceptablePort) string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "service %s, port ", p.ServiceName)
+	if p.ServicePortName != "" {
+		sb.WriteString(p.ServicePortName)
+		sb.WriteByte(' ')
+	}
+	fmt.Fprintf(&sb, "%d/%s", p.ServicePort, p.Protocol)
+	if p.AppProtocol != "" {
+		fmt.Fprintf(&sb, " (%s)", p.AppProtocol)
+	}
+	fmt.Fprintf(&sb, ", container %s port %d", p.ContainerName, p.ContainerPort)
+	return sb.String()
+}
+
+// portIdentifier returns the identifier that unambiguously selects the given port.
+func portIdentifier(p *manager.InterceptablePort) (agentconfig.PortIdentifier, error) {
+	spi := p.ServicePortName
+	if spi == "" {
+		spi = strconv.Itoa(int(p.ServicePort))
+	}
+	proto := p.Protocol
+	if proto == "TCP" {
+		proto = ""
+	}
+	return agentconfig.NewPortIdentifier(proto, spi)
+}
+
+func isTerminal(r io.Reader) bool {
+	if f, ok := r.(interface{ Fd() uintptr }); ok {
+		return term.IsTerminal(int(f.Fd()))
+	}
+	return false
+}
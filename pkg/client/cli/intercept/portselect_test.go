@@ -0,0 +1,42 @@
+package intercept
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_promptForPort(t *testing.T) {
+	ports := []*manager.InterceptablePort{
+		{ServiceName: "echo", ServicePortName: "http", ServicePort: 80, Protocol: "TCP", AppProtocol: "http", ContainerName: "echo", ContainerPort: 8080},
+		{ServiceName: "echo", ServicePort: 53, Protocol: "UDP", ContainerName: "dns", ContainerPort: 5353},
+	}
+	msg := "Deployment echo.default has multiple interceptable service ports.\nPlease specify..."
+
+	out := &bytes.Buffer{}
+	p, err := promptForPort(strings.NewReader("2\n"), out, msg, ports)
+	require.NoError(t, err)
+	assert.Equal(t, ports[1], p)
+	assert.Equal(t, "Deployment echo.default has multiple interceptable service ports.\n"+
+		"   1: service echo, port http 80/TCP (http), container echo port 8080\n"+
+		"   2: service echo, port 53/UDP, container dns port 5353\n"+
+		"Select the port to intercept [1-2]: ", out.String())
+
+	spi, err := portIdentifier(p)
+	require.NoError(t, err)
+	assert.Equal(t, "53/UDP", spi.String())
+
+	spi, err = portIdentifier(ports[0])
+	require.NoError(t, err)
+	assert.Equal(t, "http", spi.String())
+
+	for _, in := range []string{"", "0\n", "3\n", "x\n"} {
+		_, err = promptForPort(strings.NewReader(in), &bytes.Buffer{}, msg, ports)
+		assert.Error(t, err, in)
+	}
+}
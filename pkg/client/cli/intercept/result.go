@@ -2,6 +2,7 @@ package intercept
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -16,34 +17,42 @@ func Result(r *connector.InterceptResult, err error) error {
 		return err
 	}
 	msg := ""
-	errCat := errcat.Unknown
+	code := errcat.InterceptFailed
 	switch r.Error {
 	case common.InterceptError_UNSPECIFIED:
 		return nil
 	case common.InterceptError_INTERNAL:
 		msg = r.ErrorText
 	case common.InterceptError_NO_CONNECTION:
+		code = errcat.NotConnected
 		msg = "Local network is not connected to the cluster"
 	case common.InterceptError_NO_TRAFFIC_MANAGER:
+		code = errcat.NoTrafficManager
 		msg = "Intercept unavailable: no traffic manager"
 	case common.InterceptError_TRAFFIC_MANAGER_CONNECTING:
+		code = errcat.TrafficManagerConnecting
 		msg = "Connecting to traffic manager..."
 	case common.InterceptError_TRAFFIC_MANAGER_ERROR:
 		msg = r.ErrorText
 	case common.InterceptError_ALREADY_EXISTS:
+		code = errcat.InterceptExists
 		msg = fmt.Sprintf("Intercept with name %q already exists", r.ErrorText)
 	case common.InterceptError_NAMESPACE_AMBIGUITY:
+		code = errcat.NamespaceAmbiguity
 		nss := strings.Split(r.ErrorText, ",")
 		msg = fmt.Sprintf(
 			"Cannot create an intercept in namespace %q. A workstation cannot have simultaneous intercepts in different namespaces. Leave all intercepts in namespace %q first.",
 			nss[1], nss[0])
 	case common.InterceptError_LOCAL_TARGET_IN_USE:
+		code = errcat.LocalTargetInUse
 		spec := r.InterceptInfo.Spec
 		msg = fmt.Sprintf("Port %s:%d is already in use by intercept %s",
 			spec.TargetHost, spec.TargetPort, spec.Name)
 	case common.InterceptError_NO_ACCEPTABLE_WORKLOAD:
+		code = errcat.NoAcceptableWorkload
 		msg = fmt.Sprintf("No interceptable deployment, replicaset, or statefulset matching %s found", r.ErrorText)
 	case common.InterceptError_AMBIGUOUS_MATCH:
+		code = errcat.AmbiguousMatch
 		var matches []manager.AgentInfo
 		err := json.Unmarshal([]byte(r.ErrorText), &matches)
 		if err != nil {
@@ -58,26 +67,38 @@ func Result(r *connector.InterceptResult, err error) error {
 		}
 		msg = st.String()
 	case common.InterceptError_FAILED_TO_ESTABLISH:
+		code = errcat.InterceptNotEstablished
 		msg = fmt.Sprintf("Failed to establish intercept: %s", r.ErrorText)
 	case common.InterceptError_UNSUPPORTED_WORKLOAD:
+		code = errcat.UnsupportedWorkload
 		msg = fmt.Sprintf("Unsupported workload type: %s", r.ErrorText)
 	case common.InterceptError_NOT_FOUND:
+		code = errcat.InterceptNotFound
 		msg = fmt.Sprintf("Intercept named %q not found", r.ErrorText)
 	case common.InterceptError_MOUNT_POINT_BUSY:
+		code = errcat.MountPointBusy
 		msg = fmt.Sprintf("Mount point already in use by intercept %q", r.ErrorText)
 	case common.InterceptError_MISCONFIGURED_WORKLOAD:
+		code = errcat.MisconfiguredWorkload
+		msg = r.ErrorText
+	case common.InterceptError_AMBIGUOUS_PORT:
+		code = errcat.AmbiguousPort
+		msg = r.ErrorText
+	case common.InterceptError_QUOTA_EXCEEDED:
+		code = errcat.InterceptQuotaExceeded
 		msg = r.ErrorText
 	case common.InterceptError_UNKNOWN_FLAG:
+		code = errcat.UnknownFlag
 		msg = fmt.Sprintf("Unknown flag: %s", r.ErrorText)
 	default:
 		msg = fmt.Sprintf("Unknown error code %d", r.Error)
 	}
-	if r.ErrorCategory > 0 {
-		errCat = errcat.Category(r.ErrorCategory)
-	}
-
 	if id := r.GetInterceptInfo().GetId(); id != "" {
 		msg = fmt.Sprintf("%s: id = %q", msg, id)
 	}
-	return errCat.Newf(msg)
+	// The category of the code is just a default. The category provided by the daemon takes precedence.
+	if r.ErrorCategory > 0 {
+		return errcat.WithCode(errcat.Category(r.ErrorCategory).New(msg), code)
+	}
+	return errcat.WithCode(errors.New(msg), code)
 }
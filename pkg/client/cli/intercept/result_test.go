@@ -0,0 +1,28 @@
+package intercept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/common"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func TestResult_category(t *testing.T) {
+	err := Result(&connector.InterceptResult{Error: common.InterceptError_QUOTA_EXCEEDED, ErrorText: "quota exceeded"}, nil)
+	require.Error(t, err)
+	assert.Equal(t, errcat.InterceptQuotaExceeded, errcat.GetCode(err))
+	assert.Equal(t, errcat.User, errcat.GetCategory(err), "the category comes from the code")
+
+	err = Result(&connector.InterceptResult{
+		Error:         common.InterceptError_QUOTA_EXCEEDED,
+		ErrorText:     "quota exceeded",
+		ErrorCategory: int32(errcat.Config),
+	}, nil)
+	require.Error(t, err)
+	assert.Equal(t, errcat.InterceptQuotaExceeded, errcat.GetCode(err))
+	assert.Equal(t, errcat.Config, errcat.GetCategory(err), "the category provided by the daemon takes precedence")
+}
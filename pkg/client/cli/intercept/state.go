@@ -15,6 +15,7 @@ import (
 
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 	core "k8s.io/api/core/v1"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/spinner"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/redact"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
@@ -76,6 +78,11 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 	spec := &manager.InterceptSpec{
 		Name:    s.Name(),
 		Replace: s.Replace,
+		Mirror:  s.Mirror,
+		Ingest:  s.Ingest,
+	}
+	if s.TrafficPercent < 100 {
+		spec.TrafficPercent = int32(s.TrafficPercent)
 	}
 	ir := &connector.CreateInterceptRequest{
 		Spec:         spec,
@@ -94,22 +101,42 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 
 	spec.Mechanism = s.Mechanism
 	spec.MechanismArgs = s.MechanismArgs
+	spec.HttpHeaders = s.HttpHeaders
+	spec.GrpcMethods = s.GrpcMethods
+	spec.GrpcMetadata = s.GrpcMetadata
+	spec.SessionAffinity = s.Affinity
+	spec.Pod = s.Pod
+	spec.Declaration = s.Adopt
+	spec.TerminateTls = s.TerminateTLS
+	if s.TTL > 0 {
+		spec.IdleTtl = durationpb.New(s.TTL)
+	}
 	spec.Agent = s.AgentName
 	spec.TargetHost = "127.0.0.1"
 
 	ud := daemon.GetUserClient(ctx)
 
-	// Parse port into spec based on how it's formatted
 	var err error
-	s.localPort, s.dockerPort, spec.ServicePortIdentifier, err = parsePort(s.Port, s.DockerRun, ud.Containerized())
-	if err != nil {
-		return nil, err
-	}
-	spec.TargetPort = int32(s.localPort)
-	if iputil.Parse(s.Address) == nil {
-		return nil, fmt.Errorf("--address %s is not a valid IP address", s.Address)
+	if s.Ingest {
+		// An ingest has no local target. The port is just the service port identifier.
+		if s.Port != "" {
+			if err = agentconfig.ValidatePort(s.Port); err != nil {
+				return nil, errcat.InvalidInterceptFlags.New("port must be a service port name or number")
+			}
+		}
+		spec.ServicePortIdentifier = s.Port
+	} else {
+		// Parse port into spec based on how it's formatted
+		s.localPort, s.dockerPort, spec.ServicePortIdentifier, err = parsePort(s.Port, s.DockerRun, ud.Containerized())
+		if err != nil {
+			return nil, err
+		}
+		spec.TargetPort = int32(s.localPort)
+		if iputil.Parse(s.Address) == nil {
+			return nil, fmt.Errorf("--address %s is not a valid IP address", s.Address)
+		}
+		spec.TargetHost = s.Address
 	}
-	spec.TargetHost = s.Address
 
 	mountEnabled, mountPoint := s.GetMountPoint()
 	if !mountEnabled {
@@ -221,6 +248,12 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 	scout.SetMetadatum(ctx, "intercept_mechanism", s.Mechanism)
 	scout.SetMetadatum(ctx, "intercept_mechanism_numargs", len(s.MechanismArgs))
 
+	if s.Adopt != "" {
+		if err = s.adopt(ctx); err != nil {
+			return false, err
+		}
+	}
+
 	ir, err := s.self.CreateRequest(ctx)
 	if err != nil {
 		scout.Report(ctx, "intercept_validation_fail", scout.Entry{Key: "error", Value: err.Error()})
@@ -247,12 +280,16 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 
 	// Submit the request
 	r, err := ud.CreateIntercept(ctx, ir)
+	if err == nil {
+		r, err = s.selectPort(ctx, ir, r)
+	}
 	if err = Result(r, err); err != nil {
 		return false, fmt.Errorf("connector.CreateIntercept: %w", err)
 	}
 
 	if s.AgentName == "" {
 		// local-only
+		s.persist(ctx)
 		return true, nil
 	}
 	detailedOutput := s.DetailedOutput && s.FormattedOutput
@@ -304,11 +341,12 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		mountError = volumeMountProblem.Error()
 	}
 	s.info = NewInfo(ctx, intercept, mountError)
+	s.persist(ctx)
 	if !s.Silent {
 		if detailedOutput {
 			output.Object(ctx, s.info, true)
 		} else {
-			out := dos.Stdout(ctx)
+			out := output.NewRenderer(ctx, dos.Stdout(ctx))
 			_, _ = s.info.WriteTo(out)
 			_, _ = fmt.Fprintln(out)
 		}
@@ -316,10 +354,30 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 	return true, nil
 }
 
+// adopt applies the workload, service, and service port of the intercept declared by the InterceptSpec
+// that --adopt names. The header conditions of the declaration are applied by the traffic-manager.
+func (s *state) adopt(ctx context.Context) error {
+	di, err := daemon.GetUserClient(ctx).GetDeclaredIntercept(ctx, &manager.GetDeclaredInterceptRequest{Name: s.Adopt})
+	if err != nil {
+		return err
+	}
+	s.AgentName = di.Workload
+	s.ServiceName = di.Service
+	if di.Port != "" && !strings.Contains(s.Port, ":") {
+		s.Port += ":" + di.Port
+	}
+	return nil
+}
+
 func (s *state) leave(ctx context.Context) error {
 	n := strings.TrimSpace(s.Name())
 	dlog.Debugf(ctx, "Leaving intercept %s", n)
 	r, err := daemon.GetUserClient(ctx).RemoveIntercept(ctx, &manager.RemoveInterceptRequest2{Name: n})
+	if err == nil {
+		// The intercept was left deliberately, so it must not be resumed. It's retained when the daemon is
+		// unavailable.
+		Forget(ctx, n)
+	}
 	if err != nil && grpcStatus.Code(err) == grpcCodes.Canceled {
 		// Deactivation was caused by a disconnect
 		err = nil
@@ -351,7 +409,12 @@ func (s *state) runCommand(ctx context.Context) error {
 		return errcat.NoDaemonLogs.New(proc.Wait(ctx, func() {}, cmd))
 	}
 
-	envFile := s.EnvFile
+	// The values that are sourced from Secrets are masked in the file given by --env-file, so
+	// the container can only use that file when --no-redact was given.
+	envFile := ""
+	if s.NoRedact {
+		envFile = s.EnvFile
+	}
 	if envFile == "" {
 		file, err := os.CreateTemp("", "tel-*.env")
 		if err != nil {
@@ -359,7 +422,7 @@ func (s *state) runCommand(ctx context.Context) error {
 		}
 		defer os.Remove(file.Name())
 
-		if err = s.writeEnvToFileAndClose(file); err != nil {
+		if err = writeEnvToFileAndClose(file, s.env); err != nil {
 			return err
 		}
 		envFile = file.Name()
@@ -446,16 +509,25 @@ func (s *state) writeEnvFile() error {
 	if err != nil {
 		return errcat.NoDaemonLogs.Newf("failed to create environment file %q: %w", s.EnvFile, err)
 	}
-	return s.writeEnvToFileAndClose(file)
+	return writeEnvToFileAndClose(file, s.exportedEnv())
+}
+
+// exportedEnv returns the environment that is written to the --env-file and --env-json files. The
+// values that are sourced from Secrets are masked unless --no-redact was given.
+func (s *state) exportedEnv() map[string]string {
+	if s.NoRedact {
+		return s.env
+	}
+	return redact.Env(s.env)
 }
 
-func (s *state) writeEnvToFileAndClose(file *os.File) (err error) {
+func writeEnvToFileAndClose(file *os.File, env map[string]string) (err error) {
 	defer file.Close()
 	w := bufio.NewWriter(file)
 
-	keys := make([]string, len(s.env))
+	keys := make([]string, len(env))
 	i := 0
-	for k := range s.env {
+	for k := range env {
 		keys[i] = k
 		i++
 	}
@@ -468,7 +540,7 @@ func (s *state) writeEnvToFileAndClose(file *os.File) (err error) {
 		if err = w.WriteByte('='); err != nil {
 			return err
 		}
-		if _, err = w.WriteString(s.env[k]); err != nil {
+		if _, err = w.WriteString(env[k]); err != nil {
 			return err
 		}
 		if err = w.WriteByte('\n'); err != nil {
@@ -479,7 +551,7 @@ func (s *state) writeEnvToFileAndClose(file *os.File) (err error) {
 }
 
 func (s *state) writeEnvJSON() error {
-	data, err := json.MarshalIndent(s.env, "", "  ")
+	data, err := json.MarshalIndent(s.exportedEnv(), "", "  ")
 	if err != nil {
 		// Creating JSON from a map[string]string should never fail
 		panic(err)
@@ -492,9 +564,9 @@ func parsePort(portSpec string, dockerRun, remote bool) (local uint16, docker ui
 	portMapping := strings.Split(portSpec, ":")
 	portError := func() (uint16, uint16, string, error) {
 		if dockerRun && !remote {
-			return 0, 0, "", errcat.User.New("port must be of the format --port <local-port>:<container-port>[:<svcPortIdentifier>]")
+			return 0, 0, "", errcat.InvalidInterceptFlags.New("port must be of the format --port <local-port>:<container-port>[:<svcPortIdentifier>]")
 		}
-		return 0, 0, "", errcat.User.New("port must be of the format --port <local-port>[:<svcPortIdentifier>]")
+		return 0, 0, "", errcat.InvalidInterceptFlags.New("port must be of the format --port <local-port>[:<svcPortIdentifier>]")
 	}
 
 	if local, err = agentconfig.ParseNumericPort(portMapping[0]); err != nil {
@@ -517,7 +589,7 @@ func parsePort(portSpec string, dockerRun, remote bool) (local uint16, docker ui
 		}
 	case 3:
 		if remote && dockerRun {
-			return 0, 0, "", errcat.User.New(
+			return 0, 0, "", errcat.InvalidInterceptFlags.New(
 				"the format --port <local-port>:<container-port>:<svcPortIdentifier> cannot be used when the daemon runs in a container")
 		}
 		if !dockerRun {
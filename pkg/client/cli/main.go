@@ -55,6 +55,7 @@ func InitContext(ctx context.Context) context.Context {
 }
 
 func Main(ctx context.Context) {
+	ctx = filelocation.WithEnvOverrides(ctx)
 	if dir := os.Getenv("DEV_TELEPRESENCE_CONFIG_DIR"); dir != "" {
 		ctx = filelocation.WithAppUserConfigDir(ctx, dir)
 	}
@@ -75,6 +76,9 @@ func Main(ctx context.Context) {
 				os.Exit(1)
 			}
 			fmt.Fprintf(cmd.ErrOrStderr(), "%s: error: %v\n", cmd.CommandPath(), err)
+			if desc := errcat.Describe(err); desc != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", cmd.CommandPath(), desc)
+			}
 			if errcat.GetCategory(err) > errcat.NoDaemonLogs {
 				if summarizeLogs(ctx, cmd) {
 					// If the user gets here, it might be an actual bug that they found, so
@@ -38,13 +38,13 @@ func Err(ctx context.Context) io.Writer {
 	return dos.Stderr(ctx)
 }
 
-// Info is similar to Out, but if formatted output is requested, the output will be discarded.
+// Info is similar to Out, but if formatted or quiet output is requested, the output will be discarded.
 //
 // Info is primarily intended for messages that are not directly related to the command that
 // executes, such as messages about starting up daemons or being connected to a context.
 func Info(ctx context.Context) io.Writer {
 	if cmd, ok := ctx.Value(key{}).(*cobra.Command); ok {
-		if _, ok := cmd.OutOrStdout().(*output); ok {
+		if _, ok := cmd.OutOrStdout().(*output); ok || WantsQuiet(cmd) {
 			return io.Discard
 		}
 		return cmd.OutOrStdout()
@@ -134,6 +134,10 @@ func Execute(cmd *cobra.Command) (*cobra.Command, bool, error) {
 		}
 		if err != nil {
 			response.Err = err.Error()
+			if code := errcat.GetCode(err); code != nil {
+				response.ErrCode = code.ID
+				response.ErrHelpURL = code.HelpURL
+			}
 		}
 		// don't print out the "zero" object
 		if response.hasCmdOnly() {
@@ -228,10 +232,12 @@ type (
 		originalStdout io.Writer
 	}
 	object struct {
-		Cmd    string `json:"cmd"`
-		Stdout any    `json:"stdout,omitempty"`
-		Stderr any    `json:"stderr,omitempty"`
-		Err    string `json:"err,omitempty"`
+		Cmd        string `json:"cmd"`
+		Stdout     any    `json:"stdout,omitempty"`
+		Stderr     any    `json:"stderr,omitempty"`
+		Err        string `json:"err,omitempty"`
+		ErrCode    string `json:"err_code,omitempty" yaml:"err_code,omitempty"`
+		ErrHelpURL string `json:"err_help_url,omitempty" yaml:"err_help_url,omitempty"`
 	}
 )
 
@@ -13,6 +13,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
 
@@ -103,6 +104,22 @@ func TestWithOutput(t *testing.T) {
 		require.Equal(t, expectedErr, m["err"], "did not get expected err, got: %s", m["err"])
 	})
 
+	t.Run("json output with coded error", func(t *testing.T) {
+		cmd, outBuf, _ := newCmdWithBufs()
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			return errcat.VersionMismatch.New("version mismatch")
+		}
+		cmd.SetArgs([]string{"--output=json"})
+		_, _, err := Execute(cmd)
+		require.Error(t, err)
+
+		stdout := outBuf.String()
+		m := map[string]string{}
+		require.NoError(t, json.Unmarshal([]byte(stdout), &m), "did not get json as stdout, got: %s", stdout)
+		require.Equal(t, "version mismatch", m["err"])
+		require.Equal(t, errcat.VersionMismatch.ID, m["err_code"])
+	})
+
 	t.Run("json output with native json", func(t *testing.T) {
 		expectedNativeJSONMap := map[string]float64{
 			"a": 1,
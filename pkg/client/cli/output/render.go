@@ -0,0 +1,174 @@
+package output
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// Color is a text attribute that a Renderer can apply to a string.
+type Color int
+
+const (
+	Plain = Color(iota)
+	Bold
+	Red
+	Green
+	Yellow
+	Cyan
+)
+
+var ansiCodes = [...]string{ //nolint:gochecknoglobals // constant
+	Plain:  "",
+	Bold:   "\x1b[1m",
+	Red:    "\x1b[31m",
+	Green:  "\x1b[32m",
+	Yellow: "\x1b[33m",
+	Cyan:   "\x1b[36m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// Renderer is an io.Writer that knows how human-readable output should be rendered. It decides if
+// colors should be used, aligns columns, and knows if the user asked for quiet output.
+//
+// Colors are used only when the writer is a terminal, the NO_COLOR environment variable is empty,
+// the global --no-color flag isn't set, and no formatted output (json, yaml) has been requested. This
+// guarantees that machine-readable output never contains escape sequences.
+type Renderer struct {
+	io.Writer
+	color bool
+	quiet bool
+}
+
+// NewRenderer returns a Renderer that writes to the given writer and that is configured using the
+// global flags of the command that is found in the given context.
+func NewRenderer(ctx context.Context, w io.Writer) *Renderer {
+	if r, ok := w.(*Renderer); ok {
+		return r
+	}
+	r := &Renderer{Writer: w}
+	cmd, ok := ctx.Value(key{}).(*cobra.Command)
+	if !ok {
+		r.color = colorWanted(w)
+		return r
+	}
+	if _, ok := w.(*output); ok || WantsFormatted(cmd) {
+		return r
+	}
+	r.quiet = WantsQuiet(cmd)
+	if nc, err := cmd.Flags().GetBool(global.FlagNoColor); err == nil && nc {
+		return r
+	}
+	r.color = colorWanted(w)
+	return r
+}
+
+// CmdRenderer returns a Renderer for the OutOrStdout of the given command.
+func CmdRenderer(cmd *cobra.Command) *Renderer {
+	return NewRenderer(cmd.Context(), cmd.OutOrStdout())
+}
+
+// WantsQuiet returns true if the global --quiet flag is set.
+func WantsQuiet(cmd *cobra.Command) bool {
+	q, err := cmd.Flags().GetBool(global.FlagQuiet)
+	return err == nil && q
+}
+
+func colorWanted(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if f, ok := w.(interface{ Fd() uintptr }); ok {
+		return term.IsTerminal(int(f.Fd()))
+	}
+	return false
+}
+
+// Quiet returns true if the user asked for minimal output.
+func (r *Renderer) Quiet() bool {
+	return r.quiet
+}
+
+// Colorize returns the string wrapped in the escape sequences needed to render it using the given
+// color, provided that colors are enabled. The string is returned verbatim otherwise.
+func (r *Renderer) Colorize(c Color, s string) string {
+	if !r.color || c == Plain || s == "" {
+		return s
+	}
+	return ansiCodes[c] + s + ansiReset
+}
+
+// Colorize calls Colorize on the given writer if it's a Renderer. The string is returned verbatim otherwise.
+func Colorize(w io.Writer, c Color, s string) string {
+	if r, ok := w.(*Renderer); ok {
+		return r.Colorize(c, s)
+	}
+	return s
+}
+
+// IsQuiet returns true if the given writer is a Renderer that has quiet output enabled.
+func IsQuiet(w io.Writer) bool {
+	if r, ok := w.(*Renderer); ok {
+		return r.quiet
+	}
+	return false
+}
+
+// Table writes the given rows so that all columns but the last one are left aligned and padded to
+// the width of the widest cell in that column. Columns are separated by sep, and each row is
+// terminated by a newline. The width computation ignores color escape sequences.
+func (r *Renderer) Table(sep string, rows [][]string) int {
+	var widths []int
+	for _, row := range rows {
+		for i := 0; i < len(row)-1; i++ {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := VisibleWidth(row[i]); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	n := 0
+	sb := strings.Builder{}
+	for _, row := range rows {
+		sb.Reset()
+		for i, cell := range row {
+			if i == len(row)-1 {
+				sb.WriteString(cell)
+				break
+			}
+			sb.WriteString(cell)
+			sb.WriteString(strings.Repeat(" ", widths[i]-VisibleWidth(cell)))
+			sb.WriteString(sep)
+		}
+		n += ioutil.Println(r, sb.String())
+	}
+	return n
+}
+
+// VisibleWidth returns the number of runes in the given string, ignoring color escape sequences.
+func VisibleWidth(s string) int {
+	w := 0
+	for len(s) > 0 {
+		if s[0] == '\x1b' {
+			if e := strings.IndexByte(s, 'm'); e > 0 {
+				s = s[e+1:]
+				continue
+			}
+		}
+		_, sz := utf8.DecodeRuneInString(s)
+		s = s[sz:]
+		w++
+	}
+	return w
+}
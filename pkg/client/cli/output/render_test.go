@@ -0,0 +1,74 @@
+package output
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
+)
+
+func TestRenderer_Table(t *testing.T) {
+	sb := &strings.Builder{}
+	r := &Renderer{Writer: sb, color: true}
+	r.Table(": ", [][]string{
+		{"a", r.Colorize(Green, "ready")},
+		{r.Colorize(Red, "longer"), "not ready"},
+		{"mid", "x\n  y"},
+	})
+	assert.Equal(t, "a     : \x1b[32mready\x1b[0m\n\x1b[31mlonger\x1b[0m: not ready\nmid   : x\n  y\n", sb.String())
+}
+
+func TestRenderer_Colorize(t *testing.T) {
+	r := &Renderer{Writer: &strings.Builder{}}
+	assert.Equal(t, "plain", r.Colorize(Red, "plain"))
+	assert.Equal(t, "plain", Colorize(&strings.Builder{}, Red, "plain"))
+	r.color = true
+	assert.Equal(t, "\x1b[31mred\x1b[0m", r.Colorize(Red, "red"))
+	assert.Equal(t, "\x1b[31mred\x1b[0m", Colorize(r, Red, "red"))
+	assert.Equal(t, 3, VisibleWidth(r.Colorize(Red, "red")))
+}
+
+func TestNewRenderer(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{
+			Use:  "testing",
+			RunE: func(*cobra.Command, []string) error { return nil },
+		}
+		cmd.SetContext(context.Background())
+		cmd.PersistentFlags().AddFlagSet(global.Flags(false))
+		cmd.SetOut(&strings.Builder{})
+		cmd.SetArgs(args)
+		return cmd
+	}
+
+	t.Run("quiet", func(t *testing.T) {
+		cmd := newCmd("--quiet")
+		var r *Renderer
+		cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+			r = CmdRenderer(cmd)
+			return nil
+		}
+		_, _, err := Execute(cmd)
+		require.NoError(t, err)
+		assert.True(t, r.Quiet())
+		assert.False(t, r.color)
+	})
+
+	t.Run("quiet ignored with formatted output", func(t *testing.T) {
+		cmd := newCmd("--quiet", "--output", "json")
+		var r *Renderer
+		cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+			r = CmdRenderer(cmd)
+			return nil
+		}
+		_, _, err := Execute(cmd)
+		require.NoError(t, err)
+		assert.False(t, r.Quiet())
+		assert.False(t, r.color)
+	})
+}
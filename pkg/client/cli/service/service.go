@@ -0,0 +1,95 @@
+// Package service installs the telepresence daemons as services that are managed by the service manager of the
+// operating system, so that connecting to a cluster never requires admin privileges after the installation.
+package service
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+// Config describes how the daemons are installed.
+type Config struct {
+	// Executable is the telepresence binary that the services run.
+	Executable string
+
+	// User is the name of the user that the services are installed for.
+	User string
+
+	// HomeDir is the home directory of User.
+	HomeDir string
+
+	// LogDir, ConfigDir, and CacheDir are the directories of User that the root daemon uses.
+	LogDir    string
+	ConfigDir string
+	CacheDir  string
+
+	// Linger makes the user daemon keep running when the user logs out.
+	Linger bool
+}
+
+// NewConfig returns the configuration that installs the daemons for the current user.
+func NewConfig(ctx context.Context, linger bool) (*Config, error) {
+	if proc.IsAdmin() {
+		return nil, errcat.User.New("the daemons must be installed by the user that connects, not by an administrator; admin privileges are requested when needed")
+	}
+	exe, err := filepath.EvalSymlinks(client.GetExe(ctx))
+	if err != nil {
+		return nil, err
+	}
+	u, err := user.Current()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Executable: exe,
+		User:       u.Username,
+		HomeDir:    u.HomeDir,
+		LogDir:     filelocation.AppUserLogDir(ctx),
+		ConfigDir:  filelocation.AppUserConfigDir(ctx),
+		CacheDir:   filelocation.AppUserCacheDir(ctx),
+		Linger:     linger,
+	}, nil
+}
+
+// rootDaemonArgs returns the command line that the service manager uses to start the root daemon.
+func (cfg *Config) rootDaemonArgs() []string {
+	return []string{cfg.Executable, "daemon-foreground", cfg.LogDir, cfg.ConfigDir, cfg.CacheDir}
+}
+
+// userDaemonArgs returns the command line that the service manager uses to start the user daemon.
+func (cfg *Config) userDaemonArgs() []string {
+	return []string{cfg.Executable, "connector-foreground"}
+}
+
+// Install installs the root and user daemons as services, and starts them or their sockets.
+func Install(ctx context.Context, cfg *Config) error {
+	// The log files must be created before the root daemon starts, so that they aren't owned by root.
+	if err := os.MkdirAll(cfg.LogDir, 0o700); err != nil {
+		return err
+	}
+	for _, name := range []string{"daemon.log", "connector.log"} {
+		fh, err := os.OpenFile(filepath.Join(cfg.LogDir, name), os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		_ = fh.Close()
+	}
+	return install(ctx, cfg)
+}
+
+// Uninstall stops the services of the root and user daemons and removes them.
+func Uninstall(ctx context.Context) error {
+	return uninstall(ctx)
+}
+
+// Installed returns true if the user daemon of the current user is installed as a service.
+func Installed(ctx context.Context) bool {
+	return installed(ctx)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+// launchd can only pass sockets to processes that use the launch_activate_socket C API, so instead of being
+// activated on demand, the daemons are started when they're loaded and restarted when they exit.
+const (
+	rootDaemonLabel = "io.telepresence.daemon"
+	userDaemonLabel = "io.telepresence.connector"
+
+	launchDaemonsDir = "/Library/LaunchDaemons"
+)
+
+func launchAgentsDir(ctx context.Context) string {
+	return filepath.Join(filelocation.UserHomeDir(ctx), "Library", "LaunchAgents")
+}
+
+// userDaemonPlist returns the path of the user daemon's property list, and true if it's a system wide
+// launch daemon. A launch agent is stopped when the user logs out, so a lingering user daemon is installed as a
+// launch daemon that runs as the user.
+func userDaemonPlist(ctx context.Context, linger bool) (string, bool) {
+	if linger {
+		return filepath.Join(launchDaemonsDir, userDaemonLabel+".plist"), true
+	}
+	return filepath.Join(launchAgentsDir(ctx), userDaemonLabel+".plist"), false
+}
+
+// plist returns a property list that makes launchd run the given command, and keep it running.
+func plist(label string, args []string, userName, homeDir string) string {
+	sb := strings.Builder{}
+	esc := func(s string) string {
+		eb := strings.Builder{}
+		_ = xml.EscapeText(&eb, []byte(s))
+		return eb.String()
+	}
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>`)
+	sb.WriteString(esc(label))
+	sb.WriteString("</string>\n  <key>ProgramArguments</key>\n  <array>\n")
+	for _, arg := range args {
+		sb.WriteString("    <string>")
+		sb.WriteString(esc(arg))
+		sb.WriteString("</string>\n")
+	}
+	sb.WriteString("  </array>\n")
+	if userName != "" {
+		sb.WriteString("  <key>UserName</key>\n  <string>")
+		sb.WriteString(esc(userName))
+		sb.WriteString("</string>\n  <key>EnvironmentVariables</key>\n  <dict>\n    <key>HOME</key>\n    <string>")
+		sb.WriteString(esc(homeDir))
+		sb.WriteString("</string>\n  </dict>\n")
+	}
+	sb.WriteString("  <key>RunAtLoad</key>\n  <true/>\n  <key>KeepAlive</key>\n  <true/>\n</dict>\n</plist>\n")
+	return sb.String()
+}
+
+// domain returns the launchd domain that a daemon is bootstrapped into.
+func domain(system bool) string {
+	if system {
+		return "system"
+	}
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+func install(ctx context.Context, cfg *Config) error {
+	rootPlist := filepath.Join(launchDaemonsDir, rootDaemonLabel+".plist")
+	if err := writeFile(ctx, rootPlist, []byte(plist(rootDaemonLabel, cfg.rootDaemonArgs(), "", "")), true); err != nil {
+		return err
+	}
+	if err := run(ctx, true, "launchctl", "bootstrap", domain(true), rootPlist); err != nil {
+		return err
+	}
+
+	userPlist, system := userDaemonPlist(ctx, cfg.Linger)
+	var userName, homeDir string
+	if system {
+		userName, homeDir = cfg.User, cfg.HomeDir
+	}
+	if err := writeFile(ctx, userPlist, []byte(plist(userDaemonLabel, cfg.userDaemonArgs(), userName, homeDir)), system); err != nil {
+		return err
+	}
+	return run(ctx, system, "launchctl", "bootstrap", domain(system), userPlist)
+}
+
+func uninstall(ctx context.Context) error {
+	for _, linger := range []bool{false, true} {
+		userPlist, system := userDaemonPlist(ctx, linger)
+		if fileExists(userPlist) {
+			// The service might not be loaded, so errors from bootout are ignored.
+			_ = run(ctx, system, "launchctl", "bootout", domain(system)+"/"+userDaemonLabel)
+			if _, err := removeFile(ctx, userPlist, system); err != nil {
+				return err
+			}
+		}
+	}
+	rootPlist := filepath.Join(launchDaemonsDir, rootDaemonLabel+".plist")
+	if fileExists(rootPlist) {
+		_ = run(ctx, true, "launchctl", "bootout", domain(true)+"/"+rootDaemonLabel)
+		if _, err := removeFile(ctx, rootPlist, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func installed(ctx context.Context) bool {
+	for _, linger := range []bool{false, true} {
+		if p, _ := userDaemonPlist(ctx, linger); fileExists(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+const (
+	rootDaemonUnit = "telepresence-daemon"
+	userDaemonUnit = "telepresence-connector"
+
+	systemUnitDir = "/etc/systemd/system"
+)
+
+// userUnitDir returns the directory where systemd looks for the units of the current user.
+func userUnitDir(ctx context.Context) string {
+	dir := dos.Getenv(ctx, "XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(filelocation.UserHomeDir(ctx), ".config")
+	}
+	return filepath.Join(dir, "systemd", "user")
+}
+
+// rootDaemonUnits returns the socket and service units of the root daemon, keyed by file name. The socket can
+// only be used by the user that the daemons are installed for.
+func rootDaemonUnits(ctx context.Context, cfg *Config) map[string]string {
+	return map[string]string{
+		rootDaemonUnit + ".socket": fmt.Sprintf(`[Unit]
+Description=Telepresence Root Daemon socket
+
+[Socket]
+ListenStream=%s
+SocketUser=%s
+SocketMode=0600
+RemoveOnStop=true
+
+[Install]
+WantedBy=sockets.target
+`, socket.RootDaemonPath(ctx), cfg.User),
+		rootDaemonUnit + ".service": fmt.Sprintf(`[Unit]
+Description=Telepresence Root Daemon
+Requires=%s.socket
+
+[Service]
+ExecStart=%s
+`, rootDaemonUnit, execLine(cfg.rootDaemonArgs())),
+	}
+}
+
+// userDaemonUnits returns the socket and service units of the user daemon, keyed by file name.
+func userDaemonUnits(ctx context.Context, cfg *Config) map[string]string {
+	return map[string]string{
+		userDaemonUnit + ".socket": fmt.Sprintf(`[Unit]
+Description=Telepresence User Daemon socket
+
+[Socket]
+ListenStream=%s
+SocketMode=0600
+RemoveOnStop=true
+
+[Install]
+WantedBy=sockets.target
+`, socket.UserDaemonPath(ctx)),
+		userDaemonUnit + ".service": fmt.Sprintf(`[Unit]
+Description=Telepresence User Daemon
+Requires=%s.socket
+
+[Service]
+ExecStart=%s
+`, userDaemonUnit, execLine(cfg.userDaemonArgs())),
+	}
+}
+
+// execLine quotes the given arguments for use in the ExecStart of a systemd unit.
+func execLine(args []string) string {
+	qs := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;$%") {
+			qs[i] = arg
+			continue
+		}
+		arg = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`).Replace(arg)
+		qs[i] = `"` + arg + `"`
+	}
+	return strings.Join(qs, " ")
+}
+
+func install(ctx context.Context, cfg *Config) error {
+	for name, unit := range rootDaemonUnits(ctx, cfg) {
+		if err := writeFile(ctx, filepath.Join(systemUnitDir, name), []byte(unit), true); err != nil {
+			return err
+		}
+	}
+	if err := run(ctx, true, "systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	if err := run(ctx, true, "systemctl", "enable", "--now", rootDaemonUnit+".socket"); err != nil {
+		return err
+	}
+
+	dir := userUnitDir(ctx)
+	for name, unit := range userDaemonUnits(ctx, cfg) {
+		if err := writeFile(ctx, filepath.Join(dir, name), []byte(unit), false); err != nil {
+			return err
+		}
+	}
+	if err := run(ctx, false, "systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	if err := run(ctx, false, "systemctl", "--user", "enable", "--now", userDaemonUnit+".socket"); err != nil {
+		return err
+	}
+	if cfg.Linger {
+		// The user's service manager, and with it the user daemon, is otherwise stopped when the user's last
+		// session ends.
+		return run(ctx, false, "loginctl", "enable-linger", cfg.User)
+	}
+	return nil
+}
+
+func uninstall(ctx context.Context) error {
+	dir := userUnitDir(ctx)
+	if fileExists(filepath.Join(dir, userDaemonUnit+".socket")) {
+		if err := run(ctx, false, "systemctl", "--user", "disable", "--now", userDaemonUnit+".socket", userDaemonUnit+".service"); err != nil {
+			return err
+		}
+	}
+	removed := false
+	for name := range userDaemonUnits(ctx, &Config{}) {
+		ok, err := removeFile(ctx, filepath.Join(dir, name), false)
+		if err != nil {
+			return err
+		}
+		removed = removed || ok
+	}
+	if removed {
+		if err := run(ctx, false, "systemctl", "--user", "daemon-reload"); err != nil {
+			return err
+		}
+	}
+
+	if fileExists(filepath.Join(systemUnitDir, rootDaemonUnit+".socket")) {
+		if err := run(ctx, true, "systemctl", "disable", "--now", rootDaemonUnit+".socket", rootDaemonUnit+".service"); err != nil {
+			return err
+		}
+	}
+	removed = false
+	for name := range rootDaemonUnits(ctx, &Config{}) {
+		ok, err := removeFile(ctx, filepath.Join(systemUnitDir, name), true)
+		if err != nil {
+			return err
+		}
+		removed = removed || ok
+	}
+	if removed {
+		return run(ctx, true, "systemctl", "daemon-reload")
+	}
+	return nil
+}
+
+func installed(ctx context.Context) bool {
+	return fileExists(filepath.Join(userUnitDir(ctx), userDaemonUnit+".socket"))
+}
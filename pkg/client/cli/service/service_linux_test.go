@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_execLine(t *testing.T) {
+	assert.Equal(t, `/usr/bin/telepresence connector-foreground`, execLine([]string{"/usr/bin/telepresence", "connector-foreground"}))
+	assert.Equal(t,
+		`/usr/bin/telepresence daemon-foreground "/home/a user/.cache" "100%%" "$$HOME" "\"quoted\"" ""`,
+		execLine([]string{"/usr/bin/telepresence", "daemon-foreground", "/home/a user/.cache", "100%", "$HOME", `"quoted"`, ""}))
+}
+
+func Test_rootDaemonUnits(t *testing.T) {
+	cfg := &Config{
+		Executable: "/usr/local/bin/telepresence",
+		User:       "alice",
+		LogDir:     "/home/alice/.cache/telepresence/logs",
+		ConfigDir:  "/home/alice/.config/telepresence",
+		CacheDir:   "/home/alice/.cache/telepresence",
+	}
+	units := rootDaemonUnits(context.Background(), cfg)
+	assert.Contains(t, units[rootDaemonUnit+".socket"], "\nListenStream=/var/run/telepresence-daemon.socket\nSocketUser=alice\nSocketMode=0600\n")
+	assert.Contains(t, units[rootDaemonUnit+".service"], "\nExecStart=/usr/local/bin/telepresence daemon-foreground"+
+		" /home/alice/.cache/telepresence/logs /home/alice/.config/telepresence /home/alice/.cache/telepresence\n")
+}
@@ -0,0 +1,75 @@
+//go:build !windows
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+	"github.com/telepresenceio/telepresence/v2/pkg/shellquote"
+)
+
+// run runs the given command, using sudo when asRoot is true. The output of a failed command is included in
+// the returned error.
+func run(ctx context.Context, asRoot bool, args ...string) error {
+	if asRoot && !proc.IsAdmin() {
+		if err := proc.CacheAdmin(ctx, "Need root privileges to manage the Telepresence Root Daemon system service"); err != nil {
+			return err
+		}
+		args = append([]string{"sudo", "--non-interactive"}, args...)
+	}
+	cmd := proc.CommandContext(ctx, args[0], args[1:]...)
+	cmd.DisableLogging = true
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", shellquote.ShellString(args[0], args[1:]), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// writeFile writes the given file, using sudo when asRoot is true.
+func writeFile(ctx context.Context, path string, data []byte, asRoot bool) error {
+	if !asRoot {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0o644)
+	}
+	tmp, err := os.CreateTemp("", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	return run(ctx, true, "install", "-m", "0644", tmp.Name(), path)
+}
+
+// removeFile removes the given file, using sudo when asRoot is true. It returns false if the file didn't exist.
+func removeFile(ctx context.Context, path string, asRoot bool) (bool, error) {
+	if !fileExists(path) {
+		return false, nil
+	}
+	if asRoot {
+		return true, run(ctx, true, "rm", "-f", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return true, err
+	}
+	return true, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
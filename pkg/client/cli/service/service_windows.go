@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func install(context.Context, *Config) error {
+	return errcat.User.New("installing the daemons as services is not supported on Windows")
+}
+
+func uninstall(context.Context) error {
+	return errcat.User.New("installing the daemons as services is not supported on Windows")
+}
+
+func installed(context.Context) bool {
+	return false
+}
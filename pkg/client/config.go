@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,6 +25,8 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 const ConfigFile = "config.yml"
@@ -34,6 +37,10 @@ type Config interface {
 	Base() *BaseConfig
 	Timeouts() *Timeouts
 	LogLevels() *LogLevels
+	LogRotation() *LogRotation
+	LogFormat() string
+	Profiling() *Profiling
+	Tracing() *Tracing
 	Images() *Images
 	Grpc() *Grpc
 	TelepresenceAPI() *TelepresenceAPI
@@ -47,6 +54,10 @@ type BaseConfig struct {
 	OSSpecificConfig `yaml:",inline"`
 	TimeoutsV        Timeouts        `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
 	LogLevelsV       LogLevels       `json:"logLevels,omitempty" yaml:"logLevels,omitempty"`
+	LogRotationV     LogRotation     `json:"logRotation,omitempty" yaml:"logRotation,omitempty"`
+	LogFormatV       string          `json:"logFormat,omitempty" yaml:"logFormat,omitempty"`
+	ProfilingV       Profiling       `json:"profiling,omitempty" yaml:"profiling,omitempty"`
+	TracingV         Tracing         `json:"tracing,omitempty" yaml:"tracing,omitempty"`
 	ImagesV          Images          `json:"images,omitempty" yaml:"images,omitempty"`
 	GrpcV            Grpc            `json:"grpc,omitempty" yaml:"grpc,omitempty"`
 	TelepresenceAPIV TelepresenceAPI `json:"telepresenceAPI,omitempty" yaml:"telepresenceAPI,omitempty"`
@@ -70,6 +81,24 @@ func (c *BaseConfig) LogLevels() *LogLevels {
 	return &c.LogLevelsV
 }
 
+func (c *BaseConfig) LogRotation() *LogRotation {
+	return &c.LogRotationV
+}
+
+// LogFormat returns the format of the log files of the CLI and the daemons, "text" or "json". An empty
+// string means "text".
+func (c *BaseConfig) LogFormat() string {
+	return c.LogFormatV
+}
+
+func (c *BaseConfig) Profiling() *Profiling {
+	return &c.ProfilingV
+}
+
+func (c *BaseConfig) Tracing() *Tracing {
+	return &c.TracingV
+}
+
 func (c *BaseConfig) Images() *Images {
 	return &c.ImagesV
 }
@@ -103,6 +132,12 @@ func (c *BaseConfig) Merge(lc Config) {
 	c.OSSpecificConfig.Merge(lc.OSSpecific())
 	c.TimeoutsV.merge(lc.Timeouts())
 	c.LogLevelsV.merge(lc.LogLevels())
+	c.LogRotationV.merge(lc.LogRotation())
+	if lf := lc.LogFormat(); lf != "" {
+		c.LogFormatV = lf
+	}
+	c.ProfilingV.merge(lc.Profiling())
+	c.TracingV.merge(lc.Tracing())
 	c.ImagesV.merge(lc.Images())
 	c.GrpcV.merge(lc.Grpc())
 	c.TelepresenceAPIV.merge(lc.TelepresenceAPI())
@@ -382,25 +417,35 @@ func (t *Timeouts) UnmarshalYAML(node *yaml.Node) (err error) {
 			continue
 		}
 
-		v := ms[i+1]
-		var vv any
-		if err = v.Decode(&vv); err != nil {
-			return errors.New(WithLoc("unable to parse value", v))
-		}
-		switch vv := vv.(type) {
-		case int:
-			*dp = time.Duration(vv) * time.Second
-		case float64:
-			*dp = time.Duration(vv * float64(time.Second))
-		case string:
-			if *dp, err = time.ParseDuration(vv); err != nil {
-				return errors.New(WithLoc(fmt.Sprintf("%q is not a valid duration", vv), v))
-			}
+		if *dp, err = decodeDuration(ms[i+1]); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// decodeDuration decodes a duration that is either a number of seconds, or a string that can be parsed
+// using time.ParseDuration.
+func decodeDuration(v *yaml.Node) (time.Duration, error) {
+	var vv any
+	if err := v.Decode(&vv); err != nil {
+		return 0, errors.New(WithLoc("unable to parse value", v))
+	}
+	switch vv := vv.(type) {
+	case int:
+		return time.Duration(vv) * time.Second, nil
+	case float64:
+		return time.Duration(vv * float64(time.Second)), nil
+	case string:
+		d, err := time.ParseDuration(vv)
+		if err != nil {
+			return 0, errors.New(WithLoc(fmt.Sprintf("%q is not a valid duration", vv), v))
+		}
+		return d, nil
+	}
+	return 0, nil
+}
+
 const (
 	defaultTimeoutsClusterConnect        = 20 * time.Second
 	defaultTimeoutsConnectivityCheck     = 500 * time.Millisecond
@@ -570,6 +615,363 @@ func (ll *LogLevels) merge(o *LogLevels) {
 	}
 }
 
+// LogRotation controls the rotation of the log files of the CLI and the daemons. A log file is rotated when
+// it would exceed the max size, and when it's written on a day other than the day it was created.
+type LogRotation struct {
+	// MaxSizeV is the max size in bytes of a log file. Zero means no limit.
+	MaxSizeV int64 `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+
+	// MaxAgeV is the max age of a rotated log file. Zero means no limit.
+	MaxAgeV time.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+
+	// MaxBackupsV is the max number of rotated log files that are retained for each log. Zero means no limit.
+	MaxBackupsV int `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"`
+
+	// CompressV controls whether rotated log files are compressed using gzip.
+	CompressV bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+}
+
+const (
+	defaultLogRotationMaxSize    = 100 * 1024 * 1024
+	defaultLogRotationMaxAge     = 0
+	defaultLogRotationMaxBackups = 4
+	defaultLogRotationCompress   = false
+)
+
+var defaultLogRotation = LogRotation{ //nolint:gochecknoglobals // constant
+	MaxSizeV:    defaultLogRotationMaxSize,
+	MaxAgeV:     defaultLogRotationMaxAge,
+	MaxBackupsV: defaultLogRotationMaxBackups,
+	CompressV:   defaultLogRotationCompress,
+}
+
+func (lr *LogRotation) MaxSize() int64 {
+	return lr.MaxSizeV
+}
+
+func (lr *LogRotation) MaxAge() time.Duration {
+	return lr.MaxAgeV
+}
+
+func (lr *LogRotation) MaxBackups() int {
+	return lr.MaxBackupsV
+}
+
+func (lr *LogRotation) Compress() bool {
+	return lr.CompressV
+}
+
+// IsZero controls whether this element will be included in marshalled output.
+func (lr LogRotation) IsZero() bool {
+	return lr == defaultLogRotation
+}
+
+// UnmarshalYAML parses the logRotation YAML.
+func (lr *LogRotation) UnmarshalYAML(node *yaml.Node) (err error) {
+	if node.Kind != yaml.MappingNode {
+		return errors.New(WithLoc("logRotation must be an object", node))
+	}
+
+	*lr = defaultLogRotation
+	ms := node.Content
+	top := len(ms)
+	for i := 0; i < top; i += 2 {
+		kv, err := StringKey(ms[i])
+		if err != nil {
+			return err
+		}
+		v := ms[i+1]
+		switch kv {
+		case "maxSize":
+			q, err := resource.ParseQuantity(v.Value)
+			if err != nil || q.Sign() < 0 {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid logRotation.maxSize %q", v.Value), v))
+			} else {
+				lr.MaxSizeV = q.Value()
+			}
+		case "maxAge":
+			d, err := time.ParseDuration(v.Value)
+			if err != nil || d < 0 {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid logRotation.maxAge %q", v.Value), v))
+			} else {
+				lr.MaxAgeV = d
+			}
+		case "maxBackups":
+			n, err := strconv.Atoi(v.Value)
+			if err != nil || n < 0 {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid logRotation.maxBackups %q", v.Value), v))
+			} else {
+				lr.MaxBackupsV = n
+			}
+		case "compress":
+			b, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid logRotation.compress %q", v.Value), v))
+			} else {
+				lr.CompressV = b
+			}
+		default:
+			logrus.Warn(WithLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
+		}
+	}
+	return nil
+}
+
+// MarshalYAML is not using pointer receiver here, because LogRotation is not pointer in the Config struct.
+func (lr LogRotation) MarshalYAML() (any, error) {
+	m := make(map[string]any)
+	if lr.MaxSizeV != defaultLogRotationMaxSize {
+		m["maxSize"] = resource.NewQuantity(lr.MaxSizeV, resource.BinarySI).String()
+	}
+	if lr.MaxAgeV != defaultLogRotationMaxAge {
+		m["maxAge"] = lr.MaxAgeV.String()
+	}
+	if lr.MaxBackupsV != defaultLogRotationMaxBackups {
+		m["maxBackups"] = lr.MaxBackupsV
+	}
+	if lr.CompressV != defaultLogRotationCompress {
+		m["compress"] = lr.CompressV
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func (lr *LogRotation) merge(o *LogRotation) {
+	if o.MaxSizeV != defaultLogRotationMaxSize {
+		lr.MaxSizeV = o.MaxSizeV
+	}
+	if o.MaxAgeV != defaultLogRotationMaxAge {
+		lr.MaxAgeV = o.MaxAgeV
+	}
+	if o.MaxBackupsV != defaultLogRotationMaxBackups {
+		lr.MaxBackupsV = o.MaxBackupsV
+	}
+	if o.CompressV != defaultLogRotationCompress {
+		lr.CompressV = o.CompressV
+	}
+}
+
+// Profiling controls the debug HTTP listeners of the user and root daemons. A listener serves pprof profiles,
+// goroutine dumps, and runtime metrics on localhost. It is normally started on demand using
+// "telepresence loglevel --profile".
+type Profiling struct {
+	// EnabledV controls whether the daemons start their debug HTTP listeners when they start.
+	EnabledV bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// UserDaemonPortV is the port of the user daemon's debug HTTP listener. Zero means a random port.
+	UserDaemonPortV uint16 `json:"userDaemonPort,omitempty" yaml:"userDaemonPort,omitempty"`
+
+	// RootDaemonPortV is the port of the root daemon's debug HTTP listener. Zero means a random port.
+	RootDaemonPortV uint16 `json:"rootDaemonPort,omitempty" yaml:"rootDaemonPort,omitempty"`
+}
+
+func (p *Profiling) Enabled() bool {
+	return p.EnabledV
+}
+
+func (p *Profiling) UserDaemonPort() uint16 {
+	return p.UserDaemonPortV
+}
+
+func (p *Profiling) RootDaemonPort() uint16 {
+	return p.RootDaemonPortV
+}
+
+// IsZero controls whether this element will be included in marshalled output.
+func (p Profiling) IsZero() bool {
+	return p == Profiling{}
+}
+
+// UnmarshalYAML parses the profiling YAML.
+func (p *Profiling) UnmarshalYAML(node *yaml.Node) (err error) {
+	if node.Kind != yaml.MappingNode {
+		return errors.New(WithLoc("profiling must be an object", node))
+	}
+
+	*p = Profiling{}
+	ms := node.Content
+	top := len(ms)
+	for i := 0; i < top; i += 2 {
+		kv, err := StringKey(ms[i])
+		if err != nil {
+			return err
+		}
+		v := ms[i+1]
+		switch kv {
+		case "enabled":
+			b, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid profiling.enabled %q", v.Value), v))
+			} else {
+				p.EnabledV = b
+			}
+		case "userDaemonPort", "rootDaemonPort":
+			n, err := strconv.ParseUint(v.Value, 10, 16)
+			if err != nil {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid profiling.%s %q", kv, v.Value), v))
+			} else if kv == "userDaemonPort" {
+				p.UserDaemonPortV = uint16(n)
+			} else {
+				p.RootDaemonPortV = uint16(n)
+			}
+		default:
+			logrus.Warn(WithLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
+		}
+	}
+	return nil
+}
+
+func (p *Profiling) merge(o *Profiling) {
+	if o.EnabledV {
+		p.EnabledV = o.EnabledV
+	}
+	if o.UserDaemonPortV != 0 {
+		p.UserDaemonPortV = o.UserDaemonPortV
+	}
+	if o.RootDaemonPortV != 0 {
+		p.RootDaemonPortV = o.RootDaemonPortV
+	}
+}
+
+// Tracing controls the spans that the user and root daemons record. The spans are always collected for
+// retrieval using "telepresence gather-traces", and they are also exported to an OTLP collector when an
+// endpoint is configured.
+type Tracing struct {
+	// EndpointV is the address of the OTLP collector that accepts spans using gRPC, either as host:port or
+	// as a URL.
+	EndpointV string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+
+	// InsecureV disables transport security for the connection to the collector.
+	InsecureV bool `json:"insecure,omitempty" yaml:"insecure,omitempty"`
+
+	// HeadersV are sent to the collector with each export request.
+	HeadersV map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// SamplingRatioV is the ratio of the traces that are exported to the collector, between 0 and 1.
+	SamplingRatioV float64 `json:"samplingRatio,omitempty" yaml:"samplingRatio,omitempty"`
+}
+
+const defaultTracingSamplingRatio = 1.0
+
+var defaultTracing = Tracing{ //nolint:gochecknoglobals // constant
+	SamplingRatioV: defaultTracingSamplingRatio,
+}
+
+func (t *Tracing) Endpoint() string {
+	return t.EndpointV
+}
+
+func (t *Tracing) Insecure() bool {
+	return t.InsecureV
+}
+
+func (t *Tracing) Headers() map[string]string {
+	return t.HeadersV
+}
+
+func (t *Tracing) SamplingRatio() float64 {
+	return t.SamplingRatioV
+}
+
+// Exporter returns the configuration that makes a tracing.TraceServer export its spans.
+func (t *Tracing) Exporter() *tracing.Exporter {
+	return &tracing.Exporter{
+		Endpoint:      t.EndpointV,
+		Insecure:      t.InsecureV,
+		Headers:       t.HeadersV,
+		SamplingRatio: t.SamplingRatioV,
+	}
+}
+
+// IsZero controls whether this element will be included in marshalled output.
+func (t Tracing) IsZero() bool {
+	return t.EndpointV == "" && !t.InsecureV && len(t.HeadersV) == 0 && t.SamplingRatioV == defaultTracingSamplingRatio
+}
+
+// UnmarshalYAML parses the tracing YAML.
+func (t *Tracing) UnmarshalYAML(node *yaml.Node) (err error) {
+	if node.Kind != yaml.MappingNode {
+		return errors.New(WithLoc("tracing must be an object", node))
+	}
+
+	*t = defaultTracing
+	ms := node.Content
+	top := len(ms)
+	for i := 0; i < top; i += 2 {
+		kv, err := StringKey(ms[i])
+		if err != nil {
+			return err
+		}
+		v := ms[i+1]
+		switch kv {
+		case "endpoint":
+			t.EndpointV = v.Value
+		case "insecure":
+			b, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid tracing.insecure %q", v.Value), v))
+			} else {
+				t.InsecureV = b
+			}
+		case "headers":
+			var hs map[string]string
+			if err := v.Decode(&hs); err != nil {
+				logrus.Warn(WithLoc("tracing.headers must be an object with string values", v))
+			} else {
+				t.HeadersV = hs
+			}
+		case "samplingRatio":
+			r, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil || r < 0 || r > 1 {
+				logrus.Warn(WithLoc(fmt.Sprintf("invalid tracing.samplingRatio %q, must be between 0 and 1", v.Value), v))
+			} else {
+				t.SamplingRatioV = r
+			}
+		default:
+			logrus.Warn(WithLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
+		}
+	}
+	return nil
+}
+
+// MarshalYAML is not using pointer receiver here, because Tracing is not pointer in the Config struct.
+func (t Tracing) MarshalYAML() (any, error) {
+	m := make(map[string]any)
+	if t.EndpointV != "" {
+		m["endpoint"] = t.EndpointV
+	}
+	if t.InsecureV {
+		m["insecure"] = true
+	}
+	if len(t.HeadersV) > 0 {
+		m["headers"] = t.HeadersV
+	}
+	if t.SamplingRatioV != defaultTracingSamplingRatio {
+		m["samplingRatio"] = t.SamplingRatioV
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func (t *Tracing) merge(o *Tracing) {
+	if o.EndpointV != "" {
+		t.EndpointV = o.EndpointV
+	}
+	if o.InsecureV {
+		t.InsecureV = o.InsecureV
+	}
+	if len(o.HeadersV) > 0 {
+		t.HeadersV = o.HeadersV
+	}
+	if o.SamplingRatioV != defaultTracingSamplingRatio {
+		t.SamplingRatioV = o.SamplingRatioV
+	}
+}
+
 type Images struct {
 	PrivateRegistry        string `json:"registry,omitempty" yaml:"registry,omitempty"`
 	PrivateAgentImage      string `json:"agentImage,omitempty" yaml:"agentImage,omitempty"`
@@ -689,6 +1091,14 @@ type Grpc struct {
 	// MaxReceiveSize is the maximum message size in bytes the client can receive in a gRPC call or stream message.
 	// Overrides the gRPC default of 4MB.
 	MaxReceiveSizeV resource.Quantity `json:"maxReceiveSize,omitempty" yaml:"maxReceiveSize,omitempty"`
+
+	// TunnelCompressionV is the compression that the client offers for the payload of its tunnels.
+	// One of "none", "snappy", or "zstd".
+	TunnelCompressionV tunnel.Compression `json:"tunnelCompression,omitempty" yaml:"tunnelCompression,omitempty"`
+
+	// TunnelIdleV controls the idle timeout, keep-alive interval, and dead peer detection of the client's
+	// TCP and UDP tunnels. Zero values mean that the defaults are used.
+	TunnelIdleV tunnel.IdleConfigs `json:"tunnelIdle,omitempty" yaml:"tunnelIdle,omitempty"`
 }
 
 func (g *Grpc) MaxReceiveSize() int64 {
@@ -700,10 +1110,23 @@ func (g *Grpc) MaxReceiveSize() int64 {
 	return 0
 }
 
+func (g *Grpc) TunnelCompression() tunnel.Compression {
+	return g.TunnelCompressionV
+}
+
+func (g *Grpc) TunnelIdle() tunnel.IdleConfigs {
+	return g.TunnelIdleV
+}
+
 func (g *Grpc) merge(o *Grpc) {
 	if !o.MaxReceiveSizeV.IsZero() {
 		g.MaxReceiveSizeV = o.MaxReceiveSizeV
 	}
+	if o.TunnelCompressionV != tunnel.NoCompression {
+		g.TunnelCompressionV = o.TunnelCompressionV
+	}
+	mergeIdleConfig(&g.TunnelIdleV.TCP, &o.TunnelIdleV.TCP)
+	mergeIdleConfig(&g.TunnelIdleV.UDP, &o.TunnelIdleV.UDP)
 }
 
 // UnmarshalYAML parses the images YAML.
@@ -728,6 +1151,17 @@ func (g *Grpc) UnmarshalYAML(node *yaml.Node) (err error) {
 			} else {
 				g.MaxReceiveSizeV = val
 			}
+		case "tunnelCompression":
+			c, err := tunnel.ParseCompression(v.Value)
+			if err != nil {
+				logrus.Warn(WithLoc(err.Error(), ms[i]))
+			} else {
+				g.TunnelCompressionV = c
+			}
+		case "tunnelIdle":
+			if err = unmarshalIdleConfigs(v, &g.TunnelIdleV); err != nil {
+				return err
+			}
 		default:
 			logrus.Warn(WithLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
 		}
@@ -737,17 +1171,117 @@ func (g *Grpc) UnmarshalYAML(node *yaml.Node) (err error) {
 
 // IsZero controls whether this element will be included in marshalled output.
 func (g Grpc) IsZero() bool {
-	return g.MaxReceiveSizeV.IsZero()
+	return g.MaxReceiveSizeV.IsZero() && g.TunnelCompressionV == tunnel.NoCompression && g.TunnelIdleV == tunnel.IdleConfigs{}
 }
 
 // MarshalYAML is not using pointer receiver here, because Cloud is not pointer in the Config struct.
 func (g Grpc) MarshalYAML() (any, error) {
+	m := make(map[string]any)
 	if !g.MaxReceiveSizeV.IsZero() {
-		return map[string]any{
-			"maxReceiveSize": g.MaxReceiveSizeV.String(),
-		}, nil
+		m["maxReceiveSize"] = g.MaxReceiveSizeV.String()
+	}
+	if g.TunnelCompressionV != tunnel.NoCompression {
+		m["tunnelCompression"] = g.TunnelCompressionV.String()
+	}
+	if g.TunnelIdleV != (tunnel.IdleConfigs{}) {
+		im := make(map[string]any)
+		if tm := marshalIdleConfig(&g.TunnelIdleV.TCP); len(tm) > 0 {
+			im["tcp"] = tm
+		}
+		if um := marshalIdleConfig(&g.TunnelIdleV.UDP); len(um) > 0 {
+			im["udp"] = um
+		}
+		m["tunnelIdle"] = im
+	}
+	if len(m) == 0 {
+		return nil, nil
 	}
-	return nil, nil
+	return m, nil
+}
+
+// unmarshalIdleConfigs parses the "tcp" and "udp" objects of the grpc.tunnelIdle YAML.
+func unmarshalIdleConfigs(node *yaml.Node, ics *tunnel.IdleConfigs) error {
+	if node.Kind != yaml.MappingNode {
+		return errors.New(WithLoc("grpc.tunnelIdle must be an object", node))
+	}
+	ms := node.Content
+	top := len(ms)
+	for i := 0; i < top; i += 2 {
+		kv, err := StringKey(ms[i])
+		if err != nil {
+			return err
+		}
+		var ic *tunnel.IdleConfig
+		switch kv {
+		case "tcp":
+			ic = &ics.TCP
+		case "udp":
+			ic = &ics.UDP
+		default:
+			logrus.Warn(WithLoc(fmt.Sprintf(`unknown key "grpc.tunnelIdle.%s"`, kv), ms[i]))
+			continue
+		}
+		if err = unmarshalIdleConfig(ms[i+1], kv, ic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalIdleConfig(node *yaml.Node, proto string, ic *tunnel.IdleConfig) error {
+	if node.Kind != yaml.MappingNode {
+		return errors.New(WithLoc(fmt.Sprintf("grpc.tunnelIdle.%s must be an object", proto), node))
+	}
+	ms := node.Content
+	top := len(ms)
+	for i := 0; i < top; i += 2 {
+		kv, err := StringKey(ms[i])
+		if err != nil {
+			return err
+		}
+		var dp *time.Duration
+		switch kv {
+		case "timeout":
+			dp = &ic.Timeout
+		case "keepAliveInterval":
+			dp = &ic.KeepAliveInterval
+		case "deadPeerTimeout":
+			dp = &ic.DeadPeerTimeout
+		default:
+			logrus.Warn(WithLoc(fmt.Sprintf(`unknown key "grpc.tunnelIdle.%s.%s"`, proto, kv), ms[i]))
+			continue
+		}
+		if *dp, err = decodeDuration(ms[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeIdleConfig(ic, o *tunnel.IdleConfig) {
+	if o.Timeout != 0 {
+		ic.Timeout = o.Timeout
+	}
+	if o.KeepAliveInterval != 0 {
+		ic.KeepAliveInterval = o.KeepAliveInterval
+	}
+	if o.DeadPeerTimeout != 0 {
+		ic.DeadPeerTimeout = o.DeadPeerTimeout
+	}
+}
+
+func marshalIdleConfig(ic *tunnel.IdleConfig) map[string]string {
+	m := make(map[string]string)
+	if ic.Timeout != 0 {
+		m["timeout"] = ic.Timeout.String()
+	}
+	if ic.KeepAliveInterval != 0 {
+		m["keepAliveInterval"] = ic.KeepAliveInterval.String()
+	}
+	if ic.DeadPeerTimeout != 0 {
+		m["deadPeerTimeout"] = ic.DeadPeerTimeout.String()
+	}
+	return m
 }
 
 type TelepresenceAPI struct {
@@ -835,6 +1369,17 @@ type Cluster struct {
 	ConnectFromRootDaemon   bool     `json:"connectFromRootDaemon,omitempty" yaml:"connectFromRootDaemon,omitempty"`
 	AgentPortForward        bool     `json:"agentPortForward,omitempty" yaml:"agentPortForward,omitempty"`
 	VirtualIPSubnet         string   `json:"virtualIPSubnet,omitempty" yaml:"virtualIPSubnet,omitempty"`
+	ProxyExternalNames      bool     `json:"proxyExternalNames,omitempty" yaml:"proxyExternalNames,omitempty"`
+	RemapConflictingSubnets bool     `json:"remapConflictingSubnets,omitempty" yaml:"remapConflictingSubnets,omitempty"`
+
+	// QUIC makes the root daemon open the tunnels to the traffic-manager as streams of a QUIC connection
+	// instead of gRPC calls, provided that the traffic-manager has QUIC enabled. The gRPC tunnels are used
+	// when the QUIC connection can't be established, or once it fails.
+	QUIC bool `json:"quic,omitempty" yaml:"quic,omitempty"`
+
+	// WireGuard makes the root daemon send the traffic destined for the traffic-manager through a WireGuard
+	// tunnel instead of the gRPC tunnel, provided that the traffic-manager has WireGuard enabled.
+	WireGuard bool `json:"wireGuard,omitempty" yaml:"wireGuard,omitempty"`
 }
 
 // This is used by a different config -- the k8s_config, which needs to be able to tell if it's overridden at a cluster or environment variable level.
@@ -846,6 +1391,7 @@ var defaultCluster = Cluster{ //nolint:gochecknoglobals // constant
 	ConnectFromRootDaemon:   true,
 	AgentPortForward:        true,
 	VirtualIPSubnet:         defaultVirtualIPSubnet,
+	ProxyExternalNames:      true,
 }
 
 func (cc *Cluster) merge(o *Cluster) {
@@ -864,6 +1410,18 @@ func (cc *Cluster) merge(o *Cluster) {
 	if o.VirtualIPSubnet != defaultVirtualIPSubnet {
 		cc.VirtualIPSubnet = o.VirtualIPSubnet
 	}
+	if !o.ProxyExternalNames {
+		cc.ProxyExternalNames = false
+	}
+	if o.RemapConflictingSubnets {
+		cc.RemapConflictingSubnets = true
+	}
+	if o.QUIC {
+		cc.QUIC = true
+	}
+	if o.WireGuard {
+		cc.WireGuard = true
+	}
 }
 
 // IsZero controls whether this element will be included in marshalled output.
@@ -872,7 +1430,11 @@ func (cc Cluster) IsZero() bool {
 		len(cc.MappedNamespaces) == 0 &&
 		cc.ConnectFromRootDaemon &&
 		cc.AgentPortForward &&
-		cc.VirtualIPSubnet == defaultVirtualIPSubnet
+		cc.VirtualIPSubnet == defaultVirtualIPSubnet &&
+		cc.ProxyExternalNames &&
+		!cc.RemapConflictingSubnets &&
+		!cc.QUIC &&
+		!cc.WireGuard
 }
 
 // MarshalYAML is not using pointer receiver here, because Cluster is not pointer in the Config struct.
@@ -893,6 +1455,18 @@ func (cc Cluster) MarshalYAML() (any, error) {
 	if cc.VirtualIPSubnet != defaultVirtualIPSubnet {
 		cm["virtualIPSubnet"] = cc.VirtualIPSubnet
 	}
+	if !cc.ProxyExternalNames {
+		cm["proxyExternalNames"] = false
+	}
+	if cc.RemapConflictingSubnets {
+		cm["remapConflictingSubnets"] = true
+	}
+	if cc.QUIC {
+		cm["quic"] = true
+	}
+	if cc.WireGuard {
+		cm["wireGuard"] = true
+	}
 	return cm, nil
 }
 
@@ -957,6 +1531,9 @@ func GetDefaultBaseConfig() BaseConfig {
 		OSSpecificConfig: GetDefaultOSSpecificConfig(),
 		TimeoutsV:        defaultTimeouts,
 		LogLevelsV:       defaultLogLevels,
+		LogRotationV:     defaultLogRotation,
+		ProfilingV:       Profiling{},
+		TracingV:         defaultTracing,
 		ImagesV:          defaultImages,
 		GrpcV:            Grpc{},
 		TelepresenceAPIV: TelepresenceAPI{},
@@ -1022,6 +1599,10 @@ type Routing struct {
 	AlsoProxy        []*iputil.Subnet `json:"alsoProxy,omitempty" yaml:"alsoProxy,omitempty"`
 	NeverProxy       []*iputil.Subnet `json:"neverProxy,omitempty" yaml:"neverProxy,omitempty"`
 	AllowConflicting []*iputil.Subnet `json:"allowConflicting,omitempty" yaml:"allowConflicting,omitempty"`
+
+	// ExcludePorts are destination ports, on the form <port>[/<protocol>], that are never routed to
+	// the cluster, even when the destination IP is in a proxied subnet.
+	ExcludePorts []string `json:"proxyExcludePorts,omitempty" yaml:"proxyExcludePorts,omitempty"`
 }
 
 // RoutingSnake is the same as Routing but with snake_case json/yaml names.
@@ -1030,6 +1611,7 @@ type RoutingSnake struct {
 	AlsoProxy        []*iputil.Subnet `json:"also_proxy_subnets,omitempty" yaml:"also_proxy_subnets,omitempty"`
 	NeverProxy       []*iputil.Subnet `json:"never_proxy_subnets,omitempty" yaml:"never_proxy_subnets,omitempty"`
 	AllowConflicting []*iputil.Subnet `json:"allow_conflicting_subnets,omitempty" yaml:"allow_conflicting_subnets,omitempty"`
+	ExcludePorts     []string         `json:"proxy_exclude_ports,omitempty" yaml:"proxy_exclude_ports,omitempty"`
 }
 
 type DNS struct {
@@ -1041,18 +1623,28 @@ type DNS struct {
 	Excludes        []string      `json:"excludes,omitempty" yaml:"excludes,omitempty"`
 	Mappings        DNSMappings   `json:"mappings,omitempty" yaml:"mappings,omitempty"`
 	LookupTimeout   time.Duration `json:"lookupTimeout,omitempty" yaml:"lookupTimeout,omitempty"`
+
+	// Overrides maps hostnames to IP addresses that the DNS resolver answers with locally, without
+	// consulting the cluster.
+	Overrides map[string]string `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+
+	// NegativeCacheTTL is the time that a lookup that didn't find the name is cached. Zero means that
+	// such lookups aren't cached.
+	NegativeCacheTTL time.Duration `json:"negativeCacheTTL,omitempty" yaml:"negativeCacheTTL,omitempty"`
 }
 
 // DNSSnake is the same as DNS but with snake_case json/yaml names.
 type DNSSnake struct {
-	Error           string        `json:"error,omitempty" yaml:"error,omitempty"`
-	LocalIP         net.IP        `json:"local_ip,omitempty" yaml:"local_ip,omitempty"`
-	RemoteIP        net.IP        `json:"remote_ip,omitempty" yaml:"remote_ip,omitempty"`
-	IncludeSuffixes []string      `json:"include_suffixes,omitempty" yaml:"include_suffixes,omitempty"`
-	ExcludeSuffixes []string      `json:"exclude_suffixes,omitempty" yaml:"exclude_suffixes,omitempty"`
-	Excludes        []string      `json:"excludes,omitempty" yaml:"excludes,omitempty"`
-	Mappings        DNSMappings   `json:"mappings,omitempty" yaml:"mappings,omitempty"`
-	LookupTimeout   time.Duration `json:"lookup_timeout,omitempty" yaml:"lookup_timeout,omitempty"`
+	Error            string            `json:"error,omitempty" yaml:"error,omitempty"`
+	LocalIP          net.IP            `json:"local_ip,omitempty" yaml:"local_ip,omitempty"`
+	RemoteIP         net.IP            `json:"remote_ip,omitempty" yaml:"remote_ip,omitempty"`
+	IncludeSuffixes  []string          `json:"include_suffixes,omitempty" yaml:"include_suffixes,omitempty"`
+	ExcludeSuffixes  []string          `json:"exclude_suffixes,omitempty" yaml:"exclude_suffixes,omitempty"`
+	Excludes         []string          `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+	Mappings         DNSMappings       `json:"mappings,omitempty" yaml:"mappings,omitempty"`
+	LookupTimeout    time.Duration     `json:"lookup_timeout,omitempty" yaml:"lookup_timeout,omitempty"`
+	Overrides        map[string]string `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+	NegativeCacheTTL time.Duration     `json:"negative_cache_ttl,omitempty" yaml:"negative_cache_ttl,omitempty"`
 }
 
 type SessionConfig struct {
@@ -15,6 +15,7 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -31,6 +32,19 @@ timeouts:
   connectivityCheck: 0ms
 logLevels:
   userDaemon: debug
+logRotation:
+  maxSize: 10Mi
+  compress: true
+profiling:
+  enabled: true
+  rootDaemonPort: 6061
+tracing:
+  endpoint: otel-collector:4317
+  samplingRatio: 0.5
+grpc:
+  tunnelIdle:
+    tcp:
+      timeout: 1h
 `,
 		/* user */ `
 timeouts:
@@ -38,18 +52,35 @@ timeouts:
   proxyDial: 17.0
 logLevels:
   rootDaemon: trace
+logRotation:
+  maxAge: 72h
+profiling:
+  userDaemonPort: 6060
+logFormat: json
+tracing:
+  insecure: true
+  headers:
+    x-api-key: secret
 images:
   registry: testregistry.io
   agentImage: ambassador-telepresence-agent-image:0.0.2
   clientImage: ambassador-telepresence-image:0.0.2
 telepresenceAPI:
   port: 1234
+grpc:
+  tunnelIdle:
+    tcp:
+      keepAliveInterval: 30
 intercept:
   appProtocolStrategy: portName
   defaultPort: 9080
   useFtp: true
 cluster:
   virtualIPSubnet: 192.169.0.0/16
+  proxyExternalNames: false
+  remapConflictingSubnets: true
+  quic: true
+  wireGuard: true
 `,
 	}
 
@@ -82,6 +113,22 @@ cluster:
 	assert.Equal(t, logrus.DebugLevel, cfg.LogLevels().UserDaemon) // from sys2
 	assert.Equal(t, logrus.TraceLevel, cfg.LogLevels().RootDaemon) // from user
 
+	assert.Equal(t, int64(10*1024*1024), cfg.LogRotation().MaxSize()) // from sys2
+	assert.True(t, cfg.LogRotation().Compress())                      // from sys2
+	assert.Equal(t, 72*time.Hour, cfg.LogRotation().MaxAge())         // from user
+	assert.Equal(t, 4, cfg.LogRotation().MaxBackups())                // default
+
+	assert.Equal(t, "json", cfg.LogFormat()) // from user
+
+	assert.Equal(t, "otel-collector:4317", cfg.Tracing().Endpoint())                   // from sys2
+	assert.Equal(t, 0.5, cfg.Tracing().SamplingRatio())                                // from sys2
+	assert.True(t, cfg.Tracing().Insecure())                                           // from user
+	assert.Equal(t, map[string]string{"x-api-key": "secret"}, cfg.Tracing().Headers()) // from user
+
+	assert.True(t, cfg.Profiling().Enabled())                       // from sys2
+	assert.Equal(t, uint16(6060), cfg.Profiling().UserDaemonPort()) // from user
+	assert.Equal(t, uint16(6061), cfg.Profiling().RootDaemonPort()) // from sys2
+
 	assert.Equal(t, "testregistry.io", cfg.Images().PrivateRegistry)                             // from user
 	assert.Equal(t, "ambassador-telepresence-agent-image:0.0.2", cfg.Images().PrivateAgentImage) // from user
 	assert.Equal(t, "ambassador-telepresence-image:0.0.2", cfg.Images().PrivateClientImage)      // from user
@@ -91,6 +138,12 @@ cluster:
 	assert.True(t, cfg.Intercept().UseFtp)                                                       // from user
 	assert.Equal(t, cfg.Cluster().DefaultManagerNamespace, "hello")                              // from sys1
 	assert.Equal(t, cfg.Cluster().VirtualIPSubnet, "192.169.0.0/16")                             // from user
+	assert.False(t, cfg.Cluster().ProxyExternalNames)                                            // from user
+	assert.True(t, cfg.Cluster().RemapConflictingSubnets)                                        // from user
+	assert.True(t, cfg.Cluster().QUIC)                                                           // from user
+	assert.True(t, cfg.Cluster().WireGuard)                                                      // from user
+	assert.Equal(t, time.Hour, cfg.Grpc().TunnelIdle().TCP.Timeout)                              // from sys2
+	assert.Equal(t, 30*time.Second, cfg.Grpc().TunnelIdle().TCP.KeepAliveInterval)               // from user
 }
 
 func Test_ConfigMarshalYAML(t *testing.T) {
@@ -102,7 +155,13 @@ func Test_ConfigMarshalYAML(t *testing.T) {
 	cfg.Images().PrivateAgentImage = "something:else"
 	cfg.Timeouts().PrivateTrafficManagerAPI = defaultTimeoutsTrafficManagerAPI + 20*time.Second
 	cfg.LogLevels().UserDaemon = logrus.TraceLevel
+	cfg.LogRotation().MaxSizeV = 20 * 1024 * 1024
+	cfg.LogRotation().MaxBackupsV = 0
+	cfg.LogRotation().CompressV = true
 	cfg.Grpc().MaxReceiveSizeV, _ = resource.ParseQuantity("20Mi")
+	cfg.Grpc().TunnelCompressionV = tunnel.ZstdCompression
+	cfg.Grpc().TunnelIdleV.TCP = tunnel.IdleConfig{KeepAliveInterval: 30 * time.Second, DeadPeerTimeout: 2 * time.Minute}
+	cfg.Grpc().TunnelIdleV.UDP.Timeout = 5 * time.Minute
 	cfg.TelepresenceAPI().Port = 4567
 	cfg.Intercept().AppProtocolStrategy = k8sapi.PortName
 	cfg.Intercept().DefaultPort = 9080
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// connectionNameKey is the gRPC metadata key that a client of the user daemon uses to identify the connection
+// that a call concerns. The user daemon can maintain several connections, one for each name.
+const connectionNameKey = "telepresence-connection-name"
+
+// WithConnectionName returns a context that identifies the connection with the given name in user daemon calls
+// that are made using it.
+func WithConnectionName(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, connectionNameKey, name)
+}
+
+// ConnectionNameInterceptors returns dial options that identify the connection with the given name in all user
+// daemon calls.
+func ConnectionNameInterceptors(name string) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(WithConnectionName(ctx, name), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(WithConnectionName(ctx, name), desc, cc, method, opts...)
+		}),
+	}
+}
+
+// ConnectionNameFromContext returns the name of the connection that the caller of a user daemon call provided,
+// or an empty string if it didn't provide one.
+func ConnectionNameFromContext(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if names := md.Get(connectionNameKey); len(names) > 0 {
+			return names[0]
+		}
+	}
+	return ""
+}
@@ -558,5 +558,6 @@ func tryLaunch(ctx context.Context, daemonID *daemon.Identifier, port int, args
 			Namespace:    daemonID.Namespace,
 			ExposedPorts: cr.ExposedPorts,
 			Hostname:     cr.Hostname,
+			StartedAt:    time.Now(),
 		}, daemonID.InfoFileName())
 }
@@ -80,7 +80,7 @@ func (as *authService) run(cmd *cobra.Command, _ []string) error {
 	addr := grpcListener.Addr().(*net.TCPAddr)
 	dlog.Infof(ctx, "kubeauth listening on address %s", addr)
 
-	config := as.kubeFlags.ToRawKubeConfigLoader()
+	config := client.KubeconfigLoader(as.kubeFlags)
 	as.configFiles = config.ConfigAccess().GetLoadingPrecedence()
 	p := PortFile{
 		Port:       addr.Port,
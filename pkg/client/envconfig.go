@@ -23,7 +23,14 @@ type Env struct {
 
 	// The address that the user daemon is listening to (unless it is started by the client and uses a named pipe or unix socket).
 	UserDaemonAddress string `env:"TELEPRESENCE_USER_DAEMON_ADDRESS, parser=possibly-empty-string,default="`
-	ScoutDisable      bool   `env:"SCOUT_DISABLE, parser=strconv.ParseBool, default=0"`
+
+	// The certificate, key, and CA certificate that the client uses for mutual TLS when it connects to
+	// the UserDaemonAddress. The connection is insecure when they are empty.
+	UserDaemonTLSCert string `env:"TELEPRESENCE_USER_DAEMON_TLS_CERT, parser=possibly-empty-string,default="`
+	UserDaemonTLSKey  string `env:"TELEPRESENCE_USER_DAEMON_TLS_KEY,  parser=possibly-empty-string,default="`
+	UserDaemonTLSCA   string `env:"TELEPRESENCE_USER_DAEMON_TLS_CA,   parser=possibly-empty-string,default="`
+
+	ScoutDisable bool `env:"SCOUT_DISABLE, parser=strconv.ParseBool, default=0"`
 }
 
 type envKey struct{}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/pflag"
@@ -88,8 +89,16 @@ type DnsConfig struct {
 	// request is made for the name, the alias will be resolved instead.
 	Mappings DNSMappings `json:"mappings,omitempty"`
 
+	// Overrides maps hostnames to IP addresses. The DNS resolver answers queries for those names
+	// locally, without consulting the cluster.
+	Overrides map[string]string `json:"overrides,omitempty"`
+
 	// The maximum time to wait for a cluster side host lookup.
 	LookupTimeout v1.Duration `json:"lookup-timeout,omitempty"`
+
+	// The time that a lookup that didn't find the name is cached. Zero means that such lookups aren't
+	// cached.
+	NegativeCacheTTL v1.Duration `json:"negative-cache-ttl,omitempty"`
 }
 
 // The ManagerConfig is part of the KubeconfigExtension struct. It configures discovery of the traffic manager.
@@ -104,6 +113,7 @@ type KubeconfigExtension struct {
 	AlsoProxy               []*iputil.Subnet `json:"also-proxy,omitempty"`
 	NeverProxy              []*iputil.Subnet `json:"never-proxy,omitempty"`
 	AllowConflictingSubnets []*iputil.Subnet `json:"allow-conflicting-subnets,omitempty"`
+	ProxyExcludePorts       []string         `json:"proxy-exclude-ports,omitempty"`
 	Manager                 *ManagerConfig   `json:"manager,omitempty"`
 }
 
@@ -393,11 +403,27 @@ func (g *configGetter) IsDefaultConfig(config *rest.Config) bool {
 	return false
 }
 
+// KubeconfigLoader returns a clientcmd.ClientConfig that loads the kubeconfig as configured by the given
+// configFlags. Unlike configFlags.ToRawKubeConfigLoader, it accepts a --kubeconfig flag that lists several
+// files separated by the OS specific path list separator. Such files are merged using the same rules that
+// apply to the files listed in the KUBECONFIG environment variable.
+func KubeconfigLoader(configFlags *genericclioptions.ConfigFlags) clientcmd.ClientConfig {
+	if kc := configFlags.KubeConfig; kc != nil {
+		if files := filepath.SplitList(*kc); len(files) > 1 {
+			rules := clientcmd.NewDefaultClientConfigLoadingRules()
+			rules.Precedence = files
+			rules.WarnIfAllMissing = true
+			return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, flagOverrides(configFlags))
+		}
+	}
+	return configFlags.ToRawKubeConfigLoader()
+}
+
 // NewClientConfig creates a clientcmd.ClientConfig, by either reading the kubeconfig from the given configData or
 // by loading it from files as configured by the given configFlags.
 func NewClientConfig(ctx context.Context, configFlags *genericclioptions.ConfigFlags, configData []byte) (clientcmd.ClientConfig, error) {
 	if len(configData) == 0 {
-		return configFlags.ToRawKubeConfigLoader(), nil
+		return KubeconfigLoader(configFlags), nil
 	}
 	directConfig, err := clientcmd.NewClientConfigFromBytes(configData)
 	if err != nil {
@@ -601,11 +627,24 @@ func (kf *Kubeconfig) AddRemoteKubeConfigExtension(ctx context.Context, cfgYaml
 			}
 			kf.DNS.Mappings = append(kf.DNS.Mappings, dns.Mappings...)
 		}
+		for name, ip := range dns.Overrides {
+			if _, ok := kf.DNS.Overrides[name]; !ok {
+				dlog.Debugf(ctx, "Applying remote override: Name: %s, IP %s", name, ip)
+				if kf.DNS.Overrides == nil {
+					kf.DNS.Overrides = make(map[string]string)
+				}
+				kf.DNS.Overrides[name] = ip
+			}
+		}
 
 		if kf.DNS.LookupTimeout.Duration == 0 {
 			dlog.Debugf(ctx, "Applying remote lookupTimeout: %s", dns.LookupTimeout)
 			kf.DNS.LookupTimeout.Duration = dns.LookupTimeout
 		}
+		if kf.DNS.NegativeCacheTTL.Duration == 0 && dns.NegativeCacheTTL != 0 {
+			dlog.Debugf(ctx, "Applying remote negativeCacheTTL: %s", dns.NegativeCacheTTL)
+			kf.DNS.NegativeCacheTTL.Duration = dns.NegativeCacheTTL
+		}
 	}
 	if routing := remote.Routing; routing != nil {
 		if len(routing.AlsoProxy) > 0 {
@@ -620,6 +659,10 @@ func (kf *Kubeconfig) AddRemoteKubeConfigExtension(ctx context.Context, cfgYaml
 			dlog.Debugf(ctx, "Applying remote allowConflicting: %v", routing.AllowConflicting)
 			kf.AllowConflictingSubnets = append(kf.AllowConflictingSubnets, routing.AllowConflicting...)
 		}
+		if len(routing.ExcludePorts) > 0 {
+			dlog.Debugf(ctx, "Applying remote proxyExcludePorts: %v", routing.ExcludePorts)
+			kf.ProxyExcludePorts = append(kf.ProxyExcludePorts, routing.ExcludePorts...)
+		}
 	}
 	return nil
 }
@@ -3,8 +3,10 @@ package logging
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -34,20 +36,37 @@ func InitContext(ctx context.Context, name string, strategy RotationStrategy, ca
 	if captureStd && IsTerminal(int(os.Stdout.Fd())) {
 		logger.Formatter = tlog.NewFormatter("15:04:05.0000")
 	} else {
-		logger.Formatter = tlog.NewFormatter("2006-01-02 15:04:05.0000")
-		maxFiles := uint16(5)
+		cfg := client.GetConfig(ctx)
+		logFormat := cfg.LogFormat()
+		formatErr := tlog.ValidFormat(logFormat)
+		if formatErr != nil {
+			logFormat = tlog.FormatText
+		}
+		logger.Formatter = tlog.MakeFormatter(logFormat, "2006-01-02 15:04:05.0000")
+		lr := cfg.LogRotation()
+		maxFiles := uint16(0)
+		if mb := lr.MaxBackups(); mb > 0 {
+			maxFiles = uint16(min(mb+1, math.MaxUint16))
+		}
 
-		// TODO: Also make this a configurable setting in config.yml
+		// The environment variable predates the logRotation config, and takes precedence.
 		if me := os.Getenv("TELEPRESENCE_MAX_LOGFILES"); me != "" {
 			if mx, err := strconv.Atoi(me); err == nil && mx >= 0 {
 				maxFiles = uint16(mx)
 			}
 		}
-		rf, err := OpenRotatingFile(ctx, filepath.Join(filelocation.AppUserLogDir(ctx), name+".log"), "20060102T150405", true, 0o600, strategy, maxFiles)
+		if ms := lr.MaxSize(); ms > 0 {
+			strategy = NewRotateOnSize(strategy, ms)
+		}
+		rf, err := OpenRotatingFile(ctx, filepath.Join(filelocation.AppUserLogDir(ctx), name+".log"),
+			"20060102T150405", true, 0o600, strategy, maxFiles, lr.MaxAge(), lr.Compress())
 		if err != nil {
 			return ctx, err
 		}
 		logger.SetOutput(rf)
+		if formatErr != nil {
+			logger.Warn(formatErr)
+		}
 
 		if captureStd {
 			if err := dupToStdOut(rf.file.(*os.File)); err != nil {
@@ -93,11 +112,24 @@ func SummarizeLog(ctx context.Context, name string) (string, error) {
 	for scanner.Scan() {
 		// XXX: is there a better way to detect error lines?
 		txt := scanner.Text()
-		parts := strings.Fields(txt)
-		if len(parts) < 3 {
-			continue
+		var level string
+		if strings.HasPrefix(txt, "{") {
+			// A line written using the JSON log format.
+			var entry struct {
+				Level string `json:"level"`
+			}
+			if json.Unmarshal([]byte(txt), &entry) != nil {
+				continue
+			}
+			level = entry.Level
+		} else {
+			parts := strings.Fields(txt)
+			if len(parts) < 3 {
+				continue
+			}
+			level = parts[2]
 		}
-		switch parts[2] {
+		switch level {
 		case "error":
 			errorCount++
 		case "info":
@@ -1,8 +1,10 @@
 package logging
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -63,6 +65,27 @@ func (rotateDaily) RotateNow(rf *RotatingFile, _ int) bool {
 	return dtime.Now().In(bt.Location()).Day() != rf.BirthTime().Day()
 }
 
+// A rotateOnSize adds a size limit to another strategy. The file is rotated when the other strategy says so,
+// and when a write would make a file of non-zero size exceed the limit.
+type rotateOnSize struct {
+	RotationStrategy
+	maxSize int64
+}
+
+// NewRotateOnSize returns a strategy that rotates the file when the given strategy says so, and when a
+// write would make it exceed the given size.
+func NewRotateOnSize(strategy RotationStrategy, maxSize int64) RotationStrategy {
+	return &rotateOnSize{RotationStrategy: strategy, maxSize: maxSize}
+}
+
+func (r *rotateOnSize) RotateNow(rf *RotatingFile, writeSize int) bool {
+	if r.RotationStrategy.RotateNow(rf, writeSize) {
+		return true
+	}
+	sz := rf.Size()
+	return sz > 0 && sz+int64(writeSize) > r.maxSize
+}
+
 type RotatingFile struct {
 	ctx         context.Context
 	fileMode    fs.FileMode
@@ -71,6 +94,8 @@ type RotatingFile struct {
 	timeFormat  string
 	localTime   bool
 	maxFiles    uint16
+	maxAge      time.Duration
+	compress    bool
 	strategy    RotationStrategy
 	mutex       sync.Mutex
 	removeMutex sync.Mutex
@@ -107,6 +132,10 @@ type RotatingFile struct {
 //
 // - maxFiles: maximum number of files in rotation, including the currently active logfile. A value of zero means
 // unlimited.
+//
+// - maxAge: maximum age of a rotated file, determined by its timestamp. A value of zero means unlimited.
+//
+// - compress: if true, rotated files are compressed using gzip.
 func OpenRotatingFile(
 	ctx context.Context,
 	logfilePath string,
@@ -115,6 +144,8 @@ func OpenRotatingFile(
 	fileMode fs.FileMode,
 	strategy RotationStrategy,
 	maxFiles uint16,
+	maxAge time.Duration,
+	compress bool,
 ) (*RotatingFile, error) {
 	logfileDir, logfileBase := filepath.Split(logfilePath)
 
@@ -132,6 +163,8 @@ func OpenRotatingFile(
 		localTime:  localTime,
 		timeFormat: timeFormat,
 		maxFiles:   maxFiles,
+		maxAge:     maxAge,
+		compress:   compress,
 	}
 
 	// Try to open existing file for append.
@@ -271,7 +304,8 @@ func (rf *RotatingFile) openNew(prevInfo SysInfo, backupName string) (err error)
 
 // removeOldFiles checks how many files that currently exists (backups + current log file) with the same
 // name as this RotatingFile and then, as long as the number of files exceed the maxFiles given to  the
-// constructor, it will continuously remove the oldest file.
+// constructor, it will continuously remove the oldest file. Backups that are older than maxAge are removed
+// too, and the remaining backups are compressed if compression is enabled.
 //
 // This function should typically run in its own goroutine.
 func (rf *RotatingFile) removeOldFiles() {
@@ -284,6 +318,10 @@ func (rf *RotatingFile) removeOldFiles() {
 	}
 	ext := filepath.Ext(rf.fileName)
 	pfx := rf.fileName[:len(rf.fileName)-len(ext)] + "-"
+	loc := time.UTC
+	if rf.localTime {
+		loc = time.Local
+	}
 
 	// Use a map with unix nanosecond timestamp as key
 	names := make(map[int64]string, rf.maxFiles+2)
@@ -293,20 +331,35 @@ func (rf *RotatingFile) removeOldFiles() {
 
 	for _, file := range files {
 		fn := file.Name()
+		bn := strings.TrimSuffix(fn, gzExt)
 
 		// Skip files that don't start with the prefix and end with the suffix.
-		if !(strings.HasPrefix(fn, pfx) && strings.HasSuffix(fn, ext)) {
+		if !(strings.HasPrefix(bn, pfx) && strings.HasSuffix(bn, ext)) {
 			continue
 		}
 		// Parse the timestamp from the file name
 		var ts time.Time
-		if ts, err = time.Parse(rf.timeFormat, fn[len(pfx):len(fn)-len(ext)]); err != nil {
+		if ts, err = time.ParseInLocation(rf.timeFormat, bn[len(pfx):len(bn)-len(ext)], loc); err != nil {
+			continue
+		}
+		if rf.maxAge > 0 && dtime.Now().Sub(ts) > rf.maxAge {
+			_ = os.Remove(filepath.Join(rf.dirName, fn))
 			continue
 		}
+		if rf.compress && bn == fn {
+			if err = rf.compressFile(filepath.Join(rf.dirName, fn)); err != nil {
+				dlog.Errorf(rf.ctx, "failed to compress %s: %v", fn, err)
+			} else {
+				fn += gzExt
+			}
+		}
 		key := ts.UnixNano()
 		keys = append(keys, key)
 		names[key] = fn
 	}
+	if rf.maxFiles == 0 {
+		return
+	}
 	mx := int(rf.maxFiles) - 1 // -1 to account for the current log file
 	if len(keys) <= mx {
 		return
@@ -317,6 +370,55 @@ func (rf *RotatingFile) removeOldFiles() {
 	}
 }
 
+// gzExt is the extension that is added to the name of a compressed backup.
+const gzExt = ".gz"
+
+// compressFile replaces the given file with a gzip compressed file that has the same owner.
+func (rf *RotatingFile) compressFile(path string) (err error) {
+	src, err := dos.Open(rf.ctx, path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	srcInfo, err := FStat(src)
+	if err != nil {
+		return err
+	}
+
+	gzPath := path + gzExt
+	tmp := fmt.Sprintf("%s.%d.tmp", gzPath, os.Getpid())
+	dst, err := dos.OpenFile(rf.ctx, tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, rf.fileMode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if zErr := zw.Close(); err == nil {
+		err = zErr
+	}
+	if cErr := dst.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = srcInfo.SetOwnerAndGroup(tmp); err != nil {
+		return err
+	}
+	if err = dos.Rename(rf.ctx, tmp, gzPath); err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func (rf *RotatingFile) rotate() error {
 	var prevInfo SysInfo
 	var backupName string
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime"
+)
+
+func TestRotatingFile_sizeAndCompression(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ft := dtime.NewFakeTime()
+	dtime.SetNow(ft.Now)
+	t.Cleanup(func() { dtime.SetNow(time.Now) })
+
+	dir := t.TempDir()
+	rf, err := OpenRotatingFile(ctx, filepath.Join(dir, "test.log"), "20060102T150405", true, 0o600,
+		NewRotateOnSize(RotateNever, 10), 3, 0, true)
+	require.NoError(t, err)
+	defer rf.Close()
+
+	lines := []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"}
+	for _, line := range lines {
+		_, err = rf.Write([]byte(line))
+		require.NoError(t, err)
+		ft.Step(time.Second)
+	}
+
+	// Each line exceeds the size limit when appended to another line, so every write after the
+	// first one rotates the file, and only two backups are retained.
+	var names []string
+	assert.Eventually(t, func() bool {
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		names = names[:0]
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		return len(names) == 3 && filepath.Ext(names[0]) == gzExt && filepath.Ext(names[1]) == gzExt
+	}, 5*time.Second, 10*time.Millisecond, "files: %v", names)
+	sort.Strings(names)
+	assert.Equal(t, "test.log", names[2])
+
+	zf, err := os.Open(filepath.Join(dir, names[1]))
+	require.NoError(t, err)
+	defer zf.Close()
+	zr, err := gzip.NewReader(zf)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, lines[2], string(data))
+}
+
+func TestRotatingFile_maxAge(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	dir := t.TempDir()
+	ts := time.Now().Add(-48 * time.Hour).Format("20060102T150405")
+	old := filepath.Join(dir, "test-"+ts+".log")
+	require.NoError(t, os.WriteFile(old, []byte("old\n"), 0o600))
+
+	rf, err := OpenRotatingFile(ctx, filepath.Join(dir, "test.log"), "20060102T150405", true, 0o600, RotateNever, 0, 24*time.Hour, false)
+	require.NoError(t, err)
+	defer rf.Close()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(old)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := tpl, key
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+		tpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+// write writes the certificate and its key in PEM format and returns the names of the files.
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	kb, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0o600))
+	return certFile, keyFile
+}
+
+// handshake performs a TLS handshake between the given configs over a loopback connection, and returns the
+// error of the server side.
+func handshake(t *testing.T, serverCfg, clientCfg *tls.Config) error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		cc, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		c := tls.Client(cc, clientCfg)
+		_ = c.Handshake()
+		_ = c.Close()
+	}()
+	sc, err := l.Accept()
+	require.NoError(t, err)
+	defer sc.Close()
+	s := tls.Server(sc, serverCfg)
+	if err = s.Handshake(); err != nil {
+		return err
+	}
+	// Wait for the client to close the connection, so that the handshake is complete on both sides.
+	_, err = s.Read(make([]byte, 1))
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return err
+}
+
+func TestMutualTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, 0)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCert, serverKey := newTestCert(t, "daemon", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "daemon")
+	clientCert, clientKey := newTestCert(t, "cli", ca, x509.ExtKeyUsageClientAuth).write(t, dir, "cli")
+
+	serverCfg, err := MutualTLSConfig(serverCert, serverKey, caFile, true)
+	require.NoError(t, err)
+	clientCfg, err := MutualTLSConfig(clientCert, clientKey, caFile, false)
+	require.NoError(t, err)
+	clientCfg.ServerName = "127.0.0.1"
+	assert.NoError(t, handshake(t, serverCfg, clientCfg))
+
+	// A client that doesn't present a certificate is rejected.
+	anonCfg := &tls.Config{RootCAs: clientCfg.RootCAs, ServerName: "127.0.0.1", MinVersion: tls.VersionTLS12}
+	assert.Error(t, handshake(t, serverCfg, anonCfg))
+
+	// A client with a certificate that isn't signed by the CA is rejected.
+	otherCA := newTestCert(t, "other", nil, 0)
+	otherCert, otherKey := newTestCert(t, "cli", otherCA, x509.ExtKeyUsageClientAuth).write(t, dir, "other")
+	otherCfg, err := MutualTLSConfig(otherCert, otherKey, caFile, false)
+	require.NoError(t, err)
+	otherCfg.ServerName = "127.0.0.1"
+	assert.Error(t, handshake(t, serverCfg, otherCfg))
+
+	_, err = MutualTLSConfig(clientCert, "", caFile, false)
+	assert.ErrorContains(t, err, "requires a certificate, a key, and a CA certificate")
+	_, err = MutualTLSConfig(clientCert, clientKey, clientKey, false)
+	assert.ErrorContains(t, err, "no PEM encoded certificates found")
+}
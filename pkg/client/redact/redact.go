@@ -0,0 +1,141 @@
+package redact
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// Mask is what replaces a redacted value.
+const Mask = "<redacted>"
+
+// minSecretLength is the minimum length of a value that is redacted from text. Shorter values, such as
+// "1" or "true", would mask unrelated parts of the text.
+const minSecretLength = 4
+
+// telepresencePrefix is the prefix of the environment variables that Telepresence adds. They are never
+// considered to be sourced from Secrets.
+const telepresencePrefix = "TELEPRESENCE_"
+
+// SecretEnv returns the names that the agentconfig.EnvInterceptSecrets entry of the given intercept
+// environment declares as being sourced from Secrets.
+func SecretEnv(env map[string]string) []string {
+	se := env[agentconfig.EnvInterceptSecrets]
+	if se == "" {
+		return nil
+	}
+	return strings.Split(se, ",")
+}
+
+// IsSecret returns true if the given environment variable name is matched by one of the given names,
+// which may end with "*" to match a prefix, as described by agentconfig.Container.SecretEnv.
+func IsSecret(secretEnv []string, name string) bool {
+	if strings.HasPrefix(name, telepresencePrefix) {
+		return false
+	}
+	for _, se := range secretEnv {
+		if pfx, ok := strings.CutSuffix(se, "*"); ok {
+			if strings.HasPrefix(name, pfx) {
+				return true
+			}
+		} else if se == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns a copy of the given intercept environment where the values that are sourced from
+// Secrets are masked.
+func Env(env map[string]string) map[string]string {
+	secretEnv := SecretEnv(env)
+	re := make(map[string]string, len(env))
+	for k, v := range env {
+		if v != "" && IsSecret(secretEnv, k) {
+			v = Mask
+		}
+		re[k] = v
+	}
+	return re
+}
+
+// SecretValues returns the values of the given intercept environment that are sourced from Secrets.
+func SecretValues(env map[string]string) []string {
+	secretEnv := SecretEnv(env)
+	if len(secretEnv) == 0 {
+		return nil
+	}
+	var values []string
+	for k, v := range env {
+		if IsSecret(secretEnv, k) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Redactor masks all occurrences of a set of secret values in text.
+type Redactor struct {
+	replacer *strings.Replacer
+}
+
+// NewRedactor returns a Redactor that masks the given values. Values that are shorter than
+// minSecretLength are ignored.
+func NewRedactor(values []string) *Redactor {
+	uniq := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		if len(v) >= minSecretLength {
+			uniq[v] = struct{}{}
+		}
+	}
+	if len(uniq) == 0 {
+		return &Redactor{}
+	}
+	vs := make([]string, 0, len(uniq))
+	for v := range uniq {
+		vs = append(vs, v)
+	}
+	// The replacer prefers the first of several values that match at the same position, so
+	// longer values must come first.
+	sort.Slice(vs, func(i, j int) bool {
+		if len(vs[i]) != len(vs[j]) {
+			return len(vs[i]) > len(vs[j])
+		}
+		return vs[i] < vs[j]
+	})
+	oldNew := make([]string, 0, len(vs)*2)
+	for _, v := range vs {
+		oldNew = append(oldNew, v, Mask)
+	}
+	return &Redactor{replacer: strings.NewReplacer(oldNew...)}
+}
+
+// String returns the given text with all secret values masked.
+func (r *Redactor) String(s string) string {
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// File masks all secret values in the file with the given name. The file retains its mode.
+func (r *Redactor) File(name string) error {
+	if r.replacer == nil {
+		return nil
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	rc := r.replacer.Replace(string(content))
+	if rc == string(content) {
+		return nil
+	}
+	return os.WriteFile(name, []byte(rc), fi.Mode().Perm())
+}
@@ -0,0 +1,64 @@
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestIsSecret(t *testing.T) {
+	secretEnv := []string{"PASSWORD", "API_*"}
+	assert.True(t, IsSecret(secretEnv, "PASSWORD"))
+	assert.True(t, IsSecret(secretEnv, "API_TOKEN"))
+	assert.False(t, IsSecret(secretEnv, "PASSWORD_FILE"))
+	assert.False(t, IsSecret(secretEnv, "HOST"))
+	assert.False(t, IsSecret([]string{"*"}, agentconfig.EnvInterceptMounts))
+	assert.True(t, IsSecret([]string{"*"}, "HOST"))
+}
+
+func TestEnv(t *testing.T) {
+	env := map[string]string{
+		"PASSWORD":                      "s3cr3t-pw",
+		"API_TOKEN":                     "t0k3n-value",
+		"API_EMPTY":                     "",
+		"HOST":                          "db.example.com",
+		agentconfig.EnvInterceptSecrets: "PASSWORD,API_*",
+	}
+	assert.Equal(t, map[string]string{
+		"PASSWORD":                      Mask,
+		"API_TOKEN":                     Mask,
+		"API_EMPTY":                     "",
+		"HOST":                          "db.example.com",
+		agentconfig.EnvInterceptSecrets: "PASSWORD,API_*",
+	}, Env(env))
+	assert.Equal(t, "s3cr3t-pw", env["PASSWORD"], "the original is not modified")
+	assert.ElementsMatch(t, []string{"s3cr3t-pw", "t0k3n-value", ""}, SecretValues(env))
+
+	delete(env, agentconfig.EnvInterceptSecrets)
+	assert.Equal(t, env, Env(env))
+	assert.Empty(t, SecretValues(env))
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor([]string{"abc", "s3cr3t", "s3cr3t-pw", "", "s3cr3t"})
+	assert.Equal(t, "pw="+Mask+", short="+Mask+", abc", r.String("pw=s3cr3t-pw, short=s3cr3t, abc"))
+
+	name := filepath.Join(t.TempDir(), "connector.log")
+	require.NoError(t, os.WriteFile(name, []byte("password s3cr3t-pw\n"), 0o600))
+	require.NoError(t, r.File(name))
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "password "+Mask+"\n", string(content))
+	fi, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm(), "the file retains its mode")
+
+	r = NewRedactor(nil)
+	assert.Equal(t, "s3cr3t", r.String("s3cr3t"))
+	require.NoError(t, r.File(filepath.Join(t.TempDir(), "missing.log")))
+}
@@ -69,9 +69,10 @@ func (m *bridgeMounter) dispatchToTunnel(ctx context.Context, conn net.Conn, pod
 		return fmt.Errorf("failed to establish tunnel: %v", err)
 	}
 
-	tos := client2.GetConfig(ctx).Timeouts()
+	cfg := client2.GetConfig(ctx)
+	tos := cfg.Timeouts()
 	ctx, cancel := context.WithCancel(ctx)
-	s, err := tunnel.NewClientStream(ctx, ms, id, m.sessionID, tos.PrivateRoundtripLatency, tos.PrivateEndpointDial)
+	s, err := tunnel.NewClientStream(tunnel.WithCompression(ctx, cfg.Grpc().TunnelCompression()), ms, id, m.sessionID, tos.PrivateRoundtripLatency, tos.PrivateEndpointDial)
 	if err != nil {
 		cancel()
 		return fmt.Errorf("failed to create stream: %v", err)
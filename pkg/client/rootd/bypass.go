@@ -0,0 +1,83 @@
+package rootd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/routing"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// parseExcludePorts parses destination ports on the form <port>[/<protocol>].
+func parseExcludePorts(ps []string) ([]agentconfig.PortAndProto, error) {
+	if len(ps) == 0 {
+		return nil, nil
+	}
+	pps := make([]agentconfig.PortAndProto, len(ps))
+	for i, p := range ps {
+		pp, err := agentconfig.NewPortAndProto(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy-exclude-port %q: %w", p, err)
+		}
+		pps[i] = pp
+	}
+	return pps, nil
+}
+
+// isPortExcluded returns true if connections using the given protocol and destination port must never
+// be routed to the cluster.
+func (s *Session) isPortExcluded(proto int, port uint16) bool {
+	var cp core.Protocol
+	switch proto {
+	case ipproto.TCP:
+		cp = core.ProtocolTCP
+	case ipproto.UDP:
+		cp = core.ProtocolUDP
+	default:
+		return false
+	}
+	for _, pp := range s.proxyExcludePorts {
+		if pp.Port == port && pp.Proto == cp {
+			return true
+		}
+	}
+	return false
+}
+
+// bypassStream dials the destination of the given connection directly, using the interface of the
+// default route so that the connection isn't routed back into the TUN device, and returns a stream
+// that is connected to it.
+func (s *Session) bypassStream(ctx context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+	rt, err := routing.DefaultRoute(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to bypass the cluster for %s: %w", id, err)
+	}
+	ifIndex := rt.Interface.Index
+	d := net.Dialer{
+		Control: func(network, _ string, c syscall.RawConn) (err error) {
+			cErr := c.Control(func(fd uintptr) {
+				err = bindToInterface(fd, network, ifIndex, rt.Interface.Name)
+			})
+			if err == nil {
+				err = cErr
+			}
+			return err
+		},
+	}
+	conn, err := d.DialContext(ctx, ipproto.String(id.Protocol()), id.DestinationAddr().String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to bypass the cluster for %s: %w", id, err)
+	}
+	dlog.Debugf(ctx, "Bypassing the cluster for excluded port %s using interface %s", id, rt.Interface.Name)
+	ctx, cancel := context.WithCancel(ctx)
+	from, to := tunnel.NewPipe(id, s.session.SessionId)
+	tunnel.NewConnEndpoint(to, conn, cancel, nil, nil).Start(ctx)
+	return from, nil
+}
@@ -0,0 +1,14 @@
+package rootd
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func bindToInterface(fd uintptr, network string, ifIndex int, _ string) error {
+	switch network {
+	case "tcp6", "udp6":
+		return unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_BOUND_IF, ifIndex)
+	default:
+		return unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_BOUND_IF, ifIndex)
+	}
+}
@@ -0,0 +1,9 @@
+package rootd
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func bindToInterface(fd uintptr, _ string, _ int, ifName string) error {
+	return unix.BindToDevice(int(fd), ifName)
+}
@@ -0,0 +1,29 @@
+package rootd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+func TestIsPortExcluded(t *testing.T) {
+	pps, err := parseExcludePorts([]string{"22", "3306/TCP", "5353/UDP"})
+	require.NoError(t, err)
+	s := &Session{proxyExcludePorts: pps}
+	assert.True(t, s.isPortExcluded(ipproto.TCP, 22))
+	assert.True(t, s.isPortExcluded(ipproto.TCP, 3306))
+	assert.True(t, s.isPortExcluded(ipproto.UDP, 5353))
+	assert.False(t, s.isPortExcluded(ipproto.UDP, 22))
+	assert.False(t, s.isPortExcluded(ipproto.TCP, 5353))
+	assert.False(t, s.isPortExcluded(ipproto.TCP, 80))
+	assert.True(t, s.isExcludedAddress("tcp4", "22"))
+	assert.False(t, s.isExcludedAddress("udp", "22"))
+
+	_, err = parseExcludePorts([]string{"ssh"})
+	assert.Error(t, err)
+	_, err = parseExcludePorts([]string{"22/ICMP"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package rootd
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/sys/windows"
+)
+
+// Socket options that are missing from x/sys/windows.
+const (
+	ipUnicastIf   = 31
+	ipv6UnicastIf = 31
+)
+
+func bindToInterface(fd uintptr, network string, ifIndex int, _ string) error {
+	switch network {
+	case "tcp6", "udp6":
+		return windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IPV6, ipv6UnicastIf, ifIndex)
+	default:
+		// The IPv4 interface index must be given in network byte order.
+		var idx [4]byte
+		binary.BigEndian.PutUint32(idx[:], uint32(ifIndex))
+		return windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IP, ipUnicastIf, int(binary.NativeEndian.Uint32(idx[:])))
+	}
+}
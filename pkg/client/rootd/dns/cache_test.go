@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+)
+
+func TestResolveThruCache(t *testing.T) {
+	var lookups int32
+	s := &Server{
+		ctx:              context.Background(),
+		cache:            xsync.NewMapOf[cacheKey, *cacheEntry](),
+		negativeCacheTTL: time.Minute,
+		resolve: func(_ context.Context, q *dns.Question) (dnsproxy.RRs, int, error) {
+			atomic.AddInt32(&lookups, 1)
+			if q.Name != "echo.default." {
+				return nil, dns.RcodeNameError, nil
+			}
+			return dnsproxy.RRs{&dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+				A:   net.IP{10, 0, 0, 1},
+			}}, dns.RcodeSuccess, nil
+		},
+	}
+	aq := &dns.Question{Name: "echo.default.", Qtype: dns.TypeA}
+	nq := &dns.Question{Name: "missing.default.", Qtype: dns.TypeA}
+
+	for i := 0; i < 2; i++ {
+		answer, rCode, err := s.resolveThruCache(aq)
+		require.NoError(t, err)
+		assert.Equal(t, dns.RcodeSuccess, rCode)
+		require.Len(t, answer, 1)
+		assert.Equal(t, uint32(dnsTTL), answer[0].Header().Ttl, "TTL returned to the caller must be kept low")
+
+		answer, rCode, err = s.resolveThruCache(nq)
+		require.NoError(t, err)
+		assert.Equal(t, dns.RcodeNameError, rCode)
+		assert.Empty(t, answer)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+
+	ce, ok := s.cache.Load(cacheKey{name: aq.Name, qType: aq.Qtype})
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, ce.ttl, "cache must respect the TTL of the record")
+
+	st := s.GetCacheStats()
+	assert.Equal(t, int32(2), st.Entries)
+	assert.Equal(t, int32(1), st.NegativeEntries)
+	assert.Equal(t, int64(2), st.Hits)
+	assert.Equal(t, int64(2), st.Misses)
+
+	// An expired entry is resolved again.
+	ce.created = time.Now().Add(-time.Minute)
+	_, _, err := s.resolveThruCache(aq)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&lookups))
+
+	s.FlushCache()
+	assert.Zero(t, s.GetCacheStats().Entries)
+
+	// Negative results are not cached unless a negative TTL is configured.
+	s.negativeCacheTTL = 0
+	for i := 0; i < 2; i++ {
+		_, _, err = s.resolveThruCache(nq)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&lookups))
+}
+
+func TestAnswerTTL(t *testing.T) {
+	rr := func(ttl uint32) dns.RR {
+		return &dns.A{Hdr: dns.RR_Header{Name: "a.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl}, A: net.IP{10, 0, 0, 1}}
+	}
+	assert.Equal(t, cacheTTL, answerTTL(nil))
+	assert.Equal(t, cacheTTL, answerTTL(dnsproxy.RRs{rr(3600)}))
+	assert.Equal(t, 5*time.Second, answerTTL(dnsproxy.RRs{rr(30), rr(5)}))
+	assert.Equal(t, time.Duration(0), answerTTL(dnsproxy.RRs{rr(0)}))
+}
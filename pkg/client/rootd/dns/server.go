@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -81,9 +82,14 @@ type Server struct {
 	fallbackPool FallbackPool
 	resolve      Resolver
 	requestCount int64
+	cacheHits    int64
+	cacheMisses  int64
 	cache        *xsync.MapOf[cacheKey, *cacheEntry]
 	recursive    int32 // one of the recursionXXX constants declared above (unique type avoided because it just gets messy with the atomic calls)
 
+	// lookupLatency is the smoothed duration of the lookups that are sent to the cluster, in nanoseconds.
+	lookupLatency int64
+
 	// Suffixes to immediately drop from the query before processing. This list will always contain the tel2Search domain.
 	// The overriding resolver will also add the search path found in /etc/resolv.conf, because that search path is not
 	// intended for this resolver and will get reapplied when passing things on to the fallback resolver.
@@ -111,8 +117,15 @@ type Server struct {
 	excludes []string
 	mappings map[string]string
 
+	// overrides are names that resolve to a fixed IP without consulting the cluster.
+	overrides map[string]net.IP
+
 	lookupTimeout time.Duration
 
+	// negativeCacheTTL is the time that a lookup that didn't find the name is cached. Zero means that
+	// such lookups aren't cached.
+	negativeCacheTTL time.Duration
+
 	localIP  net.IP
 	remoteIP net.IP
 
@@ -122,6 +135,9 @@ type Server struct {
 	// Function that sends a lookup request to the traffic-manager
 	clusterLookup Resolver
 
+	// routeFilter, when set, returns the routes that the server may use out of the given routes.
+	routeFilter func(context.Context, []string) []string
+
 	error string
 
 	// ready is closed when the DNS server is fully configured
@@ -133,14 +149,28 @@ type cacheEntry struct {
 	currentQType int32 // will be set to the current qType during call to cluster
 	answer       dnsproxy.RRs
 	rCode        int
+	ttl          time.Duration // set when the lookup completes
 	wait         chan struct{}
 }
 
-// cacheTTL is the time to live for an entry in the local DNS cache.
+// cacheTTL is the max time to live for an entry in the local DNS cache. Entries with records that
+// have a lower TTL expire sooner.
 const cacheTTL = 60 * time.Second
 
 func (dv *cacheEntry) expired() bool {
-	return time.Since(dv.created) > cacheTTL
+	return time.Since(dv.created) > dv.ttl
+}
+
+// answerTTL returns the time that the given answer can be cached, which is the lowest TTL of its
+// records, but never more than cacheTTL.
+func answerTTL(rrs dnsproxy.RRs) time.Duration {
+	ttl := cacheTTL
+	for _, rr := range rrs {
+		if rt := time.Duration(rr.Header().Ttl) * time.Second; rt < ttl {
+			ttl = rt
+		}
+	}
+	return ttl
 }
 
 func (dv *cacheEntry) close() {
@@ -177,6 +207,7 @@ func NewServer(config *rpc.DNSConfig, clusterLookup Resolver) *Server {
 		excludeSuffixes: sliceToLower(config.ExcludeSuffixes),
 		includeSuffixes: sliceToLower(config.IncludeSuffixes),
 		mappings:        mappingsMap(config.Mappings),
+		overrides:       overridesMap(config.Overrides),
 		localIP:         config.LocalIp,
 		remoteIP:        config.RemoteIp,
 		dropSuffixes:    []string{tel2SubDomainDot},
@@ -189,6 +220,9 @@ func NewServer(config *rpc.DNSConfig, clusterLookup Resolver) *Server {
 	if lt := config.LookupTimeout; lt != nil {
 		s.lookupTimeout = lt.AsDuration()
 	}
+	if nct := config.NegativeCacheTtl; nct != nil {
+		s.negativeCacheTTL = nct.AsDuration()
+	}
 	return s
 }
 
@@ -351,20 +385,34 @@ func (s *Server) resolveInCluster(c context.Context, q *dns.Question) (result dn
 	c, cancel := context.WithTimeout(c, s.lookupTimeout)
 	defer cancel()
 
+	start := time.Now()
 	result, rCode, err = s.clusterLookup(c, q)
 	if err != nil {
 		return nil, rCode, client.CheckTimeout(c, err)
 	}
+	s.recordLookupLatency(time.Since(start))
+	return result, rCode, nil
+}
 
-	// Keep the TTLs of requests resolved in the cluster low. We
-	// cache them locally anyway, but our cache is flushed when things are
-	// intercepted or the namespaces change.
-	for _, rr := range result {
-		if h := rr.Header(); h != nil {
-			h.Ttl = dnsTTL
+// recordLookupLatency updates the smoothed latency of cluster lookups the same way that TCP smooths its
+// round trip time, i.e. by giving the latency of the latest lookup a weight of 1/8.
+func (s *Server) recordLookupLatency(d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&s.lookupLatency)
+		latency := int64(d)
+		if old != 0 {
+			latency = old + (latency-old)/8
+		}
+		if atomic.CompareAndSwapInt64(&s.lookupLatency, old, latency) {
+			return
 		}
 	}
-	return result, rCode, nil
+}
+
+// GetLookupLatency returns the smoothed latency of the lookups that were sent to the cluster, or zero when
+// no such lookup has been made.
+func (s *Server) GetLookupLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.lookupLatency))
 }
 
 func (s *Server) GetConfig() *rpc.DNSConfig {
@@ -380,6 +428,9 @@ func (s *Server) GetConfig() *rpc.DNSConfig {
 	if s.lookupTimeout != 0 {
 		c.LookupTimeout = durationpb.New(s.lookupTimeout)
 	}
+	if s.negativeCacheTTL != 0 {
+		c.NegativeCacheTtl = durationpb.New(s.negativeCacheTTL)
+	}
 	if len(s.mappings) > 0 {
 		ns := maps.Keys(s.mappings)
 		slices.Sort(ns)
@@ -391,10 +442,35 @@ func (s *Server) GetConfig() *rpc.DNSConfig {
 			}
 		}
 	}
+	if len(s.overrides) > 0 {
+		c.Overrides = make(map[string]string, len(s.overrides))
+		for n, ip := range s.overrides {
+			c.Overrides[strings.TrimSuffix(n, ".")] = ip.String()
+		}
+	}
 	s.RUnlock()
 	return &c
 }
 
+// Domains returns the sorted domains that the resolver sends to the cluster, i.e. the cluster domain, the
+// routed top level domains, and the include-suffixes.
+func (s *Server) Domains() []string {
+	s.RLock()
+	ds := make([]string, 0, len(s.routes)+len(s.includeSuffixes)+1)
+	if s.clusterDomain != "" {
+		ds = append(ds, strings.TrimSuffix(s.clusterDomain, "."))
+	}
+	for r := range s.routes {
+		ds = append(ds, r)
+	}
+	for _, sfx := range s.includeSuffixes {
+		ds = append(ds, strings.TrimPrefix(sfx, "."))
+	}
+	s.RUnlock()
+	slices.Sort(ds)
+	return slices.Compact(ds)
+}
+
 func (s *Server) Ready() <-chan struct{} {
 	return s.ready
 }
@@ -477,6 +553,21 @@ func mappingsMap(mappings []*rpc.DNSMapping) map[string]string {
 	return nil
 }
 
+// overridesMap returns a map of lower case fully qualified names to IP addresses. Entries where the
+// IP cannot be parsed are ignored.
+func overridesMap(overrides map[string]string) map[string]net.IP {
+	if len(overrides) == 0 {
+		return nil
+	}
+	om := make(map[string]net.IP, len(overrides))
+	for n, ipStr := range overrides {
+		if ip := iputil.Parse(ipStr); ip != nil {
+			om[strings.ToLower(strings.TrimSuffix(n, ".")+".")] = ip
+		}
+	}
+	return om
+}
+
 // SetMappings sets the Mappings list in the config.
 func (s *Server) SetMappings(mappings []*rpc.DNSMapping) {
 	mm := mappingsMap(mappings)
@@ -498,49 +589,73 @@ func newLocalUDPListener(c context.Context) (net.PacketConn, error) {
 func (s *Server) processSearchPaths(g *dgroup.Group, processor func(context.Context, vif.Device) error, dev vif.Device) {
 	g.Go("SearchPaths", func(c context.Context) error {
 		s.performRecursionCheck(c)
-		prevDas := nsAndDomains{
-			domains:   []string{},
-			namespace: "",
-		}
-		unchanged := func(das nsAndDomains) bool {
-			return das.namespace == prevDas.namespace && slices.Equal(das.domains, prevDas.domains)
-		}
+		return s.watchSearchPaths(c, processor, dev)
+	})
+}
 
-		for {
-			select {
-			case <-c.Done():
-				return nil
-			case das := <-s.nsAndDomainsCh:
-				// Only interested in the last one, and only if it differs
-				if len(s.nsAndDomainsCh) > 0 || unchanged(das) {
-					continue
-				}
-				prevDas = das
+// SetRouteFilter sets a function that determines which routes the server may use. It is called with the
+// routes each time they change, and must be set before the server is started.
+func (s *Server) SetRouteFilter(filter func(context.Context, []string) []string) {
+	s.routeFilter = filter
+}
 
-				routes := make(map[string]struct{}, len(das.domains))
-				for _, domain := range das.domains {
-					if domain != "" && !s.isDomainExcluded(domain) {
-						routes[domain] = struct{}{}
-					}
+// watchSearchPaths updates the routes and the search path of the server each time new top level domains
+// or a new namespace is set, and then calls the processor. It returns when the context is cancelled.
+func (s *Server) watchSearchPaths(c context.Context, processor func(context.Context, vif.Device) error, dev vif.Device) error {
+	prevDas := nsAndDomains{
+		domains:   []string{},
+		namespace: "",
+	}
+	unchanged := func(das nsAndDomains) bool {
+		return das.namespace == prevDas.namespace && slices.Equal(das.domains, prevDas.domains)
+	}
+
+	for {
+		select {
+		case <-c.Done():
+			return nil
+		case das := <-s.nsAndDomainsCh:
+			// Only interested in the last one, and only if it differs
+			if len(s.nsAndDomainsCh) > 0 || unchanged(das) {
+				continue
+			}
+			prevDas = das
+
+			routes := make(map[string]struct{}, len(das.domains))
+			for _, domain := range das.domains {
+				if domain != "" && !s.isDomainExcluded(domain) {
+					routes[domain] = struct{}{}
 				}
-				if !s.isDomainExcluded("svc") {
-					routes["svc"] = struct{}{}
+			}
+			// Short forms of service and pod names, e.g. <service>.<namespace>.svc and <a-b-c-d>.<namespace>.pod.
+			for _, domain := range []string{"svc", "pod"} {
+				if !s.isDomainExcluded(domain) {
+					routes[domain] = struct{}{}
+				}
+			}
+			if s.routeFilter != nil {
+				rs := maps.Keys(routes)
+				sort.Strings(rs)
+				granted := s.routeFilter(c, rs)
+				routes = make(map[string]struct{}, len(granted))
+				for _, route := range granted {
+					routes[route] = struct{}{}
 				}
-				s.Lock()
-				s.routes = routes
+			}
+			s.Lock()
+			s.routes = routes
 
-				// The connected namespace must be included as a search path for the cases
-				// where it's up to the traffic-manager to resolve. It cannot resolve a single
-				// label name intended for other namespaces.
-				s.search = []string{tel2SubDomain, das.namespace}
-				s.Unlock()
+			// The connected namespace must be included as a search path for the cases
+			// where it's up to the traffic-manager to resolve. It cannot resolve a single
+			// label name intended for other namespaces.
+			s.search = []string{tel2SubDomain, das.namespace}
+			s.Unlock()
 
-				if err := processor(c, dev); err != nil {
-					return err
-				}
+			if err := processor(c, dev); err != nil {
+				return err
 			}
 		}
-	})
+	}
 }
 
 func (s *Server) flushDNS() {
@@ -552,6 +667,35 @@ func (s *Server) flushDNS() {
 	})
 }
 
+// FlushCache removes all entries from the DNS cache.
+func (s *Server) FlushCache() {
+	s.flushDNS()
+}
+
+// GetCacheStats returns statistics for the DNS cache. Entries that are expired or still being
+// resolved are not counted.
+func (s *Server) GetCacheStats() *rpc.DNSCacheStats {
+	st := rpc.DNSCacheStats{
+		Hits:   atomic.LoadInt64(&s.cacheHits),
+		Misses: atomic.LoadInt64(&s.cacheMisses),
+	}
+	s.cache.Range(func(_ cacheKey, ce *cacheEntry) bool {
+		select {
+		case <-ce.wait:
+		default:
+			return true
+		}
+		if !ce.expired() {
+			st.Entries++
+			if ce.rCode != dns.RcodeSuccess {
+				st.NegativeEntries++
+			}
+		}
+		return true
+	})
+	return &st
+}
+
 // splitToUDPAddr splits the given address into an UDPAddr. It's
 // an  error if the address is based on a hostname rather than an IP.
 func splitToUDPAddr(netAddr net.Addr) (*net.UDPAddr, error) {
@@ -574,7 +718,15 @@ func copyRRs(rrs dnsproxy.RRs, qTypes []uint16) dnsproxy.RRs {
 	cp := make(dnsproxy.RRs, 0, len(rrs))
 	for _, rr := range rrs {
 		if slice.Contains(qTypes, rr.Header().Rrtype) {
-			cp = append(cp, dns.Copy(rr))
+			rr = dns.Copy(rr)
+
+			// Keep the TTLs of records resolved in the cluster low. We cache them
+			// locally anyway, but our cache is flushed when things are intercepted
+			// or the namespaces change.
+			if h := rr.Header(); h.Ttl > dnsTTL {
+				h.Ttl = dnsTTL
+			}
+			cp = append(cp, rr)
 		}
 	}
 	return cp
@@ -672,6 +824,7 @@ func (s *Server) resolveThruCache(q *dns.Question) (answer dnsproxy.RRs, rCode i
 		}
 		<-oldDv.wait
 		if !oldDv.expired() {
+			atomic.AddInt64(&s.cacheHits, 1)
 			qTypes := []uint16{q.Qtype}
 			if q.Qtype != dns.TypeCNAME {
 				// Allow additional CNAME records if they are present.
@@ -687,18 +840,24 @@ func (s *Server) resolveThruCache(q *dns.Question) (answer dnsproxy.RRs, rCode i
 		s.cache.Store(key, dv)
 	}
 
+	atomic.AddInt64(&s.cacheMisses, 1)
 	atomic.StoreInt32(&dv.currentQType, int32(q.Qtype))
 	defer func() {
-		if rCode != dns.RcodeSuccess {
-			s.cache.Delete(key) // Don't cache unless the lookup succeeded.
-		} else {
+		switch {
+		case err == nil && rCode == dns.RcodeSuccess:
 			dv.answer = answer
 			dv.rCode = rCode
+			dv.ttl = answerTTL(answer)
 
 			// Return a result for the correct query type. The result will be nil (nxdomain) if nothing was found. It might
 			// also be empty if no RRs were found for the given query type and that is OK.
 			// See https://datatracker.ietf.org/doc/html/rfc4074#section-3
 			answer = copyRRs(answer, []uint16{q.Qtype})
+		case err == nil && rCode == dns.RcodeNameError && s.negativeCacheTTL > 0:
+			dv.rCode = rCode
+			dv.ttl = s.negativeCacheTTL
+		default:
+			s.cache.Delete(key) // Don't cache unless the lookup succeeded or found that the name doesn't exist.
 		}
 		atomic.StoreInt32(&dv.currentQType, int32(dns.TypeNone))
 		dv.close()
@@ -802,6 +961,10 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 
 	defer func() {
 		dlog.Debugf(c, "%s%5d %-6s %s -> %s %s", pfx, r.Id, qts, q.Name, rct, txt)
+		if _, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+			// Sets the TC bit when records are dropped, which tells the client to retry using TCP.
+			msg.Truncate(udpSize(r))
+		}
 		_ = w.WriteMsg(msg)
 
 		// Closing the response tells the DNS service to terminate
@@ -891,9 +1054,12 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 			return
 		}
 
-		// try and resolve any mappings before consulting the cache, so that mapping hits don't
-		// end up in the cache.
-		answer, rCode, err = s.resolveMapping(q)
+		// try and resolve any overrides and mappings before consulting the cache, so that their
+		// hits don't end up in the cache.
+		answer, rCode, err = s.resolveOverride(q)
+		if err == errNoMapping {
+			answer, rCode, err = s.resolveMapping(q)
+		}
 		if err == errNoMapping {
 			answer, rCode, err = s.resolveWithRecursionCheck(q)
 		}
@@ -952,6 +1118,18 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 func (s *Server) fallbackExchange(c context.Context, msg, r *dns.Msg) (*dns.Msg, func() string) {
 	dc := &dns.Client{Net: "udp", Timeout: s.lookupTimeout}
 	poolMsg, _, err := s.fallbackPool.Exchange(c, dc, r)
+	if err == nil && poolMsg.Truncated {
+		// Retry using TCP so that our client gets the full response, or a response that is
+		// truncated to fit its own UDP size.
+		dc = &dns.Client{Net: "tcp", Timeout: s.lookupTimeout}
+		var tcpMsg *dns.Msg
+		if tcpMsg, _, err = dc.ExchangeContext(c, r, s.fallbackPool.RemoteAddr()); err == nil {
+			poolMsg = tcpMsg
+		} else {
+			dlog.Debugf(c, "TCP retry of truncated response failed: %v", err)
+			err = nil
+		}
+	}
 	var txt func() string
 	if err != nil {
 		rCode := dns.RcodeServerFailure
@@ -992,19 +1170,7 @@ func (s *Server) resolveMapping(q *dns.Question) (dnsproxy.RRs, int, error) {
 	}
 	if ip := iputil.Parse(mappingAlias); ip != nil {
 		// The name resolves to an A or AAAA record known by this DNS server.
-		var rrs dnsproxy.RRs
-		if q.Qtype == dns.TypeA && len(ip) == 4 {
-			rrs = dnsproxy.RRs{&dns.A{
-				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: dnsTTL},
-				A:   ip,
-			}}
-		} else if q.Qtype == dns.TypeAAAA && len(ip) == 16 {
-			rrs = dnsproxy.RRs{&dns.AAAA{
-				Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: dnsTTL},
-				AAAA: ip,
-			}}
-		}
-		return rrs, dns.RcodeSuccess, nil
+		return ipAnswer(q, ip), dns.RcodeSuccess, nil
 	}
 
 	cnameRRs := dnsproxy.RRs{&dns.CNAME{
@@ -1030,16 +1196,62 @@ func (s *Server) resolveMapping(q *dns.Question) (dnsproxy.RRs, int, error) {
 	return answer, rCode, err
 }
 
-// Run starts the DNS server(s) and waits for them to end.
+// resolveOverride answers A, AAAA, and CNAME queries for names that have an override. An override
+// always results in a successful response, but the answer is empty unless the override IP matches
+// the type of the query.
+func (s *Server) resolveOverride(q *dns.Question) (dnsproxy.RRs, int, error) {
+	switch q.Qtype {
+	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME:
+	default:
+		return nil, dns.RcodeNameError, errNoMapping
+	}
+
+	s.RLock()
+	ip, ok := s.overrides[q.Name]
+	s.RUnlock()
+
+	if !ok {
+		return nil, dns.RcodeNameError, errNoMapping
+	}
+	return ipAnswer(q, ip), dns.RcodeSuccess, nil
+}
+
+// ipAnswer returns an A or AAAA record for the given IP, or nil if the IP doesn't match the type of
+// the question.
+func ipAnswer(q *dns.Question, ip net.IP) dnsproxy.RRs {
+	if q.Qtype == dns.TypeA && len(ip) == 4 {
+		return dnsproxy.RRs{&dns.A{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: dnsTTL},
+			A:   ip,
+		}}
+	}
+	if q.Qtype == dns.TypeAAAA && len(ip) == 16 {
+		return dnsproxy.RRs{&dns.AAAA{
+			Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: dnsTTL},
+			AAAA: ip,
+		}}
+	}
+	return nil
+}
+
+// udpSize returns the max size of a UDP response to the given request.
+func udpSize(r *dns.Msg) int {
+	if opt := r.IsEdns0(); opt != nil && opt.UDPSize() > dns.MinMsgSize {
+		return int(opt.UDPSize())
+	}
+	return dns.MinMsgSize
+}
+
+// Run starts the DNS server(s) and waits for them to end. A TCP listener is added for each of the given UDP
+// listeners when the same address is available for TCP, so that clients can retry truncated responses.
 func (s *Server) Run(c context.Context, initDone chan<- struct{}, listeners []net.PacketConn, fallbackPool FallbackPool, resolve Resolver) error {
 	s.ctx = c
 	s.fallbackPool = fallbackPool
 	s.resolve = resolve
 
 	g := dgroup.NewGroup(c, dgroup.GroupConfig{})
-	for _, listener := range listeners {
-		srv := &dns.Server{PacketConn: listener, Handler: s, ReadTimeout: time.Second}
-		g.Go(listener.LocalAddr().String(), func(c context.Context) error {
+	serve := func(name string, srv *dns.Server) {
+		g.Go(name, func(c context.Context) error {
 			go func() {
 				<-c.Done()
 				dlog.Debugf(c, "Shutting down DNS server")
@@ -1048,6 +1260,17 @@ func (s *Server) Run(c context.Context, initDone chan<- struct{}, listeners []ne
 			return srv.ActivateAndServe()
 		})
 	}
+	for _, listener := range listeners {
+		addr := listener.LocalAddr().String()
+		serve(addr, &dns.Server{PacketConn: listener, Handler: s, ReadTimeout: time.Second})
+		lc := net.ListenConfig{}
+		tl, err := lc.Listen(c, "tcp", addr)
+		if err != nil {
+			dlog.Warnf(c, "Unable to listen to TCP on %s. Truncated DNS responses cannot be retried: %v", addr, err)
+			continue
+		}
+		serve("tcp/"+addr, &dns.Server{Listener: tl, Handler: s, ReadTimeout: time.Second})
+	}
 	close(initDone)
 	return g.Wait()
 }
@@ -284,7 +284,8 @@ func routeDNS(c context.Context, dnsIP net.IP, toAddr *net.UDPAddr, localDNSs []
 			return err
 		}
 	}
-	// This rule redirects all packets intended for the DNS service to our local DNS service
+	// This rule redirects all packets intended for the DNS service to our local DNS service. Only UDP is
+	// redirected, because our own TCP retries of truncated responses must reach the original DNS service.
 	if err = runNatTableCmd(c, "-A", tpDNSChain,
 		"-p", "udp",
 		"--dest", dnsIP.String()+"/32",
@@ -127,6 +127,48 @@ func (s *suiteServer) TestIsExcluded() {
 	assert.False(s.T(), s.server.isExcluded("something-else"))
 }
 
+func (s *suiteServer) TestResolveOverride() {
+	// given
+	s.server.overrides = overridesMap(map[string]string{
+		"Stub.Example.com": "127.0.0.1",
+		"stub6":            "::1",
+		"broken":           "not-an-ip",
+	})
+
+	// when & then
+	s.Len(s.server.overrides, 2)
+
+	answer, rCode, err := s.server.resolveOverride(&dns.Question{Name: "stub.example.com.", Qtype: dns.TypeA})
+	s.Require().NoError(err)
+	s.Equal(dns.RcodeSuccess, rCode)
+	s.Require().Len(answer, 1)
+	s.Equal("127.0.0.1", answer[0].(*dns.A).A.String())
+
+	answer, rCode, err = s.server.resolveOverride(&dns.Question{Name: "stub.example.com.", Qtype: dns.TypeAAAA})
+	s.Require().NoError(err)
+	s.Equal(dns.RcodeSuccess, rCode)
+	s.Empty(answer)
+
+	answer, _, err = s.server.resolveOverride(&dns.Question{Name: "stub6.", Qtype: dns.TypeAAAA})
+	s.Require().NoError(err)
+	s.Require().Len(answer, 1)
+	s.Equal("::1", answer[0].(*dns.AAAA).AAAA.String())
+
+	_, _, err = s.server.resolveOverride(&dns.Question{Name: "stub.example.com.", Qtype: dns.TypeMX})
+	s.ErrorIs(err, errNoMapping)
+	_, _, err = s.server.resolveOverride(&dns.Question{Name: "other.example.com.", Qtype: dns.TypeA})
+	s.ErrorIs(err, errNoMapping)
+}
+
 func TestServerTestSuite(t *testing.T) {
 	suite.Run(t, new(suiteServer))
 }
+
+func TestRecordLookupLatency(t *testing.T) {
+	s := &Server{}
+	assert.Zero(t, s.GetLookupLatency())
+	s.recordLookupLatency(80 * time.Millisecond)
+	assert.Equal(t, 80*time.Millisecond, s.GetLookupLatency())
+	s.recordLookupLatency(160 * time.Millisecond)
+	assert.Equal(t, 90*time.Millisecond, s.GetLookupLatency())
+}
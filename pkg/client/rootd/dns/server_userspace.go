@@ -0,0 +1,36 @@
+package dns
+
+import (
+	"context"
+	"net"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+)
+
+// UserSpaceWorker starts the DNS server on a local UDP port but leaves the DNS configuration of the host
+// untouched. It's used when all networking is handled in user space, in which case the DNS server is
+// only queried by the daemon itself, on behalf of the clients of its SOCKS5 proxy. No recursion check is
+// performed, because no queries made using the host's resolver will ever reach the server.
+func (s *Server) UserSpaceWorker(c context.Context, configureDNS func(net.IP, *net.UDPAddr)) error {
+	listener, err := newLocalUDPListener(c)
+	if err != nil {
+		return err
+	}
+	dnsAddr, err := splitToUDPAddr(listener.LocalAddr())
+	if err != nil {
+		return err
+	}
+	configureDNS(nil, dnsAddr)
+
+	g := dgroup.NewGroup(c, dgroup.GroupConfig{})
+	g.Go("SearchPaths", func(c context.Context) error {
+		s.Stop() // Closes the ready channel
+		return s.watchSearchPaths(c, func(context.Context, vif.Device) error { return nil }, nil)
+	})
+	g.Go("Server", func(c context.Context) error {
+		// Server will close the listener, so no need to close it here.
+		return s.Run(c, make(chan struct{}), []net.PacketConn{listener}, nil, s.resolveInCluster)
+	})
+	return g.Wait()
+}
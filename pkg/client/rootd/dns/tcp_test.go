@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+)
+
+func TestServeTruncatedOverTCP(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	const srvCount = 40
+	resolve := func(_ context.Context, q *dns.Question) (dnsproxy.RRs, int, error) {
+		rrs := make(dnsproxy.RRs, srvCount)
+		for i := range rrs {
+			rrs[i] = &dns.SRV{
+				Hdr:    dnsproxy.NewHeader(q.Name, dns.TypeSRV),
+				Target: fmt.Sprintf("pod-%d.echo.default.svc.cluster.local.", i),
+				Port:   8080,
+			}
+		}
+		return rrs, dns.RcodeSuccess, nil
+	}
+
+	pc, err := newLocalUDPListener(ctx)
+	require.NoError(t, err)
+	addr := pc.LocalAddr().String()
+	s := NewServer(nil, nil)
+	initDone := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Run(ctx, initDone, []net.PacketConn{pc}, nil, resolve)
+	}()
+	<-initDone
+
+	q := new(dns.Msg)
+	q.SetQuestion("_http._tcp.echo.default.", dns.TypeSRV)
+
+	uc := dns.Client{Net: "udp"}
+	r, _, err := uc.ExchangeContext(ctx, q, addr)
+	require.NoError(t, err)
+	assert.True(t, r.Truncated)
+	assert.Less(t, len(r.Answer), srvCount)
+
+	tc := dns.Client{Net: "tcp"}
+	r, _, err = tc.ExchangeContext(ctx, q, addr)
+	require.NoError(t, err)
+	assert.False(t, r.Truncated)
+	assert.Len(t, r.Answer, srvCount)
+
+	// A client that announces a larger UDP size using EDNS0 gets the full response over UDP.
+	q.SetEdns0(dns.DefaultMsgSize, false)
+	r, _, err = uc.ExchangeContext(ctx, q, addr)
+	require.NoError(t, err)
+	assert.False(t, r.Truncated)
+	assert.Len(t, r.Answer, srvCount)
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
@@ -0,0 +1,92 @@
+package rootd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// domainClaims keeps track of the DNS domains that each session of the root daemon resolves, so that
+// sessions that are connected to different clusters never compete for the same domain. The session that
+// first claims a domain owns it until the session ends or no longer claims it.
+type domainClaims struct {
+	sync.Mutex
+	claims map[string]*domainClaim // keyed by session ID
+}
+
+type domainClaim struct {
+	name    string
+	domains []string
+}
+
+// domainConflict is a domain that is owned by another connection.
+type domainConflict struct {
+	domain string
+	owner  string
+}
+
+type domainClaimsKey struct{}
+
+func withDomainClaims(ctx context.Context, dc *domainClaims) context.Context {
+	return context.WithValue(ctx, domainClaimsKey{}, dc)
+}
+
+// getDomainClaims returns the domainClaims of the root daemon, or nil when the session isn't managed by
+// the root daemon.
+func getDomainClaims(ctx context.Context) *domainClaims {
+	if dc, ok := ctx.Value(domainClaimsKey{}).(*domainClaims); ok {
+		return dc
+	}
+	return nil
+}
+
+func newDomainClaims() *domainClaims {
+	return &domainClaims{claims: make(map[string]*domainClaim)}
+}
+
+// claim replaces the domains claimed by the session with the given ID with the given domains. Domains
+// that are claimed by another session are not claimed. They are instead returned as conflicts. All
+// domains are granted when dc is nil.
+func (dc *domainClaims) claim(id, name string, domains []string) (granted []string, conflicts []domainConflict) {
+	if dc == nil {
+		return domains, nil
+	}
+	dc.Lock()
+	defer dc.Unlock()
+
+	// Check other claims in a predictable order, so that the same owner is reported every time.
+	others := make([]*domainClaim, 0, len(dc.claims))
+	for cid, c := range dc.claims {
+		if cid != id {
+			others = append(others, c)
+		}
+	}
+	slices.SortFunc(others, func(a, b *domainClaim) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	granted = make([]string, 0, len(domains))
+nextDomain:
+	for _, d := range domains {
+		for _, o := range others {
+			if slices.Contains(o.domains, d) {
+				conflicts = append(conflicts, domainConflict{domain: d, owner: o.name})
+				continue nextDomain
+			}
+		}
+		granted = append(granted, d)
+	}
+	dc.claims[id] = &domainClaim{name: name, domains: granted}
+	return granted, conflicts
+}
+
+// release removes all domains claimed by the session with the given ID.
+func (dc *domainClaims) release(id string) {
+	if dc == nil {
+		return
+	}
+	dc.Lock()
+	delete(dc.claims, id)
+	dc.Unlock()
+}
@@ -0,0 +1,43 @@
+package rootd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainClaims(t *testing.T) {
+	dc := newDomainClaims()
+	granted, conflicts := dc.claim("a", "cluster-a", []string{"default", "svc"})
+	assert.Equal(t, []string{"default", "svc"}, granted)
+	assert.Empty(t, conflicts)
+
+	// The shared domain is owned by the first connection.
+	granted, conflicts = dc.claim("b", "cluster-b", []string{"staging", "svc"})
+	assert.Equal(t, []string{"staging"}, granted)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, domainConflict{domain: "svc", owner: "cluster-a"}, conflicts[0])
+
+	// A session's new claim replaces its old claim.
+	granted, conflicts = dc.claim("a", "cluster-a", []string{"default"})
+	assert.Equal(t, []string{"default"}, granted)
+	assert.Empty(t, conflicts)
+
+	// The domain is free once the first session no longer claims it.
+	granted, conflicts = dc.claim("b", "cluster-b", []string{"staging", "svc"})
+	assert.Equal(t, []string{"staging", "svc"}, granted)
+	assert.Empty(t, conflicts)
+
+	// Released domains are free too.
+	dc.release("b")
+	granted, conflicts = dc.claim("a", "cluster-a", []string{"default", "svc"})
+	assert.Equal(t, []string{"default", "svc"}, granted)
+	assert.Empty(t, conflicts)
+
+	// A nil domainClaims grants everything.
+	var nilDC *domainClaims
+	granted, conflicts = nilDC.claim("a", "cluster-a", []string{"svc"})
+	assert.Equal(t, []string{"svc"}, granted)
+	assert.Empty(t, conflicts)
+}
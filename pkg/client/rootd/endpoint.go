@@ -0,0 +1,46 @@
+package rootd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// resolveEndpoint resolves the given host:port of a UDP endpoint of the traffic-manager into an IPv4 address
+// and a port. The kind names the endpoint in errors.
+func resolveEndpoint(ctx context.Context, kind, endpoint string) (netip.AddrPort, error) {
+	host, ps, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid %s endpoint %q: %w", kind, endpoint, err)
+	}
+	port, err := strconv.ParseUint(ps, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid %s endpoint %q: %w", kind, endpoint, err)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		var addrs []netip.Addr
+		if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip4", host); err != nil {
+			return netip.AddrPort{}, fmt.Errorf("unable to resolve %s endpoint %q: %w", kind, endpoint, err)
+		}
+		if len(addrs) == 0 {
+			return netip.AddrPort{}, fmt.Errorf("unable to resolve %s endpoint %q", kind, endpoint)
+		}
+		addr = addrs[0]
+	}
+	return netip.AddrPortFrom(addr.Unmap(), uint16(port)), nil
+}
+
+// checkEndpoint returns an error if the given endpoint is in one of the given subnets that are routed to the
+// TUN-device, because the traffic to the endpoint would then be sent through the gRPC tunnel.
+func checkEndpoint(kind string, endpoint netip.AddrPort, routed []*net.IPNet) error {
+	ip := endpoint.Addr().AsSlice()
+	for _, sn := range routed {
+		if sn.Contains(ip) {
+			return fmt.Errorf("%s endpoint %s is in the subnet %s that is routed to the cluster", kind, endpoint, sn)
+		}
+	}
+	return nil
+}
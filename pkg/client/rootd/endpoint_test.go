@@ -0,0 +1,38 @@
+package rootd
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	ctx := context.Background()
+	ep, err := resolveEndpoint(ctx, "QUIC", "10.1.2.3:4433")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddrPort("10.1.2.3:4433"), ep)
+
+	ep, err = resolveEndpoint(ctx, "QUIC", "localhost:4433")
+	require.NoError(t, err)
+	assert.True(t, ep.Addr().Is4())
+	assert.Equal(t, uint16(4433), ep.Port())
+
+	_, err = resolveEndpoint(ctx, "QUIC", "10.1.2.3")
+	assert.Error(t, err)
+	_, err = resolveEndpoint(ctx, "QUIC", "10.1.2.3:70000")
+	assert.Error(t, err)
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	_, pods, _ := net.ParseCIDR("10.244.0.0/16")
+	_, svcs, _ := net.ParseCIDR("10.96.0.0/12")
+	routed := []*net.IPNet{pods, svcs}
+	assert.NoError(t, checkEndpoint("QUIC", netip.MustParseAddrPort("192.168.1.20:4433"), routed))
+	assert.ErrorContains(t, checkEndpoint("QUIC", netip.MustParseAddrPort("10.244.3.7:4433"), routed), "10.244.0.0/16")
+	assert.ErrorContains(t, checkEndpoint("QUIC", netip.MustParseAddrPort("10.100.0.1:4433"), routed), "10.96.0.0/12")
+	assert.NoError(t, checkEndpoint("QUIC", netip.MustParseAddrPort("10.244.3.7:4433"), nil))
+}
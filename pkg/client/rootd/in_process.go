@@ -19,7 +19,7 @@ import (
 // userdToManagerShortcut overcomes one minor problem, namely that even though a connector.ManagerProxyClient implements a subset
 // of the manager.ManagerClient interface, we cannot pass the real thing as the proxy. In the Go implementation, the interface returned
 // from a stream function is tightly coupled to the owner of that function and therefore have a different name in the proxy, even though
-// its methods are exactly the same. That's why the two affected functions are overridden here, seemingly doing nothing at all. They
+// its methods are exactly the same. That's why the affected functions are overridden here, seemingly doing nothing at all. They
 // make it possible to pass the manager.ManagerClient as a connector.ManagerProxyClient.
 type userdToManagerShortcut struct {
 	manager.ManagerClient
@@ -33,6 +33,10 @@ func (m *userdToManagerShortcut) Tunnel(ctx context.Context, opts ...grpc.CallOp
 	return m.ManagerClient.Tunnel(ctx, opts...)
 }
 
+func (m *userdToManagerShortcut) MuxTunnel(ctx context.Context, opts ...grpc.CallOption) (connector.ManagerProxy_MuxTunnelClient, error) {
+	return m.ManagerClient.MuxTunnel(ctx, opts...)
+}
+
 func (m *userdToManagerShortcut) RealManagerClient() manager.ManagerClient {
 	return m.ManagerClient
 }
@@ -60,8 +64,11 @@ func (rd *InProcSession) Status(context.Context, *empty.Empty, ...grpc.CallOptio
 			Version:    client.Version(),
 			Name:       client.DisplayName,
 		},
-		Subnets:        nc.Subnets,
-		OutboundConfig: nc.OutboundInfo,
+		Subnets:           nc.Subnets,
+		OutboundConfig:    nc.OutboundInfo,
+		DnsCacheStats:     rd.dnsServer.GetCacheStats(),
+		Connections:       []*rpc.ConnectionStatus{rd.getConnectionStatus()},
+		ConnectionQuality: rd.getConnectionQuality(),
 	}, nil
 }
 
@@ -98,11 +105,35 @@ func (rd *InProcSession) SetDNSMappings(ctx context.Context, in *rpc.SetDNSMappi
 	return &empty.Empty{}, nil
 }
 
+func (rd *InProcSession) SetProxySubnets(ctx context.Context, in *rpc.SetProxySubnetsRequest, _ ...grpc.CallOption) (*empty.Empty, error) {
+	if err := rd.Session.SetProxySubnets(ctx, in.AlsoProxySubnets, in.NeverProxySubnets); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+func (rd *InProcSession) RefreshRoutes(ctx context.Context, _ *empty.Empty, _ ...grpc.CallOption) (*empty.Empty, error) {
+	if err := rd.Session.RefreshRoutes(ctx); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+func (rd *InProcSession) FlushDNSCache(ctx context.Context, _ *empty.Empty, _ ...grpc.CallOption) (*empty.Empty, error) {
+	rd.Session.FlushDNSCache(ctx)
+	return &empty.Empty{}, nil
+}
+
 func (rd *InProcSession) SetLogLevel(context.Context, *manager.LogLevelRequest, ...grpc.CallOption) (*empty.Empty, error) {
 	// No loglevel when session runs in the same process as the user daemon.
 	return &empty.Empty{}, nil
 }
 
+func (rd *InProcSession) Profiling(context.Context, *rpc.ProfilingRequest, ...grpc.CallOption) (*rpc.ProfilingInfo, error) {
+	// The user daemon's debug HTTP listener serves the session when it runs in the same process.
+	return &rpc.ProfilingInfo{}, nil
+}
+
 func (rd *InProcSession) WaitForNetwork(ctx context.Context, _ *empty.Empty, _ ...grpc.CallOption) (*empty.Empty, error) {
 	if err, ok := <-rd.networkReady(ctx); ok {
 		return &empty.Empty{}, status.Error(codes.Unavailable, err.Error())
@@ -131,3 +162,28 @@ func NewInProcSession(
 	}
 	return &InProcSession{Session: session, cancel: cancel}, nil
 }
+
+// NewUserSpaceSession returns a root daemon session that runs in-process, just like the one returned by
+// NewInProcSession, but handles all networking in user space. It requires no privileges. Instead of
+// configuring a TUN device and the DNS of the host, it provides access to the cluster through a SOCKS5
+// proxy and an HTTP proxy that listen to the given addresses. A proxy is not started when its address is
+// empty.
+func NewUserSpaceSession(
+	ctx context.Context,
+	mi *rpc.OutboundInfo,
+	mc manager.ManagerClient,
+	ver semver.Version,
+	socksAddress string,
+	httpProxyAddress string,
+) (*InProcSession, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	session, err := newSession(ctx, mi, &userdToManagerShortcut{mc}, ver, false)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	session.userSpace = true
+	session.socksAddress = socksAddress
+	session.httpProxyAddress = httpProxyAddress
+	return &InProcSession{Session: session, cancel: cancel}, nil
+}
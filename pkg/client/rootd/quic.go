@@ -0,0 +1,59 @@
+package rootd
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// quicDialTimeout is the time to wait for the QUIC connection to the traffic-manager to be established.
+const quicDialTimeout = 5 * time.Second
+
+// startQUIC connects to the QUIC endpoint of the traffic-manager when cluster.quic is enabled. The tunnels to
+// the traffic-manager are then opened as streams of that connection. The gRPC tunnels are used instead when the
+// connection can't be established. The given subnets are the subnets that are routed to the TUN-device.
+func (s *Session) startQUIC(ctx context.Context, routed []*net.IPNet) {
+	if !client.GetConfig(ctx).Cluster().QUIC {
+		return
+	}
+	conn, err := s.connectQUIC(ctx, routed)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			dlog.Info(ctx, "QUIC is not enabled in the traffic-manager. Using gRPC tunnels")
+		} else {
+			dlog.Errorf(ctx, "Unable to use QUIC. Using gRPC tunnels: %v", err)
+		}
+		return
+	}
+	s.quicConn = conn
+}
+
+func (s *Session) connectQUIC(ctx context.Context, routed []*net.IPNet) (quic.Connection, error) {
+	rsp, err := s.managerClient.QUICEndpoint(ctx, s.session)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := resolveEndpoint(ctx, "QUIC", rsp.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkEndpoint("QUIC", endpoint, routed); err != nil {
+		return nil, err
+	}
+	dc, cancel := context.WithTimeout(ctx, quicDialTimeout)
+	defer cancel()
+	conn, err := tunnel.DialQUIC(dc, endpoint.String(), rsp.CertFingerprint, rsp.Token)
+	if err != nil {
+		return nil, err
+	}
+	dlog.Infof(ctx, "Tunnels to the traffic-manager are carried by a QUIC connection to %s", endpoint)
+	return conn, nil
+}
@@ -0,0 +1,98 @@
+package rootd
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/subnet"
+)
+
+// routeClaims keeps track of the cluster subnets that each session of the root daemon routes, so that
+// sessions that are connected to different clusters never route overlapping subnets. The session that
+// first claims a subnet owns it until the session ends or no longer claims it.
+type routeClaims struct {
+	sync.Mutex
+	claims map[string]*routeClaim // keyed by session ID
+}
+
+type routeClaim struct {
+	name    string
+	subnets []*net.IPNet
+}
+
+// connectionConflict is a subnet that overlaps with a subnet that is owned by another connection.
+type connectionConflict struct {
+	subnet      *net.IPNet
+	ownerSubnet *net.IPNet
+	owner       string
+}
+
+type routeClaimsKey struct{}
+
+func withRouteClaims(ctx context.Context, rc *routeClaims) context.Context {
+	return context.WithValue(ctx, routeClaimsKey{}, rc)
+}
+
+// getRouteClaims returns the routeClaims of the root daemon, or nil when the session isn't managed by
+// the root daemon.
+func getRouteClaims(ctx context.Context) *routeClaims {
+	if rc, ok := ctx.Value(routeClaimsKey{}).(*routeClaims); ok {
+		return rc
+	}
+	return nil
+}
+
+func newRouteClaims() *routeClaims {
+	return &routeClaims{claims: make(map[string]*routeClaim)}
+}
+
+// claim replaces the subnets claimed by the session with the given ID with the given subnets. Subnets
+// that overlap with a subnet claimed by another session are not claimed. They are instead returned as
+// conflicts. All subnets are granted when rc is nil.
+func (rc *routeClaims) claim(id, name string, subnets []*net.IPNet) (granted []*net.IPNet, conflicts []connectionConflict) {
+	if rc == nil {
+		return subnets, nil
+	}
+	rc.Lock()
+	defer rc.Unlock()
+
+	// Check other claims in a predictable order, so that the same owner is reported every time.
+	others := make([]*routeClaim, 0, len(rc.claims))
+	for cid, c := range rc.claims {
+		if cid != id {
+			others = append(others, c)
+		}
+	}
+	slices.SortFunc(others, func(a, b *routeClaim) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	granted = make([]*net.IPNet, 0, len(subnets))
+nextSubnet:
+	for _, sn := range subnets {
+		for _, o := range others {
+			for _, osn := range o.subnets {
+				if subnet.Overlaps(sn, osn) {
+					conflicts = append(conflicts, connectionConflict{subnet: sn, ownerSubnet: osn, owner: o.name})
+					continue nextSubnet
+				}
+			}
+		}
+		granted = append(granted, sn)
+	}
+	rc.claims[id] = &routeClaim{name: name, subnets: granted}
+	return granted, conflicts
+}
+
+// release removes all subnets claimed by the session with the given ID.
+func (rc *routeClaims) release(id string) {
+	if rc == nil {
+		return
+	}
+	rc.Lock()
+	delete(rc.claims, id)
+	rc.Unlock()
+}
@@ -0,0 +1,49 @@
+package rootd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteClaims(t *testing.T) {
+	cidr := func(s string) *net.IPNet {
+		_, sn, err := net.ParseCIDR(s)
+		require.NoError(t, err)
+		return sn
+	}
+	svcA := cidr("10.96.0.0/16")
+	podA := cidr("10.244.0.0/16")
+	svcB := cidr("10.97.0.0/16")
+	podB := cidr("10.244.1.0/24")
+
+	rc := newRouteClaims()
+	granted, conflicts := rc.claim("a", "cluster-a", []*net.IPNet{svcA, podA})
+	assert.Equal(t, []*net.IPNet{svcA, podA}, granted)
+	assert.Empty(t, conflicts)
+
+	// The overlapping pod subnet is owned by the first connection.
+	granted, conflicts = rc.claim("b", "cluster-b", []*net.IPNet{svcB, podB})
+	assert.Equal(t, []*net.IPNet{svcB}, granted)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, connectionConflict{subnet: podB, ownerSubnet: podA, owner: "cluster-a"}, conflicts[0])
+
+	// A session's new claim replaces its old claim.
+	granted, conflicts = rc.claim("a", "cluster-a", []*net.IPNet{svcA, podA})
+	assert.Equal(t, []*net.IPNet{svcA, podA}, granted)
+	assert.Empty(t, conflicts)
+
+	// The pod subnet is free once the first session releases it.
+	rc.release("a")
+	granted, conflicts = rc.claim("b", "cluster-b", []*net.IPNet{svcB, podB})
+	assert.Equal(t, []*net.IPNet{svcB, podB}, granted)
+	assert.Empty(t, conflicts)
+
+	// A nil routeClaims grants everything.
+	var nilRC *routeClaims
+	granted, conflicts = nilRC.claim("a", "cluster-a", []*net.IPNet{podA})
+	assert.Equal(t, []*net.IPNet{podA}, granted)
+	assert.Empty(t, conflicts)
+}
@@ -6,6 +6,8 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -77,18 +79,34 @@ type sessionReply struct {
 	err    error
 }
 
+// rootSession is a session that the root daemon maintains on behalf of one connection.
+type rootSession struct {
+	*Session
+	ctx      context.Context
+	cancel   context.CancelFunc
+	quitting int32 // atomic boolean. True if non-zero.
+}
+
 // Service represents the state of the Telepresence Daemon.
 type Service struct {
 	rpc.UnsafeDaemonServer
-	quit            context.CancelFunc
-	connectCh       chan *rpc.OutboundInfo
-	connectReplyCh  chan sessionReply
-	sessionLock     sync.RWMutex
-	sessionCancel   context.CancelFunc
-	sessionContext  context.Context
-	sessionQuitting int32 // atomic boolean. True if non-zero.
-	session         *Session
-	timedLogLevel   log.TimedLevel
+	quit           context.CancelFunc
+	connectCh      chan *rpc.OutboundInfo
+	connectReplyCh chan sessionReply
+	sessionLock    sync.RWMutex
+
+	// sessions are keyed by session ID. There's one session for each connection to a cluster.
+	sessions map[string]*rootSession
+
+	// routeClaims arbitrates between sessions that want to route overlapping subnets.
+	routeClaims *routeClaims
+
+	// domainClaims arbitrates between sessions that want to resolve the same DNS domains.
+	domainClaims  *domainClaims
+	timedLogLevel log.TimedLevel
+
+	// profiler is the debug HTTP listener that serves pprof profiles, goroutine dumps, and runtime metrics.
+	profiler pprof.Server
 }
 
 func NewService(cfg client.Config) *Service {
@@ -96,6 +114,9 @@ func NewService(cfg client.Config) *Service {
 		timedLogLevel:  log.NewTimedLevel(cfg.LogLevels().RootDaemon.String(), log.SetLevel),
 		connectCh:      make(chan *rpc.OutboundInfo),
 		connectReplyCh: make(chan sessionReply),
+		sessions:       make(map[string]*rootSession),
+		routeClaims:    newRouteClaims(),
+		domainClaims:   newDomainClaims(),
 	}
 }
 
@@ -110,9 +131,9 @@ func (s *Service) As(ptr any) {
 // Command returns the telepresence sub-command "daemon-foreground".
 func Command() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    ProcessName + "-foreground <logging dir> <config dir>",
+		Use:    ProcessName + "-foreground <logging dir> <config dir> [<cache dir>]",
 		Short:  "Launch Telepresence " + titleName + " in the foreground (debug)",
-		Args:   cobra.ExactArgs(2),
+		Args:   cobra.RangeArgs(2, 3),
 		Hidden: true,
 		Long:   help(),
 		RunE:   run,
@@ -131,7 +152,7 @@ func (s *Service) Version(_ context.Context, _ *emptypb.Empty) (*common.VersionI
 	}, nil
 }
 
-func (s *Service) Status(_ context.Context, _ *emptypb.Empty) (*rpc.DaemonStatus, error) {
+func (s *Service) Status(ctx context.Context, _ *emptypb.Empty) (*rpc.DaemonStatus, error) {
 	s.sessionLock.RLock()
 	defer s.sessionLock.RUnlock()
 	r := &rpc.DaemonStatus{
@@ -141,10 +162,16 @@ func (s *Service) Status(_ context.Context, _ *emptypb.Empty) (*rpc.DaemonStatus
 			Name:       client.DisplayName,
 		},
 	}
-	if s.session != nil {
-		nc := s.session.getNetworkConfig()
+	if rs, err := s.callerSessionReadLocked(ctx); err == nil {
+		nc := rs.getNetworkConfig()
 		r.Subnets = nc.Subnets
 		r.OutboundConfig = nc.OutboundInfo
+		r.SubnetConflicts = rs.getSubnetConflicts()
+		r.DnsCacheStats = rs.dnsServer.GetCacheStats()
+		r.ConnectionQuality = rs.getConnectionQuality()
+	}
+	for _, rs := range s.sortedSessionsReadLocked() {
+		r.Connections = append(r.Connections, rs.getConnectionStatus())
 	}
 	return r, nil
 }
@@ -160,13 +187,15 @@ func (s *Service) Quit(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, e
 		}
 	}
 	defer s.sessionLock.RUnlock()
-	s.cancelSessionReadLocked()
+	for _, rs := range s.sessions {
+		rs.cancel()
+	}
 	s.quit()
 	return &emptypb.Empty{}, nil
 }
 
 func (s *Service) SetDNSTopLevelDomains(ctx context.Context, domains *rpc.Domains) (*emptypb.Empty, error) {
-	err := s.WithSession(func(ctx context.Context, session *Session) error {
+	err := s.WithSession(ctx, func(ctx context.Context, session *Session) error {
 		session.SetTopLevelDomains(ctx, domains.Domains)
 		return nil
 	})
@@ -174,7 +203,7 @@ func (s *Service) SetDNSTopLevelDomains(ctx context.Context, domains *rpc.Domain
 }
 
 func (s *Service) SetDNSExcludes(ctx context.Context, req *rpc.SetDNSExcludesRequest) (*emptypb.Empty, error) {
-	err := s.WithSession(func(c context.Context, session *Session) error {
+	err := s.WithSession(ctx, func(c context.Context, session *Session) error {
 		session.SetExcludes(c, req.Excludes)
 		return nil
 	})
@@ -182,13 +211,35 @@ func (s *Service) SetDNSExcludes(ctx context.Context, req *rpc.SetDNSExcludesReq
 }
 
 func (s *Service) SetDNSMappings(ctx context.Context, req *rpc.SetDNSMappingsRequest) (*emptypb.Empty, error) {
-	err := s.WithSession(func(c context.Context, session *Session) error {
+	err := s.WithSession(ctx, func(c context.Context, session *Session) error {
 		session.SetMappings(c, req.Mappings)
 		return nil
 	})
 	return &emptypb.Empty{}, err
 }
 
+func (s *Service) SetProxySubnets(ctx context.Context, req *rpc.SetProxySubnetsRequest) (*emptypb.Empty, error) {
+	err := s.WithSession(ctx, func(c context.Context, session *Session) error {
+		return session.SetProxySubnets(c, req.AlsoProxySubnets, req.NeverProxySubnets)
+	})
+	return &emptypb.Empty{}, err
+}
+
+func (s *Service) RefreshRoutes(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	err := s.WithSession(ctx, func(c context.Context, session *Session) error {
+		return session.RefreshRoutes(c)
+	})
+	return &emptypb.Empty{}, err
+}
+
+func (s *Service) FlushDNSCache(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	err := s.WithSession(ctx, func(c context.Context, session *Session) error {
+		session.FlushDNSCache(c)
+		return nil
+	})
+	return &emptypb.Empty{}, err
+}
+
 func (s *Service) Connect(ctx context.Context, info *rpc.OutboundInfo) (*rpc.DaemonStatus, error) {
 	dlog.Debug(ctx, "Received gRPC Connect")
 	select {
@@ -213,14 +264,19 @@ func (s *Service) Connect(ctx context.Context, info *rpc.OutboundInfo) (*rpc.Dae
 	}
 }
 
+// Disconnect ends the session that the caller identifies, or all sessions when the caller doesn't
+// identify a session.
 func (s *Service) Disconnect(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
 	dlog.Debug(ctx, "Received gRPC Disconnect")
-	s.cancelSession()
+	id := sessionIDFromContext(ctx)
+	s.cancelSessions(func(rs *rootSession) bool {
+		return id == "" || rs.session.SessionId == id
+	})
 	return &emptypb.Empty{}, nil
 }
 
 func (s *Service) WaitForNetwork(ctx context.Context, e *emptypb.Empty) (*emptypb.Empty, error) {
-	err := s.WithSession(func(ctx context.Context, session *Session) error {
+	err := s.WithSession(ctx, func(ctx context.Context, session *Session) error {
 		if err, ok := <-session.networkReady(ctx); ok {
 			return status.Error(codes.Unavailable, err.Error())
 		}
@@ -229,50 +285,98 @@ func (s *Service) WaitForNetwork(ctx context.Context, e *emptypb.Empty) (*emptyp
 	return &emptypb.Empty{}, err
 }
 
-func (s *Service) cancelSessionReadLocked() {
-	if s.sessionCancel != nil {
-		s.sessionCancel()
+// cancelSessions cancels the sessions that the given function returns true for, waits for them to end,
+// and then removes them.
+func (s *Service) cancelSessions(match func(*rootSession) bool) {
+	var rss []*rootSession
+	s.sessionLock.RLock()
+	for _, rs := range s.sessions {
+		if match(rs) && atomic.CompareAndSwapInt32(&rs.quitting, 0, 1) {
+			rss = append(rss, rs)
+		}
 	}
-}
-
-func (s *Service) cancelSession() {
-	if !atomic.CompareAndSwapInt32(&s.sessionQuitting, 0, 1) {
-		return
+	for _, rs := range rss {
+		rs.cancel()
 	}
-	s.sessionLock.RLock()
-	s.cancelSessionReadLocked()
 	s.sessionLock.RUnlock()
+	if len(rss) == 0 {
+		return
+	}
 
 	s.sessionLock.Lock()
-	s.session = nil
-	s.sessionCancel = nil
-	atomic.StoreInt32(&s.sessionQuitting, 0)
+	for _, rs := range rss {
+		s.removeSessionLocked(rs)
+	}
 	s.sessionLock.Unlock()
 }
 
-func (s *Service) WithSession(f func(context.Context, *Session) error) error {
-	if atomic.LoadInt32(&s.sessionQuitting) != 0 {
-		return status.Error(codes.Canceled, "session cancelled")
+// removeSessionLocked removes the given session unless it has already been removed.
+func (s *Service) removeSessionLocked(rs *rootSession) {
+	id := rs.session.SessionId
+	if s.sessions[id] == rs {
+		delete(s.sessions, id)
+		s.routeClaims.release(id)
+		s.domainClaims.release(id)
+	}
+}
+
+// callerSessionReadLocked returns the session that the caller identifies using gRPC metadata. A caller
+// that doesn't identify its session gets the only session.
+func (s *Service) callerSessionReadLocked(ctx context.Context) (*rootSession, error) {
+	if id := sessionIDFromContext(ctx); id != "" {
+		if rs, ok := s.sessions[id]; ok {
+			return rs, nil
+		}
+		return nil, status.Error(codes.Unavailable, "no active session")
+	}
+	switch len(s.sessions) {
+	case 0:
+		return nil, status.Error(codes.Unavailable, "no active session")
+	case 1:
+		for _, rs := range s.sessions {
+			return rs, nil
+		}
+	}
+	return nil, status.Error(codes.FailedPrecondition, "multiple sessions are active and the caller didn't identify its session")
+}
+
+// sortedSessionsReadLocked returns all sessions, sorted by name.
+func (s *Service) sortedSessionsReadLocked() []*rootSession {
+	rss := make([]*rootSession, 0, len(s.sessions))
+	for _, rs := range s.sessions {
+		rss = append(rss, rs)
 	}
+	slices.SortFunc(rss, func(a, b *rootSession) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return rss
+}
+
+// WithSession calls the given function with the session that the caller identifies.
+func (s *Service) WithSession(ctx context.Context, f func(context.Context, *Session) error) error {
 	s.sessionLock.RLock()
 	defer s.sessionLock.RUnlock()
-	if s.session == nil {
-		return status.Error(codes.Unavailable, "no active session")
+	rs, err := s.callerSessionReadLocked(ctx)
+	if err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&rs.quitting) != 0 {
+		return status.Error(codes.Canceled, "session cancelled")
 	}
-	return f(s.sessionContext, s.session)
+	return f(rs.ctx, rs.Session)
 }
 
 func (s *Service) GetNetworkConfig(ctx context.Context, e *emptypb.Empty) (nc *rpc.NetworkConfig, err error) {
-	err = s.WithSession(func(ctx context.Context, session *Session) error {
+	err = s.WithSession(ctx, func(ctx context.Context, session *Session) error {
 		nc = session.getNetworkConfig()
 		return nil
 	})
-	dlog.Debugf(ctx, "Returning session %v", nc.OutboundInfo.Session)
+	dlog.Debugf(ctx, "Returning session %v", nc.GetOutboundInfo().GetSession())
 	return
 }
 
 func (s *Service) WaitForAgentIP(ctx context.Context, request *rpc.WaitForAgentIPRequest) (*emptypb.Empty, error) {
-	err := s.WithSession(func(ctx context.Context, session *Session) error {
+	err := s.WithSession(ctx, func(ctx context.Context, session *Session) error {
 		_, err := session.waitForAgentIP(ctx, request)
 		return err
 	})
@@ -290,14 +394,27 @@ func (s *Service) SetLogLevel(ctx context.Context, request *manager.LogLevelRequ
 	return &emptypb.Empty{}, logging.SetAndStoreTimedLevel(ctx, s.timedLogLevel, request.LogLevel, duration, ProcessName)
 }
 
+func (s *Service) Profiling(_ context.Context, request *rpc.ProfilingRequest) (*rpc.ProfilingInfo, error) {
+	addr, err := s.profiler.Start(0, request.Duration.AsDuration())
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.ProfilingInfo{Address: addr}, nil
+}
+
 func (s *Service) configReload(c context.Context) error {
 	return client.Watch(c, func(c context.Context) error {
 		s.sessionLock.RLock()
 		defer s.sessionLock.RUnlock()
-		if s.session == nil {
+		if len(s.sessions) == 0 {
 			return client.RestoreDefaults(c, true)
 		}
-		return s.session.applyConfig(c)
+		for _, rs := range s.sessions {
+			if err := rs.applyConfig(c); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -325,7 +442,10 @@ func (s *Service) manageSessions(c context.Context) error {
 			default:
 				// Nobody left to read the response? That's fine really. Just means that
 				// whoever wanted to start the session terminated early.
-				s.cancelSession()
+				id := oi.GetSession().GetSessionId()
+				s.cancelSessions(func(rs *rootSession) bool {
+					return rs.session.SessionId == id
+				})
 			}
 		}
 	}
@@ -342,13 +462,24 @@ func (s *Service) startSession(ctx context.Context, oi *rpc.OutboundInfo, wg *sy
 			},
 		},
 	}
-	if s.session != nil {
-		reply.status.OutboundConfig = s.session.getNetworkConfig().OutboundInfo
+	id := oi.GetSession().GetSessionId()
+	if rs, ok := s.sessions[id]; ok {
+		reply.status.OutboundConfig = rs.getNetworkConfig().OutboundInfo
 		dlog.Debugf(ctx, "Returning session %v from existing session", reply.status.OutboundConfig.Session)
 		return reply
 	}
+	if name := oi.ConnectionName; name != "" {
+		// A session with the same name belongs to a connection that ended without disconnecting.
+		for _, rs := range s.sessions {
+			if rs.name == name && atomic.CompareAndSwapInt32(&rs.quitting, 0, 1) {
+				dlog.Infof(ctx, "Replacing session %s of connection %q", rs.session.SessionId, name)
+				rs.cancel()
+				s.removeSessionLocked(rs)
+			}
+		}
+	}
 
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := context.WithCancel(withDomainClaims(withRouteClaims(ctx, s.routeClaims), s.domainClaims))
 	ctx, session, err := GetNewSessionFunc(ctx)(ctx, oi)
 	if session == nil || ctx.Err() != nil || err != nil {
 		cancel()
@@ -360,17 +491,20 @@ func (s *Service) startSession(ctx context.Context, oi *rpc.OutboundInfo, wg *sy
 		return reply
 	}
 
-	s.session = session
-	s.sessionContext = ctx
-	s.sessionCancel = func() {
-		cancel()
-		<-session.Done()
+	rs := &rootSession{
+		Session: session,
+		ctx:     ctx,
+		cancel: func() {
+			cancel()
+			<-session.Done()
+		},
 	}
-	if err := s.session.applyConfig(ctx); err != nil {
+	s.sessions[id] = rs
+	if err := session.applyConfig(ctx); err != nil {
 		dlog.Warnf(ctx, "failed to apply config from traffic-manager: %v", err)
 	}
 
-	reply.status.OutboundConfig = s.session.getNetworkConfig().OutboundInfo
+	reply.status.OutboundConfig = session.getNetworkConfig().OutboundInfo
 	dlog.Debugf(ctx, "Returning session from new session %v", reply.status.OutboundConfig.Session)
 
 	initErrCh := make(chan error, 1)
@@ -386,15 +520,16 @@ func (s *Service) startSession(ctx context.Context, oi *rpc.OutboundInfo, wg *sy
 				dlog.Errorf(ctx, "%+v", derror.PanicToError(r))
 			}
 			s.sessionLock.Lock()
-			s.session = nil
-			s.sessionCancel = nil
-			if err := client.RestoreDefaults(ctx, true); err != nil {
-				dlog.Warn(ctx, err)
+			s.removeSessionLocked(rs)
+			if len(s.sessions) == 0 {
+				if err := client.RestoreDefaults(ctx, true); err != nil {
+					dlog.Warn(ctx, err)
+				}
 			}
 			s.sessionLock.Unlock()
 			wg.Done()
 		}()
-		if err := s.session.run(s.sessionContext, initErrCh); err != nil {
+		if err := session.run(ctx, initErrCh); err != nil {
 			dlog.Error(ctx, err)
 		}
 	}()
@@ -403,7 +538,9 @@ func (s *Service) startSession(ctx context.Context, oi *rpc.OutboundInfo, wg *sy
 	case err := <-initErrCh:
 		if err != nil {
 			reply.err = err
-			s.cancelSessionReadLocked()
+			if atomic.CompareAndSwapInt32(&rs.quitting, 0, 1) {
+				rs.cancel()
+			}
 		}
 	}
 	return reply
@@ -451,10 +588,13 @@ func run(cmd *cobra.Command, args []string) error {
 	configDir := args[1]
 	c := cmd.Context()
 
-	// Spoof the AppUserLogDir and AppUserConfigDir so that they return the original user's
-	// directories rather than directories for the root user.
+	// Spoof the AppUserLogDir, AppUserConfigDir, and AppUserCacheDir so that they return the original
+	// user's directories rather than directories for the root user.
 	c = filelocation.WithAppUserLogDir(c, loggingDir)
 	c = filelocation.WithAppUserConfigDir(c, configDir)
+	if len(args) > 2 {
+		c = filelocation.WithAppUserCacheDir(c, args[2])
+	}
 
 	cfg, err := client.LoadConfig(c)
 	if err != nil {
@@ -462,13 +602,7 @@ func run(cmd *cobra.Command, args []string) error {
 	}
 	c = client.WithConfig(c, cfg)
 	flags := cmd.Flags()
-	if pprofPort, _ := flags.GetUint16(pprofFlag); pprofPort > 0 {
-		go func() {
-			if err := pprof.PprofServer(c, pprofPort); err != nil {
-				dlog.Error(c, err)
-			}
-		}()
-	}
+	pprofPort, _ := flags.GetUint16(pprofFlag)
 	if disableMetriton, _ := flags.GetBool(metritonDisableFlag); disableMetriton {
 		_ = os.Setenv("SCOUT_DISABLE", "1")
 	}
@@ -479,10 +613,16 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	tracer, err := tracing.NewTraceServer(c, "root-daemon")
+	tracer, err := tracing.NewTraceServer(tracing.WithExporter(c, cfg.Tracing().Exporter()), "root-daemon")
 	if err != nil {
 		return err
 	}
+	defer func() {
+		// Flush the spans that remain to be exported.
+		c, cancel := context.WithTimeout(context.WithoutCancel(c), time.Second)
+		tracer.Shutdown(c)
+		cancel()
+	}()
 
 	dlog.Info(c, "---")
 	dlog.Infof(c, "Telepresence %s %s starting...", ProcessName, client.DisplayVersion())
@@ -515,6 +655,14 @@ func run(cmd *cobra.Command, args []string) error {
 	})
 
 	// Add a reload function that triggers on create and write of the config.yml file.
+	g.Go("profiling", func(c context.Context) error {
+		pc := cfg.Profiling()
+		port := pprofPort
+		if port == 0 {
+			port = pc.RootDaemonPort()
+		}
+		return d.profiler.Run(c, pprofPort > 0 || pc.Enabled(), port)
+	})
 	g.Go("config-reload", d.configReload)
 	g.Go("session", d.manageSessions)
 	g.Go("server-grpc", func(c context.Context) error { return d.serveGrpc(c, grpcListener, tracer) })
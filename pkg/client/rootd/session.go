@@ -20,6 +20,7 @@ import (
 	"github.com/blang/semver/v4"
 	dns2 "github.com/miekg/dns"
 	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/quic-go/quic-go"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -28,6 +29,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 	"gopkg.in/yaml.v3"
 	"k8s.io/client-go/kubernetes"
@@ -40,6 +42,7 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/agentpf"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
@@ -55,13 +58,21 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/subnet"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+	"github.com/telepresenceio/telepresence/v2/pkg/wireguard"
 )
 
+// throughputSampleInterval is the interval used when sampling the counters of the tunnel throughput.
+const throughputSampleInterval = 5 * time.Second
+
+// agentSubnet is a subnet whose IPs are translated to virtual IPs. The workload is empty when the subnet
+// is remapped because it conflicts with a route on the host, in which case the traffic-manager is used.
 type agentSubnet struct {
 	net.IPNet
 	workload string
 }
 
+// agentVIP is the destination of a virtual IP. The workload is empty when the destination is reached
+// using the traffic-manager.
 type agentVIP struct {
 	workload      string
 	destinationIP net.IP
@@ -120,8 +131,8 @@ type Session struct {
 	// dnsLocalAddr is address of the local DNS Service.
 	dnsLocalAddr *net.UDPAddr
 
-	// serviceSubnet reported by the traffic-manager
-	serviceSubnet *net.IPNet
+	// serviceSubnets reported by the traffic-manager. One for each IP family in a dual-stack cluster.
+	serviceSubnets []*net.IPNet
 
 	// podSubnets reported by the traffic-manager
 	podSubnets []*net.IPNet
@@ -135,6 +146,31 @@ type Session struct {
 	// Subnets that will be mapped even if they conflict with local routes
 	allowConflictingSubnets []*net.IPNet
 
+	// routingLock serializes updates of the routed subnets.
+	routingLock sync.Mutex
+
+	// clusterInfo is the most recent cluster info that was received from the traffic-manager.
+	clusterInfo *manager.ClusterInfo
+
+	// Destination ports configured by the user to never be proxied
+	proxyExcludePorts []agentconfig.PortAndProto
+
+	// Artificial latency, jitter, and packet loss applied to the packets that are sent to the cluster
+	chaos *rpc.TunnelChaos
+
+	// quicConn carries the tunnels to the traffic-manager instead of gRPC calls, when enabled
+	quicConn quic.Connection
+
+	// wireGuard carries the traffic destined for the traffic-manager instead of gRPC tunnels, when enabled
+	wireGuard *wireguard.Device
+
+	// tunnelThroughput measures the bytes that are sent and received by the tunnels to the cluster
+	tunnelThroughput *tunnel.Throughput
+
+	// pfDialer is the dialer of the port-forward to the traffic-manager, or nil when the user daemon
+	// establishes that port-forward
+	pfDialer dnet.PortForwardDialer
+
 	// localTranslationTable maps an IP returned by the cluster's DNS to a virtual IP created by this server.
 	localTranslationTable *xsync.MapOf[iputil.IPKey, net.IP]
 
@@ -148,6 +184,22 @@ type Session struct {
 	// vipGenerator generates virtual IPs for a given range.
 	vipGenerator vip.Generator
 
+	// Whether the IPs that the names of ExternalName services resolve to should be translated to virtual
+	// IPs, so that they are reached using the traffic-manager.
+	proxyExternalNames bool
+
+	// Whether cluster subnets that conflict with routes on the host should be translated to virtual IPs
+	// instead of being routed.
+	remapConflicting bool
+
+	// Cluster subnets that conflict with routes on the host, and the subnets among them that are remapped.
+	conflictsLock   sync.Mutex
+	subnetConflicts []vif.Conflict
+	remappedSubnets []*net.IPNet
+
+	// Cluster subnets that aren't routed because they overlap with subnets of another connection.
+	connConflicts []connectionConflict
+
 	// closing is set during shutdown and can have the values:
 	//   0 = running
 	//   1 = closing
@@ -157,6 +209,9 @@ type Session struct {
 	// session contains the manager session
 	session *manager.SessionInfo
 
+	// name of the connection that the session belongs to
+	name string
+
 	// rndSource is the source for the random number generator in the TCP handlers
 	rndSource rand.Source
 
@@ -197,6 +252,19 @@ type Session struct {
 
 	// daemon runs as part of a pod-daemon setup.
 	podDaemon bool
+
+	// userSpace is true when the session handles all networking in user space. The session will then use
+	// a user space device instead of a TUN device, leave the DNS configuration of the host untouched, and
+	// provide access to the cluster using the SOCKS5 and HTTP proxies.
+	userSpace bool
+
+	// socksAddress is the address of an optional SOCKS5 proxy that provides access to the cluster. It is
+	// only used when the session handles all networking in user space.
+	socksAddress string
+
+	// httpProxyAddress is the address of an optional HTTP proxy that provides access to the cluster. It
+	// is only used when the session handles all networking in user space.
+	httpProxyAddress string
 }
 
 type NewSessionFunc func(context.Context, *rpc.OutboundInfo) (context.Context, *Session, error)
@@ -229,6 +297,7 @@ func createK8sConfig(ctx context.Context, kubeFlags map[string]string, kubeData
 // connectToManager connects to the traffic-manager and asserts that its version is compatible.
 func connectToManager(
 	ctx context.Context,
+	connectionName string,
 	namespace string,
 	kubeFlags map[string]string,
 	kubeData []byte,
@@ -252,7 +321,7 @@ func connectToManager(
 
 	clientConfig := client.GetConfig(ctx)
 	if !clientConfig.Cluster().ConnectFromRootDaemon {
-		conn, mp, v, err := connectToUserDaemon(ctx)
+		conn, mp, v, err := connectToUserDaemon(ctx, connectionName)
 		return ctx, conn, mp, v, err
 	}
 
@@ -281,8 +350,9 @@ func connectToManager(
 
 // connectToUserDaemon is like connectToManager but the port-forward will be established from the user-daemon
 // instead. This doesn't matter when the daemon is containerized, but it will introduce an extra hop for all
-// outgoing traffic when it isn't.
-func connectToUserDaemon(c context.Context) (*grpc.ClientConn, connector.ManagerProxyClient, semver.Version, error) {
+// outgoing traffic when it isn't. The user daemon can maintain several connections, so all calls identify the
+// connection with the given name.
+func connectToUserDaemon(c context.Context, connectionName string) (*grpc.ClientConn, connector.ManagerProxyClient, semver.Version, error) {
 	// First check. Establish connection
 	tos := client.GetConfig(c).Timeouts()
 	tc, cancel := tos.TimeoutContext(c, client.TimeoutTrafficManagerAPI)
@@ -290,7 +360,7 @@ func connectToUserDaemon(c context.Context) (*grpc.ClientConn, connector.Manager
 
 	var conn *grpc.ClientConn
 	conn, err := socket.Dial(tc, socket.UserDaemonPath(c), true,
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		append(client.ConnectionNameInterceptors(connectionName), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))...,
 	)
 	var mgrVer semver.Version
 	if err != nil {
@@ -328,7 +398,7 @@ func connectToUserDaemon(c context.Context) (*grpc.ClientConn, connector.Manager
 func NewSession(c context.Context, mi *rpc.OutboundInfo) (context.Context, *Session, error) {
 	dlog.Info(c, "-- Starting new session")
 
-	c, conn, mc, ver, err := connectToManager(c, mi.ManagerNamespace, mi.KubeFlags, mi.KubeconfigData)
+	c, conn, mc, ver, err := connectToManager(c, mi.ConnectionName, mi.ManagerNamespace, mi.KubeFlags, mi.KubeconfigData)
 	if mc == nil || err != nil {
 		return c, nil, err
 	}
@@ -356,11 +426,15 @@ func newSession(c context.Context, mi *rpc.OutboundInfo, mc connector.ManagerPro
 		}
 	}
 	dlog.Debugf(c, "Creating session with id %v", mi.Session)
+	if mi.ConnectionName == "" {
+		mi.ConnectionName = mi.GetSession().GetSessionId()
+	}
 
 	s := &Session{
 		handlers:           tunnel.NewPool(),
 		rndSource:          rand.NewSource(time.Now().UnixNano()),
 		session:            mi.Session,
+		name:               mi.ConnectionName,
 		namespace:          mi.Namespace,
 		managerClient:      mc,
 		managerVersion:     ver,
@@ -371,6 +445,8 @@ func newSession(c context.Context, mi *rpc.OutboundInfo, mc connector.ManagerPro
 		config:             cfg,
 		done:               make(chan struct{}),
 		podDaemon:          isPodDaemon,
+		tunnelThroughput:   tunnel.NewThroughput(time.Minute),
+		pfDialer:           dnet.GetPortForwardDialer(c),
 	}
 	s.alsoProxySubnets, err = validateSubnets("also-proxy", mi.AlsoProxySubnets, s.alsoProxyVia)
 	if err != nil {
@@ -390,7 +466,31 @@ func newSession(c context.Context, mi *rpc.OutboundInfo, mc connector.ManagerPro
 	}
 	dlog.Infof(c, "allow-conflicting subnets %v", s.allowConflictingSubnets)
 
+	if s.proxyExcludePorts, err = parseExcludePorts(mi.ProxyExcludePorts); err != nil {
+		return nil, err
+	}
+	if len(s.proxyExcludePorts) > 0 {
+		dlog.Infof(c, "proxy-exclude ports %v", mi.ProxyExcludePorts)
+	}
+	s.chaos = mi.TunnelChaos
+
+	if client.GetConfig(c).Cluster().ProxyExternalNames {
+		if err = s.ensureVirtualIPs(c); err != nil {
+			return nil, err
+		}
+		s.proxyExternalNames = true
+	}
+	s.remapConflicting = client.GetConfig(c).Cluster().RemapConflictingSubnets
+
 	s.dnsServer = dns.NewServer(mi.Dns, s.clusterLookup)
+	s.dnsServer.SetRouteFilter(func(ctx context.Context, routes []string) []string {
+		// Domains that another connection already resolves are left to that connection.
+		granted, conflicts := getDomainClaims(ctx).claim(s.session.SessionId, s.name, routes)
+		for _, c := range conflicts {
+			dlog.Warnf(ctx, "Not resolving domain %s, because connection %q resolves it", c.domain, c.owner)
+		}
+		return granted
+	})
 	s.SetTopLevelDomains(c, nil)
 	return s, nil
 }
@@ -434,9 +534,62 @@ func (s *Session) clusterLookup(ctx context.Context, q *dns2.Question) (dnsproxy
 			}
 		}
 	}
+	if err == nil && s.proxyExternalNames && len(answer) > 0 {
+		// The traffic-manager answers with a CNAME followed by the addresses of its target when the name
+		// is an ExternalName service. Those addresses must be reached from the cluster.
+		if _, ok := answer[0].(*dns2.CNAME); ok {
+			for _, rr := range answer[1:] {
+				switch rr := rr.(type) {
+				case *dns2.A:
+					rr.A, err = s.maybeGetExternalNameVIP(ctx, rr.A)
+				case *dns2.AAAA:
+					rr.AAAA, err = s.maybeGetExternalNameVIP(ctx, rr.AAAA)
+				}
+				if err != nil {
+					rCode = dns2.RcodeServerFailure
+					break
+				}
+			}
+		}
+	}
 	return answer, rCode, err
 }
 
+// maybeGetExternalNameVIP returns a virtual IP that routes to the given IP using the traffic-manager, unless
+// the IP is already routed to the cluster, or never should be.
+func (s *Session) maybeGetExternalNameVIP(ctx context.Context, destinationIP net.IP) (net.IP, error) {
+	if s.vipGenerator.Subnet().Contains(destinationIP) || !s.shouldProxyExternalIP(destinationIP) {
+		return destinationIP, nil
+	}
+	var err error
+	vip, _ := s.localTranslationTable.Compute(iputil.IPKey(destinationIP), func(existing net.IP, loaded bool) (net.IP, bool) {
+		if loaded {
+			return existing, false
+		}
+		var nip net.IP
+		nip, err = s.nextVirtualIP("", destinationIP)
+		return nip, err != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dlog.Debugf(ctx, "using VIP %q for ExternalName IP %q", vip, destinationIP)
+	return vip, nil
+}
+
+// shouldProxyExternalIP returns false if the given IP is in a subnet that is routed to the cluster
+// already, or in a never-proxy subnet.
+func (s *Session) shouldProxyExternalIP(ip net.IP) bool {
+	for _, sns := range [][]*net.IPNet{s.serviceSubnets, s.podSubnets, s.alsoProxySubnets, s.neverProxySubnets} {
+		for _, sn := range sns {
+			if sn.Contains(ip) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (s *Session) maybeGetVirtualIP(ctx context.Context, destinationIP net.IP) (net.IP, error) {
 	var err error
 	vip, ok := s.localTranslationTable.Compute(iputil.IPKey(destinationIP), func(existing net.IP, loaded bool) (net.IP, bool) {
@@ -498,6 +651,13 @@ func (s *Session) getNetworkConfig() *rpc.NetworkConfig {
 			info.AllowConflictingSubnets[i] = iputil.IPNetToRPC(np)
 		}
 	}
+	if len(s.proxyExcludePorts) > 0 {
+		info.ProxyExcludePorts = make([]string, len(s.proxyExcludePorts))
+		for i, pp := range s.proxyExcludePorts {
+			info.ProxyExcludePorts[i] = pp.String()
+		}
+	}
+	info.TunnelChaos = s.chaos
 	if s.tunVif != nil {
 		curSubnets := s.tunVif.Router.GetRoutedSubnets()
 		nc.Subnets = make([]*manager.IPNet, len(curSubnets))
@@ -520,7 +680,7 @@ func (s *Session) shouldProxySubnet(ctx context.Context, name string, sn *net.IP
 		return false
 	}
 	for _, lt := range s.localTranslationSubnets {
-		if subnet.Covers(&lt.IPNet, sn) {
+		if lt.workload != "" && subnet.Covers(&lt.IPNet, sn) {
 			dlog.Infof(ctx, "Will not proxy %s subnet %s, because it covered by --proxy-via %s=%s", name, sn, lt.IPNet, lt.workload)
 			return false
 		}
@@ -597,27 +757,9 @@ func (s *Session) watchClusterInfo(ctx context.Context) error {
 				}
 				break
 			}
-			ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "ClusterInfoUpdate")
-			if err = s.readAdditionalRouting(ctx, mgrInfo); err != nil {
+			if err = s.handleClusterInfo(ctx, mgrInfo); err != nil {
 				return err
 			}
-			select {
-			case <-s.vifReady:
-				if err := s.onClusterInfo(ctx, mgrInfo, span); err != nil {
-					if !errors.Is(err, context.Canceled) {
-						dlog.Error(ctx, err)
-					}
-					return err
-				}
-			default:
-				if err = s.onFirstClusterInfo(ctx, mgrInfo, span); err != nil {
-					if !errors.Is(err, context.Canceled) {
-						dlog.Error(ctx, err)
-					}
-					return err
-				}
-			}
-			span.End()
 		}
 		dtime.SleepWithContext(ctx, backoff)
 		backoff *= 2
@@ -628,6 +770,88 @@ func (s *Session) watchClusterInfo(ctx context.Context) error {
 	return nil
 }
 
+func (s *Session) handleClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInfo) error {
+	s.routingLock.Lock()
+	defer s.routingLock.Unlock()
+	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "ClusterInfoUpdate")
+	defer span.End()
+	if err := s.readAdditionalRouting(ctx, mgrInfo); err != nil {
+		return err
+	}
+	var err error
+	select {
+	case <-s.vifReady:
+		err = s.onClusterInfo(ctx, mgrInfo, span)
+	default:
+		err = s.onFirstClusterInfo(ctx, mgrInfo, span)
+	}
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			dlog.Error(ctx, err)
+		}
+		return err
+	}
+	s.clusterInfo = mgrInfo
+	return nil
+}
+
+// SetProxySubnets replaces the also-proxy and never-proxy subnets of the session. The subnets that the
+// traffic-manager adds are retained, and the routes are updated without affecting existing connections
+// when the session has received its cluster info.
+func (s *Session) SetProxySubnets(ctx context.Context, alsoProxy, neverProxy []*manager.IPNet) error {
+	s.routingLock.Lock()
+	defer s.routingLock.Unlock()
+	aps, err := validateSubnets("also-proxy", alsoProxy, s.alsoProxyVia)
+	if err != nil {
+		return err
+	}
+	nps, err := validateSubnets("never-proxy", neverProxy, nope)
+	if err != nil {
+		return err
+	}
+	s.alsoProxySubnets = aps
+	s.neverProxySubnets = nps
+	mgrInfo := s.clusterInfo
+	if mgrInfo == nil {
+		dlog.Infof(ctx, "also-proxy subnets %v", s.alsoProxySubnets)
+		dlog.Infof(ctx, "never-proxy subnets %v", s.neverProxySubnets)
+		return nil
+	}
+	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "ProxySubnetsUpdate")
+	defer span.End()
+	if err = s.readAdditionalRouting(ctx, mgrInfo); err != nil {
+		return err
+	}
+	return s.onClusterInfo(ctx, mgrInfo, span)
+}
+
+// RefreshRoutes reapplies the routes of the session, so that routes that depend on the host's network, such
+// as the routes of never-proxy subnets, are bound to the host's current network.
+func (s *Session) RefreshRoutes(ctx context.Context) error {
+	s.routingLock.Lock()
+	defer s.routingLock.Unlock()
+	mgrInfo := s.clusterInfo
+	if mgrInfo == nil {
+		return nil
+	}
+	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "RefreshRoutes")
+	defer span.End()
+	dlog.Info(ctx, "Refreshing routes")
+	return s.onClusterInfo(ctx, mgrInfo, span)
+}
+
+// serviceSubnets returns the service subnets of the given cluster info. Traffic-managers that don't
+// report the subnets of dual-stack clusters only report one service subnet.
+func serviceSubnets(mgrInfo *manager.ClusterInfo) []*manager.IPNet {
+	if len(mgrInfo.ServiceSubnets) > 0 {
+		return mgrInfo.ServiceSubnets
+	}
+	if mgrInfo.ServiceSubnet != nil {
+		return []*manager.IPNet{mgrInfo.ServiceSubnet}
+	}
+	return nil
+}
+
 // createSubnetForDNSOnly will find a random IPv4 subnet that isn't currently routed and
 // attach the DNS server to that subnet.
 func (s *Session) createSubnetForDNSOnly(ctx context.Context, mgrInfo *manager.ClusterInfo) {
@@ -638,8 +862,8 @@ func (s *Session) createSubnetForDNSOnly(ctx context.Context, mgrInfo *manager.C
 
 	// Avoid the service subnet. It might be mapped with iptables (if running bare-metal) and
 	// hence invisible when listing known routes.
-	if mgrInfo.ServiceSubnet != nil {
-		avoid = append(avoid, iputil.IPNetFromRPC(mgrInfo.ServiceSubnet))
+	for _, ss := range serviceSubnets(mgrInfo) {
+		avoid = append(avoid, iputil.IPNetFromRPC(ss))
 	}
 
 	// Avoid the pod subnets. They are probably visible as known routes, but we add them to
@@ -690,18 +914,18 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		mgrInfo.Routing = &manager.Routing{}
 	}
 
-	s.serviceSubnet = nil
+	s.serviceSubnets = nil
 	s.podSubnets = nil
 
 	var subnets []*net.IPNet
 	if s.proxyClusterSvcs {
-		if mgrInfo.ServiceSubnet != nil {
-			cidr := iputil.IPNetFromRPC(mgrInfo.ServiceSubnet)
+		for _, ss := range serviceSubnets(mgrInfo) {
+			cidr := iputil.IPNetFromRPC(ss)
 			if s.shouldProxySubnet(ctx, "service", cidr) {
 				dlog.Infof(ctx, "Adding service subnet %s", cidr)
 				subnets = append(subnets, cidr)
 			}
-			s.serviceSubnet = cidr
+			s.serviceSubnets = append(s.serviceSubnets, cidr)
 		}
 	}
 
@@ -716,10 +940,32 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		}
 	}
 
+	if !s.userSpace {
+		// Subnets that another connection already routes are left to that connection. This must be done
+		// before the conflicts with the host's routes are handled, because the routes of other connections
+		// are among them.
+		var connConflicts []connectionConflict
+		subnets, connConflicts = getRouteClaims(ctx).claim(s.session.SessionId, s.name, subnets)
+		for _, c := range connConflicts {
+			dlog.Warnf(ctx, "Not routing subnet %s, because it overlaps with subnet %s of connection %q", c.subnet, c.ownerSubnet, c.owner)
+		}
+		s.conflictsLock.Lock()
+		s.connConflicts = connConflicts
+		s.conflictsLock.Unlock()
+
+		var err error
+		if subnets, err = s.handleConflicts(ctx, subnets); err != nil {
+			return err
+		}
+	}
+
 	if s.vipGenerator != nil {
 		subnets = append(subnets, s.vipGenerator.Subnet())
 		dlog.Debugf(ctx, "Adding VIP subnet %q to TUN-device", s.vipGenerator.Subnet().String())
 		s.consolidateProxyViaWorkloads(ctx)
+		for _, sn := range s.remappedSubnets {
+			s.localTranslationSubnets = append(s.localTranslationSubnets, agentSubnet{IPNet: *sn})
+		}
 	}
 
 	if !s.alsoProxyVia() {
@@ -738,7 +984,7 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 			break
 		}
 	}
-	if runtime.GOOS != "darwin" && !dnsRouted {
+	if runtime.GOOS != "darwin" && !dnsRouted && !s.userSpace {
 		// We'll need to synthesize a subnet where we can attach the DNS service when the VIF isn't configured
 		// from cluster subnets. But not on darwin systems, because there the DNS is controlled by /etc/resolver
 		// entries appointing the DNS service directly via localhost:<port>.
@@ -762,8 +1008,23 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 	}
 
 	if len(subnets) > 0 && s.tunVif == nil {
+		s.startQUIC(ctx, subnets)
+		s.startWireGuard(ctx, subnets)
+
+		// The connections that the VIF dispatches to tunnels use the configured idle timeouts and keep-alives.
+		vifCtx := tunnel.WithIdleConfigs(ctx, client.GetConfig(ctx).Grpc().TunnelIdle())
+		vifCtx = vif.WithChaos(vifCtx, vif.Chaos{
+			Latency:  s.chaos.GetLatency().AsDuration(),
+			Jitter:   s.chaos.GetJitter().AsDuration(),
+			DropRate: float64(s.chaos.GetDropPercent()) / 100,
+		})
 		var err error
-		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator()); err != nil {
+		if s.userSpace {
+			s.tunVif, err = vif.NewUserSpaceTunnelingDevice(vifCtx, s.streamCreator(ctx))
+		} else {
+			s.tunVif, err = vif.NewTunnelingDevice(vifCtx, s.streamCreator(ctx))
+		}
+		if err != nil {
 			return fmt.Errorf("NewTunnelVIF: %w", err)
 		}
 	}
@@ -796,6 +1057,90 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 	return rt.UpdateRoutes(ctx, proxy, neverProxy, neverProxyOverrides)
 }
 
+// handleConflicts finds the given subnets that conflict with routes on the host. When remapping is enabled,
+// the conflicting subnets are removed from the returned subnets, and the IPs that the cluster's DNS resolves
+// within them are translated to virtual IPs that are reached using the traffic-manager.
+func (s *Session) handleConflicts(ctx context.Context, subnets []*net.IPNet) ([]*net.IPNet, error) {
+	ifName := ""
+	if s.tunVif != nil {
+		ifName = s.tunVif.Device.Name()
+	}
+	conflicts, err := vif.FindConflicts(ctx, subnets, s.allowConflictingSubnets, ifName)
+	if err != nil {
+		return nil, err
+	}
+	var remapped []*net.IPNet
+	if s.remapConflicting && len(conflicts) > 0 {
+		if err = s.ensureVirtualIPs(ctx); err != nil {
+			return nil, err
+		}
+		for _, c := range conflicts {
+			dlog.Infof(ctx, "Remapping subnet %s to virtual IPs, because it conflicts with route %s", c.Subnet, c.Route)
+			remapped = append(remapped, c.Subnet)
+		}
+		subnets = slices.DeleteFunc(subnets, func(sn *net.IPNet) bool {
+			return slices.Contains(remapped, sn)
+		})
+	}
+	s.conflictsLock.Lock()
+	s.subnetConflicts = conflicts
+	s.remappedSubnets = remapped
+	s.conflictsLock.Unlock()
+	return subnets, nil
+}
+
+// getSubnetConflicts returns the cluster subnets that conflict with routes on the host, or with the
+// subnets of other connections.
+func (s *Session) getSubnetConflicts() []*rpc.SubnetConflict {
+	s.conflictsLock.Lock()
+	defer s.conflictsLock.Unlock()
+	if len(s.subnetConflicts)+len(s.connConflicts) == 0 {
+		return nil
+	}
+	rcs := make([]*rpc.SubnetConflict, 0, len(s.subnetConflicts)+len(s.connConflicts))
+	for _, c := range s.connConflicts {
+		rcs = append(rcs, &rpc.SubnetConflict{
+			Subnet:     iputil.IPNetToRPC(c.subnet),
+			Route:      iputil.IPNetToRPC(c.ownerSubnet),
+			Connection: c.owner,
+		})
+	}
+	for _, c := range s.subnetConflicts {
+		rcs = append(rcs, &rpc.SubnetConflict{
+			Subnet:    iputil.IPNetToRPC(c.Subnet),
+			Route:     iputil.IPNetToRPC(c.Route.RoutedNet),
+			Interface: c.Route.Interface.Name,
+			Remapped:  slices.Contains(s.remappedSubnets, c.Subnet),
+		})
+	}
+	return rcs
+}
+
+// getConnectionQuality returns the measurements of the quality of the connection that this session belongs to.
+func (s *Session) getConnectionQuality() *rpc.ConnectionQuality {
+	cq := &rpc.ConnectionQuality{}
+	cq.TunnelBytesSent, cq.TunnelBytesReceived = s.tunnelThroughput.LastWindow()
+	if l := s.dnsServer.GetLookupLatency(); l > 0 {
+		cq.DnsLookupLatency = durationpb.New(l)
+	}
+	if s.pfDialer != nil {
+		cq.PortForwardRestarts = s.pfDialer.Restarts()
+	}
+	return cq
+}
+
+// getConnectionStatus returns the status of the connection that this session belongs to.
+func (s *Session) getConnectionStatus() *rpc.ConnectionStatus {
+	return &rpc.ConnectionStatus{
+		Name:            s.name,
+		Session:         s.session,
+		Namespace:       s.namespace,
+		Subnets:         s.getNetworkConfig().Subnets,
+		DnsDomains:      s.dnsServer.Domains(),
+		SubnetConflicts: s.getSubnetConflicts(),
+	}
+}
+
 func computeNeverProxyOverrides(ctx context.Context, subnets, nvp []*net.IPNet) (proxy, neverProxy, neverProxyOverrides []*net.IPNet) {
 	neverProxy = slices.Clone(nvp)
 	last := len(neverProxy) - 1
@@ -1080,12 +1425,24 @@ func (s *Session) Start(c context.Context, g *dgroup.Group) error {
 		cancelDNSLock.Lock()
 		ctx, cancelDNS = context.WithCancel(ctx)
 		cancelDNSLock.Unlock()
+		if s.userSpace {
+			return s.dnsServer.UserSpaceWorker(ctx, s.configureDNS)
+		}
 		var dev vif.Device
 		if s.tunVif != nil {
 			dev = s.tunVif.Device
 		}
 		return s.dnsServer.Worker(ctx, dev, s.configureDNS)
 	})
+	g.Go("throughput", func(ctx context.Context) error {
+		return s.tunnelThroughput.Run(ctx, throughputSampleInterval)
+	})
+	if s.socksAddress != "" {
+		g.Go("socks", s.serveSOCKS)
+	}
+	if s.httpProxyAddress != "" {
+		g.Go("http-proxy", s.serveHTTPProxy)
+	}
 
 	if s.tunVif != nil {
 		g.Go("vif", s.tunVif.Run)
@@ -1121,6 +1478,12 @@ func (s *Session) stop(c context.Context) {
 			dlog.Errorf(c, "unable to close %s: %v", s.tunVif.Device.Name(), err)
 		}
 	}
+	if s.quicConn != nil {
+		_ = s.quicConn.CloseWithError(0, "")
+	}
+	if s.wireGuard != nil {
+		s.wireGuard.Close()
+	}
 }
 
 func (s *Session) activateProxyViaWorkloads(ctx context.Context) error {
@@ -1128,17 +1491,13 @@ func (s *Session) activateProxyViaWorkloads(ctx context.Context) error {
 	if sl == 0 {
 		return nil
 	}
-	_, vipSubnet, err := net.ParseCIDR(client.GetConfig(ctx).Cluster().VirtualIPSubnet)
-	if err != nil {
-		return fmt.Errorf("unable to parse configuration value cluster.virtualIPSubnet: %w", err)
+	if err := s.ensureVirtualIPs(ctx); err != nil {
+		return err
 	}
-	s.vipGenerator = vip.NewGenerator(vipSubnet)
-	s.localTranslationTable = xsync.NewMapOf[iputil.IPKey, net.IP]()
-	s.virtualIPs = xsync.NewMapOf[iputil.IPKey, agentVIP]()
 	s.localTranslationSubnets = make([]agentSubnet, sl)
 	for _, wlName := range s.consolidateProxyViaWorkloads(ctx) {
 		dlog.Debugf(ctx, "Ensuring proxy-via agent in %s", wlName)
-		_, err = s.managerClient.EnsureAgent(ctx, &manager.EnsureAgentRequest{
+		_, err := s.managerClient.EnsureAgent(ctx, &manager.EnsureAgentRequest{
 			Session: s.session,
 			Name:    wlName,
 		})
@@ -1149,6 +1508,22 @@ func (s *Session) activateProxyViaWorkloads(ctx context.Context) error {
 	return nil
 }
 
+// ensureVirtualIPs creates the generator of virtual IPs and the tables that map them to their destinations,
+// unless they have been created already.
+func (s *Session) ensureVirtualIPs(ctx context.Context) error {
+	if s.vipGenerator != nil {
+		return nil
+	}
+	_, vipSubnet, err := net.ParseCIDR(client.GetConfig(ctx).Cluster().VirtualIPSubnet)
+	if err != nil {
+		return fmt.Errorf("unable to parse configuration value cluster.virtualIPSubnet: %w", err)
+	}
+	s.vipGenerator = vip.NewGenerator(vipSubnet)
+	s.localTranslationTable = xsync.NewMapOf[iputil.IPKey, net.IP]()
+	s.virtualIPs = xsync.NewMapOf[iputil.IPKey, agentVIP]()
+	return nil
+}
+
 func (s *Session) consolidateProxyViaWorkloads(ctx context.Context) []string {
 	desiredVips := make(map[string][]*net.IPNet)
 	snCount := 0
@@ -1161,10 +1536,8 @@ func (s *Session) consolidateProxyViaWorkloads(ctx context.Context) []string {
 			desiredVips[pvx.Workload] = append(desiredVips[pvx.Workload], s.podSubnets...)
 			snCount += len(s.podSubnets)
 		case "service":
-			if s.serviceSubnet != nil {
-				desiredVips[pvx.Workload] = append(desiredVips[pvx.Workload], s.serviceSubnet)
-				snCount++
-			}
+			desiredVips[pvx.Workload] = append(desiredVips[pvx.Workload], s.serviceSubnets...)
+			snCount += len(s.serviceSubnets)
 		default:
 			_, sn, err := net.ParseCIDR(pvx.Subnet)
 			if err != nil {
@@ -1236,6 +1609,11 @@ func (s *Session) SetMappings(ctx context.Context, mappings []*rpc.DNSMapping) {
 	s.dnsServer.SetMappings(mappings)
 }
 
+func (s *Session) FlushDNSCache(ctx context.Context) {
+	dlog.Debug(ctx, "Flushing DNS cache")
+	s.dnsServer.FlushCache()
+}
+
 func (s *Session) applyConfig(ctx context.Context) error {
 	cfg, err := client.LoadConfig(ctx)
 	if err != nil {
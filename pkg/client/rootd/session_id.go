@@ -0,0 +1,37 @@
+package rootd
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// sessionIDKey is the gRPC metadata key that a client of the root daemon uses to identify the session
+// that a call concerns. The root daemon can maintain several sessions, one for each connection.
+const sessionIDKey = "telepresence-session-id"
+
+// WithSessionID returns a context that identifies the session with the given ID in root daemon calls
+// that are made using it.
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, sessionIDKey, id)
+}
+
+// SessionIDInterceptor returns a client interceptor that identifies the session with the given ID in all
+// unary root daemon calls.
+func SessionIDInterceptor(id string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(WithSessionID(ctx, id), method, req, reply, cc, opts...)
+	}
+}
+
+// sessionIDFromContext returns the session ID that the caller of a root daemon call provided, or an
+// empty string if it didn't provide one.
+func sessionIDFromContext(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(sessionIDKey); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+	return ""
+}
@@ -29,7 +29,13 @@ func checkRecursion(p int, ip net.IP, sn *net.IPNet) (err error) {
 	return err
 }
 
-func (s *Session) streamCreator() tunnel.StreamCreator {
+func (s *Session) streamCreator(ctx context.Context) tunnel.StreamCreator {
+	// Tunnels to the traffic-manager share a small set of MuxTunnel streams for the lifetime of the VIF.
+	mp := tunnel.ManagerProxyMuxProvider(ctx, s.managerClient)
+	if s.quicConn != nil {
+		// The tunnels are carried by the QUIC connection, and by the MuxTunnel streams once it fails.
+		mp = tunnel.NewQUICProvider(ctx, s.quicConn, mp)
+	}
 	return func(c context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
 		p := id.Protocol()
 		srcIp := id.Source()
@@ -38,20 +44,39 @@ func (s *Session) streamCreator() tunnel.StreamCreator {
 				return nil, err
 			}
 		}
-		if p == ipproto.UDP {
-			if s.isForDNS(id.Destination(), id.DestinationPort()) {
+		if s.isForDNS(id.Destination(), id.DestinationPort()) {
+			// The local DNS server listens to the same port for both UDP and TCP. TCP is used by
+			// clients that retry a query when the UDP response was truncated.
+			switch p {
+			case ipproto.UDP:
 				pipeId := tunnel.NewConnID(p, id.Source(), s.dnsLocalAddr.IP, id.SourcePort(), uint16(s.dnsLocalAddr.Port))
 				dlog.Tracef(c, "Intercept DNS %s to %s", id, pipeId.DestinationAddr())
 				from, to := tunnel.NewPipe(pipeId, s.session.SessionId)
 				tunnel.NewDialerTTL(to, func() {}, dnsConnTTL, nil, nil).Start(c)
 				return from, nil
+			case ipproto.TCP:
+				pipeId := tunnel.NewConnID(p, id.Source(), s.dnsLocalAddr.IP, id.SourcePort(), uint16(s.dnsLocalAddr.Port))
+				dlog.Tracef(c, "Intercept DNS %s to %s", id, pipeId.DestinationAddr())
+				from, to := tunnel.NewPipe(pipeId, s.session.SessionId)
+				tunnel.NewDialer(to, func() {}, nil, nil).Start(c)
+				return from, nil
 			}
 		}
+		if s.isPortExcluded(p, id.DestinationPort()) {
+			return s.bypassStream(c, id)
+		}
 
 		var err error
 		var tp tunnel.Provider
-		if a, ok := s.getAgentVIP(id); ok {
-			// s.agentClients is never nil when agentVIPs are used.
+		viaManager := false
+		if a, ok := s.getAgentVIP(id); ok && a.workload == "" {
+			// Virtual IP of an ExternalName service. The traffic-manager dials the original destination.
+			tp = mp
+			viaManager = true
+			id = tunnel.NewConnID(id.Protocol(), id.Source(), a.destinationIP, id.SourcePort(), id.DestinationPort())
+			dlog.Debugf(c, "Opening traffic-manager tunnel for ExternalName id %s", id)
+		} else if ok {
+			// s.agentClients is never nil when agentVIPs are used for workloads.
 			tp = s.agentClients.GetWorkloadClient(a.workload)
 			if tp == nil {
 				return nil, fmt.Errorf("unable to connect to a traffic-agent for workload %q", a.workload)
@@ -64,17 +89,28 @@ func (s *Session) streamCreator() tunnel.StreamCreator {
 			if tp = s.getAgentClient(id.Destination()); tp != nil {
 				dlog.Debugf(c, "Opening traffic-agent tunnel for id %s", id)
 			} else {
-				tp = tunnel.ManagerProxyProvider(s.managerClient)
+				tp = mp
+				viaManager = true
 				dlog.Debugf(c, "Opening traffic-manager tunnel for id %s", id)
 			}
 		}
-		ct, err := tp.Tunnel(c)
+		var ts tunnel.Stream
+		if viaManager && s.wireGuard != nil && id.IsDestinationIPv4() {
+			ts, err = s.wireGuardStream(c, id)
+		} else {
+			var ct tunnel.Client
+			if ct, err = tp.Tunnel(c); err != nil {
+				return nil, err
+			}
+			cfg := client.GetConfig(c)
+			tc := cfg.Timeouts()
+			c = tunnel.WithCompression(c, cfg.Grpc().TunnelCompression())
+			ts, err = tunnel.NewClientStream(c, ct, id, s.session.SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial))
+		}
 		if err != nil {
 			return nil, err
 		}
-
-		tc := client.GetConfig(c).Timeouts()
-		return tunnel.NewClientStream(c, ct, id, s.session.SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial))
+		return s.tunnelThroughput.Stream(ts), nil
 	}
 }
 
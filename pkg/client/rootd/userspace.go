@@ -0,0 +1,125 @@
+package rootd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	dns2 "github.com/miekg/dns"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/httpproxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/socks"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+)
+
+// serveSOCKS provides access to the cluster through a SOCKS5 proxy. It's only used when the session
+// handles all networking in user space.
+func (s *Session) serveSOCKS(ctx context.Context) error {
+	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.socksAddress)
+	if err != nil {
+		return fmt.Errorf("unable to start SOCKS5 proxy: %w", err)
+	}
+	dlog.Infof(ctx, "SOCKS5 proxy listening to %s", l.Addr())
+	return socks.Serve(ctx, l, s.dialUserSpace)
+}
+
+// serveHTTPProxy provides access to the cluster through an HTTP proxy. It's only used when the session
+// handles all networking in user space.
+func (s *Session) serveHTTPProxy(ctx context.Context) error {
+	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.httpProxyAddress)
+	if err != nil {
+		return fmt.Errorf("unable to start HTTP proxy: %w", err)
+	}
+	dlog.Infof(ctx, "HTTP proxy listening to %s", l.Addr())
+	return httpproxy.Serve(ctx, l, s.dialUserSpace)
+}
+
+// dialUserSpace dials the given address. Names are resolved using the session's DNS server, and the
+// connection is made through the user space device when the resulting IP is routed by the session,
+// unless the port is excluded from proxying. All other connections are dialed directly.
+func (s *Session) dialUserSpace(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ip := iputil.Parse(host)
+	if ip == nil {
+		if ip, err = s.lookupIP(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+	address = net.JoinHostPort(ip.String(), port)
+	if s.tunVif != nil {
+		if dev, ok := s.tunVif.Device.(vif.UserSpaceDevice); ok && dev.Routes(ip) && !s.isNeverProxied(ip) && !s.isExcludedAddress(network, port) {
+			dlog.Debugf(ctx, "Dialing %s through the user space device", address)
+			return dev.DialContext(ctx, network, address)
+		}
+	}
+	return (&net.Dialer{}).DialContext(ctx, network, address)
+}
+
+// isExcludedAddress returns true if the given port of the given network is excluded from proxying.
+func (s *Session) isExcludedAddress(network, port string) bool {
+	pn, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return false
+	}
+	proto := ipproto.TCP
+	if strings.HasPrefix(network, "udp") {
+		proto = ipproto.UDP
+	}
+	return s.isPortExcluded(proto, uint16(pn))
+}
+
+func (s *Session) isNeverProxied(ip net.IP) bool {
+	for _, sn := range s.neverProxySubnets {
+		if sn.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupIP resolves the given name using the session's DNS server. The host's resolver is used when
+// the DNS server can't resolve the name.
+func (s *Session) lookupIP(ctx context.Context, name string) (net.IP, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.dnsServer.Ready():
+	}
+	if s.dnsLocalAddr != nil {
+		dc := dns2.Client{Net: "udp"}
+		for _, qType := range []uint16{dns2.TypeA, dns2.TypeAAAA} {
+			q := new(dns2.Msg)
+			q.SetQuestion(dns2.Fqdn(name), qType)
+			r, _, err := dc.ExchangeContext(ctx, q, s.dnsLocalAddr.String())
+			if err == nil && r.Truncated {
+				tc := dns2.Client{Net: "tcp"}
+				r, _, err = tc.ExchangeContext(ctx, q, s.dnsLocalAddr.String())
+			}
+			if err != nil {
+				dlog.Debugf(ctx, "Lookup %s %q failed: %v", dns2.TypeToString[qType], name, err)
+				continue
+			}
+			for _, rr := range r.Answer {
+				switch rr := rr.(type) {
+				case *dns2.A:
+					return rr.A, nil
+				case *dns2.AAAA:
+					return rr.AAAA, nil
+				}
+			}
+		}
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", name)
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
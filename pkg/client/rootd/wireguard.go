@@ -0,0 +1,111 @@
+package rootd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+	"github.com/telepresenceio/telepresence/v2/pkg/wireguard"
+)
+
+const (
+	// wireGuardKeepAlive keeps the NAT mappings between the workstation and the traffic-manager alive.
+	wireGuardKeepAlive = 25 * time.Second
+
+	// wireGuardHandshakeTimeout is the time to wait for the first handshake with the traffic-manager.
+	wireGuardHandshakeTimeout = 5 * time.Second
+)
+
+// startWireGuard creates the WireGuard device that carries the traffic destined for the traffic-manager when
+// cluster.wireGuard is enabled. The gRPC tunnels are used instead when the device can't be created, or when
+// the WireGuard endpoint of the traffic-manager can't be reached. The given subnets are the subnets that are
+// routed to the TUN-device.
+func (s *Session) startWireGuard(ctx context.Context, routed []*net.IPNet) {
+	if !client.GetConfig(ctx).Cluster().WireGuard {
+		return
+	}
+	dev, err := s.connectWireGuard(ctx, routed)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			dlog.Info(ctx, "WireGuard is not enabled in the traffic-manager. Using gRPC tunnels")
+		} else {
+			dlog.Errorf(ctx, "Unable to use WireGuard. Using gRPC tunnels: %v", err)
+		}
+		return
+	}
+	s.wireGuard = dev
+}
+
+func (s *Session) connectWireGuard(ctx context.Context, routed []*net.IPNet) (*wireguard.Device, error) {
+	key, err := wireguard.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := s.managerClient.WireGuardPeer(ctx, &manager.WireGuardPeerRequest{
+		Session:   s.session,
+		PublicKey: key.PublicKey().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	serverKey, err := wireguard.ParseKey(rsp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientIP, ok := netip.AddrFromSlice(rsp.ClientIp)
+	if !ok {
+		return nil, fmt.Errorf("invalid WireGuard client IP %v", rsp.ClientIp)
+	}
+	endpoint, err := resolveEndpoint(ctx, "WireGuard", rsp.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkEndpoint("WireGuard", endpoint, routed); err != nil {
+		return nil, err
+	}
+
+	dev, err := wireguard.NewClient(ctx, key, clientIP.Unmap())
+	if err != nil {
+		return nil, err
+	}
+	if err = dev.AddPeer(serverKey, netip.MustParsePrefix("0.0.0.0/0"), endpoint, wireGuardKeepAlive); err == nil {
+		hc, cancel := context.WithTimeout(ctx, wireGuardHandshakeTimeout)
+		err = dev.WaitForHandshake(hc, serverKey)
+		cancel()
+	}
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+	dlog.Infof(ctx, "Traffic to the traffic-manager is sent through its WireGuard endpoint %s", endpoint)
+	return dev, nil
+}
+
+// wireGuardStream dials the destination of the given id through the WireGuard device, and returns a Stream
+// for the resulting connection. Only IPv4 destinations can be dialed, because the device has an IPv4 address.
+func (s *Session) wireGuardStream(ctx context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+	ip := id.Destination().To4()
+	if ip == nil {
+		return nil, errors.New("not an IPv4 destination")
+	}
+	addr := netip.AddrPortFrom(netip.AddrFrom4([4]byte(ip)), id.DestinationPort())
+	dlog.Debugf(ctx, "Dialing %s through WireGuard", id)
+	tc := client.GetConfig(ctx).Timeouts()
+	dc, cancel := context.WithTimeout(ctx, tc.Get(client.TimeoutRoundtripLatency)+tc.Get(client.TimeoutEndpointDial))
+	defer cancel()
+	conn, err := s.wireGuard.DialContext(dc, id.DestinationProtocolString(), addr)
+	if err != nil {
+		return nil, err
+	}
+	return tunnel.NewConnStream(ctx, id, conn, s.session.SessionId), nil
+}
@@ -127,13 +127,24 @@ func waitUntilReady(ctx context.Context, cc *grpc.ClientConn) error {
 	}
 }
 
-// Listen returns a listener for the given socket and returns the resulting connection.
+// Listen returns a listener for the given socket and returns the resulting connection. A listener that was
+// passed to the process by a service manager on activation is used when one exists for the given socket.
 func Listen(ctx context.Context, processName, socketName string) (net.Listener, error) {
 	return listen(ctx, processName, socketName)
 }
 
-// Remove removes any representation of the socket from the filesystem.
+// activatedListener is a listener on a socket that is owned by a service manager, such as systemd, which passed
+// it to this process on activation.
+type activatedListener struct {
+	net.Listener
+}
+
+// Remove removes any representation of the socket from the filesystem. A socket that is owned by a service
+// manager is left alone, because the service manager will use it to activate the process again.
 func Remove(listener net.Listener) error {
+	if _, ok := listener.(*activatedListener); ok {
+		return nil
+	}
 	return os.Remove(listener.Addr().String())
 }
 
@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"sync"
 
 	"golang.org/x/sys/unix"
 
+	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 )
 
@@ -24,7 +27,54 @@ func rootDaemonPath(ctx context.Context) string {
 	return "/var/run/telepresence-daemon.socket"
 }
 
-func listen(_ context.Context, processName, socketName string) (net.Listener, error) {
+// firstActivatedFD is the first file descriptor that systemd passes to a socket activated process.
+const firstActivatedFD = 3
+
+//nolint:gochecknoglobals // the activated sockets are inherited once per process
+var activated struct {
+	sync.Once
+	listeners map[string]net.Listener
+}
+
+// activatedListeners returns the listeners that were passed to this process using the socket activation protocol
+// of systemd, keyed by socket path. The environment variables of the protocol are unset, so that they aren't
+// inherited by child processes.
+func activatedListeners(ctx context.Context) map[string]net.Listener {
+	activated.Do(func() {
+		pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+		fds, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+		if pid != os.Getpid() || fds <= 0 {
+			return
+		}
+		activated.listeners = make(map[string]net.Listener, fds)
+		for fd := firstActivatedFD; fd < firstActivatedFD+fds; fd++ {
+			unix.CloseOnExec(fd)
+			f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+			l, err := net.FileListener(f)
+			_ = f.Close()
+			if err != nil {
+				dlog.Errorf(ctx, "unable to use activated socket %d: %v", fd, err)
+				continue
+			}
+			if ul, ok := l.(*net.UnixListener); ok {
+				ul.SetUnlinkOnClose(false)
+				activated.listeners[ul.Addr().String()] = ul
+			} else {
+				_ = l.Close()
+			}
+		}
+	})
+	return activated.listeners
+}
+
+func listen(ctx context.Context, processName, socketName string) (net.Listener, error) {
+	if l, ok := activatedListeners(ctx)[socketName]; ok {
+		dlog.Infof(ctx, "Using socket %s passed by the service manager", socketName)
+		return &activatedListener{Listener: l}, nil
+	}
 	if proc.IsAdmin() {
 		origUmask := unix.Umask(0)
 		defer unix.Umask(origUmask)
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/logging"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
@@ -76,29 +78,51 @@ func (s *service) FuseFTPError() error {
 	return s.fuseFTPError
 }
 
+// callerSessionReadLocked returns the session of the connection that the caller identified, or the only session
+// when the caller didn't identify a connection. The session lock must be read-locked.
+func (s *service) callerSessionReadLocked(ctx context.Context) (*namedSession, error) {
+	if name := client.ConnectionNameFromContext(ctx); name != "" {
+		if ns, ok := s.sessions[name]; ok {
+			return ns, nil
+		}
+		return nil, status.Errorf(codes.Unavailable, "no active session for connection %s", name)
+	}
+	switch len(s.sessions) {
+	case 0:
+		return nil, status.Error(codes.Unavailable, "no active session")
+	case 1:
+		for _, ns := range s.sessions {
+			return ns, nil
+		}
+	}
+	names := make([]string, 0, len(s.sessions))
+	for name := range s.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, status.Errorf(codes.FailedPrecondition,
+		"there are %d connections (%s), use --use <match> to select one of them", len(names), strings.Join(names, ", "))
+}
+
 func (s *service) WithSession(c context.Context, callName string, f func(context.Context, userd.Session) error) (err error) {
 	s.LogCall(c, callName, func(_ context.Context) {
-		if atomic.LoadInt32(&s.sessionQuitting) != 0 {
-			err = status.Error(codes.Canceled, "session cancelled")
-			return
-		}
 		s.sessionLock.RLock()
 		defer s.sessionLock.RUnlock()
-		if s.session == nil {
-			err = status.Error(codes.Unavailable, "no active session")
+		var ns *namedSession
+		if ns, err = s.callerSessionReadLocked(c); err != nil {
 			return
 		}
-		if s.sessionContext.Err() != nil {
+		if atomic.LoadInt32(&ns.quitting) != 0 || ns.ctx.Err() != nil {
 			// Session context has been cancelled
 			err = status.Error(codes.Canceled, "session cancelled")
 			return
 		}
 		defer func() { err = callRecovery(c, recover(), err) }()
 		num := getReqNumber(c)
-		ctx := dgroup.WithGoroutineName(s.sessionContext, fmt.Sprintf("/%s-%d", callName, num))
+		ctx := dgroup.WithGoroutineName(ns.ctx, fmt.Sprintf("/%s-%d", callName, num))
 		ctx, span := otel.Tracer("").Start(ctx, callName)
 		defer span.End()
-		err = f(ctx, s.session)
+		err = f(ctx, ns.Session)
 	})
 	return
 }
@@ -118,6 +142,7 @@ func (s *service) Version(_ context.Context, _ *empty.Empty) (*common.VersionInf
 
 type crImpl struct {
 	*rpc.ConnectRequest
+	caller string // name of the connection that the caller identified, if any
 }
 
 func (c crImpl) Request() *rpc.ConnectRequest {
@@ -126,7 +151,7 @@ func (c crImpl) Request() *rpc.ConnectRequest {
 
 func (s *service) Connect(ctx context.Context, cr *rpc.ConnectRequest) (result *rpc.ConnectInfo, err error) {
 	s.LogCall(ctx, "Connect", func(c context.Context) {
-		if err = s.PostConnectRequest(ctx, crImpl{ConnectRequest: cr}); err == nil {
+		if err = s.PostConnectRequest(ctx, crImpl{ConnectRequest: cr, caller: client.ConnectionNameFromContext(ctx)}); err == nil {
 			result, err = s.ReadConnectResponse(ctx)
 		}
 	})
@@ -135,27 +160,80 @@ func (s *service) Connect(ctx context.Context, cr *rpc.ConnectRequest) (result *
 
 func (s *service) Disconnect(ctx context.Context, ex *empty.Empty) (*empty.Empty, error) {
 	s.LogCall(ctx, "Disconnect", func(ctx context.Context) {
-		s.cancelSession()
+		s.disconnect(ctx)
+	})
+	return &empty.Empty{}, nil
+}
+
+// disconnect ends the session of the caller's connection. All sessions are ended when the caller is an older
+// client that doesn't identify its connection and there are several of them.
+func (s *service) disconnect(ctx context.Context) {
+	s.sessionLock.RLock()
+	var names []string
+	ns, err := s.callerSessionReadLocked(ctx)
+	switch {
+	case err == nil:
+		names = []string{ns.name}
+	case status.Code(err) == codes.FailedPrecondition:
+		for name := range s.sessions {
+			names = append(names, name)
+		}
+	}
+	noSessions := len(s.sessions) == 0
+	s.sessionLock.RUnlock()
+
+	if noSessions {
+		// The root daemon might still have sessions that were created by an earlier user daemon. It
+		// disconnects all of them when no session ID is given.
 		_ = s.withRootDaemon(ctx, func(ctx context.Context, rd daemon.DaemonClient) error {
-			_, err := rd.Disconnect(ctx, ex)
+			_, err := rd.Disconnect(ctx, &empty.Empty{})
 			return err
 		})
+		return
+	}
+	for _, name := range names {
+		s.disconnectSession(ctx, name)
+	}
+}
+
+// disconnectSession ends the session with the given name and tells the root daemon to disconnect its
+// corresponding session, which removes the DNS configuration and the routes that were added for it.
+func (s *service) disconnectSession(ctx context.Context, name string) {
+	s.sessionLock.RLock()
+	ns, ok := s.sessions[name]
+	s.sessionLock.RUnlock()
+	if !ok {
+		return
+	}
+	s.cancelSession(name)
+	s.disconnectRootSession(ctx, ns)
+}
+
+// disconnectRootSession ends the root daemon's session of the given session.
+func (s *service) disconnectRootSession(ctx context.Context, ns *namedSession) {
+	ctx = rootd.WithSessionID(ctx, ns.SessionInfo().SessionId)
+	_ = s.withRootDaemon(ctx, func(ctx context.Context, rd daemon.DaemonClient) error {
+		_, err := rd.Disconnect(ctx, &empty.Empty{})
+		return err
 	})
-	return &empty.Empty{}, nil
 }
 
 func (s *service) Status(ctx context.Context, ex *empty.Empty) (result *rpc.ConnectInfo, err error) {
 	s.LogCall(ctx, "Status", func(c context.Context) {
 		s.sessionLock.RLock()
 		defer s.sessionLock.RUnlock()
-		if s.session == nil {
+		ns, sErr := s.callerSessionReadLocked(ctx)
+		switch {
+		case sErr == nil:
+			result = ns.Status(ns.ctx)
+		case status.Code(sErr) == codes.Unavailable:
 			result = &rpc.ConnectInfo{Error: rpc.ConnectInfo_DISCONNECTED}
 			_ = s.withRootDaemon(c, func(c context.Context, dc daemon.DaemonClient) error {
 				result.DaemonStatus, err = dc.Status(c, ex)
 				return nil
 			})
-		} else {
-			result = s.session.Status(s.sessionContext)
+		default:
+			err = sErr
 		}
 	})
 	return
@@ -164,8 +242,8 @@ func (s *service) Status(ctx context.Context, ex *empty.Empty) (result *rpc.Conn
 // isMultiPortIntercept checks if the intercept is one of several active intercepts on the same workload.
 // If it is, then the first returned value will be true and the second will indicate if those intercepts are
 // on different services. Otherwise, this function returns false, false.
-func (s *service) isMultiPortIntercept(spec *manager.InterceptSpec) (multiPort, multiService bool) {
-	wis := s.session.InterceptsForWorkload(spec.Agent, spec.Namespace)
+func isMultiPortIntercept(session userd.Session, spec *manager.InterceptSpec) (multiPort, multiService bool) {
+	wis := session.InterceptsForWorkload(spec.Agent, spec.Namespace)
 
 	// The InterceptsForWorkload will not include failing or removed intercepts so the
 	// subject must be added unless it's already there.
@@ -193,7 +271,7 @@ func (s *service) isMultiPortIntercept(spec *manager.InterceptSpec) (multiPort,
 	return true, false
 }
 
-func (s *service) scoutInterceptEntries(ctx context.Context, spec *manager.InterceptSpec, result *rpc.InterceptResult) ([]scout.Entry, bool) {
+func scoutInterceptEntries(ctx context.Context, session userd.Session, spec *manager.InterceptSpec, result *rpc.InterceptResult) ([]scout.Entry, bool) {
 	// The scout belongs to the session and can only contain session specific meta-data,
 	// so we don't want to use scout.SetMetadatum() here.
 	entries := make([]scout.Entry, 0, 7)
@@ -204,7 +282,7 @@ func (s *service) scoutInterceptEntries(ctx context.Context, spec *manager.Inter
 			scout.Entry{Key: "intercept_mechanism", Value: spec.Mechanism},
 			scout.Entry{Key: "intercept_mechanism_numargs", Value: len(spec.Mechanism)},
 		)
-		multiPort, multiService := s.isMultiPortIntercept(spec)
+		multiPort, multiService := isMultiPortIntercept(session, spec)
 		if multiPort {
 			entries = append(entries, scout.Entry{Key: "multi_port", Value: multiPort})
 			if multiService {
@@ -246,7 +324,7 @@ func (s *service) CanIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 		if result == nil {
 			result = &rpc.InterceptResult{Error: common.InterceptError_UNSPECIFIED}
 		}
-		entries, ok = s.scoutInterceptEntries(c, ir.GetSpec(), result)
+		entries, ok = scoutInterceptEntries(c, session, ir.GetSpec(), result)
 		return nil
 	})
 	return
@@ -271,7 +349,7 @@ func (s *service) CreateIntercept(c context.Context, ir *rpc.CreateInterceptRequ
 		if result != nil && result.InterceptInfo != nil {
 			tracing.RecordInterceptInfo(span, result.InterceptInfo)
 		}
-		entries, ok = s.scoutInterceptEntries(c, ir.GetSpec(), result)
+		entries, ok = scoutInterceptEntries(c, session, ir.GetSpec(), result)
 		return nil
 	})
 	return
@@ -308,7 +386,7 @@ func (s *service) RemoveIntercept(c context.Context, rr *manager.RemoveIntercept
 				result.ErrorCategory = int32(errcat.Unknown)
 			}
 		}
-		entries, ok = s.scoutInterceptEntries(c, spec, result)
+		entries, ok = scoutInterceptEntries(c, session, spec, result)
 		return nil
 	})
 	return result, err
@@ -389,6 +467,25 @@ func (s *service) GatherLogs(ctx context.Context, request *rpc.LogsRequest) (res
 	return
 }
 
+func (s *service) StreamLogs(rq *rpc.StreamLogsRequest, stream rpc.Connector_StreamLogsServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+	err := s.WithSession(stream.Context(), "StreamLogs", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The session context outlives the stream, so the streaming must end with either of them.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+	return session.StreamLogs(ctx, rq, stream.Send)
+}
+
 func (s *service) SetLogLevel(ctx context.Context, request *rpc.LogLevelRequest) (result *empty.Empty, err error) {
 	s.LogCall(ctx, "SetLogLevel", func(c context.Context) {
 		mrq := &manager.LogLevelRequest{
@@ -430,11 +527,34 @@ func (s *service) SetLogLevel(ctx context.Context, request *rpc.LogLevelRequest)
 	return &empty.Empty{}, err
 }
 
+func (s *service) Profiling(ctx context.Context, request *daemon.ProfilingRequest) (result *rpc.ProfilingInfo, err error) {
+	s.LogCall(ctx, "Profiling", func(c context.Context) {
+		var addr string
+		if addr, err = s.profiler.Start(0, request.Duration.AsDuration()); err != nil {
+			err = status.Error(codes.Unavailable, err.Error())
+			return
+		}
+		result = &rpc.ProfilingInfo{UserDaemonAddress: addr}
+		if !s.rootSessionInProc {
+			err = s.withRootDaemon(c, func(c context.Context, rd daemon.DaemonClient) error {
+				ri, err := rd.Profiling(c, request)
+				if err == nil {
+					result.RootDaemonAddress = ri.Address
+				}
+				return err
+			})
+		}
+	})
+	return result, err
+}
+
 func (s *service) Quit(ctx context.Context, ex *empty.Empty) (*empty.Empty, error) {
 	s.LogCall(ctx, "Quit", func(c context.Context) {
 		s.sessionLock.RLock()
 		defer s.sessionLock.RUnlock()
-		s.cancelSessionReadLocked()
+		for _, ns := range s.sessions {
+			s.cancelSessionReadLocked(ns)
+		}
 		s.quit()
 		_ = s.withRootDaemon(ctx, func(ctx context.Context, rd daemon.DaemonClient) error {
 			_, err := rd.Quit(ctx, ex)
@@ -554,7 +674,9 @@ func (s *service) GetClusterSubnets(ctx context.Context, _ *empty.Empty) (cs *rp
 				}
 				return err
 			}
-			if mgrInfo.ServiceSubnet != nil {
+			if len(mgrInfo.ServiceSubnets) > 0 {
+				svcSubnets = append(svcSubnets, mgrInfo.ServiceSubnets...)
+			} else if mgrInfo.ServiceSubnet != nil {
 				svcSubnets = append(svcSubnets, mgrInfo.ServiceSubnet)
 			}
 			podSubnets = append(podSubnets, mgrInfo.PodSubnets...)
@@ -593,6 +715,73 @@ func (s *service) SetDNSMappings(ctx context.Context, req *daemon.SetDNSMappings
 	return &empty.Empty{}, err
 }
 
+func (s *service) SetProxySubnets(ctx context.Context, req *daemon.SetProxySubnetsRequest) (*emptypb.Empty, error) {
+	err := s.WithSession(ctx, "SetProxySubnets", func(ctx context.Context, session userd.Session) error {
+		return session.SetProxySubnets(ctx, req.AlsoProxySubnets, req.NeverProxySubnets)
+	})
+	return &empty.Empty{}, err
+}
+
+func (s *service) FlushDNSCache(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	err := s.WithSession(ctx, "FlushDNSCache", func(ctx context.Context, session userd.Session) error {
+		_, err := session.RootDaemon().FlushDNSCache(ctx, &empty.Empty{})
+		return err
+	})
+	return &empty.Empty{}, err
+}
+
+func (s *service) ListClientSessions(ctx context.Context, _ *emptypb.Empty) (result *manager.ClientSessionList, err error) {
+	err = s.WithSession(ctx, "ListClientSessions", func(ctx context.Context, session userd.Session) error {
+		result, err = session.ManagerClient().ListClientSessions(ctx, session.SessionInfo())
+		return err
+	})
+	return result, err
+}
+
+func (s *service) EvictClientSession(ctx context.Context, req *manager.SessionInfo) (*emptypb.Empty, error) {
+	err := s.WithSession(ctx, "EvictClientSession", func(ctx context.Context, session userd.Session) error {
+		_, err := session.ManagerClient().EvictClientSession(ctx, &manager.EvictClientSessionRequest{
+			Session:   session.SessionInfo(),
+			SessionId: req.SessionId,
+		})
+		return err
+	})
+	return &empty.Empty{}, err
+}
+
+func (s *service) GetDeclaredIntercept(ctx context.Context, req *manager.GetDeclaredInterceptRequest) (rs *manager.DeclaredIntercept, err error) {
+	err = s.WithSession(ctx, "GetDeclaredIntercept", func(ctx context.Context, session userd.Session) error {
+		req.Session = session.SessionInfo()
+		rs, err = session.ManagerClient().GetDeclaredIntercept(ctx, req)
+		return err
+	})
+	return rs, err
+}
+
+func (s *service) GetAgentConfigGCReport(ctx context.Context, _ *empty.Empty) (rs *manager.AgentConfigGCReport, err error) {
+	err = s.WithSession(ctx, "GetAgentConfigGCReport", func(ctx context.Context, session userd.Session) error {
+		rs, err = session.ManagerClient().GetAgentConfigGCReport(ctx, &empty.Empty{})
+		return err
+	})
+	return rs, err
+}
+
+func (s *service) GetConnectionStats(ctx context.Context, _ *empty.Empty) (rs *manager.ConnectionStatsResponse, err error) {
+	err = s.WithSession(ctx, "GetConnectionStats", func(ctx context.Context, session userd.Session) error {
+		rs, err = session.ManagerClient().GetConnectionStats(ctx, session.SessionInfo())
+		return err
+	})
+	return rs, err
+}
+
+func (s *service) GetManagerConfig(ctx context.Context, _ *empty.Empty) (rs *manager.ManagerConfig, err error) {
+	err = s.WithSession(ctx, "GetManagerConfig", func(ctx context.Context, session userd.Session) error {
+		rs, err = session.ManagerClient().GetManagerConfig(ctx, &empty.Empty{})
+		return err
+	})
+	return rs, err
+}
+
 func (s *service) withRootDaemon(ctx context.Context, f func(ctx context.Context, daemonClient daemon.DaemonClient) error) error {
 	if s.rootSessionInProc {
 		return status.Error(codes.Unavailable, "root daemon is embedded")
@@ -607,3 +796,38 @@ func (s *service) withRootDaemon(ctx context.Context, f func(ctx context.Context
 	}
 	return err
 }
+
+func (s *service) WatchInterceptEvents(rq *manager.InterceptEventsRequest, stream rpc.Connector_WatchInterceptEventsServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+	err := s.WithSession(stream.Context(), "WatchInterceptEvents", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The session context outlives the stream, so the call to the traffic-manager must end with either of them.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+
+	events, err := session.ManagerClient().WatchInterceptEvents(ctx, rq)
+	if err != nil {
+		return err
+	}
+	for {
+		ev, err := events.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err = stream.Send(ev); err != nil {
+			return err
+		}
+	}
+}
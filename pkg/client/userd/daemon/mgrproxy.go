@@ -15,37 +15,61 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
 )
 
 // mgrProxy implements connector.ManagerProxyServer, but just proxies all requests through a manager.ManagerClient.
+// There's one manager.ManagerClient for each connection of the user daemon, and the caller identifies the
+// connection using gRPC metadata.
 type mgrProxy struct {
 	sync.RWMutex
-	clientX      manager.ManagerClient
-	callOptionsX []grpc.CallOption
+	clients map[string]proxiedClient // keyed by connection name
 
 	connector.UnsafeManagerProxyServer
 }
 
+type proxiedClient struct {
+	client      manager.ManagerClient
+	callOptions []grpc.CallOption
+}
+
 var _ connector.ManagerProxyServer = &mgrProxy{}
 
-func (p *mgrProxy) setClient(client manager.ManagerClient, callOptions ...grpc.CallOption) {
+// setClient sets the client of the given connection, or removes it when the given client is nil.
+func (p *mgrProxy) setClient(name string, mc manager.ManagerClient, callOptions ...grpc.CallOption) {
 	p.Lock()
-	p.clientX = client
-	p.callOptionsX = callOptions
+	if mc == nil {
+		delete(p.clients, name)
+	} else {
+		if p.clients == nil {
+			p.clients = make(map[string]proxiedClient)
+		}
+		p.clients[name] = proxiedClient{client: mc, callOptions: callOptions}
+	}
 	p.Unlock()
 }
 
-func (p *mgrProxy) get() (manager.ManagerClient, []grpc.CallOption, error) {
+// get returns the client of the connection that the caller identifies. A caller that doesn't identify its
+// connection gets the client of the only connection.
+func (p *mgrProxy) get(ctx context.Context) (manager.ManagerClient, []grpc.CallOption, error) {
 	p.RLock()
 	defer p.RUnlock()
-	if p.clientX == nil {
+	var pc proxiedClient
+	if name := client.ConnectionNameFromContext(ctx); name != "" {
+		pc = p.clients[name]
+	} else if len(p.clients) == 1 {
+		for _, c := range p.clients {
+			pc = c
+		}
+	}
+	if pc.client == nil {
 		return nil, nil, status.Error(codes.Unavailable, "telepresence: the userd is not connected to the manager")
 	}
-	return p.clientX, p.callOptionsX, nil
+	return pc.client, pc.callOptions, nil
 }
 
 func (p *mgrProxy) Version(ctx context.Context, arg *emptypb.Empty) (*manager.VersionInfo2, error) {
-	client, callOptions, err := p.get()
+	client, callOptions, err := p.get(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,22 +77,28 @@ func (p *mgrProxy) Version(ctx context.Context, arg *emptypb.Empty) (*manager.Ve
 }
 
 func (p *mgrProxy) GetClientConfig(ctx context.Context, arg *emptypb.Empty) (*manager.CLIConfig, error) {
-	client, callOptions, err := p.get()
+	client, callOptions, err := p.get(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return client.GetClientConfig(ctx, arg, callOptions...)
 }
 
-type tmReceiver interface {
-	Recv() (*manager.TunnelMessage, error)
+// tunnelMessage is a message that is proxied by the Tunnel and MuxTunnel calls.
+type tunnelMessage interface {
+	*manager.TunnelMessage | *manager.MuxTunnelMessage
+	GetPayload() []byte
 }
 
-type tmSender interface {
-	Send(*manager.TunnelMessage) error
+type tmReceiver[M tunnelMessage] interface {
+	Recv() (M, error)
 }
 
-func recvLoop(ctx context.Context, who string, in tmReceiver, out chan<- *manager.TunnelMessage, wg *sync.WaitGroup) {
+type tmSender[M tunnelMessage] interface {
+	Send(M) error
+}
+
+func recvLoop[M tunnelMessage](ctx context.Context, who string, in tmReceiver[M], out chan<- M, wg *sync.WaitGroup) {
 	defer func() {
 		dlog.Tracef(ctx, "%s Recv loop ended", who)
 		close(out)
@@ -83,7 +113,7 @@ func recvLoop(ctx context.Context, who string, in tmReceiver, out chan<- *manage
 			}
 			return
 		}
-		dlog.Tracef(ctx, "<- %s %d", who, len(payload.Payload))
+		dlog.Tracef(ctx, "<- %s %d", who, len(payload.GetPayload()))
 		select {
 		case <-ctx.Done():
 			return
@@ -92,7 +122,7 @@ func recvLoop(ctx context.Context, who string, in tmReceiver, out chan<- *manage
 	}
 }
 
-func sendLoop(ctx context.Context, who string, out tmSender, in <-chan *manager.TunnelMessage, wg *sync.WaitGroup) {
+func sendLoop[M tunnelMessage](ctx context.Context, who string, out tmSender[M], in <-chan M, wg *sync.WaitGroup) {
 	defer func() {
 		dlog.Tracef(ctx, "%s Send loop ended", who)
 		wg.Done()
@@ -119,17 +149,17 @@ func sendLoop(ctx context.Context, who string, out tmSender, in <-chan *manager.
 				}
 				return
 			}
-			dlog.Tracef(ctx, "-> %s %d", who, len(payload.Payload))
+			dlog.Tracef(ctx, "-> %s %d", who, len(payload.GetPayload()))
 		}
 	}
 }
 
 func (p *mgrProxy) Tunnel(fhClient connector.ManagerProxy_TunnelServer) error {
-	client, callOptions, err := p.get()
+	ctx := fhClient.Context()
+	client, callOptions, err := p.get(ctx)
 	if err != nil {
 		return err
 	}
-	ctx := fhClient.Context()
 	fhManager, err := client.Tunnel(ctx, callOptions...)
 	if err != nil {
 		return err
@@ -147,8 +177,42 @@ func (p *mgrProxy) Tunnel(fhClient connector.ManagerProxy_TunnelServer) error {
 	return nil
 }
 
+func (p *mgrProxy) MuxTunnel(fhClient connector.ManagerProxy_MuxTunnelServer) error {
+	ctx := fhClient.Context()
+	client, callOptions, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	fhManager, err := client.MuxTunnel(ctx, callOptions...)
+	if err != nil {
+		return err
+	}
+
+	// The first message tells the client whether the manager supports MuxTunnel. An error, such as
+	// codes.Unimplemented from an older traffic-manager, must be returned as is, so that the client
+	// can fall back to using Tunnel.
+	hello, err := fhManager.Recv()
+	if err != nil {
+		return err
+	}
+	if err = fhClient.Send(hello); err != nil {
+		return err
+	}
+	mgrToClient := make(chan *manager.MuxTunnelMessage)
+	clientToMgr := make(chan *manager.MuxTunnelMessage)
+
+	wg := sync.WaitGroup{}
+	wg.Add(4)
+	go recvLoop(ctx, "manager", fhManager, mgrToClient, &wg)
+	go sendLoop(ctx, "manager", fhManager, clientToMgr, &wg)
+	go recvLoop(ctx, "client", fhClient, clientToMgr, &wg)
+	go sendLoop(ctx, "client", fhClient, mgrToClient, &wg)
+	wg.Wait()
+	return nil
+}
+
 func (p *mgrProxy) EnsureAgent(ctx context.Context, arg *manager.EnsureAgentRequest) (*emptypb.Empty, error) {
-	client, callOptions, err := p.get()
+	client, callOptions, err := p.get(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -156,15 +220,31 @@ func (p *mgrProxy) EnsureAgent(ctx context.Context, arg *manager.EnsureAgentRequ
 }
 
 func (p *mgrProxy) LookupDNS(ctx context.Context, arg *manager.DNSRequest) (*manager.DNSResponse, error) {
-	client, callOptions, err := p.get()
+	client, callOptions, err := p.get(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return client.LookupDNS(ctx, arg, callOptions...)
 }
 
+func (p *mgrProxy) QUICEndpoint(ctx context.Context, arg *manager.SessionInfo) (*manager.QUICEndpointResponse, error) {
+	client, callOptions, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.QUICEndpoint(ctx, arg, callOptions...)
+}
+
+func (p *mgrProxy) WireGuardPeer(ctx context.Context, arg *manager.WireGuardPeerRequest) (*manager.WireGuardPeerResponse, error) {
+	client, callOptions, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.WireGuardPeer(ctx, arg, callOptions...)
+}
+
 func (p *mgrProxy) WatchClusterInfo(arg *manager.SessionInfo, srv connector.ManagerProxy_WatchClusterInfoServer) error {
-	client, callOptions, err := p.get()
+	client, callOptions, err := p.get(srv.Context())
 	if err != nil {
 		return err
 	}
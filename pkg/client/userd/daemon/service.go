@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -71,11 +72,9 @@ type service struct {
 	// is in effect (rootSessionInProc == true).
 	quitDisable bool
 
-	session         userd.Session
-	sessionCancel   context.CancelFunc
-	sessionContext  context.Context
-	sessionQuitting int32 // atomic boolean. True if non-zero.
-	sessionLock     sync.RWMutex
+	// The sessions, one for each connection name.
+	sessions    map[string]*namedSession
+	sessionLock sync.RWMutex
 
 	// These are used to communicate between the various goroutines.
 	connectRequest  chan userd.ConnectRequest // server-grpc.connect() -> connectWorker
@@ -83,9 +82,21 @@ type service struct {
 
 	fuseFtpMgr remotefs.FuseFTPManager
 
+	// The debug HTTP listener that serves pprof profiles, goroutine dumps, and runtime metrics.
+	profiler pprof.Server
+
 	// Run root session in-process
 	rootSessionInProc bool
 
+	// True when all networking is handled in user space, which implies that the root session runs in-process.
+	userSpace bool
+
+	// The address of the optional SOCKS5 proxy. Only set when all networking is handled in user space.
+	socksAddress string
+
+	// The address of the optional HTTP proxy. Only set when all networking is handled in user space.
+	httpAddress string
+
 	// The TCP address that the daemon listens to. Will be nil if the daemon listens to a unix socket.
 	daemonAddress *net.TCPAddr
 
@@ -93,12 +104,23 @@ type service struct {
 	self userd.Service
 }
 
+// namedSession is a session of the user daemon, together with the name of its connection and the context
+// and cancel function that control it.
+type namedSession struct {
+	userd.Session
+	name     string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	quitting int32 // atomic boolean. True if non-zero.
+}
+
 func NewService(ctx context.Context, _ *dgroup.Group, cfg client.Config, srv *grpc.Server) (userd.Service, error) {
 	s := &service{
 		srv:             srv,
 		connectRequest:  make(chan userd.ConnectRequest),
 		connectResponse: make(chan *rpc.ConnectInfo),
 		managerProxy:    &mgrProxy{},
+		sessions:        make(map[string]*namedSession),
 		timedLogLevel:   log.NewTimedLevel(cfg.LogLevels().UserDaemon.String(), log.SetLevel),
 		fuseFtpMgr:      remotefs.NewFuseFTPManager(),
 	}
@@ -107,10 +129,17 @@ func NewService(ctx context.Context, _ *dgroup.Group, cfg client.Config, srv *gr
 		// The podd daemon never registers the gRPC servers
 		rpc.RegisterConnectorServer(srv, s)
 		rpc.RegisterManagerProxyServer(srv, s.managerProxy)
-		tracer, err := tracing.NewTraceServer(ctx, "user-daemon")
+		tracer, err := tracing.NewTraceServer(tracing.WithExporter(ctx, cfg.Tracing().Exporter()), "user-daemon")
 		if err != nil {
 			return nil, err
 		}
+		go func() {
+			// Flush the spans that remain to be exported.
+			<-ctx.Done()
+			c, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+			tracer.Shutdown(c)
+			cancel()
+		}()
 		common.RegisterTracingServer(srv, tracer)
 	} else {
 		s.rootSessionInProc = true
@@ -149,6 +178,18 @@ func (s *service) RootSessionInProcess() bool {
 	return s.rootSessionInProc
 }
 
+func (s *service) UserSpaceNetwork() bool {
+	return s.userSpace
+}
+
+func (s *service) SOCKSAddress() string {
+	return s.socksAddress
+}
+
+func (s *service) HTTPProxyAddress() string {
+	return s.httpAddress
+}
+
 func (s *service) Server() *grpc.Server {
 	return s.srv
 }
@@ -171,15 +212,23 @@ func (s *service) ReadConnectResponse(ctx context.Context) (result *rpc.ConnectI
 	return
 }
 
-func (s *service) SetManagerClient(managerClient manager.ManagerClient, callOptions ...grpc.CallOption) {
-	s.managerProxy.setClient(managerClient, callOptions...)
+func (s *service) SetManagerClient(name string, managerClient manager.ManagerClient, callOptions ...grpc.CallOption) {
+	s.managerProxy.setClient(name, managerClient, callOptions...)
 }
 
 const (
 	nameFlag         = "name"
 	addressFlag      = "address"
 	embedNetworkFlag = "embed-network"
+	userSpaceFlag    = "userspace-network"
+	socksAddressFlag = "socks-address"
+	httpAddressFlag  = "http-proxy-address"
 	pprofFlag        = "pprof"
+	tlsCertFlag      = "tls-cert"
+	tlsKeyFlag       = "tls-key"
+	tlsClientCAFlag  = "tls-client-ca"
+
+	defaultSOCKSAddress = "127.0.0.1:1080"
 )
 
 // Command returns the CLI sub-command for "connector-foreground".
@@ -196,7 +245,15 @@ func Command() *cobra.Command {
 	flags.String(nameFlag, userd.ProcessName, "Daemon name")
 	flags.String(addressFlag, "", "Address to listen to. Defaults to "+socket.UserDaemonPath(context.Background()))
 	flags.Bool(embedNetworkFlag, false, "Embed network functionality in the user daemon. Requires capability NET_ADMIN")
+	flags.Bool(userSpaceFlag, false, "Embed network functionality in the user daemon and handle it entirely in user space. "+
+		"No TUN device is created, and the cluster is made available through a SOCKS5 proxy")
+	flags.String(socksAddressFlag, defaultSOCKSAddress, "Address that the SOCKS5 proxy listens to when --"+userSpaceFlag+" is used. "+
+		"No SOCKS5 proxy is started when it is empty")
+	flags.String(httpAddressFlag, "", "Address that an HTTP proxy listens to when --"+userSpaceFlag+" is used")
 	flags.Uint16(pprofFlag, 0, "start pprof server on the given port")
+	flags.String(tlsCertFlag, "", "Certificate that the daemon presents when --"+addressFlag+" is used. Enables mutual TLS")
+	flags.String(tlsKeyFlag, "", "Key of the certificate given by --"+tlsCertFlag)
+	flags.String(tlsClientCAFlag, "", "CA certificate that must have signed the certificates of the clients when --"+tlsCertFlag+" is used")
 	return c
 }
 
@@ -208,10 +265,16 @@ func (s *service) configReload(c context.Context) error {
 	return client.Watch(c, func(ctx context.Context) error {
 		s.sessionLock.RLock()
 		defer s.sessionLock.RUnlock()
-		if s.session == nil {
+		if len(s.sessions) == 0 {
 			return client.RestoreDefaults(c, false)
 		}
-		return s.session.ApplyConfig(c)
+		var errs []error
+		for _, ns := range s.sessions {
+			if err := ns.ApplyConfig(c); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
 	})
 }
 
@@ -234,26 +297,76 @@ func (s *service) ManageSessions(c context.Context) error {
 			default:
 				// Nobody left to read the response? That's fine really. Just means that
 				// whoever wanted to start the session terminated early.
-				s.cancelSession()
+				s.cancelSession(rsp.ConnectionName)
 			}
 		}
 	}
 }
 
-func (s *service) startSession(ctx context.Context, cr userd.ConnectRequest, wg *sync.WaitGroup) *rpc.ConnectInfo {
-	s.sessionLock.Lock() // Locked during creation
-	defer s.sessionLock.Unlock()
+// callerConnection returns the name of the connection that the caller of the given connect request identified,
+// or an empty string if it didn't identify one.
+func callerConnection(cr userd.ConnectRequest) string {
+	if ci, ok := cr.(crImpl); ok {
+		return ci.caller
+	}
+	return ""
+}
 
-	if s.session != nil {
-		// UpdateStatus sets rpc.ConnectInfo_ALREADY_CONNECTED if successful
-		return s.session.UpdateStatus(s.sessionContext, cr)
+// switchSession is called when a connect request asks to switch context. The session that the new session
+// replaces is the one with the given name when its context or kubeconfig has changed, or else the one that the
+// caller identified. The replaced session is looked up and canceled while the sessionLock is held, so that no
+// other session can be canceled in its place, and it is then returned so that startSession can remove it under
+// the same lock that the new session is created under. A nil session is returned when no session is replaced.
+// A non-nil response must be returned to the caller as is.
+//
+// The root daemon's session of the replaced session is disconnected rather than re-targeted, because its TUN
+// device, routes, and DNS configuration are bound to the traffic-manager of the cluster that it connects to.
+func (s *service) switchSession(ctx context.Context, cr userd.ConnectRequest, name, caller string) (*namedSession, *rpc.ConnectInfo) {
+	s.sessionLock.RLock()
+	defer s.sessionLock.RUnlock()
+	ns, ok := s.sessions[name]
+	if ok {
+		if rsp := ns.UpdateStatus(ns.ctx, cr); rsp.Error != rpc.ConnectInfo_MUST_RESTART {
+			return nil, rsp
+		}
+	} else {
+		if caller == "" && len(s.sessions) == 1 {
+			// An older client that doesn't identify its connection switches the only one.
+			for caller = range s.sessions {
+			}
+		}
+		if ns, ok = s.sessions[caller]; !ok {
+			return nil, nil
+		}
+	}
+	if s.rootSessionInProc {
+		return nil, &rpc.ConnectInfo{
+			Error:         rpc.ConnectInfo_DAEMON_FAILED,
+			ErrorText:     "a daemon with an embedded network cannot switch context, please quit and reconnect",
+			ErrorCategory: int32(errcat.User),
+		}
+	}
+	if !atomic.CompareAndSwapInt32(&ns.quitting, 0, 1) {
+		// The session is already ending, e.g. because it was disconnected. Wait for it to end.
+		ns.cancel()
+		return ns, nil
 	}
+	dlog.Infof(ctx, "Switching from connection %s to a new context", ns.name)
+	s.cancelSessionReadLocked(ns)
+	s.disconnectRootSession(ctx, ns)
+	return ns, nil
+}
 
+func (s *service) startSession(ctx context.Context, cr userd.ConnectRequest, wg *sync.WaitGroup) *rpc.ConnectInfo {
 	// Obtain the kubeconfig from the request parameters so that we can determine
 	// what kubernetes context that will be used.
 	config, err := client.DaemonKubeconfig(ctx, cr.Request())
+	var daemonID *daemon.Identifier
+	if err == nil {
+		daemonID, err = daemon.NewIdentifier(cr.Request().Name, config.Context, config.Namespace, proc.RunningInContainer())
+	}
 	if err != nil {
-		if s.rootSessionInProc {
+		if s.rootSessionInProc && s.sessionCount() == 0 {
 			s.quit()
 		}
 		dlog.Errorf(ctx, "Failed to obtain kubeconfig: %v", err)
@@ -263,20 +376,40 @@ func (s *service) startSession(ctx context.Context, cr userd.ConnectRequest, wg
 			ErrorCategory: int32(errcat.GetCategory(err)),
 		}
 	}
+	name := daemonID.Name
 
-	ctx, cancel := context.WithCancel(ctx)
-	ctx = userd.WithService(ctx, s.self)
+	var replaced *namedSession
+	if cr.Request().SwitchContext {
+		var rsp *rpc.ConnectInfo
+		if replaced, rsp = s.switchSession(ctx, cr, name, callerConnection(cr)); rsp != nil {
+			return rsp
+		}
+	}
+	s.sessionLock.Lock() // Locked during creation
+	defer s.sessionLock.Unlock()
 
-	daemonID, err := daemon.NewIdentifier(cr.Request().Name, config.Context, config.Namespace, proc.RunningInContainer())
-	if err != nil {
-		cancel()
+	if replaced != nil && s.sessions[replaced.name] == replaced {
+		s.self.SetManagerClient(replaced.name, nil)
+		delete(s.sessions, replaced.name)
+	}
+
+	if ns, ok := s.sessions[name]; ok {
+		// UpdateStatus sets rpc.ConnectInfo_ALREADY_CONNECTED if successful
+		return ns.UpdateStatus(ns.ctx, cr)
+	}
+	if s.rootSessionInProc && len(s.sessions) > 0 {
 		return &rpc.ConnectInfo{
-			Error:         rpc.ConnectInfo_CLUSTER_FAILED,
-			ErrorText:     err.Error(),
-			ErrorCategory: int32(errcat.GetCategory(err)),
+			Error:         rpc.ConnectInfo_DAEMON_FAILED,
+			ErrorText:     "a daemon with an embedded network can only maintain one connection, please quit and reconnect",
+			ErrorCategory: int32(errcat.User),
 		}
 	}
-	go runAliveAndCancellation(ctx, cancel, daemonID)
+
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	ctx = userd.WithService(ctx, s.self)
+
+	go runAliveAndCancellation(ctx, cancel, daemonID, func() (*daemon.Health, bool) { return s.sessionHealth(name) })
 
 	ctx, session, rsp := userd.GetNewSessionFunc(ctx)(ctx, cr, config)
 	if ctx.Err() != nil || rsp.Error != rpc.ConnectInfo_UNSPECIFIED {
@@ -287,39 +420,60 @@ func (s *service) startSession(ctx context.Context, cr userd.ConnectRequest, wg
 		}
 		return rsp
 	}
-	s.session = session
-	s.sessionContext = userd.WithSession(ctx, session)
-	s.sessionCancel = func() {
-		cancel()
-		<-session.Done()
+	ns := &namedSession{
+		Session: session,
+		name:    name,
+		ctx:     userd.WithSession(ctx, session),
+		cancel: func() {
+			cancel()
+			<-session.Done()
+		},
 	}
+	s.sessions[name] = ns
 
 	// Run the session asynchronously. We must be able to respond to connect (with UpdateStatus) while
-	// the session is running. The s.sessionCancel is called from Disconnect
+	// the session is running. The ns.cancel is called from Disconnect
 	wg.Add(1)
 	go func(cr userd.ConnectRequest) {
+		var expired []*userd.RestorableIntercept
+		refresh := false
 		defer func() {
 			s.sessionLock.Lock()
-			s.self.SetManagerClient(nil)
-			s.session = nil
-			s.sessionCancel = nil
-			if err := client.RestoreDefaults(ctx, false); err != nil {
-				dlog.Warn(ctx, err)
+			if s.sessions[name] == ns {
+				// The session might already have been replaced when switching context.
+				s.self.SetManagerClient(name, nil)
+				delete(s.sessions, name)
+			}
+			if len(s.sessions) == 0 {
+				// Only restore the defaults when no other session exists. A session that was replaced when
+				// switching context must not clobber the configuration of the session that replaced it.
+				if err := client.RestoreDefaults(ctx, false); err != nil {
+					dlog.Warn(ctx, err)
+				}
 			}
 			s.sessionLock.Unlock()
+			if refresh {
+				wg.Add(1)
+				go s.refreshSession(parentCtx, cr, name, expired, wg)
+			}
 			wg.Done()
 		}()
-		if err := session.RunSession(s.sessionContext); err != nil {
-			if errors.Is(err, trafficmgr.ErrSessionExpired) {
-				// Session has expired. We need to cancel the owner session and reconnect
+		if err := session.RunSession(ns.ctx); err != nil {
+			if errors.Is(err, trafficmgr.ErrSessionExpired) || errors.Is(err, trafficmgr.ErrNetworkChanged) {
+				// Session has expired, typically because the traffic-manager was restarted or because
+				// another traffic-manager replica took over the leadership, or its connection broke when
+				// the host changed network or woke up from sleep. We need to cancel the owner session,
+				// reconnect, and then recreate the intercepts of the old session.
 				dlog.Info(ctx, "refreshing session")
-				s.cancelSession()
-				select {
-				case <-ctx.Done():
-				case s.connectRequest <- cr:
-				}
+				expired = session.DetachIntercepts(ctx)
+				s.cancelSession(name)
+				refresh = true
 				return
 			}
+			if errors.Is(err, trafficmgr.ErrSessionEvicted) {
+				// The session was evicted by an administrator, so its intercepts must end with it.
+				s.cancelSession(name)
+			}
 
 			dlog.Error(ctx, err)
 		}
@@ -331,12 +485,100 @@ func (s *service) startSession(ctx context.Context, cr userd.ConnectRequest, wg
 	return rsp
 }
 
-func runAliveAndCancellation(ctx context.Context, cancel context.CancelFunc, daemonID *daemon.Identifier) {
+// sessionRefreshAttempts and sessionRefreshDelay control how many times, and how often, the start of a
+// session that replaces an expired session is attempted. The traffic-manager might be unavailable for a
+// while, e.g. while a standby replica takes over the leadership.
+const (
+	sessionRefreshAttempts = 10
+	sessionRefreshDelay    = 3 * time.Second
+)
+
+// refreshSession starts a new session that replaces an expired session, and then recreates the given
+// intercepts of the expired session. Their intercept handlers are associated with the new intercepts.
+func (s *service) refreshSession(ctx context.Context, cr userd.ConnectRequest, connName string, ris []*userd.RestorableIntercept, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for i := 1; ; i++ {
+		rsp := s.startSession(ctx, cr, wg)
+		if rsp.Error == rpc.ConnectInfo_UNSPECIFIED || rsp.Error == rpc.ConnectInfo_ALREADY_CONNECTED {
+			break
+		}
+		if i == sessionRefreshAttempts {
+			dlog.Errorf(ctx, "unable to refresh session: %s", rsp.ErrorText)
+			return
+		}
+		dlog.Warnf(ctx, "unable to refresh session, will retry in %s: %s", sessionRefreshDelay, rsp.ErrorText)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sessionRefreshDelay):
+		}
+	}
+
+	s.sessionLock.RLock()
+	ns, ok := s.sessions[connName]
+	s.sessionLock.RUnlock()
+	if !ok {
+		return
+	}
+	session, sessionCtx := ns.Session, ns.ctx
+	for _, ri := range ris {
+		name := ri.Request.Spec.Name
+
+		// The new session reuses the ID of the old session when the traffic-manager still knows about it,
+		// which is the case when the old session ended because of a network change. Its intercepts must
+		// then be removed before they can be recreated.
+		if ri.SessionID == session.SessionInfo().SessionId {
+			_, err := session.ManagerClient().RemoveIntercept(sessionCtx, &manager.RemoveInterceptRequest2{
+				Session: session.SessionInfo(),
+				Name:    name,
+			})
+			if err != nil && status.Code(err) != codes.NotFound {
+				dlog.Errorf(ctx, "unable to remove intercept %s before recreating it: %v", name, err)
+				continue
+			}
+		}
+		result := session.AddIntercept(sessionCtx, ri.Request)
+		if result.Error != common.InterceptError_UNSPECIFIED {
+			dlog.Errorf(ctx, "unable to recreate intercept %s: %s", name, result.ErrorText)
+			continue
+		}
+		dlog.Infof(ctx, "recreated intercept %s", name)
+		if ih := ri.Interceptor; ih != nil {
+			ih.InterceptId = result.InterceptInfo.Id
+			if err := session.AddInterceptor(ih.InterceptId, ih); err != nil {
+				dlog.Errorf(ctx, "unable to associate intercept %s with its handler: %v", name, err)
+			}
+		}
+	}
+}
+
+// sessionHealth returns the health of the session with the given name, or nil when there is no such session.
+// The session lock is held for a long time while connecting, and false is then returned, so that the health
+// is reported as unchanged rather than delaying the caller.
+func (s *service) sessionHealth(name string) (*daemon.Health, bool) {
+	if !s.sessionLock.TryRLock() {
+		return nil, false
+	}
+	defer s.sessionLock.RUnlock()
+	if ns, ok := s.sessions[name]; ok {
+		return ns.Health(), true
+	}
+	return nil, true
+}
+
+// sessionCount returns the number of sessions.
+func (s *service) sessionCount() int {
+	s.sessionLock.RLock()
+	defer s.sessionLock.RUnlock()
+	return len(s.sessions)
+}
+
+func runAliveAndCancellation(ctx context.Context, cancel context.CancelFunc, daemonID *daemon.Identifier, health func() (*daemon.Health, bool)) {
 	daemonInfoFile := daemonID.InfoFileName()
 	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
 	g.Go(fmt.Sprintf("info-kicker-%s", daemonID), func(ctx context.Context) error {
 		// Ensure that the daemon info file is kept recent. This tells clients that we're alive.
-		return daemon.KeepInfoAlive(ctx, daemonInfoFile)
+		return daemon.KeepInfoAlive(ctx, daemonInfoFile, health)
 	})
 	g.Go(fmt.Sprintf("info-watcher-%s", daemonID), func(ctx context.Context) error {
 		// Cancel the session if the daemon info file is removed.
@@ -354,29 +596,32 @@ func runAliveAndCancellation(ctx context.Context, cancel context.CancelFunc, dae
 	}
 }
 
-func (s *service) cancelSessionReadLocked() {
-	if s.sessionCancel != nil {
-		if err := s.session.ClearIntercepts(s.sessionContext); err != nil {
-			dlog.Errorf(s.sessionContext, "failed to clear intercepts: %v", err)
-		}
-		s.sessionCancel()
+func (s *service) cancelSessionReadLocked(ns *namedSession) {
+	if err := ns.ClearIntercepts(ns.ctx); err != nil {
+		dlog.Errorf(ns.ctx, "failed to clear intercepts: %v", err)
 	}
+	ns.cancel()
 }
 
-func (s *service) cancelSession() {
-	if !atomic.CompareAndSwapInt32(&s.sessionQuitting, 0, 1) {
+// cancelSession cancels the session with the given name, and removes it. It does nothing if no such session
+// exists.
+func (s *service) cancelSession(name string) {
+	s.sessionLock.RLock()
+	ns, ok := s.sessions[name]
+	if !ok || !atomic.CompareAndSwapInt32(&ns.quitting, 0, 1) {
+		s.sessionLock.RUnlock()
 		return
 	}
-	s.sessionLock.RLock()
-	s.cancelSessionReadLocked()
+	s.cancelSessionReadLocked(ns)
 	s.sessionLock.RUnlock()
 
 	// We have to cancel the session before we can acquire this write-lock, because we need any long-running RPCs
 	// that may be holding the RLock to die.
 	s.sessionLock.Lock()
-	s.session = nil
-	s.sessionCancel = nil
-	atomic.StoreInt32(&s.sessionQuitting, 0)
+	if s.sessions[name] == ns {
+		s.self.SetManagerClient(name, nil)
+		delete(s.sessions, name)
+	}
 	s.sessionLock.Unlock()
 }
 
@@ -394,13 +639,7 @@ func run(cmd *cobra.Command, _ []string) error {
 	// the connection/socket/pipe to appear before it gives up.
 	var grpcListener net.Listener
 	flags := cmd.Flags()
-	if pprofPort, _ := flags.GetUint16(pprofFlag); pprofPort > 0 {
-		go func() {
-			if err := pprof.PprofServer(c, pprofPort); err != nil {
-				dlog.Error(c, err)
-			}
-		}()
-	}
+	pprofPort, _ := flags.GetUint16(pprofFlag)
 
 	name, _ := flags.GetString(nameFlag)
 	sessionName := "session"
@@ -414,6 +653,26 @@ func run(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 	rootSessionInProc, _ := flags.GetBool(embedNetworkFlag)
+	var socksAddress, httpAddress string
+	userSpace, _ := flags.GetBool(userSpaceFlag)
+	if userSpace {
+		rootSessionInProc = true
+		socksAddress, _ = flags.GetString(socksAddressFlag)
+		httpAddress, _ = flags.GetString(httpAddressFlag)
+	}
+	var tlsConfig *tls.Config
+	tlsCert, _ := flags.GetString(tlsCertFlag)
+	tlsKey, _ := flags.GetString(tlsKeyFlag)
+	tlsClientCA, _ := flags.GetString(tlsClientCAFlag)
+	if tlsCert != "" || tlsKey != "" || tlsClientCA != "" {
+		if addr, _ := flags.GetString(addressFlag); addr == "" {
+			return fmt.Errorf("--%s, --%s, and --%s require --%s", tlsCertFlag, tlsKeyFlag, tlsClientCAFlag, addressFlag)
+		}
+		if tlsConfig, err = client.MutualTLSConfig(tlsCert, tlsKey, tlsClientCA, true); err != nil {
+			return err
+		}
+	}
+
 	var daemonAddress *net.TCPAddr
 	if addr, _ := flags.GetString(addressFlag); addr != "" {
 		lc := net.ListenConfig{}
@@ -483,6 +742,9 @@ func run(cmd *cobra.Command, _ []string) error {
 	var s *service
 	si.As(&s)
 	s.rootSessionInProc = rootSessionInProc
+	s.userSpace = userSpace
+	s.socksAddress = socksAddress
+	s.httpAddress = httpAddress
 	s.daemonAddress = daemonAddress
 
 	if err := logging.LoadTimedLevelFromCache(c, s.timedLogLevel, userd.ProcessName); err != nil {
@@ -501,8 +763,15 @@ func run(cmd *cobra.Command, _ []string) error {
 
 	g.Go("server-grpc", func(c context.Context) (err error) {
 		sc := &dhttp.ServerConfig{Handler: s.srv}
-		dlog.Info(c, "gRPC server started")
-		if err = sc.Serve(c, grpcListener); err != nil && c.Err() != nil {
+		if tlsConfig != nil {
+			sc.TLSConfig = tlsConfig
+			dlog.Info(c, "gRPC server started, clients must use mutual TLS")
+			err = sc.ServeTLS(c, grpcListener, "", "")
+		} else {
+			dlog.Info(c, "gRPC server started")
+			err = sc.Serve(c, grpcListener)
+		}
+		if err != nil && c.Err() != nil {
 			err = nil // Normal shutdown
 		}
 		if err != nil {
@@ -513,6 +782,14 @@ func run(cmd *cobra.Command, _ []string) error {
 		return err
 	})
 
+	g.Go("profiling", func(c context.Context) error {
+		pc := cfg.Profiling()
+		port := pprofPort
+		if port == 0 {
+			port = pc.UserDaemonPort()
+		}
+		return s.profiler.Run(c, pprofPort > 0 || pc.Enabled(), port)
+	})
 	g.Go("config-reload", s.configReload)
 	g.Go(sessionName, func(c context.Context) error {
 		c, cancel := context.WithCancel(c)
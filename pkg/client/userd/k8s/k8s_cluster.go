@@ -11,6 +11,7 @@ import (
 	"github.com/blang/semver/v4"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/dlib/dtime"
@@ -20,6 +21,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 )
 
 const (
@@ -35,6 +37,9 @@ type Cluster struct {
 	// Main
 	ki kubernetes.Interface
 
+	// REST client for the OpenShift apps API. Nil unless the cluster serves DeploymentConfigs.
+	osc rest.Interface
+
 	// nsLock protects namespaceWatcherSnapshot, currentMappedNamespaces and namespaceListeners
 	nsLock sync.Mutex
 
@@ -143,6 +148,11 @@ func NewCluster(c context.Context, kubeFlags *client.Kubeconfig, namespaces []st
 	dlog.Infof(c, "Context: %s", ret.Context)
 	dlog.Infof(c, "Server: %s", ret.Server)
 
+	if ret.osc, err = openshift.NewRESTClient(timedC, rs); err != nil {
+		// DeploymentConfigs are optional, so this isn't fatal.
+		dlog.Errorf(c, "unable to create the OpenShift apps REST client: %v", err)
+	}
+
 	if len(namespaces) == 1 && namespaces[0] == "all" {
 		namespaces = nil
 	}
@@ -243,5 +253,8 @@ func (kc *Cluster) GetManagerInstallId(ctx context.Context) string {
 }
 
 func (kc *Cluster) WithK8sInterface(c context.Context) context.Context {
+	if kc.osc != nil {
+		c = openshift.WithRESTClient(c, kc.osc)
+	}
 	return k8sapi.WithK8sInterface(c, kc.ki)
 }
@@ -28,14 +28,26 @@ type Service interface {
 
 	Server() *grpc.Server
 
-	// SetManagerClient will assign the manager client that this Service will use when acting as
-	// a ManagerServer proxy
-	SetManagerClient(manager.ManagerClient, ...grpc.CallOption)
+	// SetManagerClient will assign the manager client that this Service will use for the connection with
+	// the given name when acting as a ManagerServer proxy. A nil client removes the connection's client.
+	SetManagerClient(string, manager.ManagerClient, ...grpc.CallOption)
 
 	// FuseFTPMgr returns the manager responsible for creating a client that can connect to the FuseFTP service.
 	FuseFTPMgr() remotefs.FuseFTPManager
 
 	RootSessionInProcess() bool
+
+	// UserSpaceNetwork returns true when the daemon handles all networking in user space.
+	UserSpaceNetwork() bool
+
+	// SOCKSAddress returns the address of the SOCKS5 proxy that provides access to the cluster when
+	// the daemon handles all networking in user space, or an empty string when no such proxy is used.
+	SOCKSAddress() string
+
+	// HTTPProxyAddress returns the address of the HTTP proxy that provides access to the cluster when
+	// the daemon handles all networking in user space, or an empty string when no such proxy is used.
+	HTTPProxyAddress() string
+
 	WithSession(context.Context, string, func(context.Context, Session) error) error
 
 	PostConnectRequest(context.Context, ConnectRequest) error
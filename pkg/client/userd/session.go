@@ -17,6 +17,7 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 )
 
@@ -35,6 +36,19 @@ type InterceptInfo interface {
 	PortIdentifier() (agentconfig.PortIdentifier, error)
 }
 
+// RestorableIntercept contains what's needed to recreate an intercept in a new session, after the
+// session that it belonged to has expired.
+type RestorableIntercept struct {
+	// Request is the request that recreates the intercept.
+	Request *rpc.CreateInterceptRequest
+
+	// Interceptor is the process or container that handles the intercept, or nil if no such handler exists.
+	Interceptor *rpc.Interceptor
+
+	// SessionID is the ID of the session that the intercept belonged to.
+	SessionID string
+}
+
 type KubeConfig interface {
 	GetContext() string
 	GetRestConfig() *rest.Config
@@ -57,6 +71,7 @@ type Session interface {
 	AddInterceptor(string, *rpc.Interceptor) error
 	RemoveInterceptor(string) error
 	ClearIntercepts(context.Context) error
+	DetachIntercepts(context.Context) []*RestorableIntercept
 
 	GetInterceptInfo(string) *manager.InterceptInfo
 	GetInterceptSpec(string) *manager.InterceptSpec
@@ -69,6 +84,7 @@ type Session interface {
 	NewRemainRequest() *manager.RemainRequest
 
 	Status(context.Context) *rpc.ConnectInfo
+	Health() *daemon.Health
 	UpdateStatus(context.Context, ConnectRequest) *rpc.ConnectInfo
 
 	Uninstall(context.Context, *rpc.UninstallRequest) (*common.Result, error)
@@ -84,12 +100,14 @@ type Session interface {
 	ForeachAgentPod(ctx context.Context, fn func(context.Context, typed.PodInterface, *core.Pod), filter func(*core.Pod) bool) error
 
 	GatherLogs(context.Context, *connector.LogsRequest) (*connector.LogsResponse, error)
+	StreamLogs(context.Context, *connector.StreamLogsRequest, func(*connector.LogLine) error) error
 	GatherTraces(ctx context.Context, tr *connector.TracesRequest) *common.Result
 
 	SessionInfo() *manager.SessionInfo
 	RootDaemon() rootdRpc.DaemonClient
 
 	ApplyConfig(context.Context) error
+	SetProxySubnets(ctx context.Context, alsoProxy, neverProxy []*manager.IPNet) error
 	GetConfig(context.Context) (*client.SessionConfig, error)
 	RunSession(c context.Context) error
 	StartServices(g *dgroup.Group)
@@ -0,0 +1,159 @@
+package trafficmgr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/kubectl/pkg/describe"
+	"sigs.k8s.io/yaml"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// recentEventsAge is the maximum age of the events that are gathered.
+const recentEventsAge = time.Hour
+
+// storeClusterState writes the given content to a file with the given name under the given exportDir
+// directory, and creates an entry with that name in the result map. The entry will either contain the
+// string "ok" or the error that prevented the content from being obtained or written.
+func storeClusterState(ctx context.Context, exportDir string, result *sync.Map, fileName string, content []byte, err error) {
+	if err == nil {
+		err = os.WriteFile(filepath.Join(exportDir, fileName), content, 0o666)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to get %s: %w", fileName, err)
+		dlog.Error(ctx, err)
+		result.Store(fileName, err.Error())
+		return
+	}
+	result.Store(fileName, "ok")
+}
+
+// getPodDescription stores the description of the given pod, as produced by "kubectl describe pod", in a
+// file named <POD NAME>.<POD NAMESPACE>.description.txt under the given exportDir directory. The pod doesn't
+// need to be running, so the description will reveal why it failed to start.
+func getPodDescription(ctx context.Context, exportDir string, result *sync.Map, pod *core.Pod) {
+	pd := describe.PodDescriber{Interface: k8sapi.GetK8sInterface(ctx)}
+	desc, err := pd.Describe(pod.Namespace, pod.Name, describe.DescriberSettings{ShowEvents: true, ChunkSize: 500})
+	storeClusterState(ctx, exportDir, result, pod.Name+"."+pod.Namespace+".description.txt", []byte(desc), err)
+}
+
+// getEvents stores the events of the given namespace that were seen during the last recentEventsAge in a
+// file named events.<NAMESPACE>.txt under the given exportDir directory. Failures to inject the traffic-agent
+// are often only visible as events.
+func getEvents(ctx context.Context, exportDir string, result *sync.Map, namespace string) {
+	var content []byte
+	el, err := k8sapi.GetK8sInterface(ctx).CoreV1().Events(namespace).List(ctx, meta.ListOptions{})
+	if err == nil {
+		content = formatEvents(el.Items, time.Now())
+	}
+	storeClusterState(ctx, exportDir, result, "events."+namespace+".txt", content, err)
+}
+
+// formatEvents returns a table of the given events that were seen during the last recentEventsAge
+// before now, sorted by the time they were last seen.
+func formatEvents(events []core.Event, now time.Time) []byte {
+	since := now.Add(-recentEventsAge)
+	recent := make([]*core.Event, 0, len(events))
+	for i := range events {
+		if ev := &events[i]; !eventTime(ev).Before(since) {
+			recent = append(recent, ev)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return eventTime(recent[i]).Before(eventTime(recent[j]))
+	})
+
+	buf := bytes.Buffer{}
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, ev := range recent {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s/%s\t%d\t%s\n",
+			duration.HumanDuration(now.Sub(eventTime(ev))),
+			ev.Type,
+			ev.Reason,
+			ev.InvolvedObject.Kind, ev.InvolvedObject.Name,
+			max(ev.Count, 1),
+			ev.Message)
+	}
+	_ = tw.Flush()
+	return buf.Bytes()
+}
+
+// eventTime returns the time when the given event was last seen.
+func eventTime(ev *core.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	default:
+		return ev.FirstTimestamp.Time
+	}
+}
+
+// getWebhookStatus stores the MutatingWebhookConfigurations that call a service in the given manager namespace
+// in a file named <CONFIGURATION NAME>.yaml under the given exportDir directory. Each file starts with comments
+// that describe the readiness of the endpoints of the services that the webhooks call. The CA bundles are
+// omitted. An entry named agent-injector-webhook.<MANAGER NAMESPACE>.txt is stored in the result map when no
+// such configuration is found.
+func getWebhookStatus(ctx context.Context, exportDir string, result *sync.Map, managerNamespace string) {
+	ki := k8sapi.GetK8sInterface(ctx)
+	wl, err := ki.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, meta.ListOptions{})
+	if err != nil {
+		storeClusterState(ctx, exportDir, result, "agent-injector-webhook."+managerNamespace+".txt", nil, err)
+		return
+	}
+	found := false
+	for i := range wl.Items {
+		wh := &wl.Items[i]
+		var status bytes.Buffer
+		for j := range wh.Webhooks {
+			svc := wh.Webhooks[j].ClientConfig.Service
+			if svc == nil || svc.Namespace != managerNamespace {
+				continue
+			}
+			fmt.Fprintf(&status, "# Webhook %s calls service %s.%s: %s\n", wh.Webhooks[j].Name, svc.Name, svc.Namespace, endpointsStatus(ctx, svc.Namespace, svc.Name))
+			wh.Webhooks[j].ClientConfig.CABundle = nil
+		}
+		if status.Len() == 0 {
+			continue
+		}
+		found = true
+		wh.ManagedFields = nil
+		b, err := yaml.Marshal(wh)
+		if err == nil {
+			b = append(status.Bytes(), b...)
+		}
+		storeClusterState(ctx, exportDir, result, wh.Name+".yaml", b, err)
+	}
+	if !found {
+		storeClusterState(ctx, exportDir, result, "agent-injector-webhook."+managerNamespace+".txt",
+			[]byte(fmt.Sprintf("no MutatingWebhookConfiguration calls a service in namespace %s\n", managerNamespace)), nil)
+	}
+}
+
+// endpointsStatus returns a description of the number of ready and not ready endpoints of the given service.
+func endpointsStatus(ctx context.Context, namespace, name string) string {
+	ep, err := k8sapi.GetK8sInterface(ctx).CoreV1().Endpoints(namespace).Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		return err.Error()
+	}
+	ready, notReady := 0, 0
+	for _, ss := range ep.Subsets {
+		ready += len(ss.Addresses)
+		notReady += len(ss.NotReadyAddresses)
+	}
+	return fmt.Sprintf("%d ready and %d not ready endpoints", ready, notReady)
+}
@@ -41,6 +41,7 @@ func (s *session) GetConfig(ctx context.Context) (*client.SessionConfig, error)
 			AlsoProxy:        subnets(oi.AlsoProxySubnets),
 			NeverProxy:       subnets(oi.NeverProxySubnets),
 			AllowConflicting: subnets(oi.AllowConflictingSubnets),
+			ExcludePorts:     oi.ProxyExcludePorts,
 		},
 		ManagerNamespace: s.GetManagerNamespace(),
 	}, nil
@@ -3,6 +3,7 @@ package trafficmgr
 import (
 	"context"
 
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
@@ -16,5 +17,10 @@ func (s *session) _dialRequestWatcher(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	return tunnel.DialWaitLoop(ctx, tunnel.ManagerProvider(s.managerClient), dialerStream, s.sessionInfo.SessionId)
+	// Streams to the traffic-manager that are created in response to dial requests offer the configured compression,
+	// and the dialers attached to them use the configured idle timeouts and keep-alives.
+	cfg := client.GetConfig(ctx).Grpc()
+	ctx = tunnel.WithCompression(ctx, cfg.TunnelCompression())
+	ctx = tunnel.WithIdleConfigs(ctx, cfg.TunnelIdle())
+	return tunnel.DialWaitLoop(ctx, tunnel.ManagerMuxProvider(ctx, s.managerClient), dialerStream, s.sessionInfo.SessionId)
 }
@@ -118,6 +118,14 @@ func (s *session) ForeachAgentPod(ctx context.Context, fn func(context.Context,
 	return nil
 }
 
+// trafficManagerSelector returns the selector of the traffic-manager's pods.
+func trafficManagerSelector() labels.Selector {
+	return labels.SelectorFromSet(labels.Set{
+		"app":          "traffic-manager",
+		"telepresence": "manager",
+	})
+}
+
 // GatherLogs acquires the logs for the traffic-manager and/or traffic-agents specified by the
 // connector.LogsRequest and returns them to the caller.
 func (s *session) GatherLogs(ctx context.Context, request *connector.LogsRequest) (*connector.LogsResponse, error) {
@@ -131,6 +139,9 @@ func (s *session) GatherLogs(ctx context.Context, request *connector.LogsRequest
 			podAndNs := fmt.Sprintf("%s.%s", pod.Name, pod.Namespace)
 			dlog.Debugf(ctx, "gathering logs for %s, yaml = %t", podAndNs, request.GetPodYaml)
 			getPodLog(ctx, exportDir, &result, podsAPI, pod, agentconfig.ContainerName, request.GetPodYaml, true)
+			if request.GetClusterState {
+				getPodDescription(ctx, exportDir, &result, pod)
+			}
 		}, func(pod *core.Pod) bool {
 			return strings.EqualFold(request.Agents, "all") || strings.Contains(pod.Name, request.Agents)
 		})
@@ -146,10 +157,7 @@ func (s *session) GatherLogs(ctx context.Context, request *connector.LogsRequest
 	if request.TrafficManager {
 		ns := s.GetManagerNamespace()
 		podsAPI := coreAPI.Pods(ns)
-		selector := labels.SelectorFromSet(labels.Set{
-			"app":          "traffic-manager",
-			"telepresence": "manager",
-		})
+		selector := trafficManagerSelector()
 		podList, err := podsAPI.List(ctx, meta.ListOptions{LabelSelector: selector.String()})
 		switch {
 		case err != nil:
@@ -161,6 +169,9 @@ func (s *session) GatherLogs(ctx context.Context, request *connector.LogsRequest
 			podAndNs := fmt.Sprintf("%s.%s", pod.Name, ns)
 			dlog.Debugf(ctx, "gathering logs for %s, yaml = %t", podAndNs, request.GetPodYaml)
 			getPodLog(ctx, exportDir, &result, podsAPI, pod, "traffic-manager", request.GetPodYaml, false)
+			if request.GetClusterState {
+				getPodDescription(ctx, exportDir, &result, pod)
+			}
 		case len(podList.Items) > 1:
 			err = fmt.Errorf("multiple traffic managers found in namespace %s using selector %s", ns, selector.String())
 			dlog.Error(ctx, err)
@@ -171,6 +182,18 @@ func (s *session) GatherLogs(ctx context.Context, request *connector.LogsRequest
 			resp.Error = err.Error()
 		}
 	}
+	if request.GetClusterState {
+		mgrNs := s.GetManagerNamespace()
+		getEvents(ctx, exportDir, &result, mgrNs)
+		if !strings.EqualFold(request.Agents, "none") {
+			for _, ns := range s.GetCurrentNamespaces(true) {
+				if ns != mgrNs {
+					getEvents(ctx, exportDir, &result, ns)
+				}
+			}
+		}
+		getWebhookStatus(ctx, exportDir, &result, mgrNs)
+	}
 	pi := make(map[string]string)
 	result.Range(func(k, v any) bool {
 		pi[k.(string)] = v.(string)
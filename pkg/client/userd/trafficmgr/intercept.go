@@ -14,6 +14,7 @@ import (
 
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/datawire/dlib/dgroup"
@@ -435,6 +436,11 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 	}
 	s.currentIntercepts = intercepts
 	s.reconcileAPIServers(ctx)
+	s.updateInterceptSummaries(ctx)
+
+	// Let the workload watchers know, so that intercept state changes are visible in the
+	// snapshots sent by WatchWorkloads.
+	s.wlWatcher.notify()
 }
 
 func InterceptError(tp common.InterceptError, err error) *rpc.InterceptResult {
@@ -480,7 +486,7 @@ func (s *session) ensureNoInterceptConflict(ir *rpc.CreateInterceptRequest) *rpc
 		switch {
 		case iCept.Spec.Name == spec.Name:
 			return InterceptError(common.InterceptError_ALREADY_EXISTS, errcat.User.New(spec.Name))
-		case iCept.Spec.TargetPort == spec.TargetPort && iCept.Spec.TargetHost == spec.TargetHost:
+		case !spec.Ingest && !iCept.Spec.Ingest && iCept.Spec.TargetPort == spec.TargetPort && iCept.Spec.TargetHost == spec.TargetHost:
 			return &rpc.InterceptResult{
 				Error:         common.InterceptError_LOCAL_TARGET_IN_USE,
 				ErrorText:     spec.Name,
@@ -531,6 +537,11 @@ func (s *session) CanIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 		return nil, InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, err)
 	}
 	if pi.Error != "" {
+		if len(pi.AmbiguousPorts) > 0 {
+			r := InterceptError(common.InterceptError_AMBIGUOUS_PORT, errcat.Category(pi.ErrorCategory).New(pi.Error))
+			r.AmbiguousPorts = pi.AmbiguousPorts
+			return nil, r
+		}
 		return nil, InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, errcat.Category(pi.ErrorCategory).Newf(pi.Error))
 	}
 
@@ -612,6 +623,12 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	ii, err := mgrClient.CreateIntercept(c, self.NewCreateInterceptRequest(spec))
 	if err != nil {
 		dlog.Debugf(c, "manager responded to CreateIntercept with error %v", err)
+		if st, ok := grpcStatus.FromError(err); ok && st.Code() == grpcCodes.ResourceExhausted {
+			return InterceptError(common.InterceptError_QUOTA_EXCEEDED, errcat.User.New(st.Message()))
+		}
+		if st, ok := grpcStatus.FromError(err); ok && st.Code() == grpcCodes.PermissionDenied {
+			return InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, errcat.User.New(st.Message()))
+		}
 		return InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, err)
 	}
 
@@ -799,6 +816,37 @@ func (s *session) ClearIntercepts(c context.Context) error {
 	return nil
 }
 
+// DetachIntercepts returns the current intercepts in a form that enables their recreation in another
+// session, and releases their local resources, such as mounts. It is used when the session has expired,
+// so unlike ClearIntercepts, it neither tells the traffic-manager to remove the intercepts, nor does it
+// terminate their intercept handlers.
+func (s *session) DetachIntercepts(c context.Context) []*userd.RestorableIntercept {
+	s.currentInterceptsLock.Lock()
+	ics := maps.ToSortedSlice(s.currentIntercepts)
+	s.currentIntercepts = nil
+	s.currentInterceptsLock.Unlock()
+
+	ris := make([]*userd.RestorableIntercept, len(ics))
+	for i, ic := range ics {
+		dlog.Debugf(c, "Detaching intercept %s", ic.Spec.Name)
+		ic.cancel()
+		ic.wg.Wait()
+		ri := &userd.RestorableIntercept{
+			Request: &rpc.CreateInterceptRequest{
+				Spec:           proto.Clone(ic.Spec).(*manager.InterceptSpec),
+				MountPoint:     ic.ClientMountPoint,
+				LocalMountPort: ic.localMountPort,
+			},
+			SessionID: ic.ClientSession.GetSessionId(),
+		}
+		if ic.pid != 0 || ic.containerName != "" {
+			ri.Interceptor = &rpc.Interceptor{Pid: int32(ic.pid), ContainerName: ic.containerName}
+		}
+		ris[i] = ri
+	}
+	return ris
+}
+
 // reconcileAPIServers start/stop API servers as needed based on the TELEPRESENCE_API_PORT environment variable
 // of the currently intercepted agent's env.
 func (s *session) reconcileAPIServers(ctx context.Context) {
@@ -871,11 +919,29 @@ func (s *session) newMatcher(ctx context.Context, ic *manager.InterceptInfo) {
 		dlog.Error(ctx, err)
 		return
 	}
+	hx, err := matcher.ParseHeaderExpr(ic.Spec.HttpHeaders)
+	if err != nil {
+		dlog.Error(ctx, err)
+		return
+	}
+	gm, err := matcher.NewGRPC(ic.Spec.GrpcMethods, ic.Spec.GrpcMetadata)
+	if err != nil {
+		dlog.Error(ctx, err)
+		return
+	}
+	af, err := matcher.NewAffinity(ic.Spec.SessionAffinity)
+	if err != nil {
+		dlog.Error(ctx, err)
+		return
+	}
 	if s.currentMatchers == nil {
 		s.currentMatchers = make(map[string]*apiMatcher)
 	}
 	s.currentMatchers[ic.Id] = &apiMatcher{
 		requestMatcher: m,
+		headers:        hx,
+		grpc:           gm,
+		affinity:       af,
 		metadata:       ic.Metadata,
 	}
 }
@@ -889,12 +955,12 @@ func (s *session) InterceptInfo(ctx context.Context, callerID, path string, _ ui
 	switch {
 	case am == nil:
 		dlog.Debugf(ctx, "no matcher found for callerID %s", callerID)
-	case am.requestMatcher.Matches(path, headers):
-		dlog.Debugf(ctx, "%s: matcher %s\nmatches path %q and headers\n%s", callerID, am.requestMatcher, path, matcher.HeaderStringer(headers))
+	case am.affinity.Stick(am.requestMatcher.Matches(path, headers) && am.headers.Matches(headers) && am.grpc.Matches(path, headers), headers):
+		dlog.Debugf(ctx, "%s: matcher %s, %s, %s, %s\nmatches path %q and headers\n%s", callerID, am.requestMatcher, am.headers, am.grpc, am.affinity, path, matcher.HeaderStringer(headers))
 		r.Intercepted = true
 		r.Metadata = am.metadata
 	default:
-		dlog.Debugf(ctx, "%s: matcher %s\nmatches path %q and headers\n%s", callerID, am.requestMatcher, path, matcher.HeaderStringer(headers))
+		dlog.Debugf(ctx, "%s: matcher %s, %s, %s, %s\ndoes not match path %q and headers\n%s", callerID, am.requestMatcher, am.headers, am.grpc, am.affinity, path, matcher.HeaderStringer(headers))
 	}
 	return r, nil
 }
@@ -0,0 +1,107 @@
+package trafficmgr
+
+import (
+	"context"
+	"net"
+	"slices"
+	"time"
+
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+const (
+	// networkCheckInterval is how often the network monitor looks for changes of the host's network.
+	networkCheckInterval = 5 * time.Second
+
+	// suspendThreshold is the time between two network checks that indicates that the host was suspended
+	// in between them.
+	suspendThreshold = 4 * networkCheckInterval
+)
+
+// networkFingerprint returns the sorted addresses of the host's network interfaces that are up. Loopback
+// interfaces are excluded.
+func networkFingerprint() ([]string, error) {
+	ifs, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var fp []string
+	for _, ifc := range ifs {
+		if ifc.Flags&net.FlagUp == 0 || ifc.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := ifc.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			fp = append(fp, ifc.Name+"="+addr.String())
+		}
+	}
+	slices.Sort(fp)
+	return fp, nil
+}
+
+// watchNetwork detects changes of the host's network interfaces, and that the host has been suspended,
+// which is what happens when a laptop switches network or wakes up from sleep. Such events often break
+// the port-forward to the traffic-manager, and with it, all tunnels. The connection to the traffic-manager
+// is verified when an event is detected, and ErrNetworkChanged is returned if it is broken. That makes
+// the session end, and a new session, with the same intercepts, will replace it. The routes of the session
+// are refreshed when the connection is intact, so that they are bound to the host's current network.
+func (s *session) watchNetwork(ctx context.Context) error {
+	prev, err := networkFingerprint()
+	if err != nil {
+		dlog.Warnf(ctx, "unable to monitor the network: %v", err)
+		return nil
+	}
+	ticker := time.NewTicker(networkCheckInterval)
+	defer ticker.Stop()
+
+	// The monotonic clock might not advance while the host is suspended, so the wall clock is used.
+	last := time.Now().Round(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			now = now.Round(0)
+			suspended := now.Sub(last) > suspendThreshold
+			last = now
+			fp, err := networkFingerprint()
+			if err != nil {
+				dlog.Debugf(ctx, "unable to monitor the network: %v", err)
+				continue
+			}
+			changed := !slices.Equal(fp, prev)
+			prev = fp
+			switch {
+			case suspended:
+				dlog.Info(ctx, "The host was suspended. Verifying the connection to the traffic-manager")
+			case changed:
+				dlog.Info(ctx, "The network changed. Verifying the connection to the traffic-manager")
+			default:
+				continue
+			}
+			if err = s.verifyManagerConnection(ctx); err != nil {
+				dlog.Warnf(ctx, "The connection to the traffic-manager is broken: %v", err)
+				return ErrNetworkChanged
+			}
+			dlog.Info(ctx, "The connection to the traffic-manager is intact")
+			if _, err = s.rootDaemon.RefreshRoutes(ctx, &empty.Empty{}); err != nil {
+				dlog.Errorf(ctx, "Unable to refresh the routes after the network changed: %v", err)
+			}
+		}
+	}
+}
+
+// verifyManagerConnection checks that the traffic-manager can be reached, and that it still knows about
+// this session.
+func (s *session) verifyManagerConnection(ctx context.Context) error {
+	ctx, cancel := client.GetConfig(ctx).Timeouts().TimeoutContext(ctx, client.TimeoutTrafficManagerAPI)
+	defer cancel()
+	_, err := s.self.ManagerClient().Remain(ctx, s.self.NewRemainRequest())
+	return client.CheckTimeout(ctx, err)
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
@@ -30,6 +31,8 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 	"k8s.io/client-go/util/homedir"
 
 	"github.com/datawire/dlib/dcontext"
@@ -58,6 +61,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	tlog "github.com/telepresenceio/telepresence/v2/pkg/log"
 	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
@@ -70,6 +74,9 @@ type apiServer struct {
 
 type apiMatcher struct {
 	requestMatcher matcher.Request
+	headers        matcher.HeaderExpr
+	grpc           *matcher.GRPC
+	affinity       *matcher.Affinity
 	metadata       map[string]string
 }
 
@@ -131,6 +138,12 @@ type session struct {
 
 	sessionConfig client.Config
 
+	// healthLock guards lastManagerPing, lastManagerRTT, and lastManagerError, which are updated by Remain.
+	healthLock       sync.Mutex
+	lastManagerPing  time.Time
+	lastManagerRTT   time.Duration
+	lastManagerError string
+
 	// done is closed when the session ends
 	done chan struct{}
 
@@ -289,6 +302,7 @@ func (s *session) SetSelf(self userd.Session) {
 //   - (4) mount the appropriate remote volumes.
 func (s *session) RunSession(c context.Context) error {
 	self := s.self
+	c = dlog.WithField(c, tlog.FieldSession, s.sessionInfo.SessionId)
 	g := dgroup.NewGroup(c, dgroup.GroupConfig{})
 	defer func() {
 		self.Epilog(c)
@@ -398,6 +412,7 @@ func connectMgr(
 			InstallId: installID,
 			Product:   "telepresence",
 			Version:   client.Version(),
+			KubeToken: identityToken(ctx, mClient, cluster.Kubeconfig.RestConfig),
 		})
 		if err != nil {
 			return nil, client.CheckTimeout(ctx, fmt.Errorf("manager.ArriveAsClient: %w", err))
@@ -412,7 +427,7 @@ func connectMgr(
 	if mz := cfg.Grpc().MaxReceiveSize(); mz > 0 {
 		opts = append(opts, grpc.MaxCallRecvMsgSize(int(mz)))
 	}
-	svc.SetManagerClient(mClient, opts...)
+	svc.SetManagerClient(daemonID.Name, mClient, opts...)
 
 	managerName := vi.Name
 	if managerName == "" {
@@ -438,6 +453,28 @@ func connectMgr(
 	cluster.AlsoProxy = append(cluster.AlsoProxy, extraAlsoProxy...)
 	cluster.NeverProxy = append(cluster.NeverProxy, extraNeverProxy...)
 	cluster.AllowConflictingSubnets = append(cluster.AllowConflictingSubnets, extraAllow...)
+	cluster.ProxyExcludePorts = append(cluster.ProxyExcludePorts, cr.GetProxyExcludePorts()...)
+	for _, p := range cluster.ProxyExcludePorts {
+		if _, err = agentconfig.NewPortAndProto(p); err != nil {
+			return nil, fmt.Errorf("failed to parse proxy exclude port %q: %w", p, err)
+		}
+	}
+	if dos := cr.GetDnsOverrides(); len(dos) > 0 {
+		if cluster.DNS == nil {
+			cluster.DNS = &client.DnsConfig{}
+		}
+		overrides := make(map[string]string, len(cluster.DNS.Overrides)+len(dos))
+		maps.Copy(overrides, cluster.DNS.Overrides)
+		maps.Copy(overrides, dos)
+		cluster.DNS.Overrides = overrides
+	}
+	if cluster.DNS != nil {
+		for name, ip := range cluster.DNS.Overrides {
+			if iputil.Parse(ip) == nil {
+				return nil, fmt.Errorf("invalid IP %q in DNS override for %q", ip, name)
+			}
+		}
+	}
 
 	sess := &session{
 		Cluster:            cluster,
@@ -455,6 +492,7 @@ func connectMgr(
 		isPodDaemon:        cr.IsPodDaemon,
 		done:               make(chan struct{}),
 		subnetViaWorkloads: cr.SubnetViaWorkloads,
+		lastManagerPing:    time.Now(), // The session was just created or verified by the traffic-manager.
 	}
 	sess.self = sess
 	return sess, nil
@@ -468,17 +506,62 @@ func (s *session) Remain(ctx context.Context) error {
 	self := s.self
 	ctx, cancel := client.GetConfig(ctx).Timeouts().TimeoutContext(ctx, client.TimeoutTrafficManagerAPI)
 	defer cancel()
+	start := time.Now()
 	_, err := self.ManagerClient().Remain(ctx, self.NewRemainRequest())
+	now := time.Now()
+	s.healthLock.Lock()
+	if err == nil {
+		s.lastManagerPing = now
+		s.lastManagerRTT = now.Sub(start)
+		s.lastManagerError = ""
+	} else {
+		s.lastManagerError = client.CheckTimeout(ctx, err).Error()
+	}
+	s.healthLock.Unlock()
 	if err != nil {
-		if status.Code(err) == codes.NotFound || status.Code(err) == codes.Unavailable {
+		switch status.Code(err) {
+		case codes.NotFound, codes.Unavailable:
 			// The session has expired. We need to cancel the owner session and reconnect.
 			return ErrSessionExpired
+		case codes.Aborted:
+			// The session was evicted. We need to cancel the owner session, but must not reconnect.
+			return ErrSessionEvicted
 		}
 		dlog.Errorf(ctx, "error calling Remain: %v", client.CheckTimeout(ctx, err))
 	}
 	return nil
 }
 
+// Health returns a summary of the state of the session that is stored in the daemon info.
+func (s *session) Health() *daemon.Health {
+	s.currentInterceptsLock.Lock()
+	ic := len(s.currentIntercepts)
+	s.currentInterceptsLock.Unlock()
+	s.healthLock.Lock()
+	defer s.healthLock.Unlock()
+	return &daemon.Health{
+		LastManagerPing: s.lastManagerPing,
+		Cluster:         s.Kubeconfig.Server,
+		InterceptCount:  ic,
+		Error:           s.lastManagerError,
+	}
+}
+
+// connectionQuality returns the measurements of the quality of the connection that are made by the user daemon.
+func (s *session) connectionQuality() *rootdRpc.ConnectionQuality {
+	s.healthLock.Lock()
+	rtt := s.lastManagerRTT
+	s.healthLock.Unlock()
+	cq := &rootdRpc.ConnectionQuality{}
+	if rtt > 0 {
+		cq.ManagerRtt = durationpb.New(rtt)
+	}
+	if s.pfDialer != nil {
+		cq.PortForwardRestarts = s.pfDialer.Restarts()
+	}
+	return cq
+}
+
 func parseCIDR(cidr []string) ([]*iputil.Subnet, error) {
 	result := make([]*iputil.Subnet, 0)
 
@@ -497,6 +580,63 @@ func parseCIDR(cidr []string) ([]*iputil.Subnet, error) {
 	return result, nil
 }
 
+// identityToken returns the bearer token of the given config when the traffic-manager verifies the
+// identity of clients, and an empty string otherwise. The token is never sent to traffic-managers
+// that don't need it, including those that predate the VerifyClientIdentity field of the CLIConfig.
+func identityToken(ctx context.Context, mClient manager.ManagerClient, rc *rest.Config) string {
+	cliCfg, err := mClient.GetClientConfig(ctx, &empty.Empty{})
+	if err != nil {
+		if status.Code(err) != codes.Unimplemented {
+			dlog.Warnf(ctx, "Failed to get remote config from traffic manager: %v", err)
+		}
+		return ""
+	}
+	if !cliCfg.VerifyClientIdentity {
+		return ""
+	}
+	return kubeToken(ctx, rc)
+}
+
+// errTokenCaptured stops the request that kubeToken uses to obtain the bearer token.
+var errTokenCaptured = errors.New("token captured")
+
+// kubeToken returns the bearer token that the credentials of the given config present to the API
+// server, or an empty string when they use another kind of authentication, e.g. client certificates.
+// The token is obtained by passing a request through the authentication wrappers of the config, so
+// that tokens from files, exec plugins, and auth providers are included. The request is never sent.
+// The traffic-manager verifies the token to learn the identity of the client when it authorizes intercepts.
+func kubeToken(ctx context.Context, rc *rest.Config) string {
+	tc, err := rc.TransportConfig()
+	if err != nil {
+		dlog.Debugf(ctx, "unable to obtain the Kubernetes bearer token: %v", err)
+		return ""
+	}
+	var token string
+	rt, err := transport.HTTPWrappersForConfig(tc, roundTripperFunc(func(rq *http.Request) (*http.Response, error) {
+		token, _ = strings.CutPrefix(rq.Header.Get("Authorization"), "Bearer ")
+		return nil, errTokenCaptured
+	}))
+	if err != nil {
+		dlog.Debugf(ctx, "unable to obtain the Kubernetes bearer token: %v", err)
+		return ""
+	}
+	rq, err := http.NewRequestWithContext(ctx, http.MethodGet, rc.Host, nil)
+	if err != nil {
+		dlog.Debugf(ctx, "unable to obtain the Kubernetes bearer token: %v", err)
+		return ""
+	}
+	if _, err = rt.RoundTrip(rq); err != nil && !errors.Is(err, errTokenCaptured) {
+		dlog.Debugf(ctx, "unable to obtain the Kubernetes bearer token: %v", err)
+	}
+	return token
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(rq *http.Request) (*http.Response, error) {
+	return f(rq)
+}
+
 func CheckTrafficManagerService(ctx context.Context, namespace string) error {
 	dlog.Debug(ctx, "checking that traffic-manager exists")
 	coreV1 := k8sapi.GetK8sInterface(ctx).CoreV1()
@@ -562,6 +702,7 @@ func (s *session) StartServices(g *dgroup.Group) {
 	g.Go("remain", s.remainLoop)
 	g.Go("intercept-port-forward", s.watchInterceptsHandler)
 	g.Go("dial-request-watcher", s.dialRequestWatcher)
+	g.Go("network-monitor", s.watchNetwork)
 }
 
 func runWithRetry(ctx context.Context, f func(context.Context) error) error {
@@ -649,6 +790,9 @@ func (s *session) getInfosForWorkloads(
 					},
 				},
 			}
+			if _, err := agentconfig.HostNetworkPorts(workload.GetPodTemplate()); err != nil {
+				wlInfo.NotInterceptableReason = err.Error()
+			}
 			var ok bool
 			if wlInfo.InterceptInfos, ok = iMap[name]; !ok && filter <= rpc.ListRequest_INTERCEPTS {
 				continue
@@ -717,6 +861,11 @@ func (s *session) WorkloadInfoSnapshot(
 	namespaces []string,
 	filter rpc.ListRequest_Filter,
 ) (*rpc.WorkloadInfoSnapshot, error) {
+	snapshot, err := s.listWorkloadsSnapshot(ctx, namespaces, filter)
+	if status.Code(err) != codes.Unimplemented {
+		return snapshot, err
+	}
+	// Older traffic-managers can't list workloads, so use the session's own watchers.
 	s.waitForSync(ctx)
 	return s.workloadInfoSnapshot(ctx, namespaces, filter)
 }
@@ -750,8 +899,89 @@ func (s *session) workloadInfoSnapshot(
 	namespaces []string,
 	filter rpc.ListRequest_Filter,
 ) (*rpc.WorkloadInfoSnapshot, error) {
-	is := s.getCurrentIntercepts()
 	s.ensureWatchers(ctx, namespaces)
+	nss, iMap, sMap := s.snapshotMaps(ctx, namespaces, filter)
+	if len(nss) == 0 {
+		// none of the namespaces are currently mapped
+		return &rpc.WorkloadInfoSnapshot{}, nil
+	}
+	workloadInfos := s.getInfosForWorkloads(ctx, nss, iMap, sMap, filter)
+	return &rpc.WorkloadInfoSnapshot{Workloads: workloadInfos}, nil
+}
+
+// listWorkloadsSnapshot is like workloadInfoSnapshot, but the workloads are listed by the traffic-manager,
+// one page at a time, instead of by the session's own watchers.
+func (s *session) listWorkloadsSnapshot(
+	ctx context.Context,
+	namespaces []string,
+	filter rpc.ListRequest_Filter,
+) (*rpc.WorkloadInfoSnapshot, error) {
+	nss, iMap, sMap := s.snapshotMaps(ctx, namespaces, filter)
+	if len(nss) == 0 {
+		// none of the namespaces are currently mapped
+		return &rpc.WorkloadInfoSnapshot{}, nil
+	}
+
+	// Let the traffic-manager filter out workloads without agents when they're required. Intercepts
+	// are filtered here, because only the session's own intercepts are of interest.
+	mf := manager.ListWorkloadsRequest_INTERCEPTABLE
+	if filter <= rpc.ListRequest_INSTALLED_AGENTS {
+		mf = manager.ListWorkloadsRequest_INSTALLED_AGENTS
+	}
+	rq := &manager.ListWorkloadsRequest{
+		SessionInfo: s.SessionInfo(),
+		Namespaces:  nss,
+		Filter:      mf,
+		PageSize:    listWorkloadsPageSize,
+	}
+	var wis []*rpc.WorkloadInfo
+	for {
+		rs, err := s.managerClient.ListWorkloads(ctx, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, mw := range rs.Workloads {
+			wi := &rpc.WorkloadInfo{
+				Name:                   mw.Name,
+				Namespace:              mw.Namespace,
+				NotInterceptableReason: mw.NotInterceptableReason,
+				WorkloadResourceType:   workloadKind(mw.Kind),
+				Uid:                    mw.Uid,
+				Services:               make(map[string]*rpc.WorkloadInfo_ServiceReference, len(mw.Services)),
+			}
+			for _, svc := range mw.Services {
+				ports := make([]*rpc.WorkloadInfo_ServiceReference_Port, len(svc.Ports))
+				for i, p := range svc.Ports {
+					ports[i] = &rpc.WorkloadInfo_ServiceReference_Port{Name: p.Name, Port: p.Port}
+				}
+				wi.Services[svc.Uid] = &rpc.WorkloadInfo_ServiceReference{Name: svc.Name, Namespace: mw.Namespace, Ports: ports}
+			}
+			var ok bool
+			if wi.InterceptInfos, ok = iMap[wi.Name]; !ok && filter <= rpc.ListRequest_INTERCEPTS {
+				continue
+			}
+			if wi.Sidecar, ok = sMap[wi.Name]; !ok && filter <= rpc.ListRequest_INSTALLED_AGENTS {
+				continue
+			}
+			wis = append(wis, wi)
+		}
+		if rs.NextPageToken == "" {
+			break
+		}
+		rq.PageToken = rs.NextPageToken
+	}
+	sort.SliceStable(wis, func(i, j int) bool { return wis[i].Name < wis[j].Name })
+	return &rpc.WorkloadInfoSnapshot{Workloads: wis}, nil
+}
+
+// snapshotMaps returns the namespaces of a snapshot, along with the session's intercepts and the installed
+// sidecars in those namespaces, keyed by workload name.
+func (s *session) snapshotMaps(
+	ctx context.Context,
+	namespaces []string,
+	filter rpc.ListRequest_Filter,
+) ([]string, map[string][]*manager.InterceptInfo, map[string]*rpc.WorkloadInfo_Sidecar) {
+	is := s.getCurrentIntercepts()
 
 	var nss []string
 	if filter == rpc.ListRequest_INTERCEPTS {
@@ -767,8 +997,7 @@ func (s *session) workloadInfoSnapshot(
 		}
 	}
 	if len(nss) == 0 {
-		// none of the namespaces are currently mapped
-		return &rpc.WorkloadInfoSnapshot{}, nil
+		return nil, nil, nil
 	}
 
 	iMap := make(map[string][]*manager.InterceptInfo, len(is))
@@ -792,12 +1021,14 @@ nextIs:
 			sMap[k] = &rpc.WorkloadInfo_Sidecar{Json: data}
 		}
 	}
-
-	workloadInfos := s.getInfosForWorkloads(ctx, nss, iMap, sMap, filter)
-	return &rpc.WorkloadInfoSnapshot{Workloads: workloadInfos}, nil
+	return nss, iMap, sMap
 }
 
-var ErrSessionExpired = errors.New("session expired")
+var (
+	ErrSessionExpired = errors.New("session expired")
+	ErrSessionEvicted = errors.New("session evicted by the traffic-manager")
+	ErrNetworkChanged = errors.New("connection to the traffic-manager lost after a network change")
+)
 
 func (s *session) remainLoop(c context.Context) error {
 	ticker := time.NewTicker(5 * time.Second)
@@ -904,6 +1135,7 @@ func (s *session) status(c context.Context, initial bool) *rpc.ConnectInfo {
 		},
 		ManagerNamespace:   cfg.GetManagerNamespace(),
 		SubnetViaWorkloads: s.subnetViaWorkloads,
+		ConnectionQuality:  s.connectionQuality(),
 		Version: &common.VersionInfo{
 			ApiVersion: client.APIVersion,
 			Version:    client.Version(),
@@ -1030,7 +1262,8 @@ func (s *session) Uninstall(ctx context.Context, ur *rpc.UninstallRequest) (*com
 	return errcat.ToResult(nil), nil
 }
 
-func (s *session) getOutboundInfo(ctx context.Context, cr *rpc.ConnectRequest) *rootdRpc.OutboundInfo {
+// neverProxySubnets returns the never-proxy subnets of the session, including the subnets of the API server(s).
+func (s *session) neverProxySubnets(ctx context.Context) []*manager.IPNet {
 	// We'll figure out the IP address of the API server(s) so that we can tell the daemon never to proxy them.
 	// This is because in some setups the API server will be in the same CIDR range as the pods, and the
 	// daemon will attempt to proxy traffic to it. This usually results in a loss of all traffic to/from
@@ -1068,15 +1301,22 @@ func (s *session) getOutboundInfo(ctx context.Context, cr *rpc.ConnectRequest) *
 	for _, np := range s.NeverProxy {
 		neverProxy = append(neverProxy, iputil.IPNetToRPC((*net.IPNet)(np)))
 	}
+	return neverProxy
+}
+
+func (s *session) getOutboundInfo(ctx context.Context, cr *rpc.ConnectRequest) *rootdRpc.OutboundInfo {
 	info := &rootdRpc.OutboundInfo{
 		Session:            s.sessionInfo,
-		NeverProxySubnets:  neverProxy,
+		NeverProxySubnets:  s.neverProxySubnets(ctx),
 		HomeDir:            homedir.HomeDir(),
 		Namespace:          s.Namespace,
 		ManagerNamespace:   s.GetManagerNamespace(),
 		SubnetViaWorkloads: s.subnetViaWorkloads,
 		KubeFlags:          cr.KubeFlags,
 		KubeconfigData:     cr.KubeconfigData,
+		ProxyExcludePorts:  s.ProxyExcludePorts,
+		ConnectionName:     s.daemonID.Name,
+		TunnelChaos:        cr.TunnelChaos,
 	}
 
 	if s.DNS != nil {
@@ -1085,8 +1325,12 @@ func (s *session) getOutboundInfo(ctx context.Context, cr *rpc.ConnectRequest) *
 			IncludeSuffixes: s.DNS.IncludeSuffixes,
 			Excludes:        s.DNS.Excludes,
 			Mappings:        s.DNS.Mappings.ToRPC(),
+			Overrides:       s.DNS.Overrides,
 			LookupTimeout:   durationpb.New(s.DNS.LookupTimeout.Duration),
 		}
+		if s.DNS.NegativeCacheTTL.Duration > 0 {
+			info.Dns.NegativeCacheTtl = durationpb.New(s.DNS.NegativeCacheTTL.Duration)
+		}
 		if len(s.DNS.LocalIP) > 0 {
 			info.Dns.LocalIp = s.DNS.LocalIP.IP()
 		}
@@ -1110,13 +1354,36 @@ func (s *session) getOutboundInfo(ctx context.Context, cr *rpc.ConnectRequest) *
 	return info
 }
 
+// SetProxySubnets replaces the also-proxy and never-proxy subnets of the session and tells the root daemon
+// to update its routes accordingly.
+func (s *session) SetProxySubnets(ctx context.Context, alsoProxy, neverProxy []*manager.IPNet) error {
+	s.AlsoProxy = make([]*iputil.Subnet, len(alsoProxy))
+	for i, ap := range alsoProxy {
+		s.AlsoProxy[i] = (*iputil.Subnet)(iputil.IPNetFromRPC(ap))
+	}
+	s.NeverProxy = make([]*iputil.Subnet, len(neverProxy))
+	for i, np := range neverProxy {
+		s.NeverProxy[i] = (*iputil.Subnet)(iputil.IPNetFromRPC(np))
+	}
+	_, err := s.rootDaemon.SetProxySubnets(ctx, &rootdRpc.SetProxySubnetsRequest{
+		AlsoProxySubnets:  alsoProxy,
+		NeverProxySubnets: s.neverProxySubnets(ctx),
+	})
+	return err
+}
+
 func (s *session) connectRootDaemon(ctx context.Context, oi *rootdRpc.OutboundInfo, isPodDaemon bool) (rd rootdRpc.DaemonClient, err error) {
 	// establish a connection to the root daemon gRPC grpcService
 	dlog.Info(ctx, "Connecting to root daemon...")
 	svc := userd.GetService(ctx)
 	if svc.RootSessionInProcess() {
 		// Just run the root session in-process.
-		rootSession, err := rootd.NewInProcSession(ctx, oi, s.managerClient, s.managerVersion, isPodDaemon)
+		var rootSession *rootd.InProcSession
+		if svc.UserSpaceNetwork() {
+			rootSession, err = rootd.NewUserSpaceSession(ctx, oi, s.managerClient, s.managerVersion, svc.SOCKSAddress(), svc.HTTPProxyAddress())
+		} else {
+			rootSession, err = rootd.NewInProcSession(ctx, oi, s.managerClient, s.managerVersion, isPodDaemon)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -1126,8 +1393,11 @@ func (s *session) connectRootDaemon(ctx context.Context, oi *rootdRpc.OutboundIn
 		rd = rootSession
 	} else {
 		var conn *grpc.ClientConn
+		// The root daemon can maintain sessions for several connections, so all calls must identify the
+		// session that they concern.
 		conn, err = socket.Dial(ctx, socket.RootDaemonPath(ctx), true,
 			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+			grpc.WithUnaryInterceptor(rootd.SessionIDInterceptor(oi.Session.SessionId)),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("unable open root daemon socket: %w", err)
@@ -4,7 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 
+	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cache"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
@@ -21,20 +24,21 @@ type SavedSession struct {
 }
 
 // SaveSessionInfoToUserCache saves the provided SessionInfo to user cache and returns an error if
-// something goes wrong while marshalling or persisting.
+// something goes wrong while marshalling or persisting. The session ID grants access to the session,
+// so the entry is encrypted.
 func SaveSessionInfoToUserCache(ctx context.Context, daemonID *daemon.Identifier, session *manager.SessionInfo) error {
-	return cache.SaveToUserCache(ctx, &SavedSession{
+	return cache.SaveSensitiveToUserCache(ctx, &SavedSession{
 		KubeContext: daemonID.KubeContext,
 		Namespace:   daemonID.Namespace,
 		Session:     session,
-	}, sessionInfoFile(daemonID), cache.Public)
+	}, sessionInfoFile(daemonID))
 }
 
 // LoadSessionInfoFromUserCache gets the SessionInfo from cache or returns an error if something goes
 // wrong while loading or unmarshalling.
 func LoadSessionInfoFromUserCache(ctx context.Context, daemonID *daemon.Identifier) (*manager.SessionInfo, error) {
 	var ss *SavedSession
-	err := cache.LoadFromUserCache(ctx, &ss, sessionInfoFile(daemonID))
+	err := cache.LoadSensitiveFromUserCache(ctx, &ss, sessionInfoFile(daemonID))
 	if err == nil && ss.KubeContext == daemonID.KubeContext && ss.Namespace == daemonID.Namespace {
 		return ss.Session, nil
 	}
@@ -49,3 +53,30 @@ func LoadSessionInfoFromUserCache(ctx context.Context, daemonID *daemon.Identifi
 func DeleteSessionInfoFromUserCache(ctx context.Context, daemonID *daemon.Identifier) error {
 	return cache.DeleteFromUserCache(ctx, sessionInfoFile(daemonID))
 }
+
+// updateInterceptSummaries updates the intercept summaries in the daemon info file so that tools can
+// present per-connection activity without dialing the daemon. Must be called with the
+// currentInterceptsLock held.
+func (s *session) updateInterceptSummaries(ctx context.Context) {
+	sums := make([]*daemon.InterceptSummary, 0, len(s.currentIntercepts))
+	for _, ic := range s.currentIntercepts {
+		sums = append(sums, &daemon.InterceptSummary{
+			Name:        ic.Spec.Name,
+			Workload:    ic.Spec.Agent,
+			Namespace:   ic.Spec.Namespace,
+			Disposition: ic.Disposition.String(),
+			MountPoint:  ic.ClientMountPoint,
+		})
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i].Name < sums[j].Name })
+	err := daemon.UpdateInfo(ctx, s.daemonID.InfoFileName(), func(info *daemon.Info) bool {
+		if slices.EqualFunc(info.Intercepts, sums, func(a, b *daemon.InterceptSummary) bool { return *a == *b }) {
+			return false
+		}
+		info.Intercepts = sums
+		return true
+	})
+	if err != nil {
+		dlog.Errorf(ctx, "failed to update intercept summaries: %v", err)
+	}
+}
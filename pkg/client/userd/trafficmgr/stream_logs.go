@@ -0,0 +1,153 @@
+package trafficmgr
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// trafficManagerName is the name that selects the logs of the traffic-manager in a connector.StreamLogsRequest.
+const trafficManagerName = "traffic-manager"
+
+// maxLogLineSize is the maximum size of a streamed log line.
+const maxLogLineSize = 1024 * 1024
+
+// podLogSource is a container of a pod whose log is streamed.
+type podLogSource struct {
+	pod       *core.Pod
+	container string
+}
+
+// StreamLogs streams the lines of the logs that are selected by the given request to the given send function,
+// which is never called concurrently. It returns when all logs have ended, or when the context is cancelled.
+func (s *session) StreamLogs(ctx context.Context, rq *connector.StreamLogsRequest, send func(*connector.LogLine) error) error {
+	var sources []podLogSource
+	if rq.Name == "" || rq.Name == trafficManagerName {
+		pods, err := podsWithContainer(ctx, s.GetManagerNamespace(), trafficManagerSelector().String(), trafficManagerName)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return errcat.User.Newf("no traffic-manager found in namespace %s", s.GetManagerNamespace())
+		}
+		for _, pod := range pods {
+			sources = append(sources, podLogSource{pod: pod, container: trafficManagerName})
+		}
+	}
+	if rq.Name != trafficManagerName {
+		selector := ""
+		if rq.Name != "" {
+			wl, err := agentmap.GetWorkload(ctx, rq.Name, s.Namespace, "")
+			if err != nil {
+				if k8serrors.IsNotFound(err) {
+					return errcat.User.Newf("workload %s.%s not found", rq.Name, s.Namespace)
+				}
+				return err
+			}
+			sel, err := wl.Selector()
+			if err != nil {
+				return err
+			}
+			selector = sel.String()
+		}
+		pods, err := podsWithContainer(ctx, s.Namespace, selector, agentconfig.ContainerName)
+		if err != nil {
+			return err
+		}
+		if rq.Name != "" && len(pods) == 0 {
+			return errcat.User.Newf("workload %s.%s has no running traffic-agent", rq.Name, s.Namespace)
+		}
+		for _, pod := range pods {
+			sources = append(sources, podLogSource{pod: pod, container: agentconfig.ContainerName})
+		}
+	}
+
+	opts := core.PodLogOptions{Follow: rq.Follow}
+	if since := rq.Since.AsDuration(); since > 0 {
+		secs := int64(math.Ceil(since.Seconds()))
+		opts.SinceSeconds = &secs
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sendErr error
+		errs    []error
+	)
+	coreAPI := k8sapi.GetK8sInterface(ctx).CoreV1()
+	wg.Add(len(sources))
+	for _, src := range sources {
+		go func(src podLogSource) {
+			defer wg.Done()
+			lo := opts
+			lo.Container = src.container
+			rs, err := coreAPI.Pods(src.pod.Namespace).GetLogs(src.pod.Name, &lo).Stream(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					err = fmt.Errorf("failed to get log for %s.%s: %w", src.pod.Name, src.pod.Namespace, err)
+					dlog.Error(ctx, err)
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+				return
+			}
+			defer rs.Close()
+			sc := bufio.NewScanner(rs)
+			sc.Buffer(nil, maxLogLineSize)
+			for sc.Scan() {
+				mu.Lock()
+				if sendErr == nil {
+					if sendErr = send(&connector.LogLine{Pod: src.pod.Name, Line: sc.Text()}); sendErr != nil {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}(src)
+	}
+	wg.Wait()
+	if sendErr != nil {
+		return sendErr
+	}
+	return errors.Join(errs...)
+}
+
+// podsWithContainer returns the running pods in the given namespace that match the given label selector
+// and have a container with the given name.
+func podsWithContainer(ctx context.Context, namespace, selector, container string) ([]*core.Pod, error) {
+	podList, err := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(namespace).List(ctx, meta.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var pods []*core.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !agentmap.IsPodRunning(pod) {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			if c.Name == container {
+				pods = append(pods, pod)
+				break
+			}
+		}
+	}
+	return pods, nil
+}
@@ -17,6 +17,9 @@ import (
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/openshift"
 )
 
 type workloadsAndServicesWatcher struct {
@@ -30,12 +33,16 @@ const (
 	deployments  = 0
 	replicasets  = 1
 	statefulsets = 2
+	daemonsets   = 3
+
+	// deploymentconfigs is only watched when the cluster serves OpenShift DeploymentConfigs.
+	deploymentconfigs = 4
 )
 
 // namespacedWASWatcher is watches Workloads And Services (WAS) for a namespace.
 type namespacedWASWatcher struct {
 	svcWatcher *k8sapi.Watcher[*core.Service]
-	wlWatchers [3]*k8sapi.Watcher[runtime.Object]
+	wlWatchers []*k8sapi.Watcher[runtime.Object]
 }
 
 // svcEquals compare only the Service fields that are of interest to Telepresence. They are
@@ -67,7 +74,7 @@ nextMP:
 	return true
 }
 
-// workloadEquals compare only the workload (Deployment, ResourceSet, or StatefulSet) fields that are of interest to Telepresence. They are
+// workloadEquals compare only the workload (Deployment, ResourceSet, StatefulSet, DaemonSet, or DeploymentConfig) fields that are of interest to Telepresence. They are
 //
 //   - UID
 //   - Name
@@ -76,12 +83,12 @@ nextMP:
 //   - Labels
 //   - Containers (must contain an equal number of equally named containers with equal ports)
 func workloadEquals(oa, ob runtime.Object) bool {
-	a, err := k8sapi.WrapWorkload(oa)
+	a, err := agentmap.WrapWorkload(oa)
 	if err != nil {
 		// This should definitely never happen
 		panic(err)
 	}
-	b, err := k8sapi.WrapWorkload(ob)
+	b, err := agentmap.WrapWorkload(ob)
 	if err != nil {
 		// This should definitely never happen
 		panic(err)
@@ -145,12 +152,17 @@ func newNamespaceWatcher(c context.Context, namespace string, cond *sync.Cond) *
 	appsGetter := ki.AppsV1().RESTClient()
 	w := &namespacedWASWatcher{
 		svcWatcher: k8sapi.NewWatcher("services", ki.CoreV1().RESTClient(), cond, k8sapi.WithEquals(svcEquals), k8sapi.WithNamespace[*core.Service](namespace)),
-		wlWatchers: [3]*k8sapi.Watcher[runtime.Object]{
+		wlWatchers: []*k8sapi.Watcher[runtime.Object]{
 			k8sapi.NewWatcher("deployments", appsGetter, cond, k8sapi.WithEquals(workloadEquals), k8sapi.WithNamespace[runtime.Object](namespace)),
 			k8sapi.NewWatcher("replicasets", appsGetter, cond, k8sapi.WithEquals(workloadEquals), k8sapi.WithNamespace[runtime.Object](namespace)),
 			k8sapi.NewWatcher("statefulsets", appsGetter, cond, k8sapi.WithEquals(workloadEquals), k8sapi.WithNamespace[runtime.Object](namespace)),
+			k8sapi.NewWatcher("daemonsets", appsGetter, cond, k8sapi.WithEquals(workloadEquals), k8sapi.WithNamespace[runtime.Object](namespace)),
 		},
 	}
+	if osc := openshift.GetRESTClient(c); osc != nil {
+		w.wlWatchers = append(w.wlWatchers,
+			k8sapi.NewWatcher(openshift.DeploymentConfigs, osc, cond, k8sapi.WithEquals(workloadEquals), k8sapi.WithNamespace[runtime.Object](namespace)))
+	}
 	return w
 }
 
@@ -162,10 +174,15 @@ func (nw *namespacedWASWatcher) cancel() {
 }
 
 func (nw *namespacedWASWatcher) hasSynced() bool {
-	return nw.svcWatcher.HasSynced() &&
-		nw.wlWatchers[0].HasSynced() &&
-		nw.wlWatchers[1].HasSynced() &&
-		nw.wlWatchers[2].HasSynced()
+	if !nw.svcWatcher.HasSynced() {
+		return false
+	}
+	for _, w := range nw.wlWatchers {
+		if !w.HasSynced() {
+			return false
+		}
+	}
+	return true
 }
 
 func newWASWatcher() *workloadsAndServicesWatcher {
@@ -240,6 +257,12 @@ func (w *workloadsAndServicesWatcher) subscribe(c context.Context) <-chan struct
 	return k8sapi.Subscribe(c, &w.cond)
 }
 
+// notify wakes up all subscribers so that they create a new snapshot. It's used when
+// information that isn't watched by this watcher, such as the intercepts, changes.
+func (w *workloadsAndServicesWatcher) notify() {
+	w.cond.Broadcast()
+}
+
 // setNamespacesToWatch starts new watchers or kills old ones to make the current
 // set of watchers reflect the nss argument.
 func (w *workloadsAndServicesWatcher) setNamespacesToWatch(c context.Context, nss []string) {
@@ -345,6 +368,10 @@ func (nw *namespacedWASWatcher) findMatchingWorkloads(c context.Context, svc *co
 				wl = k8sapi.ReplicaSet(o.(*apps.ReplicaSet))
 			case statefulsets:
 				wl = k8sapi.StatefulSet(o.(*apps.StatefulSet))
+			case daemonsets:
+				wl = agentmap.DaemonSet(o.(*apps.DaemonSet))
+			case deploymentconfigs:
+				wl = agentmap.DeploymentConfig(o.(*openshift.DeploymentConfig))
 			}
 			if selector.Matches(labels.Set(wl.GetPodTemplate().Labels)) {
 				owl, err := nw.maybeReplaceWithOwner(c, wl)
@@ -420,3 +447,25 @@ nextWL:
 	}
 	return filtered
 }
+
+// listWorkloadsPageSize is the number of workloads that the session requests from the traffic-manager
+// in each ListWorkloads call.
+const listWorkloadsPageSize = 500
+
+// workloadKind returns the Kubernetes kind of the given workload kind.
+func workloadKind(k manager.WorkloadInfo_Kind) string {
+	switch k {
+	case manager.WorkloadInfo_DEPLOYMENT:
+		return "Deployment"
+	case manager.WorkloadInfo_REPLICASET:
+		return "ReplicaSet"
+	case manager.WorkloadInfo_STATEFULSET:
+		return "StatefulSet"
+	case manager.WorkloadInfo_DAEMONSET:
+		return "DaemonSet"
+	case manager.WorkloadInfo_DEPLOYMENTCONFIG:
+		return "DeploymentConfig"
+	default:
+		return ""
+	}
+}
@@ -38,8 +38,10 @@ type k8sPortForwardDialer struct {
 
 	// state
 	nextRequestID int64
+	restarts      int64
 	spdyStreamsMu sync.Mutex
 	spdyStreams   map[string]httpstream.Connection // key is "podname.namespace"
+	dialedPods    map[string]struct{}              // pods that a SPDY stream has been established to
 }
 
 type DialerFunc func(context.Context, string) (net.Conn, error)
@@ -48,6 +50,10 @@ type PortForwardDialer interface {
 	io.Closer
 	Dial(ctx context.Context, addr string) (net.Conn, error)
 	DialPod(ctx context.Context, name, namespace string, port uint16) (net.Conn, error)
+
+	// Restarts returns the number of times that the port-forward to a pod had to be re-established
+	// after it was lost.
+	Restarts() int64
 }
 
 // NewK8sPortForwardDialer returns a dialer function (matching the signature required by
@@ -69,6 +75,7 @@ func NewK8sPortForwardDialer(logCtx context.Context, kubeConfig *rest.Config, k8
 		spdyUpgrader:  spdyUpgrader,
 
 		spdyStreams: make(map[string]httpstream.Connection),
+		dialedPods:  make(map[string]struct{}),
 	}
 	return dialer, nil
 }
@@ -100,6 +107,10 @@ func (pf *k8sPortForwardDialer) DialPod(ctx context.Context, name, namespace str
 	return conn, err
 }
 
+func (pf *k8sPortForwardDialer) Restarts() int64 {
+	return atomic.LoadInt64(&pf.restarts)
+}
+
 func (pf *k8sPortForwardDialer) Close() error {
 	pf.spdyStreamsMu.Lock()
 	defer pf.spdyStreamsMu.Unlock()
@@ -249,6 +260,11 @@ func (pf *k8sPortForwardDialer) spdyStream(ctx context.Context, pod *podAddress)
 	}
 
 	pf.spdyStreams[cacheKey] = spdyStream
+	if _, ok := pf.dialedPods[cacheKey]; ok {
+		atomic.AddInt64(&pf.restarts, 1)
+	} else {
+		pf.dialedPods[cacheKey] = struct{}{}
+	}
 	go func() {
 		<-spdyStream.CloseChan()
 		pf.spdyStreamsMu.Lock()
@@ -0,0 +1,48 @@
+package dnet
+
+import (
+	"context"
+	"io"
+	"net"
+)
+
+// Pipe copies data in both directions between the given connections until both directions are done, or
+// until the context is cancelled. A direction that reaches the end of its source half-closes the connection
+// that it writes to, so that the other direction can finish.
+func Pipe(ctx context.Context, a, b net.Conn) {
+	PipeWith(ctx, a, b, CopyAndCloseWrite)
+}
+
+// PipeWith is like Pipe, but copies each direction using the given function.
+func PipeWith(ctx context.Context, a, b net.Conn, copyConn func(dst, src net.Conn)) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// Unblock the copying when the context is cancelled.
+		<-ctx.Done()
+		_ = a.Close()
+		_ = b.Close()
+	}()
+	done := make(chan struct{})
+	go func() {
+		copyConn(b, a)
+		close(done)
+	}()
+	copyConn(a, b)
+	<-done
+}
+
+// CopyAndCloseWrite copies data from src to dst until the end of src, and then half-closes dst.
+func CopyAndCloseWrite(dst, src net.Conn) {
+	_, _ = io.Copy(dst, src)
+	CloseWrite(dst)
+}
+
+// CloseWrite shuts down the writing side of the given connection, or closes it when it can't be half-closed.
+func CloseWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+	} else {
+		_ = c.Close()
+	}
+}
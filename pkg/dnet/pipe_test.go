@@ -0,0 +1,78 @@
+package dnet_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
+)
+
+// tcpPair returns the two ends of a TCP connection on the loopback interface.
+func tcpPair(t *testing.T) (*net.TCPConn, *net.TCPConn) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	server, err := l.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return client.(*net.TCPConn), server.(*net.TCPConn)
+}
+
+func TestPipe(t *testing.T) {
+	a, aPeer := tcpPair(t)
+	b, bPeer := tcpPair(t)
+	done := make(chan struct{})
+	go func() {
+		dnet.Pipe(context.Background(), aPeer, bPeer)
+		close(done)
+	}()
+
+	_, err := a.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, a.CloseWrite())
+	data, err := io.ReadAll(b)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data), "the end of one direction is propagated as a half-close")
+
+	_, err = b.Write([]byte("world"))
+	require.NoError(t, err)
+	require.NoError(t, b.CloseWrite())
+	data, err = io.ReadAll(a)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pipe didn't return when both directions were done")
+	}
+}
+
+func TestPipe_cancel(t *testing.T) {
+	_, aPeer := tcpPair(t)
+	_, bPeer := tcpPair(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dnet.Pipe(ctx, aPeer, bPeer)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pipe didn't return when the context was cancelled")
+	}
+}
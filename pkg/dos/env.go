@@ -50,8 +50,10 @@ func (e MapEnv) Environ() []string {
 	return ks
 }
 
+// ExpandEnv replaces ${var} or $var in the string according to the values of the current
+// environment. See Expand for a description of the supported syntax.
 func (e MapEnv) ExpandEnv(s string) string {
-	return os.Expand(s, e.Getenv)
+	return Expand(s, e.Lookup)
 }
 
 func (e MapEnv) Getenv(key string) string {
@@ -110,7 +112,7 @@ func (osEnv) Environ() []string {
 }
 
 func (osEnv) ExpandEnv(s string) string {
-	return os.ExpandEnv(s)
+	return Expand(s, os.LookupEnv)
 }
 
 func (osEnv) Getenv(s string) string {
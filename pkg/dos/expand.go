@@ -0,0 +1,139 @@
+package dos
+
+import (
+	"strings"
+)
+
+// Expand replaces ${var} or $var in the string based on the lookup function. It's similar to
+// os.Expand but also supports the following forms:
+//
+//   - ${var:-default} expands to default when var is unset or empty.
+//   - ${var-default} expands to default when var is unset.
+//   - ${var:+alt} expands to alt when var is set and not empty, and to the empty string otherwise.
+//   - ${var+alt} expands to alt when var is set, and to the empty string otherwise.
+//   - $$ expands to a single $, which makes it possible to escape a dollar sign.
+//
+// The default and alt words may in turn contain variable references. A "${" that lacks a
+// closing brace is retained verbatim.
+func Expand(s string, lookup func(string) (string, bool)) string {
+	var buf []byte
+	i := 0
+	for j := 0; j < len(s); j++ {
+		if s[j] != '$' || j+1 >= len(s) {
+			continue
+		}
+		if buf == nil {
+			buf = make([]byte, 0, 2*len(s))
+		}
+		buf = append(buf, s[i:j]...)
+		switch c := s[j+1]; {
+		case c == '$':
+			buf = append(buf, '$')
+			j++
+		case c == '{':
+			end := closingBrace(s, j+2)
+			if end < 0 {
+				// Bad syntax. Retain the rest of the string verbatim.
+				return string(append(buf, s[j:]...))
+			}
+			buf = append(buf, expandBraced(s[j+2:end], lookup)...)
+			j = end
+		default:
+			name, w := shellName(s[j+1:])
+			if w == 0 {
+				// Not a variable reference, so retain the dollar sign.
+				buf = append(buf, '$')
+			} else {
+				v, _ := lookup(name)
+				buf = append(buf, v...)
+				j += w
+			}
+		}
+		i = j + 1
+	}
+	if buf == nil {
+		return s
+	}
+	return string(buf) + s[i:]
+}
+
+// expandBraced expands the contents of a ${...} expression.
+func expandBraced(expr string, lookup func(string) (string, bool)) string {
+	name, w := shellName(expr)
+	if w == 0 {
+		return ""
+	}
+	op := expr[w:]
+	if op == "" {
+		v, _ := lookup(name)
+		return v
+	}
+	colon := op[0] == ':'
+	if colon {
+		op = op[1:]
+	}
+	if op == "" || (op[0] != '-' && op[0] != '+') {
+		// Unsupported syntax. Treat the whole expression as the name, like os.Expand does.
+		v, _ := lookup(expr)
+		return v
+	}
+	v, ok := lookup(name)
+	set := ok && (!colon || v != "")
+	if op[0] == '-' {
+		if set {
+			return v
+		}
+		return Expand(op[1:], lookup)
+	}
+	if set {
+		return Expand(op[1:], lookup)
+	}
+	return ""
+}
+
+// closingBrace returns the index of the brace that closes a ${ that ends just before start,
+// taking nested ${...} expressions into account, or -1 if no such brace exists.
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '$':
+			if i+1 < len(s) {
+				switch s[i+1] {
+				case '$':
+					i++
+				case '{':
+					depth++
+					i++
+				}
+			}
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// shellName returns the name that begins the string and the number of bytes consumed to extract
+// it. A name is either a single special shell variable character or a sequence of alphanumeric
+// characters and underscores.
+func shellName(s string) (string, int) {
+	if s == "" {
+		return "", 0
+	}
+	if strings.IndexByte("*#@!?-0123456789", s[0]) >= 0 {
+		return s[:1], 1
+	}
+	i := 0
+	for i < len(s) && isAlphaNum(s[i]) {
+		i++
+	}
+	return s[:i], i
+}
+
+func isAlphaNum(c uint8) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,48 @@
+package dos_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+func TestMapEnv_ExpandEnv(t *testing.T) {
+	env := dos.MapEnv{
+		"NAME":  "echo",
+		"EMPTY": "",
+		"OTHER": "other",
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"no variables", "no variables"},
+		{"$NAME-secret", "echo-secret"},
+		{"${NAME}-secret", "echo-secret"},
+		{"${MISSING}-secret", "-secret"},
+		{"${NAME:-default}", "echo"},
+		{"${MISSING:-default}", "default"},
+		{"${EMPTY:-default}", "default"},
+		{"${EMPTY-default}", ""},
+		{"${MISSING-default}", "default"},
+		{"${NAME:+alt}", "alt"},
+		{"${EMPTY:+alt}", ""},
+		{"${EMPTY+alt}", "alt"},
+		{"${MISSING+alt}", ""},
+		{"${MISSING:-${OTHER}-tls}", "other-tls"},
+		{"${MISSING:-${ALSO_MISSING:-x}}", "x"},
+		{"$$NAME", "$NAME"},
+		{"$${NAME}", "${NAME}"},
+		{"cost: 5$", "cost: 5$"},
+		{"a $ b", "a $ b"},
+		{"${NAME", "${NAME"},
+		{"${MISSING:-$$}", "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, env.ExpandEnv(tt.in))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package errcat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// A Code identifies a well-known error. The ID of a code is stable across releases and, unlike the
+// error message, safe for support and tooling to depend on. Each code also carries the Category of
+// the errors it creates and an optional URL to a page that explains how to resolve the problem.
+type Code struct {
+	ID       string
+	Category Category
+	HelpURL  string
+}
+
+const docsURL = "https://www.getambassador.io/docs/telepresence/latest/"
+
+// Codes used by errors that are returned from the connect command family.
+var (
+	ConnectFailed           = Register("TP1000", Unknown, docsURL+"troubleshooting")
+	ClusterUnreachable      = Register("TP1001", Unknown, docsURL+"troubleshooting")
+	Unauthorized            = Register("TP1002", User, docsURL+"reference/rbac")
+	TrafficManagerFailed    = Register("TP1003", Unknown, docsURL+"troubleshooting")
+	RootDaemonFailed        = Register("TP1004", Unknown, docsURL+"troubleshooting")
+	MustRestart             = Register("TP1005", User, "")
+	VersionMismatch         = Register("TP1006", User, "")
+	ExecutableMismatch      = Register("TP1007", User, "")
+	ExposedPortsDiffer      = Register("TP1008", User, "")
+	DaemonLaunchFailed      = Register("TP1009", NoDaemonLogs, "")
+	DirectoryCreationFailed = Register("TP1010", NoDaemonLogs, "")
+)
+
+// Codes used by errors that are returned from the intercept command family.
+var (
+	InterceptFailed          = Register("TP2000", Unknown, docsURL+"troubleshooting")
+	NotConnected             = Register("TP2001", User, "")
+	NoTrafficManager         = Register("TP2002", Unknown, docsURL+"install/manager")
+	TrafficManagerConnecting = Register("TP2003", Unknown, "")
+	InterceptExists          = Register("TP2004", User, "")
+	NamespaceAmbiguity       = Register("TP2005", User, "")
+	LocalTargetInUse         = Register("TP2006", User, "")
+	NoAcceptableWorkload     = Register("TP2007", User, docsURL+"reference/intercepts")
+	AmbiguousMatch           = Register("TP2008", User, "")
+	InterceptNotEstablished  = Register("TP2009", Unknown, docsURL+"troubleshooting")
+	UnsupportedWorkload      = Register("TP2010", User, docsURL+"reference/intercepts")
+	InterceptNotFound        = Register("TP2011", User, "")
+	MountPointBusy           = Register("TP2012", User, "")
+	MisconfiguredWorkload    = Register("TP2013", User, docsURL+"reference/intercepts")
+	UnknownFlag              = Register("TP2014", User, "")
+	InvalidInterceptFlags    = Register("TP2015", User, "")
+	DockerRunFailed          = Register("TP2016", NoDaemonLogs, "")
+	AmbiguousPort            = Register("TP2017", User, docsURL+"reference/intercepts")
+	InterceptQuotaExceeded   = Register("TP2018", User, "")
+)
+
+var (
+	catalogLock sync.RWMutex
+	catalog     = make(map[string]*Code)
+)
+
+// Register adds a new Code to the catalog and returns it. It panics if a code with the given ID
+// has already been registered. Extensions may register their own codes, but should use a prefix
+// other than "TP".
+func Register(id string, category Category, helpURL string) *Code {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	if _, dup := catalog[id]; dup {
+		panic(fmt.Sprintf("error code %q is already registered", id))
+	}
+	c := &Code{ID: id, Category: category, HelpURL: helpURL}
+	catalog[id] = c
+	return c
+}
+
+// Lookup returns the registered Code with the given ID, or nil if no such code exists.
+func Lookup(id string) *Code {
+	catalogLock.RLock()
+	c := catalog[id]
+	catalogLock.RUnlock()
+	return c
+}
+
+// Catalog returns all registered codes, sorted by ID.
+func Catalog() []*Code {
+	catalogLock.RLock()
+	cs := make([]*Code, 0, len(catalog))
+	for _, c := range catalog {
+		cs = append(cs, c)
+	}
+	catalogLock.RUnlock()
+	sort.Slice(cs, func(i, j int) bool { return cs[i].ID < cs[j].ID })
+	return cs
+}
+
+func (c *Code) String() string {
+	return c.ID
+}
+
+// New creates a new error with this code, based on its argument. The argument is treated the same
+// way as in Category.New.
+func (c *Code) New(untypedErr any) error {
+	err := c.Category.New(untypedErr)
+	if err != nil {
+		err.(*categorized).code = c
+	}
+	return err
+}
+
+// Newf creates a new error with this code, based on a format string with arguments.
+func (c *Code) Newf(format string, a ...any) error {
+	return &categorized{error: fmt.Errorf(format, a...), category: c.Category, code: c}
+}
+
+// WithCode returns an error that wraps the given error and has the given code. The category of the
+// given error is retained if it has one. The category of the code is used otherwise.
+func WithCode(err error, code *Code) error {
+	if err == nil {
+		return nil
+	}
+	cat := code.Category
+	var ce *categorized
+	if errors.As(err, &ce) {
+		cat = ce.category
+	}
+	return &categorized{error: err, category: cat, code: code}
+}
+
+// GetCode returns the Code of the given error, or nil if the error has no code.
+func GetCode(err error) *Code {
+	var ce *categorized
+	for errors.As(err, &ce) {
+		if ce.code != nil {
+			return ce.code
+		}
+		err = ce.error
+	}
+	return nil
+}
+
+// Describe returns a text that contains the code and the help URL of the given error, suitable
+// for display following the error message. An empty string is returned when the error has
+// no code.
+func Describe(err error) string {
+	c := GetCode(err)
+	switch {
+	case c == nil:
+		return ""
+	case c.HelpURL == "":
+		return fmt.Sprintf("error code: %s", c.ID)
+	default:
+		return fmt.Sprintf("error code: %s, see %s", c.ID, c.HelpURL)
+	}
+}
@@ -0,0 +1,64 @@
+package errcat
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode(t *testing.T) {
+	err := VersionMismatch.Newf("client %s != daemon %s", "v2.19.0", "v2.19.1")
+	assert.Equal(t, "client v2.19.0 != daemon v2.19.1", err.Error())
+	assert.Equal(t, User, GetCategory(err))
+	assert.Same(t, VersionMismatch, GetCode(err))
+
+	wrapped := fmt.Errorf("connect failed: %w", err)
+	assert.Same(t, VersionMismatch, GetCode(wrapped))
+	assert.Nil(t, GetCode(errors.New("plain")))
+	assert.Nil(t, GetCode(User.New("categorized")))
+}
+
+func TestWithCode(t *testing.T) {
+	assert.Nil(t, WithCode(nil, ConnectFailed))
+
+	// The category of the error takes precedence over the category of the code.
+	err := WithCode(Config.New("bad kubeconfig"), ConnectFailed)
+	assert.Equal(t, Config, GetCategory(err))
+	assert.Same(t, ConnectFailed, GetCode(err))
+
+	err = WithCode(errors.New("boom"), Unauthorized)
+	assert.Equal(t, User, GetCategory(err))
+}
+
+func TestResultRoundTrip(t *testing.T) {
+	err := FromResult(ToResult(MountPointBusy.New("busy")))
+	require.Error(t, err)
+	assert.Equal(t, "busy", err.Error())
+	assert.Equal(t, User, GetCategory(err))
+	assert.Same(t, MountPointBusy, GetCode(err))
+
+	r := ToResult(User.New("no code"))
+	r.ErrorCode = "XX0001"
+	err = FromResult(r)
+	require.NotNil(t, GetCode(err))
+	assert.Equal(t, "XX0001", GetCode(err).ID)
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Empty(t, Describe(errors.New("plain")))
+	assert.Equal(t, "error code: TP1006", Describe(VersionMismatch.New("x")))
+	assert.Equal(t, "error code: TP2011", Describe(InterceptNotFound.New("x")))
+	assert.Contains(t, Describe(Unauthorized.New("x")), "see https://")
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	assert.Panics(t, func() { Register(VersionMismatch.ID, User, "") })
+	assert.Same(t, VersionMismatch, Lookup(VersionMismatch.ID))
+	cs := Catalog()
+	for i := 1; i < len(cs); i++ {
+		assert.Less(t, cs[i-1].ID, cs[i].ID)
+	}
+}
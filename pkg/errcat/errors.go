@@ -14,6 +14,7 @@ type Category int
 type categorized struct {
 	error
 	category Category
+	code     *Code
 }
 
 const (
@@ -78,7 +79,14 @@ func FromResult(r *common.Result) error {
 	if c == OK {
 		return nil
 	}
-	return &categorized{error: errors.New(string(r.Data)), category: c}
+	ce := &categorized{error: errors.New(string(r.Data)), category: c}
+	if r.ErrorCode != "" {
+		if ce.code = Lookup(r.ErrorCode); ce.code == nil {
+			// The code is unknown to this process, so it was probably registered by a newer peer.
+			ce.code = &Code{ID: r.ErrorCode, Category: c}
+		}
+	}
+	return ce
 }
 
 func ToResult(err error) *common.Result {
@@ -86,6 +94,9 @@ func ToResult(err error) *common.Result {
 	if err != nil {
 		r.Data = []byte(err.Error())
 		r.ErrorCategory = common.Result_ErrorCategory(GetCategory(err))
+		if c := GetCode(err); c != nil {
+			r.ErrorCode = c.ID
+		}
 	}
 	return r
 }
@@ -0,0 +1,70 @@
+package filelocation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// Environment variables that relocate the directories that Telepresence uses.
+const (
+	// EnvConfigDir overrides the AppUserConfigDir.
+	EnvConfigDir = "TELEPRESENCE_CONFIG_DIR"
+
+	// EnvCacheDir overrides the AppUserCacheDir.
+	EnvCacheDir = "TELEPRESENCE_CACHE_DIR"
+
+	// EnvLogsDir overrides the AppUserLogDir.
+	EnvLogsDir = "TELEPRESENCE_LOGS_DIR"
+
+	// EnvHome enables portable mode. The config, cache, and logs directories are then placed in
+	// "config", "cache", and "logs" subdirectories of the given directory, unless they are
+	// overridden individually.
+	EnvHome = "TELEPRESENCE_HOME"
+)
+
+// WithEnvOverrides returns a context in which the AppUserConfigDir, AppUserCacheDir, and
+// AppUserLogDir are relocated according to the TELEPRESENCE_CONFIG_DIR, TELEPRESENCE_CACHE_DIR,
+// TELEPRESENCE_LOGS_DIR, and TELEPRESENCE_HOME environment variables. Directories that are
+// already spoofed in the given context are retained.
+//
+// Relative paths are made absolute, because the daemons don't necessarily share the working
+// directory of the process that started them.
+func WithEnvOverrides(ctx context.Context) context.Context {
+	home := absDir(os.Getenv(EnvHome))
+	dir := func(env, sub string) string {
+		if d := absDir(os.Getenv(env)); d != "" {
+			return d
+		}
+		if home != "" {
+			return filepath.Join(home, sub)
+		}
+		return ""
+	}
+	if _, ok := ctx.Value(configCtxKey{}).(string); !ok {
+		if d := dir(EnvConfigDir, "config"); d != "" {
+			ctx = WithAppUserConfigDir(ctx, d)
+		}
+	}
+	if _, ok := ctx.Value(cacheCtxKey{}).(string); !ok {
+		if d := dir(EnvCacheDir, "cache"); d != "" {
+			ctx = WithAppUserCacheDir(ctx, d)
+		}
+	}
+	if _, ok := ctx.Value(logCtxKey{}).(string); !ok {
+		if d := dir(EnvLogsDir, "logs"); d != "" {
+			ctx = WithAppUserLogDir(ctx, d)
+		}
+	}
+	return ctx
+}
+
+func absDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if ad, err := filepath.Abs(dir); err == nil {
+		return ad
+	}
+	return dir
+}
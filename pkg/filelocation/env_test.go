@@ -0,0 +1,70 @@
+package filelocation
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithEnvOverrides(t *testing.T) {
+	root := t.TempDir()
+	type testcase struct {
+		env               map[string]string
+		expectedConfigDir string
+		expectedCacheDir  string
+		expectedLogDir    string
+	}
+	testcases := map[string]testcase{
+		"individual": {
+			env: map[string]string{
+				EnvConfigDir: filepath.Join(root, "cfg"),
+				EnvCacheDir:  filepath.Join(root, "cache"),
+				EnvLogsDir:   filepath.Join(root, "log"),
+			},
+			expectedConfigDir: filepath.Join(root, "cfg"),
+			expectedCacheDir:  filepath.Join(root, "cache"),
+			expectedLogDir:    filepath.Join(root, "log"),
+		},
+		"portable": {
+			env: map[string]string{
+				EnvHome: root,
+			},
+			expectedConfigDir: filepath.Join(root, "config"),
+			expectedCacheDir:  filepath.Join(root, "cache"),
+			expectedLogDir:    filepath.Join(root, "logs"),
+		},
+		"portable-with-override": {
+			env: map[string]string{
+				EnvHome:     root,
+				EnvLogsDir:  filepath.Join(root, "other"),
+				EnvCacheDir: "",
+			},
+			expectedConfigDir: filepath.Join(root, "config"),
+			expectedCacheDir:  filepath.Join(root, "cache"),
+			expectedLogDir:    filepath.Join(root, "other"),
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			for _, env := range []string{EnvHome, EnvConfigDir, EnvCacheDir, EnvLogsDir} {
+				t.Setenv(env, tc.env[env])
+			}
+			ctx := WithEnvOverrides(dlog.NewTestContext(t, true))
+			assert.Equal(t, tc.expectedConfigDir, AppUserConfigDir(ctx))
+			assert.Equal(t, tc.expectedCacheDir, AppUserCacheDir(ctx))
+			assert.Equal(t, tc.expectedLogDir, AppUserLogDir(ctx))
+		})
+	}
+
+	t.Run("spoofed-retained", func(t *testing.T) {
+		t.Setenv(EnvHome, root)
+		ctx := WithAppUserConfigDir(dlog.NewTestContext(t, true), "/spoofed")
+		ctx = WithEnvOverrides(ctx)
+		assert.Equal(t, "/spoofed", AppUserConfigDir(ctx))
+		assert.Equal(t, filepath.Join(root, "cache"), AppUserCacheDir(ctx))
+	})
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net"
+	"net/http"
 	"sync"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/log"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
@@ -17,7 +21,7 @@ import (
 type Interceptor interface {
 	io.Closer
 	InterceptId() string
-	InterceptInfo() *restapi.InterceptInfo
+	InterceptInfo(path string, header http.Header) *restapi.InterceptInfo
 	Serve(context.Context, chan<- net.Addr) error
 	SetIntercepting(*manager.InterceptInfo)
 	SetStreamProvider(tunnel.ClientStreamProvider)
@@ -38,6 +42,9 @@ type interceptor struct {
 	streamProvider tunnel.ClientStreamProvider
 
 	intercept *manager.InterceptInfo
+	headers   matcher.HeaderExpr
+	grpc      *matcher.GRPC
+	affinity  *matcher.Affinity
 }
 
 func NewInterceptor(addr net.Addr, targetHost string, targetPort uint16) Interceptor {
@@ -51,6 +58,23 @@ func NewInterceptor(addr net.Addr, targetHost string, targetPort uint16) Interce
 	}
 }
 
+// splitSelects returns true if a new connection should be routed to the intercepting client of an
+// intercept that routes the given percentage of the connections to the client. A percentage that is
+// zero or less means that all connections are routed to the client.
+// withInterceptFields adds the ID of the given intercept and the ID of the intercepting client's session to
+// the fields of the context's logger.
+func withInterceptFields(ctx context.Context, iCept *manager.InterceptInfo) context.Context {
+	ctx = dlog.WithField(ctx, log.FieldIntercept, iCept.Id)
+	if sessionID := iCept.ClientSession.GetSessionId(); sessionID != "" {
+		ctx = dlog.WithField(ctx, log.FieldSession, sessionID)
+	}
+	return ctx
+}
+
+func splitSelects(percent int32) bool {
+	return percent <= 0 || percent >= 100 || rand.Int32N(100) < percent
+}
+
 func (f *interceptor) SetStreamProvider(streamProvider tunnel.ClientStreamProvider) {
 	f.mu.Lock()
 	f.streamProvider = streamProvider
@@ -69,10 +93,13 @@ func (f *interceptor) Target() (string, uint16) {
 	return f.targetHost, f.targetPort
 }
 
-func (f *interceptor) InterceptInfo() *restapi.InterceptInfo {
+// InterceptInfo returns the info of a request with the given path and header. The request is intercepted
+// when there is an intercept and the request matches the intercept's header and gRPC conditions, or
+// when it has the session key of an earlier request that matched them.
+func (f *interceptor) InterceptInfo(path string, header http.Header) *restapi.InterceptInfo {
 	ii := &restapi.InterceptInfo{}
 	f.mu.Lock()
-	if f.intercept != nil {
+	if f.intercept != nil && f.affinity.Stick(f.headers.Matches(header) && f.grpc.Matches(path, header), header) {
 		ii.Intercepted = true
 		ii.Metadata = f.intercept.Metadata
 	}
@@ -121,4 +148,21 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 	// Set up new target and lifetime
 	f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
 	f.intercept = intercept
+	f.headers = nil
+	f.grpc = nil
+	f.affinity = nil
+	if intercept != nil {
+		// The traffic-manager validates the conditions, so errors are not expected.
+		var err error
+		spec := intercept.Spec
+		if f.headers, err = matcher.ParseHeaderExpr(spec.HttpHeaders); err != nil {
+			dlog.Errorf(f.lCtx, "ignoring the header conditions of intercept %s: %v", iceptInfo(intercept), err)
+		}
+		if f.grpc, err = matcher.NewGRPC(spec.GrpcMethods, spec.GrpcMetadata); err != nil {
+			dlog.Errorf(f.lCtx, "ignoring the gRPC conditions of intercept %s: %v", iceptInfo(intercept), err)
+		}
+		if f.affinity, err = matcher.NewAffinity(spec.SessionAffinity); err != nil {
+			dlog.Errorf(f.lCtx, "ignoring the session affinity of intercept %s: %v", iceptInfo(intercept), err)
+		}
+	}
 }
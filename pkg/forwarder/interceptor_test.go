@@ -0,0 +1,27 @@
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitSelects(t *testing.T) {
+	const n = 10000
+	count := func(percent int32) int {
+		selected := 0
+		for i := 0; i < n; i++ {
+			if splitSelects(percent) {
+				selected++
+			}
+		}
+		return selected
+	}
+	assert.Equal(t, n, count(0))
+	assert.Equal(t, n, count(100))
+	for _, percent := range []int32{1, 25, 50, 90} {
+		got := count(percent)
+		want := n * int(percent) / 100
+		assert.InDelta(t, want, got, n/20, "percent %d", percent)
+	}
+}
@@ -0,0 +1,106 @@
+package forwarder
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// mirrorQueueSize is the number of chunks of data that may be waiting to be sent to the intercepting
+// client. The mirror is abandoned when the client can't keep up, so that it never slows down the
+// connection that it mirrors.
+const mirrorQueueSize = 64
+
+// mirror is an io.Writer that copies the data written to it to a stream to the intercepting client.
+// Writes never block and never fail. The responses from the client are discarded.
+type mirror struct {
+	ctx    context.Context
+	ch     chan []byte
+	broken bool
+}
+
+// newMirror returns a mirror that sends the data written to it to the intercepting client of the
+// given intercept, as if it was sent on a connection from the given address. The mirror must be
+// closed when the mirrored connection ends.
+func newMirror(ctx context.Context, sp tunnel.ClientStreamProvider, addr net.Addr, iCept *manager.InterceptInfo) *mirror {
+	m := &mirror{ctx: ctx, ch: make(chan []byte, mirrorQueueSize)}
+	srcIp, srcPort, err := iputil.SplitToIPPort(addr)
+	if err != nil {
+		dlog.Errorf(ctx, "failed to parse mirror source address %s: %v", addr, err)
+		m.broken = true
+		close(m.ch)
+		return m
+	}
+	spec := iCept.Spec
+	id := tunnel.NewConnID(ipproto.TCP, srcIp, iputil.Parse(spec.TargetHost), srcPort, uint16(spec.TargetPort))
+	go m.run(sp, id, iCept)
+	return m
+}
+
+func (m *mirror) run(sp tunnel.ClientStreamProvider, id tunnel.ConnID, iCept *manager.InterceptInfo) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+	spec := iCept.Spec
+	clientSession := iCept.ClientSession.SessionId
+	s, err := sp.CreateClientStream(ctx, clientSession, id, time.Duration(spec.RoundtripLatency), time.Duration(spec.DialTimeout))
+	if err != nil {
+		dlog.Errorf(ctx, "unable to mirror %s: %v", id, err)
+		for range m.ch { //nolint:revive // drain until the mirrored connection ends
+		}
+		return
+	}
+	dlog.Debugf(ctx, "Mirroring %s", id)
+	defer dlog.Debugf(ctx, "Done mirroring %s", id)
+
+	conn, local := net.Pipe()
+	ingressBytes := tunnel.NewCounterProbe("FromClientBytes")
+	egressBytes := tunnel.NewCounterProbe("ToClientBytes")
+	d := tunnel.NewConnEndpoint(s, conn, cancel, egressBytes, ingressBytes)
+	d.Start(ctx)
+	go func() {
+		_, _ = io.Copy(io.Discard, local)
+	}()
+	for data := range m.ch {
+		if _, err = local.Write(data); err != nil {
+			// Keep draining until the mirrored connection ends.
+			continue
+		}
+	}
+	_ = local.Close()
+	<-d.Done()
+	sp.ReportMetrics(ctx, &manager.TunnelMetrics{
+		ClientSessionId: clientSession,
+		IngressBytes:    ingressBytes.GetValue(),
+		EgressBytes:     egressBytes.GetValue(),
+	})
+}
+
+// Write sends a copy of the given data to the mirror, unless the mirror has been abandoned because
+// it couldn't keep up.
+func (m *mirror) Write(data []byte) (int, error) {
+	if !m.broken {
+		select {
+		case m.ch <- append([]byte(nil), data...):
+		default:
+			dlog.Warn(m.ctx, "abandoning mirror because the intercepting client can't keep up")
+			m.broken = true
+			close(m.ch)
+		}
+	}
+	return len(data), nil
+}
+
+// Close ends the mirror once the data written to it has been sent.
+func (m *mirror) Close() {
+	if !m.broken {
+		m.broken = true
+		close(m.ch)
+	}
+}
@@ -0,0 +1,39 @@
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func Test_mirrorWrite(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	m := &mirror{ctx: ctx, ch: make(chan []byte, 1)}
+
+	data := []byte("hello")
+	n, err := m.Write(data)
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), n)
+
+	// The written data is copied.
+	data[0] = 'j'
+	assert.Equal(t, []byte("hello"), <-m.ch)
+
+	// The queue is full after this write, so the next one abandons the mirror.
+	_, _ = m.Write([]byte("one"))
+	n, err = m.Write([]byte("two"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.True(t, m.broken)
+
+	// Writes to an abandoned mirror still succeed, and closing it is a no-op.
+	n, err = m.Write([]byte("three"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	m.Close()
+	assert.Equal(t, []byte("one"), <-m.ch)
+	_, ok := <-m.ch
+	assert.False(t, ok)
+}
@@ -2,6 +2,7 @@ package forwarder
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
@@ -20,6 +21,7 @@ import (
 
 type tcp struct {
 	interceptor
+	tlsConfig *tls.Config
 }
 
 func newTCP(listen net.Addr, targetHost string, targetPort uint16) Interceptor {
@@ -98,8 +100,21 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	targetHost := f.targetHost
 	targetPort := f.targetPort
 	intercept := f.intercept
+	sp := f.streamProvider
 	f.mu.Unlock()
-	if intercept != nil {
+	if intercept != nil && !splitSelects(intercept.Spec.TrafficPercent) {
+		// This connection is served by the target.
+		intercept = nil
+	}
+	if intercept != nil && !intercept.Spec.Mirror {
+		if intercept.Spec.TerminateTls {
+			conn := f.terminateTLS(clientConn)
+			if conn == nil {
+				clientConn.Close()
+				return fmt.Errorf("unable to terminate TLS for intercept %s: no terminating TLS secret", intercept.Spec.Name)
+			}
+			return f.interceptConn(ctx, conn, intercept)
+		}
 		return f.interceptConn(ctx, clientConn, intercept)
 	}
 
@@ -126,12 +141,24 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	}
 	defer targetConn.Close()
 
+	var src io.Reader = clientConn
+	var m *mirror
+	if intercept != nil {
+		// Mirroring. The target serves the connection, and the intercepting client gets a copy of what
+		// the target receives.
+		m = newMirror(ctx, sp, clientConn.RemoteAddr(), intercept)
+		src = io.TeeReader(clientConn, m)
+	}
+
 	done := make(chan struct{})
 
 	go func() {
-		if _, err := io.Copy(targetConn, clientConn); err != nil {
+		if _, err := io.Copy(targetConn, src); err != nil {
 			dlog.Debugf(ctx, "Error clientConn->targetConn: %+v", err)
 		}
+		if m != nil {
+			m.Close()
+		}
 		_ = targetConn.CloseWrite()
 		done <- struct{}{}
 	}()
@@ -156,6 +183,7 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 }
 
 func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.InterceptInfo) error {
+	ctx = withInterceptFields(ctx, iCept)
 	ctx, span := otel.Tracer("").Start(ctx, "interceptConn")
 	defer span.End()
 	tracing.RecordInterceptInfo(span, iCept)
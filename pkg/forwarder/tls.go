@@ -0,0 +1,40 @@
+package forwarder
+
+import (
+	"crypto/tls"
+	"net"
+)
+
+// TLSTerminator is implemented by the Interceptors that can terminate the TLS of the connections that
+// they route to the intercepting client.
+type TLSTerminator interface {
+	// SetTerminatingTLS sets the config used when terminating TLS. A nil config disables termination.
+	SetTerminatingTLS(*tls.Config)
+
+	// TerminatesTLS returns true if the Interceptor has a config for terminating TLS.
+	TerminatesTLS() bool
+}
+
+func (f *tcp) SetTerminatingTLS(config *tls.Config) {
+	f.mu.Lock()
+	f.tlsConfig = config
+	f.mu.Unlock()
+}
+
+func (f *tcp) TerminatesTLS() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tlsConfig != nil
+}
+
+// terminateTLS returns a connection that terminates the TLS of the given connection, or nil if the
+// interceptor has no config for terminating TLS.
+func (f *tcp) terminateTLS(conn net.Conn) net.Conn {
+	f.mu.Lock()
+	config := f.tlsConfig
+	f.mu.Unlock()
+	if config == nil {
+		return nil
+	}
+	return tls.Server(conn, config)
+}
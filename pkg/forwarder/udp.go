@@ -82,7 +82,7 @@ func (f *udp) Serve(ctx context.Context, initCh chan<- net.Addr) error {
 
 func (f *udp) forward(ctx context.Context, conn *net.UDPConn, intercept *manager.InterceptInfo) error {
 	defer conn.Close()
-	if intercept != nil {
+	if intercept != nil && !intercept.Spec.Mirror {
 		f.interceptConn(ctx, conn, intercept)
 		return nil
 	}
@@ -202,6 +202,7 @@ func (u *udpHandler) forward(ctx context.Context) {
 }
 
 func (f *udp) interceptConn(ctx context.Context, conn *net.UDPConn, iCept *manager.InterceptInfo) {
+	ctx = withInterceptFields(ctx, iCept)
 	ctx, span := otel.Tracer("").Start(ctx, "interceptConn")
 	defer span.End()
 	tracing.RecordInterceptInfo(span, iCept)
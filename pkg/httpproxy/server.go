@@ -0,0 +1,150 @@
+// Package httpproxy contains a minimal HTTP forward proxy. It supports the CONNECT method, which is what
+// clients use for https and other TLS protected traffic, and requests for absolute http URLs, which is
+// what clients that honor the HTTP_PROXY environment variable send for plain http.
+package httpproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
+)
+
+// handshakeTimeout is the max time that a client may spend on sending the request headers.
+const handshakeTimeout = 10 * time.Second
+
+// DialFunc dials the given address. The host part of the address is either an IP or a name that the
+// function is expected to resolve.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// hopHeaders are the hop-by-hop headers that must not be forwarded by a proxy.
+var hopHeaders = []string{ //nolint:gochecknoglobals // constant
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+type handler struct {
+	ctx       context.Context
+	dial      DialFunc
+	transport *http.Transport
+}
+
+// Serve accepts connections on the given listener and serves them as an HTTP proxy, using the given dial
+// function to reach the destinations, until the context is cancelled. The listener is closed when Serve
+// returns.
+func Serve(ctx context.Context, l net.Listener, dial DialFunc) error {
+	h := &handler{
+		ctx:  ctx,
+		dial: dial,
+		transport: &http.Transport{
+			DialContext:         dial,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     time.Minute,
+		},
+	}
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: handshakeTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	defer h.transport.CloseIdleConnections()
+	err := srv.Serve(l)
+	if ctx.Err() != nil || errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		h.serveConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "this is a proxy, and it only accepts requests for absolute URLs", http.StatusBadRequest)
+		return
+	}
+	h.serveForward(w, r)
+}
+
+// serveConnect dials the requested address and then copies data between the client and the destination.
+func (h *handler) serveConnect(w http.ResponseWriter, r *http.Request) {
+	ctx := h.ctx
+	target, err := h.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		dlog.Debugf(ctx, "HTTP proxy dial %s failed: %v", r.Host, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer target.Close()
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		dlog.Debugf(ctx, "HTTP proxy hijack failed: %v", err)
+		return
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Time{})
+	if _, err = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		return
+	}
+	dlog.Tracef(ctx, "HTTP proxy %s connected to %s", conn.RemoteAddr(), r.Host)
+
+	// The client may have sent data beyond the request headers before the reply.
+	if n := brw.Reader.Buffered(); n > 0 {
+		data, _ := brw.Reader.Peek(n)
+		if _, err = target.Write(data); err != nil {
+			return
+		}
+	}
+
+	dnet.Pipe(ctx, conn, target)
+}
+
+// serveForward sends the request to its destination and copies the response back to the client.
+func (h *handler) serveForward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+	resp, err := h.transport.RoundTrip(out)
+	if err != nil {
+		dlog.Debugf(h.ctx, "HTTP proxy request for %s failed: %v", r.URL, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	removeHopHeaders(resp.Header)
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func removeHopHeaders(hdr http.Header) {
+	for _, h := range hopHeaders {
+		hdr.Del(h)
+	}
+}
@@ -0,0 +1,108 @@
+package httpproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer echo.Close()
+	go func() {
+		for {
+			c, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(c, c)
+				_ = c.Close()
+			}()
+		}
+	}()
+
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from "+r.Host)
+	}))
+	defer web.Close()
+
+	// The dial function resolves the names "echo.svc" and "web.svc" and refuses everything else.
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		switch address {
+		case "echo.svc:80":
+			return (&net.Dialer{}).DialContext(ctx, network, echo.Addr().String())
+		case "web.svc:80":
+			return (&net.Dialer{}).DialContext(ctx, network, web.Listener.Addr().String())
+		}
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("refused")}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, l, dial)
+	}()
+	proxyURL := &url.URL{Scheme: "http", Host: l.Addr().String()}
+
+	t.Run("CONNECT", func(t *testing.T) {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = io.WriteString(conn, "CONNECT echo.svc:80 HTTP/1.1\r\nHost: echo.svc:80\r\n\r\n")
+		require.NoError(t, err)
+		const established = "HTTP/1.1 200 Connection established\r\n\r\n"
+		buf := make([]byte, len(established))
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, established, string(buf))
+
+		_, err = io.WriteString(conn, "ping")
+		require.NoError(t, err)
+		buf = make([]byte, 4)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(buf))
+	})
+
+	t.Run("CONNECT refused", func(t *testing.T) {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = io.WriteString(conn, "CONNECT other.svc:80 HTTP/1.1\r\nHost: other.svc:80\r\n\r\n")
+		require.NoError(t, err)
+		buf := make([]byte, 12)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1 502", string(buf))
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		hc := http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := hc.Get("http://web.svc/")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello from web.svc", string(body))
+	})
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -15,7 +15,7 @@ const (
 // TCP, UDP, and ICMP.
 func Parse(network string) int {
 	switch network {
-	case "tcp", "tcp4":
+	case "tcp", "tcp4", "tcp6":
 		return TCP
 	case "udp", "udp4", "udp6":
 		return UDP
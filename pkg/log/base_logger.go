@@ -8,9 +8,9 @@ import (
 	"github.com/datawire/dlib/dlog"
 )
 
-func MakeBaseLogger(ctx context.Context, logLevel string) context.Context {
+func MakeBaseLogger(ctx context.Context, logLevel, logFormat string) context.Context {
 	logrusLogger := logrus.StandardLogger()
-	logrusFormatter := NewFormatter("2006-01-02 15:04:05.0000")
+	logrusFormatter := MakeFormatter(logFormat, "2006-01-02 15:04:05.0000")
 	logrusLogger.SetFormatter(logrusFormatter)
 
 	SetLogrusLevel(logrusLogger, logLevel, false)
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The log formats that are understood by all Telepresence components.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Names of fields that are added to the log entries of all components, so that log entries from different
+// components can be correlated when the logs are structured.
+const (
+	FieldSession   = "session_id"
+	FieldConnID    = "conn_id"
+	FieldIntercept = "intercept_id"
+)
+
+// ValidFormat returns an error unless the given format is empty, FormatText, or FormatJSON.
+func ValidFormat(format string) error {
+	switch format {
+	case "", FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid log format %q, must be %q or %q", format, FormatText, FormatJSON)
+	}
+}
+
+// MakeFormatter returns a JSONFormatter when the given format is FormatJSON, and otherwise a Formatter that
+// uses the given timestamp format.
+func MakeFormatter(format, timestampFormat string) logrus.Formatter {
+	if format == FormatJSON {
+		return NewJSONFormatter()
+	}
+	return NewFormatter(timestampFormat)
+}
+
+// SetFormat sets the formatter of the standard logger to one that produces the given format.
+func SetFormat(format string) {
+	logrus.StandardLogger().SetFormatter(MakeFormatter(format, "2006-01-02 15:04:05.0000"))
+}
+
+// JSONFormatter formats log messages for Telepresence as JSON objects, one per line. Each object has the
+// fields "time", "level", and "msg", the field "goroutine" when the goroutine is named, the field "caller"
+// when the caller is reported, and one field for each field of the log entry.
+type JSONFormatter struct{}
+
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+// Format implements logrus.Formatter.
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	obj := make(map[string]any, len(entry.Data)+5)
+	for key, val := range entry.Data {
+		switch val.(type) {
+		case string, bool, int, int32, int64, uint, uint16, uint32, uint64, float64:
+		default:
+			// Formatted the same way as by the Formatter, so that values always can be marshalled.
+			val = fmt.Sprintf("%+v", val)
+		}
+		obj[key] = val
+	}
+	if goroutine, ok := obj["THREAD"].(string); ok {
+		delete(obj, "THREAD")
+		obj["goroutine"] = strings.TrimPrefix(goroutine, "/")
+	}
+	obj["time"] = entry.Time.Format(time.RFC3339Nano)
+	obj["level"] = entry.Level.String()
+	obj["msg"] = entry.Message
+	if entry.HasCaller() && strings.HasPrefix(entry.Caller.File, thisModule+"/") {
+		obj["caller"] = fmt.Sprintf("%s:%d", strings.TrimPrefix(entry.Caller.File, thisModule+"/"), entry.Caller.Line)
+	}
+
+	// The encoder appends the newline.
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(obj); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
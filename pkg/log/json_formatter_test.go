@@ -0,0 +1,50 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	entry := &logrus.Entry{
+		Time:    time.Date(2024, 5, 17, 13, 14, 15, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "connection <lost>",
+		Data: logrus.Fields{
+			"THREAD":       "/daemon/session",
+			FieldSession:   "4711",
+			FieldIntercept: "4711:echo",
+			"count":        3,
+			"err":          errors.New("broken pipe"),
+		},
+	}
+	bs, err := NewJSONFormatter().Format(entry)
+	require.NoError(t, err)
+	require.Equal(t, byte('\n'), bs[len(bs)-1])
+
+	var obj map[string]any
+	require.NoError(t, json.Unmarshal(bs, &obj))
+	assert.Equal(t, map[string]any{
+		"time":         "2024-05-17T13:14:15Z",
+		"level":        "warning",
+		"msg":          "connection <lost>",
+		"goroutine":    "daemon/session",
+		"session_id":   "4711",
+		"intercept_id": "4711:echo",
+		"count":        float64(3),
+		"err":          "broken pipe",
+	}, obj)
+}
+
+func TestValidFormat(t *testing.T) {
+	assert.NoError(t, ValidFormat(""))
+	assert.NoError(t, ValidFormat(FormatText))
+	assert.NoError(t, ValidFormat(FormatJSON))
+	assert.Error(t, ValidFormat("xml"))
+}
@@ -27,6 +27,10 @@ type TimedLevel interface {
 
 	// Reset restores the log-level to its default value
 	Reset(ctx context.Context)
+
+	// SetDefault changes the default log-level. The new level is activated unless
+	// a temporary log-level is in effect.
+	SetDefault(ctx context.Context, level string)
 }
 
 type timedLevel struct {
@@ -99,3 +103,13 @@ func (tl *timedLevel) Reset(ctx context.Context) {
 	tl.tempLevel = ""
 	tl.setter(ctx, tl.defaultLevel)
 }
+
+// SetDefault changes the default log-level and activates it unless a temporary log-level is in effect.
+func (tl *timedLevel) SetDefault(ctx context.Context, level string) {
+	tl.Lock()
+	defer tl.Unlock()
+	tl.defaultLevel = level
+	if tl.tempLevel == "" {
+		tl.setter(ctx, level)
+	}
+}
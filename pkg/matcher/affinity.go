@@ -0,0 +1,109 @@
+package matcher
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// affinityTTL is how long a session key is remembered after the last request that carried it.
+	affinityTTL = time.Hour
+
+	// maxAffinityKeys is the maximum number of session keys that an Affinity remembers.
+	maxAffinityKeys = 10000
+)
+
+// Affinity remembers the session keys of requests that matched the conditions of an intercept, so that
+// later requests with the same session key can be considered as matching too, even when they lack the
+// headers that the conditions require. The session key is the value of a cookie or a header.
+type Affinity struct {
+	sync.Mutex
+	cookie bool
+	name   string
+	keys   map[string]time.Time
+	now    func() time.Time
+}
+
+// NewAffinity returns an Affinity that uses the session key described by the given string, which is on
+// the form cookie:<name> or header:<name>. A nil Affinity is returned when the string is empty.
+func NewAffinity(s string) (*Affinity, error) {
+	if s == "" {
+		return nil, nil
+	}
+	kind, name, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || (kind != "cookie" && kind != "header") {
+		return nil, fmt.Errorf("session affinity %q must be on the form cookie:<name> or header:<name>", s)
+	}
+	return &Affinity{cookie: kind == "cookie", name: name, keys: make(map[string]time.Time), now: time.Now}, nil
+}
+
+// key returns the session key of the given header, or an empty string when it has none.
+func (a *Affinity) key(h http.Header) string {
+	if !a.cookie {
+		return h.Get(a.name)
+	}
+	c, err := (&http.Request{Header: h}).Cookie(a.name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// Stick returns true if the given matched is true or if the session key of the given header has been
+// remembered. The session key is remembered, or its expiry extended, when true is returned.
+func (a *Affinity) Stick(matched bool, h http.Header) bool {
+	if a == nil {
+		return matched
+	}
+	k := a.key(h)
+	if k == "" {
+		return matched
+	}
+	a.Lock()
+	defer a.Unlock()
+	now := a.now()
+	if !matched {
+		exp, ok := a.keys[k]
+		if !ok || now.After(exp) {
+			delete(a.keys, k)
+			return false
+		}
+	}
+	if _, ok := a.keys[k]; !ok && len(a.keys) >= maxAffinityKeys {
+		a.prune(now)
+	}
+	a.keys[k] = now.Add(affinityTTL)
+	return true
+}
+
+// prune removes all expired keys, and if that isn't enough to make room for another key, the key that
+// expires first.
+func (a *Affinity) prune(now time.Time) {
+	var first string
+	var firstExp time.Time
+	for k, exp := range a.keys {
+		if now.After(exp) {
+			delete(a.keys, k)
+		} else if first == "" || exp.Before(firstExp) {
+			first, firstExp = k, exp
+		}
+	}
+	if len(a.keys) >= maxAffinityKeys {
+		delete(a.keys, first)
+	}
+}
+
+func (a *Affinity) String() string {
+	if a == nil {
+		return "no session affinity"
+	}
+	kind := "header"
+	if a.cookie {
+		kind = "cookie"
+	}
+	return fmt.Sprintf("session affinity on %s %s", kind, a.name)
+}
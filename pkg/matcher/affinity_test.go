@@ -0,0 +1,53 @@
+package matcher
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffinity_Stick(t *testing.T) {
+	now := time.Now()
+	withCookie := func(v string) http.Header {
+		h := http.Header{}
+		h.Set("Cookie", "other=x; session="+v)
+		return h
+	}
+
+	a, err := NewAffinity("cookie:session")
+	require.NoError(t, err)
+	a.now = func() time.Time { return now }
+
+	assert.False(t, a.Stick(false, withCookie("s1")), "not yet remembered")
+	assert.True(t, a.Stick(true, withCookie("s1")), "matched")
+	assert.True(t, a.Stick(false, withCookie("s1")), "remembered")
+	assert.False(t, a.Stick(false, withCookie("s2")), "other session")
+	assert.False(t, a.Stick(false, http.Header{}), "no session key")
+
+	now = now.Add(affinityTTL + time.Second)
+	assert.False(t, a.Stick(false, withCookie("s1")), "expired")
+
+	a, err = NewAffinity("header:x-session-id")
+	require.NoError(t, err)
+	h := http.Header{}
+	h.Set("X-Session-Id", "abc")
+	assert.True(t, a.Stick(true, h))
+	assert.True(t, a.Stick(false, h))
+
+	var none *Affinity
+	assert.True(t, none.Stick(true, h))
+	assert.False(t, none.Stick(false, h))
+}
+
+func TestNewAffinity_error(t *testing.T) {
+	for _, s := range []string{"cookie", "cookie:", "query:session", ":session"} {
+		_, err := NewAffinity(s)
+		assert.Error(t, err, s)
+	}
+	a, err := NewAffinity("")
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
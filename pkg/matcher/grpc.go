@@ -0,0 +1,103 @@
+package matcher
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GRPC matches gRPC calls on their full method names and metadata. A gRPC call is an HTTP/2 request
+// with the path /<package>.<service>/<method>, and its metadata are sent as headers of that request.
+type GRPC struct {
+	methods  []Value
+	metadata HeaderExpr
+}
+
+// NewGRPCMethod returns a Value that matches the path of the gRPC calls of the given method. The
+// method is on the form <package>.<service>/<method>, where the method can be "*" or omitted to match
+// all methods of the service.
+func NewGRPCMethod(m string) (Value, error) {
+	m = strings.Trim(strings.TrimSpace(m), "/")
+	svc, method, _ := strings.Cut(m, "/")
+	if svc == "" || strings.ContainsAny(svc, "*") || strings.Contains(method, "/") {
+		return nil, fmt.Errorf("gRPC method %q must be on the form <package>.<service>[/<method>]", m)
+	}
+	switch method {
+	case "", "*":
+		return NewPrefix("/" + svc + "/"), nil
+	default:
+		if strings.Contains(method, "*") {
+			return nil, fmt.Errorf("gRPC method %q can only use * as the whole method name", m)
+		}
+		return NewEqual("/" + svc + "/" + method), nil
+	}
+}
+
+// NewGRPC returns a GRPC that matches calls of any of the given methods, as parsed by NewGRPCMethod,
+// with metadata that match the given clauses, as parsed by ParseHeaderExpr. A nil GRPC is returned when
+// both methods and metadata are empty.
+func NewGRPC(methods, metadata []string) (*GRPC, error) {
+	if len(methods) == 0 && len(metadata) == 0 {
+		return nil, nil
+	}
+	g := &GRPC{methods: make([]Value, len(methods))}
+	for i, m := range methods {
+		v, err := NewGRPCMethod(m)
+		if err != nil {
+			return nil, err
+		}
+		g.methods[i] = v
+	}
+	var err error
+	if g.metadata, err = ParseHeaderExpr(metadata); err != nil {
+		return nil, fmt.Errorf("invalid gRPC metadata condition: %w", err)
+	}
+	return g, nil
+}
+
+// Matches returns true if the given path is the path of one of the methods of this instance, and if the
+// given headers match its metadata conditions. A nil GRPC matches everything.
+func (g *GRPC) Matches(path string, h http.Header) bool {
+	if g == nil {
+		return true
+	}
+	if len(g.methods) > 0 {
+		found := false
+		for _, m := range g.methods {
+			if m.Matches(path) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return g.metadata.Matches(h)
+}
+
+func (g *GRPC) String() string {
+	if g == nil {
+		return "all gRPC calls"
+	}
+	sb := strings.Builder{}
+	sb.WriteString("gRPC calls")
+	for i, m := range g.methods {
+		if i == 0 {
+			sb.WriteString(" to ")
+		} else {
+			sb.WriteString(" or ")
+		}
+		if m.Op() == "prefix" {
+			sb.WriteString(strings.Trim(m.String(), "/"))
+			sb.WriteString("/*")
+		} else {
+			sb.WriteString(strings.TrimPrefix(m.String(), "/"))
+		}
+	}
+	if len(g.metadata) > 0 {
+		sb.WriteString(" with metadata ")
+		sb.WriteString(g.metadata.String())
+	}
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package matcher
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGRPC_Matches(t *testing.T) {
+	md := func(kvs ...string) http.Header {
+		h := make(http.Header, len(kvs)/2)
+		for i := 0; i < len(kvs); i += 2 {
+			h.Set(kvs[i], kvs[i+1])
+		}
+		return h
+	}
+
+	tests := []struct {
+		name     string
+		methods  []string
+		metadata []string
+		path     string
+		header   http.Header
+		want     bool
+	}{
+		{"nil", nil, nil, "/orders.OrderService/Create", md(), true},
+		{"method", []string{"orders.OrderService/Create"}, nil, "/orders.OrderService/Create", md(), true},
+		{"other method", []string{"orders.OrderService/Create"}, nil, "/orders.OrderService/Delete", md(), false},
+		{"all methods", []string{"orders.OrderService/*"}, nil, "/orders.OrderService/Delete", md(), true},
+		{"service", []string{"orders.OrderService"}, nil, "/orders.OrderService/Delete", md(), true},
+		{"other service", []string{"orders.OrderService"}, nil, "/orders.OrderServiceV2/Delete", md(), false},
+		{"any of methods", []string{"orders.OrderService/Create", "orders.OrderService/Get"}, nil, "/orders.OrderService/Get", md(), true},
+		{"metadata", nil, []string{"x-tenant=acme"}, "/orders.OrderService/Get", md("x-tenant", "acme"), true},
+		{"metadata mismatch", []string{"orders.OrderService/Get"}, []string{"x-tenant=acme"}, "/orders.OrderService/Get", md("x-tenant", "other"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGRPC(tt.methods, tt.metadata)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, g.Matches(tt.path, tt.header))
+		})
+	}
+}
+
+func TestNewGRPC_error(t *testing.T) {
+	for _, m := range []string{"", "/", "*/Create", "orders.OrderService/Cr*", "orders.OrderService/Create/x"} {
+		_, err := NewGRPC([]string{m}, nil)
+		assert.Error(t, err, m)
+	}
+	_, err := NewGRPC(nil, []string{"x-tenant"})
+	assert.Error(t, err)
+}
+
+func TestGRPC_String(t *testing.T) {
+	g, err := NewGRPC([]string{"orders.OrderService/Create", "/orders.Admin"}, []string{"x-tenant=acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "gRPC calls to orders.OrderService/Create or orders.Admin/* with metadata X-Tenant == acme", g.String())
+}
@@ -0,0 +1,144 @@
+package matcher
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// HeaderCond is a condition that matches the value of one header against a Value matcher.
+type HeaderCond struct {
+	Name  string
+	Value Value
+}
+
+// headerOps are the operators recognized by ParseHeaderCond, ordered so that no operator is
+// preceded by an operator that is a prefix of it.
+var headerOps = []struct { //nolint:gochecknoglobals // constant
+	op  string
+	val func(string) (Value, error)
+}{
+	{"!^=", func(v string) (Value, error) { return NewNot(NewPrefix(v)), nil }},
+	{"!$=", func(v string) (Value, error) { return NewNot(NewSuffix(v)), nil }},
+	{"!=", func(v string) (Value, error) { return NewNot(NewEqual(v)), nil }},
+	{"!~", func(v string) (Value, error) {
+		rx, err := NewRegex(v)
+		if err != nil {
+			return nil, err
+		}
+		return NewNot(rx), nil
+	}},
+	{"^=", func(v string) (Value, error) { return NewPrefix(v), nil }},
+	{"$=", func(v string) (Value, error) { return NewSuffix(v), nil }},
+	{"=~", NewRegex},
+	{"=", func(v string) (Value, error) { return NewEqual(v), nil }},
+}
+
+// ParseHeaderCond parses a condition on the form <name><op><value> where <op> is one of:
+//
+//	=   the value is equal to <value>
+//	=~  the value matches the regular expression <value>
+//	^=  the value starts with <value>
+//	$=  the value ends with <value>
+//
+// and where != , !~, !^=, and !$= negate the corresponding operator. A missing header has the
+// value "". The name ends at the first character that starts an operator.
+func ParseHeaderCond(s string) (*HeaderCond, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, "!^$=")
+	if i <= 0 {
+		return nil, fmt.Errorf("header condition %q must be on the form <name><op><value>", s)
+	}
+	name, rest := strings.TrimSpace(s[:i]), s[i:]
+	for _, ho := range headerOps {
+		if v, ok := strings.CutPrefix(rest, ho.op); ok {
+			vm, err := ho.val(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("the value of header condition %q is invalid: %w", s, err)
+			}
+			return &HeaderCond{Name: textproto.CanonicalMIMEHeaderKey(name), Value: vm}, nil
+		}
+	}
+	return nil, fmt.Errorf("header condition %q has an invalid operator", s)
+}
+
+// Matches returns true if the value of the named header in the given http.Header matches the condition.
+func (c *HeaderCond) Matches(h http.Header) bool {
+	return c.Value.Matches(h.Get(c.Name))
+}
+
+func (c *HeaderCond) String() string {
+	return fmt.Sprintf("%s %s %s", c.Name, c.Value.Op(), c.Value)
+}
+
+// HeaderExpr is a conjunction of clauses, where each clause is a disjunction of conditions, i.e. the
+// expression matches when at least one condition of each clause matches. An empty HeaderExpr matches
+// all headers.
+type HeaderExpr [][]*HeaderCond
+
+// ParseHeaderExpr parses each of the given clauses into a disjunction of conditions. The conditions of
+// a clause are separated by "||", and each condition is parsed using ParseHeaderCond.
+func ParseHeaderExpr(clauses []string) (HeaderExpr, error) {
+	if len(clauses) == 0 {
+		return nil, nil
+	}
+	expr := make(HeaderExpr, len(clauses))
+	for i, clause := range clauses {
+		cs := strings.Split(clause, "||")
+		conds := make([]*HeaderCond, len(cs))
+		for ci, c := range cs {
+			hc, err := ParseHeaderCond(c)
+			if err != nil {
+				return nil, err
+			}
+			conds[ci] = hc
+		}
+		expr[i] = conds
+	}
+	return expr, nil
+}
+
+// Matches returns true if each clause of this expression has a condition that is matched by the given
+// http.Header.
+func (e HeaderExpr) Matches(h http.Header) bool {
+	for _, clause := range e {
+		matched := false
+		for _, c := range clause {
+			if c.Matches(h) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (e HeaderExpr) String() string {
+	if len(e) == 0 {
+		return "all headers"
+	}
+	sb := strings.Builder{}
+	for i, clause := range e {
+		if i > 0 {
+			sb.WriteString(" && ")
+		}
+		parens := len(clause) > 1 && len(e) > 1
+		if parens {
+			sb.WriteByte('(')
+		}
+		for ci, c := range clause {
+			if ci > 0 {
+				sb.WriteString(" || ")
+			}
+			sb.WriteString(c.String())
+		}
+		if parens {
+			sb.WriteByte(')')
+		}
+	}
+	return sb.String()
+}
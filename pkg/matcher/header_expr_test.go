@@ -0,0 +1,67 @@
+package matcher
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaderExpr(t *testing.T) {
+	header := func(kvs ...string) http.Header {
+		h := make(http.Header, len(kvs)/2)
+		for i := 0; i < len(kvs); i += 2 {
+			h.Set(kvs[i], kvs[i+1])
+		}
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		clauses []string
+		header  http.Header
+		want    bool
+	}{
+		{"empty", nil, header("x-a", "b"), true},
+		{"equal", []string{"x-user=alice"}, header("X-User", "alice"), true},
+		{"equal mismatch", []string{"x-user=alice"}, header("X-User", "bob"), false},
+		{"not equal", []string{"x-user!=alice"}, header("X-User", "bob"), true},
+		{"not equal missing", []string{"x-user!=alice"}, header(), true},
+		{"regex", []string{"x-user=~^a.*e$"}, header("X-User", "alice"), true},
+		{"regex mismatch", []string{"x-user=~^b"}, header("X-User", "alice"), false},
+		{"not regex", []string{"x-user!~^b"}, header("X-User", "alice"), true},
+		{"prefix", []string{"x-user^=al"}, header("X-User", "alice"), true},
+		{"not prefix", []string{"x-user!^=al"}, header("X-User", "alice"), false},
+		{"suffix", []string{"x-user$=ce"}, header("X-User", "alice"), true},
+		{"not suffix", []string{"x-user!$=ce"}, header("X-User", "alice"), false},
+		{"and", []string{"x-user=alice", "x-env=dev"}, header("X-User", "alice", "X-Env", "dev"), true},
+		{"and mismatch", []string{"x-user=alice", "x-env=dev"}, header("X-User", "alice", "X-Env", "prod"), false},
+		{"or", []string{"x-user=bob || x-user=alice"}, header("X-User", "alice"), true},
+		{"or mismatch", []string{"x-user=bob||x-user=carol"}, header("X-User", "alice"), false},
+		{"and of or", []string{"x-user=bob || x-user=alice", "x-env!=prod"}, header("X-User", "alice", "X-Env", "dev"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := ParseHeaderExpr(tt.clauses)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, e.Matches(tt.header))
+		})
+	}
+}
+
+func TestParseHeaderExpr_error(t *testing.T) {
+	for _, c := range []string{"", "x-user", "=alice", "x-user!alice", "x-user=~(", "x-user!~("} {
+		_, err := ParseHeaderExpr([]string{c})
+		assert.Error(t, err, c)
+	}
+}
+
+func TestHeaderExpr_String(t *testing.T) {
+	e, err := ParseHeaderExpr([]string{"x-user=bob || x-user=~^a", "x-env!$=prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "(X-User == bob || X-User =~ ^a) && X-Env !suffix prod", e.String())
+	e, err = ParseHeaderExpr(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "all headers", e.String())
+}
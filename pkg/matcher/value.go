@@ -6,15 +6,16 @@ import (
 	"strings"
 )
 
-// Value comes in three flavors. One that performs an exact match against a string, one that
-// uses a regular expression, and one that uses prefix matching.
+// Value comes in four flavors. One that performs an exact match against a string, one that
+// uses a regular expression, one that uses prefix matching, and one that uses suffix matching.
+// Each flavor can be negated using NewNot.
 type Value interface {
 	fmt.Stringer
 
 	// Matches returns true if the given string matches this Value
 	Matches(value string) bool
 
-	// Op returns either ==, =~, or prefix
+	// Op returns either ==, =~, prefix, or suffix, or one of !=, !~, !prefix, or !suffix when negated.
 	Op() string
 }
 
@@ -58,6 +59,39 @@ func (p prefixValue) Op() string {
 	return "prefix"
 }
 
+type suffixValue string
+
+func (p suffixValue) Matches(value string) bool {
+	return strings.HasSuffix(value, string(p))
+}
+
+func (p suffixValue) String() string {
+	return string(p)
+}
+
+func (p suffixValue) Op() string {
+	return "suffix"
+}
+
+type notValue struct {
+	Value
+}
+
+func (n notValue) Matches(value string) bool {
+	return !n.Value.Matches(value)
+}
+
+func (n notValue) Op() string {
+	switch op := n.Value.Op(); op {
+	case "==":
+		return "!="
+	case "=~":
+		return "!~"
+	default:
+		return "!" + op
+	}
+}
+
 // NewValue returns a Value that is either an exact or a regexp matcher. The latter is chosen
 // when the given string contains regexp meta characters. An error is returned if the string contains
 // meta characters but cannot be compiled into a regexp.
@@ -87,3 +121,16 @@ func NewPrefix(v string) Value {
 func NewEqual(v string) Value {
 	return textValue(v)
 }
+
+// NewSuffix returns a Value that is a suffix matcher.
+func NewSuffix(v string) Value {
+	return suffixValue(v)
+}
+
+// NewNot returns a Value that matches when the given Value doesn't.
+func NewNot(v Value) Value {
+	if n, ok := v.(notValue); ok {
+		return n.Value
+	}
+	return notValue{v}
+}
@@ -0,0 +1,166 @@
+package openshift
+
+import (
+	"context"
+	"slices"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// DeploymentConfigs is the name of the DeploymentConfig resource.
+const DeploymentConfigs = "deploymentconfigs"
+
+var (
+	scheme = newScheme()                                            //nolint:gochecknoglobals // constant
+	codecs = serializer.NewCodecFactory(scheme).WithoutConversion() //nolint:gochecknoglobals // constant
+)
+
+func newScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	s.AddKnownTypes(SchemeGroupVersion, &DeploymentConfig{}, &DeploymentConfigList{}, &DeploymentRequest{})
+	meta.AddToGroupVersion(s, SchemeGroupVersion)
+	return s
+}
+
+// NewRESTClient returns a REST client for the OpenShift apps API, or nil if the cluster doesn't serve
+// DeploymentConfigs. The k8sapi.Interface of the given context is used for the discovery.
+func NewRESTClient(ctx context.Context, cfg *rest.Config) (rest.Interface, error) {
+	rl, err := k8sapi.GetK8sInterface(ctx).Discovery().ServerResourcesForGroupVersion(SchemeGroupVersion.String())
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !slices.ContainsFunc(rl.APIResources, func(r meta.APIResource) bool { return r.Name == DeploymentConfigs }) {
+		return nil, nil
+	}
+	cfg = rest.CopyConfig(cfg)
+	cfg.GroupVersion = &SchemeGroupVersion
+	cfg.APIPath = "/apis"
+	cfg.NegotiatedSerializer = codecs
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(cfg)
+}
+
+type restClientKey struct{}
+
+// WithRESTClient returns a context that holds the given REST client for the OpenShift apps API.
+func WithRESTClient(ctx context.Context, rc rest.Interface) context.Context {
+	return context.WithValue(ctx, restClientKey{}, rc)
+}
+
+// GetRESTClient returns the REST client for the OpenShift apps API, or nil if the context has no such client,
+// which means that DeploymentConfigs aren't supported.
+func GetRESTClient(ctx context.Context) rest.Interface {
+	if rc, ok := ctx.Value(restClientKey{}).(rest.Interface); ok {
+		return rc
+	}
+	return nil
+}
+
+type informerKey string
+
+// WithInformer returns a context that holds a DeploymentConfig informer for the given namespace, provided
+// that the context has a REST client for the OpenShift apps API. The informer must be started by the caller.
+func WithInformer(ctx context.Context, ns string) context.Context {
+	rc := GetRESTClient(ctx)
+	if rc == nil {
+		return ctx
+	}
+	lw := cache.NewListWatchFromClient(rc, DeploymentConfigs, ns, fields.Everything())
+	ix := cache.NewSharedIndexInformer(lw, &DeploymentConfig{}, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	return context.WithValue(ctx, informerKey(ns), ix)
+}
+
+// GetInformer returns the DeploymentConfig informer for the given namespace, or the cluster-wide informer
+// if no informer exists for the namespace. The function returns nil when no informer is found.
+func GetInformer(ctx context.Context, ns string) cache.SharedIndexInformer {
+	if ix, ok := ctx.Value(informerKey(ns)).(cache.SharedIndexInformer); ok {
+		return ix
+	}
+	if ns != "" {
+		if ix, ok := ctx.Value(informerKey("")).(cache.SharedIndexInformer); ok {
+			return ix
+		}
+	}
+	return nil
+}
+
+// Get returns the DeploymentConfig with the given name and namespace.
+func Get(ctx context.Context, rc rest.Interface, name, namespace string) (*DeploymentConfig, error) {
+	dc := new(DeploymentConfig)
+	err := rc.Get().
+		Namespace(namespace).
+		Resource(DeploymentConfigs).
+		Name(name).
+		Do(ctx).
+		Into(dc)
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// Patch applies the given patch to the DeploymentConfig with the given name and namespace.
+func Patch(
+	ctx context.Context, rc rest.Interface, name, namespace string, pt types.PatchType, data []byte, subresources ...string,
+) (*DeploymentConfig, error) {
+	dc := new(DeploymentConfig)
+	err := rc.Patch(pt).
+		Namespace(namespace).
+		Resource(DeploymentConfigs).
+		Name(name).
+		SubResource(subresources...).
+		Body(data).
+		Do(ctx).
+		Into(dc)
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// Delete deletes the DeploymentConfig with the given name and namespace.
+func Delete(ctx context.Context, rc rest.Interface, name, namespace string) error {
+	return rc.Delete().
+		Namespace(namespace).
+		Resource(DeploymentConfigs).
+		Name(name).
+		Do(ctx).
+		Error()
+}
+
+// Instantiate starts a new deployment of the DeploymentConfig with the given name and namespace, even
+// if its pod template is unchanged. This is the API equivalent of "oc rollout latest".
+func Instantiate(ctx context.Context, rc rest.Interface, name, namespace string) (*DeploymentConfig, error) {
+	dc := new(DeploymentConfig)
+	err := rc.Post().
+		Namespace(namespace).
+		Resource(DeploymentConfigs).
+		Name(name).
+		SubResource("instantiate").
+		Body(&DeploymentRequest{
+			TypeMeta: meta.TypeMeta{APIVersion: SchemeGroupVersion.String(), Kind: "DeploymentRequest"},
+			Name:     name,
+			Latest:   true,
+			Force:    true,
+		}).
+		Do(ctx).
+		Into(dc)
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
@@ -0,0 +1,106 @@
+package openshift
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+const testDeploymentConfig = `{
+  "apiVersion": "apps.openshift.io/v1",
+  "kind": "DeploymentConfig",
+  "metadata": {"name": "echo", "namespace": "default", "generation": 3},
+  "spec": {
+    "replicas": 2,
+    "selector": {"app": "echo"},
+    "strategy": {"type": "Rolling"},
+    "template": {
+      "metadata": {"labels": {"app": "echo"}},
+      "spec": {"containers": [{"name": "echo", "image": "jmalloc/echo-server"}]}
+    }
+  },
+  "status": {"latestVersion": 4, "observedGeneration": 3, "replicas": 2, "updatedReplicas": 2, "availableReplicas": 2}
+}`
+
+func testContext(t *testing.T, served bool) context.Context {
+	cs := fake.NewSimpleClientset()
+	if served {
+		cs.Discovery().(*fakeDiscovery.FakeDiscovery).Resources = []*meta.APIResourceList{{
+			GroupVersion: SchemeGroupVersion.String(),
+			APIResources: []meta.APIResource{{Name: DeploymentConfigs, Namespaced: true, Kind: "DeploymentConfig"}},
+		}}
+	}
+	return k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+}
+
+func TestNewRESTClient_NotServed(t *testing.T) {
+	rc, err := NewRESTClient(testContext(t, false), &rest.Config{Host: "http://localhost"})
+	require.NoError(t, err)
+	assert.Nil(t, rc)
+}
+
+func TestRESTClient(t *testing.T) {
+	var instantiated DeploymentRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/apps.openshift.io/v1/namespaces/default/deploymentconfigs/echo":
+		case r.Method == http.MethodPost && r.URL.Path == "/apis/apps.openshift.io/v1/namespaces/default/deploymentconfigs/echo/instantiate":
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &instantiated)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, testDeploymentConfig)
+	}))
+	defer srv.Close()
+
+	ctx := testContext(t, true)
+	rc, err := NewRESTClient(ctx, &rest.Config{Host: srv.URL})
+	require.NoError(t, err)
+	require.NotNil(t, rc)
+
+	dc, err := Get(ctx, rc, "echo", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", dc.Name)
+	assert.Equal(t, int32(2), dc.Spec.Replicas)
+	assert.Equal(t, map[string]string{"app": "echo"}, dc.Spec.Selector)
+	require.NotNil(t, dc.Spec.Template)
+	assert.Equal(t, "echo", dc.Spec.Template.Spec.Containers[0].Name)
+	assert.Equal(t, int64(4), dc.Status.LatestVersion)
+
+	_, err = Instantiate(ctx, rc, "echo", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "DeploymentRequest", instantiated.Kind)
+	assert.Equal(t, "echo", instantiated.Name)
+	assert.True(t, instantiated.Latest)
+	assert.True(t, instantiated.Force)
+
+	_, err = Get(ctx, rc, "other", "default")
+	assert.Error(t, err)
+}
+
+func TestDeploymentConfig_DeepCopy(t *testing.T) {
+	var dc DeploymentConfig
+	require.NoError(t, json.Unmarshal([]byte(testDeploymentConfig), &dc))
+	cp := dc.DeepCopy()
+	assert.Equal(t, &dc, cp)
+	cp.Spec.Selector["app"] = "other"
+	cp.Spec.Template.Labels["app"] = "other"
+	assert.Equal(t, "echo", dc.Spec.Selector["app"])
+	assert.Equal(t, "echo", dc.Spec.Template.Labels["app"])
+}
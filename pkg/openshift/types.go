@@ -0,0 +1,153 @@
+// Package openshift contains the subset of the OpenShift apps.openshift.io/v1 API that Telepresence needs
+// in order to intercept DeploymentConfig workloads, together with a REST client for that API.
+package openshift
+
+import (
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SchemeGroupVersion is the group version of the OpenShift apps API.
+var SchemeGroupVersion = schema.GroupVersion{Group: "apps.openshift.io", Version: "v1"} //nolint:gochecknoglobals // constant
+
+// Resource returns the GroupResource of the given resource in the OpenShift apps API.
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
+// DeploymentConfig is an OpenShift workload that is similar to a Deployment. Only the fields that are
+// of interest to Telepresence are declared, so a DeploymentConfig must never be used in an update of
+// the whole resource.
+type DeploymentConfig struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeploymentConfigSpec   `json:"spec"`
+	Status DeploymentConfigStatus `json:"status,omitempty"`
+}
+
+// DeploymentConfigSpec is the desired state of a DeploymentConfig.
+type DeploymentConfigSpec struct {
+	Replicas int32                 `json:"replicas"`
+	Paused   bool                  `json:"paused,omitempty"`
+	Selector map[string]string     `json:"selector,omitempty"`
+	Template *core.PodTemplateSpec `json:"template,omitempty"`
+}
+
+// DeploymentConfigStatus is the observed state of a DeploymentConfig.
+type DeploymentConfigStatus struct {
+	LatestVersion       int64                       `json:"latestVersion"`
+	ObservedGeneration  int64                       `json:"observedGeneration"`
+	Replicas            int32                       `json:"replicas"`
+	UpdatedReplicas     int32                       `json:"updatedReplicas"`
+	AvailableReplicas   int32                       `json:"availableReplicas"`
+	UnavailableReplicas int32                       `json:"unavailableReplicas"`
+	ReadyReplicas       int32                       `json:"readyReplicas,omitempty"`
+	Conditions          []DeploymentConfigCondition `json:"conditions,omitempty"`
+}
+
+// DeploymentConditionType is the type of DeploymentConfigCondition.
+type DeploymentConditionType string
+
+const (
+	// DeploymentAvailable means that the DeploymentConfig has its minimum number of replicas available.
+	DeploymentAvailable DeploymentConditionType = "Available"
+
+	// DeploymentProgressing means that the DeploymentConfig is progressing.
+	DeploymentProgressing DeploymentConditionType = "Progressing"
+
+	// DeploymentReplicaFailure is added when one of the pods of the DeploymentConfig fails to be created or deleted.
+	DeploymentReplicaFailure DeploymentConditionType = "ReplicaFailure"
+)
+
+// DeploymentConfigCondition describes the state of a DeploymentConfig at a certain point.
+type DeploymentConfigCondition struct {
+	Type               DeploymentConditionType `json:"type"`
+	Status             core.ConditionStatus    `json:"status"`
+	LastUpdateTime     meta.Time               `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime meta.Time               `json:"lastTransitionTime,omitempty"`
+	Reason             string                  `json:"reason,omitempty"`
+	Message            string                  `json:"message,omitempty"`
+}
+
+// DeploymentConfigList is a list of DeploymentConfigs.
+type DeploymentConfigList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []DeploymentConfig `json:"items"`
+}
+
+// DeploymentRequest is a request to a DeploymentConfig for a new deployment. It is posted to the
+// "instantiate" subresource of the DeploymentConfig.
+type DeploymentRequest struct {
+	meta.TypeMeta `json:",inline"`
+
+	// Name of the DeploymentConfig.
+	Name string `json:"name"`
+
+	// Latest will update the DeploymentConfig with the latest state from all triggers.
+	Latest bool `json:"latest"`
+
+	// Force will try to force a new deployment to run. If the DeploymentConfig is paused,
+	// then setting this to true will return an Invalid error.
+	Force bool `json:"force"`
+}
+
+func (in *DeploymentConfig) DeepCopyInto(out *DeploymentConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Spec.Selector != nil {
+		out.Spec.Selector = make(map[string]string, len(in.Spec.Selector))
+		for k, v := range in.Spec.Selector {
+			out.Spec.Selector[k] = v
+		}
+	}
+	if in.Spec.Template != nil {
+		out.Spec.Template = in.Spec.Template.DeepCopy()
+	}
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]DeploymentConfigCondition, len(in.Status.Conditions))
+		copy(out.Status.Conditions, in.Status.Conditions)
+	}
+}
+
+func (in *DeploymentConfig) DeepCopy() *DeploymentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *DeploymentConfig) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *DeploymentConfigList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentConfigList)
+	*out = *in
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]DeploymentConfig, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
+
+func (in *DeploymentRequest) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
@@ -2,14 +2,198 @@ package pprof
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	_ "net/http/pprof"
+	httppprof "net/http/pprof"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sync"
+	"time"
 
 	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
 )
 
-func PprofServer(ctx context.Context, port uint16) error {
-	sc := dhttp.ServerConfig{Handler: http.DefaultServeMux}
-	return sc.ListenAndServe(ctx, fmt.Sprintf("localhost:%d", port))
+// Handler returns a handler that serves the pprof profiles under /debug/pprof/, a dump of the stacks of
+// all goroutines at /debug/goroutines, and the runtime metrics in JSON format at /debug/metrics.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", httppprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+	mux.HandleFunc("/debug/goroutines", serveGoroutines)
+	mux.HandleFunc("/debug/metrics", serveMetrics)
+	return mux
+}
+
+func serveGoroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// RuntimeMetrics returns the current values of the scalar runtime metrics, keyed by metric name. The
+// histograms are represented by their sample count.
+func RuntimeMetrics() map[string]any {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i := range descs {
+		samples[i].Name = descs[i].Name
+	}
+	metrics.Read(samples)
+	result := make(map[string]any, len(samples))
+	for _, sample := range samples {
+		v := sample.Value
+		switch v.Kind() {
+		case metrics.KindUint64:
+			result[sample.Name] = v.Uint64()
+		case metrics.KindFloat64:
+			result[sample.Name] = v.Float64()
+		case metrics.KindFloat64Histogram:
+			var count uint64
+			for _, c := range v.Float64Histogram().Counts {
+				count += c
+			}
+			result[sample.Name] = count
+		}
+	}
+	return result
+}
+
+func serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(RuntimeMetrics())
+}
+
+// Server is a debug HTTP listener that serves the Handler on localhost. It can be started on demand while
+// Run is active, and it stops when the duration that it was started for has elapsed.
+type Server struct {
+	mu   sync.Mutex
+	ctx  context.Context
+	addr string
+
+	// listenerCtx is the context of the running listener, and cancel cancels it. Both are nil when no
+	// listener is running.
+	listenerCtx context.Context
+	cancel      context.CancelFunc
+	timer       *time.Timer
+	timerID     uint64
+
+	// persistent is true when the server was started by Run, in which case it ignores durations.
+	persistent bool
+
+	// listeners tracks the running listeners, so that Run can wait for them to stop.
+	listeners sync.WaitGroup
+}
+
+// Run makes it possible to Start the server until the given context is cancelled. The server is started
+// right away, on the given port, and kept running when start is true. Run returns when the server has stopped.
+func (s *Server) Run(ctx context.Context, start bool, port uint16) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.persistent = start
+	s.mu.Unlock()
+	if start {
+		if _, err := s.Start(port, 0); err != nil {
+			dlog.Error(ctx, err)
+		}
+	}
+	<-ctx.Done()
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+	s.listeners.Wait()
+	return nil
+}
+
+// Start starts the server on the given port, or on a random port when the port is zero, unless it's
+// already running, and returns its address. The server stops when the given duration has elapsed, or
+// keeps running when the duration is zero. A duration given to a running server replaces the old one.
+func (s *Server) Start(port uint16, duration time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return "", errors.New("debug HTTP listener is not available")
+	}
+	if s.listenerCtx == nil || s.listenerCtx.Err() != nil {
+		// A listener with a cancelled context is stopping, so a new one is started in its place.
+		s.clearLocked()
+		l, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+		if err != nil {
+			return "", fmt.Errorf("unable to start debug HTTP listener: %w", err)
+		}
+		ctx, cancel := context.WithCancel(s.ctx)
+		s.addr = l.Addr().String()
+		s.listenerCtx = ctx
+		s.cancel = cancel
+		dlog.Infof(ctx, "Debug HTTP listener started on http://%s/debug/pprof/", s.addr)
+		s.listeners.Add(1)
+		go func() {
+			defer s.listeners.Done()
+			sc := dhttp.ServerConfig{Handler: Handler()}
+			if err := sc.Serve(ctx, l); err != nil && ctx.Err() == nil {
+				dlog.Errorf(ctx, "debug HTTP listener failed: %v", err)
+			}
+			s.stopped(ctx, cancel)
+			dlog.Info(ctx, "Debug HTTP listener stopped")
+		}()
+	}
+	if s.persistent {
+		return s.addr, nil
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if duration > 0 {
+		s.timerID++
+		id := s.timerID
+		s.timer = time.AfterFunc(duration, func() { s.expired(id) })
+	}
+	return s.addr, nil
+}
+
+// Address returns the address of the running server, or an empty string if it isn't running.
+func (s *Server) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// expired is called when the timer with the given ID fires. The listener is cancelled unless the timer
+// has been replaced or stopped by a call to Start that happened at the same time.
+func (s *Server) expired(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil && s.timerID == id {
+		s.timer = nil
+		s.cancel()
+	}
+}
+
+// stopped is called when the listener with the given context has stopped. The state of the server is
+// cleared unless another listener has been started in its place.
+func (s *Server) stopped(ctx context.Context, cancel context.CancelFunc) {
+	cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listenerCtx == ctx {
+		s.clearLocked()
+	}
+}
+
+func (s *Server) clearLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.addr = ""
+	s.listenerCtx = nil
+	s.cancel = nil
 }
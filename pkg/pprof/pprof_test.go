@@ -0,0 +1,95 @@
+package pprof
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	s := &Server{}
+	_, err := s.Start(0, 0)
+	require.Error(t, err, "server must not start unless Run is active")
+
+	runDone := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx, false, 0)
+		close(runDone)
+	}()
+	require.Eventually(t, func() bool {
+		_, err = s.Start(0, 200*time.Millisecond)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	addr := s.Address()
+	require.NotEmpty(t, addr)
+
+	again, err := s.Start(0, 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, addr, again, "a running server is reused")
+
+	rsp, err := http.Get("http://" + addr + "/debug/metrics")
+	require.NoError(t, err)
+	var m map[string]any
+	err = json.NewDecoder(rsp.Body).Decode(&m)
+	_ = rsp.Body.Close()
+	require.NoError(t, err)
+	assert.Contains(t, m, "/sched/goroutines:goroutines")
+
+	rsp, err = http.Get("http://" + addr + "/debug/goroutines")
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	assert.Eventually(t, func() bool { return s.Address() == "" }, 5*time.Second, 10*time.Millisecond,
+		"server must stop when its duration has elapsed")
+
+	cancel()
+	<-runDone
+}
+
+func TestServer_startWhenExpired(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	s := &Server{}
+	runDone := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx, false, 0)
+		close(runDone)
+	}()
+	require.Eventually(t, func() bool {
+		_, err := s.Start(0, time.Millisecond)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Start right after the duration has expired, which is before the expired listener has stopped or
+	// at the same time. The listener that Start returns must keep running regardless.
+	for i := 0; i < 20; i++ {
+		time.Sleep(time.Millisecond)
+		_, err := s.Start(0, time.Millisecond)
+		require.NoError(t, err)
+	}
+	addr, err := s.Start(0, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, addr)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, addr, s.Address(), "the listener that was started last must keep running")
+	rsp, err := http.Get("http://" + addr + "/debug/goroutines")
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	cancel()
+	<-runDone
+}
@@ -0,0 +1,213 @@
+// Package socks contains a minimal SOCKS5 server, as specified in RFC 1928. It supports the CONNECT
+// command with no authentication, which is enough to let browsers and command line tools reach the
+// cluster through a daemon that has no TUN device.
+package socks
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
+)
+
+const (
+	socksVersion = 5
+
+	methodNoAuth       = 0x00
+	methodNoAcceptable = 0xff
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+
+	repSucceeded          = 0x00
+	repGeneralFailure     = 0x01
+	repHostUnreachable    = 0x04
+	repConnectionRefused  = 0x05
+	repCmdNotSupported    = 0x07
+	repAddrTypeNotSupport = 0x08
+)
+
+// handshakeTimeout is the max time that a client may spend on the negotiation and the request.
+const handshakeTimeout = 10 * time.Second
+
+// DialFunc dials the given address. The host part of the address is either an IP or a name that the
+// function is expected to resolve.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Serve accepts connections on the given listener and serves them using the given dial function
+// until the context is cancelled. The listener is closed when Serve returns.
+func Serve(ctx context.Context, l net.Listener, dial DialFunc) error {
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(ctx, conn, dial)
+		}()
+	}
+}
+
+func serveConn(ctx context.Context, conn net.Conn, dial DialFunc) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	address, err := handshake(conn)
+	if err != nil {
+		dlog.Debugf(ctx, "SOCKS5 handshake with %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	target, err := dial(ctx, "tcp", address)
+	if err != nil {
+		dlog.Debugf(ctx, "SOCKS5 dial %s failed: %v", address, err)
+		_ = reply(conn, replyCode(err), nil)
+		return
+	}
+	defer target.Close()
+	if err = reply(conn, repSucceeded, target.LocalAddr()); err != nil {
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	dlog.Tracef(ctx, "SOCKS5 %s connected to %s", conn.RemoteAddr(), address)
+
+	dnet.Pipe(ctx, conn, target)
+}
+
+// handshake performs the method negotiation and reads the request. It returns the requested address
+// in host:port form.
+func handshake(conn net.Conn) (string, error) {
+	// +----+----------+----------+
+	// |VER | NMETHODS | METHODS  |
+	// +----+----------+----------+
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return "", err
+	}
+	if hdr[0] != socksVersion {
+		return "", fmt.Errorf("unsupported SOCKS version %d", hdr[0])
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	method := byte(methodNoAcceptable)
+	for _, m := range methods {
+		if m == methodNoAuth {
+			method = methodNoAuth
+			break
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return "", err
+	}
+	if method == methodNoAcceptable {
+		return "", errors.New("client doesn't accept unauthenticated access")
+	}
+
+	// +----+-----+-------+------+----------+----------+
+	// |VER | CMD |  RSV  | ATYP | DST.ADDR | DST.PORT |
+	// +----+-----+-------+------+----------+----------+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return "", err
+	}
+	if req[0] != socksVersion {
+		return "", fmt.Errorf("unsupported SOCKS version %d", req[0])
+	}
+	var host string
+	switch req[3] {
+	case atypIPv4, atypIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if req[3] == atypIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case atypDomain:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return "", err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		_ = reply(conn, repAddrTypeNotSupport, nil)
+		return "", fmt.Errorf("unsupported address type %d", req[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	if req[1] != cmdConnect {
+		_ = reply(conn, repCmdNotSupported, nil)
+		return "", fmt.Errorf("unsupported command %d", req[1])
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// reply writes a reply with the given code and bound address.
+func reply(conn net.Conn, rep byte, bound net.Addr) error {
+	// +----+-----+-------+------+----------+----------+
+	// |VER | REP |  RSV  | ATYP | BND.ADDR | BND.PORT |
+	// +----+-----+-------+------+----------+----------+
+	ip := net.IPv4zero.To4()
+	port := 0
+	if ta, ok := bound.(*net.TCPAddr); ok {
+		ip = ta.IP
+		port = ta.Port
+	}
+	msg := []byte{socksVersion, rep, 0}
+	if ip4 := ip.To4(); ip4 != nil {
+		msg = append(msg, atypIPv4)
+		msg = append(msg, ip4...)
+	} else {
+		msg = append(msg, atypIPv6)
+		msg = append(msg, ip.To16()...)
+	}
+	msg = binary.BigEndian.AppendUint16(msg, uint16(port))
+	_, err := conn.Write(msg)
+	return err
+}
+
+// replyCode translates a dial error into a SOCKS5 reply code.
+func replyCode(err error) byte {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return repHostUnreachable
+	case errors.Is(err, context.DeadlineExceeded):
+		return repHostUnreachable
+	default:
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return repConnectionRefused
+		}
+		return repGeneralFailure
+	}
+}
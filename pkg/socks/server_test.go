@@ -0,0 +1,74 @@
+package socks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer echo.Close()
+	go func() {
+		for {
+			c, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(c, c)
+				_ = c.Close()
+			}()
+		}
+	}()
+
+	// The dial function resolves the name "echo.svc" to the echo server and refuses everything else.
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if address == "echo.svc:80" {
+			return (&net.Dialer{}).DialContext(ctx, network, echo.Addr().String())
+		}
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("refused")}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, l, dial)
+	}()
+
+	d, err := proxy.SOCKS5("tcp", l.Addr().String(), nil, proxy.Direct)
+	require.NoError(t, err)
+
+	conn, err := d.Dial("tcp", "echo.svc:80")
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+	require.NoError(t, conn.Close())
+
+	_, err = d.Dial("tcp", "10.0.0.1:8080")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.Equal(t, []string{"echo.svc:80", "10.0.0.1:8080"}, dialed)
+
+	cancel()
+	require.NoError(t, <-done)
+}
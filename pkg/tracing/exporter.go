@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporter controls the export of spans to an OTLP collector using gRPC. Spans are exported in addition to
+// being collected for retrieval using DumpTraces.
+type Exporter struct {
+	// Endpoint is the address of the collector, either as host:port or as a URL. When it's a URL, the
+	// scheme "http" implies an insecure connection.
+	Endpoint string
+
+	// Insecure disables client transport security.
+	Insecure bool
+
+	// Headers are sent with each export request, typically to authenticate with the collector.
+	Headers map[string]string
+
+	// SamplingRatio is the ratio of the traces that are exported, between 0 and 1. Spans with a sampled
+	// remote parent are always exported. All traces are collected for retrieval using DumpTraces.
+	SamplingRatio float64
+}
+
+type exporterKey struct{}
+
+// WithExporter returns a context that makes NewTraceServer use the given Exporter.
+func WithExporter(ctx context.Context, exporter *Exporter) context.Context {
+	return context.WithValue(ctx, exporterKey{}, exporter)
+}
+
+func getExporter(ctx context.Context) *Exporter {
+	if e, ok := ctx.Value(exporterKey{}).(*Exporter); ok {
+		return e
+	}
+	return nil
+}
+
+func (e *Exporter) client() otlptrace.Client {
+	var opts []otlptracegrpc.Option
+	if strings.Contains(e.Endpoint, "://") {
+		opts = append(opts, otlptracegrpc.WithEndpointURL(e.Endpoint))
+	} else {
+		opts = append(opts, otlptracegrpc.WithEndpoint(e.Endpoint))
+	}
+	if e.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(e.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(e.Headers))
+	}
+	return otlptracegrpc.NewClient(opts...)
+}
+
+func (e *Exporter) sampler() tracesdk.Sampler {
+	if e.SamplingRatio >= 1 {
+		return tracesdk.AlwaysSample()
+	}
+	return tracesdk.ParentBased(tracesdk.TraceIDRatioBased(e.SamplingRatio))
+}
+
+// exportProcessor returns the SpanProcessor that batches the sampled spans for the given exporter.
+func (e *Exporter) exportProcessor(exp tracesdk.SpanExporter) tracesdk.SpanProcessor {
+	bsp := tracesdk.NewBatchSpanProcessor(exp)
+	if e.SamplingRatio >= 1 {
+		return bsp
+	}
+	return &samplingProcessor{SpanProcessor: bsp, sampler: e.sampler()}
+}
+
+// samplingProcessor passes the ended spans that its sampler samples on to another SpanProcessor. The
+// decision is made when the span that is the local root of a trace starts, and its descendants inherit it.
+type samplingProcessor struct {
+	tracesdk.SpanProcessor
+	sampler   tracesdk.Sampler
+	decisions sync.Map // bool keyed by trace.SpanID
+}
+
+func (p *samplingProcessor) OnStart(parent context.Context, s tracesdk.ReadWriteSpan) {
+	sampled, ok := false, false
+	if psc := s.Parent(); psc.IsValid() && !psc.IsRemote() {
+		var v any
+		if v, ok = p.decisions.Load(psc.SpanID()); ok {
+			sampled = v.(bool)
+		}
+	}
+	if !ok {
+		// The tracer provider samples all spans, so the sampler must only consider a remote parent.
+		ctx := context.Background()
+		if psc := s.Parent(); psc.IsRemote() {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, psc)
+		}
+		r := p.sampler.ShouldSample(tracesdk.SamplingParameters{
+			ParentContext: ctx,
+			TraceID:       s.SpanContext().TraceID(),
+			Name:          s.Name(),
+			Kind:          s.SpanKind(),
+			Attributes:    s.Attributes(),
+		})
+		sampled = r.Decision == tracesdk.RecordAndSample
+	}
+	p.decisions.Store(s.SpanContext().SpanID(), sampled)
+	if sampled {
+		p.SpanProcessor.OnStart(parent, s)
+	}
+}
+
+func (p *samplingProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	if v, ok := p.decisions.LoadAndDelete(s.SpanContext().SpanID()); ok && v.(bool) {
+		p.SpanProcessor.OnEnd(s)
+	}
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestExporter_sampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter *Exporter
+	}{
+		{"no exporter", nil},
+		{"all", &Exporter{SamplingRatio: 1}},
+		{"none", &Exporter{SamplingRatio: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := dlog.NewTestContext(t, false)
+			if tt.exporter != nil {
+				ctx = WithExporter(ctx, tt.exporter)
+			}
+			ts, err := NewTraceServer(ctx, "test")
+			require.NoError(t, err)
+			defer ts.Shutdown(ctx)
+
+			// The sampling ratio only applies to the export, so all spans are available to DumpTraces.
+			_, span := ts.tp.Tracer("").Start(context.Background(), "op")
+			span.End()
+			assert.True(t, span.SpanContext().IsSampled())
+		})
+	}
+}
+
+func TestExporter_sampler(t *testing.T) {
+	assert.Contains(t, (&Exporter{SamplingRatio: 1}).sampler().Description(), "AlwaysOn")
+	assert.Contains(t, (&Exporter{SamplingRatio: 0.25}).sampler().Description(), "TraceIDRatioBased{0.25}")
+}
+
+func TestExporter_exportProcessor(t *testing.T) {
+	remoteParent := func(sampled bool) context.Context {
+		scc := trace.SpanContextConfig{
+			TraceID: trace.TraceID{1, 2, 3},
+			SpanID:  trace.SpanID{4, 5, 6},
+			Remote:  true,
+		}
+		if sampled {
+			scc.TraceFlags = trace.FlagsSampled
+		}
+		return trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(scc))
+	}
+
+	tests := []struct {
+		name     string
+		ratio    float64
+		parent   context.Context
+		exported int
+	}{
+		{"all", 1, context.Background(), 2},
+		{"none", 0, context.Background(), 0},
+		{"sampled remote parent", 0, remoteParent(true), 2},
+		{"unsampled remote parent", 1.0 / 3, remoteParent(false), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			exp := tracetest.NewInMemoryExporter()
+			collected := tracetest.NewInMemoryExporter()
+			tp := tracesdk.NewTracerProvider(
+				tracesdk.WithSampler(tracesdk.AlwaysSample()),
+				tracesdk.WithSyncer(collected),
+				tracesdk.WithSpanProcessor((&Exporter{SamplingRatio: tt.ratio}).exportProcessor(exp)),
+			)
+			defer func() { _ = tp.Shutdown(ctx) }()
+
+			pc, parent := tp.Tracer("").Start(tt.parent, "parent")
+			_, child := tp.Tracer("").Start(pc, "child")
+			child.End()
+			parent.End()
+			require.NoError(t, tp.ForceFlush(ctx))
+			assert.Len(t, exp.GetSpans(), tt.exported)
+			assert.Len(t, collected.GetSpans(), 2)
+		})
+	}
+}
+
+func TestExporter_exportProcessor_wholeTraces(t *testing.T) {
+	ctx := context.Background()
+	exp := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSampler(tracesdk.AlwaysSample()),
+		tracesdk.WithSpanProcessor((&Exporter{SamplingRatio: 0.5}).exportProcessor(exp)),
+	)
+	defer func() { _ = tp.Shutdown(ctx) }()
+
+	const traces = 200
+	for range traces {
+		pc, parent := tp.Tracer("").Start(ctx, "parent")
+		_, child := tp.Tracer("").Start(pc, "child")
+		child.End()
+		parent.End()
+	}
+	require.NoError(t, tp.ForceFlush(ctx))
+
+	// Either all or no spans of a trace are exported.
+	perTrace := make(map[trace.TraceID]int)
+	for _, s := range exp.GetSpans() {
+		perTrace[s.SpanContext.TraceID()]++
+	}
+	for id, n := range perTrace {
+		assert.Equal(t, 2, n, "trace %s", id)
+	}
+	assert.Greater(t, len(perTrace), traces/4)
+	assert.Less(t, len(perTrace), 3*traces/4)
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// SpansOfTracesStartedBetween returns the spans of the traces that have at least one span that started within
+// the given time interval. The spans of such a trace are all included, regardless of when they started, so
+// that a trace is never torn apart. Resource and scope spans that end up without any spans are dropped.
+func SpansOfTracesStartedBetween(rss []*tracepb.ResourceSpans, start, end time.Time) []*tracepb.ResourceSpans {
+	startNano := uint64(start.UnixNano())
+	endNano := uint64(end.UnixNano())
+	traceIDs := make(map[string]struct{})
+	for _, rs := range rss {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				if span.StartTimeUnixNano >= startNano && span.StartTimeUnixNano <= endNano {
+					traceIDs[string(span.TraceId)] = struct{}{}
+				}
+			}
+		}
+	}
+
+	var result []*tracepb.ResourceSpans
+	for _, rs := range rss {
+		var sss []*tracepb.ScopeSpans
+		for _, ss := range rs.ScopeSpans {
+			var spans []*tracepb.Span
+			for _, span := range ss.Spans {
+				if _, ok := traceIDs[string(span.TraceId)]; ok {
+					spans = append(spans, span)
+				}
+			}
+			if len(spans) > 0 {
+				sss = append(sss, &tracepb.ScopeSpans{Scope: ss.Scope, Spans: spans, SchemaUrl: ss.SchemaUrl})
+			}
+		}
+		if len(sss) > 0 {
+			result = append(result, &tracepb.ResourceSpans{Resource: rs.Resource, ScopeSpans: sss, SchemaUrl: rs.SchemaUrl})
+		}
+	}
+	return result
+}
+
+// MarshalJSON marshals the given spans as OTLP JSON. It differs from the protojson marshalling of the spans
+// in that trace and span IDs are hex encoded, as required by the OTLP specification.
+func MarshalJSON(rss []*tracepb.ResourceSpans) ([]byte, error) {
+	data, err := protojson.Marshal(&tracepb.TracesData{ResourceSpans: rss})
+	if err != nil {
+		return nil, err
+	}
+	var td map[string]any
+	if err = json.Unmarshal(data, &td); err != nil {
+		return nil, err
+	}
+	for _, rs := range objects(td["resourceSpans"]) {
+		for _, ss := range objects(rs["scopeSpans"]) {
+			for _, span := range objects(ss["spans"]) {
+				hexEncodeIDs(span)
+				for _, link := range objects(span["links"]) {
+					hexEncodeIDs(link)
+				}
+			}
+		}
+	}
+	return json.Marshal(td)
+}
+
+func objects(v any) []map[string]any {
+	vs, _ := v.([]any)
+	objs := make([]map[string]any, 0, len(vs))
+	for _, v := range vs {
+		if obj, ok := v.(map[string]any); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+func hexEncodeIDs(obj map[string]any) {
+	for _, key := range []string{"traceId", "spanId", "parentSpanId"} {
+		if s, ok := obj[key].(string); ok {
+			if id, err := base64.StdEncoding.DecodeString(s); err == nil {
+				obj[key] = hex.EncodeToString(id)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func testSpan(traceID, spanID byte, start time.Time) *tracepb.Span {
+	return &tracepb.Span{
+		TraceId:           []byte{traceID, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		SpanId:            []byte{spanID, 0, 0, 0, 0, 0, 0, 1},
+		Name:              "op",
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(start.Add(time.Millisecond).UnixNano()),
+	}
+}
+
+func TestSpansOfTracesStartedBetween(t *testing.T) {
+	start := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	scope := &commonpb.InstrumentationScope{Name: "test"}
+	rss := []*tracepb.ResourceSpans{
+		{ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+			testSpan(1, 1, start.Add(-time.Hour)), // old trace
+			testSpan(2, 2, start.Add(time.Second)),
+		}}}},
+		{ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+			testSpan(1, 3, start.Add(-time.Hour)),
+			testSpan(2, 4, end.Add(time.Second)), // later span of a trace that started in time
+		}}}},
+		{ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+			testSpan(3, 5, end.Add(time.Hour)), // new trace
+		}}}},
+	}
+
+	result := SpansOfTracesStartedBetween(rss, start, end)
+	require.Len(t, result, 2)
+	var spanIDs []byte
+	for _, rs := range result {
+		for _, ss := range rs.ScopeSpans {
+			assert.Equal(t, scope, ss.Scope)
+			for _, span := range ss.Spans {
+				spanIDs = append(spanIDs, span.SpanId[0])
+			}
+		}
+	}
+	assert.Equal(t, []byte{2, 4}, spanIDs)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	span := testSpan(0xab, 0xcd, time.Now())
+	span.ParentSpanId = []byte{0xef, 0, 0, 0, 0, 0, 0, 1}
+	data, err := MarshalJSON([]*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}}}})
+	require.NoError(t, err)
+
+	var td struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(data, &td))
+	js := td.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "ab000000000000000000000000000001", js.TraceID)
+	assert.Equal(t, "cd00000000000001", js.SpanID)
+	assert.Equal(t, "ef00000000000001", js.ParentSpanID)
+	assert.Equal(t, "op", js.Name)
+}
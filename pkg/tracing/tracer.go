@@ -48,13 +48,25 @@ func setupTracer(ctx context.Context, componentName string, client otlptrace.Cli
 	if err != nil {
 		return nil, err
 	}
-	tp := tracesdk.NewTracerProvider(
+	opts := []tracesdk.TracerProviderOption{
 		// Always be sure to batch in production.
 		tracesdk.WithBatcher(exp),
 		tracesdk.WithSampler(tracesdk.AlwaysSample()),
 		// Record information about this application in a Resource.
 		tracesdk.WithResource(r),
-	)
+	}
+	if e := getExporter(ctx); e != nil {
+		if e.Endpoint != "" {
+			otlpExp, err := otlptrace.New(ctx, e.client())
+			if err != nil {
+				return nil, err
+			}
+			// The sampling ratio only applies to the export, so that DumpTraces still gets all spans.
+			opts = append(opts, tracesdk.WithSpanProcessor(e.exportProcessor(otlpExp)))
+			dlog.Infof(ctx, "Exporting spans to OTLP collector at %s", e.Endpoint)
+		}
+	}
+	tp := tracesdk.NewTracerProvider(opts...)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	otel.SetTracerProvider(tp)
 	return tp, nil
@@ -0,0 +1,74 @@
+package tunnel
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Message buffers are pooled in size classes that are powers of two, from 512 bytes to 2 MiB, so that
+// the tunnel doesn't allocate new memory for each message that it sends or receives. Larger buffers are
+// allocated on demand and never pooled.
+const (
+	minPoolShift = 9
+	maxPoolShift = 21
+)
+
+var msgPools [maxPoolShift - minPoolShift + 1]sync.Pool //nolint:gochecknoglobals // pools are global by nature
+
+// pooledMsg is a Message that uses a buffer from a pool. The buffer is returned to the pool when the
+// message is released, and the message must not be used after that.
+//
+// A pooledMsg is released by its final consumer, which is a Stream that sends it on a gRPC stream or
+// an Endpoint that writes its payload to a connection. Messages that are dropped by other consumers
+// are reclaimed by the garbage collector.
+type pooledMsg struct {
+	msg
+}
+
+// poolIndex returns the index of the pool for buffers of the given size, or -1 if such buffers aren't
+// pooled.
+func poolIndex(size int) int {
+	shift := bits.Len(uint(size - 1))
+	if shift < minPoolShift {
+		shift = minPoolShift
+	}
+	if shift > maxPoolShift {
+		return -1
+	}
+	return shift - minPoolShift
+}
+
+// getPooledMsg returns a pooledMsg with the given length. Its content is undefined.
+func getPooledMsg(size int) *pooledMsg {
+	i := poolIndex(size)
+	if i < 0 {
+		return &pooledMsg{msg: make(msg, size)}
+	}
+	if m, ok := msgPools[i].Get().(*pooledMsg); ok {
+		m.msg = m.msg[:size]
+		return m
+	}
+	return &pooledMsg{msg: make(msg, size, 1<<(i+minPoolShift))}
+}
+
+// newPooledMessage is like NewMessage, but returns a message that uses a pooled buffer.
+func newPooledMessage(code MessageCode, payload []byte) Message {
+	m := getPooledMsg(1 + len(payload))
+	m.msg[0] = byte(code)
+	copy(m.msg[1:], payload)
+	return m
+}
+
+func (m *pooledMsg) release() {
+	c := cap(m.msg)
+	if i := poolIndex(c); i >= 0 && c == 1<<(i+minPoolShift) {
+		msgPools[i].Put(m)
+	}
+}
+
+// releaseMessage returns the buffer of the given message to its pool if the message is pooled.
+func releaseMessage(m Message) {
+	if pm, ok := m.(*pooledMsg); ok {
+		pm.release()
+	}
+}
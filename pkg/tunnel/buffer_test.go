@@ -0,0 +1,126 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+	grpcproto "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestPoolIndex(t *testing.T) {
+	assert.Equal(t, 0, poolIndex(1))
+	assert.Equal(t, 0, poolIndex(512))
+	assert.Equal(t, 1, poolIndex(513))
+	assert.Equal(t, maxPoolShift-minPoolShift, poolIndex(1<<maxPoolShift))
+	assert.Equal(t, -1, poolIndex(1<<maxPoolShift+1))
+}
+
+func TestPooledMessage(t *testing.T) {
+	payload := []byte("hello")
+	m := newPooledMessage(Normal, payload)
+	assert.Equal(t, Normal, m.Code())
+	assert.Equal(t, payload, m.Payload())
+	assert.Equal(t, []byte{byte(Normal), 'h', 'e', 'l', 'l', 'o'}, m.TunnelMessage().Payload)
+
+	pm, ok := m.(*pooledMsg)
+	require.True(t, ok)
+	assert.Equal(t, 1<<minPoolShift, cap(pm.msg))
+	releaseMessage(m)
+
+	// Buffers that are too large are not pooled, but still usable.
+	m = newPooledMessage(Normal, make([]byte, 1<<maxPoolShift))
+	assert.Len(t, m.Payload(), 1<<maxPoolShift)
+	releaseMessage(m)
+
+	// Releasing a message that isn't pooled is a no-op.
+	releaseMessage(NewMessage(Normal, payload))
+}
+
+func TestCodec(t *testing.T) {
+	c := encoding.GetCodec(grpcproto.Name)
+	require.IsType(t, codec{}, c)
+
+	tm := &manager.TunnelMessage{Payload: []byte{byte(Normal), 1, 2, 3}}
+	data, err := c.Marshal(tm)
+	require.NoError(t, err)
+	expected, err := proto.Marshal(tm)
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	pt := pooledTunnelMessage{}
+	require.NoError(t, c.Unmarshal(data, &pt))
+	require.NotNil(t, pt.pm)
+	assert.Equal(t, tm.Payload, pt.Payload)
+	assert.Equal(t, tm.Payload, []byte(pt.pm.msg))
+	pt.pm.release()
+
+	// Unknown fields are skipped.
+	data = protowire.AppendVarint(protowire.AppendTag(data, 2, protowire.VarintType), 42)
+	pt = pooledTunnelMessage{}
+	require.NoError(t, c.Unmarshal(data, &pt))
+	assert.Equal(t, tm.Payload, pt.Payload)
+
+	// Truncated messages are rejected.
+	pt = pooledTunnelMessage{}
+	assert.Error(t, c.Unmarshal(expected[:len(expected)-1], &pt))
+
+	// Other messages are handled by the default codec.
+	dr := &manager.DialRequest{ConnId: []byte("id")}
+	data, err = c.Marshal(dr)
+	require.NoError(t, err)
+	dr2 := &manager.DialRequest{}
+	require.NoError(t, c.Unmarshal(data, dr2))
+	assert.Equal(t, dr.ConnId, dr2.ConnId)
+}
+
+const benchPayloadSize = 0x8000
+
+func BenchmarkNewMessage(b *testing.B) {
+	payload := make([]byte, benchPayloadSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewMessage(Normal, payload)
+	}
+}
+
+func BenchmarkNewPooledMessage(b *testing.B) {
+	payload := make([]byte, benchPayloadSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		releaseMessage(newPooledMessage(Normal, payload))
+	}
+}
+
+func benchmarkUnmarshal(b *testing.B, c encoding.Codec, newMsg func() any, release func(any)) {
+	data, err := proto.Marshal(&manager.TunnelMessage{Payload: make([]byte, benchPayloadSize)})
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := newMsg()
+		if err := c.Unmarshal(data, m); err != nil {
+			b.Fatal(err)
+		}
+		release(m)
+	}
+}
+
+func BenchmarkUnmarshalTunnelMessage(b *testing.B) {
+	benchmarkUnmarshal(b, encoding.GetCodec(grpcproto.Name).(codec).Codec, func() any {
+		return &manager.TunnelMessage{}
+	}, func(any) {})
+}
+
+func BenchmarkUnmarshalPooledTunnelMessage(b *testing.B) {
+	benchmarkUnmarshal(b, encoding.GetCodec(grpcproto.Name), func() any {
+		return &pooledTunnelMessage{}
+	}, func(m any) {
+		m.(*pooledTunnelMessage).pm.release()
+	})
+}
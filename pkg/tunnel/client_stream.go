@@ -19,7 +19,8 @@ func NewClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID
 	s.dialTimeout = dialTimeout
 	s.sessionID = sessionID
 
-	if err := s.Send(ctx, StreamInfoMessage(id, sessionID, callDelay, dialTimeout)); err != nil {
+	offered := GetCompression(ctx)
+	if err := s.Send(ctx, StreamInfoMessage(id, sessionID, callDelay, dialTimeout, offered)); err != nil {
 		_ = s.CloseSend(ctx)
 		return nil, err
 	}
@@ -32,7 +33,11 @@ func NewClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID
 		_ = s.CloseSend(ctx)
 		return nil, errors.New("initial message was not StreamOK")
 	}
-	s.peerVersion = getVersion(m)
+	var accepted Compression
+	s.peerVersion, accepted = getStreamOK(m)
+	if accepted == offered {
+		s.compression = accepted
+	}
 	return s, nil
 }
 
@@ -0,0 +1,75 @@
+package tunnel
+
+import (
+	"google.golang.org/grpc/encoding"
+	grpcproto "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// codec is the gRPC codec for protobuf messages. It delegates to the default codec, except for the
+// messages of the tunnel, which are encoded and decoded without reflection. A pooledTunnelMessage is
+// decoded into a pooled buffer.
+//
+// The codec replaces the default codec for all gRPC connections of the process, but it uses the same
+// name and wire format, so peers are unaffected.
+type codec struct {
+	encoding.Codec
+}
+
+// pooledTunnelMessage is a TunnelMessage that is received using RecvMsg. Its payload is a pooled buffer.
+type pooledTunnelMessage struct {
+	manager.TunnelMessage
+	pm *pooledMsg
+}
+
+func init() {
+	encoding.RegisterCodec(codec{Codec: encoding.GetCodec(grpcproto.Name)})
+}
+
+func (c codec) Marshal(v any) ([]byte, error) {
+	if tm, ok := v.(*manager.TunnelMessage); ok {
+		pl := tm.GetPayload()
+		if len(pl) == 0 {
+			return nil, nil
+		}
+		b := make([]byte, 0, protowire.SizeTag(1)+protowire.SizeBytes(len(pl)))
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		return protowire.AppendBytes(b, pl), nil
+	}
+	return c.Codec.Marshal(v)
+}
+
+func (c codec) Unmarshal(data []byte, v any) error {
+	if pt, ok := v.(*pooledTunnelMessage); ok {
+		return pt.unmarshal(data)
+	}
+	return c.Codec.Unmarshal(data, v)
+}
+
+func (pt *pooledTunnelMessage) unmarshal(data []byte) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if num == 1 && typ == protowire.BytesType {
+			var pl []byte
+			if pl, n = protowire.ConsumeBytes(data); n < 0 {
+				return protowire.ParseError(n)
+			}
+			if pt.pm != nil {
+				pt.pm.release()
+			}
+			pt.pm = getPooledMsg(len(pl))
+			copy(pt.pm.msg, pl)
+			pt.Payload = pt.pm.msg
+		} else if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return nil
+}
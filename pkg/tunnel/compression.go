@@ -0,0 +1,153 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is an algorithm used for compressing the payload of Normal messages on a Stream.
+//
+// The client side of a Stream offers a Compression in its StreamInfo message, and the server side
+// accepts it by appending it to its StreamOK message. Peers that don't know about compression ignore
+// the offer, and the Stream then remains uncompressed.
+type Compression byte
+
+const (
+	NoCompression = Compression(iota)
+	SnappyCompression
+	ZstdCompression
+)
+
+const (
+	// minCompressSize is the size of the smallest payload that is worth compressing.
+	minCompressSize = 256
+
+	// maxDecompressedSize is the size of the largest payload that a compressed message may expand to.
+	maxDecompressedSize = 1 << 22
+)
+
+// ParseCompression parses the given name of a compression algorithm. The empty string and "none"
+// both mean NoCompression.
+func ParseCompression(s string) (Compression, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return NoCompression, nil
+	case "snappy":
+		return SnappyCompression, nil
+	case "zstd":
+		return ZstdCompression, nil
+	default:
+		return NoCompression, fmt.Errorf("invalid compression %q, valid values are none, snappy, and zstd", s)
+	}
+}
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case SnappyCompression:
+		return "snappy"
+	case ZstdCompression:
+		return "zstd"
+	default:
+		return fmt.Sprintf("** unknown compression: %d **", c)
+	}
+}
+
+func (c Compression) supported() bool {
+	return c <= ZstdCompression
+}
+
+type compressionKey struct{}
+
+// WithCompression returns a context with the Compression that client Streams created with that context
+// will offer to their peer.
+func WithCompression(ctx context.Context, c Compression) context.Context {
+	return context.WithValue(ctx, compressionKey{}, c)
+}
+
+// GetCompression returns the Compression stored in the given context, or NoCompression.
+func GetCompression(ctx context.Context) Compression {
+	c, _ := ctx.Value(compressionKey{}).(Compression)
+	return c
+}
+
+// The zstd encoder and decoder are safe for concurrent use by EncodeAll and DecodeAll, and expensive
+// to create, so one of each is shared by all streams.
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder { //nolint:gochecknoglobals // shared encoder
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		return enc
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder { //nolint:gochecknoglobals // shared decoder
+		dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+		return dec
+	})
+)
+
+// compressMessage returns a compressed message with the payload of the given Normal message, or nil
+// if the payload is too small or doesn't compress.
+func compressMessage(c Compression, m Message) Message {
+	pl := m.Payload()
+	if len(pl) < minCompressSize {
+		return nil
+	}
+	var cm *pooledMsg
+	switch c {
+	case SnappyCompression:
+		cm = getPooledMsg(1 + s2.MaxEncodedLen(len(pl)))
+		cm.msg = cm.msg[:1+len(s2.EncodeSnappy(cm.msg[1:], pl))]
+	case ZstdCompression:
+		cm = getPooledMsg(1 + len(pl))
+		cm.msg = zstdEncoder().EncodeAll(pl, cm.msg[:1])
+	default:
+		return nil
+	}
+	if len(cm.msg) >= len(pl)+1 {
+		cm.release()
+		return nil
+	}
+	cm.msg[0] = byte(compressed)
+	return cm
+}
+
+// decompressMessage returns a Normal message with the decompressed payload of the given compressed message.
+func decompressMessage(c Compression, m Message) (Message, error) {
+	pl := m.Payload()
+	var dm *pooledMsg
+	switch c {
+	case SnappyCompression:
+		n, err := s2.DecodedLen(pl)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxDecompressedSize {
+			return nil, s2.ErrTooLarge
+		}
+		dm = getPooledMsg(1 + n)
+		if _, err = s2.Decode(dm.msg[1:], pl); err != nil {
+			dm.release()
+			return nil, err
+		}
+	case ZstdCompression:
+		dm = getPooledMsg(1 + 4*len(pl))
+		out, err := zstdDecoder().DecodeAll(pl, dm.msg[:1])
+		if err != nil {
+			dm.release()
+			return nil, err
+		}
+		// The decoder allocates a new buffer if the output doesn't fit in the pooled one.
+		dm.msg = out
+	default:
+		return nil, errors.New("received a compressed message on a stream without compression")
+	}
+	dm.msg[0] = byte(Normal)
+	releaseMessage(m)
+	return dm, nil
+}
@@ -0,0 +1,185 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func TestParseCompression(t *testing.T) {
+	for _, c := range []Compression{NoCompression, SnappyCompression, ZstdCompression} {
+		pc, err := ParseCompression(c.String())
+		require.NoError(t, err)
+		assert.Equal(t, c, pc)
+	}
+	c, err := ParseCompression("")
+	require.NoError(t, err)
+	assert.Equal(t, NoCompression, c)
+	_, err = ParseCompression("gzip")
+	assert.Error(t, err)
+}
+
+func TestCompressMessage(t *testing.T) {
+	text := bytes.Repeat([]byte(`{"name":"telepresence","kind":"json"}`), 100)
+	random := make([]byte, 0x1000)
+	_, _ = rand.Read(random)
+
+	for _, c := range []Compression{SnappyCompression, ZstdCompression} {
+		t.Run(c.String(), func(t *testing.T) {
+			cm := compressMessage(c, NewMessage(Normal, text))
+			require.NotNil(t, cm)
+			assert.Equal(t, compressed, cm.Code())
+			assert.Less(t, len(cm.Payload()), len(text))
+
+			dm, err := decompressMessage(c, cm)
+			require.NoError(t, err)
+			assert.Equal(t, Normal, dm.Code())
+			assert.Equal(t, text, dm.Payload())
+
+			// Small and incompressible payloads are sent as is.
+			assert.Nil(t, compressMessage(c, NewMessage(Normal, text[:minCompressSize-1])))
+			assert.Nil(t, compressMessage(c, NewMessage(Normal, random)))
+
+			_, err = decompressMessage(c, NewMessage(compressed, random))
+			assert.Error(t, err)
+		})
+	}
+	_, err := decompressMessage(NoCompression, NewMessage(compressed, text))
+	assert.Error(t, err)
+}
+
+func TestStreamOK(t *testing.T) {
+	v, c := getStreamOK(StreamOKMessage(ZstdCompression))
+	assert.Equal(t, Version, v)
+	assert.Equal(t, ZstdCompression, c)
+
+	// A peer that doesn't know about compression returns the version only.
+	v, c = getStreamOK(StreamOKMessage(NoCompression))
+	assert.Equal(t, Version, v)
+	assert.Equal(t, NoCompression, c)
+}
+
+func TestStream_Compression(t *testing.T) {
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	si := uuid.New().String()
+	text := bytes.Repeat([]byte("GET /api/v1/items HTTP/1.1\r\n"), 100)
+
+	for _, c := range []Compression{NoCompression, SnappyCompression, ZstdCompression} {
+		t.Run(c.String(), func(t *testing.T) {
+			ctx, cancel := testContext(t, 5*time.Second)
+			defer cancel()
+			tunnel := newBidi(10, ctx.Done())
+			clientCh := make(chan Stream, 1)
+			go func() {
+				client, err := NewClientStream(WithCompression(ctx, c), tunnel.clientSide(), id, si, 0, 0)
+				assert.NoError(t, err)
+				clientCh <- client
+			}()
+			server, err := NewServerStream(ctx, tunnel.serverSide())
+			require.NoError(t, err)
+			client := <-clientCh
+			require.NotNil(t, client)
+			assert.Equal(t, c, client.(*clientStream).compression)
+			assert.Equal(t, c, server.(*stream).compression)
+
+			require.NoError(t, client.Send(ctx, NewMessage(Normal, text)))
+			m, err := server.Receive(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, Normal, m.Code())
+			assert.Equal(t, text, m.Payload())
+
+			require.NoError(t, server.Send(ctx, NewMessage(Normal, text)))
+			m, err = client.Receive(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, text, m.Payload())
+		})
+	}
+}
+
+// tunnelClient is a Client that uses the client side of a bidi.
+type tunnelClient struct {
+	grpc.ClientStream
+	cs *clientSide
+}
+
+func (c *tunnelClient) Recv() (*manager.TunnelMessage, error) {
+	return c.cs.Recv()
+}
+
+func (c *tunnelClient) RecvMsg(m any) error {
+	tm, err := c.cs.Recv()
+	if err != nil {
+		return err
+	}
+	m.(*pooledTunnelMessage).Payload = tm.Payload
+	return nil
+}
+
+func (c *tunnelClient) Send(msg *manager.TunnelMessage) error {
+	return c.cs.Send(msg)
+}
+
+func (c *tunnelClient) CloseSend() error {
+	return c.cs.CloseSend()
+}
+
+type tunnelProvider struct {
+	client Client
+}
+
+func (p tunnelProvider) Tunnel(context.Context, ...grpc.CallOption) (Client, error) {
+	return p.client, nil
+}
+
+// dialStream is a Manager_WatchDialClient that delivers the dial requests of its channel.
+type dialStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	ch  <-chan *manager.DialRequest
+}
+
+func (d *dialStream) Recv() (*manager.DialRequest, error) {
+	select {
+	case <-d.ctx.Done():
+		return nil, io.EOF
+	case dr := <-d.ch:
+		return dr, nil
+	}
+}
+
+// TestDialWaitLoop_Compression verifies that the streams that a traffic-agent creates when the traffic-manager
+// asks it to dial offer the compression of the agent's context.
+func TestDialWaitLoop_Compression(t *testing.T) {
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("127.0.0.1"), 1001, 1)
+	si := uuid.New().String()
+	for _, c := range []Compression{NoCompression, SnappyCompression, ZstdCompression} {
+		t.Run(c.String(), func(t *testing.T) {
+			ctx, cancel := testContext(t, 5*time.Second)
+			defer cancel()
+			tunnel := newBidi(10, ctx.Done())
+			provider := tunnelProvider{client: &tunnelClient{cs: &clientSide{tunnel}}}
+			drCh := make(chan *manager.DialRequest, 1)
+			drCh <- &manager.DialRequest{ConnId: []byte(id), DialTimeout: int64(time.Second)}
+			go func() {
+				_ = DialWaitLoop(WithCompression(ctx, c), provider, &dialStream{ctx: ctx, ch: drCh}, si)
+			}()
+
+			server, err := NewServerStream(ctx, tunnel.serverSide())
+			require.NoError(t, err)
+			assert.Equal(t, id, server.ID())
+			assert.Equal(t, c, server.(*stream).compression)
+		})
+	}
+}
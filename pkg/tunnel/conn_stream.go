@@ -0,0 +1,87 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// NewConnStream creates a Stream that exchanges the payload of Normal messages with the given connection. It's
+// used when the peer is reached through a connection that is already established, such as one that has been
+// dialed through a WireGuard device, instead of a gRPC tunnel. The connection is closed when a Disconnect
+// message is sent or when the given context is done, and control messages that have no meaning for a
+// connection are ignored.
+func NewConnStream(ctx context.Context, id ConnID, conn net.Conn, sessionID string) Stream {
+	context.AfterFunc(ctx, func() { _ = conn.Close() })
+	return &connStream{id: id, conn: conn, sid: sessionID}
+}
+
+type connStream struct {
+	id   ConnID
+	conn net.Conn
+	sid  string
+}
+
+func (s *connStream) Tag() string {
+	return "CON"
+}
+
+func (s *connStream) ID() ConnID {
+	return s.id
+}
+
+func (s *connStream) Receive(_ context.Context) (Message, error) {
+	m := getPooledMsg(0x10000)
+	for {
+		n, err := s.conn.Read(m.msg[1:])
+		if n > 0 {
+			m.msg = m.msg[:1+n]
+			m.msg[0] = byte(Normal)
+			return m, nil
+		}
+		if err != nil {
+			m.release()
+			return nil, err
+		}
+	}
+}
+
+func (s *connStream) Send(_ context.Context, m Message) error {
+	defer releaseMessage(m)
+	switch m.Code() {
+	case Normal:
+		_, err := s.conn.Write(m.Payload())
+		return err
+	case Disconnect:
+		return s.conn.Close()
+	default:
+		return nil
+	}
+}
+
+func (s *connStream) CloseSend(_ context.Context) error {
+	if cw, ok := s.conn.(interface{ CloseWrite() error }); ok {
+		if err := cw.CloseWrite(); err != nil && !errors.Is(err, net.ErrClosed) {
+			return err
+		}
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *connStream) PeerVersion() uint16 {
+	return Version
+}
+
+func (s *connStream) SessionID() string {
+	return s.sid
+}
+
+func (s *connStream) DialTimeout() time.Duration {
+	return 0
+}
+
+func (s *connStream) RoundtripLatency() time.Duration {
+	return 0
+}
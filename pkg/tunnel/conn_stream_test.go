@@ -0,0 +1,68 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func TestConnStream(t *testing.T) {
+	ctx, cancel := testContext(t, 5*time.Second)
+	defer cancel()
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	s := NewConnStream(ctx, id, conn, "session")
+
+	go func() {
+		_ = s.Send(ctx, NewMessage(Normal, []byte("hello")))
+		_ = s.Send(ctx, NewMessage(KeepAlive, nil))
+	}()
+	buf := make([]byte, 10)
+	n, err := peer.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:n]))
+
+	go func() {
+		_, _ = peer.Write([]byte("world"))
+	}()
+	m, err := s.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Normal, m.Code())
+	assert.Equal(t, "world", string(m.Payload()))
+
+	require.NoError(t, s.Send(ctx, NewMessage(Disconnect, nil)))
+	_, err = peer.Read(buf)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestConnStream_ContextDone(t *testing.T) {
+	ctx, cancel := testContext(t, 5*time.Second)
+	defer cancel()
+	id := NewConnID(ipproto.UDP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 53)
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	sCtx, sCancel := context.WithCancel(ctx)
+	s := NewConnStream(sCtx, id, conn, "session")
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.Receive(ctx)
+		errCh <- err
+	}()
+	sCancel()
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, io.ErrClosedPipe)
+	case <-ctx.Done():
+		t.Fatal("Receive wasn't unblocked when the context was cancelled")
+	}
+}
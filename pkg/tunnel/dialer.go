@@ -13,17 +13,12 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
-	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
-)
-
-// The idleDuration controls how long a dialer for a specific proto+from-to address combination remains alive without
-// reading or writing any messages. The dialer is normally closed by one of the peers.
-const (
-	tcpConnTTL = 2 * time.Hour // Default tcp_keepalive_time on Linux
-	udpConnTTL = 1 * time.Minute
+	"github.com/telepresenceio/telepresence/v2/pkg/log"
 )
 
 const (
@@ -51,6 +46,8 @@ type dialer struct {
 	conn      net.Conn
 	connected int32
 	done      chan struct{}
+	idle      IdleConfig
+	sent      atomic.Bool // a message has been sent since the last keep-alive tick
 
 	ingressBytesProbe *CounterProbe
 	egressBytesProbe  *CounterProbe
@@ -75,12 +72,11 @@ func NewDialerTTL(stream Stream, cancel context.CancelFunc, ttl time.Duration, i
 	return NewConnEndpointTTL(stream, nil, cancel, ttl, ingressBytesProbe, egressBytesProbe)
 }
 
+// NewConnEndpoint creates a new handler that dispatches messages in both directions between the given gRPC stream
+// and the given connection. The handler uses the IdleConfig for the protocol of the stream that is found in the
+// context passed to its Start method.
 func NewConnEndpoint(stream Stream, conn net.Conn, cancel context.CancelFunc, ingressBytesProbe, egressBytesProbe *CounterProbe) Endpoint {
-	ttl := tcpConnTTL
-	if stream.ID().Protocol() == ipproto.UDP {
-		ttl = udpConnTTL
-	}
-	return NewConnEndpointTTL(stream, conn, cancel, ttl, ingressBytesProbe, egressBytesProbe)
+	return NewConnEndpointTTL(stream, conn, cancel, 0, ingressBytesProbe, egressBytesProbe)
 }
 
 func NewConnEndpointTTL(
@@ -115,6 +111,11 @@ func (h *dialer) Start(ctx context.Context) {
 
 		id := h.stream.ID()
 		id.SpanRecord(span)
+		ctx = dlog.WithField(ctx, log.FieldConnID, id.String())
+		h.idle = GetIdleConfig(ctx, id.Protocol())
+		if h.GetTTL() <= 0 {
+			h.SetTTL(h.idle.Timeout)
+		}
 
 		switch h.connected {
 		case notConnected:
@@ -191,7 +192,12 @@ func (h *dialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
 	id := h.stream.ID()
 
 	outgoing := make(chan Message, 50)
+	kaStop := make(chan struct{})
+	kaWg := sync.WaitGroup{}
 	defer func() {
+		// The keep-alive loop must end before the outgoing channel is closed.
+		close(kaStop)
+		kaWg.Wait()
 		if !h.ResetIdle() {
 			// Hard close of peer. We don't want any more data
 			select {
@@ -206,8 +212,14 @@ func (h *dialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
 
 	wg.Add(1)
 	WriteLoop(ctx, h.stream, outgoing, wg, h.egressBytesProbe)
+	if h.idle.KeepAliveInterval > 0 {
+		kaWg.Add(1)
+		go h.keepAliveLoop(ctx, outgoing, kaStop, &kaWg)
+	}
 
-	buf := make([]byte, 0x100000)
+	rb := getPooledMsg(0x100000)
+	defer rb.release()
+	buf := rb.msg
 	dlog.Tracef(ctx, "   CONN %s conn-to-stream loop started", id)
 	for {
 		n, err := h.conn.Read(buf)
@@ -217,7 +229,8 @@ func (h *dialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
 			case <-ctx.Done():
 				endReason = ctx.Err().Error()
 				return
-			case outgoing <- NewMessage(Normal, buf[:n]):
+			case outgoing <- newPooledMessage(Normal, buf[:n]):
+				h.sent.Store(true)
 			}
 		}
 
@@ -242,6 +255,33 @@ func (h *dialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// keepAliveLoop sends a KeepAlive message to the outgoing channel at the configured interval, unless some other
+// message has been sent since the last tick. It returns when the stop channel is closed.
+func (h *dialer) keepAliveLoop(ctx context.Context, outgoing chan<- Message, stop <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(h.idle.KeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			if h.sent.Swap(false) {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case outgoing <- keepAliveMessage(h.idle.KeepAliveInterval):
+			}
+		}
+	}
+}
+
 func (h *dialer) getStream() Stream {
 	return h.stream
 }
@@ -261,8 +301,6 @@ func handleControl(ctx context.Context, h streamReader, cm Message) {
 	switch cm.Code() {
 	case DialReject, Disconnect: // Peer wants to hard-close. No more messages will arrive
 		h.Stop(ctx)
-	case KeepAlive:
-		h.ResetIdle()
 	case DialOK:
 		// So how can a dialer get a DialOK from a peer? Surely, there cannot be a dialer at both ends?
 		// Well, the story goes like this:
@@ -285,6 +323,18 @@ func readLoop(ctx context.Context, h streamReader, trafficProbe *CounterProbe) {
 		dlog.Logf(ctx, endLevel, "   CONN %s stream-to-conn loop ended because %s", id, endReason)
 	}()
 
+	// Dead peer detection starts when the first KeepAlive message arrives, because peers that don't
+	// send KeepAlive messages might remain silent for as long as the connection is idle.
+	deadPeer := GetIdleConfig(ctx, id.Protocol()).DeadPeerTimeout
+	var deadPeerTimer *time.Timer
+	var deadPeerC <-chan time.Time
+	var peerTimeout time.Duration
+	defer func() {
+		if deadPeerTimer != nil {
+			deadPeerTimer.Stop()
+		}
+	}()
+
 	incoming, errCh := ReadLoop(ctx, h.getStream(), trafficProbe)
 	dlog.Tracef(ctx, "   CONN %s stream-to-conn loop started", id)
 	for {
@@ -295,8 +345,18 @@ func readLoop(ctx context.Context, h streamReader, trafficProbe *CounterProbe) {
 		case <-h.Idle():
 			endReason = "it was idle for too long"
 			return
+		case <-deadPeerC:
+			endReason = fmt.Sprintf("nothing was received from the peer in %s", peerTimeout)
+			endLevel = dlog.LogLevelWarn
+			return
 		case err, ok := <-errCh:
 			if ok {
+				if status.Code(err) == grpcCodes.ResourceExhausted {
+					// The traffic-manager applies backpressure by rejecting dials that exceed its limits.
+					endReason = fmt.Sprintf("the traffic-manager rejected it: %s", status.Convert(err).Message())
+					endLevel = dlog.LogLevelWarn
+					return
+				}
 				dlog.Error(ctx, err)
 			}
 		case dg, ok := <-incoming:
@@ -305,6 +365,28 @@ func readLoop(ctx context.Context, h streamReader, trafficProbe *CounterProbe) {
 				endReason = "there was no more input"
 				return
 			}
+			if dg.Code() == KeepAlive {
+				// A KeepAlive proves that the peer is alive, but it doesn't count as traffic.
+				if deadPeer > 0 {
+					peerTimeout = deadPeerTimeout(deadPeer, dg)
+					if deadPeerTimer == nil {
+						deadPeerTimer = time.NewTimer(peerTimeout)
+						deadPeerC = deadPeerTimer.C
+					}
+				}
+			}
+			if deadPeerTimer != nil {
+				if !deadPeerTimer.Stop() {
+					select {
+					case <-deadPeerTimer.C:
+					default:
+					}
+				}
+				deadPeerTimer.Reset(peerTimeout)
+			}
+			if dg.Code() == KeepAlive {
+				continue
+			}
 			if !h.ResetIdle() {
 				endReason = "it was idle for too long"
 				return
@@ -325,6 +407,7 @@ func readLoop(ctx context.Context, h streamReader, trafficProbe *CounterProbe) {
 				dlog.Tracef(ctx, "-> CONN %s, len %d", id, wn)
 				n += wn
 			}
+			releaseMessage(dg)
 		}
 	}
 }
@@ -0,0 +1,95 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+// IdleConfig controls how long a connection may remain idle, and how a peer that has stopped
+// responding is detected.
+type IdleConfig struct {
+	// Timeout is how long a connection may remain without traffic before it's closed.
+	Timeout time.Duration
+
+	// KeepAliveInterval is how often a KeepAlive message is sent to the peer when no other message
+	// has been sent. Zero means that no KeepAlive messages are sent. KeepAlive messages don't count as
+	// traffic, so they don't prevent the connection from being closed when it's idle.
+	KeepAliveInterval time.Duration
+
+	// DeadPeerTimeout is how long a connection remains open when nothing at all arrives from a peer
+	// that is known to send KeepAlive messages. Zero means that dead peers aren't detected.
+	DeadPeerTimeout time.Duration
+}
+
+// IdleConfigs contains the IdleConfig for each protocol.
+type IdleConfigs struct {
+	TCP IdleConfig
+	UDP IdleConfig
+}
+
+// The default idle timeouts control how long a dialer for a specific proto+from-to address combination remains alive
+// without reading or writing any messages. The dialer is normally closed by one of the peers.
+const (
+	tcpConnTTL = 2 * time.Hour // Default tcp_keepalive_time on Linux
+	udpConnTTL = 1 * time.Minute
+)
+
+// DefaultIdleConfigs returns the IdleConfigs used when none have been configured.
+func DefaultIdleConfigs() IdleConfigs {
+	return IdleConfigs{
+		TCP: IdleConfig{Timeout: tcpConnTTL},
+		UDP: IdleConfig{Timeout: udpConnTTL},
+	}
+}
+
+// Get returns the IdleConfig for the given protocol.
+func (ic *IdleConfigs) Get(proto int) IdleConfig {
+	if proto == ipproto.UDP {
+		return ic.UDP
+	}
+	return ic.TCP
+}
+
+type idleConfigsKey struct{}
+
+// WithIdleConfigs returns a context with the IdleConfigs that endpoints started with that context will use.
+// Zero values in the given configs are replaced by their defaults.
+func WithIdleConfigs(ctx context.Context, ic IdleConfigs) context.Context {
+	if ic.TCP.Timeout <= 0 {
+		ic.TCP.Timeout = tcpConnTTL
+	}
+	if ic.UDP.Timeout <= 0 {
+		ic.UDP.Timeout = udpConnTTL
+	}
+	return context.WithValue(ctx, idleConfigsKey{}, ic)
+}
+
+// GetIdleConfig returns the IdleConfig for the given protocol from the given context, or the default
+// if no IdleConfigs have been stored in the context.
+func GetIdleConfig(ctx context.Context, proto int) IdleConfig {
+	ic, ok := ctx.Value(idleConfigsKey{}).(IdleConfigs)
+	if !ok {
+		ic = DefaultIdleConfigs()
+	}
+	return ic.Get(proto)
+}
+
+// keepAliveMessage returns a KeepAlive message that tells the peer how often it can expect them.
+func keepAliveMessage(interval time.Duration) Message {
+	return NewMessage(KeepAlive, binary.AppendUvarint(nil, uint64(interval/time.Millisecond)))
+}
+
+// deadPeerTimeout returns the time to wait for messages from a peer that sent the given KeepAlive message.
+// The timeout is never shorter than two of the peer's KeepAlive intervals, because the peer might use
+// a longer interval than the local configuration expects.
+func deadPeerTimeout(timeout time.Duration, m Message) time.Duration {
+	if ms, n := binary.Uvarint(m.Payload()); n > 0 {
+		if pt := 2 * time.Duration(ms) * time.Millisecond; pt > timeout {
+			timeout = pt
+		}
+	}
+	return timeout
+}
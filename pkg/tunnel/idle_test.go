@@ -0,0 +1,105 @@
+package tunnel
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func TestGetIdleConfig(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, IdleConfig{Timeout: tcpConnTTL}, GetIdleConfig(ctx, ipproto.TCP))
+	assert.Equal(t, IdleConfig{Timeout: udpConnTTL}, GetIdleConfig(ctx, ipproto.UDP))
+
+	ctx = WithIdleConfigs(ctx, IdleConfigs{
+		TCP: IdleConfig{KeepAliveInterval: 30 * time.Second, DeadPeerTimeout: 2 * time.Minute},
+		UDP: IdleConfig{Timeout: 5 * time.Minute},
+	})
+	assert.Equal(t, IdleConfig{Timeout: tcpConnTTL, KeepAliveInterval: 30 * time.Second, DeadPeerTimeout: 2 * time.Minute}, GetIdleConfig(ctx, ipproto.TCP))
+	assert.Equal(t, IdleConfig{Timeout: 5 * time.Minute}, GetIdleConfig(ctx, ipproto.UDP))
+}
+
+func TestDeadPeerTimeout(t *testing.T) {
+	assert.Equal(t, time.Minute, deadPeerTimeout(time.Minute, keepAliveMessage(10*time.Second)))
+	assert.Equal(t, 2*time.Minute, deadPeerTimeout(time.Minute, keepAliveMessage(time.Minute)))
+
+	// KeepAlive messages from older peers have no payload.
+	assert.Equal(t, time.Minute, deadPeerTimeout(time.Minute, NewMessage(KeepAlive, nil)))
+}
+
+// startIdleDialer starts a dialer, using the given IdleConfig, at the server side of a tunnel, and returns the
+// client side Stream of that tunnel.
+func startIdleDialer(ctx context.Context, t *testing.T, ic IdleConfig) (Stream, Endpoint) {
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	tunnel := newBidi(10, ctx.Done())
+	clientCh := make(chan Stream, 1)
+	go func() {
+		client, err := NewClientStream(ctx, tunnel.clientSide(), id, uuid.New().String(), 0, 0)
+		assert.NoError(t, err)
+		clientCh <- client
+	}()
+	server, err := NewServerStream(ctx, tunnel.serverSide())
+	require.NoError(t, err)
+	client := <-clientCh
+	require.NotNil(t, client)
+
+	conn, peer := net.Pipe()
+	t.Cleanup(func() { _ = peer.Close() })
+	ctx, cancel := context.WithCancel(WithIdleConfigs(ctx, IdleConfigs{TCP: ic}))
+	d := NewConnEndpoint(server, conn, cancel, nil, nil)
+	d.Start(ctx)
+	return client, d
+}
+
+func TestDialer_KeepAlive(t *testing.T) {
+	ctx, cancel := testContext(t, 5*time.Second)
+	defer cancel()
+	client, _ := startIdleDialer(ctx, t, IdleConfig{KeepAliveInterval: 20 * time.Millisecond})
+
+	m, err := client.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, KeepAlive, m.Code())
+	assert.Equal(t, 40*time.Millisecond, deadPeerTimeout(0, m))
+}
+
+func TestDialer_KeepAliveIsNotTraffic(t *testing.T) {
+	ctx, cancel := testContext(t, 5*time.Second)
+	defer cancel()
+	client, d := startIdleDialer(ctx, t, IdleConfig{Timeout: 200 * time.Millisecond})
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.Done():
+			return
+		case <-ctx.Done():
+			t.Fatal("dialer wasn't closed when idle")
+		case <-ticker.C:
+			_ = client.Send(ctx, keepAliveMessage(20*time.Millisecond))
+		}
+	}
+}
+
+func TestDialer_DeadPeer(t *testing.T) {
+	ctx, cancel := testContext(t, 5*time.Second)
+	defer cancel()
+	client, d := startIdleDialer(ctx, t, IdleConfig{DeadPeerTimeout: 100 * time.Millisecond})
+
+	require.NoError(t, client.Send(ctx, keepAliveMessage(10*time.Millisecond)))
+	start := time.Now()
+	select {
+	case <-d.Done():
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	case <-ctx.Done():
+		t.Fatal("dialer wasn't closed when the peer stopped responding")
+	}
+}
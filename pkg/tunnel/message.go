@@ -57,6 +57,10 @@ const (
 
 	KeepAlive
 	Session
+
+	// compressed is a Normal message with a payload that has been compressed using the Compression that
+	// was negotiated for the Stream. It's never seen outside the Stream.
+	compressed
 )
 
 func (c MessageCode) String() string {
@@ -77,6 +81,8 @@ func (c MessageCode) String() string {
 		return "KEEP_ALIVE"
 	case Session:
 		return "SESSION"
+	case compressed:
+		return "COMPRESSED"
 	default:
 		return fmt.Sprintf("** unknown control code: %d **", c)
 	}
@@ -119,7 +125,9 @@ func NewMessage(code MessageCode, payload []byte) Message {
 	return msg{byte(code)}
 }
 
-func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.Duration) Message {
+// StreamInfoMessage returns the initial message that a client Stream sends to its server. The given
+// Compression is offered to the server unless it is NoCompression.
+func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.Duration, c Compression) Message {
 	b := bytes.Buffer{}
 	b.WriteByte(byte(streamInfo))
 
@@ -142,12 +150,21 @@ func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.
 	n = binary.PutUvarint(buf, uint64(len(sb)))
 	b.Write(buf[:n])
 	b.Write(sb)
+	if c != NoCompression {
+		b.WriteByte(byte(c))
+	}
 	return msg(b.Bytes())
 }
 
-func StreamOKMessage() Message {
-	m := makeMessage(streamOK, 4)
+// StreamOKMessage returns the message that a server Stream responds with when it has accepted a
+// StreamInfoMessage. The given Compression is the one accepted by the server.
+func StreamOKMessage(c Compression) Message {
+	m := makeMessage(streamOK, 5)
 	n := binary.PutUvarint(m.Payload(), uint64(Version))
+	if c != NoCompression {
+		m[n+1] = byte(c)
+		n++
+	}
 	return m[:n+1]
 }
 
@@ -165,10 +182,15 @@ func makeMessage(code MessageCode, payloadLength int) msg {
 	return m
 }
 
-// getVersion returns the version that this Message represents.
-func getVersion(m Message) uint16 {
-	v, _ := binary.Uvarint(m.Payload())
-	return uint16(v)
+// getStreamOK returns the version and the accepted Compression that this StreamOK Message represents.
+func getStreamOK(m Message) (uint16, Compression) {
+	pl := m.Payload()
+	v, n := binary.Uvarint(pl)
+	c := NoCompression
+	if n > 0 && len(pl) > n {
+		c = Compression(pl[n])
+	}
+	return uint16(v), c
 }
 
 var errMalformedConnect = errors.New("malformed Connect message")
@@ -212,5 +234,13 @@ func setConnectInfo(m Message, s *stream) error {
 	}
 	pl = pl[n:]
 	s.sessionID = string(pl[:v])
+	pl = pl[v:]
+
+	// An optional compression offer follows. It's accepted if it's supported.
+	if len(pl) > 0 {
+		if c := Compression(pl[0]); c.supported() {
+			s.compression = c
+		}
+	}
 	return nil
 }
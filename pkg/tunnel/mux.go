@@ -0,0 +1,401 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// muxWindowSize is the number of payload bytes that may be in flight on one multiplexed tunnel
+// before its sender must wait for a window update from the receiver.
+const muxWindowSize = 1 << 22
+
+// MuxGRPCStream is the bare minimum needed for reading and writing MuxTunnelMessages on a
+// Manager_MuxTunnelServer or Manager_MuxTunnelClient.
+type MuxGRPCStream interface {
+	Recv() (*rpc.MuxTunnelMessage, error)
+	Send(*rpc.MuxTunnelMessage) error
+}
+
+// muxConn carries many tunnels over one MuxTunnel gRPC stream. Each tunnel is represented by a
+// muxStream, which can be used as the gRPC stream of a Stream.
+type muxConn struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	grpc   MuxGRPCStream
+	sendMu sync.Mutex
+
+	sync.Mutex
+	streams map[uint32]*muxStream
+	lastID  uint32
+	err     error
+
+	// onNew is called with the ID of a tunnel that the peer opened. It is nil at the client side,
+	// where all tunnels are opened locally.
+	onNew func(id uint32) *muxStream
+}
+
+func newMuxConn(ctx context.Context, grpcStream MuxGRPCStream) *muxConn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &muxConn{
+		ctx:     ctx,
+		cancel:  cancel,
+		grpc:    grpcStream,
+		streams: make(map[uint32]*muxStream),
+	}
+}
+
+func (c *muxConn) send(m *rpc.MuxTunnelMessage) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.grpc.Send(m)
+}
+
+// streamCount returns the number of tunnels that are active on this connection.
+func (c *muxConn) streamCount() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.streams)
+}
+
+// broken returns true if the connection no longer can carry tunnels.
+func (c *muxConn) broken() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.err != nil
+}
+
+// newStream opens a new tunnel on a client side connection. The server learns about the tunnel from
+// an initial empty message. The sendMu is held while the ID is allocated, so that the server sees the
+// IDs in ascending order.
+func (c *muxConn) newStream(ctx context.Context) (*muxStream, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.Lock()
+	if c.err != nil {
+		c.Unlock()
+		return nil, c.err
+	}
+	c.lastID++
+	s := c.addStreamLocked(ctx, c.lastID)
+	c.Unlock()
+	if err := c.grpc.Send(&rpc.MuxTunnelMessage{TunnelId: s.id}); err != nil {
+		c.removeStream(s.id)
+		s.cancel()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *muxConn) addStreamLocked(ctx context.Context, id uint32) *muxStream {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &muxStream{
+		conn:     c,
+		id:       id,
+		ctx:      ctx,
+		cancel:   cancel,
+		recvCh:   make(chan struct{}, 1),
+		creditCh: make(chan struct{}, 1),
+		credit:   muxWindowSize,
+	}
+	c.streams[id] = s
+	go func() {
+		select {
+		case <-c.ctx.Done():
+		case <-ctx.Done():
+			s.abandon()
+		}
+	}()
+	return s
+}
+
+// removeStream removes the tunnel with the given ID, and returns true if it was present.
+func (c *muxConn) removeStream(id uint32) bool {
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.streams[id]
+	delete(c.streams, id)
+	return ok
+}
+
+// readLoop dispatches the messages received on the gRPC stream to the tunnels until the stream ends.
+func (c *muxConn) readLoop() {
+	err := c.doReadLoop()
+	if err == nil || errors.Is(err, io.EOF) || c.ctx.Err() != nil {
+		err = net.ErrClosed
+	} else {
+		dlog.Errorf(c.ctx, "!! MUX read failed: %v", err)
+	}
+	c.Lock()
+	c.err = err
+	streams := make([]*muxStream, 0, len(c.streams))
+	for _, s := range c.streams {
+		streams = append(streams, s)
+	}
+	clear(c.streams)
+	c.Unlock()
+	for _, s := range streams {
+		s.endRecv(err)
+		s.cancel()
+	}
+	c.cancel()
+}
+
+func (c *muxConn) doReadLoop() error {
+	for {
+		m, err := c.grpc.Recv()
+		if err != nil {
+			return err
+		}
+		id := m.GetTunnelId()
+		if id == 0 {
+			continue
+		}
+		c.Lock()
+		s, ok := c.streams[id]
+		if !ok && c.onNew != nil && id > c.lastID && !m.GetCancel() {
+			c.lastID = id
+			s = c.onNew(id)
+			ok = true
+		}
+		c.Unlock()
+		if ok {
+			s.dispatch(m)
+		}
+	}
+}
+
+// muxStream is one tunnel of a muxConn. It implements the Client interface, and can be used as
+// the gRPC stream of a Stream.
+type muxStream struct {
+	conn   *muxConn
+	id     uint32
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	queue    [][]byte
+	recvErr  error
+	recvCh   chan struct{} // signalled when queue or recvErr changes
+	consumed int
+	credit   int
+	creditCh chan struct{} // signalled when credit changes
+	closed   bool          // close has been sent
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (s *muxStream) dispatch(m *rpc.MuxTunnelMessage) {
+	s.mu.Lock()
+	if pl := m.GetPayload(); len(pl) > 0 {
+		s.queue = append(s.queue, pl)
+		signal(s.recvCh)
+	}
+	if wu := m.GetWindowUpdate(); wu > 0 {
+		s.credit += int(wu)
+		signal(s.creditCh)
+	}
+	s.mu.Unlock()
+	switch {
+	case m.GetCancel():
+		s.endRecv(context.Canceled)
+		s.cancel()
+	case m.GetClose():
+		if code := codes.Code(m.GetErrorCode()); code != codes.OK {
+			s.endRecv(status.Error(code, m.GetErrorMessage()))
+		} else {
+			s.endRecv(io.EOF)
+		}
+		if s.conn.onNew == nil {
+			// The server has ended the tunnel.
+			s.conn.removeStream(s.id)
+			s.cancel()
+		}
+	}
+}
+
+// endRecv makes Recv return the given error once all queued messages have been consumed.
+func (s *muxStream) endRecv(err error) {
+	s.mu.Lock()
+	if s.recvErr == nil {
+		s.recvErr = err
+	}
+	s.mu.Unlock()
+	signal(s.recvCh)
+}
+
+// abandon is called when the context of the tunnel is cancelled. Unless the tunnel has ended already,
+// the client tells the server to cancel its end of the tunnel, and the server tells the client that the
+// tunnel has ended.
+func (s *muxStream) abandon() {
+	s.endRecv(s.ctx.Err())
+	if !s.conn.removeStream(s.id) {
+		return
+	}
+	if s.conn.onNew == nil {
+		_ = s.conn.send(&rpc.MuxTunnelMessage{TunnelId: s.id, Cancel: true})
+	} else {
+		_ = s.CloseSend()
+	}
+}
+
+func (s *muxStream) Recv() (*rpc.TunnelMessage, error) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			pl := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.consumed += len(pl)
+			var wu int
+			if s.consumed >= muxWindowSize/2 && s.recvErr == nil {
+				wu = s.consumed
+				s.consumed = 0
+			}
+			s.mu.Unlock()
+			if wu > 0 {
+				_ = s.conn.send(&rpc.MuxTunnelMessage{TunnelId: s.id, WindowUpdate: uint32(wu)})
+			}
+			return &rpc.TunnelMessage{Payload: pl}, nil
+		}
+		err := s.recvErr
+		s.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-s.recvCh:
+		case <-s.ctx.Done():
+			s.endRecv(s.ctx.Err())
+		}
+	}
+}
+
+func (s *muxStream) Send(m *rpc.TunnelMessage) error {
+	pl := m.GetPayload()
+	for {
+		s.mu.Lock()
+		switch {
+		case s.closed:
+			s.mu.Unlock()
+			return net.ErrClosed
+		case s.recvErr != nil && s.conn.onNew == nil:
+			// The tunnel has ended.
+			s.mu.Unlock()
+			return io.EOF
+		case s.credit > 0:
+			// A message that is larger than the remaining credit is permitted, so that messages that are
+			// larger than the window can be sent.
+			s.credit -= len(pl)
+			s.mu.Unlock()
+			return s.conn.send(&rpc.MuxTunnelMessage{TunnelId: s.id, Payload: pl})
+		}
+		s.mu.Unlock()
+		select {
+		case <-s.creditCh:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+// CloseSend tells the peer that no more messages will be sent. At the client side, this is a half close.
+// At the server side, it ends the tunnel.
+func (s *muxStream) CloseSend() error {
+	return s.sendClose(&rpc.MuxTunnelMessage{TunnelId: s.id, Close: true})
+}
+
+func (s *muxStream) sendClose(m *rpc.MuxTunnelMessage) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.closed = true
+	s.mu.Unlock()
+	if closed {
+		return nil
+	}
+	return s.conn.send(m)
+}
+
+// end is called at the server side when the handler of the tunnel has returned with the given error.
+func (s *muxStream) end(err error) {
+	s.conn.removeStream(s.id)
+	m := &rpc.MuxTunnelMessage{TunnelId: s.id, Close: true}
+	if err != nil {
+		st := status.Convert(err)
+		m.ErrorCode = int32(st.Code())
+		m.ErrorMessage = st.Message()
+	}
+	_ = s.sendClose(m)
+	s.cancel()
+}
+
+func (s *muxStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *muxStream) Header() (metadata.MD, error) {
+	return nil, nil
+}
+
+func (s *muxStream) Trailer() metadata.MD {
+	return nil
+}
+
+func (s *muxStream) SendMsg(m any) error {
+	tm, ok := m.(*rpc.TunnelMessage)
+	if !ok {
+		return errors.New("muxStream can only send TunnelMessages")
+	}
+	return s.Send(tm)
+}
+
+func (s *muxStream) RecvMsg(m any) error {
+	tm, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	switch m := m.(type) {
+	case *pooledTunnelMessage:
+		m.Payload = tm.Payload
+	case *rpc.TunnelMessage:
+		m.Payload = tm.Payload
+	default:
+		return errors.New("muxStream can only receive TunnelMessages")
+	}
+	return nil
+}
+
+// ServeMux serves the server side of a MuxTunnel stream. The given handler is called in a new
+// goroutine for each tunnel that the client opens, and the tunnel ends when the handler returns. An
+// error returned by the handler is returned to the client, just like an error returned from a Tunnel
+// call. ServeMux returns when the stream ends.
+func ServeMux(ctx context.Context, grpcStream MuxGRPCStream, handler func(context.Context, GRPCStream) error) error {
+	c := newMuxConn(ctx, grpcStream)
+	c.onNew = func(id uint32) *muxStream {
+		s := c.addStreamLocked(c.ctx, id)
+		go func() {
+			s.end(handler(s.ctx, s))
+		}()
+		return s
+	}
+	// Tell the client that multiplexing is supported.
+	if err := c.send(&rpc.MuxTunnelMessage{}); err != nil {
+		return err
+	}
+	c.readLoop()
+	return nil
+}
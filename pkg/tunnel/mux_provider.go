@@ -0,0 +1,147 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+const (
+	// maxMuxConns is the maximum number of MuxTunnel streams that a muxProvider opens.
+	maxMuxConns = 4
+
+	// muxConnTunnels is the number of tunnels that a MuxTunnel stream carries before the muxProvider
+	// opens another MuxTunnel stream.
+	muxConnTunnels = 64
+)
+
+// MuxClient is the client side of a MuxTunnel stream.
+type MuxClient interface {
+	MuxGRPCStream
+	CloseSend() error
+}
+
+type muxProvider struct {
+	ctx      context.Context
+	fallback Provider
+	open     func(context.Context) (MuxClient, error)
+
+	sync.Mutex
+	conns       []*muxConn
+	unsupported bool
+}
+
+var errMuxUnsupported = errors.New("MuxTunnel is not supported")
+
+// NewMuxProvider returns a Provider that carries the tunnels over a bounded set of MuxTunnel streams
+// that are opened using the given function. The streams end when the given context is cancelled.
+// The fallback Provider is used when the peer doesn't support MuxTunnel.
+func NewMuxProvider(ctx context.Context, fallback Provider, open func(context.Context) (MuxClient, error)) Provider {
+	return &muxProvider{ctx: ctx, fallback: fallback, open: open}
+}
+
+// ManagerMuxProvider returns a Provider that carries the tunnels to the traffic-manager over a bounded
+// set of MuxTunnel streams.
+func ManagerMuxProvider(ctx context.Context, m rpc.ManagerClient) Provider {
+	return NewMuxProvider(ctx, ManagerProvider(m), func(ctx context.Context) (MuxClient, error) {
+		return m.MuxTunnel(ctx)
+	})
+}
+
+// ManagerProxyMuxProvider returns a Provider that carries the tunnels to the traffic-manager proxy over a
+// bounded set of MuxTunnel streams.
+func ManagerProxyMuxProvider(ctx context.Context, m connector.ManagerProxyClient) Provider {
+	return NewMuxProvider(ctx, ManagerProxyProvider(m), func(ctx context.Context) (MuxClient, error) {
+		return m.MuxTunnel(ctx)
+	})
+}
+
+func (p *muxProvider) Tunnel(ctx context.Context, opts ...grpc.CallOption) (Client, error) {
+	c, err := p.conn()
+	if err != nil {
+		if errors.Is(err, errMuxUnsupported) {
+			return p.fallback.Tunnel(ctx, opts...)
+		}
+		return nil, err
+	}
+	return c.newStream(ctx)
+}
+
+// conn returns the MuxTunnel stream that carries the fewest tunnels. A new stream is opened when all
+// streams carry muxConnTunnels or more, unless maxMuxConns streams are open already.
+func (p *muxProvider) conn() (*muxConn, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.unsupported {
+		return nil, errMuxUnsupported
+	}
+	var best *muxConn
+	bestCount := 0
+	live := p.conns[:0]
+	for _, c := range p.conns {
+		if c.broken() {
+			continue
+		}
+		live = append(live, c)
+		if n := c.streamCount(); best == nil || n < bestCount {
+			best, bestCount = c, n
+		}
+	}
+	clear(p.conns[len(live):])
+	p.conns = live
+	if best != nil && (bestCount < muxConnTunnels || len(p.conns) >= maxMuxConns) {
+		return best, nil
+	}
+	c, err := p.openConn()
+	if err != nil {
+		if best != nil {
+			dlog.Warnf(p.ctx, "unable to open another MuxTunnel stream: %v", err)
+			return best, nil
+		}
+		return nil, err
+	}
+	p.conns = append(p.conns, c)
+	return c, nil
+}
+
+func (p *muxProvider) openConn() (*muxConn, error) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	mc, err := p.open(ctx)
+	if err != nil {
+		cancel()
+		return nil, p.checkUnsupported(err)
+	}
+	// The server sends an initial message when it supports MuxTunnel.
+	if _, err = mc.Recv(); err != nil {
+		cancel()
+		return nil, p.checkUnsupported(err)
+	}
+	c := newMuxConn(ctx, mc)
+	go func() {
+		defer cancel()
+		c.readLoop()
+	}()
+	go func() {
+		<-c.ctx.Done()
+		_ = mc.CloseSend()
+	}()
+	return c, nil
+}
+
+func (p *muxProvider) checkUnsupported(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		dlog.Info(p.ctx, "MuxTunnel is not supported by the peer. Using one Tunnel per connection")
+		p.unsupported = true
+		return errMuxUnsupported
+	}
+	return fmt.Errorf("call to MuxTunnel() failed: %w", err)
+}
@@ -0,0 +1,247 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// muxPipeEnd is one end of an in-memory MuxTunnel stream.
+type muxPipeEnd struct {
+	in  <-chan *rpc.MuxTunnelMessage
+	out chan<- *rpc.MuxTunnelMessage
+
+	sync.Mutex
+	closed bool
+}
+
+func newMuxPipe() (client, server *muxPipeEnd) {
+	c2s := make(chan *rpc.MuxTunnelMessage, 100)
+	s2c := make(chan *rpc.MuxTunnelMessage, 100)
+	return &muxPipeEnd{in: s2c, out: c2s}, &muxPipeEnd{in: c2s, out: s2c}
+}
+
+func (p *muxPipeEnd) Send(m *rpc.MuxTunnelMessage) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return io.EOF
+	}
+	p.out <- m
+	return nil
+}
+
+func (p *muxPipeEnd) Recv() (*rpc.MuxTunnelMessage, error) {
+	m, ok := <-p.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return m, nil
+}
+
+func (p *muxPipeEnd) CloseSend() error {
+	p.Lock()
+	defer p.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.out)
+	}
+	return nil
+}
+
+type unimplementedMux struct{}
+
+func (unimplementedMux) Send(*rpc.MuxTunnelMessage) error { return nil }
+
+func (unimplementedMux) Recv() (*rpc.MuxTunnelMessage, error) {
+	return nil, status.Error(codes.Unimplemented, "unknown method MuxTunnel")
+}
+
+func (unimplementedMux) CloseSend() error { return nil }
+
+type fallbackProvider struct {
+	calls int
+}
+
+func (f *fallbackProvider) Tunnel(context.Context, ...grpc.CallOption) (Client, error) {
+	f.calls++
+	return nil, errors.New("fallback")
+}
+
+func echoHandler(_ context.Context, s GRPCStream) error {
+	for {
+		m, err := s.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err = s.Send(m); err != nil {
+			return err
+		}
+	}
+}
+
+func startMux(ctx context.Context, t *testing.T, handler func(context.Context, GRPCStream) error) (Provider, *int) {
+	opened := 0
+	p := NewMuxProvider(ctx, &fallbackProvider{}, func(ctx context.Context) (MuxClient, error) {
+		opened++
+		client, server := newMuxPipe()
+		go func() {
+			assert.NoError(t, ServeMux(ctx, server, handler))
+		}()
+		return client, nil
+	})
+	return p, &opened
+}
+
+func TestMux_Echo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, opened := startMux(ctx, t, echoHandler)
+
+	const tunnels = 10
+	clients := make([]Client, tunnels)
+	for i := range clients {
+		c, err := p.Tunnel(ctx)
+		require.NoError(t, err)
+		clients[i] = c
+	}
+	assert.Equal(t, 1, *opened)
+
+	wg := sync.WaitGroup{}
+	wg.Add(tunnels)
+	for i, c := range clients {
+		go func() {
+			defer wg.Done()
+			payload := []byte{byte(Normal), byte(i)}
+			for range 100 {
+				assert.NoError(t, c.Send(&rpc.TunnelMessage{Payload: payload}))
+				m, err := c.Recv()
+				if assert.NoError(t, err) {
+					assert.Equal(t, payload, m.Payload)
+				}
+			}
+			assert.NoError(t, c.CloseSend())
+			_, err := c.Recv()
+			assert.ErrorIs(t, err, io.EOF)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMux_NewConnWhenFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, opened := startMux(ctx, t, echoHandler)
+	for range muxConnTunnels + 1 {
+		_, err := p.Tunnel(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, *opened)
+}
+
+func TestMux_FlowControl(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The tunnel that receives large messages stops reading after the first one.
+	blocked := make(chan struct{})
+	p, _ := startMux(ctx, t, func(ctx context.Context, s GRPCStream) error {
+		m, err := s.Recv()
+		if err != nil {
+			return err
+		}
+		if len(m.Payload) > 2 {
+			<-ctx.Done()
+			close(blocked)
+			return nil
+		}
+		if err = s.Send(m); err != nil {
+			return err
+		}
+		return echoHandler(ctx, s)
+	})
+
+	slow, err := p.Tunnel(ctx)
+	require.NoError(t, err)
+	sent := make(chan int, 1)
+	go func() {
+		n := 0
+		payload := make([]byte, 0x10000)
+		for slow.Send(&rpc.TunnelMessage{Payload: payload}) == nil {
+			n += len(payload)
+		}
+		sent <- n
+	}()
+
+	// The second tunnel isn't blocked by the first.
+	fast, err := p.Tunnel(ctx)
+	require.NoError(t, err)
+	for range 10 {
+		require.NoError(t, fast.Send(&rpc.TunnelMessage{Payload: []byte{byte(Normal), 1}}))
+		_, err = fast.Recv()
+		require.NoError(t, err)
+	}
+
+	// The sender on the first tunnel waits for a window update when the window is exhausted.
+	select {
+	case n := <-sent:
+		t.Fatalf("send didn't block after %d bytes", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Cancelling the tunnel at the client side cancels it at the server side.
+	slow.(*muxStream).cancel()
+	select {
+	case n := <-sent:
+		assert.Equal(t, muxWindowSize, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("send didn't return when the tunnel was cancelled")
+	}
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server side tunnel wasn't cancelled")
+	}
+}
+
+func TestMux_Error(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, _ := startMux(ctx, t, func(context.Context, GRPCStream) error {
+		return status.Error(codes.ResourceExhausted, "too many dials")
+	})
+	c, err := p.Tunnel(ctx)
+	require.NoError(t, err)
+	require.NoError(t, c.Send(&rpc.TunnelMessage{Payload: []byte{byte(Normal)}}))
+	_, err = c.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, "too many dials", status.Convert(err).Message())
+}
+
+func TestMux_Unsupported(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fb := &fallbackProvider{}
+	p := NewMuxProvider(ctx, fb, func(context.Context) (MuxClient, error) {
+		return unimplementedMux{}, nil
+	})
+	for range 2 {
+		_, err := p.Tunnel(ctx)
+		assert.EqualError(t, err, "fallback")
+	}
+	assert.Equal(t, 2, fb.calls)
+}
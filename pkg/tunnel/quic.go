@@ -0,0 +1,296 @@
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// QUICProtocol is the ALPN protocol of the QUIC connections that carry tunnels between a client and the
+// traffic-manager.
+const QUICProtocol = "telepresence-tunnel"
+
+// maxQUICFrameSize is the maximum size of the payload of a TunnelMessage that is received on a QUIC stream.
+const maxQUICFrameSize = 1 << 24
+
+// quicHandshakeTimeout is the time to wait for the handshake that authenticates a QUIC connection.
+const quicHandshakeTimeout = 10 * time.Second
+
+// quicStream is a Client that exchanges TunnelMessages over a QUIC stream. Each message is written as the
+// uvarint encoded length of its payload followed by the payload.
+type quicStream struct {
+	ctx context.Context
+	qs  quic.Stream
+	r   *bufio.Reader
+	wmu sync.Mutex
+}
+
+// NewQUICStream returns a Client that exchanges TunnelMessages over the given QUIC stream. The stream is
+// cancelled when the given context is done. The Client can also be used as the GRPCStream of a server
+// Stream.
+func NewQUICStream(ctx context.Context, qs quic.Stream) Client {
+	context.AfterFunc(ctx, func() {
+		qs.CancelRead(0)
+		qs.CancelWrite(0)
+	})
+	return newQUICStream(ctx, qs)
+}
+
+func newQUICStream(ctx context.Context, qs quic.Stream) *quicStream {
+	return &quicStream{ctx: ctx, qs: qs, r: bufio.NewReader(qs)}
+}
+
+func (s *quicStream) Send(m *rpc.TunnelMessage) error {
+	pl := m.GetPayload()
+	var hdr [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(hdr[:], uint64(len(pl)))
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if _, err := s.qs.Write(hdr[:n]); err != nil {
+		return err
+	}
+	_, err := s.qs.Write(pl)
+	return err
+}
+
+// readFrame reads the next frame into a buffer that is obtained from the given function.
+func (s *quicStream) readFrame(alloc func(int) []byte) ([]byte, error) {
+	size, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxQUICFrameSize {
+		return nil, fmt.Errorf("QUIC frame of %d bytes exceeds the maximum of %d bytes", size, maxQUICFrameSize)
+	}
+	buf := alloc(int(size))
+	if _, err = io.ReadFull(s.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (s *quicStream) Recv() (*rpc.TunnelMessage, error) {
+	pl, err := s.readFrame(func(n int) []byte { return make([]byte, n) })
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.TunnelMessage{Payload: pl}, nil
+}
+
+func (s *quicStream) CloseSend() error {
+	return s.qs.Close()
+}
+
+func (s *quicStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *quicStream) Header() (metadata.MD, error) {
+	return nil, nil
+}
+
+func (s *quicStream) Trailer() metadata.MD {
+	return nil
+}
+
+func (s *quicStream) SendMsg(m any) error {
+	tm, ok := m.(*rpc.TunnelMessage)
+	if !ok {
+		return errors.New("quicStream can only send TunnelMessages")
+	}
+	return s.Send(tm)
+}
+
+// RecvMsg receives the payload of a pooledTunnelMessage directly into a pooled buffer.
+func (s *quicStream) RecvMsg(m any) error {
+	switch m := m.(type) {
+	case *pooledTunnelMessage:
+		pl, err := s.readFrame(func(n int) []byte {
+			m.pm = getPooledMsg(n)
+			return m.pm.msg
+		})
+		if err != nil {
+			if m.pm != nil {
+				m.pm.release()
+				m.pm = nil
+			}
+			return err
+		}
+		m.Payload = pl
+	case *rpc.TunnelMessage:
+		tm, err := s.Recv()
+		if err != nil {
+			return err
+		}
+		m.Payload = tm.Payload
+	default:
+		return errors.New("quicStream can only receive TunnelMessages")
+	}
+	return nil
+}
+
+// GenerateQUICCertificate generates the self-signed certificate of a QUIC endpoint, and returns it
+// together with its SHA-256 fingerprint. The clients verify the certificate using the fingerprint,
+// which they obtain from the traffic-manager using gRPC.
+func GenerateQUICCertificate() (tls.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	now := time.Now()
+	tpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "traffic-manager"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	fp := sha256.Sum256(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, fp[:], nil
+}
+
+// QUICServerConfig returns the TLS configuration of a QUIC endpoint that uses the given certificate.
+func QUICServerConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{QUICProtocol},
+		MinVersion:   tls.VersionTLS13,
+	}
+}
+
+// DialQUIC dials the QUIC endpoint of a traffic-manager, verifies that its certificate has the given
+// SHA-256 fingerprint, and authenticates the connection using the given token.
+func DialQUIC(ctx context.Context, endpoint string, fingerprint, token []byte) (quic.Connection, error) {
+	tc := &tls.Config{
+		NextProtos: []string{QUICProtocol},
+		MinVersion: tls.VersionTLS13,
+		// The certificate is self-signed, so it's verified using its fingerprint instead.
+		InsecureSkipVerify: true, //nolint:gosec // verified by VerifyPeerCertificate
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) > 0 {
+				if fp := sha256.Sum256(rawCerts[0]); bytes.Equal(fp[:], fingerprint) {
+					return nil
+				}
+			}
+			return errors.New("the certificate of the QUIC endpoint doesn't match its fingerprint")
+		},
+	}
+	conn, err := quic.DialAddr(ctx, endpoint, tc, &quic.Config{KeepAlivePeriod: 15 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err = quicHandshake(ctx, conn, token); err != nil {
+		_ = conn.CloseWithError(0, "")
+		return nil, fmt.Errorf("QUIC handshake failed: %w", err)
+	}
+	return conn, nil
+}
+
+// quicHandshake sends the token that authenticates the connection on the first stream of the connection,
+// and waits for the empty message that confirms it.
+func quicHandshake(ctx context.Context, conn quic.Connection, token []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, quicHandshakeTimeout)
+	defer cancel()
+	qs, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return err
+	}
+	// A cancelled context would reset the stream, so a deadline is used instead.
+	_ = qs.SetDeadline(time.Now().Add(quicHandshakeTimeout))
+	hs := newQUICStream(ctx, qs)
+	if err = hs.Send(&rpc.TunnelMessage{Payload: token}); err != nil {
+		return err
+	}
+	if _, err = hs.Recv(); err != nil {
+		return err
+	}
+	return hs.CloseSend()
+}
+
+// AcceptQUICHandshake reads the token that authenticates the given connection, and calls the given
+// verify function with it. The handshake is confirmed when the function returns true. An error is
+// returned when the handshake fails or when the token isn't verified.
+func AcceptQUICHandshake(ctx context.Context, conn quic.Connection, verify func([]byte) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, quicHandshakeTimeout)
+	defer cancel()
+	qs, err := conn.AcceptStream(ctx)
+	if err != nil {
+		return err
+	}
+	_ = qs.SetDeadline(time.Now().Add(quicHandshakeTimeout))
+	hs := newQUICStream(ctx, qs)
+	tm, err := hs.Recv()
+	if err != nil {
+		return err
+	}
+	if !verify(tm.Payload) {
+		return errors.New("invalid token")
+	}
+	if err = hs.Send(&rpc.TunnelMessage{}); err != nil {
+		return err
+	}
+	return hs.CloseSend()
+}
+
+type quicProvider struct {
+	ctx      context.Context
+	conn     quic.Connection
+	fallback Provider
+	failed   sync.Once
+}
+
+// NewQUICProvider returns a Provider that carries each tunnel on a stream of the given QUIC connection. The
+// fallback Provider is used once the connection has failed, and for tunnels that are opened with call
+// options, because those only apply to gRPC.
+func NewQUICProvider(ctx context.Context, conn quic.Connection, fallback Provider) Provider {
+	return &quicProvider{ctx: ctx, conn: conn, fallback: fallback}
+}
+
+func (p *quicProvider) Tunnel(ctx context.Context, opts ...grpc.CallOption) (Client, error) {
+	if len(opts) == 0 && p.conn.Context().Err() == nil {
+		qs, err := p.conn.OpenStreamSync(ctx)
+		if err == nil {
+			return NewQUICStream(ctx, qs), nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		p.failed.Do(func() {
+			dlog.Errorf(p.ctx, "QUIC connection to the traffic-manager failed. Using gRPC tunnels: %v", err)
+		})
+	}
+	return p.fallback.Tunnel(ctx, opts...)
+}
@@ -0,0 +1,117 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// startQUIC starts a QUIC endpoint that accepts connections that are authenticated using the given token,
+// and serves each stream using the given handler. It returns the address of the endpoint and the
+// fingerprint of its certificate.
+func startQUIC(ctx context.Context, t *testing.T, token string, handler func(context.Context, GRPCStream) error) (string, []byte) {
+	cert, fp, err := GenerateQUICCertificate()
+	require.NoError(t, err)
+	ln, err := quic.ListenAddr("127.0.0.1:0", QUICServerConfig(cert), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept(ctx)
+			if err != nil {
+				return
+			}
+			go func() {
+				if err := AcceptQUICHandshake(ctx, conn, func(b []byte) bool { return string(b) == token }); err != nil {
+					_ = conn.CloseWithError(1, err.Error())
+					return
+				}
+				for {
+					qs, err := conn.AcceptStream(ctx)
+					if err != nil {
+						return
+					}
+					go func() {
+						s := NewQUICStream(ctx, qs)
+						assert.NoError(t, handler(ctx, s))
+						_ = s.(GRPCClientStream).CloseSend()
+					}()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), fp
+}
+
+func TestQUIC_Echo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, fp := startQUIC(ctx, t, "secret", echoHandler)
+	conn, err := DialQUIC(ctx, addr, fp, []byte("secret"))
+	require.NoError(t, err)
+	fallback := &fallbackProvider{}
+	p := NewQUICProvider(ctx, conn, fallback)
+
+	const tunnels = 10
+	wg := sync.WaitGroup{}
+	wg.Add(tunnels)
+	for i := range tunnels {
+		c, err := p.Tunnel(ctx)
+		require.NoError(t, err)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				payload := make([]byte, 1+j*100)
+				payload[0] = byte(Normal)
+				payload[len(payload)-1] = byte(i)
+				assert.NoError(t, c.Send(&rpc.TunnelMessage{Payload: payload}))
+
+				// Every other message is received into a pooled buffer, like a Stream does.
+				if j%2 == 0 {
+					m, err := c.Recv()
+					if assert.NoError(t, err) {
+						assert.Equal(t, payload, m.Payload)
+					}
+				} else {
+					pt := pooledTunnelMessage{}
+					if assert.NoError(t, c.RecvMsg(&pt)) {
+						assert.Equal(t, payload, pt.Payload)
+						pt.pm.release()
+					}
+				}
+			}
+			assert.NoError(t, c.CloseSend())
+			_, err := c.Recv()
+			assert.ErrorIs(t, err, io.EOF)
+		}()
+	}
+	wg.Wait()
+	assert.Zero(t, fallback.calls)
+
+	// The fallback is used once the connection has failed.
+	require.NoError(t, conn.CloseWithError(0, ""))
+	_, err = p.Tunnel(ctx)
+	assert.EqualError(t, err, "fallback")
+	assert.Equal(t, 1, fallback.calls)
+}
+
+func TestDialQUIC_rejected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, fp := startQUIC(ctx, t, "secret", echoHandler)
+
+	_, err := DialQUIC(ctx, addr, fp, []byte("guess"))
+	assert.Error(t, err, "invalid token")
+
+	wrong := append([]byte{}, fp...)
+	wrong[0]++
+	_, err = DialQUIC(ctx, addr, wrong, []byte("secret"))
+	assert.ErrorContains(t, err, "fingerprint")
+}
@@ -18,7 +18,7 @@ func NewServerStream(ctx context.Context, grpcStream GRPCStream) (Stream, error)
 	if err = setConnectInfo(m, s); err != nil {
 		return nil, fmt.Errorf("failed to parse StreamInfo message: %w", err)
 	}
-	if err = s.Send(ctx, StreamOKMessage()); err != nil {
+	if err = s.Send(ctx, StreamOKMessage(s.compression)); err != nil {
 		return nil, err
 	}
 	return s, nil
@@ -155,10 +155,10 @@ func WriteLoop(
 					break
 				}
 
-				err := s.Send(ctx, m)
 				if m != nil && p != nil {
 					p.Increment(uint64(len(m.Payload())))
 				}
+				err := s.Send(ctx, m)
 
 				switch {
 				case err == nil:
@@ -185,6 +185,7 @@ type stream struct {
 	syncRatio        uint32 // send and check sync after each syncRatio message
 	ackWindow        uint32 // maximum permitted difference between sent and received ack
 	peerVersion      uint16
+	compression      Compression // negotiated compression of Normal messages
 }
 
 func newStream(tag string, grpcStream GRPCStream) stream {
@@ -215,12 +216,39 @@ func (s *stream) SessionID() string {
 	return s.sessionID
 }
 
-func (s *stream) Receive(ctx context.Context) (Message, error) {
+// msgReceiver is implemented by the gRPC streams, but not by all GRPCStream implementations.
+type msgReceiver interface {
+	RecvMsg(m any) error
+}
+
+func (s *stream) recv() (Message, error) {
+	if mr, ok := s.grpcStream.(msgReceiver); ok {
+		pt := pooledTunnelMessage{}
+		if err := mr.RecvMsg(&pt); err != nil {
+			return nil, err
+		}
+		if pt.pm == nil {
+			return msg(pt.Payload), nil
+		}
+		return pt.pm, nil
+	}
 	cm, err := s.grpcStream.Recv()
 	if err != nil {
 		return nil, err
 	}
-	m := msg(cm.Payload)
+	return msg(cm.Payload), nil
+}
+
+func (s *stream) Receive(ctx context.Context) (Message, error) {
+	m, err := s.recv()
+	if err != nil {
+		return nil, err
+	}
+	if m.Code() == compressed {
+		if m, err = decompressMessage(s.compression, m); err != nil {
+			return nil, fmt.Errorf("failed to decompress message: %w", err)
+		}
+	}
 	switch m.Code() {
 	case closeSend:
 		dlog.Tracef(ctx, "<- %s %s, close send", s.tag, s.id)
@@ -233,14 +261,27 @@ func (s *stream) Receive(ctx context.Context) (Message, error) {
 	return m, nil
 }
 
+// Send sends the given message. A pooled message is released once it has been sent, because the gRPC
+// stream has then encoded it. The payload of a Normal message is compressed when the stream uses
+// compression and the payload compresses well enough.
 func (s *stream) Send(ctx context.Context, m Message) error {
-	if err := s.grpcStream.Send(m.TunnelMessage()); err != nil {
+	if s.compression != NoCompression && m.Code() == Normal {
+		if cm := compressMessage(s.compression, m); cm != nil {
+			releaseMessage(m)
+			m = cm
+		}
+	}
+	err := s.grpcStream.Send(m.TunnelMessage())
+	if err == nil {
+		dlog.Tracef(ctx, "-> %s %s, %s", s.tag, s.id, m)
+	}
+	releaseMessage(m)
+	if err != nil {
 		if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
 			dlog.Errorf(ctx, "!! %s %s, Send failed: %v", s.tag, s.id, err)
 		}
 		return err
 	}
-	dlog.Tracef(ctx, "-> %s %s, %s", s.tag, s.id, m)
 	return nil
 }
 
@@ -54,6 +54,8 @@ func (t *uni) recv() (*manager.TunnelMessage, error) {
 }
 
 func (t *uni) send(msg *manager.TunnelMessage) error {
+	// A gRPC stream has encoded the message when Send returns, so the sender is free to reuse its payload.
+	msg = &manager.TunnelMessage{Payload: bytes.Clone(msg.Payload)}
 	select {
 	case <-t.done:
 		return context.Canceled
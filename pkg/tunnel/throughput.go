@@ -0,0 +1,104 @@
+package tunnel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Throughput measures the number of payload bytes that are sent and received by a set of streams during a
+// sliding window of time. The counters are sampled periodically by Run, so the window is accurate to within
+// the sampling interval.
+type Throughput struct {
+	sent     *CounterProbe
+	received *CounterProbe
+	window   time.Duration
+
+	mu      sync.Mutex
+	samples []throughputSample
+}
+
+type throughputSample struct {
+	time     time.Time
+	sent     uint64
+	received uint64
+}
+
+// NewThroughput returns a Throughput that measures the bytes of the given window.
+func NewThroughput(window time.Duration) *Throughput {
+	return &Throughput{
+		sent:     NewCounterProbe("SentBytes"),
+		received: NewCounterProbe("ReceivedBytes"),
+		window:   window,
+	}
+}
+
+// Stream returns a Stream that counts the payload bytes of the Normal messages that are sent and received
+// using the given stream.
+func (t *Throughput) Stream(s Stream) Stream {
+	return &countingStream{Stream: s, t: t}
+}
+
+// Run samples the counters using the given interval until the context is cancelled.
+func (t *Throughput) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	t.sample(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			t.sample(now)
+		}
+	}
+}
+
+func (t *Throughput) sample(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = append(t.samples, throughputSample{time: now, sent: t.sent.GetValue(), received: t.received.GetValue()})
+
+	// The newest sample that is at least as old as the window is retained, because it's the base that the
+	// current values are compared with.
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i+1 < len(t.samples) && !t.samples[i+1].time.After(cutoff) {
+		i++
+	}
+	t.samples = t.samples[i:]
+}
+
+// LastWindow returns the number of bytes that were sent and received during the window.
+func (t *Throughput) LastWindow() (sent, received uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sent, received = t.sent.GetValue(), t.received.GetValue()
+	if len(t.samples) > 0 {
+		base := t.samples[0]
+		sent -= base.sent
+		received -= base.received
+	}
+	return sent, received
+}
+
+type countingStream struct {
+	Stream
+	t *Throughput
+}
+
+func (s *countingStream) Receive(ctx context.Context) (Message, error) {
+	m, err := s.Stream.Receive(ctx)
+	if err == nil && m.Code() == Normal {
+		s.t.received.Increment(uint64(len(m.Payload())))
+	}
+	return m, err
+}
+
+func (s *countingStream) Send(ctx context.Context, m Message) error {
+	// The message might be released when it has been sent, so it must be inspected first.
+	if m.Code() == Normal {
+		s.t.sent.Increment(uint64(len(m.Payload())))
+	}
+	return s.Stream.Send(ctx, m)
+}
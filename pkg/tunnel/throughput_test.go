@@ -0,0 +1,87 @@
+package tunnel
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+func TestThroughput(t *testing.T) {
+	ctx := context.Background()
+	id := NewConnID(ipproto.TCP, net.IP{127, 0, 0, 1}, net.IP{127, 0, 0, 2}, 5000, 8080)
+	a, b := NewPipe(id, "session")
+	tp := NewThroughput(time.Minute)
+	a = tp.Stream(a)
+
+	start := time.Now()
+	tp.sample(start)
+	for _, m := range []Message{NewMessage(Normal, make([]byte, 100)), NewMessage(KeepAlive, nil)} {
+		require.NoError(t, a.Send(ctx, m))
+		_, err := b.Receive(ctx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, b.Send(ctx, NewMessage(Normal, make([]byte, 40))))
+	_, err := a.Receive(ctx)
+	require.NoError(t, err)
+
+	sent, received := tp.LastWindow()
+	assert.Equal(t, uint64(100), sent)
+	assert.Equal(t, uint64(40), received)
+
+	// Bytes counted before the newest sample that is older than the window are excluded.
+	tp.sample(start.Add(30 * time.Second))
+	require.NoError(t, a.Send(ctx, NewMessage(Normal, make([]byte, 10))))
+	_, err = b.Receive(ctx)
+	require.NoError(t, err)
+	tp.sample(start.Add(90 * time.Second))
+	sent, received = tp.LastWindow()
+	assert.Equal(t, uint64(10), sent)
+	assert.Equal(t, uint64(0), received)
+}
+
+// releasingStream is a Stream that releases each message that it sends, and then reuses its buffer, like
+// a Stream that sends the message on a gRPC stream would.
+type releasingStream struct {
+	Stream
+}
+
+func (s releasingStream) Send(_ context.Context, m Message) error {
+	releaseMessage(m)
+	r := getPooledMsg(1)
+	r.msg[0] = byte(KeepAlive)
+	r.release()
+	return nil
+}
+
+func TestThroughput_pooledMessages(t *testing.T) {
+	ctx := context.Background()
+	id := NewConnID(ipproto.TCP, net.IP{127, 0, 0, 1}, net.IP{127, 0, 0, 2}, 5000, 8080)
+	tp := NewThroughput(time.Minute)
+	tp.sample(time.Now())
+
+	const senders = 8
+	const messages = 100
+	payload := make([]byte, 100)
+	wg := sync.WaitGroup{}
+	wg.Add(senders)
+	for range senders {
+		go func() {
+			defer wg.Done()
+			a, _ := NewPipe(id, "session")
+			s := tp.Stream(releasingStream{Stream: a})
+			for range messages {
+				assert.NoError(t, s.Send(ctx, newPooledMessage(Normal, payload)))
+			}
+		}()
+	}
+	wg.Wait()
+	sent, _ := tp.LastWindow()
+	assert.Equal(t, uint64(senders*messages*len(payload)), sent)
+}
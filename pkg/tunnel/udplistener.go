@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
 )
 
 // The dialer takes care of dispatching messages between gRPC and UDP connections.
@@ -29,7 +30,7 @@ func NewUDPListener(conn *net.UDPConn, targetAddr *net.UDPAddr, creator func(con
 		state = connecting
 	}
 	return &udpListener{
-		TimedHandler: NewTimedHandler("", udpConnTTL, nil),
+		TimedHandler: NewTimedHandler("", 0, nil),
 		conn:         conn,
 		connected:    state,
 		done:         make(chan struct{}),
@@ -40,6 +41,7 @@ func NewUDPListener(conn *net.UDPConn, targetAddr *net.UDPAddr, creator func(con
 }
 
 func (h *udpListener) Start(ctx context.Context) {
+	h.SetTTL(GetIdleConfig(ctx, ipproto.UDP).Timeout)
 	h.TimedHandler.Start(ctx)
 	go func() {
 		defer close(h.done)
@@ -72,7 +74,7 @@ func (h *udpListener) connToStreamLoop(ctx context.Context) {
 				}
 				dlog.Debugf(ctx, "   LIS %s conn-to-stream loop started", id)
 				return &udpStream{
-					TimedHandler: NewTimedHandler(id, udpConnTTL, release),
+					TimedHandler: NewTimedHandler(id, h.GetTTL(), release),
 					udpListener:  h,
 					stream:       s,
 				}, nil
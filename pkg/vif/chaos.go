@@ -0,0 +1,90 @@
+package vif
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/nested"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// Chaos describes artificial latency, jitter, and packet loss that is applied to the packets that are
+// sent to the cluster, so that developers can test how their services behave when the network is degraded.
+type Chaos struct {
+	// Latency is added to each packet.
+	Latency time.Duration
+
+	// Jitter is the maximum random deviation from the Latency, in either direction.
+	Jitter time.Duration
+
+	// DropRate is the fraction, between 0 and 1, of the packets that are dropped.
+	DropRate float64
+}
+
+// Enabled returns true if the Chaos degrades the network in any way.
+func (c Chaos) Enabled() bool {
+	return c.Latency > 0 || c.Jitter > 0 || c.DropRate > 0
+}
+
+// drop returns true if a packet should be dropped.
+func (c Chaos) drop() bool {
+	return c.DropRate > 0 && rand.Float64() < c.DropRate
+}
+
+// delay returns the time that a packet should be delayed.
+func (c Chaos) delay() time.Duration {
+	d := c.Latency
+	if c.Jitter > 0 {
+		d += rand.N(2*c.Jitter+1) - c.Jitter
+	}
+	return max(d, 0)
+}
+
+type chaosKey struct{}
+
+// WithChaos returns a context with the Chaos that stacks created with that context will apply.
+func WithChaos(ctx context.Context, c Chaos) context.Context {
+	return context.WithValue(ctx, chaosKey{}, c)
+}
+
+func getChaos(ctx context.Context) Chaos {
+	c, _ := ctx.Value(chaosKey{}).(Chaos)
+	return c
+}
+
+// chaosEndpoint is a link endpoint that delays or drops the packets that the device delivers to the stack.
+// The packets that the stack writes to the device pass through unaltered, so the added latency is the
+// increase of the round trip time.
+type chaosEndpoint struct {
+	nested.Endpoint
+	ctx   context.Context
+	chaos Chaos
+}
+
+func newChaosEndpoint(ctx context.Context, dev stack.LinkEndpoint, c Chaos) *chaosEndpoint {
+	e := &chaosEndpoint{ctx: ctx, chaos: c}
+	e.Endpoint.Init(dev, e)
+	return e
+}
+
+// DeliverNetworkPacket implements stack.NetworkDispatcher.
+func (e *chaosEndpoint) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
+	if e.chaos.drop() {
+		return
+	}
+	d := e.chaos.delay()
+	if d == 0 {
+		e.Endpoint.DeliverNetworkPacket(protocol, pkt)
+		return
+	}
+	// The caller releases its reference when this function returns.
+	pkt.IncRef()
+	time.AfterFunc(d, func() {
+		defer pkt.DecRef()
+		if e.ctx.Err() == nil {
+			e.Endpoint.DeliverNetworkPacket(protocol, pkt)
+		}
+	})
+}
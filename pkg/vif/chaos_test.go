@@ -0,0 +1,66 @@
+package vif
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+type arrivalDispatcher chan time.Time
+
+func (d arrivalDispatcher) DeliverNetworkPacket(tcpip.NetworkProtocolNumber, *stack.PacketBuffer) {
+	d <- time.Now()
+}
+
+func (d arrivalDispatcher) DeliverLinkPacket(tcpip.NetworkProtocolNumber, *stack.PacketBuffer) {}
+
+func TestChaos_delay(t *testing.T) {
+	c := Chaos{Latency: 100 * time.Millisecond, Jitter: 20 * time.Millisecond}
+	for range 1000 {
+		d := c.delay()
+		assert.GreaterOrEqual(t, d, 80*time.Millisecond)
+		assert.LessOrEqual(t, d, 120*time.Millisecond)
+	}
+	assert.GreaterOrEqual(t, Chaos{Latency: 10 * time.Millisecond, Jitter: time.Second}.delay(), time.Duration(0))
+}
+
+func TestChaosEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deliver := func(c Chaos) arrivalDispatcher {
+		dev := channel.New(16, defaultDevMtu, "")
+		e := newChaosEndpoint(ctx, dev, c)
+		arrivals := make(arrivalDispatcher, 1)
+		e.Attach(arrivals)
+		pb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(make([]byte, header.IPv4MinimumSize))})
+		dev.InjectInbound(header.IPv4ProtocolNumber, pb)
+		pb.DecRef()
+		return arrivals
+	}
+
+	t.Run("latency", func(t *testing.T) {
+		start := time.Now()
+		select {
+		case at := <-deliver(Chaos{Latency: 50 * time.Millisecond}):
+			assert.GreaterOrEqual(t, at.Sub(start), 50*time.Millisecond)
+		case <-time.After(5 * time.Second):
+			t.Fatal("packet wasn't delivered")
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		select {
+		case <-deliver(Chaos{DropRate: 1}):
+			t.Fatal("packet wasn't dropped")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
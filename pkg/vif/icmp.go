@@ -0,0 +1,302 @@
+package vif
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/nested"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// probePorts are the TCP ports that are dialed through the tunnel to determine if a host is reachable.
+var probePorts = []uint16{80, 443} //nolint:gochecknoglobals // constant
+
+// probeCacheTTL is for how long the outcome of a reachability probe is used to answer echo requests.
+const probeCacheTTL = 10 * time.Second
+
+// icmpEchoEndpoint is a link endpoint that intercepts ICMP echo requests before they reach the stack.
+//
+// The stack would answer all echo requests itself, regardless of whether the destination exists in the
+// cluster. ICMP cannot be sent through the tunnel, so the reachability of the destination is instead
+// determined by dialing a TCP connection to it from the cluster. A successful or refused dial means that
+// the host is reachable, and the echo request is answered with an echo reply. Otherwise, it is answered
+// with a "destination unreachable" message, so that ping reports an unreachable host instead of timing out.
+type icmpEchoEndpoint struct {
+	nested.Endpoint
+	ctx           context.Context
+	dev           stack.LinkEndpoint
+	streamCreator tunnel.StreamCreator
+
+	sync.Mutex
+	probes map[netip.Addr]*reachabilityProbe
+}
+
+type reachabilityProbe struct {
+	done      chan struct{}
+	reachable bool
+	expires   time.Time
+}
+
+func newICMPEchoEndpoint(ctx context.Context, dev stack.LinkEndpoint, streamCreator tunnel.StreamCreator) *icmpEchoEndpoint {
+	e := &icmpEchoEndpoint{
+		ctx:           ctx,
+		dev:           dev,
+		streamCreator: streamCreator,
+		probes:        make(map[netip.Addr]*reachabilityProbe),
+	}
+	e.Endpoint.Init(dev, e)
+	return e
+}
+
+// DeliverNetworkPacket implements stack.NetworkDispatcher.
+func (e *icmpEchoEndpoint) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
+	v := pkt.ToView()
+	req := v.AsSlice()
+	if isEchoRequest(req) {
+		req = slices.Clone(req)
+		v.Release()
+		go e.answerEcho(req)
+		return
+	}
+	v.Release()
+	e.Endpoint.DeliverNetworkPacket(protocol, pkt)
+}
+
+func (e *icmpEchoEndpoint) answerEcho(req []byte) {
+	var reply []byte
+	if e.reachable(echoDestination(req)) {
+		reply = echoReply(req)
+	} else {
+		reply = hostUnreachable(req)
+	}
+	proto := header.IPv4ProtocolNumber
+	if header.IPVersion(reply) == header.IPv6Version {
+		proto = header.IPv6ProtocolNumber
+	}
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(reply)})
+	pkt.NetworkProtocolNumber = proto
+	var pkts stack.PacketBufferList
+	pkts.PushBack(pkt)
+	if _, err := e.dev.WritePackets(pkts); err != nil {
+		dlog.Errorf(e.ctx, "failed to write ICMP response: %s", err)
+	}
+	pkt.DecRef()
+}
+
+// reachable returns the outcome of a reachability probe for the given address. A recent outcome is reused,
+// and concurrent callers share one probe.
+func (e *icmpEchoEndpoint) reachable(addr netip.Addr) bool {
+	e.Lock()
+	p, ok := e.probes[addr]
+	if ok {
+		select {
+		case <-p.done:
+			if time.Now().After(p.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		p = &reachabilityProbe{done: make(chan struct{})}
+		e.probes[addr] = p
+		go func() {
+			p.reachable = e.probe(addr)
+			p.expires = time.Now().Add(probeCacheTTL)
+			close(p.done)
+		}()
+	}
+	e.Unlock()
+
+	select {
+	case <-e.ctx.Done():
+		return false
+	case <-p.done:
+		return p.reachable
+	}
+}
+
+// probe dials all probePorts of the given address through the tunnel, and returns true if any of
+// them proves that the host is reachable.
+func (e *icmpEchoEndpoint) probe(addr netip.Addr) bool {
+	results := make(chan bool, len(probePorts))
+	for _, port := range probePorts {
+		go func() {
+			results <- e.probePort(addr, port)
+		}()
+	}
+	reachable := false
+	for range probePorts {
+		if <-results {
+			reachable = true
+		}
+	}
+	dlog.Debugf(e.ctx, "ICMP echo probe of %s: reachable = %t", addr, reachable)
+	return reachable
+}
+
+func (e *icmpEchoEndpoint) probePort(addr netip.Addr, port uint16) bool {
+	ctx, cancel := context.WithCancel(e.ctx)
+	defer cancel()
+
+	var src net.IP
+	if addr.Is4() {
+		src = net.IPv4zero
+	} else {
+		src = net.IPv6zero
+	}
+	// The source port just needs to make the connection ID unique.
+	id := tunnel.NewConnID(ipproto.TCP, src, addr.AsSlice(), uint16(49152+rand.IntN(16384)), port)
+	st, err := e.streamCreator(ctx, id)
+	if err != nil {
+		dlog.Debugf(ctx, "ICMP echo probe %s: %v", id, err)
+		return false
+	}
+	defer func() {
+		_ = st.CloseSend(ctx)
+	}()
+
+	start := time.Now()
+	ctx, timeoutCancel := context.WithTimeout(ctx, st.DialTimeout()+st.RoundtripLatency())
+	defer timeoutCancel()
+	for {
+		m, err := st.Receive(ctx)
+		if err != nil {
+			return false
+		}
+		switch m.Code() {
+		case tunnel.DialOK:
+			_ = st.Send(ctx, tunnel.NewMessage(tunnel.Disconnect, nil))
+			return true
+		case tunnel.DialReject, tunnel.Disconnect:
+			// A quick rejection means that the host refused the connection, so it is reachable. A
+			// slow rejection is caused by a dial timeout.
+			return time.Since(start) < st.DialTimeout()/2
+		}
+	}
+}
+
+// isEchoRequest returns true if the given IP packet contains an ICMP echo request.
+func isEchoRequest(pkt []byte) bool {
+	if len(pkt) == 0 {
+		return false
+	}
+	switch header.IPVersion(pkt) {
+	case header.IPv4Version:
+		ip := header.IPv4(pkt)
+		if !ip.IsValid(len(pkt)) || ip.TransportProtocol() != header.ICMPv4ProtocolNumber || ip.More() || ip.FragmentOffset() != 0 {
+			return false
+		}
+		icmp := header.ICMPv4(ip.Payload())
+		return len(icmp) >= header.ICMPv4MinimumSize && icmp.Type() == header.ICMPv4Echo
+	case header.IPv6Version:
+		ip := header.IPv6(pkt)
+		if !ip.IsValid(len(pkt)) || ip.TransportProtocol() != header.ICMPv6ProtocolNumber {
+			return false
+		}
+		icmp := header.ICMPv6(ip.Payload())
+		return len(icmp) >= header.ICMPv6MinimumSize && icmp.Type() == header.ICMPv6EchoRequest
+	}
+	return false
+}
+
+func echoDestination(req []byte) netip.Addr {
+	var dst tcpip.Address
+	if header.IPVersion(req) == header.IPv4Version {
+		dst = header.IPv4(req).DestinationAddress()
+	} else {
+		dst = header.IPv6(req).DestinationAddress()
+	}
+	addr, _ := netip.AddrFromSlice(dst.AsSlice())
+	return addr
+}
+
+// echoReply creates an echo reply to the given echo request.
+func echoReply(req []byte) []byte {
+	if header.IPVersion(req) == header.IPv4Version {
+		ip := header.IPv4(req)
+		icmp := slices.Clone(header.ICMPv4(ip.Payload()))
+		icmp.SetType(header.ICMPv4EchoReply)
+		icmp.SetCode(header.ICMPv4UnusedCode)
+		return newICMPv4Packet(ip.DestinationAddress(), ip.SourceAddress(), icmp)
+	}
+	ip := header.IPv6(req)
+	icmp := slices.Clone(header.ICMPv6(ip.Payload()))
+	icmp.SetType(header.ICMPv6EchoReply)
+	icmp.SetCode(header.ICMPv6UnusedCode)
+	return newICMPv6Packet(ip.DestinationAddress(), ip.SourceAddress(), icmp)
+}
+
+// hostUnreachable creates a "destination unreachable" message in response to the given packet. The sender
+// of the message is the destination of the packet, which is a bit unusual, but it makes ping print the
+// destination as the unreachable host.
+func hostUnreachable(req []byte) []byte {
+	if header.IPVersion(req) == header.IPv4Version {
+		ip := header.IPv4(req)
+		// As per RFC 792, the original IP header and the first 64 bits of its payload are included.
+		orig := req[:min(len(req), int(ip.HeaderLength())+8)]
+		icmp := header.ICMPv4(make([]byte, header.ICMPv4MinimumSize+len(orig)))
+		icmp.SetType(header.ICMPv4DstUnreachable)
+		icmp.SetCode(header.ICMPv4HostUnreachable)
+		copy(icmp.Payload(), orig)
+		return newICMPv4Packet(ip.DestinationAddress(), ip.SourceAddress(), icmp)
+	}
+	ip := header.IPv6(req)
+	// As per RFC 4443, as much of the original packet as possible is included without exceeding the
+	// minimum IPv6 MTU.
+	orig := req[:min(len(req), header.IPv6MinimumMTU-header.IPv6MinimumSize-header.ICMPv6DstUnreachableMinimumSize)]
+	icmp := header.ICMPv6(make([]byte, header.ICMPv6DstUnreachableMinimumSize+len(orig)))
+	icmp.SetType(header.ICMPv6DstUnreachable)
+	icmp.SetCode(header.ICMPv6AddressUnreachable)
+	copy(icmp.Payload(), orig)
+	return newICMPv6Packet(ip.DestinationAddress(), ip.SourceAddress(), icmp)
+}
+
+func newICMPv4Packet(src, dst tcpip.Address, icmp header.ICMPv4) []byte {
+	icmp.SetChecksum(0)
+	icmp.SetChecksum(header.ICMPv4Checksum(icmp, 0))
+	pkt := make([]byte, header.IPv4MinimumSize+len(icmp))
+	ip := header.IPv4(pkt)
+	ip.Encode(&header.IPv4Fields{
+		TotalLength: uint16(len(pkt)),
+		TTL:         64,
+		Protocol:    uint8(header.ICMPv4ProtocolNumber),
+		SrcAddr:     src,
+		DstAddr:     dst,
+	})
+	ip.SetChecksum(^ip.CalculateChecksum())
+	copy(pkt[header.IPv4MinimumSize:], icmp)
+	return pkt
+}
+
+func newICMPv6Packet(src, dst tcpip.Address, icmp header.ICMPv6) []byte {
+	icmp.SetChecksum(0)
+	icmp.SetChecksum(header.ICMPv6Checksum(header.ICMPv6ChecksumParams{
+		Header: icmp,
+		Src:    src,
+		Dst:    dst,
+	}))
+	pkt := make([]byte, header.IPv6MinimumSize+len(icmp))
+	ip := header.IPv6(pkt)
+	ip.Encode(&header.IPv6Fields{
+		PayloadLength:     uint16(len(icmp)),
+		TransportProtocol: header.ICMPv6ProtocolNumber,
+		HopLimit:          64,
+		SrcAddr:           src,
+		DstAddr:           dst,
+	})
+	copy(pkt[header.IPv6MinimumSize:], icmp)
+	return pkt
+}
@@ -0,0 +1,124 @@
+package vif
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+func TestICMPEcho(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reachable := netip.MustParseAddr("10.128.3.4")
+	reachable6 := netip.MustParseAddr("fd00::3:4")
+	streamCreator := func(c context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+		dst, _ := netip.AddrFromSlice(id.Destination())
+		if dst = dst.Unmap(); dst != reachable && dst != reachable6 {
+			return nil, errors.New("no route to host")
+		}
+		from, to := tunnel.NewPipe(id, "session")
+		go func() {
+			_ = to.Send(c, tunnel.NewMessage(tunnel.DialOK, nil))
+		}()
+		return from, nil
+	}
+
+	dev := channel.New(16, defaultDevMtu, "")
+	s, err := NewStack(ctx, dev, streamCreator)
+	require.NoError(t, err)
+	defer s.Close()
+
+	addr := func(a netip.Addr) tcpip.Address {
+		return tcpip.AddrFromSlice(a.AsSlice())
+	}
+
+	echoRequest := func(src, dst netip.Addr) []byte {
+		var pkt []byte
+		if dst.Is4() {
+			icmp := header.ICMPv4(make([]byte, header.ICMPv4MinimumSize+4))
+			icmp.SetType(header.ICMPv4Echo)
+			icmp.SetIdent(7)
+			icmp.SetSequence(1)
+			copy(icmp.Payload(), "ping")
+			pkt = newICMPv4Packet(addr(src), addr(dst), icmp)
+		} else {
+			icmp := header.ICMPv6(make([]byte, header.ICMPv6MinimumSize+4))
+			icmp.SetType(header.ICMPv6EchoRequest)
+			icmp.SetIdent(7)
+			icmp.SetSequence(1)
+			copy(icmp.Payload(), "ping")
+			pkt = newICMPv6Packet(addr(src), addr(dst), icmp)
+		}
+		require.True(t, isEchoRequest(pkt))
+		return pkt
+	}
+
+	ping := func(src, dst netip.Addr) []byte {
+		req := echoRequest(src, dst)
+		proto := header.IPv4ProtocolNumber
+		if dst.Is6() {
+			proto = header.IPv6ProtocolNumber
+		}
+		pb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(req)})
+		dev.InjectInbound(proto, pb)
+		pb.DecRef()
+
+		rc, rcCancel := context.WithTimeout(ctx, 5*time.Second)
+		defer rcCancel()
+		pb = dev.ReadContext(rc)
+		require.NotNil(t, pb)
+		defer pb.DecRef()
+		v := pb.ToView()
+		defer v.Release()
+		return append([]byte(nil), v.AsSlice()...)
+	}
+
+	src := netip.MustParseAddr("192.168.1.2")
+	reply := header.IPv4(ping(src, reachable))
+	require.True(t, reply.IsValid(len(reply)))
+	assert.True(t, reply.IsChecksumValid())
+	assert.Equal(t, addr(reachable), reply.SourceAddress())
+	assert.Equal(t, addr(src), reply.DestinationAddress())
+	icmp := header.ICMPv4(reply.Payload())
+	assert.Equal(t, header.ICMPv4EchoReply, icmp.Type())
+	assert.Equal(t, uint16(7), icmp.Ident())
+	assert.Equal(t, uint16(1), icmp.Sequence())
+	assert.Equal(t, "ping", string(icmp.Payload()))
+	assert.Equal(t, header.ICMPv4Checksum(icmp, 0), icmp.Checksum())
+
+	unreachable := netip.MustParseAddr("10.128.3.5")
+	reply = ping(src, unreachable)
+	require.True(t, reply.IsValid(len(reply)))
+	icmp = reply.Payload()
+	assert.Equal(t, header.ICMPv4DstUnreachable, icmp.Type())
+	assert.Equal(t, header.ICMPv4HostUnreachable, icmp.Code())
+	orig := header.IPv4(icmp.Payload())
+	assert.Equal(t, addr(unreachable), orig.DestinationAddress())
+
+	src6 := netip.MustParseAddr("fd00::1")
+	reply6 := header.IPv6(ping(src6, reachable6))
+	require.True(t, reply6.IsValid(len(reply6)))
+	assert.Equal(t, addr(reachable6), reply6.SourceAddress())
+	icmp6 := header.ICMPv6(reply6.Payload())
+	assert.Equal(t, header.ICMPv6EchoReply, icmp6.Type())
+	assert.Equal(t, "ping", string(icmp6.Payload()))
+
+	reply6 = ping(src6, netip.MustParseAddr("fd00::3:5"))
+	require.True(t, reply6.IsValid(len(reply6)))
+	icmp6 = reply6.Payload()
+	assert.Equal(t, header.ICMPv6DstUnreachable, icmp6.Type())
+	assert.Equal(t, header.ICMPv6AddressUnreachable, icmp6.Code())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"slices"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -24,6 +25,8 @@ type Router struct {
 	whitelistedSubnets []*net.IPNet
 }
 
+// NewRouter creates a Router that routes the subnets through the given device. The table may be nil, in which
+// case the router will just add and remove subnets from the device, and never touch the host's routing table.
 func NewRouter(device Device, table routing.Table) *Router {
 	return &Router{device: device, routingTable: table}
 }
@@ -37,13 +40,41 @@ func (rt *Router) UpdateWhitelist(whitelist []*net.IPNet) {
 }
 
 func (rt *Router) ValidateRoutes(ctx context.Context, routes []*net.IPNet) error {
+	conflicts, err := FindConflicts(ctx, routes, rt.whitelistedSubnets, rt.device.Name())
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		c := conflicts[0]
+		return errcat.Config.New(fmt.Sprintf(
+			"subnet %s overlaps with existing route %q. Please see %s for more information",
+			c.Subnet, c.Route, "https://www.getambassador.io/docs/telepresence/latest/reference/vpn",
+		))
+	}
+	return nil
+}
+
+// Conflict is a subnet that overlaps with a route in the host's routing table.
+type Conflict struct {
+	Subnet *net.IPNet
+	Route  *routing.Route
+}
+
+// FindConflicts returns a Conflict for each of the given subnets that overlaps with a route in the host's
+// routing table. Subnets covered by the whitelist, default routes, and routes of the interface with the
+// given name, are never considered conflicting.
+func FindConflicts(ctx context.Context, subnets, whitelist []*net.IPNet, ifName string) ([]Conflict, error) {
 	// We need the entire table because we need to check for any overlaps, not just "is this IP already routed"
 	table, err := routing.GetRoutingTable(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, nonWhitelisted := subnet.Partition(routes, func(_ int, r *net.IPNet) bool {
-		for _, w := range rt.whitelistedSubnets {
+	return findConflicts(ctx, table, subnets, whitelist, ifName), nil
+}
+
+func findConflicts(ctx context.Context, table []*routing.Route, subnets, whitelist []*net.IPNet, ifName string) []Conflict {
+	_, nonWhitelisted := subnet.Partition(subnets, func(_ int, r *net.IPNet) bool {
+		for _, w := range whitelist {
 			if subnet.Covers(w, r) {
 				// This is a whitelisted subnet, so we'll overlap it if needed
 				return true
@@ -57,28 +88,35 @@ func (rt *Router) ValidateRoutes(ctx context.Context, routes []*net.IPNet) error
 		}
 		return false
 	})
+	var conflicts []Conflict
 	// Slightly awkward nested loops, since they can both continue (i.e. there's probably wasted iterations) but it's okay, there's not gonna be hundreds of routes.
 	// In any case, we really wanna run over the table as the outer loop, since it's bigger.
 	for _, tr := range table {
 		dlog.Tracef(ctx, "checking for overlap with route %q", tr)
 		if (subnet.IsZeroMask(tr.RoutedNet) || tr.Default) || // Default route, overlapped if needed
 			subnet.IsHalfOfDefault(tr.RoutedNet) || // OpenVPN covers half the address space with a /1 route and the other half with another. This is its way of doing a default route.
-			tr.Interface.Name == rt.device.Name() { // This is the interface we're routing through, so we can overlap it
+			tr.Interface.Name == ifName { // This is the interface we're routing through, so we can overlap it
 			continue
 		}
-		for _, r := range nonWhitelisted {
+		for i := 0; i < len(nonWhitelisted); {
+			r := nonWhitelisted[i]
 			if subnet.Overlaps(tr.RoutedNet, r) {
-				return errcat.Config.New(fmt.Sprintf(
-					"subnet %s overlaps with existing route %q. Please see %s for more information",
-					r, tr, "https://www.getambassador.io/docs/telepresence/latest/reference/vpn",
-				))
+				conflicts = append(conflicts, Conflict{Subnet: r, Route: tr})
+				// Only report the first conflict of each subnet.
+				nonWhitelisted = slices.Delete(nonWhitelisted, i, i+1)
+				continue
 			}
+			i++
 		}
 	}
-	return nil
+	return conflicts
 }
 
 func (rt *Router) UpdateRoutes(ctx context.Context, pleaseProxy, dontProxy, dontProxyOverrides []*net.IPNet) error {
+	if rt.routingTable == nil {
+		return rt.updateSubnets(ctx, pleaseProxy)
+	}
+
 	// Don't never-proxy subnets that aren't routed
 	if err := rt.ValidateRoutes(ctx, pleaseProxy); err != nil {
 		return err
@@ -145,6 +183,23 @@ func (rt *Router) UpdateRoutes(ctx context.Context, pleaseProxy, dontProxy, dont
 	return rt.addStaticOverrides(ctx, dontProxy, dontProxyOverrides, staticNets, pr)
 }
 
+// updateSubnets updates the subnets of the device without touching the routing table. Conflicts with
+// routes of the host are irrelevant, because no routes are added.
+func (rt *Router) updateSubnets(ctx context.Context, pleaseProxy []*net.IPNet) error {
+	for _, sn := range rt.routedSubnets {
+		if err := rt.device.RemoveSubnet(ctx, sn); err != nil {
+			dlog.Errorf(ctx, "failed to remove subnet %s: %v", sn, err)
+		}
+	}
+	rt.routedSubnets = subnet.Unique(pleaseProxy)
+	for _, sn := range rt.routedSubnets {
+		if err := rt.device.AddSubnet(ctx, sn); err != nil {
+			return fmt.Errorf("failed to add subnet %s: %w", sn, err)
+		}
+	}
+	return nil
+}
+
 func (rt *Router) addStaticOverrides(ctx context.Context, neverProxy, neverProxyOverrides, staticNets []*net.IPNet, primaryRoute *routing.Route) (err error) {
 	desired := make([]*routing.Route, 0, len(neverProxy)+len(neverProxyOverrides))
 	dr, err := routing.DefaultRoute(ctx)
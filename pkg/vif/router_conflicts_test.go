@@ -0,0 +1,43 @@
+package vif
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/routing"
+)
+
+func Test_findConflicts(t *testing.T) {
+	cidr := func(s string) *net.IPNet {
+		_, sn, err := net.ParseCIDR(s)
+		require.NoError(t, err)
+		return sn
+	}
+	eth0 := &net.Interface{Name: "eth0"}
+	tun0 := &net.Interface{Name: "tun0"}
+	table := []*routing.Route{
+		{RoutedNet: cidr("0.0.0.0/0"), Interface: eth0, Default: true},
+		{RoutedNet: cidr("10.0.0.0/8"), Interface: eth0},
+		{RoutedNet: cidr("192.168.1.0/24"), Interface: eth0},
+		{RoutedNet: cidr("172.20.0.0/16"), Interface: tun0},
+	}
+	ctx := dlog.NewTestContext(t, false)
+	svc := cidr("10.96.0.0/12")
+	pods := cidr("192.168.0.0/16")
+	clear := cidr("100.64.0.0/16")
+	routed := cidr("172.20.0.0/16")
+	allowed := cidr("10.244.0.0/16")
+
+	cs := findConflicts(ctx, table, []*net.IPNet{svc, pods, clear, routed, allowed}, []*net.IPNet{cidr("10.244.0.0/16")}, "tun0")
+	require.Len(t, cs, 2)
+	assert.Equal(t, svc, cs[0].Subnet)
+	assert.Equal(t, "10.0.0.0/8", cs[0].Route.RoutedNet.String())
+	assert.Equal(t, pods, cs[1].Subnet)
+	assert.Equal(t, "192.168.1.0/24", cs[1].Route.RoutedNet.String())
+
+	assert.Empty(t, findConflicts(ctx, table, []*net.IPNet{clear}, nil, "tun0"))
+}
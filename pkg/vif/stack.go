@@ -43,7 +43,11 @@ func NewStack(ctx context.Context, dev stack.LinkEndpoint, streamCreator tunnel.
 	if err := setDefaultOptions(s); err != nil {
 		return nil, err
 	}
-	if err := setNIC(ctx, s, dev); err != nil {
+	if c := getChaos(ctx); c.Enabled() {
+		dlog.Infof(ctx, "Degrading the network with latency %s, jitter %s, and %g%% packet loss", c.Latency, c.Jitter, c.DropRate*100)
+		dev = newChaosEndpoint(ctx, dev, c)
+	}
+	if err := setNIC(ctx, s, newICMPEchoEndpoint(ctx, dev, streamCreator)); err != nil {
 		return nil, err
 	}
 	setTCPHandler(ctx, s, streamCreator)
@@ -212,8 +216,8 @@ func forwardUDP(ctx context.Context, streamCreator tunnel.StreamCreator, fr *udp
 	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "UDPHandler",
 		trace.WithNewRoot(),
 		trace.WithAttributes(
-			attribute.String("tel2.remote-ip", id.RemoteAddress.To4().String()),
-			attribute.String("tel2.local-ip", id.LocalAddress.To4().String()),
+			attribute.String("tel2.remote-ip", id.RemoteAddress.String()),
+			attribute.String("tel2.local-ip", id.LocalAddress.String()),
 			attribute.Int("tel2.local-port", int(id.LocalPort)),
 			attribute.Int("tel2.remote-port", int(id.RemotePort)),
 			attribute.Bool("tel2.port-blocked", false),
@@ -39,13 +39,33 @@ func NewTunnelingDevice(ctx context.Context, tunnelStreamCreator tunnel.StreamCr
 	}, nil
 }
 
+// NewUserSpaceTunnelingDevice creates a TunnelingDevice that uses a UserSpaceDevice instead of a TUN device.
+// The Router of such a device never modifies the routing table of the host.
+func NewUserSpaceTunnelingDevice(ctx context.Context, tunnelStreamCreator tunnel.StreamCreator) (*TunnelingDevice, error) {
+	dev, err := NewUserSpaceDevice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stack, err := NewStack(ctx, dev, tunnelStreamCreator)
+	if err != nil {
+		return nil, err
+	}
+	return &TunnelingDevice{
+		stack:  stack,
+		Device: dev,
+		Router: NewRouter(dev, nil),
+	}, nil
+}
+
 func (vif *TunnelingDevice) Close(ctx context.Context) error {
 	var result error
 	vif.stack.Close()
 	vif.Router.Close(ctx)
 	vif.Device.Close()
-	if err := vif.table.Close(ctx); err != nil {
-		result = multierror.Append(result, err)
+	if vif.table != nil {
+		if err := vif.table.Close(ctx); err != nil {
+			result = multierror.Append(result, err)
+		}
 	}
 	return result
 }
@@ -0,0 +1,226 @@
+package vif
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// The addresses used by the dialing stack of a user space device. They are taken from the range reserved
+// for benchmarking (RFC 2544) and from the unique local range (RFC 4193) so that they never clash with
+// addresses in the cluster.
+var (
+	userSpaceIPv4 = tcpip.AddrFrom4([4]byte{198, 18, 0, 1})                   //nolint:gochecknoglobals // constant
+	userSpaceIPv6 = tcpip.AddrFrom16([16]byte{0xfd, 0x7e, 0x1e, 0xce, 15: 1}) //nolint:gochecknoglobals // constant
+)
+
+// userSpaceDevice is a Device that isn't backed by a TUN device. Instead of exchanging packets with
+// the kernel, it exchanges them with a second gVisor stack that lives in the same process. That stack
+// is used for dialing, so connections made using DialContext will traverse the same user space stack
+// and the same tunnels as connections made through a TUN device would, but no privileges are required.
+type userSpaceDevice struct {
+	*channel.Endpoint
+	ctx    context.Context
+	wg     sync.WaitGroup
+	peer   *channel.Endpoint
+	dialer *stack.Stack
+
+	sync.RWMutex
+	subnets []*net.IPNet
+}
+
+// UserSpaceDevice is a Device that can be dialed directly from within the process.
+type UserSpaceDevice interface {
+	Device
+
+	// DialContext dials the given address through the device. The network must be one of
+	// "tcp", "tcp4", "tcp6", "udp", "udp4", or "udp6".
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+
+	// Routes returns true if the given IP belongs to a subnet that has been added to the device.
+	Routes(ip net.IP) bool
+}
+
+var _ UserSpaceDevice = (*userSpaceDevice)(nil)
+
+// NewUserSpaceDevice creates a Device that exchanges packets with an in-process dialing stack.
+func NewUserSpaceDevice(ctx context.Context) (UserSpaceDevice, error) {
+	peer := channel.New(defaultDevOutQueueLen, defaultDevMtu, "")
+	dialer, err := newDialerStack(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	return &userSpaceDevice{
+		Endpoint: channel.New(defaultDevOutQueueLen, defaultDevMtu, ""),
+		ctx:      ctx,
+		peer:     peer,
+		dialer:   dialer,
+	}, nil
+}
+
+// newDialerStack creates the stack that plays the role of the kernel in the user space device. It
+// has one NIC with the addresses userSpaceIPv4 and userSpaceIPv6, and routes everything through it.
+func newDialerStack(ctx context.Context, ep stack.LinkEndpoint) (*stack.Stack, error) {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol, ipv6.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol, udp.NewProtocol},
+	})
+	nicID := s.NextNICID()
+	if err := s.CreateNICWithOptions(nicID, ep, stack.NICOptions{Name: "tel-dialer", Context: ctx}); err != nil {
+		return nil, fmt.Errorf("create NIC failed: %s", err)
+	}
+	for _, pa := range []tcpip.ProtocolAddress{
+		{Protocol: ipv4.ProtocolNumber, AddressWithPrefix: userSpaceIPv4.WithPrefix()},
+		{Protocol: ipv6.ProtocolNumber, AddressWithPrefix: userSpaceIPv6.WithPrefix()},
+	} {
+		if err := s.AddProtocolAddress(nicID, pa, stack.AddressProperties{}); err != nil {
+			return nil, fmt.Errorf("AddProtocolAddress(%d, %s): %s", nicID, pa.AddressWithPrefix, err)
+		}
+	}
+	s.SetRouteTable([]tcpip.Route{
+		{
+			Destination: header.IPv4EmptySubnet,
+			NIC:         nicID,
+		},
+		{
+			Destination: header.IPv6EmptySubnet,
+			NIC:         nicID,
+		},
+	})
+	return s, nil
+}
+
+func (d *userSpaceDevice) Attach(dp stack.NetworkDispatcher) {
+	go func() {
+		d.Endpoint.Attach(dp)
+		if dp == nil {
+			// Stack is closing
+			return
+		}
+		dlog.Info(d.ctx, "Starting user space Endpoint")
+		ctx, cancel := context.WithCancel(d.ctx)
+		d.wg.Add(2)
+		go func() {
+			defer cancel()
+			pump(ctx, &d.wg, d.peer, d.Endpoint)
+		}()
+		pump(ctx, &d.wg, d.Endpoint, d.peer)
+	}()
+}
+
+// pump moves outbound packets from one endpoint to the inbound side of the other until the
+// context is cancelled or the source endpoint is closed.
+func pump(ctx context.Context, wg *sync.WaitGroup, from, to *channel.Endpoint) {
+	defer wg.Done()
+	for {
+		pb := from.ReadContext(ctx)
+		if pb == nil {
+			return
+		}
+		in := pb.CloneToInbound()
+		to.InjectInbound(pb.NetworkProtocolNumber, in)
+		in.DecRef()
+		pb.DecRef()
+	}
+}
+
+func (d *userSpaceDevice) AddSubnet(_ context.Context, subnet *net.IPNet) error {
+	d.Lock()
+	d.subnets = append(d.subnets, subnet)
+	d.Unlock()
+	return nil
+}
+
+func (d *userSpaceDevice) RemoveSubnet(_ context.Context, subnet *net.IPNet) error {
+	d.Lock()
+	defer d.Unlock()
+	for i, sn := range d.subnets {
+		if sn.String() == subnet.String() {
+			d.subnets = append(d.subnets[:i], d.subnets[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (d *userSpaceDevice) Routes(ip net.IP) bool {
+	d.RLock()
+	defer d.RUnlock()
+	for _, sn := range d.subnets {
+		if sn.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *userSpaceDevice) Close() {
+	d.peer.Close()
+	d.dialer.Close()
+	d.Endpoint.Close()
+}
+
+// Index returns -1 because a user space device has no interface index.
+func (d *userSpaceDevice) Index() int32 {
+	return -1
+}
+
+// Name returns the name of this device.
+func (d *userSpaceDevice) Name() string {
+	return "userspace"
+}
+
+// SetDNS is a no-op. DNS is never configured for the host when the device is in user space.
+func (d *userSpaceDevice) SetDNS(context.Context, string, net.IP, []string) error {
+	return nil
+}
+
+func (d *userSpaceDevice) WaitForDevice() {
+	d.wg.Wait()
+	dlog.Info(d.ctx, "User space Endpoint done")
+}
+
+func (d *userSpaceDevice) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, ps, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not an IP address", host)
+	}
+	port, err := strconv.ParseUint(ps, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in address %q", address)
+	}
+	fa := tcpip.FullAddress{Port: uint16(port)}
+	proto := ipv6.ProtocolNumber
+	if ip4 := ip.To4(); ip4 != nil {
+		fa.Addr = tcpip.AddrFrom4Slice(ip4)
+		proto = ipv4.ProtocolNumber
+	} else {
+		fa.Addr = tcpip.AddrFrom16Slice(ip)
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return gonet.DialContextTCP(ctx, d.dialer, fa, proto)
+	case "udp", "udp4", "udp6":
+		return gonet.DialUDP(d.dialer, nil, &fa, proto)
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+}
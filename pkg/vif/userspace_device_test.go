@@ -0,0 +1,80 @@
+package vif
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+func TestUserSpaceTunnelingDevice(t *testing.T) {
+	// Tunnel goroutines may log after the test completes, so the test logger cannot be used.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An echo server on the host that all tunneled connections are redirected to.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(c, c)
+				_ = c.Close()
+			}()
+		}
+	}()
+	echoPort := uint16(l.Addr().(*net.TCPAddr).Port)
+
+	var tunneled tunnel.ConnID
+	streamCreator := func(c context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+		tunneled = id
+		id = tunnel.NewConnID(ipproto.TCP, id.Source(), net.IP{127, 0, 0, 1}, id.SourcePort(), echoPort)
+		from, to := tunnel.NewPipe(id, "session")
+		tunnel.NewDialer(to, func() {}, nil, nil).Start(c)
+		return from, nil
+	}
+
+	tv, err := NewUserSpaceTunnelingDevice(ctx, streamCreator)
+	require.NoError(t, err)
+	defer func() {
+		_ = tv.Close(ctx)
+	}()
+	_, sn, _ := net.ParseCIDR("10.128.0.0/16")
+	require.NoError(t, tv.Router.UpdateRoutes(ctx, []*net.IPNet{sn}, nil, nil))
+
+	dev := tv.Device.(UserSpaceDevice)
+	assert.True(t, dev.Routes(net.IP{10, 128, 3, 4}))
+	assert.False(t, dev.Routes(net.IP{10, 129, 3, 4}))
+
+	dc, cancelDial := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelDial()
+	conn, err := dev.DialContext(dc, "tcp", "10.128.3.4:8080")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+	assert.Equal(t, "10.128.3.4", tunneled.Destination().String())
+	assert.Equal(t, uint16(8080), tunneled.DestinationPort())
+	assert.Equal(t, "198.18.0.1", tunneled.Source().String())
+
+	require.NoError(t, tv.Router.UpdateRoutes(ctx, nil, nil, nil))
+	assert.False(t, dev.Routes(net.IP{10, 128, 3, 4}))
+}
@@ -0,0 +1,236 @@
+// Package wireguard provides userspace WireGuard devices that are backed by gVisor network stacks instead
+// of operating system devices, so that no privileges are needed to use them.
+//
+// The client device owns one address and is used for dialing. The server device accepts connections to
+// any address, dials the real destination, and forwards the traffic in both directions.
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/conn"
+	"golang.zx2c4.com/wireguard/device"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+const (
+	// MTU is the MTU of the devices. It leaves room for the WireGuard and UDP headers in a 1500 byte
+	// packet, even when the endpoint is an IPv6 address.
+	MTU = 1420
+
+	// dialTimeout is the timeout used by a server device when it dials the destination of a connection.
+	dialTimeout = 5 * time.Second
+)
+
+// Device is a userspace WireGuard device.
+type Device struct {
+	dev    *device.Device
+	stack  *stack.Stack
+	nicID  tcpip.NICID
+	dialer func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewClient creates a device with the given private key and address. The device listens to a random
+// UDP port, and is used for dialing through the peers that are added to it.
+func NewClient(ctx context.Context, privateKey Key, addr netip.Addr) (*Device, error) {
+	d, err := newDevice(ctx, privateKey, 0)
+	if err != nil {
+		return nil, err
+	}
+	pa := tcpip.ProtocolAddress{
+		Protocol:          networkProtocol(addr),
+		AddressWithPrefix: tcpip.AddrFromSlice(addr.AsSlice()).WithPrefix(),
+	}
+	if terr := d.stack.AddProtocolAddress(d.nicID, pa, stack.AddressProperties{}); terr != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to add address %s: %s", addr, terr)
+	}
+	return d, nil
+}
+
+// NewServer creates a device with the given private key that listens to the given UDP port. The device
+// accepts TCP and UDP connections to any destination from its peers, and forwards them to their real
+// destination.
+func NewServer(ctx context.Context, privateKey Key, listenPort uint16) (*Device, error) {
+	d, err := newDevice(ctx, privateKey, listenPort)
+	if err != nil {
+		return nil, err
+	}
+	if terr := d.stack.SetPromiscuousMode(d.nicID, true); terr != nil {
+		d.Close()
+		return nil, fmt.Errorf("SetPromiscuousMode(%d, %t): %s", d.nicID, true, terr)
+	}
+	if terr := d.stack.SetSpoofing(d.nicID, true); terr != nil {
+		d.Close()
+		return nil, fmt.Errorf("SetSpoofing(%d, %t): %s", d.nicID, true, terr)
+	}
+	d.setForwarders(ctx)
+	return d, nil
+}
+
+func newDevice(ctx context.Context, privateKey Key, listenPort uint16) (*Device, error) {
+	s := stack.New(stack.Options{
+		NetworkProtocols: []stack.NetworkProtocolFactory{
+			ipv4.NewProtocol,
+			ipv6.NewProtocol,
+		},
+		TransportProtocols: []stack.TransportProtocolFactory{
+			tcp.NewProtocol,
+			udp.NewProtocol,
+		},
+		HandleLocal: false,
+	})
+	sa := tcpip.TCPSACKEnabled(true)
+	s.SetTransportProtocolOption(tcp.ProtocolNumber, &sa)
+	mo := tcpip.TCPModerateReceiveBufferOption(true)
+	s.SetTransportProtocolOption(tcp.ProtocolNumber, &mo)
+
+	t := newNetTun(ctx, MTU)
+	nicID := s.NextNICID()
+	if terr := s.CreateNICWithOptions(nicID, t.ep, stack.NICOptions{Name: "wg", Context: ctx}); terr != nil {
+		s.Close()
+		return nil, fmt.Errorf("create NIC failed: %s", terr)
+	}
+	s.SetRouteTable([]tcpip.Route{
+		{
+			Destination: header.IPv4EmptySubnet,
+			NIC:         nicID,
+		},
+		{
+			Destination: header.IPv6EmptySubnet,
+			NIC:         nicID,
+		},
+	})
+
+	logger := &device.Logger{
+		Verbosef: func(format string, args ...any) { dlog.Tracef(ctx, "wireguard: "+format, args...) },
+		Errorf:   func(format string, args ...any) { dlog.Errorf(ctx, "wireguard: "+format, args...) },
+	}
+	d := &Device{
+		dev:    device.NewDevice(t, conn.NewDefaultBind(), logger),
+		stack:  s,
+		nicID:  nicID,
+		dialer: (&net.Dialer{Timeout: dialTimeout}).DialContext,
+	}
+	if err := d.dev.IpcSet(fmt.Sprintf("private_key=%s\nlisten_port=%d\n", privateKey.hex(), listenPort)); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to configure WireGuard device: %w", err)
+	}
+	if err := d.dev.Up(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to bring up WireGuard device: %w", err)
+	}
+	return d, nil
+}
+
+// AddPeer adds a peer with the given public key, or updates the peer if it already exists. Packets
+// to and from the given prefix are routed to and accepted from that peer. The endpoint is the address
+// of the peer, and can be omitted when the peer will make contact first. A keepAlive greater than zero
+// makes the device send a packet to the peer at that interval, which keeps NAT mappings alive.
+func (d *Device) AddPeer(publicKey Key, allowedIP netip.Prefix, endpoint netip.AddrPort, keepAlive time.Duration) error {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "public_key=%s\nreplace_allowed_ips=true\nallowed_ip=%s\n", publicKey.hex(), allowedIP)
+	if endpoint.IsValid() {
+		fmt.Fprintf(&sb, "endpoint=%s\n", endpoint)
+	}
+	if keepAlive > 0 {
+		fmt.Fprintf(&sb, "persistent_keepalive_interval=%d\n", int(keepAlive/time.Second))
+	}
+	if err := d.dev.IpcSet(sb.String()); err != nil {
+		return fmt.Errorf("failed to add WireGuard peer %s: %w", publicKey, err)
+	}
+	return nil
+}
+
+// RemovePeer removes the peer with the given public key.
+func (d *Device) RemovePeer(publicKey Key) error {
+	if err := d.dev.IpcSet(fmt.Sprintf("public_key=%s\nremove=true\n", publicKey.hex())); err != nil {
+		return fmt.Errorf("failed to remove WireGuard peer %s: %w", publicKey, err)
+	}
+	return nil
+}
+
+// WaitForHandshake waits until a handshake with the peer that has the given public key has completed, which
+// proves that the peer can be reached. A peer that has a keepAlive initiates a handshake as soon as it's added.
+func (d *Device) WaitForHandshake(ctx context.Context, publicKey Key) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		ok, err := d.hasHandshake(publicKey)
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no handshake with WireGuard peer %s: %w", publicKey, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Device) hasHandshake(publicKey Key) (bool, error) {
+	cfg, err := d.dev.IpcGet()
+	if err != nil {
+		return false, err
+	}
+	// The configuration lists the peers, each one starting with its public key, one setting per line.
+	inPeer := false
+	for _, line := range strings.Split(cfg, "\n") {
+		k, v, _ := strings.Cut(line, "=")
+		switch k {
+		case "public_key":
+			inPeer = v == publicKey.hex()
+		case "last_handshake_time_sec":
+			if inPeer {
+				return v != "0", nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// DialContext dials the given destination through the peers of this device. The network must
+// be "tcp" or "udp", optionally suffixed with "4" or "6", like for net.Dial.
+func (d *Device) DialContext(ctx context.Context, network string, addr netip.AddrPort) (net.Conn, error) {
+	fa := tcpip.FullAddress{
+		NIC:  d.nicID,
+		Addr: tcpip.AddrFromSlice(addr.Addr().Unmap().AsSlice()),
+		Port: addr.Port(),
+	}
+	pn := networkProtocol(addr.Addr().Unmap())
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return gonet.DialContextTCP(ctx, d.stack, fa, pn)
+	case "udp", "udp4", "udp6":
+		return gonet.DialUDP(d.stack, nil, &fa, pn)
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+}
+
+// Close closes the device and its network stack.
+func (d *Device) Close() {
+	d.dev.Close()
+	d.stack.Close()
+}
+
+func networkProtocol(addr netip.Addr) tcpip.NetworkProtocolNumber {
+	if addr.Is4() {
+		return ipv4.ProtocolNumber
+	}
+	return ipv6.ProtocolNumber
+}
@@ -0,0 +1,158 @@
+package wireguard
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	priv, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	pub := priv.PublicKey()
+	assert.NotEqual(t, priv, pub)
+
+	parsed, err := ParseKey(pub.String())
+	require.NoError(t, err)
+	assert.Equal(t, pub, parsed)
+
+	_, err = ParseKey("not a key")
+	assert.Error(t, err)
+	_, err = ParseKey("AAAA")
+	assert.Error(t, err)
+}
+
+// testContext returns a context that doesn't log to the test, because the WireGuard devices stop their
+// goroutines, which do log, asynchronously after they are closed.
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 10*time.Second)
+}
+
+// startDevices starts a server device on a random local port, and a client device that has the server as its
+// peer. The server dials the given address, regardless of the requested destination.
+func startDevices(ctx context.Context, t *testing.T, target string) *Device {
+	serverKey, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	clientKey, err := GeneratePrivateKey()
+	require.NoError(t, err)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := uint16(pc.LocalAddr().(*net.UDPAddr).Port)
+	require.NoError(t, pc.Close())
+
+	server, err := NewServer(ctx, serverKey, port)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	server.dialer = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, target)
+	}
+
+	clientIP := netip.MustParseAddr("198.18.0.2")
+	require.NoError(t, server.AddPeer(clientKey.PublicKey(), netip.PrefixFrom(clientIP, 32), netip.AddrPort{}, 0))
+
+	client, err := NewClient(ctx, clientKey, clientIP)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	endpoint := netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), port)
+	require.NoError(t, client.AddPeer(serverKey.PublicKey(), netip.MustParsePrefix("0.0.0.0/0"), endpoint, 25*time.Second))
+	require.NoError(t, client.WaitForHandshake(ctx, serverKey.PublicKey()))
+	return client
+}
+
+func TestDevice_TCP(t *testing.T) {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	client := startDevices(ctx, t, l.Addr().String())
+	conn, err := client.DialContext(ctx, "tcp", netip.MustParseAddrPort("10.1.2.3:8080"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	data := make([]byte, 256*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	go func() {
+		_, _ = conn.Write(data)
+	}()
+	echo := make([]byte, len(data))
+	_, err = io.ReadFull(conn, echo)
+	require.NoError(t, err)
+	assert.Equal(t, data, echo)
+}
+
+func TestDevice_WaitForHandshake(t *testing.T) {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	clientKey, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	client, err := NewClient(ctx, clientKey, netip.MustParseAddr("198.18.0.2"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// No one listens to the endpoint of the peer.
+	peerKey, err := GeneratePrivateKey()
+	require.NoError(t, err)
+	endpoint := netip.MustParseAddrPort("127.0.0.1:9")
+	require.NoError(t, client.AddPeer(peerKey.PublicKey(), netip.MustParsePrefix("0.0.0.0/0"), endpoint, 25*time.Second))
+
+	hsCtx, hsCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer hsCancel()
+	assert.ErrorIs(t, client.WaitForHandshake(hsCtx, peerKey.PublicKey()), context.DeadlineExceeded)
+}
+
+func TestDevice_UDP(t *testing.T) {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, MTU)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	client := startDevices(ctx, t, pc.LocalAddr().String())
+	conn, err := client.DialContext(ctx, "udp", netip.MustParseAddrPort("10.1.2.3:53"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, MTU)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:n]))
+}
@@ -0,0 +1,108 @@
+package wireguard
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// maxInFlight is the max number of in-flight TCP connection attempts.
+const maxInFlight = 512
+
+func (d *Device) setForwarders(ctx context.Context) {
+	tf := tcp.NewForwarder(d.stack, 0, maxInFlight, func(fr *tcp.ForwarderRequest) {
+		d.forwardTCP(ctx, fr)
+	})
+	d.stack.SetTransportProtocolHandler(tcp.ProtocolNumber, tf.HandlePacket)
+
+	uf := udp.NewForwarder(d.stack, func(fr *udp.ForwarderRequest) {
+		// The UDP forwarder calls this function synchronously.
+		go d.forwardUDP(ctx, fr)
+	})
+	d.stack.SetTransportProtocolHandler(udp.ProtocolNumber, uf.HandlePacket)
+}
+
+func destination(id stack.TransportEndpointID) string {
+	addr, _ := netip.AddrFromSlice(id.LocalAddress.AsSlice())
+	return netip.AddrPortFrom(addr, id.LocalPort).String()
+}
+
+// forwardTCP dials the destination of the given request, and completes the request only when that
+// succeeds, so that the peer sees a refused connection when the destination can't be reached.
+func (d *Device) forwardTCP(ctx context.Context, fr *tcp.ForwarderRequest) {
+	dst := destination(fr.ID())
+	target, err := d.dialer(ctx, "tcp", dst)
+	if err != nil {
+		dlog.Debugf(ctx, "wireguard: dial tcp %s failed: %v", dst, err)
+		fr.Complete(true)
+		return
+	}
+	wq := waiter.Queue{}
+	ep, terr := fr.CreateEndpoint(&wq)
+	if terr != nil {
+		dlog.Errorf(ctx, "wireguard: forward tcp %s: %s", dst, terr)
+		fr.Complete(true)
+		_ = target.Close()
+		return
+	}
+	fr.Complete(false)
+	ep.SocketOptions().SetKeepAlive(true)
+	dnet.Pipe(ctx, gonet.NewTCPConn(&wq, ep), target)
+}
+
+func (d *Device) forwardUDP(ctx context.Context, fr *udp.ForwarderRequest) {
+	dst := destination(fr.ID())
+	wq := waiter.Queue{}
+	ep, terr := fr.CreateEndpoint(&wq)
+	if terr != nil {
+		dlog.Errorf(ctx, "wireguard: forward udp %s: %s", dst, terr)
+		return
+	}
+	conn := gonet.NewUDPConn(&wq, ep)
+	target, err := d.dialer(ctx, "udp", dst)
+	if err != nil {
+		dlog.Debugf(ctx, "wireguard: dial udp %s failed: %v", dst, err)
+		_ = conn.Close()
+		return
+	}
+	idle := tunnel.GetIdleConfig(ctx, ipproto.UDP).Timeout
+	dnet.PipeWith(ctx, conn, target, func(dst, src net.Conn) { copyUDP(dst, src, idle) })
+}
+
+// copyUDP copies data from src to dst. A UDP connection has no end, so it's closed when it has been idle
+// for too long. Both directions share the deadline, so traffic in one direction keeps the other one alive.
+func copyUDP(dst, src net.Conn, idle time.Duration) {
+	if idle <= 0 {
+		dnet.CopyAndCloseWrite(dst, src)
+		return
+	}
+	buf := make([]byte, MTU)
+	for {
+		_ = src.SetReadDeadline(time.Now().Add(idle))
+		n, err := src.Read(buf)
+		if n > 0 {
+			_ = dst.SetReadDeadline(time.Now().Add(idle))
+			if _, err := dst.Write(buf[:n]); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	// Ending one direction ends the other.
+	_ = src.Close()
+	_ = dst.Close()
+}
@@ -0,0 +1,60 @@
+package wireguard
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Key is a Curve25519 key. Keys are exchanged in their base64 encoded form, which is also the form
+// that the wg tool uses.
+type Key [32]byte
+
+// GeneratePrivateKey generates a new random private key.
+func GeneratePrivateKey() (Key, error) {
+	var k Key
+	pk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return k, err
+	}
+	copy(k[:], pk.Bytes())
+	return k, nil
+}
+
+// PublicKey returns the public key of this private key.
+func (k Key) PublicKey() Key {
+	var pub Key
+	pk, err := ecdh.X25519().NewPrivateKey(k[:])
+	if err != nil {
+		// Can't happen. Every 32 byte value is a valid X25519 private key.
+		panic(err)
+	}
+	copy(pub[:], pk.PublicKey().Bytes())
+	return pub
+}
+
+// ParseKey parses a base64 encoded key.
+func ParseKey(s string) (Key, error) {
+	var k Key
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return k, fmt.Errorf("invalid WireGuard key: %w", err)
+	}
+	if len(b) != len(k) {
+		return k, fmt.Errorf("invalid WireGuard key: length is %d, expected %d", len(b), len(k))
+	}
+	copy(k[:], b)
+	return k, nil
+}
+
+// String returns the base64 encoded key.
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// hex returns the hex encoded key, which is the form used by the WireGuard configuration protocol.
+func (k Key) hex() string {
+	return hex.EncodeToString(k[:])
+}
@@ -0,0 +1,113 @@
+package wireguard
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.zx2c4.com/wireguard/tun"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// netTun is a tun.Device that, instead of being backed by an operating system device, exchanges
+// packets with a gVisor network stack. The WireGuard device reads the packets that the stack sends
+// and writes the packets that it decrypts.
+type netTun struct {
+	ep        *channel.Endpoint
+	ctx       context.Context
+	cancel    context.CancelFunc
+	events    chan tun.Event
+	closeOnce sync.Once
+}
+
+var _ tun.Device = (*netTun)(nil)
+
+func newNetTun(ctx context.Context, mtu uint32) *netTun {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &netTun{
+		ep:     channel.New(1024, mtu, ""),
+		ctx:    ctx,
+		cancel: cancel,
+		events: make(chan tun.Event, 1),
+	}
+	t.events <- tun.EventUp
+	return t
+}
+
+// File implements tun.Device. There's no file descriptor.
+func (t *netTun) File() *os.File {
+	return nil
+}
+
+// Read implements tun.Device. It reads one packet that the stack has sent.
+func (t *netTun) Read(bufs [][]byte, sizes []int, offset int) (int, error) {
+	pkt := t.ep.ReadContext(t.ctx)
+	if pkt == nil {
+		return 0, os.ErrClosed
+	}
+	view := pkt.ToView()
+	pkt.DecRef()
+	n, err := view.Read(bufs[0][offset:])
+	view.Release()
+	if err != nil {
+		return 0, err
+	}
+	sizes[0] = n
+	return 1, nil
+}
+
+// Write implements tun.Device. It delivers the given packets to the stack.
+func (t *netTun) Write(bufs [][]byte, offset int) (int, error) {
+	for _, buf := range bufs {
+		packet := buf[offset:]
+		if len(packet) == 0 {
+			continue
+		}
+		pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(packet)})
+		switch header.IPVersion(packet) {
+		case header.IPv4Version:
+			t.ep.InjectInbound(header.IPv4ProtocolNumber, pkt)
+		case header.IPv6Version:
+			t.ep.InjectInbound(header.IPv6ProtocolNumber, pkt)
+		default:
+			pkt.DecRef()
+			return 0, syscall.EAFNOSUPPORT
+		}
+		pkt.DecRef()
+	}
+	return len(bufs), nil
+}
+
+// MTU implements tun.Device.
+func (t *netTun) MTU() (int, error) {
+	return int(t.ep.MTU()), nil
+}
+
+// Name implements tun.Device.
+func (t *netTun) Name() (string, error) {
+	return "tel-wg", nil
+}
+
+// Events implements tun.Device.
+func (t *netTun) Events() <-chan tun.Event {
+	return t.events
+}
+
+// Close implements tun.Device.
+func (t *netTun) Close() error {
+	t.closeOnce.Do(func() {
+		t.cancel()
+		t.ep.Close()
+		close(t.events)
+	})
+	return nil
+}
+
+// BatchSize implements tun.Device.
+func (t *netTun) BatchSize() int {
+	return 1
+}
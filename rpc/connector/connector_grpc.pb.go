@@ -40,8 +40,10 @@ const (
 	Connector_List_FullMethodName                    = "/telepresence.connector.Connector/List"
 	Connector_WatchWorkloads_FullMethodName          = "/telepresence.connector.Connector/WatchWorkloads"
 	Connector_SetLogLevel_FullMethodName             = "/telepresence.connector.Connector/SetLogLevel"
+	Connector_Profiling_FullMethodName               = "/telepresence.connector.Connector/Profiling"
 	Connector_Quit_FullMethodName                    = "/telepresence.connector.Connector/Quit"
 	Connector_GatherLogs_FullMethodName              = "/telepresence.connector.Connector/GatherLogs"
+	Connector_StreamLogs_FullMethodName              = "/telepresence.connector.Connector/StreamLogs"
 	Connector_GatherTraces_FullMethodName            = "/telepresence.connector.Connector/GatherTraces"
 	Connector_AddInterceptor_FullMethodName          = "/telepresence.connector.Connector/AddInterceptor"
 	Connector_RemoveInterceptor_FullMethodName       = "/telepresence.connector.Connector/RemoveInterceptor"
@@ -50,6 +52,15 @@ const (
 	Connector_GetConfig_FullMethodName               = "/telepresence.connector.Connector/GetConfig"
 	Connector_SetDNSExcludes_FullMethodName          = "/telepresence.connector.Connector/SetDNSExcludes"
 	Connector_SetDNSMappings_FullMethodName          = "/telepresence.connector.Connector/SetDNSMappings"
+	Connector_SetProxySubnets_FullMethodName         = "/telepresence.connector.Connector/SetProxySubnets"
+	Connector_FlushDNSCache_FullMethodName           = "/telepresence.connector.Connector/FlushDNSCache"
+	Connector_ListClientSessions_FullMethodName      = "/telepresence.connector.Connector/ListClientSessions"
+	Connector_EvictClientSession_FullMethodName      = "/telepresence.connector.Connector/EvictClientSession"
+	Connector_WatchInterceptEvents_FullMethodName    = "/telepresence.connector.Connector/WatchInterceptEvents"
+	Connector_GetDeclaredIntercept_FullMethodName    = "/telepresence.connector.Connector/GetDeclaredIntercept"
+	Connector_GetAgentConfigGCReport_FullMethodName  = "/telepresence.connector.Connector/GetAgentConfigGCReport"
+	Connector_GetConnectionStats_FullMethodName      = "/telepresence.connector.Connector/GetConnectionStats"
+	Connector_GetManagerConfig_FullMethodName        = "/telepresence.connector.Connector/GetManagerConfig"
 )
 
 // ConnectorClient is the client API for Connector service.
@@ -102,11 +113,17 @@ type ConnectorClient interface {
 	WatchWorkloads(ctx context.Context, in *WatchWorkloadsRequest, opts ...grpc.CallOption) (Connector_WatchWorkloadsClient, error)
 	// SetLogLevel will temporarily change the log-level of the traffic-manager, traffic-agent, and user and root daemons.
 	SetLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Profiling starts the debug HTTP listeners of the user and root daemons, unless
+	// they're already running, and returns their addresses.
+	Profiling(ctx context.Context, in *daemon.ProfilingRequest, opts ...grpc.CallOption) (*ProfilingInfo, error)
 	// Quits (terminates) the connector process.
 	Quit(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// GatherLogs will acquire logs for the various Telepresence components in kubernetes
 	// (pending the request) and return them to the caller
 	GatherLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (*LogsResponse, error)
+	// StreamLogs streams the logs of the traffic-manager and of the traffic-agents in the connected
+	// namespace.
+	StreamLogs(ctx context.Context, in *StreamLogsRequest, opts ...grpc.CallOption) (Connector_StreamLogsClient, error)
 	// GatherTraces will acquire traces for the various Telepresence components in kubernetes
 	// (pending the request) and save them in a file.
 	GatherTraces(ctx context.Context, in *TracesRequest, opts ...grpc.CallOption) (*common.Result, error)
@@ -126,6 +143,28 @@ type ConnectorClient interface {
 	SetDNSExcludes(ctx context.Context, in *daemon.SetDNSExcludesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(ctx context.Context, in *daemon.SetDNSMappingsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// SetProxySubnets replaces the also-proxy and never-proxy subnets of the current
+	// session without the need to reconnect.
+	SetProxySubnets(ctx context.Context, in *daemon.SetProxySubnetsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// FlushDNSCache removes all entries from the cache of the root daemon's DNS resolver.
+	FlushDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListClientSessions returns the client sessions that are connected to the
+	// traffic-manager.
+	ListClientSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.ClientSessionList, error)
+	// EvictClientSession forcibly terminates a client session in the traffic-manager.
+	EvictClientSession(ctx context.Context, in *manager.SessionInfo, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// WatchInterceptEvents streams the traffic-manager's intercept events.
+	WatchInterceptEvents(ctx context.Context, in *manager.InterceptEventsRequest, opts ...grpc.CallOption) (Connector_WatchInterceptEventsClient, error)
+	// GetDeclaredIntercept returns the intercept declared by an InterceptSpec resource.
+	GetDeclaredIntercept(ctx context.Context, in *manager.GetDeclaredInterceptRequest, opts ...grpc.CallOption) (*manager.DeclaredIntercept, error)
+	// GetAgentConfigGCReport returns the traffic-manager's report of removed orphaned
+	// agent config entries.
+	GetAgentConfigGCReport(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AgentConfigGCReport, error)
+	// GetConnectionStats returns the traffic-manager's statistics of the active
+	// tunneled connections of this session.
+	GetConnectionStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.ConnectionStatsResponse, error)
+	// GetManagerConfig returns the active settings of the traffic-manager.
+	GetManagerConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.ManagerConfig, error)
 }
 
 type connectorClient struct {
@@ -329,6 +368,16 @@ func (c *connectorClient) SetLogLevel(ctx context.Context, in *LogLevelRequest,
 	return out, nil
 }
 
+func (c *connectorClient) Profiling(ctx context.Context, in *daemon.ProfilingRequest, opts ...grpc.CallOption) (*ProfilingInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProfilingInfo)
+	err := c.cc.Invoke(ctx, Connector_Profiling_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *connectorClient) Quit(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -349,6 +398,39 @@ func (c *connectorClient) GatherLogs(ctx context.Context, in *LogsRequest, opts
 	return out, nil
 }
 
+func (c *connectorClient) StreamLogs(ctx context.Context, in *StreamLogsRequest, opts ...grpc.CallOption) (Connector_StreamLogsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[1], Connector_StreamLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorStreamLogsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_StreamLogsClient interface {
+	Recv() (*LogLine, error)
+	grpc.ClientStream
+}
+
+type connectorStreamLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorStreamLogsClient) Recv() (*LogLine, error) {
+	m := new(LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *connectorClient) GatherTraces(ctx context.Context, in *TracesRequest, opts ...grpc.CallOption) (*common.Result, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(common.Result)
@@ -429,6 +511,119 @@ func (c *connectorClient) SetDNSMappings(ctx context.Context, in *daemon.SetDNSM
 	return out, nil
 }
 
+func (c *connectorClient) SetProxySubnets(ctx context.Context, in *daemon.SetProxySubnetsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_SetProxySubnets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) FlushDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_FlushDNSCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) ListClientSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.ClientSessionList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.ClientSessionList)
+	err := c.cc.Invoke(ctx, Connector_ListClientSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) EvictClientSession(ctx context.Context, in *manager.SessionInfo, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_EvictClientSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) WatchInterceptEvents(ctx context.Context, in *manager.InterceptEventsRequest, opts ...grpc.CallOption) (Connector_WatchInterceptEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[2], Connector_WatchInterceptEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorWatchInterceptEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_WatchInterceptEventsClient interface {
+	Recv() (*manager.InterceptEvent, error)
+	grpc.ClientStream
+}
+
+type connectorWatchInterceptEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorWatchInterceptEventsClient) Recv() (*manager.InterceptEvent, error) {
+	m := new(manager.InterceptEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) GetDeclaredIntercept(ctx context.Context, in *manager.GetDeclaredInterceptRequest, opts ...grpc.CallOption) (*manager.DeclaredIntercept, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.DeclaredIntercept)
+	err := c.cc.Invoke(ctx, Connector_GetDeclaredIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetAgentConfigGCReport(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AgentConfigGCReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.AgentConfigGCReport)
+	err := c.cc.Invoke(ctx, Connector_GetAgentConfigGCReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetConnectionStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.ConnectionStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.ConnectionStatsResponse)
+	err := c.cc.Invoke(ctx, Connector_GetConnectionStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetManagerConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.ManagerConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.ManagerConfig)
+	err := c.cc.Invoke(ctx, Connector_GetManagerConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ConnectorServer is the server API for Connector service.
 // All implementations must embed UnimplementedConnectorServer
 // for forward compatibility
@@ -479,11 +674,17 @@ type ConnectorServer interface {
 	WatchWorkloads(*WatchWorkloadsRequest, Connector_WatchWorkloadsServer) error
 	// SetLogLevel will temporarily change the log-level of the traffic-manager, traffic-agent, and user and root daemons.
 	SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error)
+	// Profiling starts the debug HTTP listeners of the user and root daemons, unless
+	// they're already running, and returns their addresses.
+	Profiling(context.Context, *daemon.ProfilingRequest) (*ProfilingInfo, error)
 	// Quits (terminates) the connector process.
 	Quit(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	// GatherLogs will acquire logs for the various Telepresence components in kubernetes
 	// (pending the request) and return them to the caller
 	GatherLogs(context.Context, *LogsRequest) (*LogsResponse, error)
+	// StreamLogs streams the logs of the traffic-manager and of the traffic-agents in the connected
+	// namespace.
+	StreamLogs(*StreamLogsRequest, Connector_StreamLogsServer) error
 	// GatherTraces will acquire traces for the various Telepresence components in kubernetes
 	// (pending the request) and save them in a file.
 	GatherTraces(context.Context, *TracesRequest) (*common.Result, error)
@@ -503,6 +704,28 @@ type ConnectorServer interface {
 	SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error)
+	// SetProxySubnets replaces the also-proxy and never-proxy subnets of the current
+	// session without the need to reconnect.
+	SetProxySubnets(context.Context, *daemon.SetProxySubnetsRequest) (*emptypb.Empty, error)
+	// FlushDNSCache removes all entries from the cache of the root daemon's DNS resolver.
+	FlushDNSCache(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// ListClientSessions returns the client sessions that are connected to the
+	// traffic-manager.
+	ListClientSessions(context.Context, *emptypb.Empty) (*manager.ClientSessionList, error)
+	// EvictClientSession forcibly terminates a client session in the traffic-manager.
+	EvictClientSession(context.Context, *manager.SessionInfo) (*emptypb.Empty, error)
+	// WatchInterceptEvents streams the traffic-manager's intercept events.
+	WatchInterceptEvents(*manager.InterceptEventsRequest, Connector_WatchInterceptEventsServer) error
+	// GetDeclaredIntercept returns the intercept declared by an InterceptSpec resource.
+	GetDeclaredIntercept(context.Context, *manager.GetDeclaredInterceptRequest) (*manager.DeclaredIntercept, error)
+	// GetAgentConfigGCReport returns the traffic-manager's report of removed orphaned
+	// agent config entries.
+	GetAgentConfigGCReport(context.Context, *emptypb.Empty) (*manager.AgentConfigGCReport, error)
+	// GetConnectionStats returns the traffic-manager's statistics of the active
+	// tunneled connections of this session.
+	GetConnectionStats(context.Context, *emptypb.Empty) (*manager.ConnectionStatsResponse, error)
+	// GetManagerConfig returns the active settings of the traffic-manager.
+	GetManagerConfig(context.Context, *emptypb.Empty) (*manager.ManagerConfig, error)
 	mustEmbedUnimplementedConnectorServer()
 }
 
@@ -561,12 +784,18 @@ func (UnimplementedConnectorServer) WatchWorkloads(*WatchWorkloadsRequest, Conne
 func (UnimplementedConnectorServer) SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
 }
+func (UnimplementedConnectorServer) Profiling(context.Context, *daemon.ProfilingRequest) (*ProfilingInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Profiling not implemented")
+}
 func (UnimplementedConnectorServer) Quit(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Quit not implemented")
 }
 func (UnimplementedConnectorServer) GatherLogs(context.Context, *LogsRequest) (*LogsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GatherLogs not implemented")
 }
+func (UnimplementedConnectorServer) StreamLogs(*StreamLogsRequest, Connector_StreamLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLogs not implemented")
+}
 func (UnimplementedConnectorServer) GatherTraces(context.Context, *TracesRequest) (*common.Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GatherTraces not implemented")
 }
@@ -591,6 +820,33 @@ func (UnimplementedConnectorServer) SetDNSExcludes(context.Context, *daemon.SetD
 func (UnimplementedConnectorServer) SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetDNSMappings not implemented")
 }
+func (UnimplementedConnectorServer) SetProxySubnets(context.Context, *daemon.SetProxySubnetsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProxySubnets not implemented")
+}
+func (UnimplementedConnectorServer) FlushDNSCache(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushDNSCache not implemented")
+}
+func (UnimplementedConnectorServer) ListClientSessions(context.Context, *emptypb.Empty) (*manager.ClientSessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClientSessions not implemented")
+}
+func (UnimplementedConnectorServer) EvictClientSession(context.Context, *manager.SessionInfo) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvictClientSession not implemented")
+}
+func (UnimplementedConnectorServer) WatchInterceptEvents(*manager.InterceptEventsRequest, Connector_WatchInterceptEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInterceptEvents not implemented")
+}
+func (UnimplementedConnectorServer) GetDeclaredIntercept(context.Context, *manager.GetDeclaredInterceptRequest) (*manager.DeclaredIntercept, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeclaredIntercept not implemented")
+}
+func (UnimplementedConnectorServer) GetAgentConfigGCReport(context.Context, *emptypb.Empty) (*manager.AgentConfigGCReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAgentConfigGCReport not implemented")
+}
+func (UnimplementedConnectorServer) GetConnectionStats(context.Context, *emptypb.Empty) (*manager.ConnectionStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnectionStats not implemented")
+}
+func (UnimplementedConnectorServer) GetManagerConfig(context.Context, *emptypb.Empty) (*manager.ManagerConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetManagerConfig not implemented")
+}
 func (UnimplementedConnectorServer) mustEmbedUnimplementedConnectorServer() {}
 
 // UnsafeConnectorServer may be embedded to opt out of forward compatibility for this service.
@@ -913,6 +1169,24 @@ func _Connector_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_Profiling_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(daemon.ProfilingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).Profiling(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_Profiling_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).Profiling(ctx, req.(*daemon.ProfilingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Connector_Quit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -949,6 +1223,27 @@ func _Connector_GatherLogs_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_StreamLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).StreamLogs(m, &connectorStreamLogsServer{ServerStream: stream})
+}
+
+type Connector_StreamLogsServer interface {
+	Send(*LogLine) error
+	grpc.ServerStream
+}
+
+type connectorStreamLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorStreamLogsServer) Send(m *LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Connector_GatherTraces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(TracesRequest)
 	if err := dec(in); err != nil {
@@ -1093,6 +1388,171 @@ func _Connector_SetDNSMappings_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_SetProxySubnets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(daemon.SetProxySubnetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).SetProxySubnets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_SetProxySubnets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).SetProxySubnets(ctx, req.(*daemon.SetProxySubnetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_FlushDNSCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).FlushDNSCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_FlushDNSCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).FlushDNSCache(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_ListClientSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).ListClientSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_ListClientSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).ListClientSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_EvictClientSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).EvictClientSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_EvictClientSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).EvictClientSession(ctx, req.(*manager.SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_WatchInterceptEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(manager.InterceptEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).WatchInterceptEvents(m, &connectorWatchInterceptEventsServer{ServerStream: stream})
+}
+
+type Connector_WatchInterceptEventsServer interface {
+	Send(*manager.InterceptEvent) error
+	grpc.ServerStream
+}
+
+type connectorWatchInterceptEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorWatchInterceptEventsServer) Send(m *manager.InterceptEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_GetDeclaredIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.GetDeclaredInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetDeclaredIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetDeclaredIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetDeclaredIntercept(ctx, req.(*manager.GetDeclaredInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetAgentConfigGCReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetAgentConfigGCReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetAgentConfigGCReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetAgentConfigGCReport(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetConnectionStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetConnectionStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetConnectionStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetConnectionStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetManagerConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetManagerConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetManagerConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetManagerConfig(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Connector_ServiceDesc is the grpc.ServiceDesc for Connector service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1164,6 +1624,10 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetLogLevel",
 			Handler:    _Connector_SetLogLevel_Handler,
 		},
+		{
+			MethodName: "Profiling",
+			Handler:    _Connector_Profiling_Handler,
+		},
 		{
 			MethodName: "Quit",
 			Handler:    _Connector_Quit_Handler,
@@ -1204,6 +1668,38 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetDNSMappings",
 			Handler:    _Connector_SetDNSMappings_Handler,
 		},
+		{
+			MethodName: "SetProxySubnets",
+			Handler:    _Connector_SetProxySubnets_Handler,
+		},
+		{
+			MethodName: "FlushDNSCache",
+			Handler:    _Connector_FlushDNSCache_Handler,
+		},
+		{
+			MethodName: "ListClientSessions",
+			Handler:    _Connector_ListClientSessions_Handler,
+		},
+		{
+			MethodName: "EvictClientSession",
+			Handler:    _Connector_EvictClientSession_Handler,
+		},
+		{
+			MethodName: "GetDeclaredIntercept",
+			Handler:    _Connector_GetDeclaredIntercept_Handler,
+		},
+		{
+			MethodName: "GetAgentConfigGCReport",
+			Handler:    _Connector_GetAgentConfigGCReport_Handler,
+		},
+		{
+			MethodName: "GetConnectionStats",
+			Handler:    _Connector_GetConnectionStats_Handler,
+		},
+		{
+			MethodName: "GetManagerConfig",
+			Handler:    _Connector_GetManagerConfig_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1211,6 +1707,16 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Connector_WatchWorkloads_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamLogs",
+			Handler:       _Connector_StreamLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchInterceptEvents",
+			Handler:       _Connector_WatchInterceptEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "connector/connector.proto",
 }
@@ -1221,7 +1727,10 @@ const (
 	ManagerProxy_EnsureAgent_FullMethodName      = "/telepresence.connector.ManagerProxy/EnsureAgent"
 	ManagerProxy_WatchClusterInfo_FullMethodName = "/telepresence.connector.ManagerProxy/WatchClusterInfo"
 	ManagerProxy_LookupDNS_FullMethodName        = "/telepresence.connector.ManagerProxy/LookupDNS"
+	ManagerProxy_QUICEndpoint_FullMethodName     = "/telepresence.connector.ManagerProxy/QUICEndpoint"
+	ManagerProxy_WireGuardPeer_FullMethodName    = "/telepresence.connector.ManagerProxy/WireGuardPeer"
 	ManagerProxy_Tunnel_FullMethodName           = "/telepresence.connector.ManagerProxy/Tunnel"
+	ManagerProxy_MuxTunnel_FullMethodName        = "/telepresence.connector.ManagerProxy/MuxTunnel"
 )
 
 // ManagerProxyClient is the client API for ManagerProxy service.
@@ -1245,6 +1754,11 @@ type ManagerProxyClient interface {
 	// LookupDNS performs a DNS lookup in the cluster. If the caller has intercepts
 	// active, the lookup will be performed from the intercepted pods.
 	LookupDNS(ctx context.Context, in *manager.DNSRequest, opts ...grpc.CallOption) (*manager.DNSResponse, error)
+	// QUICEndpoint returns the QUIC endpoint of the traffic-manager.
+	QUICEndpoint(ctx context.Context, in *manager.SessionInfo, opts ...grpc.CallOption) (*manager.QUICEndpointResponse, error)
+	// WireGuardPeer adds the client as a peer of the traffic-manager's
+	// WireGuard endpoint.
+	WireGuardPeer(ctx context.Context, in *manager.WireGuardPeerRequest, opts ...grpc.CallOption) (*manager.WireGuardPeerResponse, error)
 	// A Tunnel represents one single connection where the client or
 	// traffic-agent represents one end (the client-side) and the
 	// traffic-manager represents the other (the server side). The first
@@ -1252,6 +1766,10 @@ type ManagerProxyClient interface {
 	// always contain the session ID, connection ID, and timeouts used by
 	// the dialer endpoints.
 	Tunnel(ctx context.Context, opts ...grpc.CallOption) (ManagerProxy_TunnelClient, error)
+	// MuxTunnel carries many tunnels over one stream. Each tunnel behaves
+	// like a Tunnel, and has its own flow control, so that a slow tunnel
+	// doesn't block the others.
+	MuxTunnel(ctx context.Context, opts ...grpc.CallOption) (ManagerProxy_MuxTunnelClient, error)
 }
 
 type managerProxyClient struct {
@@ -1335,6 +1853,26 @@ func (c *managerProxyClient) LookupDNS(ctx context.Context, in *manager.DNSReque
 	return out, nil
 }
 
+func (c *managerProxyClient) QUICEndpoint(ctx context.Context, in *manager.SessionInfo, opts ...grpc.CallOption) (*manager.QUICEndpointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.QUICEndpointResponse)
+	err := c.cc.Invoke(ctx, ManagerProxy_QUICEndpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerProxyClient) WireGuardPeer(ctx context.Context, in *manager.WireGuardPeerRequest, opts ...grpc.CallOption) (*manager.WireGuardPeerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.WireGuardPeerResponse)
+	err := c.cc.Invoke(ctx, ManagerProxy_WireGuardPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerProxyClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (ManagerProxy_TunnelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &ManagerProxy_ServiceDesc.Streams[1], ManagerProxy_Tunnel_FullMethodName, cOpts...)
@@ -1367,6 +1905,38 @@ func (x *managerProxyTunnelClient) Recv() (*manager.TunnelMessage, error) {
 	return m, nil
 }
 
+func (c *managerProxyClient) MuxTunnel(ctx context.Context, opts ...grpc.CallOption) (ManagerProxy_MuxTunnelClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ManagerProxy_ServiceDesc.Streams[2], ManagerProxy_MuxTunnel_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerProxyMuxTunnelClient{ClientStream: stream}
+	return x, nil
+}
+
+type ManagerProxy_MuxTunnelClient interface {
+	Send(*manager.MuxTunnelMessage) error
+	Recv() (*manager.MuxTunnelMessage, error)
+	grpc.ClientStream
+}
+
+type managerProxyMuxTunnelClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerProxyMuxTunnelClient) Send(m *manager.MuxTunnelMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *managerProxyMuxTunnelClient) Recv() (*manager.MuxTunnelMessage, error) {
+	m := new(manager.MuxTunnelMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ManagerProxyServer is the server API for ManagerProxy service.
 // All implementations must embed UnimplementedManagerProxyServer
 // for forward compatibility
@@ -1388,6 +1958,11 @@ type ManagerProxyServer interface {
 	// LookupDNS performs a DNS lookup in the cluster. If the caller has intercepts
 	// active, the lookup will be performed from the intercepted pods.
 	LookupDNS(context.Context, *manager.DNSRequest) (*manager.DNSResponse, error)
+	// QUICEndpoint returns the QUIC endpoint of the traffic-manager.
+	QUICEndpoint(context.Context, *manager.SessionInfo) (*manager.QUICEndpointResponse, error)
+	// WireGuardPeer adds the client as a peer of the traffic-manager's
+	// WireGuard endpoint.
+	WireGuardPeer(context.Context, *manager.WireGuardPeerRequest) (*manager.WireGuardPeerResponse, error)
 	// A Tunnel represents one single connection where the client or
 	// traffic-agent represents one end (the client-side) and the
 	// traffic-manager represents the other (the server side). The first
@@ -1395,6 +1970,10 @@ type ManagerProxyServer interface {
 	// always contain the session ID, connection ID, and timeouts used by
 	// the dialer endpoints.
 	Tunnel(ManagerProxy_TunnelServer) error
+	// MuxTunnel carries many tunnels over one stream. Each tunnel behaves
+	// like a Tunnel, and has its own flow control, so that a slow tunnel
+	// doesn't block the others.
+	MuxTunnel(ManagerProxy_MuxTunnelServer) error
 	mustEmbedUnimplementedManagerProxyServer()
 }
 
@@ -1417,9 +1996,18 @@ func (UnimplementedManagerProxyServer) WatchClusterInfo(*manager.SessionInfo, Ma
 func (UnimplementedManagerProxyServer) LookupDNS(context.Context, *manager.DNSRequest) (*manager.DNSResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LookupDNS not implemented")
 }
+func (UnimplementedManagerProxyServer) QUICEndpoint(context.Context, *manager.SessionInfo) (*manager.QUICEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QUICEndpoint not implemented")
+}
+func (UnimplementedManagerProxyServer) WireGuardPeer(context.Context, *manager.WireGuardPeerRequest) (*manager.WireGuardPeerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WireGuardPeer not implemented")
+}
 func (UnimplementedManagerProxyServer) Tunnel(ManagerProxy_TunnelServer) error {
 	return status.Errorf(codes.Unimplemented, "method Tunnel not implemented")
 }
+func (UnimplementedManagerProxyServer) MuxTunnel(ManagerProxy_MuxTunnelServer) error {
+	return status.Errorf(codes.Unimplemented, "method MuxTunnel not implemented")
+}
 func (UnimplementedManagerProxyServer) mustEmbedUnimplementedManagerProxyServer() {}
 
 // UnsafeManagerProxyServer may be embedded to opt out of forward compatibility for this service.
@@ -1526,6 +2114,42 @@ func _ManagerProxy_LookupDNS_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ManagerProxy_QUICEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerProxyServer).QUICEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManagerProxy_QUICEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerProxyServer).QUICEndpoint(ctx, req.(*manager.SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagerProxy_WireGuardPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.WireGuardPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerProxyServer).WireGuardPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManagerProxy_WireGuardPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerProxyServer).WireGuardPeer(ctx, req.(*manager.WireGuardPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ManagerProxy_Tunnel_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(ManagerProxyServer).Tunnel(&managerProxyTunnelServer{ServerStream: stream})
 }
@@ -1552,6 +2176,32 @@ func (x *managerProxyTunnelServer) Recv() (*manager.TunnelMessage, error) {
 	return m, nil
 }
 
+func _ManagerProxy_MuxTunnel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ManagerProxyServer).MuxTunnel(&managerProxyMuxTunnelServer{ServerStream: stream})
+}
+
+type ManagerProxy_MuxTunnelServer interface {
+	Send(*manager.MuxTunnelMessage) error
+	Recv() (*manager.MuxTunnelMessage, error)
+	grpc.ServerStream
+}
+
+type managerProxyMuxTunnelServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerProxyMuxTunnelServer) Send(m *manager.MuxTunnelMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *managerProxyMuxTunnelServer) Recv() (*manager.MuxTunnelMessage, error) {
+	m := new(manager.MuxTunnelMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ManagerProxy_ServiceDesc is the grpc.ServiceDesc for ManagerProxy service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1575,6 +2225,14 @@ var ManagerProxy_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "LookupDNS",
 			Handler:    _ManagerProxy_LookupDNS_Handler,
 		},
+		{
+			MethodName: "QUICEndpoint",
+			Handler:    _ManagerProxy_QUICEndpoint_Handler,
+		},
+		{
+			MethodName: "WireGuardPeer",
+			Handler:    _ManagerProxy_WireGuardPeer_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1588,6 +2246,12 @@ var ManagerProxy_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "MuxTunnel",
+			Handler:       _ManagerProxy_MuxTunnel_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "connector/connector.proto",
 }
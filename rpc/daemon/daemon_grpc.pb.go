@@ -31,7 +31,11 @@ const (
 	Daemon_SetDNSTopLevelDomains_FullMethodName = "/telepresence.daemon.Daemon/SetDNSTopLevelDomains"
 	Daemon_SetDNSExcludes_FullMethodName        = "/telepresence.daemon.Daemon/SetDNSExcludes"
 	Daemon_SetDNSMappings_FullMethodName        = "/telepresence.daemon.Daemon/SetDNSMappings"
+	Daemon_SetProxySubnets_FullMethodName       = "/telepresence.daemon.Daemon/SetProxySubnets"
+	Daemon_RefreshRoutes_FullMethodName         = "/telepresence.daemon.Daemon/RefreshRoutes"
+	Daemon_FlushDNSCache_FullMethodName         = "/telepresence.daemon.Daemon/FlushDNSCache"
 	Daemon_SetLogLevel_FullMethodName           = "/telepresence.daemon.Daemon/SetLogLevel"
+	Daemon_Profiling_FullMethodName             = "/telepresence.daemon.Daemon/Profiling"
 	Daemon_WaitForNetwork_FullMethodName        = "/telepresence.daemon.Daemon/WaitForNetwork"
 	Daemon_WaitForAgentIP_FullMethodName        = "/telepresence.daemon.Daemon/WaitForAgentIP"
 )
@@ -61,8 +65,19 @@ type DaemonClient interface {
 	SetDNSExcludes(ctx context.Context, in *SetDNSExcludesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(ctx context.Context, in *SetDNSMappingsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// SetProxySubnets replaces the also-proxy and never-proxy subnets of the current
+	// session and updates its routes without affecting existing connections.
+	SetProxySubnets(ctx context.Context, in *SetProxySubnetsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RefreshRoutes reapplies the routes of the current session so that they are
+	// bound to the host's current network, e.g. after the host switched network.
+	RefreshRoutes(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// FlushDNSCache removes all entries from the cache of the DNS resolver.
+	FlushDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetLogLevel will temporarily set the log-level for the daemon for a duration that is determined b the request.
 	SetLogLevel(ctx context.Context, in *manager.LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Profiling starts the debug HTTP listener of the daemon, unless it's already
+	// running, and returns its address.
+	Profiling(ctx context.Context, in *ProfilingRequest, opts ...grpc.CallOption) (*ProfilingInfo, error)
 	// WaitForNetwork waits for the network of the currently connected session to become ready.
 	WaitForNetwork(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
@@ -167,6 +182,36 @@ func (c *daemonClient) SetDNSMappings(ctx context.Context, in *SetDNSMappingsReq
 	return out, nil
 }
 
+func (c *daemonClient) SetProxySubnets(ctx context.Context, in *SetProxySubnetsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Daemon_SetProxySubnets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) RefreshRoutes(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Daemon_RefreshRoutes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) FlushDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Daemon_FlushDNSCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *daemonClient) SetLogLevel(ctx context.Context, in *manager.LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -177,6 +222,16 @@ func (c *daemonClient) SetLogLevel(ctx context.Context, in *manager.LogLevelRequ
 	return out, nil
 }
 
+func (c *daemonClient) Profiling(ctx context.Context, in *ProfilingRequest, opts ...grpc.CallOption) (*ProfilingInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProfilingInfo)
+	err := c.cc.Invoke(ctx, Daemon_Profiling_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *daemonClient) WaitForNetwork(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -222,8 +277,19 @@ type DaemonServer interface {
 	SetDNSExcludes(context.Context, *SetDNSExcludesRequest) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(context.Context, *SetDNSMappingsRequest) (*emptypb.Empty, error)
+	// SetProxySubnets replaces the also-proxy and never-proxy subnets of the current
+	// session and updates its routes without affecting existing connections.
+	SetProxySubnets(context.Context, *SetProxySubnetsRequest) (*emptypb.Empty, error)
+	// RefreshRoutes reapplies the routes of the current session so that they are
+	// bound to the host's current network, e.g. after the host switched network.
+	RefreshRoutes(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// FlushDNSCache removes all entries from the cache of the DNS resolver.
+	FlushDNSCache(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	// SetLogLevel will temporarily set the log-level for the daemon for a duration that is determined b the request.
 	SetLogLevel(context.Context, *manager.LogLevelRequest) (*emptypb.Empty, error)
+	// Profiling starts the debug HTTP listener of the daemon, unless it's already
+	// running, and returns its address.
+	Profiling(context.Context, *ProfilingRequest) (*ProfilingInfo, error)
 	// WaitForNetwork waits for the network of the currently connected session to become ready.
 	WaitForNetwork(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
@@ -262,9 +328,21 @@ func (UnimplementedDaemonServer) SetDNSExcludes(context.Context, *SetDNSExcludes
 func (UnimplementedDaemonServer) SetDNSMappings(context.Context, *SetDNSMappingsRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetDNSMappings not implemented")
 }
+func (UnimplementedDaemonServer) SetProxySubnets(context.Context, *SetProxySubnetsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProxySubnets not implemented")
+}
+func (UnimplementedDaemonServer) RefreshRoutes(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshRoutes not implemented")
+}
+func (UnimplementedDaemonServer) FlushDNSCache(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushDNSCache not implemented")
+}
 func (UnimplementedDaemonServer) SetLogLevel(context.Context, *manager.LogLevelRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
 }
+func (UnimplementedDaemonServer) Profiling(context.Context, *ProfilingRequest) (*ProfilingInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Profiling not implemented")
+}
 func (UnimplementedDaemonServer) WaitForNetwork(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WaitForNetwork not implemented")
 }
@@ -446,6 +524,60 @@ func _Daemon_SetDNSMappings_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_SetProxySubnets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetProxySubnetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).SetProxySubnets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_SetProxySubnets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).SetProxySubnets(ctx, req.(*SetProxySubnetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_RefreshRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).RefreshRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_RefreshRoutes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).RefreshRoutes(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_FlushDNSCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).FlushDNSCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_FlushDNSCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).FlushDNSCache(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Daemon_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(manager.LogLevelRequest)
 	if err := dec(in); err != nil {
@@ -464,6 +596,24 @@ func _Daemon_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_Profiling_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProfilingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).Profiling(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_Profiling_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).Profiling(ctx, req.(*ProfilingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Daemon_WaitForNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -543,10 +693,26 @@ var Daemon_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetDNSMappings",
 			Handler:    _Daemon_SetDNSMappings_Handler,
 		},
+		{
+			MethodName: "SetProxySubnets",
+			Handler:    _Daemon_SetProxySubnets_Handler,
+		},
+		{
+			MethodName: "RefreshRoutes",
+			Handler:    _Daemon_RefreshRoutes_Handler,
+		},
+		{
+			MethodName: "FlushDNSCache",
+			Handler:    _Daemon_FlushDNSCache_Handler,
+		},
 		{
 			MethodName: "SetLogLevel",
 			Handler:    _Daemon_SetLogLevel_Handler,
 		},
+		{
+			MethodName: "Profiling",
+			Handler:    _Daemon_Profiling_Handler,
+		},
 		{
 			MethodName: "WaitForNetwork",
 			Handler:    _Daemon_WaitForNetwork_Handler,
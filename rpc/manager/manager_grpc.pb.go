@@ -30,18 +30,28 @@ const (
 	Manager_CanConnectAmbassadorCloud_FullMethodName = "/telepresence.manager.Manager/CanConnectAmbassadorCloud"
 	Manager_GetCloudConfig_FullMethodName            = "/telepresence.manager.Manager/GetCloudConfig"
 	Manager_GetClientConfig_FullMethodName           = "/telepresence.manager.Manager/GetClientConfig"
+	Manager_GetManagerConfig_FullMethodName          = "/telepresence.manager.Manager/GetManagerConfig"
 	Manager_GetTelepresenceAPI_FullMethodName        = "/telepresence.manager.Manager/GetTelepresenceAPI"
 	Manager_ArriveAsClient_FullMethodName            = "/telepresence.manager.Manager/ArriveAsClient"
 	Manager_ArriveAsAgent_FullMethodName             = "/telepresence.manager.Manager/ArriveAsAgent"
 	Manager_Remain_FullMethodName                    = "/telepresence.manager.Manager/Remain"
 	Manager_Depart_FullMethodName                    = "/telepresence.manager.Manager/Depart"
+	Manager_ListClientSessions_FullMethodName        = "/telepresence.manager.Manager/ListClientSessions"
+	Manager_EvictClientSession_FullMethodName        = "/telepresence.manager.Manager/EvictClientSession"
 	Manager_SetLogLevel_FullMethodName               = "/telepresence.manager.Manager/SetLogLevel"
 	Manager_GetLogs_FullMethodName                   = "/telepresence.manager.Manager/GetLogs"
 	Manager_WatchAgentPods_FullMethodName            = "/telepresence.manager.Manager/WatchAgentPods"
 	Manager_WatchAgents_FullMethodName               = "/telepresence.manager.Manager/WatchAgents"
 	Manager_WatchAgentsNS_FullMethodName             = "/telepresence.manager.Manager/WatchAgentsNS"
 	Manager_WatchIntercepts_FullMethodName           = "/telepresence.manager.Manager/WatchIntercepts"
+	Manager_WatchInterceptEvents_FullMethodName      = "/telepresence.manager.Manager/WatchInterceptEvents"
+	Manager_GetDeclaredIntercept_FullMethodName      = "/telepresence.manager.Manager/GetDeclaredIntercept"
 	Manager_WatchWorkloads_FullMethodName            = "/telepresence.manager.Manager/WatchWorkloads"
+	Manager_ListWorkloads_FullMethodName             = "/telepresence.manager.Manager/ListWorkloads"
+	Manager_GetAgentConfigGCReport_FullMethodName    = "/telepresence.manager.Manager/GetAgentConfigGCReport"
+	Manager_GetConnectionStats_FullMethodName        = "/telepresence.manager.Manager/GetConnectionStats"
+	Manager_QUICEndpoint_FullMethodName              = "/telepresence.manager.Manager/QUICEndpoint"
+	Manager_WireGuardPeer_FullMethodName             = "/telepresence.manager.Manager/WireGuardPeer"
 	Manager_WatchClusterInfo_FullMethodName          = "/telepresence.manager.Manager/WatchClusterInfo"
 	Manager_EnsureAgent_FullMethodName               = "/telepresence.manager.Manager/EnsureAgent"
 	Manager_PrepareIntercept_FullMethodName          = "/telepresence.manager.Manager/PrepareIntercept"
@@ -55,6 +65,7 @@ const (
 	Manager_WatchLookupDNS_FullMethodName            = "/telepresence.manager.Manager/WatchLookupDNS"
 	Manager_WatchLogLevel_FullMethodName             = "/telepresence.manager.Manager/WatchLogLevel"
 	Manager_Tunnel_FullMethodName                    = "/telepresence.manager.Manager/Tunnel"
+	Manager_MuxTunnel_FullMethodName                 = "/telepresence.manager.Manager/MuxTunnel"
 	Manager_ReportMetrics_FullMethodName             = "/telepresence.manager.Manager/ReportMetrics"
 	Manager_WatchDial_FullMethodName                 = "/telepresence.manager.Manager/WatchDial"
 )
@@ -78,6 +89,9 @@ type ManagerClient interface {
 	GetCloudConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AmbassadorCloudConfig, error)
 	// GetClientConfig returns the config that connected clients should use for this manager.
 	GetClientConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CLIConfig, error)
+	// GetManagerConfig returns the active settings of the traffic-manager that
+	// are reloaded when the traffic-manager ConfigMap changes.
+	GetManagerConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ManagerConfig, error)
 	// GetTelepresenceAPI returns information about the TelepresenceAPI server
 	GetTelepresenceAPI(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TelepresenceAPIInfo, error)
 	// ArriveAsClient establishes a session between a client and the Manager.
@@ -89,6 +103,12 @@ type ManagerClient interface {
 	Remain(ctx context.Context, in *RemainRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Depart terminates a session.
 	Depart(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListClientSessions returns the client sessions that are connected to
+	// the traffic-manager to the client of the given session.
+	ListClientSessions(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*ClientSessionList, error)
+	// EvictClientSession forcibly terminates a client session and removes
+	// its intercepts. The evicted client will not refresh its session.
+	EvictClientSession(ctx context.Context, in *EvictClientSessionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetLogLevel will temporarily set the log-level for the traffic-manager and all
 	// traffic-agents for a duration that is determined b the request.
 	SetLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
@@ -114,9 +134,33 @@ type ManagerClient interface {
 	// that session are watched.  If no session ID is given, then all
 	// intercepts are watched.
 	WatchIntercepts(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchInterceptsClient, error)
+	// WatchInterceptEvents streams an event for each intercept that is added,
+	// updated, or removed, limited to the intercepts selected by the request.
+	// The stream starts with an ADDED event for each existing intercept.
+	WatchInterceptEvents(ctx context.Context, in *InterceptEventsRequest, opts ...grpc.CallOption) (Manager_WatchInterceptEventsClient, error)
+	// GetDeclaredIntercept returns the intercept declared by an InterceptSpec
+	// resource, provided that the client is allowed to adopt it.
+	GetDeclaredIntercept(ctx context.Context, in *GetDeclaredInterceptRequest, opts ...grpc.CallOption) (*DeclaredIntercept, error)
 	// WatchWorkloads notifies a client of the set of Workloads from the client
 	// connection's namespace.
 	WatchWorkloads(ctx context.Context, in *WorkloadEventsRequest, opts ...grpc.CallOption) (Manager_WatchWorkloadsClient, error)
+	// ListWorkloads returns one page of the workloads in the given namespaces. The workloads
+	// are served from the traffic-manager's informer caches.
+	ListWorkloads(ctx context.Context, in *ListWorkloadsRequest, opts ...grpc.CallOption) (*ListWorkloadsResponse, error)
+	// GetAgentConfigGCReport returns a report of the orphaned telepresence-agents
+	// ConfigMap entries that the traffic-manager has removed.
+	GetAgentConfigGCReport(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AgentConfigGCReport, error)
+	// GetConnectionStats returns the statistics of the active tunneled
+	// connections of the given client session.
+	GetConnectionStats(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*ConnectionStatsResponse, error)
+	// QUICEndpoint returns the QUIC endpoint of the traffic-manager, on which
+	// the client of the given session can open tunnels instead of using the
+	// Tunnel call. Unimplemented is returned when QUIC isn't enabled.
+	QUICEndpoint(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*QUICEndpointResponse, error)
+	// WireGuardPeer adds the client of the given session as a peer of the
+	// traffic-manager's WireGuard endpoint. The peer is removed when the
+	// session ends. Unimplemented is returned when WireGuard isn't enabled.
+	WireGuardPeer(ctx context.Context, in *WireGuardPeerRequest, opts ...grpc.CallOption) (*WireGuardPeerResponse, error)
 	// WatchClusterInfo returns information needed when establishing
 	// connectivity to the cluster.
 	WatchClusterInfo(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchClusterInfoClient, error)
@@ -156,6 +200,10 @@ type ManagerClient interface {
 	// always contain the session ID, connection ID, and timeouts used by
 	// the dialer endpoints.
 	Tunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_TunnelClient, error)
+	// MuxTunnel carries many tunnels over one stream. Each tunnel behaves
+	// like a Tunnel, and has its own flow control, so that a slow tunnel
+	// doesn't block the others.
+	MuxTunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_MuxTunnelClient, error)
 	// ReportMetrics is used by a traffic-agent to report metrics for streams
 	// established when clients connect directly to traffic-agents using port-forward.
 	ReportMetrics(ctx context.Context, in *TunnelMetrics, opts ...grpc.CallOption) (*emptypb.Empty, error)
@@ -238,6 +286,16 @@ func (c *managerClient) GetClientConfig(ctx context.Context, in *emptypb.Empty,
 	return out, nil
 }
 
+func (c *managerClient) GetManagerConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ManagerConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ManagerConfig)
+	err := c.cc.Invoke(ctx, Manager_GetManagerConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) GetTelepresenceAPI(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TelepresenceAPIInfo, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TelepresenceAPIInfo)
@@ -288,6 +346,26 @@ func (c *managerClient) Depart(ctx context.Context, in *SessionInfo, opts ...grp
 	return out, nil
 }
 
+func (c *managerClient) ListClientSessions(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*ClientSessionList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClientSessionList)
+	err := c.cc.Invoke(ctx, Manager_ListClientSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) EvictClientSession(ctx context.Context, in *EvictClientSessionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_EvictClientSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) SetLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -440,9 +518,52 @@ func (x *managerWatchInterceptsClient) Recv() (*InterceptInfoSnapshot, error) {
 	return m, nil
 }
 
+func (c *managerClient) WatchInterceptEvents(ctx context.Context, in *InterceptEventsRequest, opts ...grpc.CallOption) (Manager_WatchInterceptEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[4], Manager_WatchInterceptEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchInterceptEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchInterceptEventsClient interface {
+	Recv() (*InterceptEvent, error)
+	grpc.ClientStream
+}
+
+type managerWatchInterceptEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchInterceptEventsClient) Recv() (*InterceptEvent, error) {
+	m := new(InterceptEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) GetDeclaredIntercept(ctx context.Context, in *GetDeclaredInterceptRequest, opts ...grpc.CallOption) (*DeclaredIntercept, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeclaredIntercept)
+	err := c.cc.Invoke(ctx, Manager_GetDeclaredIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) WatchWorkloads(ctx context.Context, in *WorkloadEventsRequest, opts ...grpc.CallOption) (Manager_WatchWorkloadsClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[4], Manager_WatchWorkloads_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[5], Manager_WatchWorkloads_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -473,9 +594,59 @@ func (x *managerWatchWorkloadsClient) Recv() (*WorkloadEventsDelta, error) {
 	return m, nil
 }
 
+func (c *managerClient) ListWorkloads(ctx context.Context, in *ListWorkloadsRequest, opts ...grpc.CallOption) (*ListWorkloadsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorkloadsResponse)
+	err := c.cc.Invoke(ctx, Manager_ListWorkloads_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) GetAgentConfigGCReport(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AgentConfigGCReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AgentConfigGCReport)
+	err := c.cc.Invoke(ctx, Manager_GetAgentConfigGCReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) GetConnectionStats(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*ConnectionStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectionStatsResponse)
+	err := c.cc.Invoke(ctx, Manager_GetConnectionStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) QUICEndpoint(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*QUICEndpointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QUICEndpointResponse)
+	err := c.cc.Invoke(ctx, Manager_QUICEndpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) WireGuardPeer(ctx context.Context, in *WireGuardPeerRequest, opts ...grpc.CallOption) (*WireGuardPeerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WireGuardPeerResponse)
+	err := c.cc.Invoke(ctx, Manager_WireGuardPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) WatchClusterInfo(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchClusterInfoClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[5], Manager_WatchClusterInfo_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], Manager_WatchClusterInfo_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -598,7 +769,7 @@ func (c *managerClient) AgentLookupDNSResponse(ctx context.Context, in *DNSAgent
 
 func (c *managerClient) WatchLookupDNS(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchLookupDNSClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], Manager_WatchLookupDNS_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], Manager_WatchLookupDNS_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -631,7 +802,7 @@ func (x *managerWatchLookupDNSClient) Recv() (*DNSRequest, error) {
 
 func (c *managerClient) WatchLogLevel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Manager_WatchLogLevelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], Manager_WatchLogLevel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], Manager_WatchLogLevel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -664,7 +835,7 @@ func (x *managerWatchLogLevelClient) Recv() (*LogLevelRequest, error) {
 
 func (c *managerClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_TunnelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], Manager_Tunnel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], Manager_Tunnel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -694,6 +865,38 @@ func (x *managerTunnelClient) Recv() (*TunnelMessage, error) {
 	return m, nil
 }
 
+func (c *managerClient) MuxTunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_MuxTunnelClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[10], Manager_MuxTunnel_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerMuxTunnelClient{ClientStream: stream}
+	return x, nil
+}
+
+type Manager_MuxTunnelClient interface {
+	Send(*MuxTunnelMessage) error
+	Recv() (*MuxTunnelMessage, error)
+	grpc.ClientStream
+}
+
+type managerMuxTunnelClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerMuxTunnelClient) Send(m *MuxTunnelMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *managerMuxTunnelClient) Recv() (*MuxTunnelMessage, error) {
+	m := new(MuxTunnelMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *managerClient) ReportMetrics(ctx context.Context, in *TunnelMetrics, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -706,7 +909,7 @@ func (c *managerClient) ReportMetrics(ctx context.Context, in *TunnelMetrics, op
 
 func (c *managerClient) WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], Manager_WatchDial_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[11], Manager_WatchDial_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -756,6 +959,9 @@ type ManagerServer interface {
 	GetCloudConfig(context.Context, *emptypb.Empty) (*AmbassadorCloudConfig, error)
 	// GetClientConfig returns the config that connected clients should use for this manager.
 	GetClientConfig(context.Context, *emptypb.Empty) (*CLIConfig, error)
+	// GetManagerConfig returns the active settings of the traffic-manager that
+	// are reloaded when the traffic-manager ConfigMap changes.
+	GetManagerConfig(context.Context, *emptypb.Empty) (*ManagerConfig, error)
 	// GetTelepresenceAPI returns information about the TelepresenceAPI server
 	GetTelepresenceAPI(context.Context, *emptypb.Empty) (*TelepresenceAPIInfo, error)
 	// ArriveAsClient establishes a session between a client and the Manager.
@@ -767,6 +973,12 @@ type ManagerServer interface {
 	Remain(context.Context, *RemainRequest) (*emptypb.Empty, error)
 	// Depart terminates a session.
 	Depart(context.Context, *SessionInfo) (*emptypb.Empty, error)
+	// ListClientSessions returns the client sessions that are connected to
+	// the traffic-manager to the client of the given session.
+	ListClientSessions(context.Context, *SessionInfo) (*ClientSessionList, error)
+	// EvictClientSession forcibly terminates a client session and removes
+	// its intercepts. The evicted client will not refresh its session.
+	EvictClientSession(context.Context, *EvictClientSessionRequest) (*emptypb.Empty, error)
 	// SetLogLevel will temporarily set the log-level for the traffic-manager and all
 	// traffic-agents for a duration that is determined b the request.
 	SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error)
@@ -792,9 +1004,33 @@ type ManagerServer interface {
 	// that session are watched.  If no session ID is given, then all
 	// intercepts are watched.
 	WatchIntercepts(*SessionInfo, Manager_WatchInterceptsServer) error
+	// WatchInterceptEvents streams an event for each intercept that is added,
+	// updated, or removed, limited to the intercepts selected by the request.
+	// The stream starts with an ADDED event for each existing intercept.
+	WatchInterceptEvents(*InterceptEventsRequest, Manager_WatchInterceptEventsServer) error
+	// GetDeclaredIntercept returns the intercept declared by an InterceptSpec
+	// resource, provided that the client is allowed to adopt it.
+	GetDeclaredIntercept(context.Context, *GetDeclaredInterceptRequest) (*DeclaredIntercept, error)
 	// WatchWorkloads notifies a client of the set of Workloads from the client
 	// connection's namespace.
 	WatchWorkloads(*WorkloadEventsRequest, Manager_WatchWorkloadsServer) error
+	// ListWorkloads returns one page of the workloads in the given namespaces. The workloads
+	// are served from the traffic-manager's informer caches.
+	ListWorkloads(context.Context, *ListWorkloadsRequest) (*ListWorkloadsResponse, error)
+	// GetAgentConfigGCReport returns a report of the orphaned telepresence-agents
+	// ConfigMap entries that the traffic-manager has removed.
+	GetAgentConfigGCReport(context.Context, *emptypb.Empty) (*AgentConfigGCReport, error)
+	// GetConnectionStats returns the statistics of the active tunneled
+	// connections of the given client session.
+	GetConnectionStats(context.Context, *SessionInfo) (*ConnectionStatsResponse, error)
+	// QUICEndpoint returns the QUIC endpoint of the traffic-manager, on which
+	// the client of the given session can open tunnels instead of using the
+	// Tunnel call. Unimplemented is returned when QUIC isn't enabled.
+	QUICEndpoint(context.Context, *SessionInfo) (*QUICEndpointResponse, error)
+	// WireGuardPeer adds the client of the given session as a peer of the
+	// traffic-manager's WireGuard endpoint. The peer is removed when the
+	// session ends. Unimplemented is returned when WireGuard isn't enabled.
+	WireGuardPeer(context.Context, *WireGuardPeerRequest) (*WireGuardPeerResponse, error)
 	// WatchClusterInfo returns information needed when establishing
 	// connectivity to the cluster.
 	WatchClusterInfo(*SessionInfo, Manager_WatchClusterInfoServer) error
@@ -834,6 +1070,10 @@ type ManagerServer interface {
 	// always contain the session ID, connection ID, and timeouts used by
 	// the dialer endpoints.
 	Tunnel(Manager_TunnelServer) error
+	// MuxTunnel carries many tunnels over one stream. Each tunnel behaves
+	// like a Tunnel, and has its own flow control, so that a slow tunnel
+	// doesn't block the others.
+	MuxTunnel(Manager_MuxTunnelServer) error
 	// ReportMetrics is used by a traffic-agent to report metrics for streams
 	// established when clients connect directly to traffic-agents using port-forward.
 	ReportMetrics(context.Context, *TunnelMetrics) (*emptypb.Empty, error)
@@ -871,6 +1111,9 @@ func (UnimplementedManagerServer) GetCloudConfig(context.Context, *emptypb.Empty
 func (UnimplementedManagerServer) GetClientConfig(context.Context, *emptypb.Empty) (*CLIConfig, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetClientConfig not implemented")
 }
+func (UnimplementedManagerServer) GetManagerConfig(context.Context, *emptypb.Empty) (*ManagerConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetManagerConfig not implemented")
+}
 func (UnimplementedManagerServer) GetTelepresenceAPI(context.Context, *emptypb.Empty) (*TelepresenceAPIInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTelepresenceAPI not implemented")
 }
@@ -886,6 +1129,12 @@ func (UnimplementedManagerServer) Remain(context.Context, *RemainRequest) (*empt
 func (UnimplementedManagerServer) Depart(context.Context, *SessionInfo) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Depart not implemented")
 }
+func (UnimplementedManagerServer) ListClientSessions(context.Context, *SessionInfo) (*ClientSessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClientSessions not implemented")
+}
+func (UnimplementedManagerServer) EvictClientSession(context.Context, *EvictClientSessionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvictClientSession not implemented")
+}
 func (UnimplementedManagerServer) SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
 }
@@ -904,9 +1153,30 @@ func (UnimplementedManagerServer) WatchAgentsNS(*AgentsRequest, Manager_WatchAge
 func (UnimplementedManagerServer) WatchIntercepts(*SessionInfo, Manager_WatchInterceptsServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchIntercepts not implemented")
 }
+func (UnimplementedManagerServer) WatchInterceptEvents(*InterceptEventsRequest, Manager_WatchInterceptEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInterceptEvents not implemented")
+}
+func (UnimplementedManagerServer) GetDeclaredIntercept(context.Context, *GetDeclaredInterceptRequest) (*DeclaredIntercept, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeclaredIntercept not implemented")
+}
 func (UnimplementedManagerServer) WatchWorkloads(*WorkloadEventsRequest, Manager_WatchWorkloadsServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchWorkloads not implemented")
 }
+func (UnimplementedManagerServer) ListWorkloads(context.Context, *ListWorkloadsRequest) (*ListWorkloadsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkloads not implemented")
+}
+func (UnimplementedManagerServer) GetAgentConfigGCReport(context.Context, *emptypb.Empty) (*AgentConfigGCReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAgentConfigGCReport not implemented")
+}
+func (UnimplementedManagerServer) GetConnectionStats(context.Context, *SessionInfo) (*ConnectionStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnectionStats not implemented")
+}
+func (UnimplementedManagerServer) QUICEndpoint(context.Context, *SessionInfo) (*QUICEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QUICEndpoint not implemented")
+}
+func (UnimplementedManagerServer) WireGuardPeer(context.Context, *WireGuardPeerRequest) (*WireGuardPeerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WireGuardPeer not implemented")
+}
 func (UnimplementedManagerServer) WatchClusterInfo(*SessionInfo, Manager_WatchClusterInfoServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchClusterInfo not implemented")
 }
@@ -946,6 +1216,9 @@ func (UnimplementedManagerServer) WatchLogLevel(*emptypb.Empty, Manager_WatchLog
 func (UnimplementedManagerServer) Tunnel(Manager_TunnelServer) error {
 	return status.Errorf(codes.Unimplemented, "method Tunnel not implemented")
 }
+func (UnimplementedManagerServer) MuxTunnel(Manager_MuxTunnelServer) error {
+	return status.Errorf(codes.Unimplemented, "method MuxTunnel not implemented")
+}
 func (UnimplementedManagerServer) ReportMetrics(context.Context, *TunnelMetrics) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReportMetrics not implemented")
 }
@@ -1073,6 +1346,24 @@ func _Manager_GetClientConfig_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_GetManagerConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetManagerConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetManagerConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetManagerConfig(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_GetTelepresenceAPI_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -1163,6 +1454,42 @@ func _Manager_Depart_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_ListClientSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ListClientSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_ListClientSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ListClientSessions(ctx, req.(*SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_EvictClientSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvictClientSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).EvictClientSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_EvictClientSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).EvictClientSession(ctx, req.(*EvictClientSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogLevelRequest)
 	if err := dec(in); err != nil {
@@ -1283,6 +1610,45 @@ func (x *managerWatchInterceptsServer) Send(m *InterceptInfoSnapshot) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_WatchInterceptEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InterceptEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchInterceptEvents(m, &managerWatchInterceptEventsServer{ServerStream: stream})
+}
+
+type Manager_WatchInterceptEventsServer interface {
+	Send(*InterceptEvent) error
+	grpc.ServerStream
+}
+
+type managerWatchInterceptEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchInterceptEventsServer) Send(m *InterceptEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_GetDeclaredIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeclaredInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetDeclaredIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetDeclaredIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetDeclaredIntercept(ctx, req.(*GetDeclaredInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_WatchWorkloads_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(WorkloadEventsRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -1304,6 +1670,96 @@ func (x *managerWatchWorkloadsServer) Send(m *WorkloadEventsDelta) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_ListWorkloads_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkloadsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ListWorkloads(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_ListWorkloads_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ListWorkloads(ctx, req.(*ListWorkloadsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_GetAgentConfigGCReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetAgentConfigGCReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetAgentConfigGCReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetAgentConfigGCReport(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_GetConnectionStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetConnectionStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetConnectionStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetConnectionStats(ctx, req.(*SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_QUICEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).QUICEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_QUICEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).QUICEndpoint(ctx, req.(*SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_WireGuardPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WireGuardPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).WireGuardPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_WireGuardPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).WireGuardPeer(ctx, req.(*WireGuardPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_WatchClusterInfo_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(SessionInfo)
 	if err := stream.RecvMsg(m); err != nil {
@@ -1555,6 +2011,32 @@ func (x *managerTunnelServer) Recv() (*TunnelMessage, error) {
 	return m, nil
 }
 
+func _Manager_MuxTunnel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ManagerServer).MuxTunnel(&managerMuxTunnelServer{ServerStream: stream})
+}
+
+type Manager_MuxTunnelServer interface {
+	Send(*MuxTunnelMessage) error
+	Recv() (*MuxTunnelMessage, error)
+	grpc.ServerStream
+}
+
+type managerMuxTunnelServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerMuxTunnelServer) Send(m *MuxTunnelMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *managerMuxTunnelServer) Recv() (*MuxTunnelMessage, error) {
+	m := new(MuxTunnelMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Manager_ReportMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(TunnelMetrics)
 	if err := dec(in); err != nil {
@@ -1625,6 +2107,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetClientConfig",
 			Handler:    _Manager_GetClientConfig_Handler,
 		},
+		{
+			MethodName: "GetManagerConfig",
+			Handler:    _Manager_GetManagerConfig_Handler,
+		},
 		{
 			MethodName: "GetTelepresenceAPI",
 			Handler:    _Manager_GetTelepresenceAPI_Handler,
@@ -1645,6 +2131,14 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Depart",
 			Handler:    _Manager_Depart_Handler,
 		},
+		{
+			MethodName: "ListClientSessions",
+			Handler:    _Manager_ListClientSessions_Handler,
+		},
+		{
+			MethodName: "EvictClientSession",
+			Handler:    _Manager_EvictClientSession_Handler,
+		},
 		{
 			MethodName: "SetLogLevel",
 			Handler:    _Manager_SetLogLevel_Handler,
@@ -1653,6 +2147,30 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetLogs",
 			Handler:    _Manager_GetLogs_Handler,
 		},
+		{
+			MethodName: "GetDeclaredIntercept",
+			Handler:    _Manager_GetDeclaredIntercept_Handler,
+		},
+		{
+			MethodName: "ListWorkloads",
+			Handler:    _Manager_ListWorkloads_Handler,
+		},
+		{
+			MethodName: "GetAgentConfigGCReport",
+			Handler:    _Manager_GetAgentConfigGCReport_Handler,
+		},
+		{
+			MethodName: "GetConnectionStats",
+			Handler:    _Manager_GetConnectionStats_Handler,
+		},
+		{
+			MethodName: "QUICEndpoint",
+			Handler:    _Manager_QUICEndpoint_Handler,
+		},
+		{
+			MethodName: "WireGuardPeer",
+			Handler:    _Manager_WireGuardPeer_Handler,
+		},
 		{
 			MethodName: "EnsureAgent",
 			Handler:    _Manager_EnsureAgent_Handler,
@@ -1715,6 +2233,11 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Manager_WatchIntercepts_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchInterceptEvents",
+			Handler:       _Manager_WatchInterceptEvents_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "WatchWorkloads",
 			Handler:       _Manager_WatchWorkloads_Handler,
@@ -1741,6 +2264,12 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "MuxTunnel",
+			Handler:       _Manager_MuxTunnel_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "WatchDial",
 			Handler:       _Manager_WatchDial_Handler,